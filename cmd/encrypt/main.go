@@ -0,0 +1,170 @@
+// Package main provides a CLI tool for managing the encryption of gift card
+// PINs, card numbers and voucher codes: key generation, re-encryption after a
+// key rotation and cleanup of old audit log snapshots.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+
+	"savvy/internal/config"
+	"savvy/internal/database"
+	"savvy/internal/encryption"
+	"savvy/internal/services"
+	"savvy/internal/setup"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		printUsage()
+		os.Exit(1)
+	}
+
+	command := os.Args[1]
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	dryRun := flags.Bool("dry-run", false, "only count the rows that would change")
+	if err := flags.Parse(os.Args[2:]); err != nil {
+		os.Exit(1)
+	}
+
+	switch command {
+	case "generate-key":
+		generateKey()
+	case "status":
+		status()
+	case "rotate":
+		rotate(*dryRun)
+	case "redact-audit-log":
+		redactAuditLog(*dryRun)
+	case "help", "--help", "-h":
+		printUsage()
+	default:
+		fmt.Printf("❌ Unknown command: %s\n\n", command)
+		printUsage()
+		os.Exit(1)
+	}
+}
+
+func generateKey() {
+	key, err := encryption.GenerateKey()
+	if err != nil {
+		log.Fatalf("❌ Failed to generate key: %v", err)
+	}
+	fmt.Println(key)
+}
+
+// connect loads the configuration and keyring and opens the database
+func connect() *services.Container {
+	cfg := config.Load()
+
+	if err := setup.InitEncryption(cfg); err != nil {
+		log.Fatalf("❌ Invalid ENCRYPTION_KEYS: %v", err)
+	}
+
+	if err := database.Connect(cfg.DatabaseURL); err != nil {
+		log.Fatalf("❌ Database connection failed: %v", err)
+	}
+
+	return services.NewContainer(database.DB)
+}
+
+func status() {
+	container := connect()
+
+	if keyring := encryption.Current(); keyring != nil {
+		fmt.Printf("🔐 Active key: %s (identifiers encrypted: %t)\n\n", keyring.ActiveKeyID(), keyring.EncryptIdentifiers())
+	} else {
+		fmt.Print("⚠️  Encryption disabled (ENCRYPTION_KEYS not set)\n\n")
+	}
+
+	statuses, err := container.EncryptionService.Status(context.Background())
+	if err != nil {
+		log.Fatalf("❌ Failed to read status: %v", err)
+	}
+
+	for _, s := range statuses {
+		fmt.Printf("%-24s plaintext: %d", s.Table+"."+s.Column, s.Plaintext)
+		keyIDs := make([]string, 0, len(s.ByKey))
+		for keyID := range s.ByKey {
+			keyIDs = append(keyIDs, keyID)
+		}
+		sort.Strings(keyIDs)
+		for _, keyID := range keyIDs {
+			fmt.Printf("  %s: %d", keyID, s.ByKey[keyID])
+		}
+		fmt.Println()
+	}
+}
+
+func rotate(dryRun bool) {
+	container := connect()
+
+	keyring := encryption.Current()
+	if keyring == nil {
+		log.Fatal("❌ ENCRYPTION_KEYS must be set to re-encrypt")
+	}
+
+	fmt.Printf("🔄 Re-encrypting with key %s...\n", keyring.ActiveKeyID())
+	results, err := container.EncryptionService.Reencrypt(context.Background(), keyring, dryRun)
+	for _, r := range results {
+		fmt.Printf("   %-24s %d of %d rows\n", r.Table+"."+r.Column, r.Updated, r.Scanned)
+	}
+	if err != nil {
+		log.Fatalf("❌ Re-encryption failed: %v", err)
+	}
+
+	if dryRun {
+		fmt.Println("\n🔍 Dry run - nothing was changed. Re-run without --dry-run to re-encrypt.")
+		return
+	}
+	fmt.Println("✅ All values use the active key. Older keys can be removed from ENCRYPTION_KEYS.")
+}
+
+func redactAuditLog(dryRun bool) {
+	container := connect()
+
+	fmt.Println("🧹 Redacting audit log snapshots...")
+	updated, err := container.EncryptionService.RedactAuditLogs(context.Background(), dryRun)
+	if err != nil {
+		log.Fatalf("❌ Redaction failed after %d entries: %v", updated, err)
+	}
+
+	if dryRun {
+		fmt.Printf("🔍 Dry run - %d entries contain PINs or full numbers.\n", updated)
+		return
+	}
+	fmt.Printf("✅ Redacted %d entries\n", updated)
+}
+
+func printUsage() {
+	fmt.Print(`
+Savvy System - Encryption Tool
+
+USAGE:
+    go run cmd/encrypt/main.go [COMMAND] [OPTIONS]
+
+COMMANDS:
+    generate-key        Print a new random key for ENCRYPTION_KEYS
+    status              Count plaintext and encrypted values per key
+    rotate              Re-encrypt all values with the active (first) key
+    redact-audit-log    Remove PINs and mask numbers in existing audit log entries
+    help                Show this help message
+
+OPTIONS:
+    --dry-run           Only count the rows that would change (rotate, redact-audit-log)
+
+KEY ROTATION:
+    1. go run cmd/encrypt/main.go generate-key
+    2. Prepend the new key: ENCRYPTION_KEYS=2026-10:NEWKEY,2025-01:OLDKEY
+    3. Restart the app, then run: go run cmd/encrypt/main.go rotate
+    4. Remove the old key once status shows no values left for it
+
+MAKEFILE SHORTCUTS:
+    make encrypt-status
+    make encrypt-rotate [DRY_RUN=1]
+`)
+}
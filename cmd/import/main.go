@@ -0,0 +1,192 @@
+// Package main provides a CLI tool for importing loyalty cards from other apps.
+// Without --commit it only prints a dry-run preview of what would be imported.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"savvy/internal/config"
+	"savvy/internal/database"
+	"savvy/internal/importer"
+	"savvy/internal/services"
+	"savvy/internal/setup"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		printUsage()
+		os.Exit(1)
+	}
+
+	command := os.Args[1]
+	if command == "help" || command == "--help" || command == "-h" {
+		printUsage()
+		return
+	}
+
+	format, err := importer.ParseFormat(command)
+	if err != nil {
+		fmt.Printf("❌ Unknown command: %s\n\n", command)
+		printUsage()
+		os.Exit(1)
+	}
+
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	email := flags.String("user", "", "email of the user the cards are imported for (required)")
+	commit := flags.Bool("commit", false, "create the cards instead of printing a dry-run preview")
+	var mapping importer.Mapping
+	flags.StringVar(&mapping.Merchant, "merchant-column", "", "CSV column holding the merchant name")
+	flags.StringVar(&mapping.Program, "program-column", "", "CSV column holding the program name")
+	flags.StringVar(&mapping.CardNumber, "number-column", "", "CSV column holding the card number")
+	flags.StringVar(&mapping.BarcodeType, "barcode-column", "", "CSV column holding the barcode type")
+	flags.StringVar(&mapping.Notes, "notes-column", "", "CSV column holding notes")
+	flags.StringVar(&mapping.Status, "status-column", "", "CSV column holding the status")
+
+	// Accept "FILE --user ..." as well as "--user ... FILE"
+	args := os.Args[2:]
+	var file string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		file, args = args[0], args[1:]
+	}
+	if err := flags.Parse(args); err != nil {
+		os.Exit(1)
+	}
+	if file == "" {
+		file = flags.Arg(0)
+	}
+	if file == "" || *email == "" {
+		fmt.Println("❌ Error: FILE and --user are required")
+		fmt.Printf("Usage: go run cmd/import/main.go %s FILE --user EMAIL [--commit]\n", command)
+		os.Exit(1)
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		log.Fatalf("❌ Failed to read %s: %v", file, err)
+	}
+
+	records, err := importer.Parse(format, data, mapping)
+	if err != nil {
+		log.Fatalf("❌ Failed to parse %s: %v", file, err)
+	}
+
+	// Load configuration
+	cfg := config.Load()
+
+	// Encrypt PINs like the server does
+	if err := setup.InitEncryption(cfg); err != nil {
+		log.Fatalf("❌ Invalid ENCRYPTION_KEYS: %v", err)
+	}
+
+	// Connect to database
+	if err := database.Connect(cfg.DatabaseURL); err != nil {
+		log.Fatalf("❌ Database connection failed: %v", err)
+	}
+
+	ctx := context.Background()
+	container := services.NewContainer(database.DB)
+
+	user, err := container.UserService.GetUserByEmail(ctx, *email)
+	if err != nil {
+		log.Fatalf("❌ User not found: %s", *email)
+	}
+
+	preview, err := container.ImportService.PreviewCards(ctx, records)
+	if err != nil {
+		log.Fatalf("❌ Preview failed: %v", err)
+	}
+	printPreview(preview)
+
+	if !*commit {
+		fmt.Println("\n🔍 Dry run - nothing was imported. Re-run with --commit to create the cards.")
+		return
+	}
+
+	fmt.Printf("\n📥 Importing %d cards for %s...\n", preview.ValidCount(), user.Email)
+	result, err := container.ImportService.ImportCards(ctx, user.ID, preview)
+	if err != nil {
+		log.Fatalf("❌ Import failed after %d cards: %v", result.Created, err)
+	}
+
+	fmt.Printf("✅ Imported %d cards\n", result.Created)
+	if result.Invalid > 0 {
+		fmt.Printf("⚠️  Skipped %d rows with errors\n", result.Invalid)
+	}
+	for _, line := range result.Duplicates {
+		fmt.Printf("⚠️  Line %d: card number already exists\n", line)
+	}
+}
+
+// printPreview lists every record with its matched merchant and validation errors
+func printPreview(preview *services.CardImportPreview) {
+	fmt.Printf("📋 %d of %d cards can be imported\n\n", preview.ValidCount(), len(preview.Rows))
+
+	for _, row := range preview.Rows {
+		marker := "✅"
+		if !row.Valid() {
+			marker = "❌"
+		}
+
+		merchant := row.Request.MerchantName + " (new)"
+		if row.Merchant != nil {
+			merchant = row.Merchant.Name + " (matched)"
+		}
+
+		barcode := row.Request.BarcodeType
+		if !row.BarcodeExact {
+			barcode = row.Record.BarcodeType + " → " + barcode
+		}
+
+		fmt.Printf("%s line %-4d %-30s %-20s %-20s %s\n", marker, row.Record.Line, merchant, row.Request.Program, row.Request.CardNumber, barcode)
+
+		fields := make([]string, 0, len(row.Errors))
+		for field := range row.Errors {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+		for _, field := range fields {
+			fmt.Printf("      %s: %s\n", field, row.Errors[field])
+		}
+	}
+}
+
+func printUsage() {
+	fmt.Print(`
+Savvy System - Card Import Tool
+
+USAGE:
+    go run cmd/import/main.go [FORMAT] FILE --user EMAIL [OPTIONS]
+
+FORMATS:
+    catima          Catima export (ZIP archive or catima.csv)
+    stocard         Stocard data export (ZIP archive)
+    csv             Generic CSV file with a header row
+    help            Show this help message
+
+OPTIONS:
+    --user EMAIL            User the cards are imported for (required)
+    --commit                Create the cards (default: dry-run preview only)
+    --merchant-column NAME  CSV column of the merchant name
+    --program-column NAME   CSV column of the program name
+    --number-column NAME    CSV column of the card number
+    --barcode-column NAME   CSV column of the barcode type
+    --notes-column NAME     CSV column of the notes
+    --status-column NAME    CSV column of the status
+    Columns that are not given are detected from common header names.
+
+EXAMPLES:
+    go run cmd/import/main.go catima catima-export.zip --user anna.mueller@example.com
+    go run cmd/import/main.go stocard stocard-export.zip --user anna.mueller@example.com --commit
+    go run cmd/import/main.go csv cards.csv --user anna.mueller@example.com --number-column "Kartennr."
+
+MAKEFILE SHORTCUTS:
+    make import FORMAT=catima FILE=export.zip EMAIL=anna.mueller@example.com
+    make import FORMAT=catima FILE=export.zip EMAIL=anna.mueller@example.com COMMIT=1
+`)
+}
@@ -5,12 +5,25 @@ import (
 	"log"
 	"savvy/internal/config"
 	"savvy/internal/database"
+	"savvy/internal/encryption"
 	"savvy/internal/models"
+	"savvy/internal/money"
+	"savvy/internal/setup"
 	"time"
 
 	"golang.org/x/crypto/bcrypt"
 )
 
+// storedIdentifier returns a card number or voucher code as stored in the
+// database, encrypted if ENCRYPT_IDENTIFIERS is enabled
+func storedIdentifier(table, column, value string) string {
+	stored, err := encryption.IdentifierValue(table, column, value)
+	if err != nil {
+		log.Fatalf("❌ Failed to encrypt %s.%s: %v", table, column, err)
+	}
+	return stored
+}
+
 // createGiftCardTransactions creates transactions for a gift card and handles duplicates
 func createGiftCardTransactions(transactions []models.GiftCardTransaction) {
 	for _, t := range transactions {
@@ -19,7 +32,7 @@ func createGiftCardTransactions(transactions []models.GiftCardTransaction) {
 			log.Printf("  • Transaction already exists: %s", t.Description)
 		} else {
 			database.DB.Create(&t)
-			log.Printf("  ✓ Created transaction: %s (%.2f CHF)", t.Description, t.Amount)
+			log.Printf("  ✓ Created transaction: %s (%s)", t.Description, t.Amount)
 		}
 	}
 }
@@ -208,7 +221,7 @@ func createCards(users []models.User, merchants []models.Merchant) {
 	log.Println("Creating cards (all barcode types & statuses)...")
 	for _, card := range cards {
 		var existing models.Card
-		if err := database.DB.Where("card_number = ?", card.CardNumber).First(&existing).Error; err == nil {
+		if err := database.DB.Where("card_number = ?", storedIdentifier("cards", "card_number", card.CardNumber)).First(&existing).Error; err == nil {
 			log.Printf("  • Card already exists: %s", card.CardNumber)
 		} else {
 			if err := database.DB.Create(&card).Error; err != nil {
@@ -229,9 +242,9 @@ func createVouchers(users []models.User, merchants []models.Merchant) {
 			MerchantName:      merchants[0].Name,
 			Code:              "SUMMER2026",
 			Type:              "percentage",
-			Value:             20.0,
+			Value:             2000,
 			Description:       "20% Sommerrabatt auf alle Artikel",
-			MinPurchaseAmount: 50.0,
+			MinPurchaseAmount: money.New(5000, "CHF"),
 			ValidFrom:         time.Now().AddDate(0, 0, -7),
 			ValidUntil:        time.Now().AddDate(0, 3, 0),
 			UsageLimitType:    "multiple_use_with_card",
@@ -244,9 +257,9 @@ func createVouchers(users []models.User, merchants []models.Merchant) {
 			MerchantName:      merchants[1].Name,
 			Code:              "WELCOME50",
 			Type:              "fixed_amount",
-			Value:             50.0,
+			Value:             5000,
 			Description:       "50 CHF Willkommensbonus",
-			MinPurchaseAmount: 100.0,
+			MinPurchaseAmount: money.New(10000, "CHF"),
 			ValidFrom:         time.Now(),
 			ValidUntil:        time.Now().AddDate(0, 1, 0),
 			UsageLimitType:    "single_use",
@@ -259,9 +272,9 @@ func createVouchers(users []models.User, merchants []models.Merchant) {
 			MerchantName:      merchants[3].Name,
 			Code:              "TECH15",
 			Type:              "percentage",
-			Value:             15.0,
+			Value:             1500,
 			Description:       "15% Rabatt auf Elektronik",
-			MinPurchaseAmount: 0.0,
+			MinPurchaseAmount: money.New(0, "CHF"),
 			ValidFrom:         time.Now(),
 			ValidUntil:        time.Now().AddDate(0, 2, 0),
 			UsageLimitType:    "one_per_customer",
@@ -274,9 +287,9 @@ func createVouchers(users []models.User, merchants []models.Merchant) {
 			MerchantName:      merchants[0].Name,
 			Code:              "DOUBLE-POINTS",
 			Type:              "points_multiplier",
-			Value:             2.0,
+			Value:             200,
 			Description:       "Doppelte Cumulus-Punkte sammeln",
-			MinPurchaseAmount: 0.0,
+			MinPurchaseAmount: money.New(0, "CHF"),
 			ValidFrom:         time.Now(),
 			ValidUntil:        time.Now().AddDate(0, 1, 0),
 			UsageLimitType:    "multiple_use_without_card",
@@ -289,9 +302,9 @@ func createVouchers(users []models.User, merchants []models.Merchant) {
 			MerchantName:      merchants[2].Name,
 			Code:              "MANOR-FOREVER",
 			Type:              "fixed_amount",
-			Value:             10.0,
+			Value:             1000,
 			Description:       "10 CHF Dauerrabatt",
-			MinPurchaseAmount: 30.0,
+			MinPurchaseAmount: money.New(3000, "CHF"),
 			ValidFrom:         time.Now(),
 			ValidUntil:        time.Now().AddDate(5, 0, 0),
 			UsageLimitType:    "unlimited",
@@ -304,9 +317,9 @@ func createVouchers(users []models.User, merchants []models.Merchant) {
 			MerchantName:      merchants[1].Name,
 			Code:              "EXPIRED2025",
 			Type:              "percentage",
-			Value:             30.0,
+			Value:             3000,
 			Description:       "Abgelaufener Gutschein",
-			MinPurchaseAmount: 0.0,
+			MinPurchaseAmount: money.New(0, "CHF"),
 			ValidFrom:         time.Now().AddDate(0, -2, 0),
 			ValidUntil:        time.Now().AddDate(0, -1, 0),
 			UsageLimitType:    "single_use",
@@ -319,9 +332,9 @@ func createVouchers(users []models.User, merchants []models.Merchant) {
 			MerchantName:      merchants[2].Name,
 			Code:              "VIP2026",
 			Type:              "fixed_amount",
-			Value:             25.0,
+			Value:             2500,
 			Description:       "VIP Bonus 25 CHF",
-			MinPurchaseAmount: 75.0,
+			MinPurchaseAmount: money.New(7500, "CHF"),
 			ValidFrom:         time.Now(),
 			ValidUntil:        time.Now().AddDate(0, 6, 0),
 			UsageLimitType:    "one_per_customer",
@@ -333,9 +346,9 @@ func createVouchers(users []models.User, merchants []models.Merchant) {
 			MerchantName:      merchants[4].Name,
 			Code:              "DIGITEC-3X",
 			Type:              "points_multiplier",
-			Value:             3.0,
+			Value:             300,
 			Description:       "3x Punkte auf alle Einkäufe",
-			MinPurchaseAmount: 0.0,
+			MinPurchaseAmount: money.New(0, "CHF"),
 			ValidFrom:         time.Now(),
 			ValidUntil:        time.Now().AddDate(0, 2, 0),
 			UsageLimitType:    "multiple_use_with_card",
@@ -348,9 +361,9 @@ func createVouchers(users []models.User, merchants []models.Merchant) {
 			MerchantName:      merchants[6].Name,
 			Code:              "ID-SAVE-100",
 			Type:              "fixed_amount",
-			Value:             100.0,
+			Value:             10000,
 			Description:       "100 CHF Mega-Rabatt",
-			MinPurchaseAmount: 500.0,
+			MinPurchaseAmount: money.New(50000, "CHF"),
 			ValidFrom:         time.Now(),
 			ValidUntil:        time.Now().AddDate(0, 1, 0),
 			UsageLimitType:    "single_use",
@@ -361,7 +374,7 @@ func createVouchers(users []models.User, merchants []models.Merchant) {
 	log.Println("Creating vouchers (all types & usage limits)...")
 	for _, voucher := range vouchers {
 		var existing models.Voucher
-		if err := database.DB.Where("code = ?", voucher.Code).First(&existing).Error; err == nil {
+		if err := database.DB.Where("code = ?", storedIdentifier("vouchers", "code", voucher.Code)).First(&existing).Error; err == nil {
 			log.Printf("  • Voucher already exists: %s", voucher.Code)
 		} else {
 			if err := database.DB.Create(&voucher).Error; err != nil {
@@ -381,7 +394,7 @@ func createGiftCards(users []models.User, merchants []models.Merchant) {
 			MerchantID:     &merchants[3].ID,
 			MerchantName:   merchants[3].Name,
 			CardNumber:     "MM1234567890",
-			InitialBalance: 100.0,
+			InitialBalance: money.New(10000, "CHF"),
 			Currency:       "CHF",
 			PIN:            "1234",
 			ExpiresAt:      ptrTime(time.Now().AddDate(1, 0, 0)),
@@ -395,7 +408,7 @@ func createGiftCards(users []models.User, merchants []models.Merchant) {
 			MerchantID:     &merchants[4].ID,
 			MerchantName:   merchants[4].Name,
 			CardNumber:     "7610200000002",
-			InitialBalance: 200.0,
+			InitialBalance: money.New(20000, "CHF"),
 			Currency:       "CHF",
 			PIN:            "5678",
 			ExpiresAt:      ptrTime(time.Now().AddDate(2, 0, 0)),
@@ -409,7 +422,7 @@ func createGiftCards(users []models.User, merchants []models.Merchant) {
 			MerchantID:     &merchants[5].ID,
 			MerchantName:   merchants[5].Name,
 			CardNumber:     "GX-CARD-QR-001",
-			InitialBalance: 150.0,
+			InitialBalance: money.New(15000, "CHF"),
 			Currency:       "CHF",
 			PIN:            "",
 			ExpiresAt:      ptrTime(time.Now().AddDate(1, 6, 0)),
@@ -423,7 +436,7 @@ func createGiftCards(users []models.User, merchants []models.Merchant) {
 			MerchantID:     &merchants[2].ID,
 			MerchantName:   merchants[2].Name,
 			CardNumber:     "MANOR-EXPIRED-99",
-			InitialBalance: 50.0,
+			InitialBalance: money.New(5000, "CHF"),
 			Currency:       "CHF",
 			PIN:            "9999",
 			ExpiresAt:      ptrTime(time.Now().AddDate(0, -1, 0)),
@@ -437,7 +450,7 @@ func createGiftCards(users []models.User, merchants []models.Merchant) {
 			MerchantID:     &merchants[1].ID,
 			MerchantName:   merchants[1].Name,
 			CardNumber:     "COOP-USED-777",
-			InitialBalance: 75.0,
+			InitialBalance: money.New(7500, "CHF"),
 			Currency:       "CHF",
 			PIN:            "0000",
 			ExpiresAt:      ptrTime(time.Now().AddDate(0, 6, 0)),
@@ -451,7 +464,7 @@ func createGiftCards(users []models.User, merchants []models.Merchant) {
 			MerchantID:     &merchants[2].ID,
 			MerchantName:   merchants[2].Name,
 			CardNumber:     "MANOR-ANNA-555",
-			InitialBalance: 120.0,
+			InitialBalance: money.New(12000, "CHF"),
 			Currency:       "CHF",
 			PIN:            "1111",
 			ExpiresAt:      ptrTime(time.Now().AddDate(1, 0, 0)),
@@ -465,7 +478,7 @@ func createGiftCards(users []models.User, merchants []models.Merchant) {
 			MerchantID:     &merchants[6].ID,
 			MerchantName:   merchants[6].Name,
 			CardNumber:     "ID-THOMAS-888",
-			InitialBalance: 80.0,
+			InitialBalance: money.New(8000, "CHF"),
 			Currency:       "CHF",
 			PIN:            "2222",
 			ExpiresAt:      ptrTime(time.Now().AddDate(0, 9, 0)),
@@ -479,7 +492,7 @@ func createGiftCards(users []models.User, merchants []models.Merchant) {
 			MerchantID:     &merchants[0].ID,
 			MerchantName:   merchants[0].Name,
 			CardNumber:     "MIGROS-MARIA-333",
-			InitialBalance: 60.0,
+			InitialBalance: money.New(6000, "CHF"),
 			Currency:       "CHF",
 			PIN:            "",
 			ExpiresAt:      nil,
@@ -492,7 +505,7 @@ func createGiftCards(users []models.User, merchants []models.Merchant) {
 	log.Println("Creating gift cards (with & without transactions, different statuses)...")
 	for _, giftCard := range giftCards {
 		var existing models.GiftCard
-		if err := database.DB.Where("card_number = ?", giftCard.CardNumber).First(&existing).Error; err == nil {
+		if err := database.DB.Where("card_number = ?", storedIdentifier("gift_cards", "card_number", giftCard.CardNumber)).First(&existing).Error; err == nil {
 			log.Printf("  • Gift card already exists: %s", giftCard.CardNumber)
 		} else {
 			if err := database.DB.Create(&giftCard).Error; err != nil {
@@ -507,24 +520,25 @@ func createGiftCards(users []models.User, merchants []models.Merchant) {
 
 	// Media Markt card - multiple transactions
 	var mediaMarktGC models.GiftCard
-	database.DB.Where("card_number = ?", "MM1234567890").First(&mediaMarktGC)
+	database.DB.Where("card_number = ?", storedIdentifier("gift_cards", "card_number", "MM1234567890")).First(&mediaMarktGC)
 	if mediaMarktGC.ID.String() != "00000000-0000-0000-0000-000000000000" {
 		transactions1 := []models.GiftCardTransaction{
 			{
 				GiftCardID:      mediaMarktGC.ID,
-				Amount:          25.50,
+				Amount:          money.New(2550, "CHF"),
 				Description:     "Kopfhörer gekauft",
 				TransactionDate: time.Now().AddDate(0, 0, -5),
 			},
 			{
 				GiftCardID:      mediaMarktGC.ID,
-				Amount:          10.00,
+				Amount:          money.New(1000, "CHF"),
 				Description:     "USB Kabel",
 				TransactionDate: time.Now().AddDate(0, 0, -2),
 			},
 			{
 				GiftCardID:      mediaMarktGC.ID,
-				Amount:          50.00,
+				Kind:            models.TransactionKindReload,
+				Amount:          money.New(5000, "CHF"),
 				Description:     "Aufladung",
 				TransactionDate: time.Now().AddDate(0, 0, -1),
 			},
@@ -534,24 +548,24 @@ func createGiftCards(users []models.User, merchants []models.Merchant) {
 
 	// Coop fully used card - transactions that sum to initial balance
 	var coopUsedGC models.GiftCard
-	database.DB.Where("card_number = ?", "COOP-USED-777").First(&coopUsedGC)
+	database.DB.Where("card_number = ?", storedIdentifier("gift_cards", "card_number", "COOP-USED-777")).First(&coopUsedGC)
 	if coopUsedGC.ID.String() != "00000000-0000-0000-0000-000000000000" {
 		transactions2 := []models.GiftCardTransaction{
 			{
 				GiftCardID:      coopUsedGC.ID,
-				Amount:          30.00,
+				Amount:          money.New(3000, "CHF"),
 				Description:     "Einkauf 1",
 				TransactionDate: time.Now().AddDate(0, 0, -10),
 			},
 			{
 				GiftCardID:      coopUsedGC.ID,
-				Amount:          25.00,
+				Amount:          money.New(2500, "CHF"),
 				Description:     "Einkauf 2",
 				TransactionDate: time.Now().AddDate(0, 0, -7),
 			},
 			{
 				GiftCardID:      coopUsedGC.ID,
-				Amount:          20.00,
+				Amount:          money.New(2000, "CHF"),
 				Description:     "Einkauf 3 (letzter Rest)",
 				TransactionDate: time.Now().AddDate(0, 0, -3),
 			},
@@ -572,14 +586,14 @@ func createShares(users []models.User) {
 	database.DB.Where("merchant_name = ? AND user_id = ?", "Manor", users[0].ID).First(&manorCard)
 
 	var summerVoucher, welcomeVoucher, techVoucher, doublePointsVoucher models.Voucher
-	database.DB.Where("code = ?", "SUMMER2026").First(&summerVoucher)
-	database.DB.Where("code = ?", "WELCOME50").First(&welcomeVoucher)
-	database.DB.Where("code = ?", "TECH15").First(&techVoucher)
-	database.DB.Where("code = ?", "DOUBLE-POINTS").First(&doublePointsVoucher)
+	database.DB.Where("code = ?", storedIdentifier("vouchers", "code", "SUMMER2026")).First(&summerVoucher)
+	database.DB.Where("code = ?", storedIdentifier("vouchers", "code", "WELCOME50")).First(&welcomeVoucher)
+	database.DB.Where("code = ?", storedIdentifier("vouchers", "code", "TECH15")).First(&techVoucher)
+	database.DB.Where("code = ?", storedIdentifier("vouchers", "code", "DOUBLE-POINTS")).First(&doublePointsVoucher)
 
 	var digitecGC, galaxusGC models.GiftCard
-	database.DB.Where("card_number = ?", "7610200000002").First(&digitecGC)
-	database.DB.Where("card_number = ?", "GX-CARD-QR-001").First(&galaxusGC)
+	database.DB.Where("card_number = ?", storedIdentifier("gift_cards", "card_number", "7610200000002")).First(&digitecGC)
+	database.DB.Where("card_number = ?", storedIdentifier("gift_cards", "card_number", "GX-CARD-QR-001")).First(&galaxusGC)
 
 	// Card Shares - all permission combinations
 	cardShares := []models.CardShare{
@@ -765,6 +779,11 @@ func main() {
 	// Load config
 	cfg := config.Load()
 
+	// Encrypt PINs like the server does
+	if err := setup.InitEncryption(cfg); err != nil {
+		log.Fatalf("❌ Invalid ENCRYPTION_KEYS: %v", err)
+	}
+
 	// Connect to database
 	if err := database.Connect(cfg.DatabaseURL); err != nil {
 		log.Fatal(err)
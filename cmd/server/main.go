@@ -101,8 +101,22 @@ func run() int {
 	}
 	setup.RegisterRoutes(routeConfig)
 
-	// Start metrics collector goroutine
-	setup.StartMetricsCollector()
+	// Run background jobs (cleanups, metrics) until shutdown
+	scheduler, err := setup.NewJobScheduler(database.DB, serviceContainer)
+	if err != nil {
+		log.Printf("Failed to create job scheduler: %v", err)
+		return 1
+	}
+	workers, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+	go scheduler.Run(workers)
+
+	// Send queued notification emails and push messages until shutdown
+	go serviceContainer.NotificationDeliveryService.Run(workers)
+
+	// Receive notification events of all replicas; stopping it also ends
+	// the open event streams, which would otherwise delay the shutdown
+	go serviceContainer.NotificationEvents.Listen(workers, cfg.DatabaseURL)
 
 	// Start server with graceful shutdown
 	slog.Info("Server starting", "port", cfg.ServerPort)
@@ -120,6 +134,7 @@ func run() int {
 	<-quit
 
 	log.Println("Shutting down server...")
+	stopWorkers()
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 	if err := e.Shutdown(ctx); err != nil {
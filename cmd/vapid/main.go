@@ -0,0 +1,19 @@
+// Package main provides a CLI tool that prints a new VAPID key pair for
+// Web Push notifications in the format of the environment variables.
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"savvy/internal/push"
+)
+
+func main() {
+	publicKey, privateKey, err := push.GenerateKeys()
+	if err != nil {
+		log.Fatalf("❌ Failed to generate VAPID keys: %v", err)
+	}
+	fmt.Printf("VAPID_PUBLIC_KEY=%s\n", publicKey)
+	fmt.Printf("VAPID_PRIVATE_KEY=%s\n", privateKey)
+}
@@ -35201,6 +35201,184 @@ function initOrientationStore (Alpine) {
   };
 }
 
+/**
+ * Live notification badge
+ * The server pushes the unread count over Server-Sent Events, see
+ * NotificationHandler.Stream. The browser reconnects on its own after a
+ * network error or a server restart and gets the current count again.
+ */
+function initNotificationBadge (Alpine) {
+  // config: { stream }
+  Alpine.data('notificationBadge', (config) => ({
+    open: false,
+    count: 0,
+    source: null,
+
+    init () {
+      if (!('EventSource' in window)) return
+      this.source = new EventSource(config.stream)
+      this.source.addEventListener('count', (event) => this.update(event))
+      this.source.addEventListener('notification', (event) => this.update(event, true))
+    },
+
+    update (event, created = false) {
+      const data = JSON.parse(event.data)
+      const changed = data.count !== this.count
+      this.count = data.count
+      // Reload the dropdown (hx-trigger) when something changed in another tab or on another device
+      if (created || changed) {
+        window.dispatchEvent(new CustomEvent('notifications-changed'))
+      }
+    },
+
+    destroy () {
+      if (this.source) this.source.close()
+    }
+  }))
+}
+
+/**
+ * Passkey (WebAuthn) login and registration
+ * The server sends the options as JSON with base64url encoded binary fields,
+ * the browser API needs ArrayBuffers and returns them in its response
+ */
+function base64urlToBuffer (value) {
+  const base64 = value.replace(/-/g, '+').replace(/_/g, '/');
+  const padded = base64 + '='.repeat((4 - base64.length % 4) % 4);
+  return Uint8Array.from(atob(padded), c => c.charCodeAt(0)).buffer
+}
+
+function bufferToBase64url (buffer) {
+  let binary = '';
+  for (const byte of new Uint8Array(buffer)) {
+    binary += String.fromCharCode(byte);
+  }
+  return btoa(binary).replace(/\+/g, '-').replace(/\//g, '_').replace(/=+$/, '')
+}
+
+function decodeDescriptors (descriptors) {
+  return (descriptors || []).map(descriptor => ({ ...descriptor, id: base64urlToBuffer(descriptor.id) }))
+}
+
+function creationOptions (options) {
+  const publicKey = options.publicKey;
+  return {
+    publicKey: {
+      ...publicKey,
+      challenge: base64urlToBuffer(publicKey.challenge),
+      user: { ...publicKey.user, id: base64urlToBuffer(publicKey.user.id) },
+      excludeCredentials: decodeDescriptors(publicKey.excludeCredentials)
+    }
+  }
+}
+
+function requestOptions (options) {
+  const publicKey = options.publicKey;
+  return {
+    publicKey: {
+      ...publicKey,
+      challenge: base64urlToBuffer(publicKey.challenge),
+      allowCredentials: decodeDescriptors(publicKey.allowCredentials)
+    }
+  }
+}
+
+function credentialToJSON (credential) {
+  const response = credential.response;
+  const json = {
+    id: credential.id,
+    rawId: bufferToBase64url(credential.rawId),
+    type: credential.type,
+    authenticatorAttachment: credential.authenticatorAttachment,
+    clientExtensionResults: credential.getClientExtensionResults(),
+    response: {
+      clientDataJSON: bufferToBase64url(response.clientDataJSON)
+    }
+  }
+
+  if (response.attestationObject) {
+    json.response.attestationObject = bufferToBase64url(response.attestationObject);
+    json.response.transports = response.getTransports ? response.getTransports() : [];
+  } else {
+    json.response.authenticatorData = bufferToBase64url(response.authenticatorData);
+    json.response.signature = bufferToBase64url(response.signature);
+    if (response.userHandle) {
+      json.response.userHandle = bufferToBase64url(response.userHandle);
+    }
+  };
+  return json
+}
+
+async function postJSON (url, csrfToken, body) {
+  const response = await fetch(url, {
+    method: 'POST',
+    credentials: 'same-origin',
+    headers: {
+      'Content-Type': 'application/json',
+      'X-CSRF-Token': csrfToken
+    },
+    body: body === undefined ? undefined : JSON.stringify(body)
+  });
+  const data = await response.json().catch(() => ({}));
+  return { ok: response.ok, data }
+}
+
+function initPasskeys (Alpine) {
+  // config: { begin, finish, csrfToken, failed, nameRequired }
+  Alpine.data('passkey', (config) => ({
+    supported: typeof window.PublicKeyCredential !== 'undefined',
+    busy: false,
+    error: '',
+    name: '',
+
+    login () {
+      return this.run(async () => {
+        const begin = await postJSON(config.begin, config.csrfToken);
+        if (!begin.ok) return begin
+        const credential = await navigator.credentials.get(requestOptions(begin.data));
+        return postJSON(config.finish, config.csrfToken, credentialToJSON(credential))
+      })
+    },
+
+    register () {
+      if (!this.name.trim()) {
+        this.error = config.nameRequired;
+        return
+      }
+      return this.run(async () => {
+        const begin = await postJSON(config.begin, config.csrfToken);
+        if (!begin.ok) return begin
+        const credential = await navigator.credentials.create(creationOptions(begin.data));
+        return postJSON(config.finish, config.csrfToken, {
+          name: this.name.trim(),
+          credential: credentialToJSON(credential)
+        })
+      })
+    },
+
+    async run (ceremony) {
+      this.busy = true;
+      this.error = '';
+      try {
+        const result = await ceremony();
+        if (result.data.redirect) {
+          window.location.href = result.data.redirect;
+          return
+        }
+        if (!result.ok) {
+          this.error = result.data.error || config.failed;
+        }
+      } catch (err) {
+        // Cancelled by the user, timed out or no matching passkey
+        console.warn('Passkey ceremony failed:', err);
+        this.error = config.failed;
+      } finally {
+        this.busy = false;
+      }
+    }
+  }));
+}
+
 /**
  * Precache setup for offline access
  * Caches all detail pages and associated resources for offline browsing
@@ -35333,6 +35511,89 @@ function setupPrecaching () {
   }
 }
 
+/**
+ * Web Push subscription of the current browser
+ * The service worker shows the messages, see the push handler in service-worker.js
+ */
+function urlBase64ToUint8Array (value) {
+  const base64 = value.replace(/-/g, '+').replace(/_/g, '/')
+  const padded = base64 + '='.repeat((4 - base64.length % 4) % 4)
+  return Uint8Array.from(atob(padded), c => c.charCodeAt(0))
+}
+
+async function sendSubscription (url, method, csrfToken, body) {
+  const response = await fetch(url, {
+    method,
+    credentials: 'same-origin',
+    headers: {
+      'Content-Type': 'application/json',
+      'X-CSRF-Token': csrfToken
+    },
+    body: JSON.stringify(body)
+  })
+  if (!response.ok) {
+    throw new Error(`Push subscription request failed: ${response.status}`)
+  }
+}
+
+function initPushSubscription (Alpine) {
+  // config: { publicKey, endpoint, csrfToken, failed, denied }
+  Alpine.data('pushSubscription', (config) => ({
+    supported: 'serviceWorker' in navigator && 'PushManager' in window && 'Notification' in window,
+    subscribed: false,
+    busy: false,
+    error: '',
+
+    async init () {
+      if (!this.supported) return
+      const registration = await navigator.serviceWorker.ready
+      this.subscribed = (await registration.pushManager.getSubscription()) !== null
+    },
+
+    async subscribe () {
+      this.busy = true
+      this.error = ''
+      try {
+        if (await Notification.requestPermission() !== 'granted') {
+          this.error = config.denied
+          return
+        }
+        const registration = await navigator.serviceWorker.ready
+        const subscription = await registration.pushManager.subscribe({
+          userVisibleOnly: true,
+          applicationServerKey: urlBase64ToUint8Array(config.publicKey)
+        })
+        await sendSubscription(config.endpoint, 'POST', config.csrfToken, subscription.toJSON())
+        this.subscribed = true
+      } catch (err) {
+        console.warn('Push subscription failed:', err)
+        this.error = config.failed
+      } finally {
+        this.busy = false
+      }
+    },
+
+    async unsubscribe () {
+      this.busy = true
+      this.error = ''
+      try {
+        const registration = await navigator.serviceWorker.ready
+        const subscription = await registration.pushManager.getSubscription()
+        if (subscription) {
+          await subscription.unsubscribe()
+          await sendSubscription(config.endpoint, 'DELETE', config.csrfToken, { endpoint: subscription.endpoint })
+        }
+        this.subscribed = false
+      } catch (err) {
+        console.warn('Push unsubscribe failed:', err)
+        this.error = config.failed
+      } finally {
+        this.busy = false
+      }
+    }
+  }))
+}
+
 // static/js/src/app.js - Main entry point for bundled JS
 
 
@@ -35342,6 +35603,9 @@ window.offlineHandler = offlineHandler;
 
 initOfflineStore(module_default);
 initOrientationStore(module_default);
+initNotificationBadge(module_default);
+initPasskeys(module_default);
+initPushSubscription(module_default);
 setupPrecaching();
 
 module_default.start();
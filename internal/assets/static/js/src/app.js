@@ -6,7 +6,10 @@ import htmx from 'htmx.org'
 import './scanner-loader.js'
 import { initOfflineStore, offlineHandler } from './offline.js'
 import { initOrientationStore } from './orientation.js'
+import { initNotificationBadge } from './notifications.js'
+import { initPasskeys } from './passkeys.js'
 import { setupPrecaching } from './precache.js'
+import { initPushSubscription } from './push.js'
 
 window.Alpine = Alpine
 window.htmx = htmx
@@ -14,6 +17,9 @@ window.offlineHandler = offlineHandler
 
 initOfflineStore(Alpine)
 initOrientationStore(Alpine)
+initNotificationBadge(Alpine)
+initPasskeys(Alpine)
+initPushSubscription(Alpine)
 setupPrecaching()
 
 Alpine.start()
@@ -0,0 +1,35 @@
+/**
+ * Live notification badge
+ * The server pushes the unread count over Server-Sent Events, see
+ * NotificationHandler.Stream. The browser reconnects on its own after a
+ * network error or a server restart and gets the current count again.
+ */
+export function initNotificationBadge (Alpine) {
+  // config: { stream }
+  Alpine.data('notificationBadge', (config) => ({
+    open: false,
+    count: 0,
+    source: null,
+
+    init () {
+      if (!('EventSource' in window)) return
+      this.source = new EventSource(config.stream)
+      this.source.addEventListener('count', (event) => this.update(event))
+      this.source.addEventListener('notification', (event) => this.update(event, true))
+    },
+
+    update (event, created = false) {
+      const data = JSON.parse(event.data)
+      const changed = data.count !== this.count
+      this.count = data.count
+      // Reload the dropdown (hx-trigger) when something changed in another tab or on another device
+      if (created || changed) {
+        window.dispatchEvent(new CustomEvent('notifications-changed'))
+      }
+    },
+
+    destroy () {
+      if (this.source) this.source.close()
+    }
+  }))
+}
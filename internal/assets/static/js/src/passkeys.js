@@ -0,0 +1,141 @@
+/**
+ * Passkey (WebAuthn) login and registration
+ * The server sends the options as JSON with base64url encoded binary fields,
+ * the browser API needs ArrayBuffers and returns them in its response
+ */
+function base64urlToBuffer (value) {
+  const base64 = value.replace(/-/g, '+').replace(/_/g, '/')
+  const padded = base64 + '='.repeat((4 - base64.length % 4) % 4)
+  return Uint8Array.from(atob(padded), c => c.charCodeAt(0)).buffer
+}
+
+function bufferToBase64url (buffer) {
+  let binary = ''
+  for (const byte of new Uint8Array(buffer)) {
+    binary += String.fromCharCode(byte)
+  }
+  return btoa(binary).replace(/\+/g, '-').replace(/\//g, '_').replace(/=+$/, '')
+}
+
+function decodeDescriptors (descriptors) {
+  return (descriptors || []).map(descriptor => ({ ...descriptor, id: base64urlToBuffer(descriptor.id) }))
+}
+
+function creationOptions (options) {
+  const publicKey = options.publicKey
+  return {
+    publicKey: {
+      ...publicKey,
+      challenge: base64urlToBuffer(publicKey.challenge),
+      user: { ...publicKey.user, id: base64urlToBuffer(publicKey.user.id) },
+      excludeCredentials: decodeDescriptors(publicKey.excludeCredentials)
+    }
+  }
+}
+
+function requestOptions (options) {
+  const publicKey = options.publicKey
+  return {
+    publicKey: {
+      ...publicKey,
+      challenge: base64urlToBuffer(publicKey.challenge),
+      allowCredentials: decodeDescriptors(publicKey.allowCredentials)
+    }
+  }
+}
+
+function credentialToJSON (credential) {
+  const response = credential.response
+  const json = {
+    id: credential.id,
+    rawId: bufferToBase64url(credential.rawId),
+    type: credential.type,
+    authenticatorAttachment: credential.authenticatorAttachment,
+    clientExtensionResults: credential.getClientExtensionResults(),
+    response: {
+      clientDataJSON: bufferToBase64url(response.clientDataJSON)
+    }
+  }
+
+  if (response.attestationObject) {
+    json.response.attestationObject = bufferToBase64url(response.attestationObject)
+    json.response.transports = response.getTransports ? response.getTransports() : []
+  } else {
+    json.response.authenticatorData = bufferToBase64url(response.authenticatorData)
+    json.response.signature = bufferToBase64url(response.signature)
+    if (response.userHandle) {
+      json.response.userHandle = bufferToBase64url(response.userHandle)
+    }
+  }
+  return json
+}
+
+async function postJSON (url, csrfToken, body) {
+  const response = await fetch(url, {
+    method: 'POST',
+    credentials: 'same-origin',
+    headers: {
+      'Content-Type': 'application/json',
+      'X-CSRF-Token': csrfToken
+    },
+    body: body === undefined ? undefined : JSON.stringify(body)
+  })
+  const data = await response.json().catch(() => ({}))
+  return { ok: response.ok, data }
+}
+
+export function initPasskeys (Alpine) {
+  // config: { begin, finish, csrfToken, failed, nameRequired }
+  Alpine.data('passkey', (config) => ({
+    supported: typeof window.PublicKeyCredential !== 'undefined',
+    busy: false,
+    error: '',
+    name: '',
+
+    login () {
+      return this.run(async () => {
+        const begin = await postJSON(config.begin, config.csrfToken)
+        if (!begin.ok) return begin
+        const credential = await navigator.credentials.get(requestOptions(begin.data))
+        return postJSON(config.finish, config.csrfToken, credentialToJSON(credential))
+      })
+    },
+
+    register () {
+      if (!this.name.trim()) {
+        this.error = config.nameRequired
+        return
+      }
+      return this.run(async () => {
+        const begin = await postJSON(config.begin, config.csrfToken)
+        if (!begin.ok) return begin
+        const credential = await navigator.credentials.create(creationOptions(begin.data))
+        return postJSON(config.finish, config.csrfToken, {
+          name: this.name.trim(),
+          credential: credentialToJSON(credential)
+        })
+      })
+    },
+
+    async run (ceremony) {
+      this.busy = true
+      this.error = ''
+      try {
+        const result = await ceremony()
+        if (result.data.redirect) {
+          window.location.href = result.data.redirect
+          return
+        }
+        if (!result.ok) {
+          this.error = result.data.error || config.failed
+        }
+      } catch (err) {
+        // Cancelled by the user, timed out or no matching passkey
+        console.warn('Passkey ceremony failed:', err)
+        this.error = config.failed
+      } finally {
+        this.busy = false
+      }
+    }
+  }))
+}
@@ -0,0 +1,82 @@
+/**
+ * Web Push subscription of the current browser
+ * The service worker shows the messages, see the push handler in service-worker.js
+ */
+function urlBase64ToUint8Array (value) {
+  const base64 = value.replace(/-/g, '+').replace(/_/g, '/')
+  const padded = base64 + '='.repeat((4 - base64.length % 4) % 4)
+  return Uint8Array.from(atob(padded), c => c.charCodeAt(0))
+}
+
+async function sendSubscription (url, method, csrfToken, body) {
+  const response = await fetch(url, {
+    method,
+    credentials: 'same-origin',
+    headers: {
+      'Content-Type': 'application/json',
+      'X-CSRF-Token': csrfToken
+    },
+    body: JSON.stringify(body)
+  })
+  if (!response.ok) {
+    throw new Error(`Push subscription request failed: ${response.status}`)
+  }
+}
+
+export function initPushSubscription (Alpine) {
+  // config: { publicKey, endpoint, csrfToken, failed, denied }
+  Alpine.data('pushSubscription', (config) => ({
+    supported: 'serviceWorker' in navigator && 'PushManager' in window && 'Notification' in window,
+    subscribed: false,
+    busy: false,
+    error: '',
+
+    async init () {
+      if (!this.supported) return
+      const registration = await navigator.serviceWorker.ready
+      this.subscribed = (await registration.pushManager.getSubscription()) !== null
+    },
+
+    async subscribe () {
+      this.busy = true
+      this.error = ''
+      try {
+        if (await Notification.requestPermission() !== 'granted') {
+          this.error = config.denied
+          return
+        }
+        const registration = await navigator.serviceWorker.ready
+        const subscription = await registration.pushManager.subscribe({
+          userVisibleOnly: true,
+          applicationServerKey: urlBase64ToUint8Array(config.publicKey)
+        })
+        await sendSubscription(config.endpoint, 'POST', config.csrfToken, subscription.toJSON())
+        this.subscribed = true
+      } catch (err) {
+        console.warn('Push subscription failed:', err)
+        this.error = config.failed
+      } finally {
+        this.busy = false
+      }
+    },
+
+    async unsubscribe () {
+      this.busy = true
+      this.error = ''
+      try {
+        const registration = await navigator.serviceWorker.ready
+        const subscription = await registration.pushManager.getSubscription()
+        if (subscription) {
+          await subscription.unsubscribe()
+          await sendSubscription(config.endpoint, 'DELETE', config.csrfToken, { endpoint: subscription.endpoint })
+        }
+        this.subscribed = false
+      } catch (err) {
+        console.warn('Push unsubscribe failed:', err)
+        this.error = config.failed
+      } finally {
+        this.busy = false
+      }
+    }
+  }))
+}
@@ -3,7 +3,6 @@ package audit
 
 import (
 	"context"
-	"encoding/json"
 	"savvy/internal/models"
 
 	"github.com/google/uuid"
@@ -13,8 +12,8 @@ import (
 
 // LogDeletion creates an audit log entry for a deletion operation
 func LogDeletion(db *gorm.DB, userID *uuid.UUID, resourceType string, resourceID uuid.UUID, resourceData interface{}, ipAddress, userAgent string) error {
-	// Serialize resource data to JSON
-	dataJSON, err := json.Marshal(resourceData)
+	// Serialize resource data to JSON without PINs and full card numbers
+	dataJSON, err := marshalSnapshot(resourceData)
 	if err != nil {
 		return err
 	}
@@ -47,8 +46,8 @@ func LogDeletionFromContext(c echo.Context, db *gorm.DB, resourceType string, re
 
 // LogUpdate creates an audit log entry for an update operation
 func LogUpdate(db *gorm.DB, userID *uuid.UUID, resourceType string, resourceID uuid.UUID, resourceData interface{}, ipAddress, userAgent string) error {
-	// Serialize resource data to JSON
-	dataJSON, err := json.Marshal(resourceData)
+	// Serialize resource data to JSON without PINs and full card numbers
+	dataJSON, err := marshalSnapshot(resourceData)
 	if err != nil {
 		return err
 	}
@@ -79,6 +78,84 @@ func LogUpdateFromContext(c echo.Context, db *gorm.DB, resourceType string, reso
 	return LogUpdate(db, userID, resourceType, resourceID, resourceData, ipAddress, userAgent)
 }
 
+// LogPINRevealFromContext records that the current user revealed a gift card PIN.
+// method is how the user re-authenticated ("password" or "oidc").
+func LogPINRevealFromContext(c echo.Context, db *gorm.DB, giftCardID uuid.UUID, method string) error {
+	var userID *uuid.UUID
+	if user, ok := c.Get("current_user").(*models.User); ok && user != nil {
+		userID = &user.ID
+	}
+
+	dataJSON, err := marshalSnapshot(map[string]string{"method": method})
+	if err != nil {
+		return err
+	}
+
+	auditLog := models.AuditLog{
+		UserID:       userID,
+		Action:       models.AuditActionRevealPIN,
+		ResourceType: "gift_cards",
+		ResourceID:   giftCardID,
+		ResourceData: string(dataJSON),
+		IPAddress:    c.RealIP(),
+		UserAgent:    c.Request().UserAgent(),
+	}
+
+	return db.Create(&auditLog).Error
+}
+
+// LogTwoFactorFromContext records a change of the current user's two-factor
+// authentication (enabled, disabled or new recovery codes).
+func LogTwoFactorFromContext(c echo.Context, db *gorm.DB, action string) error {
+	user, ok := c.Get("current_user").(*models.User)
+	if !ok || user == nil {
+		return nil
+	}
+
+	dataJSON, err := marshalSnapshot(map[string]string{"email": user.Email})
+	if err != nil {
+		return err
+	}
+
+	auditLog := models.AuditLog{
+		UserID:       &user.ID,
+		Action:       action,
+		ResourceType: "users",
+		ResourceID:   user.ID,
+		ResourceData: string(dataJSON),
+		IPAddress:    c.RealIP(),
+		UserAgent:    c.Request().UserAgent(),
+	}
+
+	return db.Create(&auditLog).Error
+}
+
+// LogPasskeyFromContext records that the current user registered or removed
+// a passkey. ResourceData holds the passkey name, not the key.
+func LogPasskeyFromContext(c echo.Context, db *gorm.DB, action, name string) error {
+	user, ok := c.Get("current_user").(*models.User)
+	if !ok || user == nil {
+		return nil
+	}
+
+	dataJSON, err := marshalSnapshot(map[string]string{"email": user.Email, "passkey": name})
+	if err != nil {
+		return err
+	}
+
+	auditLog := models.AuditLog{
+		UserID:       &user.ID,
+		Action:       action,
+		ResourceType: "users",
+		ResourceID:   user.ID,
+		ResourceData: string(dataJSON),
+		IPAddress:    c.RealIP(),
+		UserAgent:    c.Request().UserAgent(),
+	}
+
+	return db.Create(&auditLog).Error
+}
+
 // SetupAuditHooks registers GORM callbacks for automatic audit logging
 func SetupAuditHooks(db *gorm.DB) error {
 	// Register AfterDelete callback for all models
@@ -136,7 +213,11 @@ func afterDeleteHook(db *gorm.DB) {
 	}
 
 	// Create audit log (without triggering another hook)
-	dataJSON, _ := json.Marshal(db.Statement.Dest)
+	dataJSON, err := marshalSnapshot(db.Statement.Dest)
+	if err != nil {
+		db.Logger.Error(db.Statement.Context, "Failed to serialize audit snapshot: %v", err)
+		return
+	}
 
 	// Get underlying SQL DB connection
 	sqlDB, err := db.DB()
@@ -0,0 +1,80 @@
+package audit
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Snapshot fields that never end up in the audit log
+var redactedFields = map[string]bool{
+	"pin": true,
+}
+
+// Snapshot fields that are masked down to their last characters, enough to
+// recognize a card without making the number usable
+var maskedFields = map[string]bool{
+	"card_number": true,
+	"code":        true,
+}
+
+// maskVisible is the number of trailing characters kept by mask
+const maskVisible = 4
+
+// marshalSnapshot serializes a resource for the audit log with sensitive
+// fields removed or masked, including those of nested resources
+func marshalSnapshot(resourceData interface{}) ([]byte, error) {
+	dataJSON, err := json.Marshal(resourceData)
+	if err != nil {
+		return nil, err
+	}
+	return RedactSnapshot(dataJSON)
+}
+
+// RedactSnapshot removes or masks sensitive fields in a JSON snapshot. Used
+// to clean up entries written before snapshots were redacted.
+func RedactSnapshot(dataJSON []byte) ([]byte, error) {
+	var data interface{}
+	if err := json.Unmarshal(dataJSON, &data); err != nil {
+		return nil, err
+	}
+	return json.Marshal(redact(data))
+}
+
+// redact walks decoded JSON and removes or masks sensitive fields
+func redact(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			switch {
+			case redactedFields[key]:
+				delete(v, key)
+			case maskedFields[key]:
+				if s, ok := field.(string); ok && !isMasked(s) {
+					v[key] = mask(s)
+				}
+			default:
+				v[key] = redact(field)
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redact(item)
+		}
+	}
+	return value
+}
+
+// isMasked reports whether a value was already masked, so redacting a
+// snapshot twice keeps it unchanged
+func isMasked(value string) bool {
+	return strings.HasPrefix(value, "•")
+}
+
+// mask replaces all but the last characters, e.g. "•••• 6789"
+func mask(value string) string {
+	runes := []rune(value)
+	if len(runes) <= maskVisible {
+		return strings.Repeat("•", len(runes))
+	}
+	return "•••• " + string(runes[len(runes)-maskVisible:])
+}
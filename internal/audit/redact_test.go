@@ -0,0 +1,62 @@
+package audit
+
+import (
+	"encoding/json"
+	"testing"
+
+	"savvy/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarshalSnapshot_GiftCard(t *testing.T) {
+	giftCard := &models.GiftCard{
+		ID:         uuid.New(),
+		CardNumber: "6006491234567890",
+		PIN:        "4711",
+	}
+
+	dataJSON, err := marshalSnapshot(giftCard)
+	require.NoError(t, err)
+
+	assert.NotContains(t, string(dataJSON), "4711")
+	assert.NotContains(t, string(dataJSON), "6006491234567890")
+
+	var data map[string]interface{}
+	require.NoError(t, json.Unmarshal(dataJSON, &data))
+	assert.NotContains(t, data, "pin")
+	assert.Equal(t, "•••• 7890", data["card_number"])
+	assert.Equal(t, giftCard.ID.String(), data["id"])
+}
+
+func TestMarshalSnapshot_Nested(t *testing.T) {
+	share := &models.GiftCardShare{
+		ID:       uuid.New(),
+		GiftCard: &models.GiftCard{CardNumber: "6006491234567890", PIN: "4711"},
+	}
+
+	dataJSON, err := marshalSnapshot(share)
+	require.NoError(t, err)
+	assert.NotContains(t, string(dataJSON), "4711")
+	assert.NotContains(t, string(dataJSON), "6006491234567890")
+
+	dataJSON, err = marshalSnapshot([]models.Voucher{{Code: "SUMMER"}, {Code: "ABC"}})
+	require.NoError(t, err)
+	assert.Contains(t, string(dataJSON), `"•••• MMER"`)
+	assert.Contains(t, string(dataJSON), `"•••"`)
+}
+
+func TestRedactSnapshot_Idempotent(t *testing.T) {
+	first, err := RedactSnapshot([]byte(`{"card_number":"6006491234567890","pin":"4711","merchant_name":"Migros"}`))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"card_number":"•••• 7890","merchant_name":"Migros"}`, string(first))
+
+	second, err := RedactSnapshot(first)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(first), string(second))
+
+	_, err = RedactSnapshot([]byte("not json"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,227 @@
+// Package backup defines the versioned archive format of per-user account
+// exports and reads and writes it as a ZIP file.
+//
+// IDs in an archive are the IDs of the exporting instance. They are only used
+// to link items within the archive (favorites, shares, merchants) and are
+// replaced by new IDs when the archive is imported.
+package backup
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FormatVersion is the archive format written by this version of Savvy.
+// Bump it for incompatible changes and keep Read able to load older versions.
+const FormatVersion = 1
+
+// DataFileName is the name of the JSON document inside the ZIP archive
+const DataFileName = "savvy-export.json"
+
+// ContentType is the MIME type of export archives
+const ContentType = "application/zip"
+
+// maxDataSize caps the decompressed size of the JSON document
+const maxDataSize = 50 << 20 // 50 MiB
+
+// Resource types used by favorites and shares, matching models.UserFavorite
+const (
+	ResourceCard     = "card"
+	ResourceVoucher  = "voucher"
+	ResourceGiftCard = "gift_card"
+)
+
+var (
+	// ErrInvalidArchive is returned for files that are not Savvy exports
+	ErrInvalidArchive = errors.New("invalid export archive")
+	// ErrUnsupportedVersion is returned for archives written by a newer Savvy version
+	ErrUnsupportedVersion = errors.New("unsupported export format version")
+)
+
+// Archive is the content of an account export.
+type Archive struct {
+	Version    int        `json:"version"`
+	ExportedAt time.Time  `json:"exported_at"`
+	User       User       `json:"user"`
+	Merchants  []Merchant `json:"merchants"`
+	Cards      []Card     `json:"cards"`
+	Vouchers   []Voucher  `json:"vouchers"`
+	GiftCards  []GiftCard `json:"gift_cards"`
+	Favorites  []Favorite `json:"favorites"`
+	Shares     []Share    `json:"shares"`
+}
+
+// User identifies the exporting account.
+type User struct {
+	Email     string `json:"email"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+}
+
+// Merchant is a merchant referenced by an exported item. Merchants are
+// resolved by name on import.
+type Merchant struct {
+	ID      uuid.UUID `json:"id"`
+	Name    string    `json:"name"`
+	Website string    `json:"website,omitempty"`
+	LogoURL string    `json:"logo_url,omitempty"`
+	Color   string    `json:"color,omitempty"`
+}
+
+// Card is an exported loyalty card.
+type Card struct {
+	ID           uuid.UUID  `json:"id"`
+	MerchantID   *uuid.UUID `json:"merchant_id,omitempty"`
+	MerchantName string     `json:"merchant_name"`
+	Program      string     `json:"program"`
+	CardNumber   string     `json:"card_number"`
+	BarcodeType  string     `json:"barcode_type"`
+	Status       string     `json:"status"`
+	Notes        string     `json:"notes,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// Voucher is an exported voucher.
+type Voucher struct {
+	ID                uuid.UUID  `json:"id"`
+	MerchantID        *uuid.UUID `json:"merchant_id,omitempty"`
+	MerchantName      string     `json:"merchant_name"`
+	Code              string     `json:"code"`
+	Type              string     `json:"type"`
+	Value             float64    `json:"value"`
+	Description       string     `json:"description,omitempty"`
+	MinPurchaseAmount float64    `json:"min_purchase_amount"`
+	ValidFrom         time.Time  `json:"valid_from"`
+	ValidUntil        time.Time  `json:"valid_until"`
+	UsageLimitType    string     `json:"usage_limit_type"`
+	BarcodeType       string     `json:"barcode_type"`
+	CreatedAt         time.Time  `json:"created_at"`
+}
+
+// GiftCard is an exported gift card with its full transaction history.
+type GiftCard struct {
+	ID             uuid.UUID     `json:"id"`
+	MerchantID     *uuid.UUID    `json:"merchant_id,omitempty"`
+	MerchantName   string        `json:"merchant_name"`
+	CardNumber     string        `json:"card_number"`
+	InitialBalance float64       `json:"initial_balance"`
+	Currency       string        `json:"currency"`
+	PIN            string        `json:"pin,omitempty"`
+	ExpiresAt      *time.Time    `json:"expires_at,omitempty"`
+	Status         string        `json:"status"`
+	BarcodeType    string        `json:"barcode_type"`
+	Notes          string        `json:"notes,omitempty"`
+	CreatedAt      time.Time     `json:"created_at"`
+	Transactions   []Transaction `json:"transactions"`
+}
+
+// Transaction is a gift card transaction. Kind is purchase, reload, refund or
+// adjustment; archives without kind only contain purchases.
+type Transaction struct {
+	Kind            string    `json:"kind,omitempty"`
+	Amount          float64   `json:"amount"`
+	Description     string    `json:"description,omitempty"`
+	TransactionDate time.Time `json:"transaction_date"`
+	CreatedByEmail  string    `json:"created_by_email,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// Favorite marks an exported item as favorite of the exporting user.
+type Favorite struct {
+	ResourceType string    `json:"resource_type"`
+	ResourceID   uuid.UUID `json:"resource_id"`
+}
+
+// Share is a share the exporting user created for one of their items.
+// The recipient is resolved by email on import.
+type Share struct {
+	ResourceType        string    `json:"resource_type"`
+	ResourceID          uuid.UUID `json:"resource_id"`
+	SharedWithEmail     string    `json:"shared_with_email"`
+	CanEdit             bool      `json:"can_edit"`
+	CanDelete           bool      `json:"can_delete"`
+	CanEditTransactions bool      `json:"can_edit_transactions,omitempty"`
+}
+
+// FileName returns the download name of an export created at t.
+func FileName(t time.Time) string {
+	return "savvy-export-" + t.Format("2006-01-02") + ".zip"
+}
+
+// Write encodes the archive as a ZIP file containing DataFileName.
+func Write(w io.Writer, archive *Archive) error {
+	zw := zip.NewWriter(w)
+
+	f, err := zw.CreateHeader(&zip.FileHeader{
+		Name:     DataFileName,
+		Method:   zip.Deflate,
+		Modified: archive.ExportedAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(f)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(archive); err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
+
+// Read decodes an export, either the ZIP archive created by Write or the
+// bare JSON document extracted from it.
+func Read(data []byte) (*Archive, error) {
+	if bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+		extracted, err := readDataFile(data)
+		if err != nil {
+			return nil, err
+		}
+		data = extracted
+	}
+
+	var archive Archive
+	if err := json.Unmarshal(data, &archive); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+	if archive.Version < 1 {
+		return nil, fmt.Errorf("%w: missing version", ErrInvalidArchive)
+	}
+	if archive.Version > FormatVersion {
+		return nil, fmt.Errorf("%w: %d (supported: %d)", ErrUnsupportedVersion, archive.Version, FormatVersion)
+	}
+	return &archive, nil
+}
+
+// readDataFile extracts DataFileName from a ZIP archive
+func readDataFile(data []byte) ([]byte, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+
+	for _, f := range zr.File {
+		if f.Name != DataFileName {
+			continue
+		}
+		if f.UncompressedSize64 > maxDataSize {
+			return nil, fmt.Errorf("%w: %s exceeds %d bytes", ErrInvalidArchive, DataFileName, maxDataSize)
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+		}
+		defer func() { _ = rc.Close() }()
+		return io.ReadAll(io.LimitReader(rc, maxDataSize))
+	}
+
+	return nil, fmt.Errorf("%w: %s not found", ErrInvalidArchive, DataFileName)
+}
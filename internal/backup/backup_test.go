@@ -0,0 +1,92 @@
+package backup
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testArchive() *Archive {
+	merchantID := uuid.New()
+	cardID := uuid.New()
+	giftCardID := uuid.New()
+	expiresAt := time.Date(2027, 12, 31, 0, 0, 0, 0, time.UTC)
+
+	return &Archive{
+		Version:    FormatVersion,
+		ExportedAt: time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC),
+		User:       User{Email: "anna.mueller@example.com", FirstName: "Anna", LastName: "Müller"},
+		Merchants:  []Merchant{{ID: merchantID, Name: "Migros", Color: "#FF6600"}},
+		Cards: []Card{{
+			ID: cardID, MerchantID: &merchantID, MerchantName: "Migros", Program: "Cumulus",
+			CardNumber: "2099123456789", BarcodeType: "EAN13", Status: "active",
+		}},
+		GiftCards: []GiftCard{{
+			ID: giftCardID, MerchantName: "Manor", CardNumber: "6001234", InitialBalance: 100,
+			Currency: "CHF", PIN: "1234", ExpiresAt: &expiresAt, Status: "active", BarcodeType: "CODE128",
+			Transactions: []Transaction{
+				{Amount: 30, Description: "Shoes", TransactionDate: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), CreatedByEmail: "anna.mueller@example.com"},
+				{Amount: -10, Description: "Reload", TransactionDate: time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)},
+			},
+		}},
+		Favorites: []Favorite{{ResourceType: ResourceCard, ResourceID: cardID}},
+		Shares: []Share{{
+			ResourceType: ResourceGiftCard, ResourceID: giftCardID, SharedWithEmail: "thomas.schmidt@example.com",
+			CanEdit: true, CanEditTransactions: true,
+		}},
+	}
+}
+
+func TestWriteRead_RoundTrip(t *testing.T) {
+	archive := testArchive()
+
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, archive))
+
+	// The ZIP contains exactly the JSON document
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	require.Len(t, zr.File, 1)
+	assert.Equal(t, DataFileName, zr.File[0].Name)
+
+	decoded, err := Read(buf.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, archive, decoded)
+}
+
+func TestRead_BareJSON(t *testing.T) {
+	archive, err := Read([]byte(`{"version": 1, "user": {"email": "a@example.com"}, "cards": [{"card_number": "1"}]}`))
+	require.NoError(t, err)
+	assert.Equal(t, "a@example.com", archive.User.Email)
+	assert.Len(t, archive.Cards, 1)
+}
+
+func TestRead_Invalid(t *testing.T) {
+	_, err := Read([]byte("not json"))
+	assert.ErrorIs(t, err, ErrInvalidArchive)
+
+	_, err = Read([]byte(`{"cards": []}`))
+	assert.ErrorIs(t, err, ErrInvalidArchive)
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	_, err = zw.Create("catima.csv")
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	_, err = Read(buf.Bytes())
+	assert.ErrorIs(t, err, ErrInvalidArchive)
+}
+
+func TestRead_NewerVersion(t *testing.T) {
+	_, err := Read([]byte(`{"version": 99}`))
+	assert.ErrorIs(t, err, ErrUnsupportedVersion)
+}
+
+func TestFileName(t *testing.T) {
+	assert.Equal(t, "savvy-export-2026-10-16.zip", FileName(time.Date(2026, 10, 16, 23, 0, 0, 0, time.UTC)))
+}
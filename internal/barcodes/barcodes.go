@@ -0,0 +1,57 @@
+// Package barcodes maps the barcode types stored on cards, vouchers and gift cards
+// to the symbologies they are rendered with.
+package barcodes
+
+// Symbology is a barcode encoding that can actually be rendered.
+type Symbology string
+
+// Supported symbologies
+const (
+	Code128    Symbology = "code128"
+	Code39     Symbology = "code39"
+	Code93     Symbology = "code93"
+	Codabar    Symbology = "codabar"
+	QR         Symbology = "qr"
+	EAN        Symbology = "ean"
+	PDF417     Symbology = "pdf417"
+	DataMatrix Symbology = "datamatrix"
+	Aztec      Symbology = "aztec"
+)
+
+// symbologies maps stored barcode types to their symbology.
+// Types without their own encoder (UPC, ITF, MaxiCode) fall back to Code 128.
+var symbologies = map[string]Symbology{
+	"CODE128":    Code128,
+	"CODE39":     Code39,
+	"CODE93":     Code93,
+	"CODABAR":    Codabar,
+	"QR":         QR,
+	"EAN13":      EAN,
+	"ISBN13":     EAN,
+	"EAN8":       EAN,
+	"PDF417":     PDF417,
+	"DATAMATRIX": DataMatrix,
+	"AZTEC":      Aztec,
+	"UPCA":       Code128,
+	"UPCE":       Code128,
+	"ITF":        Code128,
+	"ITF14":      Code128,
+	"MAXICODE":   Code128,
+}
+
+// SymbologyFor returns the symbology used to render a stored barcode type.
+// The second return value is false for unknown types.
+func SymbologyFor(barcodeType string) (Symbology, bool) {
+	symbology, ok := symbologies[barcodeType]
+	return symbology, ok
+}
+
+// IsTwoDimensional returns true for matrix and stacked symbologies
+func (s Symbology) IsTwoDimensional() bool {
+	switch s {
+	case QR, PDF417, DataMatrix, Aztec:
+		return true
+	default:
+		return false
+	}
+}
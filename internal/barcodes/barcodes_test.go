@@ -0,0 +1,43 @@
+package barcodes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSymbologyFor(t *testing.T) {
+	tests := []struct {
+		barcodeType string
+		want        Symbology
+		ok          bool
+	}{
+		{"CODE128", Code128, true},
+		{"QR", QR, true},
+		{"EAN13", EAN, true},
+		{"ISBN13", EAN, true},
+		{"EAN8", EAN, true},
+		{"AZTEC", Aztec, true},
+		{"UPCA", Code128, true},
+		{"MAXICODE", Code128, true},
+		{"UNKNOWN", "", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.barcodeType, func(t *testing.T) {
+			got, ok := SymbologyFor(tt.barcodeType)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.ok, ok)
+		})
+	}
+}
+
+func TestSymbology_IsTwoDimensional(t *testing.T) {
+	assert.True(t, QR.IsTwoDimensional())
+	assert.True(t, PDF417.IsTwoDimensional())
+	assert.True(t, DataMatrix.IsTwoDimensional())
+	assert.True(t, Aztec.IsTwoDimensional())
+	assert.False(t, Code128.IsTwoDimensional())
+	assert.False(t, EAN.IsTwoDimensional())
+}
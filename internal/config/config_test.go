@@ -237,6 +237,134 @@ func TestLoadConfigDefaults(t *testing.T) {
 	if cfg.LogLevel != "DEBUG" {
 		t.Errorf("Expected LogLevel=DEBUG in development, got %s", cfg.LogLevel)
 	}
+
+	// Exchange rates default to a static table in CHF
+	if cfg.ExchangeRateProvider != "static" || cfg.ExchangeRateBase != "CHF" {
+		t.Errorf("Expected static exchange rates in CHF, got %s/%s", cfg.ExchangeRateProvider, cfg.ExchangeRateBase)
+	}
+
+	// Encryption is opt-in, identifiers stay searchable by default
+	if cfg.IsEncryptionEnabled() || cfg.EncryptIdentifiers {
+		t.Error("Expected encryption to be disabled by default")
+	}
+}
+
+func TestIsAppleWalletEnabled(t *testing.T) {
+	cfg := &Config{
+		AppleWalletPassTypeID: "pass.ch.example.savvy",
+		AppleWalletTeamID:     "ABCDE12345",
+		AppleWalletCertFile:   "/etc/savvy/pass.pem",
+		AppleWalletKeyFile:    "/etc/savvy/pass.key",
+	}
+	if !cfg.IsAppleWalletEnabled() {
+		t.Error("Expected Apple Wallet to be enabled with pass type, team, certificate and key")
+	}
+
+	cfg.AppleWalletKeyFile = ""
+	if cfg.IsAppleWalletEnabled() {
+		t.Error("Expected Apple Wallet to be disabled without private key")
+	}
+
+	if (&Config{}).IsAppleWalletEnabled() {
+		t.Error("Expected Apple Wallet to be disabled by default")
+	}
+}
+
+func TestIsGoogleWalletEnabled(t *testing.T) {
+	cfg := &Config{
+		GoogleWalletIssuerID:           "3388000000012345678",
+		GoogleWalletServiceAccountFile: "/etc/savvy/wallet-sa.json",
+	}
+	if !cfg.IsGoogleWalletEnabled() {
+		t.Error("Expected Google Wallet to be enabled with issuer and service account")
+	}
+
+	cfg.GoogleWalletIssuerID = ""
+	if cfg.IsGoogleWalletEnabled() {
+		t.Error("Expected Google Wallet to be disabled without issuer ID")
+	}
+}
+
+func TestWebAuthnOrigins(t *testing.T) {
+	t.Setenv("WEBAUTHN_RP_ID", "savvy.example.com")
+	t.Setenv("WEBAUTHN_RP_ORIGINS", "")
+
+	cfg := Load()
+	if !cfg.IsWebAuthnEnabled() {
+		t.Error("Expected WebAuthn to be enabled with a relying party ID")
+	}
+	if len(cfg.WebAuthnRPOrigins) != 1 || cfg.WebAuthnRPOrigins[0] != "https://savvy.example.com" {
+		t.Errorf("Expected HTTPS origin of the relying party ID, got %v", cfg.WebAuthnRPOrigins)
+	}
+
+	t.Setenv("WEBAUTHN_RP_ID", "")
+	if Load().IsWebAuthnEnabled() {
+		t.Error("Expected WebAuthn to be disabled by default")
+	}
+}
+
+func TestMailConfig(t *testing.T) {
+	t.Setenv("SMTP_HOST", "")
+	t.Setenv("SMTP_FROM", "")
+	t.Setenv("BASE_URL", "https://savvy.example.com/")
+
+	cfg := Load()
+	if cfg.IsMailEnabled() {
+		t.Error("Expected mail to be disabled without SMTP host")
+	}
+	if cfg.BaseURL != "https://savvy.example.com" {
+		t.Errorf("Expected trailing slash to be trimmed, got %q", cfg.BaseURL)
+	}
+
+	t.Setenv("SMTP_HOST", "smtp.example.com")
+	t.Setenv("SMTP_FROM", "Savvy <savvy@example.com>")
+	cfg = Load()
+	if !cfg.IsMailEnabled() {
+		t.Error("Expected mail to be enabled with SMTP host and sender")
+	}
+	if cfg.SMTPPort != "587" || cfg.SMTPTLS != "starttls" {
+		t.Errorf("Expected STARTTLS on port 587 by default, got %s on port %s", cfg.SMTPTLS, cfg.SMTPPort)
+	}
+
+	cfg.Environment = "production"
+	cfg.SessionSecret = "this-is-a-very-secure-secret-key-with-more-than-32-chars"
+	cfg.BaseURL = "http://localhost:3000"
+	if err := cfg.ValidateProduction(); err == nil || !contains(err.Error(), "BASE_URL") {
+		t.Errorf("Expected BASE_URL error in production, got %v", err)
+	}
+}
+
+func TestWebPushConfig(t *testing.T) {
+	t.Setenv("VAPID_PUBLIC_KEY", "")
+	t.Setenv("VAPID_PRIVATE_KEY", "")
+	t.Setenv("VAPID_SUBJECT", "")
+	t.Setenv("BASE_URL", "https://savvy.example.com")
+
+	cfg := Load()
+	if cfg.IsWebPushEnabled() {
+		t.Error("Expected Web Push to be disabled without VAPID keys")
+	}
+	if cfg.VAPIDSubject != "https://savvy.example.com" {
+		t.Errorf("Expected the base URL as default VAPID subject, got %q", cfg.VAPIDSubject)
+	}
+
+	t.Setenv("VAPID_PUBLIC_KEY", "public")
+	t.Setenv("VAPID_PRIVATE_KEY", "private")
+	t.Setenv("VAPID_SUBJECT", "mailto:admin@example.com")
+	cfg = Load()
+	if !cfg.IsWebPushEnabled() {
+		t.Error("Expected Web Push to be enabled with both VAPID keys")
+	}
+
+	cfg.Environment = "production"
+	cfg.SessionSecret = "this-is-a-very-secure-secret-key-with-more-than-32-chars"
+	if err := cfg.ValidateProduction(); err != nil {
+		t.Errorf("Expected valid production config, got %v", err)
+	}
+	cfg.VAPIDSubject = "http://localhost:3000"
+	if err := cfg.ValidateProduction(); err == nil || !contains(err.Error(), "VAPID_SUBJECT") {
+		t.Errorf("Expected VAPID_SUBJECT error in production, got %v", err)
+	}
 }
 
 // contains checks if a string contains a substring
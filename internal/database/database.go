@@ -72,6 +72,8 @@ func AutoMigrate() error {
 		&models.VoucherShare{},
 		&models.GiftCard{},
 		&models.GiftCardTransaction{},
+		&models.GiftCardTransactionRevision{},
+		&models.ExchangeRate{},
 		&models.GiftCardShare{},
 		&models.Merchant{},
 		&models.UserFavorite{},
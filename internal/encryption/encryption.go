@@ -0,0 +1,314 @@
+// Package encryption encrypts sensitive columns (gift card PINs, optionally
+// card numbers and voucher codes) at the application level.
+//
+// Keys are configured as a keyring of key-encryption keys (KEKs), each with
+// an ID. The first key encrypts new values; the others only decrypt, which
+// allows rotating keys and re-encrypting existing rows in the background.
+//
+// Secrets such as PINs use envelope encryption: every value gets a random
+// data key, which is stored wrapped with the KEK next to the ciphertext.
+// Identifiers (card numbers, voucher codes) are encrypted deterministically
+// with a data key derived from the KEK, so equal numbers produce equal
+// ciphertexts and the per-user unique indexes keep working.
+//
+// Stored values are self-describing:
+//
+//	enc:v1:<key id>:<wrapped data key>:<nonce+ciphertext>   secrets
+//	enc:d1:<key id>:<nonce+ciphertext>                      identifiers
+//
+// Values without the "enc:" prefix are plaintext rows written before
+// encryption was enabled and are returned unchanged.
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync/atomic"
+)
+
+const (
+	prefix              = "enc:"
+	formatEnvelope      = "v1"
+	formatDeterministic = "d1"
+
+	// KeySize is the length of a key-encryption key (AES-256)
+	KeySize = 32
+)
+
+var (
+	// ErrNoKey is returned when an encrypted value is read without a matching key
+	ErrNoKey = errors.New("encryption key not available")
+	// ErrInvalidCiphertext is returned for malformed or tampered values
+	ErrInvalidCiphertext = errors.New("invalid ciphertext")
+	// ErrInvalidKeyring is returned when the key configuration cannot be parsed
+	ErrInvalidKeyring = errors.New("invalid encryption keys")
+)
+
+var (
+	keyIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,32}$`)
+	encoding     = base64.RawURLEncoding
+)
+
+// Keyring holds the key-encryption keys by ID.
+type Keyring struct {
+	activeID           string
+	keys               map[string][]byte
+	encryptIdentifiers bool
+}
+
+// NewKeyring parses a key list like "2025-10:BASE64,2024-01:BASE64". The
+// first key is the active one. Keys are 32 random bytes, base64 encoded.
+// encryptIdentifiers enables encryption of card numbers and voucher codes.
+func NewKeyring(spec string, encryptIdentifiers bool) (*Keyring, error) {
+	keyring := &Keyring{keys: make(map[string][]byte), encryptIdentifiers: encryptIdentifiers}
+
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, encoded, ok := strings.Cut(entry, ":")
+		if !ok || !keyIDPattern.MatchString(id) {
+			return nil, fmt.Errorf("%w: expected ID:BASE64KEY, key IDs use letters, digits, - and _", ErrInvalidKeyring)
+		}
+		key, err := decodeKey(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("%w: key %s: %v", ErrInvalidKeyring, id, err)
+		}
+		if _, exists := keyring.keys[id]; exists {
+			return nil, fmt.Errorf("%w: duplicate key ID %s", ErrInvalidKeyring, id)
+		}
+		if keyring.activeID == "" {
+			keyring.activeID = id
+		}
+		keyring.keys[id] = key
+	}
+
+	if keyring.activeID == "" {
+		return nil, fmt.Errorf("%w: no key configured", ErrInvalidKeyring)
+	}
+	return keyring, nil
+}
+
+// decodeKey accepts standard and URL-safe base64, with or without padding
+func decodeKey(encoded string) ([]byte, error) {
+	encoded = strings.TrimRight(strings.TrimSpace(encoded), "=")
+	key, err := base64.RawStdEncoding.DecodeString(encoded)
+	if err != nil {
+		key, err = base64.RawURLEncoding.DecodeString(encoded)
+	}
+	if err != nil {
+		return nil, errors.New("not valid base64")
+	}
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("must be %d bytes, got %d", KeySize, len(key))
+	}
+	return key, nil
+}
+
+// GenerateKey returns a new random key in the format NewKeyring expects.
+func GenerateKey() (string, error) {
+	key := make([]byte, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// ActiveKeyID returns the ID of the key used for new values.
+func (k *Keyring) ActiveKeyID() string {
+	return k.activeID
+}
+
+// EncryptIdentifiers reports whether card numbers and voucher codes are encrypted.
+func (k *Keyring) EncryptIdentifiers() bool {
+	return k.encryptIdentifiers
+}
+
+// Encrypt encrypts a secret with a random data key wrapped by the active key.
+// aad binds the ciphertext to its column, e.g. "gift_cards.pin".
+func (k *Keyring) Encrypt(plaintext, aad string) (string, error) {
+	dataKey := make([]byte, KeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", err
+	}
+
+	wrapped, err := seal(k.keys[k.activeID], randomNonce, dataKey, []byte(k.activeID))
+	if err != nil {
+		return "", err
+	}
+	sealed, err := seal(dataKey, randomNonce, []byte(plaintext), []byte(aad))
+	if err != nil {
+		return "", err
+	}
+
+	return strings.Join([]string{prefix + formatEnvelope, k.activeID, encoding.EncodeToString(wrapped), encoding.EncodeToString(sealed)}, ":"), nil
+}
+
+// EncryptDeterministic encrypts an identifier so that equal plaintexts under
+// the same key and aad produce equal ciphertexts.
+func (k *Keyring) EncryptDeterministic(plaintext, aad string) (string, error) {
+	dataKey, nonceKey := deriveKeys(k.keys[k.activeID])
+	nonce := func(size int) ([]byte, error) {
+		mac := hmac.New(sha256.New, nonceKey)
+		mac.Write([]byte(aad))
+		mac.Write([]byte{0})
+		mac.Write([]byte(plaintext))
+		return mac.Sum(nil)[:size], nil
+	}
+
+	sealed, err := seal(dataKey, nonce, []byte(plaintext), []byte(aad))
+	if err != nil {
+		return "", err
+	}
+
+	return strings.Join([]string{prefix + formatDeterministic, k.activeID, encoding.EncodeToString(sealed)}, ":"), nil
+}
+
+// Decrypt decrypts a value written by Encrypt or EncryptDeterministic with
+// any key of the keyring. Plaintext values are returned unchanged.
+func (k *Keyring) Decrypt(value, aad string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+
+	parts := strings.Split(value, ":")
+	if len(parts) < 4 {
+		return "", ErrInvalidCiphertext
+	}
+	format, keyID := parts[1], parts[2]
+	if k == nil {
+		return "", ErrNoKey
+	}
+	key, ok := k.keys[keyID]
+	if !ok {
+		return "", fmt.Errorf("%w: key ID %s", ErrNoKey, keyID)
+	}
+
+	var plaintext []byte
+	switch {
+	case format == formatEnvelope && len(parts) == 5:
+		wrapped, err := encoding.DecodeString(parts[3])
+		if err != nil {
+			return "", ErrInvalidCiphertext
+		}
+		dataKey, err := open(key, wrapped, []byte(keyID))
+		if err != nil {
+			return "", err
+		}
+		sealed, err := encoding.DecodeString(parts[4])
+		if err != nil {
+			return "", ErrInvalidCiphertext
+		}
+		if plaintext, err = open(dataKey, sealed, []byte(aad)); err != nil {
+			return "", err
+		}
+	case format == formatDeterministic && len(parts) == 4:
+		dataKey, _ := deriveKeys(key)
+		sealed, err := encoding.DecodeString(parts[3])
+		if err != nil {
+			return "", ErrInvalidCiphertext
+		}
+		if plaintext, err = open(dataKey, sealed, []byte(aad)); err != nil {
+			return "", err
+		}
+	default:
+		return "", ErrInvalidCiphertext
+	}
+
+	return string(plaintext), nil
+}
+
+// IsEncrypted reports whether a stored value is encrypted.
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, prefix)
+}
+
+// KeyID returns the ID of the key a stored value is encrypted with.
+// Returns false for plaintext values.
+func KeyID(value string) (string, bool) {
+	if !IsEncrypted(value) {
+		return "", false
+	}
+	parts := strings.SplitN(value, ":", 4)
+	if len(parts) < 3 {
+		return "", false
+	}
+	return parts[2], true
+}
+
+// deriveKeys derives the data and nonce key for deterministic encryption
+func deriveKeys(kek []byte) (dataKey, nonceKey []byte) {
+	derive := func(label string) []byte {
+		mac := hmac.New(sha256.New, kek)
+		mac.Write([]byte(label))
+		return mac.Sum(nil)
+	}
+	return derive("savvy identifier data key"), derive("savvy identifier nonce key")
+}
+
+// randomNonce returns size random bytes
+func randomNonce(size int) ([]byte, error) {
+	nonce := make([]byte, size)
+	_, err := rand.Read(nonce)
+	return nonce, err
+}
+
+// seal encrypts with AES-256-GCM and returns nonce followed by ciphertext
+func seal(key []byte, nonceFn func(int) ([]byte, error), plaintext, aad []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := nonceFn(aead.NonceSize())
+	if err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+// open reverses seal
+func open(key, sealed, aad []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, ErrInvalidCiphertext
+	}
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], aad)
+	if err != nil {
+		return nil, ErrInvalidCiphertext
+	}
+	return plaintext, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// current is the keyring used by the GORM serializers
+var current atomic.Pointer[Keyring]
+
+// Init sets the keyring used for reading and writing encrypted columns.
+// A nil keyring disables encryption; encrypted values can then not be read.
+func Init(keyring *Keyring) {
+	current.Store(keyring)
+}
+
+// Current returns the configured keyring, or nil if encryption is disabled.
+func Current() *Keyring {
+	return current.Load()
+}
@@ -0,0 +1,246 @@
+package encryption
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm/schema"
+)
+
+const (
+	testKeyA = "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=" // "0123456789abcdef0123456789abcdef"
+	testKeyB = "ZmVkY2JhOTg3NjU0MzIxMGZlZGNiYTk4NzY1NDMyMTA=" // "fedcba9876543210fedcba9876543210"
+)
+
+func newTestKeyring(t *testing.T, spec string, identifiers bool) *Keyring {
+	t.Helper()
+	keyring, err := NewKeyring(spec, identifiers)
+	require.NoError(t, err)
+	return keyring
+}
+
+func TestNewKeyring(t *testing.T) {
+	keyring := newTestKeyring(t, " 2026-10:"+testKeyA+", 2025-01:"+strings.TrimRight(testKeyB, "="), true)
+	assert.Equal(t, "2026-10", keyring.ActiveKeyID())
+	assert.True(t, keyring.EncryptIdentifiers())
+	assert.Len(t, keyring.keys, 2)
+
+	for _, spec := range []string{
+		"",
+		testKeyA,
+		"k1:not-base64!",
+		"k1:c2hvcnQ=",
+		"k:1:" + testKeyA,
+		"k1:" + testKeyA + ",k1:" + testKeyB,
+	} {
+		_, err := NewKeyring(spec, false)
+		assert.ErrorIs(t, err, ErrInvalidKeyring, spec)
+	}
+}
+
+func TestGenerateKey(t *testing.T) {
+	key, err := GenerateKey()
+	require.NoError(t, err)
+
+	_, err = NewKeyring("k1:"+key, false)
+	assert.NoError(t, err)
+}
+
+func TestEncrypt_RoundTrip(t *testing.T) {
+	keyring := newTestKeyring(t, "k1:"+testKeyA, false)
+
+	first, err := keyring.Encrypt("1234", "gift_cards.pin")
+	require.NoError(t, err)
+	second, err := keyring.Encrypt("1234", "gift_cards.pin")
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(first, "enc:v1:k1:"))
+	assert.NotContains(t, first, "1234")
+	assert.NotEqual(t, first, second, "secrets use a random data key per value")
+
+	plaintext, err := keyring.Decrypt(first, "gift_cards.pin")
+	require.NoError(t, err)
+	assert.Equal(t, "1234", plaintext)
+
+	// Ciphertexts are bound to their column
+	_, err = keyring.Decrypt(first, "cards.card_number")
+	assert.ErrorIs(t, err, ErrInvalidCiphertext)
+}
+
+func TestEncryptDeterministic(t *testing.T) {
+	keyring := newTestKeyring(t, "k1:"+testKeyA, true)
+
+	first, err := keyring.EncryptDeterministic("2099123456789", "cards.card_number")
+	require.NoError(t, err)
+	second, err := keyring.EncryptDeterministic("2099123456789", "cards.card_number")
+	require.NoError(t, err)
+	other, err := keyring.EncryptDeterministic("2099123456789", "gift_cards.card_number")
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(first, "enc:d1:k1:"))
+	assert.Equal(t, first, second, "equal numbers must keep colliding in unique indexes")
+	assert.NotEqual(t, first, other)
+
+	plaintext, err := keyring.Decrypt(first, "cards.card_number")
+	require.NoError(t, err)
+	assert.Equal(t, "2099123456789", plaintext)
+}
+
+func TestDecrypt(t *testing.T) {
+	old := newTestKeyring(t, "old:"+testKeyA, false)
+	ciphertext, err := old.Encrypt("9876", "gift_cards.pin")
+	require.NoError(t, err)
+
+	// Rotated keyring still reads values of the previous key
+	rotated := newTestKeyring(t, "new:"+testKeyB+",old:"+testKeyA, false)
+	plaintext, err := rotated.Decrypt(ciphertext, "gift_cards.pin")
+	require.NoError(t, err)
+	assert.Equal(t, "9876", plaintext)
+
+	// Plaintext rows from before encryption pass through
+	plaintext, err = rotated.Decrypt("legacy-pin", "gift_cards.pin")
+	require.NoError(t, err)
+	assert.Equal(t, "legacy-pin", plaintext)
+
+	// Unknown key and missing keyring
+	_, err = newTestKeyring(t, "new:"+testKeyB, false).Decrypt(ciphertext, "gift_cards.pin")
+	assert.ErrorIs(t, err, ErrNoKey)
+	var none *Keyring
+	_, err = none.Decrypt(ciphertext, "gift_cards.pin")
+	assert.ErrorIs(t, err, ErrNoKey)
+
+	// Tampered and malformed values
+	tampered := ciphertext[:len(ciphertext)-2] + "AA"
+	_, err = old.Decrypt(tampered, "gift_cards.pin")
+	assert.ErrorIs(t, err, ErrInvalidCiphertext)
+	_, err = old.Decrypt("enc:v9:old:abc", "gift_cards.pin")
+	assert.ErrorIs(t, err, ErrInvalidCiphertext)
+}
+
+func TestKeyID(t *testing.T) {
+	keyring := newTestKeyring(t, "k7:"+testKeyA, true)
+	ciphertext, err := keyring.Encrypt("1234", "gift_cards.pin")
+	require.NoError(t, err)
+
+	keyID, ok := KeyID(ciphertext)
+	assert.True(t, ok)
+	assert.Equal(t, "k7", keyID)
+
+	_, ok = KeyID("1234")
+	assert.False(t, ok)
+}
+
+func TestRewrap(t *testing.T) {
+	old := newTestKeyring(t, "old:"+testKeyA, true)
+	rotated := newTestKeyring(t, "new:"+testKeyB+",old:"+testKeyA, true)
+
+	stored, err := old.Encrypt("1234", "gift_cards.pin")
+	require.NoError(t, err)
+
+	value, changed, err := rotated.Rewrap(stored, "gift_cards.pin", false)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	keyID, _ := KeyID(value)
+	assert.Equal(t, "new", keyID)
+
+	// Already current
+	_, changed, err = rotated.Rewrap(value, "gift_cards.pin", false)
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	// Plaintext identifier gets encrypted deterministically
+	value, changed, err = rotated.Rewrap("2099123456789", "cards.card_number", true)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.True(t, strings.HasPrefix(value, "enc:d1:new:"))
+
+	// Identifier encryption switched off decrypts again
+	plainOnly := newTestKeyring(t, "new:"+testKeyB, false)
+	value, changed, err = plainOnly.Rewrap(value, "cards.card_number", true)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, "2099123456789", value)
+
+	// Empty values stay empty
+	value, changed, err = rotated.Rewrap("", "gift_cards.pin", false)
+	require.NoError(t, err)
+	assert.False(t, changed)
+	assert.Empty(t, value)
+}
+
+type serializerModel struct {
+	ID         uint
+	PIN        string `gorm:"serializer:encrypted"`
+	CardNumber string `gorm:"serializer:encrypted_identifier"`
+}
+
+func (serializerModel) TableName() string { return "gift_cards" }
+
+func TestSerializer(t *testing.T) {
+	s, err := schema.Parse(&serializerModel{}, &sync.Map{}, schema.NamingStrategy{})
+	require.NoError(t, err)
+	pinField := s.LookUpField("PIN")
+	numberField := s.LookUpField("CardNumber")
+	ctx := context.Background()
+
+	model := &serializerModel{}
+	dst := reflect.ValueOf(model).Elem()
+
+	t.Cleanup(func() { Init(nil) })
+
+	// Without keyring values are stored as they are
+	Init(nil)
+	value, err := pinField.Serializer.Value(ctx, pinField, dst, "1234")
+	require.NoError(t, err)
+	assert.Equal(t, "1234", value)
+
+	Init(newTestKeyring(t, "k1:"+testKeyA, false))
+	value, err = pinField.Serializer.Value(ctx, pinField, dst, "1234")
+	require.NoError(t, err)
+	assert.True(t, IsEncrypted(value.(string)))
+
+	require.NoError(t, pinField.Serializer.Scan(ctx, pinField, dst, []byte(value.(string))))
+	assert.Equal(t, "1234", model.PIN)
+
+	// Identifiers are only encrypted when enabled
+	value, err = numberField.Serializer.Value(ctx, numberField, dst, "2099123456789")
+	require.NoError(t, err)
+	assert.Equal(t, "2099123456789", value)
+
+	Init(newTestKeyring(t, "k1:"+testKeyA, true))
+	value, err = numberField.Serializer.Value(ctx, numberField, dst, "2099123456789")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(value.(string), "enc:d1:"))
+
+	require.NoError(t, numberField.Serializer.Scan(ctx, numberField, dst, value))
+	assert.Equal(t, "2099123456789", model.CardNumber)
+
+	// Empty PIN stays empty, NULL scans as empty
+	value, err = pinField.Serializer.Value(ctx, pinField, dst, "")
+	require.NoError(t, err)
+	assert.Equal(t, "", value)
+	require.NoError(t, pinField.Serializer.Scan(ctx, pinField, dst, nil))
+	assert.Empty(t, model.PIN)
+}
+
+func TestIdentifierValue(t *testing.T) {
+	t.Cleanup(func() { Init(nil) })
+
+	Init(nil)
+	value, err := IdentifierValue("cards", "card_number", "2099123456789")
+	require.NoError(t, err)
+	assert.Equal(t, "2099123456789", value)
+
+	keyring := newTestKeyring(t, "k1:"+testKeyA, true)
+	Init(keyring)
+	value, err = IdentifierValue("cards", "card_number", "2099123456789")
+	require.NoError(t, err)
+	expected, err := keyring.EncryptDeterministic("2099123456789", "cards.card_number")
+	require.NoError(t, err)
+	assert.Equal(t, expected, value)
+}
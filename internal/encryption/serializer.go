@@ -0,0 +1,119 @@
+package encryption
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm/schema"
+)
+
+// GORM serializer names for encrypted string fields, used as
+// `gorm:"serializer:encrypted"` in model tags
+const (
+	SerializerSecret     = "encrypted"            // Always encrypted when a keyring is configured
+	SerializerIdentifier = "encrypted_identifier" // Encrypted if ENCRYPT_IDENTIFIERS is enabled
+)
+
+func init() {
+	schema.RegisterSerializer(SerializerSecret, Serializer{})
+	schema.RegisterSerializer(SerializerIdentifier, Serializer{Identifier: true})
+}
+
+// Serializer encrypts string fields on write and decrypts them on read
+// using the keyring set with Init.
+type Serializer struct {
+	Identifier bool // Deterministic encryption, only if enabled in the keyring
+}
+
+// Scan decrypts the column value into the field.
+func (s Serializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	var stored string
+	switch v := dbValue.(type) {
+	case nil:
+	case string:
+		stored = v
+	case []byte:
+		stored = string(v)
+	default:
+		return fmt.Errorf("encryption: unsupported column type %T for %s", dbValue, field.Name)
+	}
+
+	plaintext, err := Current().Decrypt(stored, ColumnAAD(field.Schema.Table, field.DBName))
+	if err != nil {
+		return fmt.Errorf("decrypt %s.%s: %w", field.Schema.Table, field.DBName, err)
+	}
+
+	field.ReflectValueOf(ctx, dst).SetString(plaintext)
+	return nil
+}
+
+// Value encrypts the field value for storage.
+func (s Serializer) Value(_ context.Context, field *schema.Field, _ reflect.Value, fieldValue interface{}) (interface{}, error) {
+	plaintext, ok := fieldValue.(string)
+	if !ok {
+		return nil, fmt.Errorf("encryption: %s must be a string", field.Name)
+	}
+
+	keyring := Current()
+	if plaintext == "" || keyring == nil {
+		return plaintext, nil
+	}
+
+	aad := ColumnAAD(field.Schema.Table, field.DBName)
+	if s.Identifier {
+		if !keyring.EncryptIdentifiers() {
+			return plaintext, nil
+		}
+		return keyring.EncryptDeterministic(plaintext, aad)
+	}
+	return keyring.Encrypt(plaintext, aad)
+}
+
+// ColumnAAD returns the additional authenticated data binding a ciphertext
+// to its column, so values cannot be moved between columns.
+func ColumnAAD(table, column string) string {
+	return strings.ToLower(table + "." + column)
+}
+
+// Rewrap returns how a stored value should be stored with the current
+// configuration: encrypted with the active key, or as plaintext for
+// identifiers when identifier encryption is disabled. changed is false if
+// the value is already stored that way.
+func (k *Keyring) Rewrap(stored, aad string, identifier bool) (value string, changed bool, err error) {
+	plaintext, err := k.Decrypt(stored, aad)
+	if err != nil {
+		return "", false, err
+	}
+
+	if plaintext == "" || (identifier && !k.encryptIdentifiers) {
+		return plaintext, plaintext != stored, nil
+	}
+
+	wantFormat := prefix + formatEnvelope + ":"
+	if identifier {
+		wantFormat = prefix + formatDeterministic + ":"
+	}
+	if keyID, ok := KeyID(stored); ok && keyID == k.activeID && strings.HasPrefix(stored, wantFormat) {
+		return stored, false, nil
+	}
+
+	if identifier {
+		value, err = k.EncryptDeterministic(plaintext, aad)
+	} else {
+		value, err = k.Encrypt(plaintext, aad)
+	}
+	return value, err == nil, err
+}
+
+// IdentifierValue returns an identifier as it is stored in the database, for
+// queries like Where("card_number = ?", ...) that bypass the serializers.
+// Matches only rows written with the active key.
+func IdentifierValue(table, column, plaintext string) (string, error) {
+	keyring := Current()
+	if plaintext == "" || keyring == nil || !keyring.EncryptIdentifiers() {
+		return plaintext, nil
+	}
+	return keyring.EncryptDeterministic(plaintext, ColumnAAD(table, column))
+}
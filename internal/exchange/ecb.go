@@ -0,0 +1,137 @@
+package exchange
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ECBDailyURL is the ECB euro foreign exchange reference rates feed
+const ECBDailyURL = "https://www.ecb.europa.eu/stats/eurofxref/eurofxref-daily.xml"
+
+const (
+	ecbCacheTTL     = 6 * time.Hour // The feed is updated once per working day
+	ecbFetchTimeout = 10 * time.Second
+	maxECBFeedSize  = 1 << 20 // 1 MiB
+)
+
+// ECBProvider loads the ECB reference rates (base EUR) from a URL or a local
+// file and caches them. If a refresh fails, the last rates are served.
+type ECBProvider struct {
+	source     string // http(s) URL, file:// URL or file path
+	ttl        time.Duration
+	httpClient *http.Client
+
+	mu        sync.Mutex
+	rates     *Rates
+	fetchedAt time.Time
+}
+
+// NewECBProvider creates a provider reading the feed from source.
+func NewECBProvider(source string, ttl time.Duration) *ECBProvider {
+	if source == "" {
+		source = ECBDailyURL
+	}
+	return &ECBProvider{
+		source:     source,
+		ttl:        ttl,
+		httpClient: &http.Client{Timeout: ecbFetchTimeout},
+	}
+}
+
+// Rates returns the cached rates, refreshing them when the TTL has passed.
+func (p *ECBProvider) Rates(ctx context.Context) (*Rates, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.rates != nil && time.Since(p.fetchedAt) < p.ttl {
+		return p.rates, nil
+	}
+
+	rates, err := p.fetch(ctx)
+	if err != nil {
+		if p.rates != nil {
+			return p.rates, nil
+		}
+		return nil, err
+	}
+
+	p.rates = rates
+	p.fetchedAt = time.Now()
+	return rates, nil
+}
+
+// fetch reads and parses the feed
+func (p *ECBProvider) fetch(ctx context.Context) (*Rates, error) {
+	if !strings.HasPrefix(p.source, "http://") && !strings.HasPrefix(p.source, "https://") {
+		file, err := os.Open(strings.TrimPrefix(p.source, "file://"))
+		if err != nil {
+			return nil, fmt.Errorf("ecb rates: %w", err)
+		}
+		defer func() { _ = file.Close() }()
+		return ParseECB(io.LimitReader(file, maxECBFeedSize))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.source, nil)
+	if err != nil {
+		return nil, fmt.Errorf("ecb rates: %w", err)
+	}
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("ecb rates: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ecb rates: unexpected status %d", resp.StatusCode)
+	}
+	return ParseECB(io.LimitReader(resp.Body, maxECBFeedSize))
+}
+
+// ecbEnvelope mirrors the nested Cube elements of the ECB feed
+type ecbEnvelope struct {
+	Days []struct {
+		Time  string `xml:"time,attr"`
+		Rates []struct {
+			Currency string `xml:"currency,attr"`
+			Rate     string `xml:"rate,attr"`
+		} `xml:"Cube"`
+	} `xml:"Cube>Cube"`
+}
+
+// ParseECB parses an ECB reference rates document. If it contains several
+// days (the historical feeds), the first and most recent one is used.
+func ParseECB(r io.Reader) (*Rates, error) {
+	var envelope ecbEnvelope
+	if err := xml.NewDecoder(r).Decode(&envelope); err != nil {
+		return nil, fmt.Errorf("ecb rates: %w", err)
+	}
+	if len(envelope.Days) == 0 || len(envelope.Days[0].Rates) == 0 {
+		return nil, errors.New("ecb rates: feed contains no rates")
+	}
+
+	day := envelope.Days[0]
+	date, err := time.Parse("2006-01-02", day.Time)
+	if err != nil {
+		return nil, fmt.Errorf("ecb rates: invalid date %q", day.Time)
+	}
+
+	rates := make(map[string]float64, len(day.Rates))
+	for _, entry := range day.Rates {
+		rate, err := strconv.ParseFloat(entry.Rate, 64)
+		if err != nil || rate <= 0 {
+			return nil, fmt.Errorf("ecb rates: invalid rate for %s: %q", entry.Currency, entry.Rate)
+		}
+		rates[strings.ToUpper(entry.Currency)] = rate
+	}
+
+	return &Rates{Base: "EUR", Rates: rates, Date: date, Source: ProviderECB}, nil
+}
@@ -0,0 +1,157 @@
+// Package exchange provides currency exchange rates for converting money
+// amounts into a user's display currency.
+//
+// Rates come from a Provider: a static table (configuration and admin UI)
+// or the daily reference rates feed of the European Central Bank.
+package exchange
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"savvy/internal/config"
+	"savvy/internal/money"
+)
+
+// Provider names used in EXCHANGE_RATE_PROVIDER
+const (
+	ProviderStatic = "static"
+	ProviderECB    = "ecb"
+)
+
+// ErrUnknownCurrency is returned when no rate exists for a currency
+var ErrUnknownCurrency = errors.New("no exchange rate for currency")
+
+// Rates is a snapshot of exchange rates relative to a base currency.
+type Rates struct {
+	Base   string             // ISO 4217 code of the base currency
+	Rates  map[string]float64 // Units of the currency per one unit of Base
+	Date   time.Time          // Date the rates were published or last changed
+	Source string             // Provider name, e.g. "static" or "ecb"
+}
+
+// Rate returns the units of currency per one unit of the base currency.
+func (r *Rates) Rate(currency string) (float64, bool) {
+	currency = strings.ToUpper(currency)
+	if currency == r.Base {
+		return 1, true
+	}
+	rate, ok := r.Rates[currency]
+	return rate, ok && rate > 0
+}
+
+// Convert converts m into the target currency, rounding to its minor unit.
+// Returns ErrUnknownCurrency if a rate for either currency is missing.
+func (r *Rates) Convert(m money.Money, to string) (money.Money, error) {
+	to = strings.ToUpper(to)
+	from := strings.ToUpper(m.Currency)
+	if from == to {
+		return money.New(m.Amount, to), nil
+	}
+
+	fromRate, ok := r.Rate(from)
+	if !ok {
+		return money.Money{}, fmt.Errorf("%w: %s", ErrUnknownCurrency, from)
+	}
+	toRate, ok := r.Rate(to)
+	if !ok {
+		return money.Money{}, fmt.Errorf("%w: %s", ErrUnknownCurrency, to)
+	}
+
+	scale := math.Pow10(money.Exponent(to) - money.Exponent(from))
+	return money.New(int64(math.Round(float64(m.Amount)*toRate/fromRate*scale)), to), nil
+}
+
+// Currencies returns the base currency and all currencies with a rate, sorted.
+func (r *Rates) Currencies() []string {
+	currencies := []string{r.Base}
+	for currency := range r.Rates {
+		if currency != r.Base {
+			currencies = append(currencies, currency)
+		}
+	}
+	sort.Strings(currencies)
+	return currencies
+}
+
+// Provider supplies the current exchange rates.
+type Provider interface {
+	Rates(ctx context.Context) (*Rates, error)
+}
+
+// StaticProvider serves a fixed rate table.
+type StaticProvider struct {
+	base      string
+	rates     map[string]float64
+	updatedAt time.Time
+}
+
+// NewStaticProvider creates a provider for the given rates relative to base.
+func NewStaticProvider(base string, rates map[string]float64) *StaticProvider {
+	return &StaticProvider{
+		base:      strings.ToUpper(base),
+		rates:     rates,
+		updatedAt: time.Now(),
+	}
+}
+
+// Base returns the base currency of the rate table.
+func (p *StaticProvider) Base() string {
+	return p.base
+}
+
+// Rates returns a copy of the rate table.
+func (p *StaticProvider) Rates(_ context.Context) (*Rates, error) {
+	rates := make(map[string]float64, len(p.rates))
+	for currency, rate := range p.rates {
+		rates[currency] = rate
+	}
+	return &Rates{Base: p.base, Rates: rates, Date: p.updatedAt, Source: ProviderStatic}, nil
+}
+
+// ParseRateList parses a rate list like "EUR=1.07,USD=1.16".
+func ParseRateList(s string) (map[string]float64, error) {
+	rates := make(map[string]float64)
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		currency, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid exchange rate %q, expected CUR=rate", part)
+		}
+		currency = strings.ToUpper(strings.TrimSpace(currency))
+		if len(currency) != 3 {
+			return nil, fmt.Errorf("invalid currency code %q", currency)
+		}
+		rate, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || rate <= 0 {
+			return nil, fmt.Errorf("invalid rate for %s: %q", currency, value)
+		}
+		rates[currency] = rate
+	}
+	return rates, nil
+}
+
+// NewProvider creates the provider selected in cfg.
+func NewProvider(cfg *config.Config) (Provider, error) {
+	switch strings.ToLower(cfg.ExchangeRateProvider) {
+	case "", ProviderStatic:
+		rates, err := ParseRateList(cfg.ExchangeRates)
+		if err != nil {
+			return nil, fmt.Errorf("EXCHANGE_RATES: %w", err)
+		}
+		return NewStaticProvider(cfg.ExchangeRateBase, rates), nil
+	case ProviderECB:
+		return NewECBProvider(cfg.ExchangeRateECBURL, ecbCacheTTL), nil
+	default:
+		return nil, fmt.Errorf("unknown exchange rate provider %q", cfg.ExchangeRateProvider)
+	}
+}
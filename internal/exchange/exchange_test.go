@@ -0,0 +1,174 @@
+package exchange
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"savvy/internal/config"
+	"savvy/internal/money"
+)
+
+func TestRates_Convert(t *testing.T) {
+	rates := &Rates{Base: "CHF", Rates: map[string]float64{"EUR": 1.07, "USD": 1.25, "JPY": 180}}
+
+	tests := []struct {
+		name string
+		in   money.Money
+		to   string
+		want money.Money
+	}{
+		{"same currency", money.New(1250, "CHF"), "CHF", money.New(1250, "CHF")},
+		{"from base", money.New(10000, "CHF"), "EUR", money.New(10700, "EUR")},
+		{"to base", money.New(10700, "EUR"), "CHF", money.New(10000, "CHF")},
+		{"cross rate", money.New(10700, "EUR"), "USD", money.New(12500, "USD")},
+		{"to zero decimals", money.New(1000, "CHF"), "JPY", money.New(1800, "JPY")},
+		{"from zero decimals", money.New(1800, "JPY"), "CHF", money.New(1000, "CHF")},
+		{"lowercase target", money.New(10000, "CHF"), "eur", money.New(10700, "EUR")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := rates.Convert(tt.in, tt.to)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRates_Convert_UnknownCurrency(t *testing.T) {
+	rates := &Rates{Base: "CHF", Rates: map[string]float64{"EUR": 1.07}}
+
+	_, err := rates.Convert(money.New(100, "GBP"), "CHF")
+	assert.ErrorIs(t, err, ErrUnknownCurrency)
+
+	_, err = rates.Convert(money.New(100, "CHF"), "GBP")
+	assert.ErrorIs(t, err, ErrUnknownCurrency)
+}
+
+func TestRates_Currencies(t *testing.T) {
+	rates := &Rates{Base: "EUR", Rates: map[string]float64{"USD": 1.16, "CHF": 0.93}}
+	assert.Equal(t, []string{"CHF", "EUR", "USD"}, rates.Currencies())
+}
+
+func TestParseRateList(t *testing.T) {
+	rates, err := ParseRateList(" eur=1.07, USD = 1.16 ,")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]float64{"EUR": 1.07, "USD": 1.16}, rates)
+
+	rates, err = ParseRateList("")
+	require.NoError(t, err)
+	assert.Empty(t, rates)
+
+	for _, input := range []string{"EUR", "EURO=1.0", "EUR=abc", "EUR=0", "USD=-1"} {
+		_, err := ParseRateList(input)
+		assert.Error(t, err, input)
+	}
+}
+
+func TestParseECB(t *testing.T) {
+	file, err := os.Open(filepath.Join("testdata", "eurofxref-daily.xml"))
+	require.NoError(t, err)
+	defer func() { _ = file.Close() }()
+
+	rates, err := ParseECB(file)
+	require.NoError(t, err)
+
+	assert.Equal(t, "EUR", rates.Base)
+	assert.Equal(t, ProviderECB, rates.Source)
+	assert.Equal(t, time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC), rates.Date)
+	assert.Len(t, rates.Rates, 5)
+	assert.InDelta(t, 0.935, rates.Rates["CHF"], 1e-9)
+
+	converted, err := rates.Convert(money.New(9350, "CHF"), "EUR")
+	require.NoError(t, err)
+	assert.Equal(t, money.New(10000, "EUR"), converted)
+}
+
+func TestParseECB_Invalid(t *testing.T) {
+	for _, doc := range []string{
+		"not xml",
+		`<Envelope><Cube></Cube></Envelope>`,
+		`<Envelope><Cube><Cube time="yesterday"><Cube currency="USD" rate="1.1"/></Cube></Cube></Envelope>`,
+		`<Envelope><Cube><Cube time="2026-10-15"><Cube currency="USD" rate="x"/></Cube></Cube></Envelope>`,
+	} {
+		_, err := ParseECB(strings.NewReader(doc))
+		assert.Error(t, err, doc)
+	}
+}
+
+func TestECBProvider_File(t *testing.T) {
+	provider := NewECBProvider("file://"+filepath.Join("testdata", "eurofxref-daily.xml"), time.Hour)
+
+	rates, err := provider.Rates(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "EUR", rates.Base)
+	assert.InDelta(t, 1.165, rates.Rates["USD"], 1e-9)
+}
+
+func TestECBProvider_HTTPCachesAndServesStaleRates(t *testing.T) {
+	fixture, err := os.ReadFile(filepath.Join("testdata", "eurofxref-daily.xml"))
+	require.NoError(t, err)
+
+	requests := 0
+	failing := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests++
+		if failing {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write(fixture)
+	}))
+	defer server.Close()
+
+	provider := NewECBProvider(server.URL, time.Hour)
+	ctx := context.Background()
+
+	_, err = provider.Rates(ctx)
+	require.NoError(t, err)
+	_, err = provider.Rates(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, requests, "second call is served from the cache")
+
+	// Expired cache and a failing feed fall back to the last rates
+	provider.ttl = 0
+	failing = true
+	rates, err := provider.Rates(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, requests)
+	assert.Equal(t, "EUR", rates.Base)
+}
+
+func TestECBProvider_Unavailable(t *testing.T) {
+	provider := NewECBProvider(filepath.Join("testdata", "missing.xml"), time.Hour)
+
+	_, err := provider.Rates(context.Background())
+	assert.Error(t, err)
+}
+
+func TestNewProvider(t *testing.T) {
+	provider, err := NewProvider(&config.Config{ExchangeRateBase: "chf", ExchangeRates: "EUR=1.07"})
+	require.NoError(t, err)
+	rates, err := provider.Rates(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "CHF", rates.Base)
+	assert.Equal(t, ProviderStatic, rates.Source)
+	assert.InDelta(t, 1.07, rates.Rates["EUR"], 1e-9)
+
+	provider, err = NewProvider(&config.Config{ExchangeRateProvider: "ecb", ExchangeRateECBURL: "testdata/eurofxref-daily.xml"})
+	require.NoError(t, err)
+	assert.IsType(t, &ECBProvider{}, provider)
+
+	_, err = NewProvider(&config.Config{ExchangeRateProvider: "static", ExchangeRates: "EUR"})
+	assert.Error(t, err)
+
+	_, err = NewProvider(&config.Config{ExchangeRateProvider: "oanda"})
+	assert.Error(t, err)
+}
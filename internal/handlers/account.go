@@ -0,0 +1,345 @@
+// Package handlers contains HTTP request handlers for the savvy system.
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"savvy/internal/audit"
+	"savvy/internal/backup"
+	"savvy/internal/middleware"
+	"savvy/internal/models"
+	"savvy/internal/services"
+	"savvy/internal/templates"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// maxBackupSize limits uploaded account export archives
+const maxBackupSize = 50 << 20 // 50 MiB
+
+// AccountHandler handles the account settings page
+type AccountHandler struct {
+	apiTokenService     services.APITokenServiceInterface
+	backupService       services.BackupServiceInterface
+	userService         services.UserServiceInterface
+	exchangeRateService services.ExchangeRateServiceInterface
+	sessionService      services.SessionServiceInterface
+	accountEmailService services.AccountEmailServiceInterface
+}
+
+// NewAccountHandler creates a new account handler
+func NewAccountHandler(
+	apiTokenService services.APITokenServiceInterface,
+	backupService services.BackupServiceInterface,
+	userService services.UserServiceInterface,
+	exchangeRateService services.ExchangeRateServiceInterface,
+	sessionService services.SessionServiceInterface,
+	accountEmailService services.AccountEmailServiceInterface,
+) *AccountHandler {
+	return &AccountHandler{
+		apiTokenService:     apiTokenService,
+		backupService:       backupService,
+		userService:         userService,
+		exchangeRateService: exchangeRateService,
+		sessionService:      sessionService,
+		accountEmailService: accountEmailService,
+	}
+}
+
+// Show displays the account page
+// GET /account
+func (h *AccountHandler) Show(c echo.Context) error {
+	return h.render(c, templates.AccountPageData{})
+}
+
+// UpdateDisplayCurrency sets the currency dashboard totals are converted into
+// POST /account/display-currency
+func (h *AccountHandler) UpdateDisplayCurrency(c echo.Context) error {
+	user := c.Get("current_user").(*models.User)
+
+	// Empty selects the base currency of the exchange rates
+	currency := strings.ToUpper(strings.TrimSpace(c.FormValue("display_currency")))
+	if currency != "" {
+		rates, err := h.exchangeRateService.GetRates(c.Request().Context())
+		if err != nil || !slices.Contains(rates.Currencies(), currency) {
+			return h.render(c, templates.AccountPageData{Error: "account.preferences.error_currency"})
+		}
+	}
+
+	user.DisplayCurrency = currency
+	ctx := audit.AddUserIDToContext(c.Request().Context(), user.ID)
+	if err := h.userService.UpdateUser(ctx, user); err != nil {
+		c.Logger().Errorf("Failed to update display currency for user %s: %v", user.ID, err)
+		return h.render(c, templates.AccountPageData{Error: "account.preferences.error_save"})
+	}
+
+	return h.render(c, templates.AccountPageData{Notice: "account.preferences.saved"})
+}
+
+// CreateAPIToken creates a personal API token and shows its plaintext once
+// POST /account/api-tokens
+func (h *AccountHandler) CreateAPIToken(c echo.Context) error {
+	user := c.Get("current_user").(*models.User)
+
+	// Tokens would outlive the impersonation session
+	if c.Get("is_impersonating") != nil {
+		return h.render(c, templates.AccountPageData{Error: "account.api_tokens.error_impersonating"})
+	}
+
+	scopes := make(map[string]string, len(models.APIScopeResources))
+	for _, resource := range models.APIScopeResources {
+		scopes[resource] = c.FormValue("scope_" + resource)
+	}
+
+	var expiresAt *time.Time
+	if value := c.FormValue("expires_in_days"); value != "" && value != "0" {
+		days, err := strconv.Atoi(value)
+		if err != nil || days < 0 {
+			return h.render(c, templates.AccountPageData{Error: "account.api_tokens.error_expiry"})
+		}
+		expiry := time.Now().AddDate(0, 0, days)
+		expiresAt = &expiry
+	}
+
+	plaintext, _, err := h.apiTokenService.CreateToken(c.Request().Context(), user.ID, c.FormValue("name"), scopes, expiresAt)
+	if err != nil {
+		msg := "account.api_tokens.error_create"
+		if errors.Is(err, services.ErrInvalidAPITokenScope) {
+			msg = "account.api_tokens.error_scope"
+		}
+		return h.render(c, templates.AccountPageData{Error: msg})
+	}
+
+	return h.render(c, templates.AccountPageData{NewAPIToken: plaintext})
+}
+
+// RevokeAPIToken revokes a personal API token of the current user
+// DELETE /account/api-tokens/:id
+func (h *AccountHandler) RevokeAPIToken(c echo.Context) error {
+	user := c.Get("current_user").(*models.User)
+
+	tokenID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid token ID")
+	}
+
+	if err := h.apiTokenService.RevokeToken(c.Request().Context(), tokenID, user.ID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.String(http.StatusNotFound, "Token not found")
+		}
+		return c.String(http.StatusInternalServerError, "Failed to revoke token")
+	}
+
+	// Empty response removes the row (hx-swap="outerHTML")
+	return c.String(http.StatusOK, "")
+}
+
+// RevokeSession logs out one device of the current user. Revoking the
+// current session logs out like /auth/logout.
+// DELETE /account/sessions/:id
+func (h *AccountHandler) RevokeSession(c echo.Context) error {
+	user := c.Get("current_user").(*models.User)
+
+	// The sessions belong to the impersonated user
+	if c.Get("is_impersonating") != nil {
+		return c.String(http.StatusForbidden, "Not allowed while impersonating")
+	}
+
+	sessionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid session ID")
+	}
+
+	sessions, err := h.sessionService.ListSessions(c.Request().Context(), user.ID)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Failed to load sessions")
+	}
+	isCurrent := false
+	currentHash := middleware.CurrentSessionHash(c)
+	for _, session := range sessions {
+		if session.ID == sessionID && session.TokenHash == currentHash {
+			isCurrent = true
+		}
+	}
+
+	if err := h.sessionService.RevokeSession(c.Request().Context(), sessionID, user.ID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.String(http.StatusNotFound, "Session not found")
+		}
+		return c.String(http.StatusInternalServerError, "Failed to revoke session")
+	}
+
+	if isCurrent {
+		return logoutAfterRevoke(c)
+	}
+
+	// Empty response removes the row (hx-swap="outerHTML")
+	return c.String(http.StatusOK, "")
+}
+
+// RevokeAllSessions logs out all devices of the current user, including this one
+// POST /account/sessions/revoke-all
+func (h *AccountHandler) RevokeAllSessions(c echo.Context) error {
+	user := c.Get("current_user").(*models.User)
+
+	if c.Get("is_impersonating") != nil {
+		return h.render(c, templates.AccountPageData{Error: "account.sessions.error_impersonating"})
+	}
+
+	count, err := h.sessionService.RevokeAllSessions(c.Request().Context(), user.ID)
+	if err != nil {
+		c.Logger().Errorf("Failed to revoke sessions of user %s: %v", user.ID, err)
+		return h.render(c, templates.AccountPageData{Error: "account.sessions.error_revoke"})
+	}
+	c.Logger().Printf("Logged out %d sessions of user %s", count, user.Email)
+
+	return logoutAfterRevoke(c)
+}
+
+// ResendVerification emails a new link that confirms the address of the current user
+// POST /account/verify-email
+func (h *AccountHandler) ResendVerification(c echo.Context) error {
+	user := c.Get("current_user").(*models.User)
+
+	if user.IsEmailVerified() {
+		return h.render(c, templates.AccountPageData{})
+	}
+	if err := h.accountEmailService.SendVerification(c.Request().Context(), user); err != nil {
+		c.Logger().Errorf("Failed to send verification email to user %s: %v", user.ID, err)
+		return h.render(c, templates.AccountPageData{Error: "account.email.error_send"})
+	}
+	return h.render(c, templates.AccountPageData{Notice: "account.email.sent"})
+}
+
+// logoutAfterRevoke clears the cookie of the already deleted current session
+// and sends the browser to the login page
+func logoutAfterRevoke(c echo.Context) error {
+	session, _ := middleware.GetSession(c)
+	session.Options.MaxAge = -1
+	if err := session.Save(c.Request(), c.Response()); err != nil {
+		c.Logger().Errorf("Failed to clear session cookie: %v", err)
+	}
+
+	if c.Request().Header.Get("HX-Request") == "true" {
+		c.Response().Header().Set("HX-Redirect", "/auth/login")
+		return c.NoContent(http.StatusOK)
+	}
+	return c.Redirect(http.StatusSeeOther, "/auth/login")
+}
+
+// Export downloads all items owned by the current user as a ZIP archive
+// GET /account/export
+func (h *AccountHandler) Export(c echo.Context) error {
+	user := c.Get("current_user").(*models.User)
+
+	// The archive contains PINs and codes of the impersonated user
+	if c.Get("is_impersonating") != nil {
+		return echo.NewHTTPError(http.StatusForbidden, "Export während der Impersonation nicht möglich")
+	}
+
+	archive, err := h.backupService.ExportUserData(c.Request().Context(), user.ID)
+	if err != nil {
+		c.Logger().Errorf("Account export failed for user %s: %v", user.ID, err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Fehler beim Erstellen des Exports")
+	}
+
+	var buf bytes.Buffer
+	if err := backup.Write(&buf, archive); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Fehler beim Erstellen des Exports")
+	}
+
+	c.Response().Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", backup.FileName(archive.ExportedAt)))
+	c.Response().Header().Set("Cache-Control", "private, no-store")
+	return c.Blob(http.StatusOK, backup.ContentType, buf.Bytes())
+}
+
+// Import restores an uploaded account export into the current user's account
+// POST /account/import
+func (h *AccountHandler) Import(c echo.Context) error {
+	user := c.Get("current_user").(*models.User)
+
+	if c.Get("is_impersonating") != nil {
+		return h.render(c, templates.AccountPageData{Error: "account.backup.error_impersonating"})
+	}
+
+	data, err := readBackupUpload(c)
+	if err != nil {
+		return h.render(c, templates.AccountPageData{Error: "account.backup.error_file"})
+	}
+
+	archive, err := backup.Read(data)
+	if err != nil {
+		msg := "account.backup.error_invalid"
+		if errors.Is(err, backup.ErrUnsupportedVersion) {
+			msg = "account.backup.error_version"
+		}
+		return h.render(c, templates.AccountPageData{Error: msg})
+	}
+
+	ctx := audit.AddUserIDToContext(c.Request().Context(), user.ID)
+	result, err := h.backupService.ImportUserData(ctx, user.ID, archive)
+	if err != nil {
+		c.Logger().Errorf("Account import failed for user %s: %v", user.ID, err)
+		return h.render(c, templates.AccountPageData{Error: "account.backup.error_import"})
+	}
+
+	return h.render(c, templates.AccountPageData{BackupResult: result})
+}
+
+// readBackupUpload reads the uploaded archive, enforcing maxBackupSize
+func readBackupUpload(c echo.Context) ([]byte, error) {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return nil, err
+	}
+	if fileHeader.Size > maxBackupSize {
+		return nil, backup.ErrInvalidArchive
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	return io.ReadAll(io.LimitReader(file, maxBackupSize))
+}
+
+// render loads the account data and renders the page
+func (h *AccountHandler) render(c echo.Context, data templates.AccountPageData) error {
+	user := c.Get("current_user").(*models.User)
+	isImpersonating := c.Get("is_impersonating") != nil
+	csrfToken, ok := c.Get("csrf").(string)
+	if !ok {
+		csrfToken = ""
+	}
+
+	tokens, err := h.apiTokenService.ListTokens(c.Request().Context(), user.ID)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Failed to load API tokens")
+	}
+	data.APITokens = tokens
+
+	sessions, err := h.sessionService.ListSessions(c.Request().Context(), user.ID)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Failed to load sessions")
+	}
+	data.Sessions = sessions
+	data.CurrentSessionHash = middleware.CurrentSessionHash(c)
+
+	// Without rates only the base currency can be selected
+	if rates, err := h.exchangeRateService.GetRates(c.Request().Context()); err == nil {
+		data.Currencies = rates.Currencies()
+		data.BaseCurrency = rates.Base
+	}
+
+	return templates.AccountPage(c.Request().Context(), csrfToken, user, isImpersonating, data).Render(c.Request().Context(), c.Response().Writer)
+}
@@ -2,29 +2,42 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
 	"net/http"
 	"savvy/internal/models"
 	"savvy/internal/services"
 	"savvy/internal/templates"
 	"savvy/internal/validation"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
 // AdminHandler handles admin operations
 type AdminHandler struct {
-	adminService services.AdminServiceInterface
-	userService  services.UserServiceInterface
+	adminService  services.AdminServiceInterface
+	userService   services.UserServiceInterface
+	loginThrottle services.LoginThrottleServiceInterface
+	jobService    services.JobServiceInterface
 }
 
 // NewAdminHandler creates a new admin handler
-func NewAdminHandler(adminService services.AdminServiceInterface, userService services.UserServiceInterface) *AdminHandler {
+func NewAdminHandler(
+	adminService services.AdminServiceInterface,
+	userService services.UserServiceInterface,
+	loginThrottle services.LoginThrottleServiceInterface,
+	jobService services.JobServiceInterface,
+) *AdminHandler {
 	return &AdminHandler{
-		adminService: adminService,
-		userService:  userService,
+		adminService:  adminService,
+		userService:   userService,
+		loginThrottle: loginThrottle,
+		jobService:    jobService,
 	}
 }
 
@@ -259,13 +272,16 @@ func (h *AdminHandler) CreateUserPost(c echo.Context) error {
 		}).Render(c.Request().Context(), c.Response().Writer)
 	}
 
+	// The admin vouches for the address
+	verifiedAt := time.Now()
 	user := models.User{
-		Email:        email,
-		PasswordHash: string(hashedPassword),
-		FirstName:    req.FirstName,
-		LastName:     req.LastName,
-		Role:         role,
-		AuthProvider: "local",
+		Email:           email,
+		PasswordHash:    string(hashedPassword),
+		FirstName:       req.FirstName,
+		LastName:        req.LastName,
+		Role:            role,
+		AuthProvider:    "local",
+		EmailVerifiedAt: &verifiedAt,
 	}
 
 	if err := h.adminService.CreateLocalUser(c.Request().Context(), &user); err != nil {
@@ -286,3 +302,113 @@ func (h *AdminHandler) CreateUserPost(c echo.Context) error {
 
 	return c.Redirect(http.StatusSeeOther, "/admin/users?success=user_created")
 }
+
+// LockoutsIndex lists the email addresses and IPs locked after failed logins
+func (h *AdminHandler) LockoutsIndex(c echo.Context) error {
+	currentUser := c.Get("current_user").(*models.User)
+	isImpersonating := c.Get("is_impersonating") != nil
+	csrfToken, ok := c.Get("csrf").(string)
+	if !ok {
+		csrfToken = ""
+	}
+
+	ctx := c.Request().Context()
+	lockouts, err := h.loginThrottle.ListLocked(ctx)
+	if err != nil {
+		c.Logger().Errorf("Failed to load login lockouts: %v", err)
+		return c.String(http.StatusInternalServerError, "Failed to load lockouts")
+	}
+
+	// Locked addresses without an account are shown without a name
+	users, _ := h.adminService.GetAllUsers(ctx)
+	usersByEmail := make(map[string]models.User, len(users))
+	for _, user := range users {
+		usersByEmail[strings.ToLower(user.Email)] = user
+	}
+
+	data := templates.AdminLockoutsData{
+		Lockouts:     lockouts,
+		UsersByEmail: usersByEmail,
+		Now:          time.Now(),
+	}
+	return templates.AdminLockouts(ctx, csrfToken, currentUser, isImpersonating, data).Render(ctx, c.Response().Writer)
+}
+
+// UnlockLogin lifts a lockout and forgets its failed attempts
+func (h *AdminHandler) UnlockLogin(c echo.Context) error {
+	currentUser := c.Get("current_user").(*models.User)
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid lockout ID")
+	}
+
+	throttle, err := h.loginThrottle.Unlock(c.Request().Context(), id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.String(http.StatusNotFound, "Lockout not found")
+		}
+		c.Logger().Errorf("Failed to unlock login: %v", err)
+		return c.String(http.StatusInternalServerError, "Failed to unlock login")
+	}
+
+	resourceData, _ := json.Marshal(map[string]any{
+		"scope":    throttle.Scope,
+		"subject":  throttle.Subject,
+		"failures": throttle.Failures,
+	})
+	auditLog := models.AuditLog{
+		UserID:       &currentUser.ID,
+		Action:       models.AuditActionUnlockLogin,
+		ResourceType: "login_throttles",
+		ResourceID:   throttle.ID,
+		ResourceData: string(resourceData),
+		IPAddress:    c.RealIP(),
+		UserAgent:    c.Request().UserAgent(),
+	}
+	if err := h.adminService.CreateAuditLog(c.Request().Context(), &auditLog); err != nil {
+		c.Logger().Errorf("Failed to log unlock: %v", err)
+	}
+
+	c.Logger().Infof("Login of %s %s unlocked by %s", throttle.Scope, throttle.Subject, currentUser.Email)
+
+	c.Response().Header().Set("HX-Redirect", "/admin/lockouts")
+	return c.NoContent(http.StatusOK)
+}
+
+// adminJobRunsLimit is the number of runs shown below the jobs
+const adminJobRunsLimit = 50
+
+// JobsIndex lists the background jobs with their last run and the latest runs
+func (h *AdminHandler) JobsIndex(c echo.Context) error {
+	currentUser := c.Get("current_user").(*models.User)
+	isImpersonating := c.Get("is_impersonating") != nil
+
+	ctx := c.Request().Context()
+	jobs, err := h.jobService.List(ctx)
+	if err != nil {
+		c.Logger().Errorf("Failed to load jobs: %v", err)
+		return c.String(http.StatusInternalServerError, "Failed to load jobs")
+	}
+
+	// Only known jobs can be filtered on
+	filter := ""
+	for _, job := range jobs {
+		if job.Name == c.QueryParam("job") {
+			filter = job.Name
+		}
+	}
+
+	runs, err := h.jobService.ListRuns(ctx, filter, adminJobRunsLimit)
+	if err != nil {
+		c.Logger().Errorf("Failed to load job runs: %v", err)
+		return c.String(http.StatusInternalServerError, "Failed to load job runs")
+	}
+
+	data := templates.AdminJobsData{
+		Jobs:   jobs,
+		Runs:   runs,
+		Filter: filter,
+	}
+	return templates.AdminJobs(ctx, currentUser, isImpersonating, data).Render(ctx, c.Response().Writer)
+}
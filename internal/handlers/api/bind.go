@@ -0,0 +1,75 @@
+package api
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"savvy/internal/models"
+	"savvy/internal/money"
+	"savvy/internal/validation"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// bindJSON decodes the request body over v and validates the result.
+// Callers pre-fill v with defaults (create) or the current values (update),
+// so omitted fields keep their value and PATCH bodies can be partial.
+func bindJSON(c echo.Context, v any) error {
+	dec := json.NewDecoder(c.Request().Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return newError(http.StatusBadRequest, CodeBadRequest, "invalid JSON body: "+err.Error())
+	}
+
+	if err := validation.ValidateStruct(v); err != nil {
+		return errValidation(err)
+	}
+
+	return nil
+}
+
+// formatDate renders a date the way the API accepts it (YYYY-MM-DD).
+func formatDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format("2006-01-02")
+}
+
+// fieldError builds a validation error for a single field.
+func fieldError(field, rule string) *Error {
+	apiErr := newError(http.StatusUnprocessableEntity, CodeValidationFailed, "request validation failed")
+	apiErr.Fields = map[string]string{field: rule}
+	return apiErr
+}
+
+// checkStatus refuses to set a status the lifecycle job derives, the job
+// would revert it within a minute. Keeping the current status is allowed.
+func checkStatus(current, requested string) error {
+	if requested != current && !models.IsManualItemStatus(requested) {
+		return fieldError("status", "lifecycle")
+	}
+	return nil
+}
+
+// parseAmount converts a JSON amount to minor units of the currency,
+// rejecting more decimal places than the currency has.
+func parseAmount(field string, value float64, currency string) (money.Money, error) {
+	amount := money.FromFloat(value, currency)
+	if math.Abs(amount.Float()-value) > 1e-9 {
+		return money.Money{}, fieldError(field, "decimals")
+	}
+	return amount, nil
+}
+
+// parseVoucherValue converts the JSON value of a voucher like parseAmount,
+// percentages and points multipliers allow two decimal places.
+func parseVoucherValue(voucherType string, value float64) (int64, error) {
+	stored := models.VoucherValueFromFloat(voucherType, value)
+	voucher := models.Voucher{Type: voucherType, Value: stored}
+	if math.Abs(voucher.ValueFloat()-value) > 1e-9 {
+		return 0, fieldError("value", "decimals")
+	}
+	return stored, nil
+}
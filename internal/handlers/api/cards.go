@@ -0,0 +1,167 @@
+package api
+
+import (
+	"net/http"
+	"savvy/internal/audit"
+	"savvy/internal/models"
+	"savvy/internal/validation"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ListCards returns the cards owned by or shared with the current user.
+// GET /api/v1/cards
+func (h *Handler) ListCards(c echo.Context) error {
+	user := currentUser(c)
+
+	page, perPage, err := parsePagination(c)
+	if err != nil {
+		return err
+	}
+
+	cards, err := h.cardService.GetUserCards(c.Request().Context(), user.ID)
+	if err != nil {
+		return errFromService(err, "card")
+	}
+
+	return c.JSON(http.StatusOK, paginate(cards, page, perPage))
+}
+
+// GetCard returns a single card.
+// GET /api/v1/cards/:id
+func (h *Handler) GetCard(c echo.Context) error {
+	user := currentUser(c)
+
+	cardID, err := parseIDParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	if _, err := h.authzService.CheckCardAccess(c.Request().Context(), user.ID, cardID); err != nil {
+		return errFromAccessCheck(err, "card")
+	}
+
+	card, err := h.cardService.GetCard(c.Request().Context(), cardID)
+	if err != nil {
+		return errFromService(err, "card")
+	}
+
+	return c.JSON(http.StatusOK, card)
+}
+
+// CreateCard creates a card owned by the current user.
+// POST /api/v1/cards
+func (h *Handler) CreateCard(c echo.Context) error {
+	user := currentUser(c)
+
+	req := newCardRequest()
+	if err := bindJSON(c, &req); err != nil {
+		return err
+	}
+
+	card := models.Card{UserID: &user.ID}
+	h.applyCardRequest(c, &card, &req)
+
+	if err := h.cardService.CreateCard(c.Request().Context(), &card); err != nil {
+		return errFromService(err, "card")
+	}
+
+	return c.JSON(http.StatusCreated, card)
+}
+
+// UpdateCard partially updates a card. Requires edit permission.
+// PATCH /api/v1/cards/:id
+func (h *Handler) UpdateCard(c echo.Context) error {
+	user := currentUser(c)
+
+	cardID, err := parseIDParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	perms, err := h.authzService.CheckCardAccess(c.Request().Context(), user.ID, cardID)
+	if err != nil {
+		return errFromAccessCheck(err, "card")
+	}
+	if !perms.CanEdit {
+		return errForbidden()
+	}
+
+	card, err := h.cardService.GetCard(c.Request().Context(), cardID)
+	if err != nil {
+		return errFromService(err, "card")
+	}
+
+	req := validation.CardRequest{
+		MerchantName: card.MerchantName,
+		Program:      card.Program,
+		CardNumber:   card.CardNumber,
+		BarcodeType:  card.BarcodeType,
+		Notes:        card.Notes,
+		Status:       card.Status,
+	}
+	if card.MerchantID != nil {
+		req.MerchantID = card.MerchantID.String()
+	}
+	if err := bindJSON(c, &req); err != nil {
+		return err
+	}
+
+	h.applyCardRequest(c, card, &req)
+
+	if err := h.cardService.UpdateCard(c.Request().Context(), card); err != nil {
+		return errFromService(err, "card")
+	}
+
+	if h.db != nil {
+		if err := audit.LogUpdateFromContext(c, h.db, "cards", card.ID, *card); err != nil {
+			c.Logger().Errorf("Failed to log card update: %v", err)
+		}
+	}
+
+	return c.JSON(http.StatusOK, card)
+}
+
+// DeleteCard deletes a card. Requires delete permission.
+// DELETE /api/v1/cards/:id
+func (h *Handler) DeleteCard(c echo.Context) error {
+	user := currentUser(c)
+
+	cardID, err := parseIDParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	perms, err := h.authzService.CheckCardAccess(c.Request().Context(), user.ID, cardID)
+	if err != nil {
+		return errFromAccessCheck(err, "card")
+	}
+	if !perms.CanDelete {
+		return errForbidden()
+	}
+
+	ctx := audit.AddUserIDToContext(c.Request().Context(), user.ID)
+	if err := h.cardService.DeleteCard(ctx, cardID); err != nil {
+		return errFromService(err, "card")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// applyCardRequest copies validated request fields onto the card.
+func (h *Handler) applyCardRequest(c echo.Context, card *models.Card, req *validation.CardRequest) {
+	card.MerchantID, card.MerchantName = h.resolveMerchant(c, req.MerchantID, req.MerchantName)
+	card.Program = req.Program
+	card.CardNumber = req.CardNumber
+	card.BarcodeType = req.BarcodeType
+	card.Notes = req.Notes
+	card.Status = req.Status
+}
+
+// newCardRequest returns the defaults for fields omitted on create.
+func newCardRequest() validation.CardRequest {
+	return validation.CardRequest{
+		BarcodeType: "CODE128",
+		Status:      "active",
+	}
+}
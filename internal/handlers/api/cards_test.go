@@ -0,0 +1,172 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"savvy/internal/models"
+	"savvy/internal/services"
+)
+
+func TestListCards_Paginates(t *testing.T) {
+	h, deps := newTestHandler()
+	user := &models.User{ID: uuid.New()}
+
+	cards := make([]models.Card, 5)
+	for i := range cards {
+		cards[i] = models.Card{ID: uuid.New(), Program: "Cumulus"}
+	}
+	deps.cards.On("GetUserCards", mock.Anything, user.ID).Return(cards, nil)
+
+	rec := serve(t, h.ListCards, user, http.MethodGet, "/api/v1/cards?page=2&per_page=2", "", nil)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var resp ListResponse[models.Card]
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Len(t, resp.Data, 2)
+	assert.Equal(t, cards[2].ID, resp.Data[0].ID)
+	assert.Equal(t, Pagination{Page: 2, PerPage: 2, Total: 5, TotalPages: 3}, resp.Pagination)
+}
+
+func TestListCards_InvalidPagination(t *testing.T) {
+	h, deps := newTestHandler()
+	user := &models.User{ID: uuid.New()}
+
+	rec := serve(t, h.ListCards, user, http.MethodGet, "/api/v1/cards?per_page=500", "", nil)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, CodeBadRequest, decodeError(t, rec).Code)
+	deps.cards.AssertNotCalled(t, "GetUserCards", mock.Anything, mock.Anything)
+}
+
+func TestGetCard_NoAccess(t *testing.T) {
+	h, deps := newTestHandler()
+	user := &models.User{ID: uuid.New()}
+	cardID := uuid.New()
+
+	deps.authz.On("CheckCardAccess", mock.Anything, user.ID, cardID).Return(nil, services.ErrForbidden)
+
+	rec := serve(t, h.GetCard, user, http.MethodGet, "/api/v1/cards/"+cardID.String(), "", map[string]string{"id": cardID.String()})
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, CodeNotFound, decodeError(t, rec).Code)
+	deps.cards.AssertNotCalled(t, "GetCard", mock.Anything, mock.Anything)
+}
+
+func TestGetCard_InvalidID(t *testing.T) {
+	h, _ := newTestHandler()
+	user := &models.User{ID: uuid.New()}
+
+	rec := serve(t, h.GetCard, user, http.MethodGet, "/api/v1/cards/abc", "", map[string]string{"id": "abc"})
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, CodeBadRequest, decodeError(t, rec).Code)
+}
+
+func TestCreateCard_Success(t *testing.T) {
+	h, deps := newTestHandler()
+	user := &models.User{ID: uuid.New()}
+
+	deps.cards.On("CreateCard", mock.Anything, mock.MatchedBy(func(card *models.Card) bool {
+		return card.MerchantName == "Migros" &&
+			card.CardNumber == "1234" &&
+			card.BarcodeType == "CODE128" &&
+			card.Status == "active" &&
+			*card.UserID == user.ID
+	})).Return(nil)
+
+	body := `{"merchant_name":"Migros","program":"Cumulus","card_number":"1234"}`
+	rec := serve(t, h.CreateCard, user, http.MethodPost, "/api/v1/cards", body, nil)
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	deps.cards.AssertExpectations(t)
+}
+
+func TestCreateCard_ValidationError(t *testing.T) {
+	h, deps := newTestHandler()
+	user := &models.User{ID: uuid.New()}
+
+	body := `{"merchant_name":"Migros","card_number":"1234","barcode_type":"UPCA"}`
+	rec := serve(t, h.CreateCard, user, http.MethodPost, "/api/v1/cards", body, nil)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	apiErr := decodeError(t, rec)
+	assert.Equal(t, CodeValidationFailed, apiErr.Code)
+	assert.Equal(t, "required", apiErr.Fields["program"])
+	assert.Equal(t, "oneof", apiErr.Fields["barcode_type"])
+	deps.cards.AssertNotCalled(t, "CreateCard", mock.Anything, mock.Anything)
+}
+
+func TestCreateCard_UnknownField(t *testing.T) {
+	h, _ := newTestHandler()
+	user := &models.User{ID: uuid.New()}
+
+	body := `{"merchant_name":"Migros","program":"Cumulus","card_number":"1234","user_id":"x"}`
+	rec := serve(t, h.CreateCard, user, http.MethodPost, "/api/v1/cards", body, nil)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestUpdateCard_PartialUpdate(t *testing.T) {
+	h, deps := newTestHandler()
+	user := &models.User{ID: uuid.New()}
+	cardID := uuid.New()
+
+	existing := &models.Card{ID: cardID, UserID: &user.ID, MerchantName: "Migros", Program: "Cumulus", CardNumber: "1234", BarcodeType: "EAN13", Status: "active"}
+	deps.authz.On("CheckCardAccess", mock.Anything, user.ID, cardID).Return(ownerPerms, nil)
+	deps.cards.On("GetCard", mock.Anything, cardID).Return(existing, nil)
+	deps.cards.On("UpdateCard", mock.Anything, mock.MatchedBy(func(card *models.Card) bool {
+		return card.Notes == "new notes" && card.BarcodeType == "EAN13" && card.CardNumber == "1234"
+	})).Return(nil)
+
+	rec := serve(t, h.UpdateCard, user, http.MethodPatch, "/api/v1/cards/"+cardID.String(), `{"notes":"new notes"}`, map[string]string{"id": cardID.String()})
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	deps.cards.AssertExpectations(t)
+}
+
+func TestUpdateCard_ReadOnlyShare(t *testing.T) {
+	h, deps := newTestHandler()
+	user := &models.User{ID: uuid.New()}
+	cardID := uuid.New()
+
+	deps.authz.On("CheckCardAccess", mock.Anything, user.ID, cardID).Return(viewerPerms, nil)
+
+	rec := serve(t, h.UpdateCard, user, http.MethodPatch, "/api/v1/cards/"+cardID.String(), `{"notes":"x"}`, map[string]string{"id": cardID.String()})
+
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Equal(t, CodeForbidden, decodeError(t, rec).Code)
+}
+
+func TestDeleteCard(t *testing.T) {
+	h, deps := newTestHandler()
+	user := &models.User{ID: uuid.New()}
+	cardID := uuid.New()
+
+	deps.authz.On("CheckCardAccess", mock.Anything, user.ID, cardID).Return(ownerPerms, nil)
+	deps.cards.On("DeleteCard", mock.Anything, cardID).Return(nil)
+
+	rec := serve(t, h.DeleteCard, user, http.MethodDelete, "/api/v1/cards/"+cardID.String(), "", map[string]string{"id": cardID.String()})
+
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	deps.cards.AssertExpectations(t)
+}
+
+func TestDeleteCard_NotFound(t *testing.T) {
+	h, deps := newTestHandler()
+	user := &models.User{ID: uuid.New()}
+	cardID := uuid.New()
+
+	deps.authz.On("CheckCardAccess", mock.Anything, user.ID, cardID).Return(ownerPerms, nil)
+	deps.cards.On("DeleteCard", mock.Anything, cardID).Return(gorm.ErrRecordNotFound)
+
+	rec := serve(t, h.DeleteCard, user, http.MethodDelete, "/api/v1/cards/"+cardID.String(), "", map[string]string{"id": cardID.String()})
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
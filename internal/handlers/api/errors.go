@@ -0,0 +1,156 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"savvy/internal/database"
+	"savvy/internal/services"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// Error codes returned in the "code" field of every error body.
+const (
+	CodeBadRequest       = "bad_request"
+	CodeUnauthorized     = "unauthorized"
+	CodeForbidden        = "forbidden"
+	CodeNotFound         = "not_found"
+	CodeConflict         = "conflict"
+	CodeValidationFailed = "validation_failed"
+	CodeRateLimited      = "rate_limited"
+	CodeInternal         = "internal_error"
+)
+
+// Error is the error type returned by API handlers.
+// The Errors middleware renders it as an ErrorResponse.
+type Error struct {
+	Status  int               `json:"-"`
+	Code    string            `json:"code"`
+	Message string            `json:"message"`
+	Fields  map[string]string `json:"fields,omitempty"`
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	return fmt.Sprintf("%d %s: %s", e.Status, e.Code, e.Message)
+}
+
+// ErrorResponse is the JSON body of every API error.
+type ErrorResponse struct {
+	Error *Error `json:"error"`
+}
+
+func newError(status int, code, message string) *Error {
+	return &Error{Status: status, Code: code, Message: message}
+}
+
+func errNotFound(resource string) *Error {
+	return newError(http.StatusNotFound, CodeNotFound, resource+" not found")
+}
+
+func errForbidden() *Error {
+	return newError(http.StatusForbidden, CodeForbidden, "you do not have permission to perform this action")
+}
+
+func errInternal() *Error {
+	return newError(http.StatusInternalServerError, CodeInternal, "internal server error")
+}
+
+// errValidation converts validator errors into a field → rule map.
+func errValidation(err error) *Error {
+	apiErr := newError(http.StatusUnprocessableEntity, CodeValidationFailed, "request validation failed")
+
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		apiErr.Fields = make(map[string]string, len(validationErrs))
+		for _, fe := range validationErrs {
+			apiErr.Fields[fe.Field()] = fe.Tag()
+		}
+		return apiErr
+	}
+
+	apiErr.Message = err.Error()
+	return apiErr
+}
+
+// errFromAccessCheck maps AuthzService errors to API errors.
+// Missing resources and missing shares both answer 404 so that IDs of
+// other users' items cannot be probed.
+func errFromAccessCheck(err error, resource string) *Error {
+	if errors.Is(err, services.ErrForbidden) {
+		return errNotFound(resource)
+	}
+	return errInternal()
+}
+
+// errFromService maps errors returned by services/repositories to API errors.
+func errFromService(err error, resource string) *Error {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return errNotFound(resource)
+	case database.IsDuplicateError(err):
+		return newError(http.StatusConflict, CodeConflict, resource+" already exists")
+	case strings.Contains(err.Error(), "Insufficient balance"),
+		strings.Contains(err.Error(), "check_gift_card_balance"):
+		return newError(http.StatusUnprocessableEntity, CodeValidationFailed, "insufficient balance")
+	default:
+		return errInternal()
+	}
+}
+
+// Errors renders every error returned further down the chain as a
+// consistent JSON ErrorResponse, including echo.HTTPErrors raised by
+// shared middleware (feature toggles, rate limiting, auth).
+func Errors(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		err := next(c)
+		if err == nil || c.Response().Committed {
+			return err
+		}
+
+		var apiErr *Error
+		var httpErr *echo.HTTPError
+		switch {
+		case errors.As(err, &apiErr):
+		case errors.As(err, &httpErr):
+			apiErr = fromHTTPError(httpErr)
+		default:
+			c.Logger().Errorf("API request failed: %v", err)
+			apiErr = errInternal()
+		}
+
+		return c.JSON(apiErr.Status, ErrorResponse{Error: apiErr})
+	}
+}
+
+// fromHTTPError converts an echo.HTTPError into an API error.
+func fromHTTPError(httpErr *echo.HTTPError) *Error {
+	message := http.StatusText(httpErr.Code)
+	if msg, ok := httpErr.Message.(string); ok && msg != "" {
+		message = msg
+	}
+
+	code := CodeInternal
+	switch httpErr.Code {
+	case http.StatusBadRequest:
+		code = CodeBadRequest
+	case http.StatusUnauthorized:
+		code = CodeUnauthorized
+	case http.StatusForbidden:
+		code = CodeForbidden
+	case http.StatusNotFound, http.StatusMethodNotAllowed:
+		code = CodeNotFound
+	case http.StatusConflict:
+		code = CodeConflict
+	case http.StatusUnprocessableEntity:
+		code = CodeValidationFailed
+	case http.StatusTooManyRequests:
+		code = CodeRateLimited
+	}
+
+	return newError(httpErr.Code, code, message)
+}
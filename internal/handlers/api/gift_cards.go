@@ -0,0 +1,218 @@
+package api
+
+import (
+	"net/http"
+	"savvy/internal/audit"
+	"savvy/internal/models"
+	"savvy/internal/validation"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// giftCardStatuses are the values of the ?status= filter of ListGiftCards
+var giftCardStatuses = []string{models.ItemStatusActive, models.ItemStatusInactive, models.ItemStatusExpired, models.ItemStatusRedeemed}
+
+// ListGiftCards returns the gift cards owned by or shared with the current user,
+// optionally only those with a status.
+// GET /api/v1/gift-cards
+func (h *Handler) ListGiftCards(c echo.Context) error {
+	user := currentUser(c)
+
+	page, perPage, err := parsePagination(c)
+	if err != nil {
+		return err
+	}
+	status, err := parseStatusFilter(c, giftCardStatuses)
+	if err != nil {
+		return err
+	}
+
+	giftCards, err := h.giftCardService.GetUserGiftCards(c.Request().Context(), user.ID)
+	if err != nil {
+		return errFromService(err, "gift card")
+	}
+
+	giftCards = filterStatus(giftCards, status, func(g models.GiftCard) string { return g.Status })
+	return c.JSON(http.StatusOK, paginate(giftCards, page, perPage))
+}
+
+// GetGiftCard returns a single gift card including its transactions.
+// GET /api/v1/gift-cards/:id
+func (h *Handler) GetGiftCard(c echo.Context) error {
+	user := currentUser(c)
+
+	giftCardID, err := parseIDParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	if _, err := h.authzService.CheckGiftCardAccess(c.Request().Context(), user.ID, giftCardID); err != nil {
+		return errFromAccessCheck(err, "gift card")
+	}
+
+	giftCard, err := h.giftCardService.GetGiftCard(c.Request().Context(), giftCardID)
+	if err != nil {
+		return errFromService(err, "gift card")
+	}
+
+	return c.JSON(http.StatusOK, giftCard)
+}
+
+// CreateGiftCard creates a gift card owned by the current user.
+// POST /api/v1/gift-cards
+func (h *Handler) CreateGiftCard(c echo.Context) error {
+	user := currentUser(c)
+
+	req := newGiftCardRequest()
+	if err := bindJSON(c, &req); err != nil {
+		return err
+	}
+
+	giftCard := models.GiftCard{UserID: &user.ID}
+	if err := h.applyGiftCardRequest(c, &giftCard, &req); err != nil {
+		return err
+	}
+
+	if err := h.giftCardService.CreateGiftCard(c.Request().Context(), &giftCard); err != nil {
+		return errFromService(err, "gift card")
+	}
+
+	return c.JSON(http.StatusCreated, giftCard)
+}
+
+// UpdateGiftCard partially updates a gift card. Requires edit permission.
+// PATCH /api/v1/gift-cards/:id
+func (h *Handler) UpdateGiftCard(c echo.Context) error {
+	user := currentUser(c)
+
+	giftCardID, err := parseIDParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	perms, err := h.authzService.CheckGiftCardAccess(c.Request().Context(), user.ID, giftCardID)
+	if err != nil {
+		return errFromAccessCheck(err, "gift card")
+	}
+	if !perms.CanEdit {
+		return errForbidden()
+	}
+
+	giftCard, err := h.giftCardService.GetGiftCard(c.Request().Context(), giftCardID)
+	if err != nil {
+		return errFromService(err, "gift card")
+	}
+
+	req := validation.GiftCardRequest{
+		MerchantName:   giftCard.MerchantName,
+		CardNumber:     giftCard.CardNumber,
+		InitialBalance: giftCard.InitialBalance.Float(),
+		Currency:       giftCard.Currency,
+		PIN:            giftCard.PIN,
+		BarcodeType:    giftCard.BarcodeType,
+		Notes:          giftCard.Notes,
+		Status:         giftCard.Status,
+	}
+	if giftCard.MerchantID != nil {
+		req.MerchantID = giftCard.MerchantID.String()
+	}
+	if giftCard.ExpiresAt != nil {
+		req.ExpiresAt = formatDate(*giftCard.ExpiresAt)
+	}
+	if err := bindJSON(c, &req); err != nil {
+		return err
+	}
+
+	if err := h.applyGiftCardRequest(c, giftCard, &req); err != nil {
+		return err
+	}
+
+	// Transactions are managed through their own endpoints
+	giftCard.Transactions = nil
+
+	if err := h.giftCardService.UpdateGiftCard(c.Request().Context(), giftCard); err != nil {
+		return errFromService(err, "gift card")
+	}
+
+	if h.db != nil {
+		if err := audit.LogUpdateFromContext(c, h.db, "gift_cards", giftCard.ID, *giftCard); err != nil {
+			c.Logger().Errorf("Failed to log gift card update: %v", err)
+		}
+	}
+
+	return c.JSON(http.StatusOK, giftCard)
+}
+
+// DeleteGiftCard deletes a gift card. Requires delete permission.
+// DELETE /api/v1/gift-cards/:id
+func (h *Handler) DeleteGiftCard(c echo.Context) error {
+	user := currentUser(c)
+
+	giftCardID, err := parseIDParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	perms, err := h.authzService.CheckGiftCardAccess(c.Request().Context(), user.ID, giftCardID)
+	if err != nil {
+		return errFromAccessCheck(err, "gift card")
+	}
+	if !perms.CanDelete {
+		return errForbidden()
+	}
+
+	ctx := audit.AddUserIDToContext(c.Request().Context(), user.ID)
+	if err := h.giftCardService.DeleteGiftCard(ctx, giftCardID); err != nil {
+		return errFromService(err, "gift card")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// applyGiftCardRequest copies validated request fields onto the gift card.
+func (h *Handler) applyGiftCardRequest(c echo.Context, giftCard *models.GiftCard, req *validation.GiftCardRequest) error {
+	if err := checkStatus(giftCard.Status, req.Status); err != nil {
+		return err
+	}
+	// Stored amounts are minor units of the current currency
+	if req.Currency != giftCard.Currency && len(giftCard.Transactions) > 0 {
+		return fieldError("currency", "has_transactions")
+	}
+
+	var expiresAt *time.Time
+	if req.ExpiresAt != "" {
+		parsed, err := validation.ParseAndValidateDate(req.ExpiresAt, true)
+		if err != nil {
+			return fieldError("expires_at", "datetime")
+		}
+		parsed = time.Date(parsed.Year(), parsed.Month(), parsed.Day(), 23, 59, 59, 0, time.UTC)
+		expiresAt = &parsed
+	}
+
+	initialBalance, err := parseAmount("initial_balance", req.InitialBalance, req.Currency)
+	if err != nil {
+		return err
+	}
+
+	giftCard.MerchantID, giftCard.MerchantName = h.resolveMerchant(c, req.MerchantID, req.MerchantName)
+	giftCard.CardNumber = req.CardNumber
+	giftCard.InitialBalance = initialBalance
+	giftCard.Currency = req.Currency
+	giftCard.PIN = req.PIN
+	giftCard.ExpiresAt = expiresAt
+	giftCard.BarcodeType = req.BarcodeType
+	giftCard.Notes = req.Notes
+	giftCard.Status = req.Status
+
+	return nil
+}
+
+// newGiftCardRequest returns the defaults for fields omitted on create.
+func newGiftCardRequest() validation.GiftCardRequest {
+	return validation.GiftCardRequest{
+		Currency:    "CHF",
+		BarcodeType: "CODE128",
+		Status:      "active",
+	}
+}
@@ -0,0 +1,346 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"savvy/internal/models"
+	"savvy/internal/money"
+)
+
+func TestCreateGiftCard_Defaults(t *testing.T) {
+	h, deps := newTestHandler()
+	user := &models.User{ID: uuid.New()}
+
+	deps.giftCards.On("CreateGiftCard", mock.Anything, mock.MatchedBy(func(gc *models.GiftCard) bool {
+		return gc.Currency == "CHF" &&
+			gc.InitialBalance == money.New(5000, "CHF") &&
+			gc.ExpiresAt != nil &&
+			gc.ExpiresAt.Hour() == 23
+	})).Return(nil)
+
+	body := `{"merchant_name":"Manor","card_number":"GC-1","initial_balance":50,"expires_at":"2030-12-31"}`
+	rec := serve(t, h.CreateGiftCard, user, http.MethodPost, "/api/v1/gift-cards", body, nil)
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	deps.giftCards.AssertExpectations(t)
+}
+
+func TestListGiftCards_StatusFilter(t *testing.T) {
+	h, deps := newTestHandler()
+	user := &models.User{ID: uuid.New()}
+
+	active := models.GiftCard{ID: uuid.New(), Status: models.ItemStatusActive}
+	redeemed := models.GiftCard{ID: uuid.New(), Status: models.ItemStatusRedeemed}
+	deps.giftCards.On("GetUserGiftCards", mock.Anything, user.ID).Return([]models.GiftCard{active, redeemed}, nil)
+
+	rec := serve(t, h.ListGiftCards, user, http.MethodGet, "/api/v1/gift-cards?status=redeemed", "", nil)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var resp ListResponse[models.GiftCard]
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Len(t, resp.Data, 1)
+	assert.Equal(t, redeemed.ID, resp.Data[0].ID)
+	assert.Equal(t, 1, resp.Pagination.Total)
+}
+
+func TestListGiftCards_InvalidStatus(t *testing.T) {
+	h, deps := newTestHandler()
+	user := &models.User{ID: uuid.New()}
+
+	rec := serve(t, h.ListGiftCards, user, http.MethodGet, "/api/v1/gift-cards?status=empty", "", nil)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, CodeBadRequest, decodeError(t, rec).Code)
+	deps.giftCards.AssertNotCalled(t, "GetUserGiftCards", mock.Anything, mock.Anything)
+}
+
+func TestCreateGiftCard_InvalidExpiry(t *testing.T) {
+	h, _ := newTestHandler()
+	user := &models.User{ID: uuid.New()}
+
+	body := `{"merchant_name":"Manor","card_number":"GC-1","initial_balance":50,"expires_at":"31.12.2030"}`
+	rec := serve(t, h.CreateGiftCard, user, http.MethodPost, "/api/v1/gift-cards", body, nil)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Equal(t, "datetime", decodeError(t, rec).Fields["expires_at"])
+}
+
+func TestListTransactions_NewestFirst(t *testing.T) {
+	h, deps := newTestHandler()
+	user := &models.User{ID: uuid.New()}
+	giftCardID := uuid.New()
+
+	older := models.GiftCardTransaction{ID: uuid.New(), TransactionDate: time.Now().AddDate(0, 0, -2)}
+	newer := models.GiftCardTransaction{ID: uuid.New(), TransactionDate: time.Now()}
+
+	deps.authz.On("CheckGiftCardAccess", mock.Anything, user.ID, giftCardID).Return(viewerPerms, nil)
+	deps.giftCards.On("GetGiftCard", mock.Anything, giftCardID).Return(&models.GiftCard{
+		ID:           giftCardID,
+		Transactions: []models.GiftCardTransaction{older, newer},
+	}, nil)
+
+	rec := serve(t, h.ListTransactions, user, http.MethodGet, "/", "", map[string]string{"id": giftCardID.String()})
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var resp ListResponse[models.GiftCardTransaction]
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Len(t, resp.Data, 2)
+	assert.Equal(t, newer.ID, resp.Data[0].ID)
+	assert.Equal(t, 2, resp.Pagination.Total)
+}
+
+func TestCreateTransaction_RequiresTransactionPermission(t *testing.T) {
+	h, deps := newTestHandler()
+	user := &models.User{ID: uuid.New()}
+	giftCardID := uuid.New()
+
+	deps.authz.On("CheckGiftCardAccess", mock.Anything, user.ID, giftCardID).Return(viewerPerms, nil)
+
+	rec := serve(t, h.CreateTransaction, user, http.MethodPost, "/", `{"amount":10}`, map[string]string{"id": giftCardID.String()})
+
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	deps.giftCards.AssertNotCalled(t, "CreateTransaction", mock.Anything, mock.Anything)
+}
+
+func TestCreateTransaction_InsufficientBalance(t *testing.T) {
+	h, deps := newTestHandler()
+	user := &models.User{ID: uuid.New()}
+	giftCardID := uuid.New()
+
+	deps.authz.On("CheckGiftCardAccess", mock.Anything, user.ID, giftCardID).Return(ownerPerms, nil)
+	deps.giftCards.On("GetGiftCard", mock.Anything, giftCardID).Return(&models.GiftCard{ID: giftCardID, CurrentBalance: money.New(500, "CHF"), Currency: "CHF"}, nil)
+
+	rec := serve(t, h.CreateTransaction, user, http.MethodPost, "/", `{"amount":10}`, map[string]string{"id": giftCardID.String()})
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Equal(t, "insufficient balance", decodeError(t, rec).Message)
+}
+
+func TestCreateTransaction_ReloadAboveBalance(t *testing.T) {
+	h, deps := newTestHandler()
+	user := &models.User{ID: uuid.New()}
+	giftCardID := uuid.New()
+
+	deps.authz.On("CheckGiftCardAccess", mock.Anything, user.ID, giftCardID).Return(ownerPerms, nil)
+	deps.giftCards.On("GetGiftCard", mock.Anything, giftCardID).Return(&models.GiftCard{ID: giftCardID, CurrentBalance: money.New(500, "CHF"), Currency: "CHF"}, nil)
+	deps.giftCards.On("CreateTransaction", mock.Anything, mock.MatchedBy(func(tx *models.GiftCardTransaction) bool {
+		return tx.Kind == models.TransactionKindReload && tx.Amount == money.New(5000, "CHF")
+	})).Return(nil)
+
+	rec := serve(t, h.CreateTransaction, user, http.MethodPost, "/", `{"kind":"reload","amount":50}`, map[string]string{"id": giftCardID.String()})
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	deps.giftCards.AssertExpectations(t)
+}
+
+func TestCreateTransaction_NegativePurchase(t *testing.T) {
+	h, deps := newTestHandler()
+	user := &models.User{ID: uuid.New()}
+	giftCardID := uuid.New()
+
+	deps.authz.On("CheckGiftCardAccess", mock.Anything, user.ID, giftCardID).Return(ownerPerms, nil)
+	deps.giftCards.On("GetGiftCard", mock.Anything, giftCardID).Return(&models.GiftCard{ID: giftCardID, CurrentBalance: money.New(500, "CHF"), Currency: "CHF"}, nil)
+
+	rec := serve(t, h.CreateTransaction, user, http.MethodPost, "/", `{"kind":"purchase","amount":-5}`, map[string]string{"id": giftCardID.String()})
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Equal(t, "gt", decodeError(t, rec).Fields["amount"])
+}
+
+func TestCreateTransaction_TooManyDecimals(t *testing.T) {
+	h, deps := newTestHandler()
+	user := &models.User{ID: uuid.New()}
+	giftCardID := uuid.New()
+
+	deps.authz.On("CheckGiftCardAccess", mock.Anything, user.ID, giftCardID).Return(ownerPerms, nil)
+	deps.giftCards.On("GetGiftCard", mock.Anything, giftCardID).Return(&models.GiftCard{ID: giftCardID, CurrentBalance: money.New(5000, "JPY"), Currency: "JPY"}, nil)
+
+	rec := serve(t, h.CreateTransaction, user, http.MethodPost, "/", `{"amount":10.5}`, map[string]string{"id": giftCardID.String()})
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Equal(t, "decimals", decodeError(t, rec).Fields["amount"])
+	deps.giftCards.AssertNotCalled(t, "CreateTransaction", mock.Anything, mock.Anything)
+}
+
+func TestCreateTransaction_TriggerRejects(t *testing.T) {
+	h, deps := newTestHandler()
+	user := &models.User{ID: uuid.New()}
+	giftCardID := uuid.New()
+
+	deps.authz.On("CheckGiftCardAccess", mock.Anything, user.ID, giftCardID).Return(ownerPerms, nil)
+	deps.giftCards.On("GetGiftCard", mock.Anything, giftCardID).Return(&models.GiftCard{ID: giftCardID, CurrentBalance: money.New(5000, "CHF"), Currency: "CHF"}, nil)
+	deps.giftCards.On("CreateTransaction", mock.Anything, mock.Anything).
+		Return(errors.New("ERROR: Insufficient balance: current 5.00, requested 10.00"))
+
+	rec := serve(t, h.CreateTransaction, user, http.MethodPost, "/", `{"amount":10}`, map[string]string{"id": giftCardID.String()})
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+}
+
+func TestCreateTransaction_Success(t *testing.T) {
+	h, deps := newTestHandler()
+	user := &models.User{ID: uuid.New()}
+	giftCardID := uuid.New()
+
+	deps.authz.On("CheckGiftCardAccess", mock.Anything, user.ID, giftCardID).Return(ownerPerms, nil)
+	deps.giftCards.On("GetGiftCard", mock.Anything, giftCardID).Return(&models.GiftCard{ID: giftCardID, CurrentBalance: money.New(5000, "CHF"), Currency: "CHF"}, nil)
+	deps.giftCards.On("CreateTransaction", mock.Anything, mock.MatchedBy(func(tx *models.GiftCardTransaction) bool {
+		return tx.Amount == money.New(1250, "CHF") &&
+			tx.TransactionDate.Equal(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)) &&
+			*tx.CreatedByUserID == user.ID
+	})).Return(nil)
+
+	body := `{"amount":12.5,"description":"Lunch","transaction_date":"2026-03-01"}`
+	rec := serve(t, h.CreateTransaction, user, http.MethodPost, "/", body, map[string]string{"id": giftCardID.String()})
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	deps.giftCards.AssertExpectations(t)
+}
+
+func TestDeleteTransaction_WrongGiftCard(t *testing.T) {
+	h, deps := newTestHandler()
+	user := &models.User{ID: uuid.New()}
+	giftCardID := uuid.New()
+	transactionID := uuid.New()
+
+	deps.authz.On("CheckGiftCardAccess", mock.Anything, user.ID, giftCardID).Return(ownerPerms, nil)
+	deps.giftCards.On("GetTransaction", mock.Anything, transactionID, giftCardID).Return(nil, errors.New("record not found"))
+
+	rec := serve(t, h.DeleteTransaction, user, http.MethodDelete, "/", "", map[string]string{
+		"id":             giftCardID.String(),
+		"transaction_id": transactionID.String(),
+	})
+
+	assert.NotEqual(t, http.StatusNoContent, rec.Code)
+	deps.giftCards.AssertNotCalled(t, "DeleteTransaction", mock.Anything, mock.Anything)
+}
+
+func TestGiftCardResponses_OmitPIN(t *testing.T) {
+	h, deps := newTestHandler()
+	user := &models.User{ID: uuid.New()}
+	giftCard := &models.GiftCard{
+		ID:             uuid.New(),
+		MerchantName:   "Manor",
+		CardNumber:     "GC-1",
+		InitialBalance: money.New(5000, "CHF"),
+		Currency:       "CHF",
+		PIN:            "secret-4711",
+		BarcodeType:    "CODE128",
+		Status:         models.ItemStatusActive,
+	}
+	params := map[string]string{"id": giftCard.ID.String()}
+
+	deps.authz.On("CheckGiftCardAccess", mock.Anything, user.ID, giftCard.ID).Return(ownerPerms, nil)
+	deps.giftCards.On("GetGiftCard", mock.Anything, giftCard.ID).Return(giftCard, nil)
+	deps.giftCards.On("GetUserGiftCards", mock.Anything, user.ID).Return([]models.GiftCard{*giftCard}, nil)
+	deps.giftCards.On("CreateGiftCard", mock.Anything, mock.Anything).Return(nil)
+	deps.giftCards.On("UpdateGiftCard", mock.Anything, mock.Anything).Return(nil)
+
+	responses := map[string]*httptest.ResponseRecorder{
+		"get":    serve(t, h.GetGiftCard, user, http.MethodGet, "/", "", params),
+		"list":   serve(t, h.ListGiftCards, user, http.MethodGet, "/api/v1/gift-cards", "", nil),
+		"create": serve(t, h.CreateGiftCard, user, http.MethodPost, "/api/v1/gift-cards", `{"merchant_name":"Manor","card_number":"GC-2","initial_balance":50,"pin":"secret-1234"}`, nil),
+		"update": serve(t, h.UpdateGiftCard, user, http.MethodPatch, "/", `{"notes":"Birthday"}`, params),
+	}
+	for name, rec := range responses {
+		assert.Less(t, rec.Code, 300, name)
+		assert.NotContains(t, rec.Body.String(), `"pin"`, name)
+		assert.NotContains(t, rec.Body.String(), "secret-", name)
+	}
+	assert.Equal(t, "secret-4711", giftCard.PIN, "Updating other fields keeps the PIN")
+}
+
+func TestCreateGiftCard_LifecycleStatusRefused(t *testing.T) {
+	h, deps := newTestHandler()
+	user := &models.User{ID: uuid.New()}
+
+	body := `{"merchant_name":"Manor","card_number":"GC-1","initial_balance":50,"status":"redeemed"}`
+	rec := serve(t, h.CreateGiftCard, user, http.MethodPost, "/api/v1/gift-cards", body, nil)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Equal(t, "lifecycle", decodeError(t, rec).Fields["status"])
+	deps.giftCards.AssertNotCalled(t, "CreateGiftCard", mock.Anything, mock.Anything)
+}
+
+func TestUpdateGiftCard_Status(t *testing.T) {
+	tests := []struct {
+		name     string
+		current  string
+		body     string
+		wantCode int
+	}{
+		{name: "keep expired", current: models.ItemStatusExpired, body: `{"notes":"Birthday"}`, wantCode: http.StatusOK},
+		{name: "deactivate", current: models.ItemStatusExpired, body: `{"status":"inactive"}`, wantCode: http.StatusOK},
+		{name: "reactivate", current: models.ItemStatusInactive, body: `{"status":"active"}`, wantCode: http.StatusOK},
+		{name: "expire by hand", current: models.ItemStatusActive, body: `{"status":"expired"}`, wantCode: http.StatusUnprocessableEntity},
+		{name: "redeem by hand", current: models.ItemStatusActive, body: `{"status":"redeemed"}`, wantCode: http.StatusUnprocessableEntity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, deps := newTestHandler()
+			user := &models.User{ID: uuid.New()}
+			giftCard := &models.GiftCard{
+				ID:             uuid.New(),
+				MerchantName:   "Manor",
+				CardNumber:     "GC-1",
+				InitialBalance: money.New(5000, "CHF"),
+				Currency:       "CHF",
+				BarcodeType:    "CODE128",
+				Status:         tt.current,
+			}
+
+			deps.authz.On("CheckGiftCardAccess", mock.Anything, user.ID, giftCard.ID).Return(ownerPerms, nil)
+			deps.giftCards.On("GetGiftCard", mock.Anything, giftCard.ID).Return(giftCard, nil)
+			deps.giftCards.On("UpdateGiftCard", mock.Anything, mock.Anything).Return(nil)
+
+			rec := serve(t, h.UpdateGiftCard, user, http.MethodPatch, "/", tt.body, map[string]string{"id": giftCard.ID.String()})
+
+			assert.Equal(t, tt.wantCode, rec.Code)
+			if tt.wantCode != http.StatusOK {
+				assert.Equal(t, "lifecycle", decodeError(t, rec).Fields["status"])
+				deps.giftCards.AssertNotCalled(t, "UpdateGiftCard", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestUpdateGiftCard_CurrencyWithTransactions(t *testing.T) {
+	h, deps := newTestHandler()
+	user := &models.User{ID: uuid.New()}
+	giftCard := &models.GiftCard{
+		ID:             uuid.New(),
+		MerchantName:   "Manor",
+		CardNumber:     "GC-1",
+		InitialBalance: money.New(5000, "CHF"),
+		Currency:       "CHF",
+		BarcodeType:    "CODE128",
+		Status:         models.ItemStatusActive,
+		Transactions:   []models.GiftCardTransaction{{ID: uuid.New(), Kind: models.TransactionKindPurchase, Amount: money.New(1250, "CHF")}},
+	}
+
+	deps.authz.On("CheckGiftCardAccess", mock.Anything, user.ID, giftCard.ID).Return(ownerPerms, nil)
+	deps.giftCards.On("GetGiftCard", mock.Anything, giftCard.ID).Return(giftCard, nil)
+	deps.giftCards.On("UpdateGiftCard", mock.Anything, mock.Anything).Return(nil)
+	params := map[string]string{"id": giftCard.ID.String()}
+
+	rec := serve(t, h.UpdateGiftCard, user, http.MethodPatch, "/", `{"currency":"JPY","initial_balance":5000}`, params)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Equal(t, "has_transactions", decodeError(t, rec).Fields["currency"])
+	deps.giftCards.AssertNotCalled(t, "UpdateGiftCard", mock.Anything, mock.Anything)
+	assert.Equal(t, "CHF", giftCard.Currency)
+
+	// Other fields can still be changed
+	rec = serve(t, h.UpdateGiftCard, user, http.MethodPatch, "/", `{"currency":"CHF","notes":"Birthday"}`, params)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
@@ -0,0 +1,83 @@
+// Package api contains the versioned JSON REST API handlers (/api/v1).
+package api
+
+import (
+	"net/http"
+	"savvy/internal/handlers/shares"
+	"savvy/internal/models"
+	"savvy/internal/services"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// Handler serves the JSON API for cards, vouchers and gift cards.
+// It reuses the same services and share adapters as the HTML handlers,
+// so authorization and business rules stay identical.
+type Handler struct {
+	cardService     services.CardServiceInterface
+	voucherService  services.VoucherServiceInterface
+	giftCardService services.GiftCardServiceInterface
+	authzService    services.AuthzServiceInterface
+	merchantService services.MerchantServiceInterface
+	cardShares      shares.ShareAdapter
+	voucherShares   shares.ShareAdapter
+	giftCardShares  shares.ShareAdapter
+	db              *gorm.DB
+}
+
+// NewHandler creates a new API handler.
+func NewHandler(
+	cardService services.CardServiceInterface,
+	voucherService services.VoucherServiceInterface,
+	giftCardService services.GiftCardServiceInterface,
+	authzService services.AuthzServiceInterface,
+	merchantService services.MerchantServiceInterface,
+	cardShares shares.ShareAdapter,
+	voucherShares shares.ShareAdapter,
+	giftCardShares shares.ShareAdapter,
+	db *gorm.DB,
+) *Handler {
+	return &Handler{
+		cardService:     cardService,
+		voucherService:  voucherService,
+		giftCardService: giftCardService,
+		authzService:    authzService,
+		merchantService: merchantService,
+		cardShares:      cardShares,
+		voucherShares:   voucherShares,
+		giftCardShares:  giftCardShares,
+		db:              db,
+	}
+}
+
+// currentUser returns the authenticated user set by SetCurrentUser.
+func currentUser(c echo.Context) *models.User {
+	user, _ := c.Get("current_user").(*models.User)
+	return user
+}
+
+// parseIDParam parses a UUID path parameter.
+func parseIDParam(c echo.Context, name string) (uuid.UUID, error) {
+	id, err := uuid.Parse(c.Param(name))
+	if err != nil {
+		return uuid.Nil, newError(http.StatusBadRequest, CodeBadRequest, "invalid "+name)
+	}
+	return id, nil
+}
+
+// resolveMerchant fills merchant ID and name from the request input.
+// A known merchant ID wins over the free-text merchant name.
+func (h *Handler) resolveMerchant(c echo.Context, merchantID, merchantName string) (*uuid.UUID, string) {
+	if merchantID != "" {
+		id, err := uuid.Parse(merchantID)
+		if err == nil {
+			merchant, err := h.merchantService.GetMerchantByID(c.Request().Context(), id)
+			if err == nil {
+				return &id, merchant.Name
+			}
+		}
+	}
+	return nil, merchantName
+}
@@ -0,0 +1,407 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"savvy/internal/handlers/shares"
+	"savvy/internal/models"
+	"savvy/internal/money"
+	"savvy/internal/services"
+)
+
+// MockCardService is a manual mock for CardServiceInterface
+type MockCardService struct {
+	mock.Mock
+}
+
+func (m *MockCardService) CreateCard(ctx context.Context, card *models.Card) error {
+	args := m.Called(ctx, card)
+	return args.Error(0)
+}
+
+func (m *MockCardService) GetCard(ctx context.Context, id uuid.UUID) (*models.Card, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Card), args.Error(1)
+}
+
+func (m *MockCardService) GetUserCards(ctx context.Context, userID uuid.UUID) ([]models.Card, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Card), args.Error(1)
+}
+
+func (m *MockCardService) UpdateCard(ctx context.Context, card *models.Card) error {
+	args := m.Called(ctx, card)
+	return args.Error(0)
+}
+
+func (m *MockCardService) DeleteCard(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockCardService) CountUserCards(ctx context.Context, userID uuid.UUID) (int64, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockCardService) CanUserAccessCard(ctx context.Context, userID, cardID uuid.UUID) (bool, error) {
+	args := m.Called(ctx, userID, cardID)
+	return args.Bool(0), args.Error(1)
+}
+
+// MockAuthzService is a manual mock for AuthzServiceInterface
+type MockAuthzService struct {
+	mock.Mock
+}
+
+func (m *MockAuthzService) CheckCardAccess(ctx context.Context, userID, cardID uuid.UUID) (*services.ResourcePermissions, error) {
+	args := m.Called(ctx, userID, cardID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.ResourcePermissions), args.Error(1)
+}
+
+func (m *MockAuthzService) CheckVoucherAccess(ctx context.Context, userID, voucherID uuid.UUID) (*services.ResourcePermissions, error) {
+	args := m.Called(ctx, userID, voucherID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.ResourcePermissions), args.Error(1)
+}
+
+func (m *MockAuthzService) CheckGiftCardAccess(ctx context.Context, userID, giftCardID uuid.UUID) (*services.ResourcePermissions, error) {
+	args := m.Called(ctx, userID, giftCardID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.ResourcePermissions), args.Error(1)
+}
+
+// MockMerchantService is a manual mock for MerchantServiceInterface
+type MockMerchantService struct {
+	mock.Mock
+}
+
+func (m *MockMerchantService) CreateMerchant(ctx context.Context, merchant *models.Merchant) error {
+	args := m.Called(ctx, merchant)
+	return args.Error(0)
+}
+
+func (m *MockMerchantService) GetMerchantByID(ctx context.Context, id uuid.UUID) (*models.Merchant, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Merchant), args.Error(1)
+}
+
+func (m *MockMerchantService) GetMerchantByName(ctx context.Context, name string) (*models.Merchant, error) {
+	args := m.Called(ctx, name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Merchant), args.Error(1)
+}
+
+func (m *MockMerchantService) GetAllMerchants(ctx context.Context) ([]models.Merchant, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Merchant), args.Error(1)
+}
+
+func (m *MockMerchantService) SearchMerchants(ctx context.Context, query string) ([]models.Merchant, error) {
+	args := m.Called(ctx, query)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Merchant), args.Error(1)
+}
+
+func (m *MockMerchantService) UpdateMerchant(ctx context.Context, merchant *models.Merchant) error {
+	args := m.Called(ctx, merchant)
+	return args.Error(0)
+}
+
+func (m *MockMerchantService) DeleteMerchant(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockMerchantService) GetMerchantCount(ctx context.Context) (int64, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// MockVoucherService is a manual mock for VoucherServiceInterface
+type MockVoucherService struct {
+	mock.Mock
+}
+
+func (m *MockVoucherService) CreateVoucher(ctx context.Context, voucher *models.Voucher) error {
+	args := m.Called(ctx, voucher)
+	return args.Error(0)
+}
+
+func (m *MockVoucherService) GetVoucher(ctx context.Context, id uuid.UUID) (*models.Voucher, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Voucher), args.Error(1)
+}
+
+func (m *MockVoucherService) GetUserVouchers(ctx context.Context, userID uuid.UUID) ([]models.Voucher, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Voucher), args.Error(1)
+}
+
+func (m *MockVoucherService) UpdateVoucher(ctx context.Context, voucher *models.Voucher) error {
+	args := m.Called(ctx, voucher)
+	return args.Error(0)
+}
+
+func (m *MockVoucherService) DeleteVoucher(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockVoucherService) CountUserVouchers(ctx context.Context, userID uuid.UUID) (int64, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// MockGiftCardService is a manual mock for GiftCardServiceInterface
+type MockGiftCardService struct {
+	mock.Mock
+}
+
+func (m *MockGiftCardService) CreateGiftCard(ctx context.Context, giftCard *models.GiftCard) error {
+	args := m.Called(ctx, giftCard)
+	return args.Error(0)
+}
+
+func (m *MockGiftCardService) GetGiftCard(ctx context.Context, id uuid.UUID) (*models.GiftCard, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.GiftCard), args.Error(1)
+}
+
+func (m *MockGiftCardService) GetUserGiftCards(ctx context.Context, userID uuid.UUID) ([]models.GiftCard, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.GiftCard), args.Error(1)
+}
+
+func (m *MockGiftCardService) UpdateGiftCard(ctx context.Context, giftCard *models.GiftCard) error {
+	args := m.Called(ctx, giftCard)
+	return args.Error(0)
+}
+
+func (m *MockGiftCardService) DeleteGiftCard(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockGiftCardService) CountUserGiftCards(ctx context.Context, userID uuid.UUID) (int64, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockGiftCardService) GetCurrentBalance(ctx context.Context, giftCardID uuid.UUID) (money.Money, error) {
+	args := m.Called(ctx, giftCardID)
+	return args.Get(0).(money.Money), args.Error(1)
+}
+
+func (m *MockGiftCardService) CanUserAccessGiftCard(ctx context.Context, giftCardID, userID uuid.UUID) (bool, error) {
+	args := m.Called(ctx, giftCardID, userID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockGiftCardService) CreateTransaction(ctx context.Context, transaction *models.GiftCardTransaction) error {
+	args := m.Called(ctx, transaction)
+	return args.Error(0)
+}
+
+func (m *MockGiftCardService) GetTransaction(ctx context.Context, transactionID, giftCardID uuid.UUID) (*models.GiftCardTransaction, error) {
+	args := m.Called(ctx, transactionID, giftCardID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.GiftCardTransaction), args.Error(1)
+}
+
+func (m *MockGiftCardService) UpdateTransaction(ctx context.Context, transaction *models.GiftCardTransaction, userID uuid.UUID) error {
+	args := m.Called(ctx, transaction, userID)
+	return args.Error(0)
+}
+
+func (m *MockGiftCardService) DeleteTransaction(ctx context.Context, transactionID uuid.UUID) error {
+	args := m.Called(ctx, transactionID)
+	return args.Error(0)
+}
+
+func (m *MockGiftCardService) GetPINReveals(ctx context.Context, giftCardID uuid.UUID) ([]models.AuditLog, error) {
+	args := m.Called(ctx, giftCardID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.AuditLog), args.Error(1)
+}
+
+// MockShareAdapter is a manual mock for shares.ShareAdapter
+type MockShareAdapter struct {
+	mock.Mock
+	supportsEdit   bool
+	hasTransaction bool
+}
+
+func (m *MockShareAdapter) ResourceType() string { return "cards" }
+
+func (m *MockShareAdapter) ResourceName() string { return "Card" }
+
+func (m *MockShareAdapter) CheckOwnership(ctx context.Context, userID, resourceID uuid.UUID) (bool, error) {
+	args := m.Called(ctx, userID, resourceID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockShareAdapter) ListShares(ctx context.Context, resourceID uuid.UUID) ([]shares.ShareView, error) {
+	args := m.Called(ctx, resourceID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]shares.ShareView), args.Error(1)
+}
+
+func (m *MockShareAdapter) CreateShare(ctx context.Context, req shares.CreateShareRequest) error {
+	args := m.Called(ctx, req)
+	return args.Error(0)
+}
+
+func (m *MockShareAdapter) UpdateShare(ctx context.Context, req shares.UpdateShareRequest) error {
+	args := m.Called(ctx, req)
+	return args.Error(0)
+}
+
+func (m *MockShareAdapter) DeleteShare(ctx context.Context, shareID uuid.UUID) error {
+	args := m.Called(ctx, shareID)
+	return args.Error(0)
+}
+
+func (m *MockShareAdapter) SupportsEdit() bool { return m.supportsEdit }
+
+func (m *MockShareAdapter) HasTransactionPermission() bool { return m.hasTransaction }
+
+// testDeps bundles the mocks behind a Handler
+type testDeps struct {
+	cards     *MockCardService
+	vouchers  *MockVoucherService
+	giftCards *MockGiftCardService
+	authz     *MockAuthzService
+	merchants *MockMerchantService
+	shares    *MockShareAdapter
+}
+
+func newTestHandler() (*Handler, *testDeps) {
+	deps := &testDeps{
+		cards:     new(MockCardService),
+		vouchers:  new(MockVoucherService),
+		giftCards: new(MockGiftCardService),
+		authz:     new(MockAuthzService),
+		merchants: new(MockMerchantService),
+		shares:    &MockShareAdapter{supportsEdit: true},
+	}
+	h := NewHandler(deps.cards, deps.vouchers, deps.giftCards, deps.authz, deps.merchants, deps.shares, deps.shares, deps.shares, nil)
+	return h, deps
+}
+
+// serve runs a handler behind the Errors middleware with the given user and path params
+func serve(t *testing.T, handler echo.HandlerFunc, user *models.User, method, target, body string, params map[string]string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	e := echo.New()
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	}
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	if user != nil {
+		c.Set("current_user", user)
+	}
+	if len(params) > 0 {
+		names := make([]string, 0, len(params))
+		values := make([]string, 0, len(params))
+		for name, value := range params {
+			names = append(names, name)
+			values = append(values, value)
+		}
+		c.SetParamNames(names...)
+		c.SetParamValues(values...)
+	}
+
+	require.NoError(t, Errors(handler)(c))
+	return rec
+}
+
+// decodeError decodes an ErrorResponse body
+func decodeError(t *testing.T, rec *httptest.ResponseRecorder) *Error {
+	t.Helper()
+
+	var resp ErrorResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.NotNil(t, resp.Error)
+	return resp.Error
+}
+
+var (
+	ownerPerms  = &services.ResourcePermissions{CanView: true, CanEdit: true, CanDelete: true, CanEditTransactions: true, IsOwner: true}
+	viewerPerms = &services.ResourcePermissions{CanView: true}
+)
+
+func TestErrors_RendersHTTPErrors(t *testing.T) {
+	rec := serve(t, func(c echo.Context) error {
+		return echo.NewHTTPError(http.StatusNotFound, "Cards feature is disabled")
+	}, nil, http.MethodGet, "/api/v1/cards", "", nil)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	apiErr := decodeError(t, rec)
+	assert.Equal(t, CodeNotFound, apiErr.Code)
+	assert.Equal(t, "Cards feature is disabled", apiErr.Message)
+}
+
+func TestErrors_HidesUnexpectedErrors(t *testing.T) {
+	rec := serve(t, func(c echo.Context) error {
+		return assert.AnError
+	}, nil, http.MethodGet, "/api/v1/cards", "", nil)
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	apiErr := decodeError(t, rec)
+	assert.Equal(t, CodeInternal, apiErr.Code)
+	assert.NotContains(t, apiErr.Message, assert.AnError.Error())
+}
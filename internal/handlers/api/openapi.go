@@ -0,0 +1,327 @@
+package api
+
+import (
+	"net/http"
+	"savvy/internal/models"
+	"savvy/internal/validation"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Document is the subset of an OpenAPI 3.0 document produced by Spec.
+type Document struct {
+	OpenAPI    string                `json:"openapi"`
+	Info       Info                  `json:"info"`
+	Servers    []Server              `json:"servers"`
+	Security   []map[string][]string `json:"security"`
+	Tags       []Tag                 `json:"tags"`
+	Paths      map[string]PathItem   `json:"paths"`
+	Components Components            `json:"components"`
+}
+
+// Info describes the API.
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Version     string `json:"version"`
+}
+
+// Server is a base URL of the API.
+type Server struct {
+	URL string `json:"url"`
+}
+
+// Tag groups operations.
+type Tag struct {
+	Name string `json:"name"`
+}
+
+// PathItem maps lower-case HTTP methods to operations.
+type PathItem map[string]*Operation
+
+// Operation describes a single route.
+type Operation struct {
+	OperationID string                 `json:"operationId"`
+	Summary     string                 `json:"summary"`
+	Description string                 `json:"description,omitempty"`
+	Tags        []string               `json:"tags"`
+	Security    *[]map[string][]string `json:"security,omitempty"`
+	Parameters  []Parameter            `json:"parameters,omitempty"`
+	RequestBody *RequestBody           `json:"requestBody,omitempty"`
+	Responses   map[string]Response    `json:"responses"`
+}
+
+// Parameter is a path or query parameter.
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody is a JSON request body.
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response is an operation response.
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType holds the schema of a body.
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Components holds reusable schemas and security schemes.
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes"`
+}
+
+// SecurityScheme describes an authentication method.
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	In           string `json:"in,omitempty"`
+	Name         string `json:"name,omitempty"`
+	Description  string `json:"description,omitempty"`
+}
+
+// SpecPath is the route the OpenAPI document is served at.
+const SpecPath = "/api/v1/openapi.json"
+
+// route declares one API operation for the spec.
+// Paths use echo syntax (":id") and are converted to OpenAPI templates.
+type route struct {
+	method   string
+	path     string
+	id       string
+	summary  string
+	scope    string   // API token resource scope, "" for public routes
+	list     bool     // paginated collection
+	statuses []string // values of the ?status= filter of a list
+	request  *Schema
+	response *Schema // nil for 204 responses
+	status   int
+}
+
+// Spec builds the OpenAPI document for /api/v1.
+// Request schemas come from the internal/validation structs (including the
+// defaults the handlers apply on create), response schemas from the models.
+func Spec(version string) *Document {
+	reg := newSchemaRegistry()
+
+	card, voucher, giftCard := reg.ref(models.Card{}), reg.ref(models.Voucher{}), reg.ref(models.GiftCard{})
+	transaction, share := reg.ref(models.GiftCardTransaction{}), reg.ref(Share{})
+	// Encoded by Voucher.MarshalJSON
+	reg.components["Voucher"].Properties["value"] = &Schema{
+		Type: "number", Format: "double",
+		Description: "Amount in CHF for fixed_amount, percentage or points multiplier otherwise",
+	}
+	cardCreate, cardPatch := reg.request(newCardRequest())
+	voucherCreate, voucherPatch := reg.request(newVoucherRequest())
+	giftCardCreate, giftCardPatch := reg.request(newGiftCardRequest())
+	transactionCreate := reg.ref(validation.TransactionRequest{})
+	shareCreate, sharePatch := reg.ref(validation.ShareRequest{}), reg.ref(validation.SharePermissionsRequest{})
+	errorResponse := reg.ref(ErrorResponse{})
+	reg.ref(Pagination{})
+
+	routes := []route{
+		{method: http.MethodGet, path: "/cards", id: "listCards", summary: "List own and shared cards", scope: models.APIScopeCards, list: true, response: card},
+		{method: http.MethodPost, path: "/cards", id: "createCard", summary: "Create a card", scope: models.APIScopeCards, request: cardCreate, response: card, status: http.StatusCreated},
+		{method: http.MethodGet, path: "/cards/:id", id: "getCard", summary: "Get a card", scope: models.APIScopeCards, response: card},
+		{method: http.MethodPatch, path: "/cards/:id", id: "updateCard", summary: "Update a card (edit permission)", scope: models.APIScopeCards, request: cardPatch, response: card},
+		{method: http.MethodDelete, path: "/cards/:id", id: "deleteCard", summary: "Delete a card (delete permission)", scope: models.APIScopeCards},
+		{method: http.MethodGet, path: "/cards/:id/shares", id: "listCardShares", summary: "List the shares of a card (owner only)", scope: models.APIScopeCards, list: true, response: share},
+		{method: http.MethodPost, path: "/cards/:id/shares", id: "createCardShare", summary: "Share a card (owner only)", scope: models.APIScopeCards, request: shareCreate, response: share, status: http.StatusCreated},
+		{method: http.MethodPatch, path: "/cards/:id/shares/:share_id", id: "updateCardShare", summary: "Change the permissions of a card share (owner only)", scope: models.APIScopeCards, request: sharePatch, response: share},
+		{method: http.MethodDelete, path: "/cards/:id/shares/:share_id", id: "deleteCardShare", summary: "Revoke a card share (owner only)", scope: models.APIScopeCards},
+
+		{method: http.MethodGet, path: "/vouchers", id: "listVouchers", summary: "List own and shared vouchers", scope: models.APIScopeVouchers, list: true, statuses: voucherStatuses, response: voucher},
+		{method: http.MethodPost, path: "/vouchers", id: "createVoucher", summary: "Create a voucher", scope: models.APIScopeVouchers, request: voucherCreate, response: voucher, status: http.StatusCreated},
+		{method: http.MethodGet, path: "/vouchers/:id", id: "getVoucher", summary: "Get a voucher", scope: models.APIScopeVouchers, response: voucher},
+		{method: http.MethodPatch, path: "/vouchers/:id", id: "updateVoucher", summary: "Update a voucher (edit permission)", scope: models.APIScopeVouchers, request: voucherPatch, response: voucher},
+		{method: http.MethodDelete, path: "/vouchers/:id", id: "deleteVoucher", summary: "Delete a voucher (delete permission)", scope: models.APIScopeVouchers},
+		{method: http.MethodGet, path: "/vouchers/:id/shares", id: "listVoucherShares", summary: "List the shares of a voucher (owner only)", scope: models.APIScopeVouchers, list: true, response: share},
+		{method: http.MethodPost, path: "/vouchers/:id/shares", id: "createVoucherShare", summary: "Share a voucher read-only (owner only)", scope: models.APIScopeVouchers, request: shareCreate, response: share, status: http.StatusCreated},
+		{method: http.MethodDelete, path: "/vouchers/:id/shares/:share_id", id: "deleteVoucherShare", summary: "Revoke a voucher share (owner only)", scope: models.APIScopeVouchers},
+
+		{method: http.MethodGet, path: "/gift-cards", id: "listGiftCards", summary: "List own and shared gift cards", scope: models.APIScopeGiftCards, list: true, statuses: giftCardStatuses, response: giftCard},
+		{method: http.MethodPost, path: "/gift-cards", id: "createGiftCard", summary: "Create a gift card", scope: models.APIScopeGiftCards, request: giftCardCreate, response: giftCard, status: http.StatusCreated},
+		{method: http.MethodGet, path: "/gift-cards/:id", id: "getGiftCard", summary: "Get a gift card including its transactions", scope: models.APIScopeGiftCards, response: giftCard},
+		{method: http.MethodPatch, path: "/gift-cards/:id", id: "updateGiftCard", summary: "Update a gift card (edit permission)", scope: models.APIScopeGiftCards, request: giftCardPatch, response: giftCard},
+		{method: http.MethodDelete, path: "/gift-cards/:id", id: "deleteGiftCard", summary: "Delete a gift card (delete permission)", scope: models.APIScopeGiftCards},
+		{method: http.MethodGet, path: "/gift-cards/:id/transactions", id: "listTransactions", summary: "List the transactions of a gift card, newest first", scope: models.APIScopeGiftCards, list: true, response: transaction},
+		{method: http.MethodPost, path: "/gift-cards/:id/transactions", id: "createTransaction", summary: "Record an expense (transaction permission)", scope: models.APIScopeGiftCards, request: transactionCreate, response: transaction, status: http.StatusCreated},
+		{method: http.MethodDelete, path: "/gift-cards/:id/transactions/:transaction_id", id: "deleteTransaction", summary: "Delete a transaction (transaction permission)", scope: models.APIScopeGiftCards},
+		{method: http.MethodGet, path: "/gift-cards/:id/shares", id: "listGiftCardShares", summary: "List the shares of a gift card (owner only)", scope: models.APIScopeGiftCards, list: true, response: share},
+		{method: http.MethodPost, path: "/gift-cards/:id/shares", id: "createGiftCardShare", summary: "Share a gift card (owner only)", scope: models.APIScopeGiftCards, request: shareCreate, response: share, status: http.StatusCreated},
+		{method: http.MethodPatch, path: "/gift-cards/:id/shares/:share_id", id: "updateGiftCardShare", summary: "Change the permissions of a gift card share (owner only)", scope: models.APIScopeGiftCards, request: sharePatch, response: share},
+		{method: http.MethodDelete, path: "/gift-cards/:id/shares/:share_id", id: "deleteGiftCardShare", summary: "Revoke a gift card share (owner only)", scope: models.APIScopeGiftCards},
+
+		{method: http.MethodGet, path: strings.TrimPrefix(SpecPath, "/api/v1"), id: "getOpenAPISpec", summary: "This OpenAPI document", response: &Schema{Type: "object"}},
+	}
+
+	doc := &Document{
+		OpenAPI: "3.0.3",
+		Info: Info{
+			Title:       "Savvy API",
+			Description: "JSON API for loyalty cards, vouchers and gift cards. Authenticate with a personal API token (Authorization: Bearer) or the browser session.",
+			Version:     version,
+		},
+		Servers:  []Server{{URL: "/api/v1"}},
+		Security: []map[string][]string{{"bearerAuth": {}}, {"sessionCookie": {}}},
+		Tags:     []Tag{{Name: "cards"}, {Name: "vouchers"}, {Name: "gift_cards"}, {Name: "meta"}},
+		Paths:    map[string]PathItem{},
+		Components: Components{
+			Schemas: reg.components,
+			SecuritySchemes: map[string]SecurityScheme{
+				"bearerAuth": {
+					Type:         "http",
+					Scheme:       "bearer",
+					BearerFormat: "svy_…",
+					Description:  "Personal API token created on the account page. Write scopes are needed for all methods except GET.",
+				},
+				"sessionCookie": {
+					Type:        "apiKey",
+					In:          "cookie",
+					Name:        "session",
+					Description: "Browser session. Non-GET requests additionally need the X-CSRF-Token header.",
+				},
+			},
+		},
+	}
+
+	for _, r := range routes {
+		path, params := openAPIPath(r.path)
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = PathItem{}
+		}
+		doc.Paths[path][strings.ToLower(r.method)] = r.operation(params, errorResponse)
+	}
+
+	return doc
+}
+
+func (r route) operation(pathParams []string, errorResponse *Schema) *Operation {
+	op := &Operation{
+		OperationID: r.id,
+		Summary:     r.summary,
+		Tags:        []string{"meta"},
+		Responses:   map[string]Response{},
+	}
+
+	if r.scope == "" {
+		op.Security = &[]map[string][]string{}
+	} else {
+		op.Tags = []string{r.scope}
+		level := models.APIAccessWrite
+		if r.method == http.MethodGet {
+			level = models.APIAccessRead
+		}
+		op.Description = "Token scope: " + r.scope + ":" + level
+	}
+
+	for _, name := range pathParams {
+		op.Parameters = append(op.Parameters, Parameter{Name: name, In: "path", Required: true, Schema: &Schema{Type: "string", Format: "uuid"}})
+	}
+	if r.list {
+		one, maxPer, defPer := 1.0, float64(maxPerPage), defaultPerPage
+		op.Parameters = append(op.Parameters,
+			Parameter{Name: "page", In: "query", Description: "1-based page number", Schema: &Schema{Type: "integer", Minimum: &one, Default: 1}},
+			Parameter{Name: "per_page", In: "query", Description: "Items per page", Schema: &Schema{Type: "integer", Minimum: &one, Maximum: &maxPer, Default: defPer}},
+		)
+	}
+	if len(r.statuses) > 0 {
+		enum := make([]any, len(r.statuses))
+		for i, status := range r.statuses {
+			enum[i] = status
+		}
+		op.Parameters = append(op.Parameters, Parameter{Name: "status", In: "query", Description: "Only items with this status", Schema: &Schema{Type: "string", Enum: enum}})
+	}
+
+	if r.request != nil {
+		op.RequestBody = &RequestBody{Required: true, Content: jsonContent(r.request)}
+	}
+
+	status := r.status
+	switch {
+	case r.response == nil:
+		op.Responses[strconv.Itoa(http.StatusNoContent)] = Response{Description: "Deleted"}
+	case r.list:
+		op.Responses["200"] = Response{Description: "OK", Content: jsonContent(listSchema(r.response))}
+	default:
+		if status == 0 {
+			status = http.StatusOK
+		}
+		op.Responses[strconv.Itoa(status)] = Response{Description: http.StatusText(status), Content: jsonContent(r.response)}
+	}
+
+	if r.scope != "" {
+		errorStatuses := []int{http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound}
+		if r.request != nil || len(pathParams) > 0 || r.list {
+			errorStatuses = append(errorStatuses, http.StatusBadRequest)
+		}
+		if r.request != nil {
+			errorStatuses = append(errorStatuses, http.StatusUnprocessableEntity)
+		}
+		if r.request != nil && r.method == http.MethodPost {
+			errorStatuses = append(errorStatuses, http.StatusConflict)
+		}
+		for _, code := range errorStatuses {
+			op.Responses[strconv.Itoa(code)] = Response{Description: http.StatusText(code), Content: jsonContent(errorResponse)}
+		}
+	}
+
+	return op
+}
+
+// openAPIPath converts "/cards/:id" into "/cards/{id}" and returns the parameter names.
+func openAPIPath(echoPath string) (string, []string) {
+	segments := strings.Split(echoPath, "/")
+	var params []string
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") {
+			params = append(params, segment[1:])
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/"), params
+}
+
+func listSchema(item *Schema) *Schema {
+	return &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"data":       {Type: "array", Items: item},
+			"pagination": componentRef("Pagination"),
+		},
+		Required: []string{"data", "pagination"},
+	}
+}
+
+func jsonContent(schema *Schema) map[string]MediaType {
+	return map[string]MediaType{echo.MIMEApplicationJSON: {Schema: schema}}
+}
+
+// OpenAPI serves the OpenAPI document. It is public so client generators can fetch it.
+// GET /api/v1/openapi.json
+func OpenAPI(version string) echo.HandlerFunc {
+	var (
+		once sync.Once
+		doc  *Document
+	)
+	return func(c echo.Context) error {
+		once.Do(func() { doc = Spec(version) })
+		return c.JSON(http.StatusOK, doc)
+	}
+}
@@ -0,0 +1,262 @@
+package api
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"savvy/internal/money"
+)
+
+// Schema is the subset of the OpenAPI 3.0 Schema Object used by the spec.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	ReadOnly             bool               `json:"readOnly,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Default              any                `json:"default,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	ExclusiveMinimum     bool               `json:"exclusiveMinimum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	uuidType      = reflect.TypeOf(uuid.UUID{})
+	deletedAtType = reflect.TypeOf(gorm.DeletedAt{})
+	moneyType     = reflect.TypeOf(money.Money{})
+)
+
+// schemaRegistry derives schemas from Go types via reflection and collects
+// named structs as reusable components (which also breaks reference cycles
+// such as GiftCard -> Transactions -> GiftCard).
+type schemaRegistry struct {
+	components map[string]*Schema
+}
+
+func newSchemaRegistry() *schemaRegistry {
+	return &schemaRegistry{components: map[string]*Schema{}}
+}
+
+func componentRef(name string) *Schema {
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// ref registers the type of v as a component and returns a reference to it.
+func (r *schemaRegistry) ref(v any) *Schema {
+	return r.schemaFor(reflect.TypeOf(v))
+}
+
+// request registers a request struct whose zero fields are filled with the
+// values of defaults on create. Fields with a default are not required.
+// A second "<Name>Patch" component without required fields describes partial updates.
+func (r *schemaRegistry) request(defaults any) (create, patch *Schema) {
+	t := reflect.TypeOf(defaults)
+	base := r.structSchema(t)
+
+	createSchema := *base
+	createSchema.Properties = map[string]*Schema{}
+	createSchema.Required = nil
+	patchSchema := createSchema
+	patchSchema.Properties = map[string]*Schema{}
+	patchSchema.Description = "Partial update: omitted fields keep their current value."
+
+	v := reflect.ValueOf(defaults)
+	defaulted := map[string]bool{}
+	for i := 0; i < t.NumField(); i++ {
+		name, ok := jsonName(t.Field(i))
+		if !ok {
+			continue
+		}
+		prop := *base.Properties[name]
+		patchSchema.Properties[name] = &prop
+		if !v.Field(i).IsZero() {
+			withDefault := prop
+			withDefault.Default = v.Field(i).Interface()
+			createSchema.Properties[name] = &withDefault
+			defaulted[name] = true
+			continue
+		}
+		createSchema.Properties[name] = &prop
+	}
+	for _, name := range base.Required {
+		if !defaulted[name] {
+			createSchema.Required = append(createSchema.Required, name)
+		}
+	}
+
+	r.components[t.Name()] = &createSchema
+	r.components[t.Name()+"Patch"] = &patchSchema
+	return componentRef(t.Name()), componentRef(t.Name() + "Patch")
+}
+
+func (r *schemaRegistry) schemaFor(t reflect.Type) *Schema {
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case uuidType:
+		return &Schema{Type: "string", Format: "uuid"}
+	case deletedAtType:
+		return &Schema{Type: "string", Format: "date-time", Nullable: true}
+	case moneyType:
+		return &Schema{Type: "number", Format: "double"}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		s := r.schemaFor(t.Elem())
+		if s.Ref == "" {
+			s.Nullable = true
+		}
+		return s
+	case reflect.Struct:
+		if _, ok := r.components[t.Name()]; !ok {
+			// Reserve the name before descending into fields
+			r.components[t.Name()] = &Schema{}
+			*r.components[t.Name()] = *r.structSchema(t)
+		}
+		return componentRef(t.Name())
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: r.schemaFor(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: r.schemaFor(t.Elem())}
+	case reflect.Interface:
+		return &Schema{}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	default:
+		return &Schema{}
+	}
+}
+
+// structSchema builds an object schema from the JSON tags of a struct.
+// Embedded structs are flattened like encoding/json does.
+func (r *schemaRegistry) structSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && field.Tag.Get("json") == "" {
+			embedded := r.structSchema(field.Type)
+			for name, prop := range embedded.Properties {
+				s.Properties[name] = prop
+			}
+			s.Required = append(s.Required, embedded.Required...)
+			continue
+		}
+
+		name, ok := jsonName(field)
+		if !ok {
+			continue
+		}
+
+		prop := r.schemaFor(field.Type)
+		if applyValidateTag(prop, t, field) {
+			s.Required = append(s.Required, name)
+		}
+		s.Properties[name] = prop
+	}
+
+	return s
+}
+
+// jsonName returns the JSON property name of an exported struct field.
+func jsonName(field reflect.StructField) (string, bool) {
+	if !field.IsExported() {
+		return "", false
+	}
+	name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+	if name == "-" {
+		return "", false
+	}
+	if name == "" {
+		name = field.Name
+	}
+	return name, true
+}
+
+// applyValidateTag maps go-playground/validator rules onto schema keywords
+// and reports whether the field is required.
+func applyValidateTag(s *Schema, parent reflect.Type, field reflect.StructField) bool {
+	tag := field.Tag.Get("validate")
+	if tag == "" || s.Ref != "" {
+		return false
+	}
+
+	required := false
+	isString := s.Type == "string"
+	for _, rule := range strings.Split(tag, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "required":
+			required = true
+		case "required_without":
+			if other, ok := parent.FieldByName(param); ok {
+				otherName, _ := jsonName(other)
+				s.Description = "Required unless " + otherName + " is set."
+			}
+		case "oneof":
+			for _, value := range strings.Fields(param) {
+				s.Enum = append(s.Enum, value)
+			}
+		case "email":
+			s.Format = "email"
+		case "uuid":
+			s.Format = "uuid"
+		case "datetime":
+			if param == "2006-01-02" {
+				s.Format = "date"
+			}
+		case "len":
+			if n, err := strconv.Atoi(param); err == nil && isString {
+				s.MinLength, s.MaxLength = &n, &n
+			}
+		case "min", "max", "gt", "gte":
+			n, err := strconv.ParseFloat(param, 64)
+			if err != nil {
+				continue
+			}
+			if isString {
+				length := int(n)
+				if name == "max" {
+					s.MaxLength = &length
+				} else {
+					s.MinLength = &length
+				}
+				continue
+			}
+			if name == "max" {
+				s.Maximum = &n
+			} else {
+				s.Minimum = &n
+				s.ExclusiveMinimum = name == "gt"
+			}
+		case "ne":
+			if param == "0" {
+				s.Description = "Must not be zero."
+			}
+		}
+	}
+
+	return required
+}
@@ -0,0 +1,156 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpec_RequestSchemaFromValidationTags(t *testing.T) {
+	doc := Spec("test")
+
+	schema := doc.Components.Schemas["CardRequest"]
+	require.NotNil(t, schema)
+	assert.ElementsMatch(t, []string{"program", "card_number"}, schema.Required)
+
+	// Defaults applied by CreateCard are documented and not required
+	assert.Equal(t, "CODE128", schema.Properties["barcode_type"].Default)
+	assert.Equal(t, []any{"CODE128", "QR", "EAN13", "EAN8"}, schema.Properties["barcode_type"].Enum)
+	assert.Equal(t, 255, *schema.Properties["card_number"].MaxLength)
+	assert.Equal(t, "uuid", schema.Properties["merchant_id"].Format)
+	assert.Contains(t, schema.Properties["merchant_name"].Description, "merchant_id")
+
+	patch := doc.Components.Schemas["CardRequestPatch"]
+	require.NotNil(t, patch)
+	assert.Empty(t, patch.Required)
+	assert.Nil(t, patch.Properties["barcode_type"].Default)
+
+	voucher := doc.Components.Schemas["VoucherRequest"]
+	require.NotNil(t, voucher)
+	assert.Equal(t, "date", voucher.Properties["valid_from"].Format)
+	assert.Equal(t, 0.0, *voucher.Properties["value"].Minimum)
+	assert.True(t, voucher.Properties["value"].ExclusiveMinimum)
+
+	giftCard := doc.Components.Schemas["GiftCardRequest"]
+	require.NotNil(t, giftCard)
+	assert.Equal(t, 3, *giftCard.Properties["currency"].MinLength)
+	assert.Equal(t, "CHF", giftCard.Properties["currency"].Default)
+
+	transaction := doc.Components.Schemas["TransactionRequest"]
+	require.NotNil(t, transaction)
+	assert.Equal(t, []string{"amount"}, transaction.Required)
+}
+
+func TestSpec_ModelSchemas(t *testing.T) {
+	doc := Spec("test")
+
+	card := doc.Components.Schemas["Card"]
+	require.NotNil(t, card)
+	assert.Equal(t, "uuid", card.Properties["id"].Format)
+	assert.Equal(t, "#/components/schemas/Merchant", card.Properties["merchant"].Ref)
+	assert.True(t, card.Properties["user_id"].Nullable)
+
+	giftCard := doc.Components.Schemas["GiftCard"]
+	require.NotNil(t, giftCard)
+	assert.Equal(t, "array", giftCard.Properties["transactions"].Type)
+	assert.Equal(t, "#/components/schemas/GiftCardTransaction", giftCard.Properties["transactions"].Items.Ref)
+	assert.NotContains(t, giftCard.Properties, "pin", "PINs are only revealed after re-authentication")
+	assert.NotContains(t, giftCard.Properties, "PIN")
+	assert.Equal(t, "number", giftCard.Properties["current_balance"].Type, "Money is a decimal number")
+
+	voucher := doc.Components.Schemas["Voucher"]
+	require.NotNil(t, voucher)
+	assert.Equal(t, "number", voucher.Properties["value"].Type)
+	assert.NotContains(t, voucher.Properties, "Value")
+
+	// json:"-" fields are not part of the API
+	user := doc.Components.Schemas["User"]
+	require.NotNil(t, user)
+	assert.NotContains(t, user.Properties, "password_hash")
+	assert.NotContains(t, user.Properties, "PasswordHash")
+
+	apiError := doc.Components.Schemas["Error"]
+	require.NotNil(t, apiError)
+	assert.NotContains(t, apiError.Properties, "Status")
+	assert.Contains(t, apiError.Properties, "fields")
+}
+
+func TestSpec_ReferencesResolve(t *testing.T) {
+	doc := Spec("test")
+
+	body, err := json.Marshal(doc)
+	require.NoError(t, err)
+
+	var raw any
+	require.NoError(t, json.Unmarshal(body, &raw))
+
+	var walk func(v any)
+	walk = func(v any) {
+		switch v := v.(type) {
+		case map[string]any:
+			if ref, ok := v["$ref"].(string); ok {
+				name := strings.TrimPrefix(ref, "#/components/schemas/")
+				assert.Contains(t, doc.Components.Schemas, name, "unresolved $ref %s", ref)
+			}
+			for _, child := range v {
+				walk(child)
+			}
+		case []any:
+			for _, child := range v {
+				walk(child)
+			}
+		}
+	}
+	walk(raw)
+}
+
+func TestSpec_StatusFilter(t *testing.T) {
+	doc := Spec("test")
+
+	var status *Parameter
+	for i, param := range doc.Paths["/gift-cards"]["get"].Parameters {
+		if param.Name == "status" {
+			status = &doc.Paths["/gift-cards"]["get"].Parameters[i]
+		}
+	}
+	require.NotNil(t, status)
+	assert.Equal(t, "query", status.In)
+	assert.Contains(t, status.Schema.Enum, "redeemed")
+
+	for _, param := range doc.Paths["/cards"]["get"].Parameters {
+		assert.NotEqual(t, "status", param.Name, "Cards have no status filter")
+	}
+}
+
+func TestSpec_OperationIDsUnique(t *testing.T) {
+	seen := map[string]bool{}
+	for path, item := range Spec("test").Paths {
+		for method, op := range item {
+			assert.False(t, seen[op.OperationID], "duplicate operationId %s (%s %s)", op.OperationID, method, path)
+			seen[op.OperationID] = true
+		}
+	}
+}
+
+func TestOpenAPI_Handler(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, SpecPath, nil)
+	rec := httptest.NewRecorder()
+
+	err := OpenAPI("1.2.3")(e.NewContext(req, rec))
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var doc Document
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &doc))
+	assert.Equal(t, "3.0.3", doc.OpenAPI)
+	assert.Equal(t, "1.2.3", doc.Info.Version)
+	assert.Contains(t, doc.Paths, "/cards/{id}")
+}
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	defaultPerPage = 25
+	maxPerPage     = 100
+)
+
+// Pagination describes the page returned in a ListResponse.
+type Pagination struct {
+	Page       int `json:"page"`
+	PerPage    int `json:"per_page"`
+	Total      int `json:"total"`
+	TotalPages int `json:"total_pages"`
+}
+
+// ListResponse is the JSON body of every collection endpoint.
+type ListResponse[T any] struct {
+	Data       []T        `json:"data"`
+	Pagination Pagination `json:"pagination"`
+}
+
+// parsePagination reads ?page= and ?per_page= (1-based, per_page capped at 100).
+func parsePagination(c echo.Context) (page, perPage int, err error) {
+	page, perPage = 1, defaultPerPage
+
+	if v := c.QueryParam("page"); v != "" {
+		page, err = strconv.Atoi(v)
+		if err != nil || page < 1 {
+			return 0, 0, newError(http.StatusBadRequest, CodeBadRequest, "page must be a positive integer")
+		}
+	}
+
+	if v := c.QueryParam("per_page"); v != "" {
+		perPage, err = strconv.Atoi(v)
+		if err != nil || perPage < 1 || perPage > maxPerPage {
+			return 0, 0, newError(http.StatusBadRequest, CodeBadRequest, "per_page must be between 1 and 100")
+		}
+	}
+
+	return page, perPage, nil
+}
+
+// paginate slices items into the requested page.
+func paginate[T any](items []T, page, perPage int) ListResponse[T] {
+	total := len(items)
+	totalPages := (total + perPage - 1) / perPage
+
+	start := (page - 1) * perPage
+	if start > total {
+		start = total
+	}
+	end := start + perPage
+	if end > total {
+		end = total
+	}
+
+	data := items[start:end]
+	if data == nil {
+		data = []T{}
+	}
+
+	return ListResponse[T]{
+		Data: data,
+		Pagination: Pagination{
+			Page:       page,
+			PerPage:    perPage,
+			Total:      total,
+			TotalPages: totalPages,
+		},
+	}
+}
+
+// parseStatusFilter reads ?status=, which must be one of statuses if given.
+func parseStatusFilter(c echo.Context, statuses []string) (string, error) {
+	status := c.QueryParam("status")
+	if status != "" && !slices.Contains(statuses, status) {
+		return "", newError(http.StatusBadRequest, CodeBadRequest, "status must be one of: "+strings.Join(statuses, ", "))
+	}
+	return status, nil
+}
+
+// filterStatus keeps the items with the given status, all of them if status is empty.
+func filterStatus[T any](items []T, status string, statusOf func(T) string) []T {
+	if status == "" {
+		return items
+	}
+	filtered := make([]T, 0, len(items))
+	for _, item := range items {
+		if statusOf(item) == status {
+			filtered = append(filtered, item)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,292 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"savvy/internal/audit"
+	"savvy/internal/handlers/shares"
+	"savvy/internal/models"
+	"savvy/internal/services"
+	"savvy/internal/validation"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// Share is the JSON representation of a card, voucher or gift card share.
+type Share struct {
+	ID                  uuid.UUID    `json:"id"`
+	ResourceID          uuid.UUID    `json:"resource_id"`
+	SharedWith          *models.User `json:"shared_with"`
+	CanEdit             bool         `json:"can_edit"`
+	CanDelete           bool         `json:"can_delete"`
+	CanEditTransactions bool         `json:"can_edit_transactions"`
+	CreatedAt           time.Time    `json:"created_at"`
+}
+
+func newShare(view shares.ShareView) Share {
+	return Share{
+		ID:                  view.ID,
+		ResourceID:          view.ResourceID,
+		SharedWith:          view.SharedWith,
+		CanEdit:             view.CanEdit,
+		CanDelete:           view.CanDelete,
+		CanEditTransactions: view.CanEditTransactions,
+		CreatedAt:           view.CreatedAt,
+	}
+}
+
+// ListCardShares lists the shares of a card (owner only).
+// GET /api/v1/cards/:id/shares
+func (h *Handler) ListCardShares(c echo.Context) error {
+	return h.listShares(c, h.cardShares)
+}
+
+// CreateCardShare shares a card with another user (owner only).
+// POST /api/v1/cards/:id/shares
+func (h *Handler) CreateCardShare(c echo.Context) error {
+	return h.createShare(c, h.cardShares)
+}
+
+// UpdateCardShare changes the permissions of a card share (owner only).
+// PATCH /api/v1/cards/:id/shares/:share_id
+func (h *Handler) UpdateCardShare(c echo.Context) error {
+	return h.updateShare(c, h.cardShares)
+}
+
+// DeleteCardShare revokes a card share (owner only).
+// DELETE /api/v1/cards/:id/shares/:share_id
+func (h *Handler) DeleteCardShare(c echo.Context) error {
+	return h.deleteShare(c, h.cardShares)
+}
+
+// ListVoucherShares lists the shares of a voucher (owner only).
+// GET /api/v1/vouchers/:id/shares
+func (h *Handler) ListVoucherShares(c echo.Context) error {
+	return h.listShares(c, h.voucherShares)
+}
+
+// CreateVoucherShare shares a voucher (always read-only) with another user.
+// POST /api/v1/vouchers/:id/shares
+func (h *Handler) CreateVoucherShare(c echo.Context) error {
+	return h.createShare(c, h.voucherShares)
+}
+
+// DeleteVoucherShare revokes a voucher share (owner only).
+// DELETE /api/v1/vouchers/:id/shares/:share_id
+func (h *Handler) DeleteVoucherShare(c echo.Context) error {
+	return h.deleteShare(c, h.voucherShares)
+}
+
+// ListGiftCardShares lists the shares of a gift card (owner only).
+// GET /api/v1/gift-cards/:id/shares
+func (h *Handler) ListGiftCardShares(c echo.Context) error {
+	return h.listShares(c, h.giftCardShares)
+}
+
+// CreateGiftCardShare shares a gift card with another user (owner only).
+// POST /api/v1/gift-cards/:id/shares
+func (h *Handler) CreateGiftCardShare(c echo.Context) error {
+	return h.createShare(c, h.giftCardShares)
+}
+
+// UpdateGiftCardShare changes the permissions of a gift card share (owner only).
+// PATCH /api/v1/gift-cards/:id/shares/:share_id
+func (h *Handler) UpdateGiftCardShare(c echo.Context) error {
+	return h.updateShare(c, h.giftCardShares)
+}
+
+// DeleteGiftCardShare revokes a gift card share (owner only).
+// DELETE /api/v1/gift-cards/:id/shares/:share_id
+func (h *Handler) DeleteGiftCardShare(c echo.Context) error {
+	return h.deleteShare(c, h.giftCardShares)
+}
+
+// requireOwner parses :id and verifies the current user owns the resource.
+func requireOwner(c echo.Context, adapter shares.ShareAdapter) (uuid.UUID, error) {
+	user := currentUser(c)
+
+	resourceID, err := parseIDParam(c, "id")
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	isOwner, err := adapter.CheckOwnership(c.Request().Context(), user.ID, resourceID)
+	if err != nil {
+		return uuid.Nil, errInternal()
+	}
+	if !isOwner {
+		return uuid.Nil, errForbidden()
+	}
+
+	return resourceID, nil
+}
+
+// findShare looks up a share of the resource by predicate.
+func findShare(c echo.Context, adapter shares.ShareAdapter, resourceID uuid.UUID, match func(shares.ShareView) bool) (*shares.ShareView, error) {
+	views, err := adapter.ListShares(c.Request().Context(), resourceID)
+	if err != nil {
+		return nil, errInternal()
+	}
+	for i := range views {
+		if match(views[i]) {
+			return &views[i], nil
+		}
+	}
+	return nil, errNotFound("share")
+}
+
+func (h *Handler) listShares(c echo.Context, adapter shares.ShareAdapter) error {
+	page, perPage, err := parsePagination(c)
+	if err != nil {
+		return err
+	}
+
+	resourceID, err := requireOwner(c, adapter)
+	if err != nil {
+		return err
+	}
+
+	views, err := adapter.ListShares(c.Request().Context(), resourceID)
+	if err != nil {
+		return errInternal()
+	}
+
+	result := make([]Share, len(views))
+	for i, view := range views {
+		result[i] = newShare(view)
+	}
+
+	return c.JSON(http.StatusOK, paginate(result, page, perPage))
+}
+
+func (h *Handler) createShare(c echo.Context, adapter shares.ShareAdapter) error {
+	user := currentUser(c)
+
+	resourceID, err := requireOwner(c, adapter)
+	if err != nil {
+		return err
+	}
+
+	var req validation.ShareRequest
+	if err := bindJSON(c, &req); err != nil {
+		return err
+	}
+
+	email := strings.ToLower(strings.TrimSpace(req.SharedWithEmail))
+	if email == strings.ToLower(user.Email) {
+		return fieldError("shared_with_email", "ne_self")
+	}
+
+	createReq := shares.CreateShareRequest{
+		UserID:          user.ID,
+		ResourceID:      resourceID,
+		SharedWithEmail: email,
+	}
+	if adapter.SupportsEdit() {
+		createReq.CanEdit = req.CanEdit
+		createReq.CanDelete = req.CanDelete
+	}
+	if adapter.HasTransactionPermission() {
+		createReq.CanEditTransactions = req.CanEditTransactions
+	}
+
+	if err := adapter.CreateShare(c.Request().Context(), createReq); err != nil {
+		switch {
+		case errors.Is(err, services.ErrShareTargetUnverified):
+			return fieldError("shared_with_email", "verified")
+		case err.Error() == "user not found":
+			return fieldError("shared_with_email", "exists")
+		case err.Error() == "already shared with this user":
+			return newError(http.StatusConflict, CodeConflict, "already shared with this user")
+		default:
+			return errInternal()
+		}
+	}
+
+	view, err := findShare(c, adapter, resourceID, func(v shares.ShareView) bool {
+		return v.SharedWith != nil && strings.EqualFold(v.SharedWith.Email, email)
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, newShare(*view))
+}
+
+func (h *Handler) updateShare(c echo.Context, adapter shares.ShareAdapter) error {
+	user := currentUser(c)
+
+	resourceID, err := requireOwner(c, adapter)
+	if err != nil {
+		return err
+	}
+
+	shareID, err := parseIDParam(c, "share_id")
+	if err != nil {
+		return err
+	}
+
+	view, err := findShare(c, adapter, resourceID, func(v shares.ShareView) bool { return v.ID == shareID })
+	if err != nil {
+		return err
+	}
+
+	req := validation.SharePermissionsRequest{
+		CanEdit:             view.CanEdit,
+		CanDelete:           view.CanDelete,
+		CanEditTransactions: view.CanEditTransactions,
+	}
+	if err := bindJSON(c, &req); err != nil {
+		return err
+	}
+
+	updateReq := shares.UpdateShareRequest{
+		ShareID:    shareID,
+		UserID:     user.ID,
+		ResourceID: resourceID,
+		CanEdit:    req.CanEdit,
+		CanDelete:  req.CanDelete,
+	}
+	if adapter.HasTransactionPermission() {
+		updateReq.CanEditTransactions = req.CanEditTransactions
+	}
+
+	if err := adapter.UpdateShare(c.Request().Context(), updateReq); err != nil {
+		return errFromService(err, "share")
+	}
+
+	view.CanEdit = updateReq.CanEdit
+	view.CanDelete = updateReq.CanDelete
+	view.CanEditTransactions = updateReq.CanEditTransactions
+
+	return c.JSON(http.StatusOK, newShare(*view))
+}
+
+func (h *Handler) deleteShare(c echo.Context, adapter shares.ShareAdapter) error {
+	user := currentUser(c)
+
+	resourceID, err := requireOwner(c, adapter)
+	if err != nil {
+		return err
+	}
+
+	shareID, err := parseIDParam(c, "share_id")
+	if err != nil {
+		return err
+	}
+
+	// Only shares of this resource may be deleted through this route
+	if _, err := findShare(c, adapter, resourceID, func(v shares.ShareView) bool { return v.ID == shareID }); err != nil {
+		return err
+	}
+
+	ctx := audit.AddUserIDToContext(c.Request().Context(), user.ID)
+	if err := adapter.DeleteShare(ctx, shareID); err != nil {
+		return errInternal()
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,99 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"savvy/internal/handlers/shares"
+	"savvy/internal/models"
+	"savvy/internal/services"
+)
+
+func TestCreateShare_OwnerOnly(t *testing.T) {
+	h, deps := newTestHandler()
+	user := &models.User{ID: uuid.New(), Email: "owner@example.com"}
+	cardID := uuid.New()
+
+	deps.shares.On("CheckOwnership", mock.Anything, user.ID, cardID).Return(false, nil)
+
+	rec := serve(t, h.CreateCardShare, user, http.MethodPost, "/", `{"shared_with_email":"anna@example.com"}`, map[string]string{"id": cardID.String()})
+
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	deps.shares.AssertNotCalled(t, "CreateShare", mock.Anything, mock.Anything)
+}
+
+func TestCreateShare_Success(t *testing.T) {
+	h, deps := newTestHandler()
+	user := &models.User{ID: uuid.New(), Email: "owner@example.com"}
+	sharee := &models.User{ID: uuid.New(), Email: "anna@example.com"}
+	cardID := uuid.New()
+
+	deps.shares.On("CheckOwnership", mock.Anything, user.ID, cardID).Return(true, nil)
+	deps.shares.On("CreateShare", mock.Anything, shares.CreateShareRequest{
+		UserID:          user.ID,
+		ResourceID:      cardID,
+		SharedWithEmail: "anna@example.com",
+		CanEdit:         true,
+	}).Return(nil)
+	deps.shares.On("ListShares", mock.Anything, cardID).Return([]shares.ShareView{
+		{ID: uuid.New(), ResourceID: cardID, SharedWith: sharee, CanEdit: true},
+	}, nil)
+
+	body := `{"shared_with_email":"Anna@Example.com","can_edit":true,"can_edit_transactions":true}`
+	rec := serve(t, h.CreateCardShare, user, http.MethodPost, "/", body, map[string]string{"id": cardID.String()})
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	deps.shares.AssertExpectations(t)
+}
+
+func TestCreateShare_AlreadyShared(t *testing.T) {
+	h, deps := newTestHandler()
+	user := &models.User{ID: uuid.New(), Email: "owner@example.com"}
+	cardID := uuid.New()
+
+	deps.shares.On("CheckOwnership", mock.Anything, user.ID, cardID).Return(true, nil)
+	deps.shares.On("CreateShare", mock.Anything, mock.Anything).Return(errors.New("already shared with this user"))
+
+	rec := serve(t, h.CreateCardShare, user, http.MethodPost, "/", `{"shared_with_email":"anna@example.com"}`, map[string]string{"id": cardID.String()})
+
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Equal(t, CodeConflict, decodeError(t, rec).Code)
+}
+
+func TestCreateShare_UnverifiedTarget(t *testing.T) {
+	h, deps := newTestHandler()
+	user := &models.User{ID: uuid.New(), Email: "owner@example.com"}
+	cardID := uuid.New()
+
+	deps.shares.On("CheckOwnership", mock.Anything, user.ID, cardID).Return(true, nil)
+	deps.shares.On("CreateShare", mock.Anything, mock.Anything).Return(services.ErrShareTargetUnverified)
+
+	rec := serve(t, h.CreateCardShare, user, http.MethodPost, "/", `{"shared_with_email":"anna@example.com"}`, map[string]string{"id": cardID.String()})
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	apiErr := decodeError(t, rec)
+	assert.Equal(t, CodeValidationFailed, apiErr.Code)
+	assert.Equal(t, "verified", apiErr.Fields["shared_with_email"])
+}
+
+func TestDeleteShare_ForeignShare(t *testing.T) {
+	h, deps := newTestHandler()
+	user := &models.User{ID: uuid.New()}
+	cardID := uuid.New()
+	foreignShareID := uuid.New()
+
+	deps.shares.On("CheckOwnership", mock.Anything, user.ID, cardID).Return(true, nil)
+	deps.shares.On("ListShares", mock.Anything, cardID).Return([]shares.ShareView{{ID: uuid.New(), ResourceID: cardID}}, nil)
+
+	rec := serve(t, h.DeleteCardShare, user, http.MethodDelete, "/", "", map[string]string{
+		"id":       cardID.String(),
+		"share_id": foreignShareID.String(),
+	})
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	deps.shares.AssertNotCalled(t, "DeleteShare", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,152 @@
+package api
+
+import (
+	"net/http"
+	"savvy/internal/audit"
+	"savvy/internal/models"
+	"savvy/internal/validation"
+	"sort"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ListTransactions returns the transaction history of a gift card, newest first.
+// GET /api/v1/gift-cards/:id/transactions
+func (h *Handler) ListTransactions(c echo.Context) error {
+	user := currentUser(c)
+
+	page, perPage, err := parsePagination(c)
+	if err != nil {
+		return err
+	}
+
+	giftCardID, err := parseIDParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	if _, err := h.authzService.CheckGiftCardAccess(c.Request().Context(), user.ID, giftCardID); err != nil {
+		return errFromAccessCheck(err, "gift card")
+	}
+
+	giftCard, err := h.giftCardService.GetGiftCard(c.Request().Context(), giftCardID)
+	if err != nil {
+		return errFromService(err, "gift card")
+	}
+
+	transactions := giftCard.Transactions
+	sort.SliceStable(transactions, func(i, j int) bool {
+		return transactions[i].TransactionDate.After(transactions[j].TransactionDate)
+	})
+
+	return c.JSON(http.StatusOK, paginate(transactions, page, perPage))
+}
+
+// CreateTransaction records a new purchase, reload, refund or adjustment on a gift card.
+// Requires the transaction permission.
+// POST /api/v1/gift-cards/:id/transactions
+func (h *Handler) CreateTransaction(c echo.Context) error {
+	user := currentUser(c)
+
+	giftCardID, err := parseIDParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	perms, err := h.authzService.CheckGiftCardAccess(c.Request().Context(), user.ID, giftCardID)
+	if err != nil {
+		return errFromAccessCheck(err, "gift card")
+	}
+	if !perms.CanEditTransactions {
+		return errForbidden()
+	}
+
+	giftCard, err := h.giftCardService.GetGiftCard(c.Request().Context(), giftCardID)
+	if err != nil {
+		return errFromService(err, "gift card")
+	}
+
+	var req validation.TransactionRequest
+	if err := bindJSON(c, &req); err != nil {
+		return err
+	}
+
+	amount, err := parseAmount("amount", req.Amount, giftCard.Currency)
+	if err != nil {
+		return err
+	}
+
+	transaction := models.GiftCardTransaction{
+		GiftCardID:      giftCard.ID,
+		Kind:            req.Kind,
+		Amount:          amount,
+		Description:     req.Description,
+		CreatedByUserID: &user.ID,
+	}
+	if transaction.Kind == "" {
+		transaction.Kind = models.TransactionKindPurchase
+	}
+
+	// Adjustments are signed, all other kinds use positive amounts
+	if transaction.Kind != models.TransactionKindAdjustment && !amount.IsPositive() {
+		return fieldError("amount", "gt")
+	}
+	if giftCard.CurrentBalance.Add(transaction.BalanceEffect()).IsNegative() {
+		return newError(http.StatusUnprocessableEntity, CodeValidationFailed, "insufficient balance")
+	}
+
+	transactionDate := time.Now().UTC()
+	if req.TransactionDate != "" {
+		parsed, err := validation.ParseAndValidateDate(req.TransactionDate, true)
+		if err != nil {
+			return fieldError("transaction_date", "datetime")
+		}
+		transactionDate = parsed
+	}
+	// Set to noon, like the HTML form does
+	transaction.TransactionDate = time.Date(transactionDate.Year(), transactionDate.Month(), transactionDate.Day(), 12, 0, 0, 0, time.UTC)
+
+	if err := h.giftCardService.CreateTransaction(c.Request().Context(), &transaction); err != nil {
+		return errFromService(err, "transaction")
+	}
+
+	return c.JSON(http.StatusCreated, transaction)
+}
+
+// DeleteTransaction removes a transaction from a gift card.
+// Requires the transaction permission.
+// DELETE /api/v1/gift-cards/:id/transactions/:transaction_id
+func (h *Handler) DeleteTransaction(c echo.Context) error {
+	user := currentUser(c)
+
+	giftCardID, err := parseIDParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	transactionID, err := parseIDParam(c, "transaction_id")
+	if err != nil {
+		return err
+	}
+
+	perms, err := h.authzService.CheckGiftCardAccess(c.Request().Context(), user.ID, giftCardID)
+	if err != nil {
+		return errFromAccessCheck(err, "gift card")
+	}
+	if !perms.CanEditTransactions {
+		return errForbidden()
+	}
+
+	// Verify transaction exists and belongs to this gift card
+	if _, err := h.giftCardService.GetTransaction(c.Request().Context(), transactionID, giftCardID); err != nil {
+		return errFromService(err, "transaction")
+	}
+
+	ctx := audit.AddUserIDToContext(c.Request().Context(), user.ID)
+	if err := h.giftCardService.DeleteTransaction(ctx, transactionID); err != nil {
+		return errFromService(err, "transaction")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
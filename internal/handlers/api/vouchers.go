@@ -0,0 +1,210 @@
+package api
+
+import (
+	"net/http"
+	"savvy/internal/audit"
+	"savvy/internal/models"
+	"savvy/internal/validation"
+
+	"github.com/labstack/echo/v4"
+)
+
+// voucherStatuses are the values of the ?status= filter of ListVouchers
+var voucherStatuses = []string{models.ItemStatusActive, models.ItemStatusInactive, models.ItemStatusExpired}
+
+// ListVouchers returns the vouchers owned by or shared with the current user,
+// optionally only those with a status.
+// GET /api/v1/vouchers
+func (h *Handler) ListVouchers(c echo.Context) error {
+	user := currentUser(c)
+
+	page, perPage, err := parsePagination(c)
+	if err != nil {
+		return err
+	}
+	status, err := parseStatusFilter(c, voucherStatuses)
+	if err != nil {
+		return err
+	}
+
+	vouchers, err := h.voucherService.GetUserVouchers(c.Request().Context(), user.ID)
+	if err != nil {
+		return errFromService(err, "voucher")
+	}
+
+	vouchers = filterStatus(vouchers, status, func(v models.Voucher) string { return v.Status })
+	return c.JSON(http.StatusOK, paginate(vouchers, page, perPage))
+}
+
+// GetVoucher returns a single voucher.
+// GET /api/v1/vouchers/:id
+func (h *Handler) GetVoucher(c echo.Context) error {
+	user := currentUser(c)
+
+	voucherID, err := parseIDParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	if _, err := h.authzService.CheckVoucherAccess(c.Request().Context(), user.ID, voucherID); err != nil {
+		return errFromAccessCheck(err, "voucher")
+	}
+
+	voucher, err := h.voucherService.GetVoucher(c.Request().Context(), voucherID)
+	if err != nil {
+		return errFromService(err, "voucher")
+	}
+
+	return c.JSON(http.StatusOK, voucher)
+}
+
+// CreateVoucher creates a voucher owned by the current user.
+// POST /api/v1/vouchers
+func (h *Handler) CreateVoucher(c echo.Context) error {
+	user := currentUser(c)
+
+	req := newVoucherRequest()
+	if err := bindJSON(c, &req); err != nil {
+		return err
+	}
+
+	voucher := models.Voucher{UserID: &user.ID}
+	if err := h.applyVoucherRequest(c, &voucher, &req); err != nil {
+		return err
+	}
+
+	if err := h.voucherService.CreateVoucher(c.Request().Context(), &voucher); err != nil {
+		return errFromService(err, "voucher")
+	}
+
+	return c.JSON(http.StatusCreated, voucher)
+}
+
+// UpdateVoucher partially updates a voucher. Requires edit permission.
+// PATCH /api/v1/vouchers/:id
+func (h *Handler) UpdateVoucher(c echo.Context) error {
+	user := currentUser(c)
+
+	voucherID, err := parseIDParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	perms, err := h.authzService.CheckVoucherAccess(c.Request().Context(), user.ID, voucherID)
+	if err != nil {
+		return errFromAccessCheck(err, "voucher")
+	}
+	if !perms.CanEdit {
+		return errForbidden()
+	}
+
+	voucher, err := h.voucherService.GetVoucher(c.Request().Context(), voucherID)
+	if err != nil {
+		return errFromService(err, "voucher")
+	}
+
+	req := validation.VoucherRequest{
+		MerchantName:      voucher.MerchantName,
+		Code:              voucher.Code,
+		VoucherType:       voucher.Type,
+		Value:             voucher.ValueFloat(),
+		Description:       voucher.Description,
+		MinPurchaseAmount: voucher.MinPurchaseAmount.Float(),
+		ValidFrom:         formatDate(voucher.ValidFrom),
+		ValidUntil:        formatDate(voucher.ValidUntil),
+		UsageLimitType:    voucher.UsageLimitType,
+		BarcodeType:       voucher.BarcodeType,
+		Status:            voucher.Status,
+	}
+	if voucher.MerchantID != nil {
+		req.MerchantID = voucher.MerchantID.String()
+	}
+	if err := bindJSON(c, &req); err != nil {
+		return err
+	}
+
+	if err := h.applyVoucherRequest(c, voucher, &req); err != nil {
+		return err
+	}
+
+	if err := h.voucherService.UpdateVoucher(c.Request().Context(), voucher); err != nil {
+		return errFromService(err, "voucher")
+	}
+
+	if h.db != nil {
+		if err := audit.LogUpdateFromContext(c, h.db, "vouchers", voucher.ID, *voucher); err != nil {
+			c.Logger().Errorf("Failed to log voucher update: %v", err)
+		}
+	}
+
+	return c.JSON(http.StatusOK, voucher)
+}
+
+// DeleteVoucher deletes a voucher. Requires delete permission.
+// DELETE /api/v1/vouchers/:id
+func (h *Handler) DeleteVoucher(c echo.Context) error {
+	user := currentUser(c)
+
+	voucherID, err := parseIDParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	perms, err := h.authzService.CheckVoucherAccess(c.Request().Context(), user.ID, voucherID)
+	if err != nil {
+		return errFromAccessCheck(err, "voucher")
+	}
+	if !perms.CanDelete {
+		return errForbidden()
+	}
+
+	ctx := audit.AddUserIDToContext(c.Request().Context(), user.ID)
+	if err := h.voucherService.DeleteVoucher(ctx, voucherID); err != nil {
+		return errFromService(err, "voucher")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// applyVoucherRequest copies validated request fields onto the voucher.
+func (h *Handler) applyVoucherRequest(c echo.Context, voucher *models.Voucher, req *validation.VoucherRequest) error {
+	validFrom, validUntil, err := validation.ParseAndValidateDateRange(req.ValidFrom, req.ValidUntil, true)
+	if err != nil {
+		return fieldError("valid_until", "gtefield")
+	}
+	if err := checkStatus(voucher.Status, req.Status); err != nil {
+		return err
+	}
+
+	value, err := parseVoucherValue(req.VoucherType, req.Value)
+	if err != nil {
+		return err
+	}
+	minPurchaseAmount, err := parseAmount("min_purchase_amount", req.MinPurchaseAmount, models.VoucherCurrency)
+	if err != nil {
+		return err
+	}
+
+	voucher.MerchantID, voucher.MerchantName = h.resolveMerchant(c, req.MerchantID, req.MerchantName)
+	voucher.Code = req.Code
+	voucher.Type = req.VoucherType
+	voucher.Value = value
+	voucher.Description = req.Description
+	voucher.MinPurchaseAmount = minPurchaseAmount
+	voucher.ValidFrom = validFrom
+	voucher.ValidUntil = validUntil
+	voucher.UsageLimitType = req.UsageLimitType
+	voucher.BarcodeType = req.BarcodeType
+	voucher.Status = req.Status
+
+	return nil
+}
+
+// newVoucherRequest returns the defaults for fields omitted on create.
+func newVoucherRequest() validation.VoucherRequest {
+	return validation.VoucherRequest{
+		UsageLimitType: "single_use",
+		BarcodeType:    "QR",
+		Status:         "active",
+	}
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"savvy/internal/models"
+)
+
+func TestUpdateVoucher_Status(t *testing.T) {
+	tests := []struct {
+		name     string
+		current  string
+		body     string
+		wantCode int
+	}{
+		{name: "keep expired", current: models.ItemStatusExpired, body: `{"description":"Spring sale"}`, wantCode: http.StatusOK},
+		{name: "deactivate", current: models.ItemStatusActive, body: `{"status":"inactive"}`, wantCode: http.StatusOK},
+		{name: "expire by hand", current: models.ItemStatusActive, body: `{"status":"expired"}`, wantCode: http.StatusUnprocessableEntity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, deps := newTestHandler()
+			user := &models.User{ID: uuid.New()}
+			voucher := &models.Voucher{
+				ID:             uuid.New(),
+				MerchantName:   "Migros",
+				Code:           "SPRING",
+				Type:           "fixed_amount",
+				Value:          1000,
+				ValidFrom:      time.Now().AddDate(0, -1, 0),
+				ValidUntil:     time.Now().AddDate(0, 1, 0),
+				UsageLimitType: "single_use",
+				BarcodeType:    "QR",
+				Status:         tt.current,
+			}
+
+			deps.authz.On("CheckVoucherAccess", mock.Anything, user.ID, voucher.ID).Return(ownerPerms, nil)
+			deps.vouchers.On("GetVoucher", mock.Anything, voucher.ID).Return(voucher, nil)
+			deps.vouchers.On("UpdateVoucher", mock.Anything, mock.Anything).Return(nil)
+
+			rec := serve(t, h.UpdateVoucher, user, http.MethodPatch, "/", tt.body, map[string]string{"id": voucher.ID.String()})
+
+			assert.Equal(t, tt.wantCode, rec.Code, rec.Body.String())
+			if tt.wantCode != http.StatusOK {
+				assert.Equal(t, "lifecycle", decodeError(t, rec).Fields["status"])
+				deps.vouchers.AssertNotCalled(t, "UpdateVoucher", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestCreateVoucher_PercentageValue(t *testing.T) {
+	h, deps := newTestHandler()
+	user := &models.User{ID: uuid.New()}
+	validFrom := time.Now().Format("2006-01-02")
+	validUntil := time.Now().AddDate(0, 1, 0).Format("2006-01-02")
+
+	deps.vouchers.On("CreateVoucher", mock.Anything, mock.MatchedBy(func(v *models.Voucher) bool {
+		return v.Value == 1250
+	})).Return(nil)
+
+	body := `{"merchant_name":"Migros","code":"SPRING","type":"percentage","value":12.5,"valid_from":"` + validFrom + `","valid_until":"` + validUntil + `"}`
+	rec := serve(t, h.CreateVoucher, user, http.MethodPost, "/api/v1/vouchers", body, nil)
+
+	assert.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	assert.Contains(t, rec.Body.String(), `"value":12.5`)
+	deps.vouchers.AssertExpectations(t)
+
+	body = `{"merchant_name":"Migros","code":"SPRING","type":"percentage","value":12.345,"valid_from":"` + validFrom + `","valid_until":"` + validUntil + `"}`
+	rec = serve(t, h.CreateVoucher, user, http.MethodPost, "/api/v1/vouchers", body, nil)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Equal(t, "decimals", decodeError(t, rec).Fields["value"])
+}
@@ -2,6 +2,7 @@
 package handlers
 
 import (
+	"math"
 	"net/http"
 	"net/mail"
 	"savvy/internal/middleware"
@@ -9,7 +10,9 @@ import (
 	"savvy/internal/services"
 	"savvy/internal/templates"
 	"savvy/internal/validation"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
@@ -18,12 +21,36 @@ import (
 
 // AuthHandler handles authentication operations.
 type AuthHandler struct {
-	userService services.UserServiceInterface
+	userService         services.UserServiceInterface
+	webAuthnService     services.WebAuthnServiceInterface
+	accountEmailService services.AccountEmailServiceInterface
+	loginThrottle       services.LoginThrottleServiceInterface
 }
 
 // NewAuthHandler creates a new auth handler.
-func NewAuthHandler(userService services.UserServiceInterface) *AuthHandler {
-	return &AuthHandler{userService: userService}
+func NewAuthHandler(
+	userService services.UserServiceInterface,
+	webAuthnService services.WebAuthnServiceInterface,
+	accountEmailService services.AccountEmailServiceInterface,
+	loginThrottle services.LoginThrottleServiceInterface,
+) *AuthHandler {
+	return &AuthHandler{
+		userService:         userService,
+		webAuthnService:     webAuthnService,
+		accountEmailService: accountEmailService,
+		loginThrottle:       loginThrottle,
+	}
+}
+
+// loginNotices maps the notice query parameter to the message shown above the login form
+var loginNotices = map[string]string{
+	"password_reset": "auth.password_reset.done",
+}
+
+// loginErrors maps the error query parameter to the message shown above the login form
+var loginErrors = map[string]string{
+	"locked":  "auth.lockout.locked",
+	"delayed": "auth.lockout.delayed",
 }
 
 // AuthLoginGet shows the login page
@@ -44,7 +71,9 @@ func AuthLoginGet(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusNotFound, "Login is disabled")
 	}
 
-	return templates.Login(ctx, csrfToken, IsOAuthEnabled(), IsLocalLoginEnabled()).Render(ctx, c.Response().Writer)
+	notice := loginNotices[c.QueryParam("notice")]
+	errorMsg := loginErrors[c.QueryParam("error")]
+	return templates.Login(ctx, csrfToken, IsOAuthEnabled(), IsLocalLoginEnabled(), notice, errorMsg).Render(ctx, c.Response().Writer)
 }
 
 // LoginPost handles login with constant-time response to prevent account enumeration
@@ -68,6 +97,12 @@ func (h *AuthHandler) LoginPost(c echo.Context) error {
 	email := strings.ToLower(strings.TrimSpace(req.Email))
 	password := req.Password
 
+	// Locked and delayed attempts are refused before the password is checked,
+	// the same way for existing and unknown addresses
+	if target := loginThrottleRedirect(c, h.loginThrottle, email); target != "" {
+		return c.Redirect(http.StatusSeeOther, target)
+	}
+
 	user, err := h.userService.GetUserByEmail(c.Request().Context(), email)
 
 	// Always run bcrypt comparison, even if user doesn't exist
@@ -87,6 +122,27 @@ func (h *AuthHandler) LoginPost(c echo.Context) error {
 
 	// Only proceed if both user exists AND password matches
 	if err == nil && bcryptErr == nil {
+		hasPasskeys, err := h.hasPasskeys(c, user)
+		if err != nil {
+			c.Logger().Errorf("Failed to count passkeys of user %s: %v", user.ID, err)
+			return c.Redirect(http.StatusSeeOther, "/auth/login?error=session_error")
+		}
+
+		// With 2FA the session stays unauthenticated until the code was
+		// entered or a passkey confirmed the login
+		if user.HasTwoFactor() || hasPasskeys {
+			if err := middleware.SetPendingTwoFactor(c, user.ID); err != nil {
+				c.Logger().Errorf("Failed to save session: %v", err)
+				return c.Redirect(http.StatusSeeOther, "/auth/login?error=session_error")
+			}
+			return c.Redirect(http.StatusSeeOther, "/auth/login/two-factor")
+		}
+
+		// With 2FA the failures are only cleared once the second step succeeded
+		if err := h.loginThrottle.RecordSuccess(c.Request().Context(), email); err != nil {
+			c.Logger().Errorf("Failed to reset failed logins of %s: %v", email, err)
+		}
+
 		// Regenerate session to prevent session fixation attacks
 		// This creates a NEW session with a FRESH session ID
 		newSession, err := middleware.RegenerateSession(c)
@@ -104,10 +160,46 @@ func (h *AuthHandler) LoginPost(c echo.Context) error {
 		return c.Redirect(http.StatusSeeOther, "/")
 	}
 
+	if err := h.loginThrottle.RecordFailure(c.Request().Context(), email, c.RealIP(), services.LoginStepPassword); err != nil {
+		c.Logger().Errorf("Failed to record failed login: %v", err)
+	}
+
 	// Always return the same error regardless of whether user exists or password is wrong
 	return c.Redirect(http.StatusSeeOther, "/auth/login")
 }
 
+// loginThrottleRedirect returns the login form URL with the reason while the
+// address or the client IP is locked or has to wait after failed attempts,
+// an empty string if the attempt may proceed
+func loginThrottleRedirect(c echo.Context, loginThrottle services.LoginThrottleServiceInterface, email string) string {
+	block, err := loginThrottle.Check(c.Request().Context(), email, c.RealIP())
+	if err != nil {
+		// Without the counters the login is still protected by the rate limiter
+		c.Logger().Errorf("Failed to check failed logins: %v", err)
+		return ""
+	}
+	if block == nil {
+		return ""
+	}
+
+	c.Response().Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(block.RetryAfter.Seconds()))))
+	if block.Locked {
+		return "/auth/login?error=locked"
+	}
+	return "/auth/login?error=delayed"
+}
+
+// hasPasskeys reports whether the user has to confirm a password login with
+// a passkey. Without WebAuthn configuration passkeys cannot be checked, so
+// they are ignored instead of locking the user out.
+func (h *AuthHandler) hasPasskeys(c echo.Context, user *models.User) (bool, error) {
+	if !h.webAuthnService.Enabled() {
+		return false, nil
+	}
+	count, err := h.webAuthnService.CountCredentials(c.Request().Context(), user.ID)
+	return count > 0, err
+}
+
 // AuthRegisterGet shows the register page
 func AuthRegisterGet(c echo.Context) error {
 	// Check if registration is enabled
@@ -177,10 +269,24 @@ func (h *AuthHandler) RegisterPost(c echo.Context) error {
 		AuthProvider: "local",
 	}
 
+	// Without mail the address cannot be confirmed, so it is trusted
+	mailEnabled := h.accountEmailService.Enabled()
+	if !mailEnabled {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+	}
+
 	if err := h.userService.CreateUser(c.Request().Context(), &user); err != nil {
 		return c.Redirect(http.StatusSeeOther, "/auth/register")
 	}
 
+	if mailEnabled {
+		// The account works without confirmation, a failed send can be retried from the account page
+		if err := h.accountEmailService.SendVerification(c.Request().Context(), &user); err != nil {
+			c.Logger().Errorf("Failed to send verification email to user %s: %v", user.ID, err)
+		}
+	}
+
 	// Regenerate session to prevent session fixation attacks
 	// This creates a NEW session with a FRESH session ID
 	newSession, err := middleware.RegenerateSession(c)
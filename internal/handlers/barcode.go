@@ -4,6 +4,7 @@ package handlers
 import (
 	"image/png"
 	"net/http"
+	"savvy/internal/barcodes"
 	"savvy/internal/models"
 	"savvy/internal/security"
 	"savvy/internal/services"
@@ -94,50 +95,42 @@ func (h *BarcodeHandler) fetchResourceData(ctx echo.Context, claims *security.Ba
 
 // encodeBarcode creates a barcode image from data and type
 func encodeBarcode(barcodeType, data string) (barcode.Barcode, error) {
-	switch barcodeType {
-	case "CODE128":
-		return code128.Encode(data)
+	symbology, ok := barcodes.SymbologyFor(barcodeType)
+	if !ok {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "Nicht unterstützter Barcode-Typ")
+	}
 
-	case "CODE39":
+	switch symbology {
+	case barcodes.Code39:
 		return code39.Encode(data, true, true)
 
-	case "CODE93":
+	case barcodes.Code93:
 		return code93.Encode(data, true, true)
 
-	case "CODABAR":
+	case barcodes.Codabar:
 		return codabar.Encode(data)
 
-	case "QR":
+	case barcodes.QR:
 		return qr.Encode(data, qr.M, qr.Auto)
 
-	case "EAN13", "ISBN13":
+	case barcodes.EAN:
 		barcodeImage, err := ean.Encode(data)
 		if err != nil {
 			return code128.Encode(data)
 		}
 		return barcodeImage, nil
 
-	case "EAN8":
-		barcodeImage, err := ean.Encode(data)
-		if err != nil {
-			return code128.Encode(data)
-		}
-		return barcodeImage, nil
-
-	case "PDF417":
+	case barcodes.PDF417:
 		return pdf417.Encode(data, 2)
 
-	case "DATAMATRIX":
+	case barcodes.DataMatrix:
 		return datamatrix.Encode(data)
 
-	case "AZTEC":
+	case barcodes.Aztec:
 		return aztec.Encode([]byte(data), 50, 0)
 
-	case "UPCA", "UPCE", "ITF", "ITF14", "MAXICODE":
-		return code128.Encode(data)
-
 	default:
-		return nil, echo.NewHTTPError(http.StatusBadRequest, "Nicht unterstützter Barcode-Typ")
+		return code128.Encode(data)
 	}
 }
 
@@ -0,0 +1,58 @@
+// Package handlers contains HTTP request handlers for the savvy system.
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"savvy/internal/services"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// CalendarHandler serves the iCalendar feeds calendar apps subscribe to
+type CalendarHandler struct {
+	calendarService services.CalendarFeedServiceInterface
+	baseURL         string
+}
+
+// NewCalendarHandler creates a new calendar handler
+func NewCalendarHandler(calendarService services.CalendarFeedServiceInterface, baseURL string) *CalendarHandler {
+	return &CalendarHandler{calendarService: calendarService, baseURL: baseURL}
+}
+
+// CalendarFeedURL returns the URL of the calendar feed with the given token
+func CalendarFeedURL(baseURL, token string) string {
+	return baseURL + "/calendar/" + token + ".ics"
+}
+
+// Feed renders the expiry dates of the owner of the token. Calendar apps do
+// not send cookies, the secret token in the URL is the only authentication.
+// GET /calendar/:token
+func (h *CalendarHandler) Feed(c echo.Context) error {
+	token := strings.TrimSuffix(c.Param("token"), ".ics")
+
+	feed, err := h.calendarService.Authenticate(c.Request().Context(), token)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidCalendarFeedToken) {
+			return c.String(http.StatusNotFound, "Calendar not found")
+		}
+		c.Logger().Errorf("Failed to authenticate calendar feed: %v", err)
+		return c.String(http.StatusInternalServerError, "Failed to load calendar")
+	}
+
+	calendar, err := h.calendarService.Calendar(c.Request().Context(), feed, h.baseURL)
+	if err != nil {
+		c.Logger().Errorf("Failed to build calendar feed of user %s: %v", feed.UserID, err)
+		return c.String(http.StatusInternalServerError, "Failed to load calendar")
+	}
+
+	header := c.Response().Header()
+	header.Set(echo.HeaderContentType, "text/calendar; charset=utf-8")
+	header.Set(echo.HeaderContentDisposition, `inline; filename="savvy.ics"`)
+	header.Set("Cache-Control", "private, no-store")
+	// Keep the token out of the Referer of links opened from the calendar
+	header.Set("Referrer-Policy", "no-referrer")
+	c.Response().WriteHeader(http.StatusOK)
+	return calendar.Encode(c.Response().Writer)
+}
@@ -20,6 +20,7 @@ type Handler struct {
 	favoriteService services.FavoriteServiceInterface
 	shareService    services.ShareServiceInterface
 	transferService services.TransferServiceInterface
+	importService   services.ImportServiceInterface
 	db              *gorm.DB
 }
 
@@ -32,6 +33,7 @@ func NewHandler(
 	favoriteService services.FavoriteServiceInterface,
 	shareService services.ShareServiceInterface,
 	transferService services.TransferServiceInterface,
+	importService services.ImportServiceInterface,
 	db *gorm.DB,
 ) *Handler {
 	return &Handler{
@@ -42,6 +44,7 @@ func NewHandler(
 		favoriteService: favoriteService,
 		shareService:    shareService,
 		transferService: transferService,
+		importService:   importService,
 		db:              db,
 	}
 }
@@ -0,0 +1,157 @@
+// Package cards contains HTTP request handlers for card operations.
+package cards
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"savvy/internal/i18n"
+	"savvy/internal/importer"
+	"savvy/internal/models"
+	"savvy/internal/templates"
+	"savvy/internal/views"
+
+	"github.com/labstack/echo/v4"
+)
+
+// maxImportSize limits uploaded import files
+const maxImportSize = 10 << 20 // 10 MiB
+
+var errImportTooLarge = errors.New("import file too large")
+
+// ImportForm shows the upload form for Catima, Stocard and CSV imports
+func (h *Handler) ImportForm(c echo.Context) error {
+	return h.renderImport(c, http.StatusOK, h.importView(c))
+}
+
+// ImportPreview parses an uploaded file and shows a dry-run preview of the cards to import
+func (h *Handler) ImportPreview(c echo.Context) error {
+	ctx := c.Request().Context()
+	view := h.importView(c)
+
+	format, err := importer.ParseFormat(c.FormValue("format"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Ungültiges Importformat")
+	}
+	view.Format = format
+	view.Mapping = importer.Mapping{
+		Merchant:    c.FormValue("map_merchant"),
+		Program:     c.FormValue("map_program"),
+		CardNumber:  c.FormValue("map_card_number"),
+		BarcodeType: c.FormValue("map_barcode_type"),
+		Notes:       c.FormValue("map_notes"),
+		Status:      c.FormValue("map_status"),
+	}
+
+	data, err := readImportFile(c)
+	if errors.Is(err, errImportTooLarge) {
+		view.Error = i18n.T(ctx, "cards.import.error.too_large")
+		return h.renderImport(c, http.StatusUnprocessableEntity, view)
+	}
+	if err != nil {
+		view.Error = i18n.T(ctx, "cards.import.error.no_file")
+		return h.renderImport(c, http.StatusUnprocessableEntity, view)
+	}
+
+	records, err := importer.Parse(format, data, view.Mapping)
+	if err != nil {
+		c.Logger().Warnf("Card import (%s) failed to parse: %v", format, err)
+		view.Error = importErrorMessage(c, err)
+		return h.renderImport(c, http.StatusUnprocessableEntity, view)
+	}
+
+	preview, err := h.importService.PreviewCards(ctx, records)
+	if err != nil {
+		c.Logger().Errorf("Card import preview failed: %v", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Fehler beim Erstellen der Vorschau")
+	}
+
+	encoded, err := json.Marshal(records)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Fehler beim Erstellen der Vorschau")
+	}
+	view.Preview = preview
+	view.Records = string(encoded)
+
+	return h.renderImport(c, http.StatusOK, view)
+}
+
+// ImportCommit imports the previewed cards. The records are posted back from
+// the preview and validated again, so rows with errors are never created.
+func (h *Handler) ImportCommit(c echo.Context) error {
+	user := c.Get("current_user").(*models.User)
+	ctx := c.Request().Context()
+
+	var records []importer.Record
+	if err := json.Unmarshal([]byte(c.FormValue("records")), &records); err != nil || len(records) == 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "Ungültige Importdaten")
+	}
+
+	preview, err := h.importService.PreviewCards(ctx, records)
+	if err != nil {
+		c.Logger().Errorf("Card import preview failed: %v", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Fehler beim Importieren der Karten")
+	}
+
+	result, err := h.importService.ImportCards(ctx, user.ID, preview)
+	if err != nil {
+		c.Logger().Errorf("Card import failed after %d cards: %v", result.Created, err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Fehler beim Importieren der Karten")
+	}
+	c.Logger().Printf("Imported %d cards for user %s (%d invalid, %d duplicates)", result.Created, user.ID, result.Invalid, len(result.Duplicates))
+
+	view := h.importView(c)
+	view.Result = result
+	return h.renderImport(c, http.StatusOK, view)
+}
+
+// importView creates the view with the fields every import page needs
+func (h *Handler) importView(c echo.Context) views.CardImportView {
+	return views.CardImportView{
+		User:            c.Get("current_user").(*models.User),
+		IsImpersonating: c.Get("is_impersonating") != nil,
+		Format:          importer.FormatCatima,
+	}
+}
+
+func (h *Handler) renderImport(c echo.Context, status int, view views.CardImportView) error {
+	csrfToken, ok := c.Get("csrf").(string)
+	if !ok {
+		csrfToken = ""
+	}
+	c.Response().WriteHeader(status)
+	return templates.CardsImport(c.Request().Context(), csrfToken, view).Render(c.Request().Context(), c.Response().Writer)
+}
+
+// readImportFile reads the uploaded "file" form field
+func readImportFile(c echo.Context) ([]byte, error) {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return nil, err
+	}
+	if fileHeader.Size > maxImportSize {
+		return nil, errImportTooLarge
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+	return io.ReadAll(io.LimitReader(file, maxImportSize))
+}
+
+// importErrorMessage translates parser errors for the upload form
+func importErrorMessage(c echo.Context, err error) string {
+	ctx := c.Request().Context()
+	switch {
+	case errors.Is(err, importer.ErrEncrypted):
+		return i18n.T(ctx, "cards.import.error.encrypted")
+	case errors.Is(err, importer.ErrNoCards):
+		return i18n.T(ctx, "cards.import.error.no_cards")
+	case errors.Is(err, importer.ErrMissingColumn):
+		return i18n.T(ctx, "cards.import.error.missing_column", map[string]any{"Detail": err.Error()})
+	default:
+		return i18n.T(ctx, "cards.import.error.unreadable")
+	}
+}
@@ -0,0 +1,192 @@
+package cards
+
+import (
+	"bytes"
+	"context"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"savvy/internal/importer"
+	"savvy/internal/models"
+	"savvy/internal/services"
+)
+
+// MockImportService is a mock implementation of ImportServiceInterface
+type MockImportService struct {
+	mock.Mock
+}
+
+func (m *MockImportService) PreviewCards(ctx context.Context, records []importer.Record) (*services.CardImportPreview, error) {
+	args := m.Called(ctx, records)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.CardImportPreview), args.Error(1)
+}
+
+func (m *MockImportService) ImportCards(ctx context.Context, userID uuid.UUID, preview *services.CardImportPreview) (*services.CardImportResult, error) {
+	args := m.Called(ctx, userID, preview)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.CardImportResult), args.Error(1)
+}
+
+// newImportUploadRequest builds a multipart request as sent by the import form
+func newImportUploadRequest(t *testing.T, fields map[string]string, fileContent string) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	for name, value := range fields {
+		require.NoError(t, w.WriteField(name, value))
+	}
+	if fileContent != "" {
+		f, err := w.CreateFormFile("file", "cards.csv")
+		require.NoError(t, err)
+		_, err = f.Write([]byte(fileContent))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/cards/import", &body)
+	req.Header.Set(echo.HeaderContentType, w.FormDataContentType())
+	return req
+}
+
+func newImportContext(req *http.Request) (echo.Context, *httptest.ResponseRecorder, *models.User) {
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+	user := &models.User{ID: uuid.New(), Email: "test@example.com"}
+	c.Set("current_user", user)
+	c.Set("csrf", "test-csrf-token")
+	setupI18nContext(c)
+	return c, rec, user
+}
+
+func TestImportForm(t *testing.T) {
+	c, rec, _ := newImportContext(httptest.NewRequest(http.MethodGet, "/cards/import", nil))
+	handler := &Handler{}
+
+	err := handler.ImportForm(c)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `enctype="multipart/form-data"`)
+}
+
+func TestImportPreview_CSV(t *testing.T) {
+	req := newImportUploadRequest(t, map[string]string{
+		"format":          "csv",
+		"map_card_number": "Nummer",
+	}, "Händler,Nummer\nMigros,2099123456789\nIKEA,\n")
+	c, rec, _ := newImportContext(req)
+
+	mockImportService := new(MockImportService)
+	preview := &services.CardImportPreview{Rows: []services.CardImportRow{
+		{Record: importer.Record{Line: 2}, BarcodeExact: true},
+		{Record: importer.Record{Line: 3}, BarcodeExact: true, Errors: map[string]string{"card_number": "required"}},
+	}}
+	preview.Rows[0].Request.MerchantName = "Migros"
+	preview.Rows[0].Request.CardNumber = "2099123456789"
+	mockImportService.On("PreviewCards", mock.Anything, mock.MatchedBy(func(records []importer.Record) bool {
+		return len(records) == 2 && records[0].MerchantName == "Migros" && records[1].CardNumber == ""
+	})).Return(preview, nil)
+
+	handler := &Handler{importService: mockImportService}
+
+	err := handler.ImportPreview(c)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	body := rec.Body.String()
+	assert.Contains(t, body, "2099123456789")
+	assert.Contains(t, body, `action="/cards/import/commit"`)
+	assert.Contains(t, body, `name="records"`)
+	mockImportService.AssertExpectations(t)
+}
+
+func TestImportPreview_ParseError(t *testing.T) {
+	req := newImportUploadRequest(t, map[string]string{"format": "csv"}, "Shop,Comment\nIKEA,Family\n")
+	c, rec, _ := newImportContext(req)
+	mockImportService := new(MockImportService)
+	handler := &Handler{importService: mockImportService}
+
+	err := handler.ImportPreview(c)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Contains(t, rec.Body.String(), `enctype="multipart/form-data"`) // Form shown again with the error
+	mockImportService.AssertNotCalled(t, "PreviewCards", mock.Anything, mock.Anything)
+}
+
+func TestImportPreview_MissingFile(t *testing.T) {
+	req := newImportUploadRequest(t, map[string]string{"format": "catima"}, "")
+	c, rec, _ := newImportContext(req)
+	handler := &Handler{importService: new(MockImportService)}
+
+	err := handler.ImportPreview(c)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+}
+
+func TestImportPreview_InvalidFormat(t *testing.T) {
+	req := newImportUploadRequest(t, map[string]string{"format": "keyring"}, "a,b\n")
+	c, _, _ := newImportContext(req)
+	handler := &Handler{importService: new(MockImportService)}
+
+	err := handler.ImportPreview(c)
+
+	var httpErr *echo.HTTPError
+	require.ErrorAs(t, err, &httpErr)
+	assert.Equal(t, http.StatusBadRequest, httpErr.Code)
+}
+
+func TestImportCommit_Success(t *testing.T) {
+	formData := url.Values{}
+	formData.Set("records", `[{"Line":2,"MerchantName":"Migros","Program":"Cumulus","CardNumber":"2099123456789","Status":"active"}]`)
+	req := httptest.NewRequest(http.MethodPost, "/cards/import/commit", strings.NewReader(formData.Encode()))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+	c, rec, user := newImportContext(req)
+
+	mockImportService := new(MockImportService)
+	preview := &services.CardImportPreview{}
+	mockImportService.On("PreviewCards", mock.Anything, []importer.Record{{
+		Line: 2, MerchantName: "Migros", Program: "Cumulus", CardNumber: "2099123456789", Status: "active",
+	}}).Return(preview, nil)
+	mockImportService.On("ImportCards", mock.Anything, user.ID, preview).
+		Return(&services.CardImportResult{Created: 1, Duplicates: []int{7}}, nil)
+
+	handler := &Handler{importService: mockImportService}
+
+	err := handler.ImportCommit(c)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `href="/cards"`)
+	mockImportService.AssertExpectations(t)
+}
+
+func TestImportCommit_InvalidRecords(t *testing.T) {
+	formData := url.Values{}
+	formData.Set("records", "not json")
+	req := httptest.NewRequest(http.MethodPost, "/cards/import/commit", strings.NewReader(formData.Encode()))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+	c, _, _ := newImportContext(req)
+	handler := &Handler{importService: new(MockImportService)}
+
+	err := handler.ImportCommit(c)
+
+	var httpErr *echo.HTTPError
+	require.ErrorAs(t, err, &httpErr)
+	assert.Equal(t, http.StatusBadRequest, httpErr.Code)
+}
@@ -0,0 +1,107 @@
+// Package handlers contains HTTP request handlers for the savvy system.
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"savvy/internal/models"
+	"savvy/internal/services"
+	"savvy/internal/templates"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ExchangeRatesHandler lets admins maintain the static exchange rates
+type ExchangeRatesHandler struct {
+	exchangeRateService services.ExchangeRateServiceInterface
+}
+
+// NewExchangeRatesHandler creates a new exchange rates handler
+func NewExchangeRatesHandler(exchangeRateService services.ExchangeRateServiceInterface) *ExchangeRatesHandler {
+	return &ExchangeRatesHandler{exchangeRateService: exchangeRateService}
+}
+
+// Index shows the effective rates and the stored static rates
+// GET /admin/exchange-rates
+func (h *ExchangeRatesHandler) Index(c echo.Context) error {
+	return h.render(c, templates.AdminExchangeRatesData{})
+}
+
+// Save creates or updates a static rate
+// POST /admin/exchange-rates
+func (h *ExchangeRatesHandler) Save(c echo.Context) error {
+	currentUser := c.Get("current_user").(*models.User)
+
+	currency := strings.ToUpper(strings.TrimSpace(c.FormValue("currency")))
+	rate, err := strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(c.FormValue("rate")), ",", "."), 64)
+	if err != nil {
+		return h.render(c, templates.AdminExchangeRatesData{Error: "admin.exchange_rates.error_invalid"})
+	}
+
+	if err := h.exchangeRateService.SetStaticRate(c.Request().Context(), currency, rate); err != nil {
+		msg := "admin.exchange_rates.error_save"
+		switch {
+		case errors.Is(err, services.ErrInvalidExchangeRate):
+			msg = "admin.exchange_rates.error_invalid"
+		case errors.Is(err, services.ErrExchangeRatesNotStatic):
+			msg = "admin.exchange_rates.error_feed"
+		default:
+			c.Logger().Errorf("Failed to save exchange rate %s: %v", currency, err)
+		}
+		return h.render(c, templates.AdminExchangeRatesData{Error: msg})
+	}
+
+	c.Logger().Infof("Exchange rate %s set to %g by %s", currency, rate, currentUser.Email)
+	return c.Redirect(http.StatusSeeOther, "/admin/exchange-rates")
+}
+
+// Delete removes a static rate, the configured rate applies again
+// DELETE /admin/exchange-rates/:currency
+func (h *ExchangeRatesHandler) Delete(c echo.Context) error {
+	currentUser := c.Get("current_user").(*models.User)
+	currency := c.Param("currency")
+
+	if err := h.exchangeRateService.DeleteStaticRate(c.Request().Context(), currency); err != nil {
+		if errors.Is(err, services.ErrExchangeRatesNotStatic) {
+			return c.String(http.StatusConflict, "Exchange rates are loaded from a feed")
+		}
+		return c.String(http.StatusInternalServerError, "Failed to delete exchange rate")
+	}
+
+	c.Logger().Infof("Exchange rate %s deleted by %s", currency, currentUser.Email)
+
+	// The effective rate changes as well, so reload the whole page
+	c.Response().Header().Set("HX-Redirect", "/admin/exchange-rates")
+	return c.NoContent(http.StatusOK)
+}
+
+// render loads the rates and renders the page
+func (h *ExchangeRatesHandler) render(c echo.Context, data templates.AdminExchangeRatesData) error {
+	currentUser := c.Get("current_user").(*models.User)
+	isImpersonating := c.Get("is_impersonating") != nil
+	csrfToken, ok := c.Get("csrf").(string)
+	if !ok {
+		csrfToken = ""
+	}
+
+	ctx := c.Request().Context()
+	data.IsStatic = h.exchangeRateService.IsStatic()
+
+	rates, err := h.exchangeRateService.GetRates(ctx)
+	if err != nil {
+		c.Logger().Warnf("Failed to load exchange rates: %v", err)
+		data.RatesUnavailable = true
+	}
+	data.Rates = rates
+
+	if data.IsStatic {
+		data.Stored, err = h.exchangeRateService.ListStaticRates(ctx)
+		if err != nil {
+			return c.String(http.StatusInternalServerError, "Failed to load exchange rates")
+		}
+	}
+
+	return templates.AdminExchangeRates(ctx, csrfToken, currentUser, isImpersonating, data).Render(ctx, c.Response().Writer)
+}
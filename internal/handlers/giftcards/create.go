@@ -5,8 +5,8 @@ import (
 	"net/http"
 	"savvy/internal/database"
 	"savvy/internal/models"
+	"savvy/internal/money"
 	"savvy/internal/validation"
-	"strconv"
 	"time"
 
 	"github.com/google/uuid"
@@ -17,7 +17,11 @@ import (
 func (h *Handler) Create(c echo.Context) error {
 	user := c.Get("current_user").(*models.User)
 
-	initialBalance, _ := strconv.ParseFloat(c.FormValue("initial_balance"), 64)
+	currency := c.FormValue("currency")
+	if currency == "" {
+		currency = money.DefaultCurrency
+	}
+	initialBalance, _ := money.Parse(c.FormValue("initial_balance"), currency)
 	cardNumber := c.FormValue("card_number")
 
 	var expiresAt *time.Time
@@ -38,7 +42,7 @@ func (h *Handler) Create(c echo.Context) error {
 		UserID:         &user.ID,
 		CardNumber:     cardNumber,
 		InitialBalance: initialBalance,
-		Currency:       c.FormValue("currency"),
+		Currency:       currency,
 		PIN:            c.FormValue("pin"),
 		ExpiresAt:      expiresAt,
 		Status:         "active",
@@ -63,10 +67,6 @@ func (h *Handler) Create(c echo.Context) error {
 		giftCard.BarcodeType = "CODE128"
 	}
 
-	if giftCard.Currency == "" {
-		giftCard.Currency = "CHF"
-	}
-
 	if err := h.giftCardService.CreateGiftCard(c.Request().Context(), &giftCard); err != nil {
 		if database.IsDuplicateError(err) {
 			c.Logger().Warnf("Duplicate gift card number detected by database constraint: %s", cardNumber)
@@ -12,6 +12,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"savvy/internal/models"
+	"savvy/internal/money"
 )
 
 func TestIndexHandler_Success(t *testing.T) {
@@ -40,7 +41,7 @@ func TestIndexHandler_Success(t *testing.T) {
 			UserID:         &userID,
 			CardNumber:     "GC1234567890",
 			MerchantName:   "Test Merchant 1",
-			InitialBalance: 100.00,
+			InitialBalance: money.New(10000, "CHF"),
 			Currency:       "CHF",
 			ExpiresAt:      &expiresAt,
 		},
@@ -49,7 +50,7 @@ func TestIndexHandler_Success(t *testing.T) {
 			UserID:         &userID,
 			CardNumber:     "GC0987654321",
 			MerchantName:   "Test Merchant 2",
-			InitialBalance: 50.00,
+			InitialBalance: money.New(5000, "CHF"),
 			Currency:       "EUR",
 			ExpiresAt:      &expiresAt,
 		},
@@ -167,7 +168,7 @@ func TestIndexHandler_WithImpersonation(t *testing.T) {
 			UserID:         &userID,
 			CardNumber:     "GC555",
 			MerchantName:   "Test Store",
-			InitialBalance: 75.00,
+			InitialBalance: money.New(7500, "CHF"),
 			Currency:       "USD",
 			ExpiresAt:      &expiresAt,
 		},
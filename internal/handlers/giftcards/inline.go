@@ -5,9 +5,9 @@ import (
 	"net/http"
 	"savvy/internal/i18n"
 	"savvy/internal/models"
+	"savvy/internal/money"
 	"savvy/internal/templates"
 	"savvy/internal/validation"
-	"strconv"
 	"time"
 
 	"github.com/google/uuid"
@@ -105,7 +105,15 @@ func (h *Handler) UpdateInline(c echo.Context) error {
 	}
 
 	// Parse balance
-	initialBalance, _ := strconv.ParseFloat(c.FormValue("initial_balance"), 64)
+	currency := c.FormValue("currency")
+	if currency == "" {
+		currency = giftCard.Currency
+	}
+	// Stored amounts are minor units of the current currency
+	if currency != giftCard.Currency && len(giftCard.Transactions) > 0 {
+		return c.String(http.StatusBadRequest, i18n.T(c.Request().Context(), "giftcards.form.currency_locked"))
+	}
+	initialBalance, _ := money.Parse(c.FormValue("initial_balance"), currency)
 
 	// Parse expiration date
 	var expiresAt *time.Time
@@ -126,8 +134,8 @@ func (h *Handler) UpdateInline(c echo.Context) error {
 	// Update fields
 	giftCard.CardNumber = c.FormValue("card_number")
 	giftCard.InitialBalance = initialBalance
-	giftCard.Currency = c.FormValue("currency")
-	giftCard.PIN = c.FormValue("pin")
+	giftCard.Currency = currency
+	giftCard.PIN = updatedPIN(c, giftCard.PIN)
 	giftCard.ExpiresAt = expiresAt
 	giftCard.BarcodeType = c.FormValue("barcode_type")
 	giftCard.Notes = c.FormValue("notes")
@@ -14,6 +14,7 @@ import (
 	"github.com/stretchr/testify/mock"
 	savvyi18n "savvy/internal/i18n"
 	"savvy/internal/models"
+	"savvy/internal/money"
 	"savvy/internal/services"
 )
 
@@ -52,8 +53,8 @@ func TestEditInline_Success(t *testing.T) {
 		ID:             giftCardID,
 		UserID:         &userID,
 		CardNumber:     "1234567890",
-		InitialBalance: 100.0,
-		CurrentBalance: 75.0,
+		InitialBalance: money.New(10000, "CHF"),
+		CurrentBalance: money.New(7500, "CHF"),
 		Currency:       "CHF",
 	}
 	mockGiftCardService.On("GetGiftCard", mock.Anything, giftCardID).Return(giftCard, nil)
@@ -171,8 +172,8 @@ func TestCancelEdit_Success(t *testing.T) {
 		ID:             giftCardID,
 		UserID:         &userID,
 		CardNumber:     "1234567890",
-		InitialBalance: 100.0,
-		CurrentBalance: 75.0,
+		InitialBalance: money.New(10000, "CHF"),
+		CurrentBalance: money.New(7500, "CHF"),
 		Currency:       "CHF",
 	}
 	mockGiftCardService.On("GetGiftCard", mock.Anything, giftCardID).Return(giftCard, nil)
@@ -268,8 +269,8 @@ func TestUpdateInline_Success(t *testing.T) {
 		ID:             giftCardID,
 		UserID:         &userID,
 		CardNumber:     "1234567890",
-		InitialBalance: 100.0,
-		CurrentBalance: 75.0,
+		InitialBalance: money.New(10000, "CHF"),
+		CurrentBalance: money.New(7500, "CHF"),
 		Currency:       "CHF",
 	}
 	mockGiftCardService.On("GetGiftCard", mock.Anything, giftCardID).Return(giftCard, nil).Twice()
@@ -377,8 +378,8 @@ func TestUpdateInline_ServiceError(t *testing.T) {
 		ID:             giftCardID,
 		UserID:         &userID,
 		CardNumber:     "1234567890",
-		InitialBalance: 100.0,
-		CurrentBalance: 75.0,
+		InitialBalance: money.New(10000, "CHF"),
+		CurrentBalance: money.New(7500, "CHF"),
 		Currency:       "CHF",
 	}
 	mockGiftCardService.On("GetGiftCard", mock.Anything, giftCardID).Return(giftCard, nil)
@@ -0,0 +1,136 @@
+// Package giftcards provides HTTP handlers for gift card management operations.
+package giftcards
+
+import (
+	"net/http"
+	"savvy/internal/audit"
+	"savvy/internal/i18n"
+	"savvy/internal/middleware"
+	"savvy/internal/models"
+	"savvy/internal/templates"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// RevealPIN shows the PIN if the user re-authenticated recently, otherwise
+// asks for the password (local accounts) or an OIDC login.
+// GET /gift-cards/:id/pin
+func (h *Handler) RevealPIN(c echo.Context) error {
+	user := c.Get("current_user").(*models.User)
+
+	giftCard, status, message := h.pinGiftCard(c, user)
+	if giftCard == nil {
+		return c.String(status, message)
+	}
+
+	if method, ok := middleware.RecentReauthentication(c); ok {
+		return h.renderRevealedPIN(c, giftCard, method)
+	}
+
+	csrfToken, ok := c.Get("csrf").(string)
+	if !ok {
+		csrfToken = ""
+	}
+	ctx := c.Request().Context()
+	return templates.GiftCardPINReauth(ctx, csrfToken, giftCard.ID.String(), user.IsOAuthUser(), "").Render(ctx, c.Response().Writer)
+}
+
+// ConfirmRevealPIN checks the password and shows the PIN.
+// POST /gift-cards/:id/pin
+func (h *Handler) ConfirmRevealPIN(c echo.Context) error {
+	user := c.Get("current_user").(*models.User)
+	ctx := c.Request().Context()
+
+	giftCard, status, message := h.pinGiftCard(c, user)
+	if giftCard == nil {
+		return c.String(status, message)
+	}
+
+	// OIDC accounts have a random password, they re-authenticate at the provider
+	if user.IsOAuthUser() {
+		return c.String(http.StatusForbidden, i18n.T(ctx, "error.unauthorized"))
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(c.FormValue("password"))); err != nil {
+		c.Logger().Warnf("PIN reveal for gift card %s: wrong password for %s", giftCard.ID, user.Email)
+		csrfToken, ok := c.Get("csrf").(string)
+		if !ok {
+			csrfToken = ""
+		}
+		return templates.GiftCardPINReauth(ctx, csrfToken, giftCard.ID.String(), false, i18n.T(ctx, "giftcards.pin.wrong_password")).Render(ctx, c.Response().Writer)
+	}
+
+	if err := middleware.MarkReauthenticated(c, middleware.ReauthPassword); err != nil {
+		c.Logger().Errorf("Failed to save re-authentication: %v", err)
+		return c.String(http.StatusInternalServerError, "Failed to save session")
+	}
+
+	return h.renderRevealedPIN(c, giftCard, middleware.ReauthPassword)
+}
+
+// HidePIN masks the PIN again.
+// GET /gift-cards/:id/pin/hide
+func (h *Handler) HidePIN(c echo.Context) error {
+	giftCardID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.String(http.StatusNotFound, i18n.T(c.Request().Context(), "error.gift_card_not_found"))
+	}
+
+	ctx := c.Request().Context()
+	return templates.GiftCardPINMasked(ctx, giftCardID.String()).Render(ctx, c.Response().Writer)
+}
+
+// pinGiftCard loads a gift card with a PIN the user may reveal. Admins
+// impersonating a user never see PINs. Returns the status and message to
+// respond with if the PIN cannot be revealed.
+func (h *Handler) pinGiftCard(c echo.Context, user *models.User) (*models.GiftCard, int, string) {
+	ctx := c.Request().Context()
+
+	giftCardID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return nil, http.StatusNotFound, i18n.T(ctx, "error.gift_card_not_found")
+	}
+
+	if c.Get("is_impersonating") != nil {
+		return nil, http.StatusForbidden, i18n.T(ctx, "giftcards.pin.impersonating")
+	}
+
+	perms, err := h.authzService.CheckGiftCardAccess(ctx, user.ID, giftCardID)
+	if err != nil || !perms.CanView {
+		return nil, http.StatusForbidden, i18n.T(ctx, "error.unauthorized")
+	}
+
+	giftCard, err := h.giftCardService.GetGiftCard(ctx, giftCardID)
+	if err != nil || giftCard.PIN == "" {
+		return nil, http.StatusNotFound, i18n.T(ctx, "error.gift_card_not_found")
+	}
+
+	return giftCard, http.StatusOK, ""
+}
+
+// renderRevealedPIN logs the reveal and renders the PIN. Nothing is shown
+// if the reveal cannot be logged.
+func (h *Handler) renderRevealedPIN(c echo.Context, giftCard *models.GiftCard, method string) error {
+	ctx := c.Request().Context()
+
+	if err := audit.LogPINRevealFromContext(c, h.db, giftCard.ID, method); err != nil {
+		c.Logger().Errorf("Failed to log PIN reveal for gift card %s: %v", giftCard.ID, err)
+		return c.String(http.StatusInternalServerError, i18n.T(ctx, "giftcards.pin.error_log"))
+	}
+
+	return templates.GiftCardPINRevealed(ctx, giftCard.ID.String(), giftCard.PIN).Render(ctx, c.Response().Writer)
+}
+
+// updatedPIN returns the PIN to store from an edit form. The forms never
+// contain the current PIN, so an empty field keeps it unless remove_pin is set.
+func updatedPIN(c echo.Context, current string) string {
+	if pin := c.FormValue("pin"); pin != "" {
+		return pin
+	}
+	if c.FormValue("remove_pin") == trueStringValue {
+		return ""
+	}
+	return current
+}
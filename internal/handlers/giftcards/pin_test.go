@@ -0,0 +1,140 @@
+package giftcards
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+	"savvy/internal/middleware"
+	"savvy/internal/models"
+	"savvy/internal/services"
+)
+
+func setupPINTest(t *testing.T, method string, form url.Values, user *models.User) (echo.Context, *httptest.ResponseRecorder, *Handler, uuid.UUID) {
+	t.Helper()
+	middleware.InitSessionStore("test-secret-with-at-least-32-characters", false)
+
+	e := echo.New()
+	giftCardID := uuid.New()
+
+	var body *strings.Reader
+	if form != nil {
+		body = strings.NewReader(form.Encode())
+	} else {
+		body = strings.NewReader("")
+	}
+	req := httptest.NewRequest(method, "/gift-cards/"+giftCardID.String()+"/pin", body)
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues(giftCardID.String())
+	c.Set("current_user", user)
+	c.Set("csrf", "test-csrf-token")
+	setupI18nContext(c)
+
+	mockAuthzService := new(MockAuthzService)
+	mockGiftCardService := new(MockGiftCardService)
+	mockAuthzService.On("CheckGiftCardAccess", mock.Anything, user.ID, giftCardID).
+		Return(&services.ResourcePermissions{CanView: true}, nil)
+	mockGiftCardService.On("GetGiftCard", mock.Anything, giftCardID).
+		Return(&models.GiftCard{ID: giftCardID, PIN: "4711"}, nil)
+
+	handler := &Handler{
+		giftCardService: mockGiftCardService,
+		authzService:    mockAuthzService,
+	}
+	return c, rec, handler, giftCardID
+}
+
+func TestRevealPIN_RequiresReauthentication(t *testing.T) {
+	user := &models.User{ID: uuid.New(), Email: "sharee@example.com"}
+	c, rec, handler, giftCardID := setupPINTest(t, http.MethodGet, nil, user)
+
+	err := handler.RevealPIN(c)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `name="password"`)
+	assert.Contains(t, rec.Body.String(), "/gift-cards/"+giftCardID.String()+"/pin")
+	assert.NotContains(t, rec.Body.String(), "4711")
+}
+
+func TestRevealPIN_OAuthUser(t *testing.T) {
+	user := &models.User{ID: uuid.New(), Email: "sso@example.com", AuthProvider: "oauth"}
+	c, rec, handler, giftCardID := setupPINTest(t, http.MethodGet, nil, user)
+
+	err := handler.RevealPIN(c)
+
+	assert.NoError(t, err)
+	assert.Contains(t, rec.Body.String(), "/auth/oauth/reauth?return_to=/gift-cards/"+giftCardID.String())
+	assert.NotContains(t, rec.Body.String(), `name="password"`)
+	assert.NotContains(t, rec.Body.String(), "4711")
+}
+
+func TestRevealPIN_Impersonating(t *testing.T) {
+	user := &models.User{ID: uuid.New(), Email: "owner@example.com"}
+	c, rec, handler, _ := setupPINTest(t, http.MethodGet, nil, user)
+	c.Set("is_impersonating", true)
+
+	err := handler.RevealPIN(c)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "4711")
+}
+
+func TestConfirmRevealPIN_WrongPassword(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("correct-password"), bcrypt.MinCost)
+	assert.NoError(t, err)
+	user := &models.User{ID: uuid.New(), Email: "sharee@example.com", PasswordHash: string(hash)}
+	c, rec, handler, _ := setupPINTest(t, http.MethodPost, url.Values{"password": {"wrong-password"}}, user)
+
+	err = handler.ConfirmRevealPIN(c)
+
+	assert.NoError(t, err)
+	assert.Contains(t, rec.Body.String(), `name="password"`)
+	assert.NotContains(t, rec.Body.String(), "4711")
+	_, ok := middleware.RecentReauthentication(c)
+	assert.False(t, ok)
+}
+
+func TestConfirmRevealPIN_OAuthUserForbidden(t *testing.T) {
+	user := &models.User{ID: uuid.New(), Email: "sso@example.com", AuthProvider: "oauth"}
+	c, rec, handler, _ := setupPINTest(t, http.MethodPost, url.Values{"password": {"anything"}}, user)
+
+	err := handler.ConfirmRevealPIN(c)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func TestUpdatedPIN(t *testing.T) {
+	tests := []struct {
+		name string
+		form url.Values
+		want string
+	}{
+		{name: "empty keeps PIN", form: url.Values{"pin": {""}}, want: "4711"},
+		{name: "new PIN", form: url.Values{"pin": {"1234"}}, want: "1234"},
+		{name: "remove PIN", form: url.Values{"remove_pin": {"true"}}, want: ""},
+		{name: "new PIN wins over remove", form: url.Values{"pin": {"1234"}, "remove_pin": {"true"}}, want: "1234"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.form.Encode()))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+			c := echo.New().NewContext(req, httptest.NewRecorder())
+
+			assert.Equal(t, tt.want, updatedPIN(c, "4711"))
+		})
+	}
+}
@@ -33,8 +33,10 @@ func (h *Handler) Show(c echo.Context) error {
 	}
 
 	var shares []models.GiftCardShare
+	var pinReveals []models.AuditLog
 	if perms.IsOwner {
 		shares, _ = h.shareService.GetGiftCardShares(c.Request().Context(), giftCardID)
+		pinReveals, _ = h.giftCardService.GetPINReveals(c.Request().Context(), giftCardID)
 	}
 
 	// Load all merchants for dropdown (used in inline edit)
@@ -51,10 +53,11 @@ func (h *Handler) Show(c echo.Context) error {
 	}
 
 	view := views.GiftCardShowView{
-		GiftCard:  *giftCard,
-		Merchants: merchants,
-		Shares:    shares,
-		User:      user,
+		GiftCard:   *giftCard,
+		Merchants:  merchants,
+		Shares:     shares,
+		PINReveals: pinReveals,
+		User:       user,
 		Permissions: views.GiftCardPermissions{
 			CanEdit:             perms.CanEdit,
 			CanDelete:           perms.CanDelete,
@@ -16,6 +16,7 @@ import (
 	"golang.org/x/text/language"
 	savvyi18n "savvy/internal/i18n"
 	"savvy/internal/models"
+	"savvy/internal/money"
 	"savvy/internal/services"
 )
 
@@ -66,14 +67,9 @@ func (m *MockGiftCardService) CountUserGiftCards(ctx context.Context, userID uui
 	return args.Get(0).(int64), args.Error(1)
 }
 
-func (m *MockGiftCardService) GetTotalBalance(ctx context.Context, userID uuid.UUID) (float64, error) {
-	args := m.Called(ctx, userID)
-	return args.Get(0).(float64), args.Error(1)
-}
-
-func (m *MockGiftCardService) GetCurrentBalance(ctx context.Context, giftCardID uuid.UUID) (float64, error) {
+func (m *MockGiftCardService) GetCurrentBalance(ctx context.Context, giftCardID uuid.UUID) (money.Money, error) {
 	args := m.Called(ctx, giftCardID)
-	return args.Get(0).(float64), args.Error(1)
+	return args.Get(0).(money.Money), args.Error(1)
 }
 
 func (m *MockGiftCardService) CanUserAccessGiftCard(ctx context.Context, giftCardID, userID uuid.UUID) (bool, error) {
@@ -94,11 +90,24 @@ func (m *MockGiftCardService) GetTransaction(ctx context.Context, transactionID,
 	return args.Get(0).(*models.GiftCardTransaction), args.Error(1)
 }
 
+func (m *MockGiftCardService) UpdateTransaction(ctx context.Context, transaction *models.GiftCardTransaction, userID uuid.UUID) error {
+	args := m.Called(ctx, transaction, userID)
+	return args.Error(0)
+}
+
 func (m *MockGiftCardService) DeleteTransaction(ctx context.Context, transactionID uuid.UUID) error {
 	args := m.Called(ctx, transactionID)
 	return args.Error(0)
 }
 
+func (m *MockGiftCardService) GetPINReveals(ctx context.Context, giftCardID uuid.UUID) ([]models.AuditLog, error) {
+	args := m.Called(ctx, giftCardID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.AuditLog), args.Error(1)
+}
+
 // MockAuthzService is a manual mock for AuthzServiceInterface
 type MockAuthzService struct {
 	mock.Mock
@@ -399,6 +408,7 @@ func TestShowHandler_Owner(t *testing.T) {
 	mockAuthzService.On("CheckGiftCardAccess", mock.Anything, userID, giftCardID).Return(perms, nil)
 	mockGiftCardService.On("GetGiftCard", mock.Anything, giftCardID).Return(giftCard, nil)
 	mockShareService.On("GetGiftCardShares", mock.Anything, giftCardID).Return(shares, nil)
+	mockGiftCardService.On("GetPINReveals", mock.Anything, giftCardID).Return([]models.AuditLog{}, nil)
 	mockMerchantService.On("GetAllMerchants", mock.Anything).Return(merchants, nil)
 	mockFavoriteService.On("IsFavorite", mock.Anything, userID, "gift_card", giftCardID).Return(false, nil)
 
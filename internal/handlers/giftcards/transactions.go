@@ -6,9 +6,9 @@ import (
 	"net/http"
 	"savvy/internal/audit"
 	"savvy/internal/models"
+	"savvy/internal/money"
 	"savvy/internal/templates"
 	"savvy/internal/validation"
-	"strconv"
 	"strings"
 	"time"
 
@@ -51,34 +51,48 @@ func (h *Handler) TransactionCreate(c echo.Context) error {
 		return c.NoContent(http.StatusNotFound)
 	}
 
+	// Parse kind (purchase if omitted)
+	kind := c.FormValue("kind")
+	if kind == "" {
+		kind = models.TransactionKindPurchase
+	}
+	if !models.IsValidTransactionKind(kind) {
+		c.Logger().Errorf("Invalid transaction kind: %q", kind)
+		return c.NoContent(http.StatusBadRequest)
+	}
+
 	// Parse and validate amount
 	amountStr := c.FormValue("amount")
-	amount, err := strconv.ParseFloat(amountStr, 64)
+	amount, err := money.Parse(amountStr, giftCard.Currency)
 	if err != nil {
 		c.Logger().Errorf("Amount parse failed: %v", err)
 		return c.NoContent(http.StatusBadRequest)
 	}
 
-	// Validate amount is positive (expenses are positive values)
-	if amount <= 0 {
-		c.Logger().Errorf("Amount must be positive, got: %f", amount)
-		csrfToken, ok := c.Get("csrf").(string)
-		if !ok {
-			csrfToken = ""
-		}
-		return templates.TransactionNewFormWithError(c.Request().Context(), csrfToken, giftCardID.String(), "Der Betrag muss positiv sein").Render(c.Request().Context(), c.Response().Writer)
+	csrfToken, ok := c.Get("csrf").(string)
+	if !ok {
+		csrfToken = ""
+	}
+
+	if errorMsg := transactionAmountError(kind, amount); errorMsg != "" {
+		c.Logger().Errorf("Invalid %s amount: %s", kind, amount)
+		return templates.TransactionNewFormWithError(c.Request().Context(), csrfToken, giftCardID.String(), errorMsg).Render(c.Request().Context(), c.Response().Writer)
+	}
+
+	transaction := models.GiftCardTransaction{
+		GiftCardID:      giftCard.ID,
+		Kind:            kind,
+		Amount:          amount,
+		Description:     c.FormValue("description"),
+		CreatedByUserID: &user.ID, // Track who created this transaction
 	}
 
 	// Check if sufficient balance exists
-	currentBalance := giftCard.CurrentBalance
+	currentBalance := giftCard.GetCurrentBalance()
 
-	if amount > currentBalance {
-		c.Logger().Warnf("Insufficient funds: amount=%f, balance=%f", amount, currentBalance)
-		csrfToken, ok := c.Get("csrf").(string)
-		if !ok {
-			csrfToken = ""
-		}
-		errorMsg := fmt.Sprintf("Nicht genügend Guthaben. Verfügbar: %.2f CHF", currentBalance)
+	if currentBalance.Add(transaction.BalanceEffect()).IsNegative() {
+		c.Logger().Warnf("Insufficient funds: %s %s, balance=%s", kind, amount, currentBalance)
+		errorMsg := fmt.Sprintf("Nicht genügend Guthaben. Verfügbar: %s", currentBalance)
 		return templates.TransactionNewFormWithError(c.Request().Context(), csrfToken, giftCardID.String(), errorMsg).Render(c.Request().Context(), c.Response().Writer)
 	}
 
@@ -90,15 +104,7 @@ func (h *Handler) TransactionCreate(c echo.Context) error {
 		return c.NoContent(http.StatusBadRequest)
 	}
 	// Set to noon
-	transactionDate = time.Date(transactionDate.Year(), transactionDate.Month(), transactionDate.Day(), 12, 0, 0, 0, time.UTC)
-
-	transaction := models.GiftCardTransaction{
-		GiftCardID:      giftCard.ID,
-		Amount:          amount,
-		Description:     c.FormValue("description"),
-		TransactionDate: transactionDate,
-		CreatedByUserID: &user.ID, // Track who created this transaction
-	}
+	transaction.TransactionDate = time.Date(transactionDate.Year(), transactionDate.Month(), transactionDate.Day(), 12, 0, 0, 0, time.UTC)
 
 	if err := h.giftCardService.CreateTransaction(c.Request().Context(), &transaction); err != nil {
 		// Check if error is from balance constraint trigger
@@ -120,6 +126,127 @@ func (h *Handler) TransactionCreate(c echo.Context) error {
 	return c.NoContent(http.StatusOK)
 }
 
+// TransactionEdit shows the inline form for editing a transaction (HTMX)
+func (h *Handler) TransactionEdit(c echo.Context) error {
+	user := c.Get("current_user").(*models.User)
+
+	giftCardID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	transactionID, err := uuid.Parse(c.Param("transaction_id"))
+	if err != nil {
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	// Check authorization
+	perms, err := h.authzService.CheckGiftCardAccess(c.Request().Context(), user.ID, giftCardID)
+	if err != nil || !perms.CanEditTransactions {
+		return c.NoContent(http.StatusForbidden)
+	}
+
+	if _, err := h.giftCardService.GetGiftCard(c.Request().Context(), giftCardID); err != nil {
+		return c.NoContent(http.StatusNotFound)
+	}
+
+	transaction, err := h.giftCardService.GetTransaction(c.Request().Context(), transactionID, giftCardID)
+	if err != nil {
+		return c.NoContent(http.StatusNotFound)
+	}
+
+	csrfToken, ok := c.Get("csrf").(string)
+	if !ok {
+		csrfToken = ""
+	}
+	return templates.TransactionEditForm(c.Request().Context(), csrfToken, giftCardID.String(), *transaction, "").Render(c.Request().Context(), c.Response().Writer)
+}
+
+// TransactionUpdate saves an edited transaction and keeps its previous values as revision (HTMX)
+func (h *Handler) TransactionUpdate(c echo.Context) error {
+	user := c.Get("current_user").(*models.User)
+
+	giftCardID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	transactionID, err := uuid.Parse(c.Param("transaction_id"))
+	if err != nil {
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	// Check authorization
+	perms, err := h.authzService.CheckGiftCardAccess(c.Request().Context(), user.ID, giftCardID)
+	if err != nil || !perms.CanEditTransactions {
+		return c.NoContent(http.StatusForbidden)
+	}
+
+	giftCard, err := h.giftCardService.GetGiftCard(c.Request().Context(), giftCardID)
+	if err != nil {
+		return c.NoContent(http.StatusNotFound)
+	}
+
+	// Verify transaction exists and belongs to this gift card
+	transaction, err := h.giftCardService.GetTransaction(c.Request().Context(), transactionID, giftCardID)
+	if err != nil {
+		return c.NoContent(http.StatusNotFound)
+	}
+	previousEffect := transaction.BalanceEffect()
+
+	amount, err := money.Parse(c.FormValue("amount"), giftCard.Currency)
+	if err != nil {
+		c.Logger().Errorf("Amount parse failed: %v", err)
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	transactionDate, err := validation.ParseAndValidateDate(c.FormValue("transaction_date"), true) // allow past transactions
+	if err != nil {
+		c.Logger().Errorf("Transaction date validation failed: %v", err)
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	csrfToken, ok := c.Get("csrf").(string)
+	if !ok {
+		csrfToken = ""
+	}
+
+	edited := *transaction
+	edited.Amount = amount
+	edited.Description = c.FormValue("description")
+	// Set to noon
+	edited.TransactionDate = time.Date(transactionDate.Year(), transactionDate.Month(), transactionDate.Day(), 12, 0, 0, 0, time.UTC)
+
+	if errorMsg := transactionAmountError(edited.Kind, amount); errorMsg != "" {
+		return templates.TransactionEditForm(c.Request().Context(), csrfToken, giftCardID.String(), edited, errorMsg).Render(c.Request().Context(), c.Response().Writer)
+	}
+
+	// The balance without this transaction must cover the edited one
+	currentBalance := giftCard.GetCurrentBalance()
+	if currentBalance.Sub(previousEffect).Add(edited.BalanceEffect()).IsNegative() {
+		c.Logger().Warnf("Insufficient funds for edit: %s %s, balance=%s", edited.Kind, amount, currentBalance)
+		errorMsg := fmt.Sprintf("Nicht genügend Guthaben. Verfügbar: %s", currentBalance)
+		return templates.TransactionEditForm(c.Request().Context(), csrfToken, giftCardID.String(), edited, errorMsg).Render(c.Request().Context(), c.Response().Writer)
+	}
+
+	// Add user context for audit logging (automatic hook will create audit log)
+	ctx := audit.AddUserIDToContext(c.Request().Context(), user.ID)
+	if err := h.giftCardService.UpdateTransaction(ctx, &edited, user.ID); err != nil {
+		// Balance trigger prevented a race with another transaction
+		if strings.Contains(err.Error(), "Insufficient balance") ||
+			strings.Contains(err.Error(), "check_gift_card_balance") {
+			c.Logger().Warnf("Database balance check failed on edit: %v", err)
+			c.Response().Header().Set("HX-Redirect", "/gift-cards/"+giftCard.ID.String()+"?error=insufficient_balance")
+			return c.NoContent(http.StatusBadRequest)
+		}
+		c.Logger().Errorf("Failed to update transaction: %v", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	c.Response().Header().Set("HX-Redirect", "/gift-cards/"+giftCard.ID.String())
+	return c.NoContent(http.StatusOK)
+}
+
 // TransactionDelete deletes a transaction (HTMX)
 func (h *Handler) TransactionDelete(c echo.Context) error {
 	user := c.Get("current_user").(*models.User)
@@ -154,6 +281,13 @@ func (h *Handler) TransactionDelete(c echo.Context) error {
 	// Add user context for audit logging (automatic hook will create audit log)
 	ctx := audit.AddUserIDToContext(c.Request().Context(), user.ID)
 	if err := h.giftCardService.DeleteTransaction(ctx, transactionID); err != nil {
+		// Removing a reload or refund that has already been spent would
+		// make the balance negative
+		if strings.Contains(err.Error(), "Insufficient balance") ||
+			strings.Contains(err.Error(), "check_gift_card_balance") {
+			c.Response().Header().Set("HX-Redirect", "/gift-cards/"+giftCard.ID.String()+"?error=insufficient_balance")
+			return c.NoContent(http.StatusBadRequest)
+		}
 		return c.NoContent(http.StatusInternalServerError)
 	}
 
@@ -161,3 +295,18 @@ func (h *Handler) TransactionDelete(c echo.Context) error {
 	c.Response().Header().Set("HX-Redirect", "/gift-cards/"+giftCard.ID.String())
 	return c.NoContent(http.StatusOK)
 }
+
+// transactionAmountError returns the form error for an amount that does not
+// fit the kind: adjustments are signed, all other kinds use positive amounts
+func transactionAmountError(kind string, amount money.Money) string {
+	if kind == models.TransactionKindAdjustment {
+		if amount.IsZero() {
+			return "Der Betrag darf nicht null sein"
+		}
+		return ""
+	}
+	if !amount.IsPositive() {
+		return "Der Betrag muss positiv sein"
+	}
+	return ""
+}
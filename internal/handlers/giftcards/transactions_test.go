@@ -1,6 +1,7 @@
 package giftcards
 
 import (
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -14,6 +15,7 @@ import (
 	"github.com/stretchr/testify/mock"
 	savvyi18n "savvy/internal/i18n"
 	"savvy/internal/models"
+	"savvy/internal/money"
 	"savvy/internal/services"
 )
 
@@ -188,7 +190,7 @@ func TestTransactionCreate_InvalidAmount(t *testing.T) {
 		ID:             giftCardID,
 		UserID:         &userID,
 		CardNumber:     "1234567890",
-		CurrentBalance: 100.0,
+		CurrentBalance: money.New(10000, "CHF"),
 		Currency:       "CHF",
 	}
 	mockGiftCardService.On("GetGiftCard", mock.Anything, giftCardID).Return(giftCard, nil)
@@ -247,7 +249,7 @@ func TestTransactionCreate_NegativeAmount(t *testing.T) {
 		ID:             giftCardID,
 		UserID:         &userID,
 		CardNumber:     "1234567890",
-		CurrentBalance: 100.0,
+		CurrentBalance: money.New(10000, "CHF"),
 		Currency:       "CHF",
 	}
 	mockGiftCardService.On("GetGiftCard", mock.Anything, giftCardID).Return(giftCard, nil)
@@ -307,7 +309,7 @@ func TestTransactionCreate_InsufficientBalance(t *testing.T) {
 		ID:             giftCardID,
 		UserID:         &userID,
 		CardNumber:     "1234567890",
-		CurrentBalance: 100.0,
+		CurrentBalance: money.New(10000, "CHF"),
 		Currency:       "CHF",
 	}
 	mockGiftCardService.On("GetGiftCard", mock.Anything, giftCardID).Return(giftCard, nil)
@@ -361,7 +363,7 @@ func TestTransactionCreate_InvalidDate(t *testing.T) {
 		ID:             giftCardID,
 		UserID:         &userID,
 		CardNumber:     "1234567890",
-		CurrentBalance: 100.0,
+		CurrentBalance: money.New(10000, "CHF"),
 		Currency:       "CHF",
 	}
 	mockGiftCardService.On("GetGiftCard", mock.Anything, giftCardID).Return(giftCard, nil)
@@ -499,3 +501,345 @@ func TestTransactionDelete_GiftCardNotFound(t *testing.T) {
 	mockAuthz.AssertExpectations(t)
 	mockGiftCardService.AssertExpectations(t)
 }
+
+func TestTransactionCreate_ReloadAboveBalance(t *testing.T) {
+	e := echo.New()
+	giftCardID := uuid.New()
+
+	formData := url.Values{}
+	formData.Set("kind", models.TransactionKindReload)
+	formData.Set("amount", "150.00") // More than balance, but raises it
+	formData.Set("description", "Aufladung")
+	formData.Set("transaction_date", time.Now().Format("2006-01-02"))
+
+	req := httptest.NewRequest(http.MethodPost, "/gift-cards/"+giftCardID.String()+"/transactions", strings.NewReader(formData.Encode()))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues(giftCardID.String())
+
+	userID := uuid.New()
+	user := &models.User{
+		ID:    userID,
+		Email: "test@example.com",
+	}
+	c.Set("current_user", user)
+
+	mockAuthz := new(MockAuthzService)
+	perms := &services.ResourcePermissions{
+		CanView:             true,
+		CanEditTransactions: true,
+	}
+	mockAuthz.On("CheckGiftCardAccess", mock.Anything, userID, giftCardID).Return(perms, nil)
+
+	mockGiftCardService := new(MockGiftCardService)
+	giftCard := &models.GiftCard{
+		ID:             giftCardID,
+		UserID:         &userID,
+		CardNumber:     "1234567890",
+		CurrentBalance: money.New(10000, "CHF"),
+		Currency:       "CHF",
+	}
+	mockGiftCardService.On("GetGiftCard", mock.Anything, giftCardID).Return(giftCard, nil)
+	mockGiftCardService.On("CreateTransaction", mock.Anything, mock.MatchedBy(func(tx *models.GiftCardTransaction) bool {
+		return tx.Kind == models.TransactionKindReload && tx.Amount == money.New(15000, "CHF")
+	})).Return(nil)
+
+	handler := &Handler{
+		authzService:    mockAuthz,
+		giftCardService: mockGiftCardService,
+	}
+
+	err := handler.TransactionCreate(c)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "/gift-cards/"+giftCardID.String(), rec.Header().Get("HX-Redirect"))
+	mockGiftCardService.AssertExpectations(t)
+}
+
+func TestTransactionCreate_NegativeAdjustmentAboveBalance(t *testing.T) {
+	e := echo.New()
+	giftCardID := uuid.New()
+
+	formData := url.Values{}
+	formData.Set("kind", models.TransactionKindAdjustment)
+	formData.Set("amount", "-120.00")
+	formData.Set("description", "Korrektur")
+	formData.Set("transaction_date", time.Now().Format("2006-01-02"))
+
+	req := httptest.NewRequest(http.MethodPost, "/gift-cards/"+giftCardID.String()+"/transactions", strings.NewReader(formData.Encode()))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues(giftCardID.String())
+
+	localizer := savvyi18n.NewLocalizer("de")
+	ctx := savvyi18n.SetLocalizer(c.Request().Context(), localizer)
+	c.SetRequest(c.Request().WithContext(ctx))
+
+	userID := uuid.New()
+	c.Set("current_user", &models.User{ID: userID, Email: "test@example.com"})
+	c.Set("csrf", "test-csrf-token")
+
+	mockAuthz := new(MockAuthzService)
+	perms := &services.ResourcePermissions{
+		CanView:             true,
+		CanEditTransactions: true,
+	}
+	mockAuthz.On("CheckGiftCardAccess", mock.Anything, userID, giftCardID).Return(perms, nil)
+
+	mockGiftCardService := new(MockGiftCardService)
+	giftCard := &models.GiftCard{
+		ID:             giftCardID,
+		UserID:         &userID,
+		CardNumber:     "1234567890",
+		CurrentBalance: money.New(10000, "CHF"),
+		Currency:       "CHF",
+	}
+	mockGiftCardService.On("GetGiftCard", mock.Anything, giftCardID).Return(giftCard, nil)
+
+	handler := &Handler{
+		authzService:    mockAuthz,
+		giftCardService: mockGiftCardService,
+	}
+
+	err := handler.TransactionCreate(c)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code) // Returns form with error
+	assert.Contains(t, rec.Body.String(), "Nicht genügend Guthaben")
+	mockGiftCardService.AssertNotCalled(t, "CreateTransaction", mock.Anything, mock.Anything)
+}
+
+func TestTransactionCreate_InvalidKind(t *testing.T) {
+	e := echo.New()
+	giftCardID := uuid.New()
+
+	formData := url.Values{}
+	formData.Set("kind", "withdrawal")
+	formData.Set("amount", "10.00")
+	formData.Set("transaction_date", time.Now().Format("2006-01-02"))
+
+	req := httptest.NewRequest(http.MethodPost, "/gift-cards/"+giftCardID.String()+"/transactions", strings.NewReader(formData.Encode()))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues(giftCardID.String())
+
+	userID := uuid.New()
+	c.Set("current_user", &models.User{ID: userID, Email: "test@example.com"})
+
+	mockAuthz := new(MockAuthzService)
+	perms := &services.ResourcePermissions{
+		CanView:             true,
+		CanEditTransactions: true,
+	}
+	mockAuthz.On("CheckGiftCardAccess", mock.Anything, userID, giftCardID).Return(perms, nil)
+
+	mockGiftCardService := new(MockGiftCardService)
+	giftCard := &models.GiftCard{ID: giftCardID, UserID: &userID, CurrentBalance: money.New(10000, "CHF"), Currency: "CHF"}
+	mockGiftCardService.On("GetGiftCard", mock.Anything, giftCardID).Return(giftCard, nil)
+
+	handler := &Handler{
+		authzService:    mockAuthz,
+		giftCardService: mockGiftCardService,
+	}
+
+	err := handler.TransactionCreate(c)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	mockGiftCardService.AssertNotCalled(t, "CreateTransaction", mock.Anything, mock.Anything)
+}
+
+func TestTransactionEdit_Success(t *testing.T) {
+	e := echo.New()
+	giftCardID := uuid.New()
+	transactionID := uuid.New()
+	req := httptest.NewRequest(http.MethodGet, "/gift-cards/"+giftCardID.String()+"/transactions/"+transactionID.String()+"/edit", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("id", "transaction_id")
+	c.SetParamValues(giftCardID.String(), transactionID.String())
+
+	localizer := savvyi18n.NewLocalizer("de")
+	ctx := savvyi18n.SetLocalizer(c.Request().Context(), localizer)
+	c.SetRequest(c.Request().WithContext(ctx))
+
+	userID := uuid.New()
+	c.Set("current_user", &models.User{ID: userID, Email: "test@example.com"})
+	c.Set("csrf", "test-csrf-token")
+
+	mockAuthz := new(MockAuthzService)
+	perms := &services.ResourcePermissions{
+		CanView:             true,
+		CanEditTransactions: true,
+	}
+	mockAuthz.On("CheckGiftCardAccess", mock.Anything, userID, giftCardID).Return(perms, nil)
+
+	mockGiftCardService := new(MockGiftCardService)
+	giftCard := &models.GiftCard{ID: giftCardID, UserID: &userID, CurrentBalance: money.New(7500, "CHF"), Currency: "CHF"}
+	mockGiftCardService.On("GetGiftCard", mock.Anything, giftCardID).Return(giftCard, nil)
+	transaction := &models.GiftCardTransaction{
+		ID:              transactionID,
+		GiftCardID:      giftCardID,
+		Kind:            models.TransactionKindPurchase,
+		Amount:          money.New(2500, ""),
+		Description:     "Kopfhörer",
+		TransactionDate: time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC),
+	}
+	mockGiftCardService.On("GetTransaction", mock.Anything, transactionID, giftCardID).Return(transaction, nil)
+
+	handler := &Handler{
+		authzService:    mockAuthz,
+		giftCardService: mockGiftCardService,
+	}
+
+	err := handler.TransactionEdit(c)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	body := rec.Body.String()
+	assert.Contains(t, body, `value="25.00"`)
+	assert.Contains(t, body, `value="2026-10-01"`)
+	assert.Contains(t, body, "Kopfhörer")
+}
+
+func TestTransactionEdit_Forbidden(t *testing.T) {
+	e := echo.New()
+	giftCardID := uuid.New()
+	transactionID := uuid.New()
+	req := httptest.NewRequest(http.MethodGet, "/gift-cards/"+giftCardID.String()+"/transactions/"+transactionID.String()+"/edit", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("id", "transaction_id")
+	c.SetParamValues(giftCardID.String(), transactionID.String())
+
+	userID := uuid.New()
+	c.Set("current_user", &models.User{ID: userID, Email: "test@example.com"})
+
+	mockAuthz := new(MockAuthzService)
+	perms := &services.ResourcePermissions{
+		CanView:             true,
+		CanEditTransactions: false,
+	}
+	mockAuthz.On("CheckGiftCardAccess", mock.Anything, userID, giftCardID).Return(perms, nil)
+
+	handler := &Handler{
+		authzService: mockAuthz,
+	}
+
+	err := handler.TransactionEdit(c)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+// newTransactionUpdateContext builds a PATCH request editing a 25.00 CHF purchase
+// on a gift card with 10.00 CHF left
+func newTransactionUpdateContext(t *testing.T, amount string) (echo.Context, *httptest.ResponseRecorder, *MockGiftCardService, *Handler, uuid.UUID, uuid.UUID) {
+	t.Helper()
+	e := echo.New()
+	giftCardID := uuid.New()
+	transactionID := uuid.New()
+
+	formData := url.Values{}
+	formData.Set("amount", amount)
+	formData.Set("description", "Kopfhörer (korrigiert)")
+	formData.Set("transaction_date", "2026-10-02")
+
+	req := httptest.NewRequest(http.MethodPatch, "/gift-cards/"+giftCardID.String()+"/transactions/"+transactionID.String(), strings.NewReader(formData.Encode()))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("id", "transaction_id")
+	c.SetParamValues(giftCardID.String(), transactionID.String())
+
+	localizer := savvyi18n.NewLocalizer("de")
+	ctx := savvyi18n.SetLocalizer(c.Request().Context(), localizer)
+	c.SetRequest(c.Request().WithContext(ctx))
+
+	userID := uuid.New()
+	c.Set("current_user", &models.User{ID: userID, Email: "test@example.com"})
+	c.Set("csrf", "test-csrf-token")
+
+	mockAuthz := new(MockAuthzService)
+	perms := &services.ResourcePermissions{
+		CanView:             true,
+		CanEditTransactions: true,
+	}
+	mockAuthz.On("CheckGiftCardAccess", mock.Anything, userID, giftCardID).Return(perms, nil)
+
+	mockGiftCardService := new(MockGiftCardService)
+	giftCard := &models.GiftCard{ID: giftCardID, UserID: &userID, CurrentBalance: money.New(1000, "CHF"), Currency: "CHF"}
+	mockGiftCardService.On("GetGiftCard", mock.Anything, giftCardID).Return(giftCard, nil)
+	transaction := &models.GiftCardTransaction{
+		ID:              transactionID,
+		GiftCardID:      giftCardID,
+		Kind:            models.TransactionKindPurchase,
+		Amount:          money.New(2500, ""),
+		Description:     "Kopfhörer",
+		TransactionDate: time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC),
+	}
+	mockGiftCardService.On("GetTransaction", mock.Anything, transactionID, giftCardID).Return(transaction, nil)
+
+	handler := &Handler{
+		authzService:    mockAuthz,
+		giftCardService: mockGiftCardService,
+	}
+	return c, rec, mockGiftCardService, handler, giftCardID, userID
+}
+
+func TestTransactionUpdate_Success(t *testing.T) {
+	// 25.00 -> 35.00 uses the remaining 10.00
+	c, rec, mockGiftCardService, handler, giftCardID, userID := newTransactionUpdateContext(t, "35.00")
+	mockGiftCardService.On("UpdateTransaction", mock.Anything, mock.MatchedBy(func(tx *models.GiftCardTransaction) bool {
+		return tx.Amount == money.New(3500, "CHF") &&
+			tx.Description == "Kopfhörer (korrigiert)" &&
+			tx.TransactionDate.Equal(time.Date(2026, 10, 2, 12, 0, 0, 0, time.UTC))
+	}), userID).Return(nil)
+
+	err := handler.TransactionUpdate(c)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "/gift-cards/"+giftCardID.String(), rec.Header().Get("HX-Redirect"))
+	mockGiftCardService.AssertExpectations(t)
+}
+
+func TestTransactionUpdate_InsufficientBalance(t *testing.T) {
+	c, rec, mockGiftCardService, handler, _, _ := newTransactionUpdateContext(t, "35.05")
+
+	err := handler.TransactionUpdate(c)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code) // Returns form with error
+	assert.Contains(t, rec.Body.String(), "Nicht genügend Guthaben")
+	mockGiftCardService.AssertNotCalled(t, "UpdateTransaction", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestTransactionUpdate_NegativeAmount(t *testing.T) {
+	c, rec, mockGiftCardService, handler, _, _ := newTransactionUpdateContext(t, "-5")
+
+	err := handler.TransactionUpdate(c)
+
+	assert.NoError(t, err)
+	assert.Contains(t, rec.Body.String(), "Der Betrag muss positiv sein")
+	mockGiftCardService.AssertNotCalled(t, "UpdateTransaction", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestTransactionUpdate_TriggerRejects(t *testing.T) {
+	c, rec, mockGiftCardService, handler, giftCardID, userID := newTransactionUpdateContext(t, "30.00")
+	mockGiftCardService.On("UpdateTransaction", mock.Anything, mock.Anything, userID).
+		Return(errors.New("ERROR: Insufficient balance: current=500, transaction=purchase 3000"))
+
+	err := handler.TransactionUpdate(c)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, "/gift-cards/"+giftCardID.String()+"?error=insufficient_balance", rec.Header().Get("HX-Redirect"))
+}
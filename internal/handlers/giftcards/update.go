@@ -5,8 +5,8 @@ import (
 	"net/http"
 	"savvy/internal/audit"
 	"savvy/internal/models"
+	"savvy/internal/money"
 	"savvy/internal/validation"
-	"strconv"
 	"time"
 
 	"github.com/google/uuid"
@@ -33,7 +33,15 @@ func (h *Handler) Update(c echo.Context) error {
 		return c.Redirect(http.StatusSeeOther, "/gift-cards")
 	}
 
-	initialBalance, _ := strconv.ParseFloat(c.FormValue("initial_balance"), 64)
+	currency := c.FormValue("currency")
+	if currency == "" {
+		currency = giftCard.Currency
+	}
+	// Stored amounts are minor units of the current currency
+	if currency != giftCard.Currency && len(giftCard.Transactions) > 0 {
+		return c.Redirect(http.StatusSeeOther, "/gift-cards/"+giftCard.ID.String()+"/edit?error=currency_locked")
+	}
+	initialBalance, _ := money.Parse(c.FormValue("initial_balance"), currency)
 
 	var expiresAt *time.Time
 	if expiresAtStr := c.FormValue("expires_at"); expiresAtStr != "" {
@@ -51,8 +59,8 @@ func (h *Handler) Update(c echo.Context) error {
 
 	giftCard.CardNumber = c.FormValue("card_number")
 	giftCard.InitialBalance = initialBalance
-	giftCard.Currency = c.FormValue("currency")
-	giftCard.PIN = c.FormValue("pin")
+	giftCard.Currency = currency
+	giftCard.PIN = updatedPIN(c, giftCard.PIN)
 	giftCard.ExpiresAt = expiresAt
 	giftCard.BarcodeType = c.FormValue("barcode_type")
 	giftCard.Notes = c.FormValue("notes")
@@ -14,6 +14,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"savvy/internal/models"
+	"savvy/internal/money"
 	"savvy/internal/services"
 )
 
@@ -71,7 +72,7 @@ func TestUpdateHandler_Owner(t *testing.T) {
 		ID:             giftCardID,
 		UserID:         &userID,
 		CardNumber:     "GC1234567890",
-		InitialBalance: 100.00,
+		InitialBalance: money.New(10000, "CHF"),
 		Currency:       "CHF",
 		ExpiresAt:      &expiresAt,
 	}
@@ -201,7 +202,7 @@ func TestUpdateHandler_InvalidDate(t *testing.T) {
 		ID:             giftCardID,
 		UserID:         &userID,
 		CardNumber:     "GC123",
-		InitialBalance: 50.00,
+		InitialBalance: money.New(5000, "CHF"),
 		ExpiresAt:      &expiresAt,
 	}
 	mockGiftCardService.On("GetGiftCard", mock.Anything, giftCardID).Return(giftCard, nil)
@@ -223,6 +224,58 @@ func TestUpdateHandler_InvalidDate(t *testing.T) {
 	mockGiftCardService.AssertExpectations(t)
 }
 
+func TestUpdateHandler_CurrencyLocked(t *testing.T) {
+	e := echo.New()
+	userID := uuid.New()
+	giftCardID := uuid.New()
+
+	formData := url.Values{}
+	formData.Set("card_number", "GC123")
+	formData.Set("initial_balance", "5000")
+	formData.Set("currency", "JPY")
+
+	req := httptest.NewRequest(http.MethodPost, "/gift-cards/"+giftCardID.String(), strings.NewReader(formData.Encode()))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues(giftCardID.String())
+	c.Set("current_user", &models.User{ID: userID, Email: "owner@example.com"})
+	setupI18nContext(c)
+
+	mockAuthzService := new(MockAuthzService)
+	mockGiftCardService := new(MockGiftCardService)
+
+	perms := &services.ResourcePermissions{CanView: true, CanEdit: true, IsOwner: true}
+	mockAuthzService.On("CheckGiftCardAccess", mock.Anything, userID, giftCardID).Return(perms, nil)
+
+	giftCard := &models.GiftCard{
+		ID:             giftCardID,
+		UserID:         &userID,
+		CardNumber:     "GC123",
+		InitialBalance: money.New(5000, "CHF"),
+		Currency:       "CHF",
+		Transactions: []models.GiftCardTransaction{
+			{ID: uuid.New(), Kind: models.TransactionKindPurchase, Amount: money.New(1250, "CHF")},
+		},
+	}
+	mockGiftCardService.On("GetGiftCard", mock.Anything, giftCardID).Return(giftCard, nil)
+
+	handler := &Handler{
+		authzService:    mockAuthzService,
+		giftCardService: mockGiftCardService,
+	}
+
+	err := handler.Update(c)
+
+	// The 12.50 CHF transaction would otherwise be read as 1250 JPY
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusSeeOther, rec.Code)
+	assert.Contains(t, rec.Header().Get("Location"), "/gift-cards/"+giftCardID.String()+"/edit?error=currency_locked")
+	assert.Equal(t, "CHF", giftCard.Currency)
+	mockGiftCardService.AssertNotCalled(t, "UpdateGiftCard", mock.Anything, mock.Anything)
+}
+
 func TestUpdateHandler_ServiceError(t *testing.T) {
 	// Setup
 	e := echo.New()
@@ -267,7 +320,7 @@ func TestUpdateHandler_ServiceError(t *testing.T) {
 		ID:             giftCardID,
 		UserID:         &userID,
 		CardNumber:     "GC123",
-		InitialBalance: 50.00,
+		InitialBalance: money.New(5000, "CHF"),
 	}
 	mockGiftCardService.On("GetGiftCard", mock.Anything, giftCardID).Return(giftCard, nil)
 
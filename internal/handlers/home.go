@@ -22,7 +22,7 @@ func HomeIndex(c echo.Context) error {
 	user := c.Get("current_user").(*models.User)
 	isImpersonating := c.Get("is_impersonating") != nil
 
-	data, err := dashboardService.GetDashboardData(c.Request().Context(), user.ID)
+	data, err := dashboardService.GetDashboardData(c.Request().Context(), user.ID, user.DisplayCurrency)
 	if err != nil {
 		c.Logger().Errorf("Failed to load dashboard data: %v", err)
 		return c.String(500, "Internal Server Error")
@@ -35,7 +35,7 @@ func HomeIndex(c echo.Context) error {
 		data.Stats.CardsOwned+data.Stats.CardsShared,
 		data.Stats.VouchersOwned+data.Stats.VouchersShared,
 		data.Stats.GiftCardsOwned+data.Stats.GiftCardsShared,
-		data.Stats.TotalBalance,
+		data.Stats,
 		data.RecentCards,
 		data.RecentVouchers,
 		data.RecentGiftCards,
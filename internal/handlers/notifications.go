@@ -2,25 +2,62 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"savvy/internal/i18n"
 	"savvy/internal/models"
+	"savvy/internal/push"
+	"savvy/internal/realtime"
 	"savvy/internal/services"
 	"savvy/internal/templates"
+	"slices"
 	"strconv"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 )
 
+// notificationSettingsMessages maps the notice query parameter of the
+// settings page to i18n message IDs
+var notificationSettingsMessages = map[string]string{
+	"saved":            "notifications.settings.saved",
+	"reminders_saved":  "notifications.settings.reminders_saved",
+	"calendar_deleted": "notifications.settings.calendar_deleted",
+}
+
+// streamKeepAlive is how often an idle event stream sends a comment, so
+// proxies do not close it
+const streamKeepAlive = 25 * time.Second
+
 // NotificationHandler handles notification operations
 type NotificationHandler struct {
 	notificationService services.NotificationServiceInterface
+	deliveryService     services.NotificationDeliveryServiceInterface
+	events              *realtime.Hub
+	reminderService     services.ExpiryReminderServiceInterface
+	calendarService     services.CalendarFeedServiceInterface
+	baseURL             string
 }
 
 // NewNotificationHandler creates a new notification handler
-func NewNotificationHandler(notificationService services.NotificationServiceInterface) *NotificationHandler {
+func NewNotificationHandler(
+	notificationService services.NotificationServiceInterface,
+	deliveryService services.NotificationDeliveryServiceInterface,
+	events *realtime.Hub,
+	reminderService services.ExpiryReminderServiceInterface,
+	calendarService services.CalendarFeedServiceInterface,
+	baseURL string,
+) *NotificationHandler {
 	return &NotificationHandler{
 		notificationService: notificationService,
+		deliveryService:     deliveryService,
+		events:              events,
+		reminderService:     reminderService,
+		calendarService:     calendarService,
+		baseURL:             baseURL,
 	}
 }
 
@@ -65,6 +102,80 @@ func (h *NotificationHandler) GetUnreadCount(c echo.Context) error {
 	return c.JSON(http.StatusOK, map[string]int64{"count": count})
 }
 
+// Stream pushes the unread count as Server-Sent Events: once on connect and
+// again whenever a notification of the user is created, read or deleted on
+// any replica. New notifications are sent as "notification", all other
+// changes as "count".
+// GET /api/notifications/stream
+func (h *NotificationHandler) Stream(c echo.Context) error {
+	user := c.Get("current_user").(*models.User)
+	ctx := c.Request().Context()
+
+	events, cancel := h.events.Subscribe(user.ID)
+	defer cancel()
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set(echo.HeaderCacheControl, "no-cache")
+	res.Header().Set("X-Accel-Buffering", "no") // Disable proxy buffering (nginx)
+	res.WriteHeader(http.StatusOK)
+
+	if err := h.writeCountEvent(c, realtime.Event{Type: realtime.EventCount}); err != nil {
+		return nil
+	}
+
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-events:
+			if !ok {
+				// Server shutdown, the browser reconnects to another replica
+				return nil
+			}
+			if err := h.writeCountEvent(c, event); err != nil {
+				return nil
+			}
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(res, ": keep-alive\n\n"); err != nil {
+				return nil
+			}
+			res.Flush()
+		}
+	}
+}
+
+// writeCountEvent loads the unread count and writes it as an event
+func (h *NotificationHandler) writeCountEvent(c echo.Context, event realtime.Event) error {
+	user := c.Get("current_user").(*models.User)
+	count, err := h.notificationService.GetUnreadCount(c.Request().Context(), user.ID)
+	if err != nil {
+		c.Logger().Errorf("Failed to count notifications of user %s: %v", user.ID, err)
+		return nil // Keep the stream, the next event retries
+	}
+
+	name := "count"
+	data := map[string]any{"count": count}
+	if event.Type == realtime.EventNotification {
+		name = "notification"
+		data["id"] = event.NotificationID
+	}
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	res := c.Response()
+	if _, err := fmt.Fprintf(res, "event: %s\ndata: %s\n\n", name, payload); err != nil {
+		return err
+	}
+	res.Flush()
+	return nil
+}
+
 // MarkAsRead marks a notification as read
 // POST /notifications/:id/read
 func (h *NotificationHandler) MarkAsRead(c echo.Context) error {
@@ -139,3 +250,186 @@ func (h *NotificationHandler) GetNotificationsDropdown(c echo.Context) error {
 
 	return templates.NotificationsDropdown(c.Request().Context(), notifications, unreadCount).Render(c.Request().Context(), c.Response().Writer)
 }
+
+// ShowSettings displays on which channels the user receives notifications
+// GET /notifications/settings
+func (h *NotificationHandler) ShowSettings(c echo.Context) error {
+	return h.renderSettings(c, templates.NotificationSettingsPageData{
+		Notice: notificationSettingsMessages[c.QueryParam("notice")],
+	})
+}
+
+// UpdateSettings stores the channel checkboxes of each notification type.
+// Unchecked and disabled checkboxes are not submitted, so a channel that is
+// not configured keeps its stored value.
+// POST /notifications/settings
+func (h *NotificationHandler) UpdateSettings(c echo.Context) error {
+	user := c.Get("current_user").(*models.User)
+
+	current, err := h.deliveryService.GetPreferences(c.Request().Context(), user.ID)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Failed to load notification settings")
+	}
+
+	preferences := make([]models.NotificationPreference, 0, len(current))
+	for _, preference := range current {
+		if h.deliveryService.EmailEnabled() {
+			preference.Email = c.FormValue(string(preference.Type)+"_email") != ""
+		}
+		if h.deliveryService.PushPublicKey() != "" {
+			preference.Push = c.FormValue(string(preference.Type)+"_push") != ""
+		}
+		preferences = append(preferences, preference)
+	}
+
+	if err := h.deliveryService.UpdatePreferences(c.Request().Context(), user.ID, preferences); err != nil {
+		c.Logger().Errorf("Failed to save notification settings of user %s: %v", user.ID, err)
+		return h.renderSettings(c, templates.NotificationSettingsPageData{Error: "notifications.settings.error_save"})
+	}
+
+	return c.Redirect(http.StatusSeeOther, "/notifications/settings?notice=saved")
+}
+
+// UpdateReminders stores how many days before expiry the user is reminded
+// of vouchers and gift cards. No checked offset turns the reminders off.
+// POST /notifications/settings/reminders
+func (h *NotificationHandler) UpdateReminders(c echo.Context) error {
+	user := c.Get("current_user").(*models.User)
+
+	form, err := c.FormParams()
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid form")
+	}
+	days := models.ReminderDays{}
+	for _, value := range form["reminder_days"] {
+		offset, err := strconv.Atoi(value)
+		if err != nil || !slices.Contains(models.ExpiryReminderDayOptions, offset) {
+			return h.renderSettings(c, templates.NotificationSettingsPageData{Error: "notifications.settings.error_reminders"})
+		}
+		days = append(days, offset)
+	}
+
+	if err := h.reminderService.UpdateDays(c.Request().Context(), user.ID, days); err != nil {
+		c.Logger().Errorf("Failed to save expiry reminders of user %s: %v", user.ID, err)
+		return h.renderSettings(c, templates.NotificationSettingsPageData{Error: "notifications.settings.error_save"})
+	}
+
+	return c.Redirect(http.StatusSeeOther, "/notifications/settings?notice=reminders_saved")
+}
+
+// RotateCalendarToken creates the calendar feed of the user or replaces its
+// URL and shows the new URL once
+// POST /notifications/settings/calendar
+func (h *NotificationHandler) RotateCalendarToken(c echo.Context) error {
+	user := c.Get("current_user").(*models.User)
+
+	// The URL would outlive the impersonation session
+	if c.Get("is_impersonating") != nil {
+		return h.renderSettings(c, templates.NotificationSettingsPageData{Error: "notifications.settings.error_calendar_impersonating"})
+	}
+
+	token, err := h.calendarService.RotateToken(c.Request().Context(), user.ID, i18n.GetLanguage(c.Request().Context()))
+	if err != nil {
+		c.Logger().Errorf("Failed to rotate calendar feed token of user %s: %v", user.ID, err)
+		return h.renderSettings(c, templates.NotificationSettingsPageData{Error: "notifications.settings.error_calendar"})
+	}
+
+	return h.renderSettings(c, templates.NotificationSettingsPageData{NewCalendarURL: CalendarFeedURL(h.baseURL, token)})
+}
+
+// DeleteCalendarFeed removes the calendar feed of the user, so its URL stops working
+// POST /notifications/settings/calendar/delete
+func (h *NotificationHandler) DeleteCalendarFeed(c echo.Context) error {
+	user := c.Get("current_user").(*models.User)
+
+	if err := h.calendarService.DeleteFeed(c.Request().Context(), user.ID); err != nil {
+		c.Logger().Errorf("Failed to delete calendar feed of user %s: %v", user.ID, err)
+		return h.renderSettings(c, templates.NotificationSettingsPageData{Error: "notifications.settings.error_calendar"})
+	}
+
+	return c.Redirect(http.StatusSeeOther, "/notifications/settings?notice=calendar_deleted")
+}
+
+// pushSubscriptionRequest is the JSON of PushSubscription.toJSON()
+type pushSubscriptionRequest struct {
+	Endpoint string `json:"endpoint"`
+	Keys     struct {
+		P256dh string `json:"p256dh"`
+		Auth   string `json:"auth"`
+	} `json:"keys"`
+}
+
+// Subscribe stores the push subscription of the current browser
+// POST /notifications/push-subscriptions
+func (h *NotificationHandler) Subscribe(c echo.Context) error {
+	user := c.Get("current_user").(*models.User)
+
+	var req pushSubscriptionRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid subscription"})
+	}
+
+	err := h.deliveryService.Subscribe(c.Request().Context(), user.ID, push.Subscription{
+		Endpoint: req.Endpoint,
+		P256dh:   req.Keys.P256dh,
+		Auth:     req.Keys.Auth,
+	}, c.Request().UserAgent())
+	switch {
+	case errors.Is(err, services.ErrPushDisabled):
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Push notifications are not configured"})
+	case errors.Is(err, services.ErrInvalidPushSubscription):
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid subscription"})
+	case err != nil:
+		c.Logger().Errorf("Failed to save push subscription of user %s: %v", user.ID, err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to save subscription"})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// Unsubscribe removes the push subscription of the current browser
+// DELETE /notifications/push-subscriptions
+func (h *NotificationHandler) Unsubscribe(c echo.Context) error {
+	user := c.Get("current_user").(*models.User)
+
+	var req pushSubscriptionRequest
+	if err := c.Bind(&req); err != nil || req.Endpoint == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid subscription"})
+	}
+
+	if err := h.deliveryService.Unsubscribe(c.Request().Context(), user.ID, req.Endpoint); err != nil {
+		c.Logger().Errorf("Failed to delete push subscription of user %s: %v", user.ID, err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to delete subscription"})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// renderSettings loads the preferences and renders the settings page
+func (h *NotificationHandler) renderSettings(c echo.Context, data templates.NotificationSettingsPageData) error {
+	user := c.Get("current_user").(*models.User)
+	isImpersonating := c.Get("is_impersonating") != nil
+	csrfToken, _ := c.Get("csrf").(string)
+
+	preferences, err := h.deliveryService.GetPreferences(c.Request().Context(), user.ID)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Failed to load notification settings")
+	}
+	data.Preferences = preferences
+	if data.ReminderDays, err = h.reminderService.GetDays(c.Request().Context(), user.ID); err != nil {
+		return c.String(http.StatusInternalServerError, "Failed to load notification settings")
+	}
+	if data.CalendarFeed, err = h.calendarService.GetFeed(c.Request().Context(), user.ID); err != nil {
+		return c.String(http.StatusInternalServerError, "Failed to load notification settings")
+	}
+	data.EmailEnabled = h.deliveryService.EmailEnabled()
+	data.PushPublicKey = h.deliveryService.PushPublicKey()
+
+	if data.PushPublicKey != "" {
+		if data.PushSubscriptions, err = h.deliveryService.CountSubscriptions(c.Request().Context(), user.ID); err != nil {
+			c.Logger().Errorf("Failed to count push subscriptions of user %s: %v", user.ID, err)
+		}
+	}
+
+	return templates.NotificationSettingsPage(c.Request().Context(), csrfToken, user, isImpersonating, data).Render(c.Request().Context(), c.Response().Writer)
+}
@@ -12,9 +12,13 @@ import (
 	"savvy/internal/oauth"
 	"savvy/internal/services"
 	"strings"
+	"time"
 
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/gorilla/sessions"
 	"github.com/labstack/echo/v4"
 	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/oauth2"
 )
 
 // OAuthHandler handles OAuth authentication operations.
@@ -122,6 +126,41 @@ func OAuthLogin(c echo.Context) error {
 	return c.Redirect(http.StatusSeeOther, url)
 }
 
+// OAuthReauth sends a logged-in OAuth user back to the provider to confirm
+// their identity before a sensitive action, e.g. revealing a PIN.
+// GET /auth/oauth/reauth?return_to=/gift-cards/:id
+func OAuthReauth(c echo.Context) error {
+	returnTo := c.QueryParam("return_to")
+	// Only local paths, never "//host" or absolute URLs
+	if !strings.HasPrefix(returnTo, "/") || strings.HasPrefix(returnTo, "//") || strings.HasPrefix(returnTo, "/\\") {
+		returnTo = "/"
+	}
+
+	if oauthProvider == nil {
+		return c.Redirect(http.StatusSeeOther, returnTo)
+	}
+
+	state, err := generateRandomString(32)
+	if err != nil {
+		c.Logger().Errorf("Failed to generate state: %v", err)
+		return c.Redirect(http.StatusSeeOther, returnTo)
+	}
+
+	sess, _ := middleware.GetSession(c)
+	sess.Values["oauth_state"] = state
+	sess.Values["oauth_reauth"] = returnTo
+	if err := sess.Save(c.Request(), c.Response()); err != nil {
+		return c.String(http.StatusInternalServerError, "Failed to save session")
+	}
+
+	// Ask the provider for a fresh login instead of reusing its SSO session
+	url := oauthProvider.Config.AuthCodeURL(state,
+		oauth2.SetAuthURLParam("prompt", "login"),
+		oauth2.SetAuthURLParam("max_age", "0"),
+	)
+	return c.Redirect(http.StatusSeeOther, url)
+}
+
 // Callback handles the OAuth callback from Authentik
 func (h *OAuthHandler) Callback(c echo.Context) error {
 	if oauthProvider == nil {
@@ -183,6 +222,10 @@ func (h *OAuthHandler) Callback(c echo.Context) error {
 		return c.Redirect(http.StatusSeeOther, "/auth/login?error=no_email")
 	}
 
+	if returnTo, ok := sess.Values["oauth_reauth"].(string); ok {
+		return h.finishReauth(c, sess, idToken, email, returnTo)
+	}
+
 	firstName := userInfo.FirstName
 	lastName := userInfo.LastName
 	if lastName == "" && firstName != "" && strings.Contains(firstName, " ") {
@@ -214,6 +257,7 @@ func (h *OAuthHandler) Callback(c echo.Context) error {
 			role = roleAdmin
 		}
 
+		now := time.Now()
 		newUser := models.User{
 			Email:        email,
 			PasswordHash: string(hashedPassword),
@@ -221,6 +265,8 @@ func (h *OAuthHandler) Callback(c echo.Context) error {
 			LastName:     lastName,
 			Role:         role,
 			AuthProvider: "oauth",
+			// The configured identity provider vouches for the address
+			EmailVerifiedAt: &now,
 		}
 
 		if err := h.userService.CreateUser(c.Request().Context(), &newUser); err != nil {
@@ -259,6 +305,11 @@ func (h *OAuthHandler) Callback(c echo.Context) error {
 			user.AuthProvider = "oauth"
 			updated = true
 		}
+		if !user.IsEmailVerified() {
+			now := time.Now()
+			user.EmailVerifiedAt = &now
+			updated = true
+		}
 
 		if updated {
 			h.userService.UpdateUser(c.Request().Context(), user)
@@ -285,6 +336,43 @@ func (h *OAuthHandler) Callback(c echo.Context) error {
 	return c.Redirect(http.StatusSeeOther, "/")
 }
 
+// finishReauth completes an OAuthReauth round trip. The provider login must
+// belong to the user of the current session and must be fresh; the session
+// itself is kept.
+func (h *OAuthHandler) finishReauth(c echo.Context, sess *sessions.Session, idToken *oidc.IDToken, email, returnTo string) error {
+	delete(sess.Values, "oauth_reauth")
+
+	// auth_time is when the user actually logged in at the provider
+	authenticatedAt := idToken.IssuedAt
+	var claims struct {
+		AuthTime int64 `json:"auth_time"`
+	}
+	if err := idToken.Claims(&claims); err == nil && claims.AuthTime > 0 {
+		authenticatedAt = time.Unix(claims.AuthTime, 0)
+	}
+
+	userIDStr, _ := sess.Values["user_id"].(string)
+	user, err := h.userService.GetUserByEmail(c.Request().Context(), email)
+	if err != nil || userIDStr == "" || user.ID.String() != userIDStr {
+		c.Logger().Warnf("OAuth re-authentication as %s does not match the session user", email)
+		_ = sess.Save(c.Request(), c.Response())
+		return c.Redirect(http.StatusSeeOther, returnTo)
+	}
+
+	if time.Since(authenticatedAt) > middleware.ReauthWindow {
+		c.Logger().Warnf("OAuth re-authentication for %s is not fresh (%s)", email, authenticatedAt)
+		_ = sess.Save(c.Request(), c.Response())
+		return c.Redirect(http.StatusSeeOther, returnTo)
+	}
+
+	if err := middleware.MarkReauthenticated(c, middleware.ReauthOIDC); err != nil {
+		c.Logger().Errorf("Failed to save re-authentication: %v", err)
+	}
+
+	c.Logger().Printf("OAuth re-authentication successful for user: %s", email)
+	return c.Redirect(http.StatusSeeOther, returnTo)
+}
+
 // generateRandomString generates a random string of the specified length
 func generateRandomString(length int) (string, error) {
 	bytes := make([]byte, length)
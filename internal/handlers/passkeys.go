@@ -0,0 +1,409 @@
+// Package handlers contains HTTP request handlers for the savvy system.
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"savvy/internal/audit"
+	"savvy/internal/config"
+	"savvy/internal/i18n"
+	"savvy/internal/middleware"
+	"savvy/internal/models"
+	"savvy/internal/services"
+
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// passkeyCeremonyKey keeps the challenge of a running passkey ceremony in the session
+const passkeyCeremonyKey = "webauthn_ceremony"
+
+// maxPasskeyResponseSize limits the JSON body of a passkey response
+const maxPasskeyResponseSize = 64 << 10
+
+// Passkey ceremonies. A challenge is only accepted by the step it was created for.
+const (
+	passkeyCeremonyLogin        = "login"
+	passkeyCeremonySecondFactor = "second_factor"
+	passkeyCeremonyRegistration = "registration"
+)
+
+// passkeyCeremony is stored in the session between the begin and finish requests
+type passkeyCeremony struct {
+	Purpose string               `json:"purpose"`
+	Session webauthn.SessionData `json:"session"`
+}
+
+// passkeyRegistration is the body of the request that finishes a registration
+type passkeyRegistration struct {
+	Name       string          `json:"name"`
+	Credential json.RawMessage `json:"credential"`
+}
+
+// PasskeyHandler handles WebAuthn registration on the 2FA settings page and
+// passkey logins, both passwordless and as second step after the password.
+// The begin endpoints return the options for the browser API as JSON, the
+// finish endpoints take its response.
+type PasskeyHandler struct {
+	webAuthnService services.WebAuthnServiceInterface
+	userService     services.UserServiceInterface
+	loginThrottle   services.LoginThrottleServiceInterface
+	db              *gorm.DB
+	cfg             *config.Config
+}
+
+// NewPasskeyHandler creates a new passkey handler
+func NewPasskeyHandler(
+	webAuthnService services.WebAuthnServiceInterface,
+	userService services.UserServiceInterface,
+	loginThrottle services.LoginThrottleServiceInterface,
+	db *gorm.DB,
+	cfg *config.Config,
+) *PasskeyHandler {
+	return &PasskeyHandler{
+		webAuthnService: webAuthnService,
+		userService:     userService,
+		loginThrottle:   loginThrottle,
+		db:              db,
+		cfg:             cfg,
+	}
+}
+
+// LoginBegin starts a passwordless login
+// POST /auth/passkey/login/begin
+func (h *PasskeyHandler) LoginBegin(c echo.Context) error {
+	if !h.webAuthnService.Enabled() {
+		return passkeyError(c, http.StatusNotFound, "auth.passkey.unavailable")
+	}
+
+	assertion, session, err := h.webAuthnService.BeginLogin(c.Request().Context())
+	if err != nil {
+		c.Logger().Errorf("Failed to start passkey login: %v", err)
+		return passkeyError(c, http.StatusInternalServerError, "auth.passkey.error")
+	}
+	if err := savePasskeyCeremony(c, passkeyCeremonyLogin, session); err != nil {
+		c.Logger().Errorf("Failed to save session: %v", err)
+		return passkeyError(c, http.StatusInternalServerError, "auth.passkey.error")
+	}
+	return c.JSON(http.StatusOK, assertion)
+}
+
+// LoginFinish logs the owner of the passkey in. A passkey with user
+// verification replaces both the password and the second factor.
+// POST /auth/passkey/login/finish
+func (h *PasskeyHandler) LoginFinish(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	session, ok := takePasskeyCeremony(c, passkeyCeremonyLogin)
+	if !ok {
+		return passkeyError(c, http.StatusBadRequest, "auth.passkey.expired")
+	}
+	response, err := readPasskeyResponse(c)
+	if err != nil {
+		return passkeyError(c, http.StatusBadRequest, "auth.passkey.failed")
+	}
+
+	user, err := h.webAuthnService.FinishLogin(ctx, *session, response)
+	if err != nil {
+		return h.loginFailed(c, err)
+	}
+
+	if err := startUserSession(c, user); err != nil {
+		c.Logger().Errorf("Failed to save session: %v", err)
+		return passkeyError(c, http.StatusInternalServerError, "auth.passkey.error")
+	}
+	return c.JSON(http.StatusOK, map[string]string{"redirect": "/"})
+}
+
+// SecondFactorBegin starts the passkey check after the password was accepted
+// POST /auth/login/two-factor/passkey/begin
+func (h *PasskeyHandler) SecondFactorBegin(c echo.Context) error {
+	user, ok := h.pendingUser(c)
+	if !ok {
+		return passkeyError(c, http.StatusUnauthorized, "auth.passkey.expired")
+	}
+
+	assertion, session, err := h.webAuthnService.BeginSecondFactor(c.Request().Context(), user)
+	if err != nil {
+		if errors.Is(err, services.ErrPasskeyNotFound) || errors.Is(err, services.ErrWebAuthnDisabled) {
+			return passkeyError(c, http.StatusNotFound, "auth.passkey.unavailable")
+		}
+		c.Logger().Errorf("Failed to start passkey check for user %s: %v", user.ID, err)
+		return passkeyError(c, http.StatusInternalServerError, "auth.passkey.error")
+	}
+	if err := savePasskeyCeremony(c, passkeyCeremonySecondFactor, session); err != nil {
+		c.Logger().Errorf("Failed to save session: %v", err)
+		return passkeyError(c, http.StatusInternalServerError, "auth.passkey.error")
+	}
+	return c.JSON(http.StatusOK, assertion)
+}
+
+// SecondFactorFinish completes the login with the passkey. Failed checks
+// count towards the attempts of the second login step.
+// POST /auth/login/two-factor/passkey/finish
+func (h *PasskeyHandler) SecondFactorFinish(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user, ok := h.pendingUser(c)
+	if !ok {
+		return passkeyError(c, http.StatusUnauthorized, "auth.passkey.expired")
+	}
+	session, ok := takePasskeyCeremony(c, passkeyCeremonySecondFactor)
+	if !ok {
+		return passkeyError(c, http.StatusBadRequest, "auth.passkey.expired")
+	}
+	response, err := readPasskeyResponse(c)
+	if err != nil {
+		return passkeyError(c, http.StatusBadRequest, "auth.passkey.failed")
+	}
+
+	if err := h.webAuthnService.FinishSecondFactor(ctx, user, *session, response); err != nil {
+		if errors.Is(err, services.ErrInvalidPasskey) || errors.Is(err, services.ErrPasskeyCloned) {
+			remaining, recordErr := middleware.RecordFailedTwoFactor(c)
+			if recordErr != nil || remaining <= 0 {
+				return c.JSON(http.StatusUnauthorized, map[string]string{"redirect": "/auth/login"})
+			}
+		}
+		return h.loginFailed(c, err)
+	}
+
+	// Password and passkey were both right, the failures of the address no longer count
+	if err := h.loginThrottle.RecordSuccess(ctx, user.Email); err != nil {
+		c.Logger().Errorf("Failed to reset failed logins of %s: %v", user.Email, err)
+	}
+
+	if err := startUserSession(c, user); err != nil {
+		c.Logger().Errorf("Failed to save session: %v", err)
+		return passkeyError(c, http.StatusInternalServerError, "auth.passkey.error")
+	}
+	return c.JSON(http.StatusOK, map[string]string{"redirect": "/"})
+}
+
+// RegisterBegin starts the registration of a passkey for the current user
+// POST /account/two-factor/passkeys/begin
+func (h *PasskeyHandler) RegisterBegin(c echo.Context) error {
+	user := c.Get("current_user").(*models.User)
+	if msg := twoFactorUnavailable(c, user); msg != "" {
+		return passkeyError(c, http.StatusForbidden, msg)
+	}
+
+	creation, session, err := h.webAuthnService.BeginRegistration(c.Request().Context(), user)
+	if err != nil {
+		if errors.Is(err, services.ErrWebAuthnDisabled) {
+			return passkeyError(c, http.StatusNotFound, "auth.passkey.unavailable")
+		}
+		c.Logger().Errorf("Failed to start passkey registration for user %s: %v", user.ID, err)
+		return passkeyError(c, http.StatusInternalServerError, "account.passkeys.error_register")
+	}
+	if err := savePasskeyCeremony(c, passkeyCeremonyRegistration, session); err != nil {
+		c.Logger().Errorf("Failed to save session: %v", err)
+		return passkeyError(c, http.StatusInternalServerError, "account.passkeys.error_register")
+	}
+	return c.JSON(http.StatusOK, creation)
+}
+
+// RegisterFinish stores the new passkey under the name the user entered
+// POST /account/two-factor/passkeys/finish
+func (h *PasskeyHandler) RegisterFinish(c echo.Context) error {
+	user := c.Get("current_user").(*models.User)
+	if msg := twoFactorUnavailable(c, user); msg != "" {
+		return passkeyError(c, http.StatusForbidden, msg)
+	}
+
+	session, ok := takePasskeyCeremony(c, passkeyCeremonyRegistration)
+	if !ok {
+		return passkeyError(c, http.StatusBadRequest, "auth.passkey.expired")
+	}
+	body, err := readPasskeyResponse(c)
+	if err != nil {
+		return passkeyError(c, http.StatusBadRequest, "account.passkeys.error_register")
+	}
+	var registration passkeyRegistration
+	if err := json.Unmarshal(body, &registration); err != nil {
+		return passkeyError(c, http.StatusBadRequest, "account.passkeys.error_register")
+	}
+
+	credential, err := h.webAuthnService.FinishRegistration(c.Request().Context(), user, *session, registration.Name, registration.Credential)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidPasskeyName):
+			return passkeyError(c, http.StatusBadRequest, "account.passkeys.error_name")
+		case errors.Is(err, services.ErrInvalidPasskey):
+			c.Logger().Warnf("Passkey registration of user %s failed: %v", user.ID, err)
+			return passkeyError(c, http.StatusBadRequest, "account.passkeys.error_register")
+		default:
+			c.Logger().Errorf("Failed to store passkey of user %s: %v", user.ID, err)
+			return passkeyError(c, http.StatusInternalServerError, "account.passkeys.error_register")
+		}
+	}
+	if err := audit.LogPasskeyFromContext(c, h.db, models.AuditActionAddPasskey, credential.Name); err != nil {
+		c.Logger().Errorf("Failed to log passkey registration of user %s: %v", user.ID, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"redirect": "/account/two-factor?notice=passkey_added"})
+}
+
+// Rename changes the name of a passkey
+// POST /account/two-factor/passkeys/:id
+func (h *PasskeyHandler) Rename(c echo.Context) error {
+	user := c.Get("current_user").(*models.User)
+	if msg := twoFactorUnavailable(c, user); msg != "" {
+		return c.String(http.StatusForbidden, "Not allowed")
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid passkey ID")
+	}
+
+	if err := h.webAuthnService.RenameCredential(c.Request().Context(), id, user.ID, c.FormValue("name")); err != nil {
+		switch {
+		case errors.Is(err, services.ErrPasskeyNotFound):
+			return c.String(http.StatusNotFound, "Passkey not found")
+		case errors.Is(err, services.ErrInvalidPasskeyName):
+			return c.Redirect(http.StatusSeeOther, "/account/two-factor?error=passkey_name")
+		default:
+			return c.String(http.StatusInternalServerError, "Failed to rename passkey")
+		}
+	}
+	return c.Redirect(http.StatusSeeOther, "/account/two-factor")
+}
+
+// Delete removes a passkey. Admins who must use 2FA cannot remove their
+// last second factor.
+// DELETE /account/two-factor/passkeys/:id
+func (h *PasskeyHandler) Delete(c echo.Context) error {
+	ctx := c.Request().Context()
+	user := c.Get("current_user").(*models.User)
+	if msg := twoFactorUnavailable(c, user); msg != "" {
+		return c.String(http.StatusForbidden, "Not allowed")
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid passkey ID")
+	}
+
+	credentials, err := h.webAuthnService.ListCredentials(ctx, user.ID)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Failed to load passkeys")
+	}
+	var name string
+	for _, credential := range credentials {
+		if credential.ID == id {
+			name = credential.Name
+		}
+	}
+	if name == "" {
+		return c.String(http.StatusNotFound, "Passkey not found")
+	}
+	if h.cfg.RequireAdmin2FA && user.IsAdmin() && !user.HasTwoFactor() && len(credentials) == 1 {
+		return c.String(http.StatusConflict, i18n.T(ctx, "account.two_factor.error_required"))
+	}
+
+	if err := h.webAuthnService.DeleteCredential(ctx, id, user.ID); err != nil {
+		if errors.Is(err, services.ErrPasskeyNotFound) {
+			return c.String(http.StatusNotFound, "Passkey not found")
+		}
+		return c.String(http.StatusInternalServerError, "Failed to remove passkey")
+	}
+	if err := audit.LogPasskeyFromContext(c, h.db, models.AuditActionRemovePasskey, name); err != nil {
+		c.Logger().Errorf("Failed to log passkey removal of user %s: %v", user.ID, err)
+	}
+
+	// Reload so the status and the admin requirement reflect the change
+	c.Response().Header().Set("HX-Redirect", "/account/two-factor")
+	return c.NoContent(http.StatusOK)
+}
+
+// pendingUser returns the user whose password was accepted in this session
+func (h *PasskeyHandler) pendingUser(c echo.Context) (*models.User, bool) {
+	userID, ok := middleware.PendingTwoFactor(c)
+	if !ok {
+		return nil, false
+	}
+	user, err := h.userService.GetUserByID(c.Request().Context(), userID)
+	if err != nil {
+		return nil, false
+	}
+	return user, true
+}
+
+// loginFailed answers a failed passkey login. A cloned authenticator is
+// logged, the user only learns that the passkey was not accepted.
+func (h *PasskeyHandler) loginFailed(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, services.ErrPasskeyCloned):
+		c.Logger().Warnf("Rejected passkey login, the signature counter did not increase: %v", err)
+	case errors.Is(err, services.ErrPasskeysUnavailable):
+		return passkeyError(c, http.StatusForbidden, "auth.passkey.oauth_account")
+	case errors.Is(err, services.ErrInvalidPasskey):
+		c.Logger().Warnf("Passkey login failed: %v", err)
+	default:
+		c.Logger().Errorf("Failed to check passkey: %v", err)
+		return passkeyError(c, http.StatusInternalServerError, "auth.passkey.error")
+	}
+	return passkeyError(c, http.StatusUnauthorized, "auth.passkey.failed")
+}
+
+// startUserSession logs the user in with a fresh session ID, which also
+// drops the state of the login steps
+func startUserSession(c echo.Context, user *models.User) error {
+	newSession, err := middleware.RegenerateSession(c)
+	if err != nil {
+		return err
+	}
+	newSession.Values["user_id"] = user.ID.String()
+	return newSession.Save(c.Request(), c.Response())
+}
+
+// savePasskeyCeremony stores the challenge until the browser answers
+func savePasskeyCeremony(c echo.Context, purpose string, data *webauthn.SessionData) error {
+	session, err := middleware.GetSession(c)
+	if err != nil {
+		return err
+	}
+	encoded, err := json.Marshal(passkeyCeremony{Purpose: purpose, Session: *data})
+	if err != nil {
+		return err
+	}
+	session.Values[passkeyCeremonyKey] = string(encoded)
+	return session.Save(c.Request(), c.Response())
+}
+
+// takePasskeyCeremony removes the stored challenge, so each one is answered
+// at most once, and returns it if it belongs to the expected step
+func takePasskeyCeremony(c echo.Context, purpose string) (*webauthn.SessionData, bool) {
+	session, err := middleware.GetSession(c)
+	if err != nil {
+		return nil, false
+	}
+	encoded, _ := session.Values[passkeyCeremonyKey].(string)
+	if encoded == "" {
+		return nil, false
+	}
+	delete(session.Values, passkeyCeremonyKey)
+	if err := session.Save(c.Request(), c.Response()); err != nil {
+		c.Logger().Errorf("Failed to save session: %v", err)
+		return nil, false
+	}
+
+	var ceremony passkeyCeremony
+	if err := json.Unmarshal([]byte(encoded), &ceremony); err != nil || ceremony.Purpose != purpose {
+		return nil, false
+	}
+	return &ceremony.Session, true
+}
+
+func readPasskeyResponse(c echo.Context) ([]byte, error) {
+	return io.ReadAll(io.LimitReader(c.Request().Body, maxPasskeyResponseSize))
+}
+
+// passkeyError answers a passkey request with a translated message for the page
+func passkeyError(c echo.Context, status int, messageID string) error {
+	return c.JSON(status, map[string]string{"error": i18n.T(c.Request().Context(), messageID)})
+}
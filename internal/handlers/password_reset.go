@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"savvy/internal/services"
+	"savvy/internal/templates"
+	"savvy/internal/validation"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// resetRequestTimeout bounds the background delivery of a reset link
+const resetRequestTimeout = time.Minute
+
+// forgotPasswordErrors maps the error query parameter to the message shown
+// above the form, e.g. after opening an outdated reset link
+var forgotPasswordErrors = map[string]string{
+	"invalid": "auth.password_reset.invalid",
+	"expired": "auth.password_reset.expired",
+}
+
+// ForgotPasswordGet shows the form that requests a reset link
+// GET /auth/forgot-password
+func (h *AuthHandler) ForgotPasswordGet(c echo.Context) error {
+	if !h.accountEmailService.Enabled() {
+		return echo.NewHTTPError(http.StatusNotFound, "Password reset is not available")
+	}
+
+	csrfToken, _ := c.Get("csrf").(string)
+	ctx := c.Request().Context()
+	errorMsg := forgotPasswordErrors[c.QueryParam("error")]
+	return templates.ForgotPassword(ctx, csrfToken, false, errorMsg).Render(ctx, c.Response().Writer)
+}
+
+// ForgotPasswordPost sends a reset link. The response is the same whether or
+// not an account exists, and the mail is sent in the background so the
+// response time does not reveal it either.
+// POST /auth/forgot-password
+func (h *AuthHandler) ForgotPasswordPost(c echo.Context) error {
+	if !h.accountEmailService.Enabled() {
+		return echo.NewHTTPError(http.StatusNotFound, "Password reset is not available")
+	}
+
+	ctx := c.Request().Context()
+	email := strings.ToLower(strings.TrimSpace(c.FormValue("email")))
+	if email != "" {
+		// Keeps the language of the request for the mail
+		sendCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), resetRequestTimeout)
+		logger := c.Logger()
+		go func() {
+			defer cancel()
+			if err := h.accountEmailService.RequestPasswordReset(sendCtx, email); err != nil {
+				logger.Errorf("Failed to send password reset email: %v", err)
+			}
+		}()
+	}
+
+	csrfToken, _ := c.Get("csrf").(string)
+	return templates.ForgotPassword(ctx, csrfToken, true, "").Render(ctx, c.Response().Writer)
+}
+
+// ResetPasswordGet shows the form for a new password if the link is valid
+// GET /auth/reset-password?token=...
+func (h *AuthHandler) ResetPasswordGet(c echo.Context) error {
+	ctx := c.Request().Context()
+	token := c.QueryParam("token")
+
+	if _, err := h.accountEmailService.CheckPasswordReset(ctx, token); err != nil {
+		return h.redirectInvalidResetLink(c, err)
+	}
+	csrfToken, _ := c.Get("csrf").(string)
+	return templates.ResetPassword(ctx, csrfToken, token, "").Render(ctx, c.Response().Writer)
+}
+
+// ResetPasswordPost sets the new password and logs out all devices
+// POST /auth/reset-password
+func (h *AuthHandler) ResetPasswordPost(c echo.Context) error {
+	ctx := c.Request().Context()
+	token := c.FormValue("token")
+	password := c.FormValue("password")
+
+	renderError := func(msg string) error {
+		csrfToken, _ := c.Get("csrf").(string)
+		return templates.ResetPassword(ctx, csrfToken, token, msg).Render(ctx, c.Response().Writer)
+	}
+	if password != c.FormValue("password_confirm") {
+		return renderError("auth.password_reset.mismatch")
+	}
+	if err := validation.ValidatePassword(password); err != nil {
+		return renderError("auth.password_reset.weak")
+	}
+
+	user, err := h.accountEmailService.ResetPassword(ctx, token, password)
+	if err != nil {
+		return h.redirectInvalidResetLink(c, err)
+	}
+
+	c.Logger().Printf("Password of user %s was reset, all sessions logged out", user.Email)
+
+	// Proving access to the mailbox lifts a lockout of the address
+	if err := h.loginThrottle.RecordSuccess(ctx, user.Email); err != nil {
+		c.Logger().Errorf("Failed to reset failed logins of %s: %v", user.Email, err)
+	}
+	return c.Redirect(http.StatusSeeOther, "/auth/login?notice=password_reset")
+}
+
+// redirectInvalidResetLink sends the user back to request a new link
+func (h *AuthHandler) redirectInvalidResetLink(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, services.ErrEmailLinkExpired):
+		return c.Redirect(http.StatusSeeOther, "/auth/forgot-password?error=expired")
+	case errors.Is(err, services.ErrInvalidEmailLink):
+		return c.Redirect(http.StatusSeeOther, "/auth/forgot-password?error=invalid")
+	default:
+		c.Logger().Errorf("Failed to reset password: %v", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to reset password")
+	}
+}
+
+// VerifyEmail confirms the address of the link's user. It works without a
+// session, the link may be opened on another device.
+// GET /auth/verify-email?token=...
+func (h *AuthHandler) VerifyEmail(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	_, err := h.accountEmailService.VerifyEmail(ctx, c.QueryParam("token"))
+	switch {
+	case err == nil:
+		return templates.VerifyEmailResult(ctx, true, "auth.verify_email.done").Render(ctx, c.Response().Writer)
+	case errors.Is(err, services.ErrEmailLinkExpired):
+		return templates.VerifyEmailResult(ctx, false, "auth.verify_email.expired").Render(ctx, c.Response().Writer)
+	case errors.Is(err, services.ErrInvalidEmailLink):
+		return templates.VerifyEmailResult(ctx, false, "auth.verify_email.invalid").Render(ctx, c.Response().Writer)
+	default:
+		c.Logger().Errorf("Failed to verify email: %v", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to verify email")
+	}
+}
@@ -3,6 +3,7 @@
 package shares
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 
@@ -80,10 +81,12 @@ func (h *BaseShareHandler) Create(c echo.Context) error {
 	if err := h.adapter.CreateShare(c.Request().Context(), req); err != nil {
 		// Translate error message based on error type
 		var msgKey string
-		switch err.Error() {
-		case "user not found":
+		switch {
+		case errors.Is(err, services.ErrShareTargetUnverified):
+			msgKey = "error.share_target_unverified"
+		case err.Error() == "user not found":
 			msgKey = "error.user_not_found"
-		case "already shared with this user":
+		case err.Error() == "already shared with this user":
 			msgKey = "error.already_shared"
 		default:
 			msgKey = "error.server_error"
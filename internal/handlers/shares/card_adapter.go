@@ -89,6 +89,11 @@ func (a *CardShareAdapter) CreateShare(ctx context.Context, req CreateShareReque
 		return err
 	}
 
+	// Only users with a confirmed address can be share targets
+	if !sharedUser.IsEmailVerified() {
+		return services.ErrShareTargetUnverified
+	}
+
 	// Check if already shared
 	var existingShare models.CardShare
 	if err := a.db.WithContext(ctx).Where("card_id = ? AND shared_with_id = ?",
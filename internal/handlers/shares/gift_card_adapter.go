@@ -91,6 +91,11 @@ func (a *GiftCardShareAdapter) CreateShare(ctx context.Context, req CreateShareR
 		return err
 	}
 
+	// Only users with a confirmed address can be share targets
+	if !sharedUser.IsEmailVerified() {
+		return services.ErrShareTargetUnverified
+	}
+
 	// Check if already shared
 	var existingShare models.GiftCardShare
 	if err := a.db.WithContext(ctx).Where("gift_card_id = ? AND shared_with_id = ?",
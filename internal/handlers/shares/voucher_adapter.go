@@ -91,6 +91,11 @@ func (a *VoucherShareAdapter) CreateShare(ctx context.Context, req CreateShareRe
 		return err
 	}
 
+	// Only users with a confirmed address can be share targets
+	if !sharedUser.IsEmailVerified() {
+		return services.ErrShareTargetUnverified
+	}
+
 	// Check if already shared
 	var existingShare models.VoucherShare
 	if err := a.db.WithContext(ctx).Where("voucher_id = ? AND shared_with_id = ?",
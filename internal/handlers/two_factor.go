@@ -0,0 +1,394 @@
+// Package handlers contains HTTP request handlers for the savvy system.
+package handlers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"image/png"
+	"net/http"
+	"savvy/internal/audit"
+	"savvy/internal/config"
+	"savvy/internal/middleware"
+	"savvy/internal/models"
+	"savvy/internal/services"
+	"savvy/internal/templates"
+	"savvy/internal/totp"
+
+	"github.com/boombuler/barcode"
+	"github.com/boombuler/barcode/qr"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// totpIssuer is shown as account name prefix in authenticator apps
+const totpIssuer = "Savvy"
+
+// totpQRSize is the edge length of the enrollment QR code in pixels
+const totpQRSize = 240
+
+// pendingTOTPSecretKey keeps the secret of an unfinished enrollment in the session
+const pendingTOTPSecretKey = "totp_pending_secret"
+
+// twoFactorPageMessages maps the notice and error query parameters set by
+// redirects to the 2FA page to i18n message IDs
+var twoFactorPageMessages = map[string]string{
+	"passkey_added": "account.passkeys.added",
+	"passkey_name":  "account.passkeys.error_name",
+}
+
+// TwoFactorHandler handles TOTP enrollment on the account page and the
+// second step of the local login
+type TwoFactorHandler struct {
+	twoFactorService services.TwoFactorServiceInterface
+	webAuthnService  services.WebAuthnServiceInterface
+	userService      services.UserServiceInterface
+	loginThrottle    services.LoginThrottleServiceInterface
+	db               *gorm.DB
+	cfg              *config.Config
+}
+
+// NewTwoFactorHandler creates a new two-factor handler
+func NewTwoFactorHandler(
+	twoFactorService services.TwoFactorServiceInterface,
+	webAuthnService services.WebAuthnServiceInterface,
+	userService services.UserServiceInterface,
+	loginThrottle services.LoginThrottleServiceInterface,
+	db *gorm.DB,
+	cfg *config.Config,
+) *TwoFactorHandler {
+	return &TwoFactorHandler{
+		twoFactorService: twoFactorService,
+		webAuthnService:  webAuthnService,
+		userService:      userService,
+		loginThrottle:    loginThrottle,
+		db:               db,
+		cfg:              cfg,
+	}
+}
+
+// LoginGet shows the code form and the passkey button after the password
+// was accepted
+// GET /auth/login/two-factor
+func (h *TwoFactorHandler) LoginGet(c echo.Context) error {
+	userID, ok := middleware.PendingTwoFactor(c)
+	if !ok {
+		return c.Redirect(http.StatusSeeOther, "/auth/login")
+	}
+	user, err := h.userService.GetUserByID(c.Request().Context(), userID)
+	if err != nil {
+		return c.Redirect(http.StatusSeeOther, "/auth/login")
+	}
+	return h.renderLogin(c, user, "")
+}
+
+// LoginPost checks the TOTP or recovery code and logs the user in
+// POST /auth/login/two-factor
+func (h *TwoFactorHandler) LoginPost(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	userID, ok := middleware.PendingTwoFactor(c)
+	if !ok {
+		return c.Redirect(http.StatusSeeOther, "/auth/login")
+	}
+
+	user, err := h.userService.GetUserByID(ctx, userID)
+	if err != nil || !user.HasTwoFactor() {
+		return c.Redirect(http.StatusSeeOther, "/auth/login")
+	}
+
+	// Wrong codes count towards the lockout of the address, so an attacker
+	// who knows the password cannot guess codes across many sessions
+	if target := loginThrottleRedirect(c, h.loginThrottle, user.Email); target != "" {
+		return c.Redirect(http.StatusSeeOther, target)
+	}
+
+	usedRecovery, err := h.twoFactorService.Verify(ctx, user, c.FormValue("code"))
+	if err != nil {
+		if !errors.Is(err, services.ErrInvalidTwoFactorCode) {
+			c.Logger().Errorf("Failed to verify 2FA code for user %s: %v", user.ID, err)
+			return h.renderLogin(c, user, "auth.two_factor.error")
+		}
+
+		c.Logger().Warnf("Wrong 2FA code for %s", user.Email)
+		if err := h.loginThrottle.RecordFailure(ctx, user.Email, c.RealIP(), services.LoginStepTwoFactor); err != nil {
+			c.Logger().Errorf("Failed to record failed login: %v", err)
+		}
+		remaining, err := middleware.RecordFailedTwoFactor(c)
+		if err != nil || remaining <= 0 {
+			return c.Redirect(http.StatusSeeOther, "/auth/login")
+		}
+		return h.renderLogin(c, user, "auth.two_factor.invalid_code")
+	}
+
+	if usedRecovery {
+		c.Logger().Printf("User %s logged in with a recovery code", user.Email)
+	}
+	if err := h.loginThrottle.RecordSuccess(ctx, user.Email); err != nil {
+		c.Logger().Errorf("Failed to reset failed logins of %s: %v", user.Email, err)
+	}
+
+	// Only now the session becomes authenticated, with a fresh session ID
+	if err := startUserSession(c, user); err != nil {
+		c.Logger().Errorf("Failed to save session: %v", err)
+		return c.Redirect(http.StatusSeeOther, "/auth/login?error=session_error")
+	}
+
+	return c.Redirect(http.StatusSeeOther, "/")
+}
+
+// Show displays the 2FA status, or the QR code to enroll, and the passkeys
+// GET /account/two-factor
+func (h *TwoFactorHandler) Show(c echo.Context) error {
+	return h.render(c, templates.TwoFactorPageData{
+		Notice: twoFactorPageMessages[c.QueryParam("notice")],
+		Error:  twoFactorPageMessages[c.QueryParam("error")],
+	})
+}
+
+// Enable confirms the enrollment with a code from the authenticator app and
+// shows the recovery codes once
+// POST /account/two-factor
+func (h *TwoFactorHandler) Enable(c echo.Context) error {
+	user := c.Get("current_user").(*models.User)
+	if msg := twoFactorUnavailable(c, user); msg != "" {
+		return h.render(c, templates.TwoFactorPageData{Error: msg})
+	}
+
+	session, err := middleware.GetSession(c)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Failed to load session")
+	}
+	secret, _ := session.Values[pendingTOTPSecretKey].(string)
+	if secret == "" {
+		return c.Redirect(http.StatusSeeOther, "/account/two-factor")
+	}
+
+	codes, err := h.twoFactorService.Enable(c.Request().Context(), user.ID, secret, c.FormValue("code"))
+	if err != nil {
+		msg := "account.two_factor.error_enable"
+		if errors.Is(err, services.ErrInvalidTwoFactorCode) {
+			msg = "account.two_factor.error_code"
+		}
+		return h.render(c, templates.TwoFactorPageData{Error: msg})
+	}
+
+	delete(session.Values, pendingTOTPSecretKey)
+	if err := session.Save(c.Request(), c.Response()); err != nil {
+		c.Logger().Errorf("Failed to save session: %v", err)
+	}
+	if err := audit.LogTwoFactorFromContext(c, h.db, models.AuditActionEnableTwoFactor); err != nil {
+		c.Logger().Errorf("Failed to log 2FA enrollment of user %s: %v", user.ID, err)
+	}
+
+	h.reloadUser(c, user)
+	return h.render(c, templates.TwoFactorPageData{RecoveryCodes: codes, Notice: "account.two_factor.enabled"})
+}
+
+// Disable removes 2FA after checking a current code
+// POST /account/two-factor/disable
+func (h *TwoFactorHandler) Disable(c echo.Context) error {
+	user := c.Get("current_user").(*models.User)
+	if msg := twoFactorUnavailable(c, user); msg != "" {
+		return h.render(c, templates.TwoFactorPageData{Error: msg})
+	}
+
+	// Admins who must use 2FA can only switch to passkeys
+	if h.cfg.RequireAdmin2FA && user.IsAdmin() {
+		if !h.webAuthnService.Enabled() {
+			return h.render(c, templates.TwoFactorPageData{Error: "account.two_factor.error_required"})
+		}
+		passkeys, err := h.webAuthnService.CountCredentials(c.Request().Context(), user.ID)
+		if err != nil || passkeys == 0 {
+			return h.render(c, templates.TwoFactorPageData{Error: "account.two_factor.error_required"})
+		}
+	}
+
+	if msg := h.verifyCode(c, user); msg != "" {
+		return h.render(c, templates.TwoFactorPageData{Error: msg})
+	}
+
+	if err := h.twoFactorService.Disable(c.Request().Context(), user.ID); err != nil {
+		c.Logger().Errorf("Failed to disable 2FA for user %s: %v", user.ID, err)
+		return h.render(c, templates.TwoFactorPageData{Error: "account.two_factor.error_disable"})
+	}
+	if err := audit.LogTwoFactorFromContext(c, h.db, models.AuditActionDisableTwoFactor); err != nil {
+		c.Logger().Errorf("Failed to log 2FA removal of user %s: %v", user.ID, err)
+	}
+
+	h.reloadUser(c, user)
+	return h.render(c, templates.TwoFactorPageData{Notice: "account.two_factor.disabled"})
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes after checking a current code
+// POST /account/two-factor/recovery-codes
+func (h *TwoFactorHandler) RegenerateRecoveryCodes(c echo.Context) error {
+	user := c.Get("current_user").(*models.User)
+	if msg := twoFactorUnavailable(c, user); msg != "" {
+		return h.render(c, templates.TwoFactorPageData{Error: msg})
+	}
+
+	if msg := h.verifyCode(c, user); msg != "" {
+		return h.render(c, templates.TwoFactorPageData{Error: msg})
+	}
+
+	codes, err := h.twoFactorService.RegenerateRecoveryCodes(c.Request().Context(), user.ID)
+	if err != nil {
+		c.Logger().Errorf("Failed to regenerate recovery codes for user %s: %v", user.ID, err)
+		return h.render(c, templates.TwoFactorPageData{Error: "account.two_factor.error_recovery"})
+	}
+	if err := audit.LogTwoFactorFromContext(c, h.db, models.AuditActionRegenerateRecovery); err != nil {
+		c.Logger().Errorf("Failed to log new recovery codes of user %s: %v", user.ID, err)
+	}
+
+	return h.render(c, templates.TwoFactorPageData{RecoveryCodes: codes, Notice: "account.two_factor.recovery_regenerated"})
+}
+
+// verifyCode checks the code of a form that changes 2FA and returns an i18n
+// message ID if it is wrong
+func (h *TwoFactorHandler) verifyCode(c echo.Context, user *models.User) string {
+	if !user.HasTwoFactor() {
+		return "account.two_factor.error_not_enabled"
+	}
+	if _, err := h.twoFactorService.Verify(c.Request().Context(), user, c.FormValue("code")); err != nil {
+		if errors.Is(err, services.ErrInvalidTwoFactorCode) {
+			return "account.two_factor.error_code"
+		}
+		c.Logger().Errorf("Failed to verify 2FA code for user %s: %v", user.ID, err)
+		return "account.two_factor.error_verify"
+	}
+	return ""
+}
+
+// reloadUser refreshes the current user after the 2FA columns changed
+func (h *TwoFactorHandler) reloadUser(c echo.Context, user *models.User) {
+	updated, err := h.userService.GetUserByID(c.Request().Context(), user.ID)
+	if err != nil {
+		c.Logger().Errorf("Failed to reload user %s: %v", user.ID, err)
+		return
+	}
+	*user = *updated
+}
+
+// twoFactorUnavailable returns an i18n message ID if the user cannot change
+// 2FA: OIDC accounts use the provider's second factor, and admins must not
+// change it for an impersonated user
+func twoFactorUnavailable(c echo.Context, user *models.User) string {
+	if c.Get("is_impersonating") != nil {
+		return "account.two_factor.error_impersonating"
+	}
+	if user.IsOAuthUser() {
+		return "account.two_factor.oauth_hint"
+	}
+	return ""
+}
+
+// render renders the 2FA page. Without 2FA it creates (or reuses) the
+// secret of the pending enrollment and its QR code.
+func (h *TwoFactorHandler) render(c echo.Context, data templates.TwoFactorPageData) error {
+	user := c.Get("current_user").(*models.User)
+	isImpersonating := c.Get("is_impersonating") != nil
+	ctx := c.Request().Context()
+	csrfToken, ok := c.Get("csrf").(string)
+	if !ok {
+		csrfToken = ""
+	}
+
+	data.Required = h.cfg.RequireAdmin2FA && user.IsAdmin()
+	data.Unavailable = twoFactorUnavailable(c, user)
+	data.PasskeysEnabled = h.webAuthnService.Enabled()
+
+	if data.Unavailable == "" {
+		passkeys, err := h.webAuthnService.ListCredentials(ctx, user.ID)
+		if err != nil {
+			return c.String(http.StatusInternalServerError, "Failed to load passkeys")
+		}
+		data.Passkeys = passkeys
+	}
+
+	switch {
+	case data.Unavailable != "":
+	case user.HasTwoFactor():
+		remaining, err := h.twoFactorService.RemainingRecoveryCodes(ctx, user.ID)
+		if err != nil {
+			return c.String(http.StatusInternalServerError, "Failed to load recovery codes")
+		}
+		data.RemainingRecoveryCodes = remaining
+	default:
+		secret, err := h.pendingSecret(c)
+		if err != nil {
+			c.Logger().Errorf("Failed to create TOTP secret: %v", err)
+			return c.String(http.StatusInternalServerError, "Failed to create secret")
+		}
+		uri := totp.URI(totpIssuer, user.Email, secret)
+		qrCode, err := totpQRCode(uri)
+		if err != nil {
+			c.Logger().Errorf("Failed to render TOTP QR code: %v", err)
+			return c.String(http.StatusInternalServerError, "Failed to create QR code")
+		}
+		data.Secret = secret
+		data.QRCode = qrCode
+	}
+
+	return templates.TwoFactorPage(ctx, csrfToken, user, isImpersonating, data).Render(ctx, c.Response().Writer)
+}
+
+// pendingSecret returns the secret of the running enrollment, so reloading the
+// page does not invalidate an already scanned QR code
+func (h *TwoFactorHandler) pendingSecret(c echo.Context) (string, error) {
+	session, err := middleware.GetSession(c)
+	if err != nil {
+		return "", err
+	}
+	if secret, ok := session.Values[pendingTOTPSecretKey].(string); ok && secret != "" {
+		return secret, nil
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return "", err
+	}
+	session.Values[pendingTOTPSecretKey] = secret
+	if err := session.Save(c.Request(), c.Response()); err != nil {
+		return "", err
+	}
+	return secret, nil
+}
+
+// totpQRCode renders the otpauth URI as PNG data URL
+func totpQRCode(uri string) (string, error) {
+	code, err := qr.Encode(uri, qr.M, qr.Auto)
+	if err != nil {
+		return "", err
+	}
+	code, err = barcode.Scale(code, totpQRSize, totpQRSize)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, code); err != nil {
+		return "", err
+	}
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// renderLogin renders the second login step with the code form if the user
+// enabled TOTP and the passkey button if the user has passkeys
+func (h *TwoFactorHandler) renderLogin(c echo.Context, user *models.User, errorMsg string) error {
+	ctx := c.Request().Context()
+	csrfToken, ok := c.Get("csrf").(string)
+	if !ok {
+		csrfToken = ""
+	}
+
+	hasPasskeys := false
+	if h.webAuthnService.Enabled() {
+		count, err := h.webAuthnService.CountCredentials(ctx, user.ID)
+		if err != nil {
+			c.Logger().Errorf("Failed to count passkeys of user %s: %v", user.ID, err)
+		}
+		hasPasskeys = count > 0
+	}
+	return templates.LoginTwoFactor(ctx, csrfToken, user.HasTwoFactor(), hasPasskeys, errorMsg).Render(ctx, c.Response().Writer)
+}
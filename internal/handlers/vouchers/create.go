@@ -5,8 +5,8 @@ import (
 	"net/http"
 	"savvy/internal/database"
 	"savvy/internal/models"
+	"savvy/internal/money"
 	"savvy/internal/validation"
-	"strconv"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
@@ -16,8 +16,8 @@ import (
 func (h *Handler) Create(c echo.Context) error {
 	user := c.Get("current_user").(*models.User)
 
-	value, _ := strconv.ParseFloat(c.FormValue("value"), 64)
-	minPurchaseAmount, _ := strconv.ParseFloat(c.FormValue("min_purchase_amount"), 64)
+	value, _ := models.ParseVoucherValue(c.FormValue("type"), c.FormValue("value"))
+	minPurchaseAmount, _ := money.Parse(c.FormValue("min_purchase_amount"), models.VoucherCurrency)
 	usageLimitType := c.FormValue("usage_limit_type")
 	code := c.FormValue("code")
 
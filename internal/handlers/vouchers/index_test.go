@@ -40,7 +40,7 @@ func TestIndexHandler_Success(t *testing.T) {
 			Code:         "SAVE20",
 			MerchantName: "Test Merchant 1",
 			Type:         "percentage",
-			Value:        20.0,
+			Value:        2000,
 			ValidFrom:    time.Now().Add(-24 * time.Hour),
 			ValidUntil:   time.Now().Add(30 * 24 * time.Hour),
 		},
@@ -50,7 +50,7 @@ func TestIndexHandler_Success(t *testing.T) {
 			Code:         "WELCOME10",
 			MerchantName: "Test Merchant 2",
 			Type:         "fixed",
-			Value:        10.0,
+			Value:        1000,
 			ValidFrom:    time.Now().Add(-24 * time.Hour),
 			ValidUntil:   time.Now().Add(60 * 24 * time.Hour),
 		},
@@ -168,7 +168,7 @@ func TestIndexHandler_WithImpersonation(t *testing.T) {
 			Code:         "DISCOUNT",
 			MerchantName: "Test Merchant",
 			Type:         "percentage",
-			Value:        15.0,
+			Value:        1500,
 			ValidFrom:    time.Now().Add(-24 * time.Hour),
 			ValidUntil:   time.Now().Add(30 * 24 * time.Hour),
 		},
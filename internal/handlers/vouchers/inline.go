@@ -5,9 +5,9 @@ import (
 	"net/http"
 	"savvy/internal/i18n"
 	"savvy/internal/models"
+	"savvy/internal/money"
 	"savvy/internal/templates"
 	"savvy/internal/validation"
-	"strconv"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
@@ -99,8 +99,8 @@ func (h *Handler) UpdateInline(c echo.Context) error {
 	}
 
 	// Parse value
-	value, _ := strconv.ParseFloat(c.FormValue("value"), 64)
-	minPurchaseAmount, _ := strconv.ParseFloat(c.FormValue("min_purchase_amount"), 64)
+	value, _ := models.ParseVoucherValue(c.FormValue("type"), c.FormValue("value"))
+	minPurchaseAmount, _ := money.Parse(c.FormValue("min_purchase_amount"), models.VoucherCurrency)
 
 	// Parse usage limit type
 	usageLimitType := c.FormValue("usage_limit_type")
@@ -5,8 +5,8 @@ import (
 	"net/http"
 	"savvy/internal/audit"
 	"savvy/internal/models"
+	"savvy/internal/money"
 	"savvy/internal/validation"
-	"strconv"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
@@ -32,8 +32,8 @@ func (h *Handler) Update(c echo.Context) error {
 		return c.Redirect(http.StatusSeeOther, "/vouchers")
 	}
 
-	value, _ := strconv.ParseFloat(c.FormValue("value"), 64)
-	minPurchaseAmount, _ := strconv.ParseFloat(c.FormValue("min_purchase_amount"), 64)
+	value, _ := models.ParseVoucherValue(c.FormValue("type"), c.FormValue("value"))
+	minPurchaseAmount, _ := money.Parse(c.FormValue("min_purchase_amount"), models.VoucherCurrency)
 	usageLimitType := c.FormValue("usage_limit_type")
 
 	validFrom, validUntil, err := validation.ParseAndValidateDateRange(
@@ -0,0 +1,178 @@
+// Package handlers contains HTTP request handlers for the savvy system.
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"savvy/internal/config"
+	"savvy/internal/models"
+	"savvy/internal/services"
+	"savvy/internal/wallet"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// WalletHandler serves wallet passes for cards, vouchers and gift cards with authorization checks.
+type WalletHandler struct {
+	cfg             *config.Config
+	apple           *wallet.Apple  // nil if Apple Wallet is not configured
+	google          *wallet.Google // nil if Google Wallet is not configured
+	authzService    services.AuthzServiceInterface
+	cardService     services.CardServiceInterface
+	voucherService  services.VoucherServiceInterface
+	giftCardService services.GiftCardServiceInterface
+}
+
+// NewWalletHandler creates a new wallet handler.
+func NewWalletHandler(
+	cfg *config.Config,
+	apple *wallet.Apple,
+	google *wallet.Google,
+	authzService services.AuthzServiceInterface,
+	cardService services.CardServiceInterface,
+	voucherService services.VoucherServiceInterface,
+	giftCardService services.GiftCardServiceInterface,
+) *WalletHandler {
+	return &WalletHandler{
+		cfg:             cfg,
+		apple:           apple,
+		google:          google,
+		authzService:    authzService,
+		cardService:     cardService,
+		voucherService:  voucherService,
+		giftCardService: giftCardService,
+	}
+}
+
+// walletResource holds the resource a pass is generated for; exactly one field is set
+type walletResource struct {
+	card     *models.Card
+	voucher  *models.Voucher
+	giftCard *models.GiftCard
+}
+
+// fetchResource loads the resource named by the :type and :id params after checking access
+func (h *WalletHandler) fetchResource(c echo.Context, userID uuid.UUID) (*walletResource, error) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "Invalid ID")
+	}
+	ctx := c.Request().Context()
+
+	switch c.Param("type") {
+	case wallet.KindCard:
+		if !h.cfg.EnableCards {
+			return nil, echo.ErrNotFound
+		}
+		if _, err := h.authzService.CheckCardAccess(ctx, userID, id); err != nil {
+			return nil, echo.NewHTTPError(http.StatusForbidden, "Access denied")
+		}
+		card, err := h.cardService.GetCard(ctx, id)
+		if err != nil {
+			return nil, echo.ErrNotFound
+		}
+		return &walletResource{card: card}, nil
+
+	case wallet.KindVoucher:
+		if !h.cfg.EnableVouchers {
+			return nil, echo.ErrNotFound
+		}
+		if _, err := h.authzService.CheckVoucherAccess(ctx, userID, id); err != nil {
+			return nil, echo.NewHTTPError(http.StatusForbidden, "Access denied")
+		}
+		voucher, err := h.voucherService.GetVoucher(ctx, id)
+		if err != nil {
+			return nil, echo.ErrNotFound
+		}
+		return &walletResource{voucher: voucher}, nil
+
+	case wallet.KindGiftCard:
+		if !h.cfg.EnableGiftCards {
+			return nil, echo.ErrNotFound
+		}
+		if _, err := h.authzService.CheckGiftCardAccess(ctx, userID, id); err != nil {
+			return nil, echo.NewHTTPError(http.StatusForbidden, "Access denied")
+		}
+		giftCard, err := h.giftCardService.GetGiftCard(ctx, id)
+		if err != nil {
+			return nil, echo.ErrNotFound
+		}
+		return &walletResource{giftCard: giftCard}, nil
+
+	default:
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "Invalid resource type")
+	}
+}
+
+// ApplePass downloads a signed Apple Wallet pass (.pkpass) for a card, voucher or gift card.
+func (h *WalletHandler) ApplePass(c echo.Context) error {
+	if h.apple == nil {
+		return echo.ErrNotFound
+	}
+	user := c.Get("current_user").(*models.User)
+
+	resource, err := h.fetchResource(c, user.ID)
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+	var pass *wallet.ApplePass
+	var merchant *models.Merchant
+	switch {
+	case resource.card != nil:
+		pass, merchant = h.apple.CardPass(ctx, resource.card), resource.card.Merchant
+	case resource.voucher != nil:
+		pass, merchant = h.apple.VoucherPass(ctx, resource.voucher), resource.voucher.Merchant
+	default:
+		pass, merchant = h.apple.GiftCardPass(ctx, resource.giftCard), resource.giftCard.Merchant
+	}
+
+	data, err := h.apple.Bundle(ctx, pass, merchant)
+	if err != nil {
+		c.Logger().Errorf("Apple Wallet pass generation failed (%s): %v", pass.SerialNumber, err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Fehler beim Erstellen des Wallet-Passes")
+	}
+
+	c.Response().Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", pass.SerialNumber+".pkpass"))
+	// Passes contain the barcode data and a balance snapshot - never cache them
+	c.Response().Header().Set("Cache-Control", "private, no-store")
+	return c.Blob(http.StatusOK, wallet.ApplePassContentType, data)
+}
+
+// GooglePass redirects to the "Save to Google Wallet" link for a card, voucher or gift card.
+func (h *WalletHandler) GooglePass(c echo.Context) error {
+	if h.google == nil {
+		return echo.ErrNotFound
+	}
+	user := c.Get("current_user").(*models.User)
+
+	resource, err := h.fetchResource(c, user.ID)
+	if err != nil {
+		return err
+	}
+
+	// Google fetches images itself and requires a program logo
+	defaultLogo := c.Scheme() + "://" + c.Request().Host + "/static/icons/icon-512.png"
+
+	ctx := c.Request().Context()
+	var payload *wallet.GooglePayload
+	switch {
+	case resource.card != nil:
+		payload = h.google.CardPayload(ctx, resource.card, defaultLogo)
+	case resource.voucher != nil:
+		payload = h.google.VoucherPayload(ctx, resource.voucher, defaultLogo)
+	default:
+		payload = h.google.GiftCardPayload(ctx, resource.giftCard, defaultLogo)
+	}
+
+	saveURL, err := h.google.SaveURL(payload)
+	if err != nil {
+		c.Logger().Errorf("Google Wallet JWT signing failed: %v", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Fehler beim Erstellen des Wallet-Passes")
+	}
+
+	c.Response().Header().Set("Cache-Control", "private, no-store")
+	return c.Redirect(http.StatusSeeOther, saveURL)
+}
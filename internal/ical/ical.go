@@ -0,0 +1,161 @@
+// Package ical writes iCalendar feeds (RFC 5545) with all-day events, as
+// subscribed to by calendar apps.
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// ProductID identifies the app in the PRODID property
+	ProductID = "-//Savvy//Expiry Dates//EN"
+	// RefreshInterval is how often calendar apps are asked to fetch the feed again
+	RefreshInterval = 6 * time.Hour
+	// maxLineLength is the maximum length of a content line in octets
+	maxLineLength = 75
+)
+
+// Calendar is a feed of events
+type Calendar struct {
+	Name   string    // Shown by calendar apps as the name of the subscription
+	Stamp  time.Time // Time the feed was generated
+	Events []Event
+}
+
+// Event is an all-day event
+type Event struct {
+	UID         string    // Stable across fetches, so apps update the event instead of adding it again
+	Date        time.Time // Day of the event, the UTC date is used
+	Summary     string
+	Description string
+	URL         string
+	Alarms      []Alarm
+}
+
+// Alarm shows a reminder before an event
+type Alarm struct {
+	Before      time.Duration // Before the start of the day of the event
+	Description string
+}
+
+// Encode writes the calendar with CRLF line endings and folded lines
+func (c *Calendar) Encode(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	line := func(name, value string) {
+		writeLine(bw, name+":"+value)
+	}
+
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", ProductID)
+	line("CALSCALE", "GREGORIAN")
+	line("METHOD", "PUBLISH")
+	line("X-WR-CALNAME", escapeText(c.Name))
+	line("REFRESH-INTERVAL;VALUE=DURATION", formatDuration(RefreshInterval))
+	line("X-PUBLISHED-TTL", formatDuration(RefreshInterval))
+
+	stamp := c.Stamp.UTC().Format("20060102T150405Z")
+	for _, event := range c.Events {
+		date := event.Date.UTC()
+		line("BEGIN", "VEVENT")
+		line("UID", escapeText(event.UID))
+		line("DTSTAMP", stamp)
+		line("DTSTART;VALUE=DATE", date.Format("20060102"))
+		line("DTEND;VALUE=DATE", date.AddDate(0, 0, 1).Format("20060102"))
+		line("SUMMARY", escapeText(event.Summary))
+		if event.Description != "" {
+			line("DESCRIPTION", escapeText(event.Description))
+		}
+		if event.URL != "" {
+			line("URL", event.URL)
+		}
+		// All-day reminders should not block the day in free/busy lookups
+		line("TRANSP", "TRANSPARENT")
+		for _, alarm := range event.Alarms {
+			line("BEGIN", "VALARM")
+			line("ACTION", "DISPLAY")
+			line("DESCRIPTION", escapeText(alarm.Description))
+			line("TRIGGER", formatDuration(-alarm.Before))
+			line("END", "VALARM")
+		}
+		line("END", "VEVENT")
+	}
+
+	line("END", "VCALENDAR")
+	return bw.Flush()
+}
+
+// writeLine writes a content line, folded after 75 octets without splitting
+// a UTF-8 sequence. Continuation lines start with a space.
+func writeLine(w *bufio.Writer, line string) {
+	limit := maxLineLength
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		_, _ = w.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+		// The leading space counts towards the length of the next line
+		limit = maxLineLength - 1
+	}
+	_, _ = w.WriteString(line + "\r\n")
+}
+
+// textEscaper escapes the characters with a meaning in TEXT values
+var textEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	";", `\;`,
+	",", `\,`,
+	"\r\n", `\n`,
+	"\n", `\n`,
+	"\r", `\n`,
+)
+
+func escapeText(s string) string {
+	return textEscaper.Replace(s)
+}
+
+// formatDuration formats a duration as e.g. "-P6DT15H"
+func formatDuration(d time.Duration) string {
+	sign := ""
+	if d < 0 {
+		sign = "-"
+		d = -d
+	}
+	if d == 0 {
+		return "PT0S"
+	}
+
+	days := d / (24 * time.Hour)
+	d -= days * 24 * time.Hour
+	hours := d / time.Hour
+	d -= hours * time.Hour
+	minutes := d / time.Minute
+	d -= minutes * time.Minute
+	seconds := d / time.Second
+
+	var b strings.Builder
+	b.WriteString(sign + "P")
+	if days > 0 {
+		fmt.Fprintf(&b, "%dD", days)
+	}
+	if hours > 0 || minutes > 0 || seconds > 0 {
+		b.WriteString("T")
+		if hours > 0 {
+			fmt.Fprintf(&b, "%dH", hours)
+		}
+		if minutes > 0 {
+			fmt.Fprintf(&b, "%dM", minutes)
+		}
+		if seconds > 0 {
+			fmt.Fprintf(&b, "%dS", seconds)
+		}
+	}
+	return b.String()
+}
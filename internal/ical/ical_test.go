@@ -0,0 +1,68 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalendar_Encode(t *testing.T) {
+	calendar := &Calendar{
+		Name:  "Savvy",
+		Stamp: time.Date(2026, 10, 16, 8, 30, 0, 0, time.UTC),
+		Events: []Event{{
+			UID:         "voucher-1-valid-until@savvy",
+			Date:        time.Date(2026, 11, 15, 23, 59, 59, 0, time.UTC),
+			Summary:     "Voucher Migros, Coop; expires",
+			Description: "Line one\nLine two",
+			URL:         "https://savvy.example.com/vouchers/1",
+			Alarms:      []Alarm{{Before: 7*24*time.Hour - 9*time.Hour, Description: "7 days left"}},
+		}},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, calendar.Encode(&buf))
+	out := buf.String()
+
+	assert.True(t, strings.HasPrefix(out, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"))
+	assert.True(t, strings.HasSuffix(out, "END:VCALENDAR\r\n"))
+	assert.Contains(t, out, "X-WR-CALNAME:Savvy\r\n")
+	assert.Contains(t, out, "DTSTAMP:20261016T083000Z\r\n")
+	assert.Contains(t, out, "DTSTART;VALUE=DATE:20261115\r\n")
+	assert.Contains(t, out, "DTEND;VALUE=DATE:20261116\r\n")
+	assert.Contains(t, out, `SUMMARY:Voucher Migros\, Coop\; expires`+"\r\n")
+	assert.Contains(t, out, `DESCRIPTION:Line one\nLine two`+"\r\n")
+	assert.Contains(t, out, "BEGIN:VALARM\r\nACTION:DISPLAY\r\nDESCRIPTION:7 days left\r\nTRIGGER:-P6DT15H\r\nEND:VALARM\r\n")
+	assert.NotContains(t, strings.ReplaceAll(out, "\r\n", ""), "\n", "Only CRLF line endings")
+}
+
+func TestWriteLine_Folds(t *testing.T) {
+	var buf bytes.Buffer
+	calendar := &Calendar{Name: strings.Repeat("ä", 100)}
+	require.NoError(t, calendar.Encode(&buf))
+
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\r\n"), "\r\n") {
+		assert.LessOrEqual(t, len(line), maxLineLength)
+		assert.True(t, strings.ToValidUTF8(line, "") == line, "Folding must not split characters")
+	}
+	unfolded := strings.ReplaceAll(buf.String(), "\r\n ", "")
+	assert.Contains(t, unfolded, "X-WR-CALNAME:"+strings.Repeat("ä", 100)+"\r\n")
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := map[time.Duration]string{
+		0:                                 "PT0S",
+		-15 * time.Hour:                   "-PT15H",
+		-(29*24*time.Hour + 15*time.Hour): "-P29DT15H",
+		6 * time.Hour:                     "PT6H",
+		24 * time.Hour:                    "P1D",
+		90*time.Minute + 5*time.Second:    "PT1H30M5S",
+	}
+	for d, want := range tests {
+		assert.Equal(t, want, formatDuration(d), d.String())
+	}
+}
@@ -0,0 +1,133 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// catimaCSVName is the file name Catima uses inside its ZIP export
+const catimaCSVName = "catima.csv"
+
+// ParseCatima reads a Catima export, either the ZIP archive created by
+// "Export" in the app or the catima.csv file contained in it.
+//
+// The CSV consists of several tables (groups, cards, card groups), each
+// starting with its own header row. Only the cards table is imported; card
+// images and groups are ignored.
+func ParseCatima(data []byte) ([]Record, error) {
+	if isZip(data) {
+		csvData, err := catimaCSVFromZip(data)
+		if err != nil {
+			return nil, err
+		}
+		data = csvData
+	}
+
+	reader := csv.NewReader(bytes.NewReader(trimBOM(data)))
+	reader.FieldsPerRecord = -1 // Tables have different widths
+
+	var records []Record
+	var columns map[string]int // Set while reading the cards table
+	foundCards := false
+
+	for {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrUnsupportedFormat, err)
+		}
+
+		// Every table starts with a header row: "_id,..." or "cardId,groupId"
+		if row[0] == "_id" || row[0] == "cardId" {
+			columns = catimaCardColumns(row)
+			foundCards = foundCards || columns != nil
+			continue
+		}
+		if columns == nil {
+			continue // Version line or a non-card table
+		}
+
+		line, _ := reader.FieldPos(0)
+		records = append(records, catimaRecord(line, row, columns))
+	}
+
+	if !foundCards {
+		return nil, fmt.Errorf("%w: no Catima cards table found", ErrUnsupportedFormat)
+	}
+	return records, nil
+}
+
+// catimaCSVFromZip extracts catima.csv from a Catima ZIP export
+func catimaCSVFromZip(data []byte) ([]byte, error) {
+	archive, err := openZip(data)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range archive.File {
+		if path.Base(f.Name) == catimaCSVName {
+			return readZipFile(f)
+		}
+	}
+	return nil, fmt.Errorf("%w: %s not found in archive", ErrUnsupportedFormat, catimaCSVName)
+}
+
+// catimaCardColumns indexes a header row, returning nil unless it is the cards table
+func catimaCardColumns(header []string) map[string]int {
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["store"]; !ok {
+		return nil
+	}
+	if _, ok := columns["cardid"]; !ok {
+		return nil
+	}
+	return columns
+}
+
+// catimaRecord converts a row of the cards table
+func catimaRecord(line int, row []string, columns map[string]int) Record {
+	get := func(name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[i])
+	}
+
+	// Catima distinguishes the number shown to the user from the value
+	// encoded in the barcode; the barcode value is what the till scans.
+	number := get("barcodeid")
+	if number == "" {
+		number = get("cardid")
+	}
+
+	status := statusActive
+	if get("archive") == "1" {
+		status = statusInactive
+	}
+	if expiry, err := strconv.ParseInt(get("expiry"), 10, 64); err == nil && time.UnixMilli(expiry).Before(time.Now()) {
+		status = statusExpired
+	}
+
+	store := get("store")
+	return Record{
+		Line:         line,
+		MerchantName: store,
+		Program:      store, // Catima has no program name
+		CardNumber:   number,
+		BarcodeType:  get("barcodetype"),
+		Notes:        get("note"),
+		Status:       status,
+	}
+}
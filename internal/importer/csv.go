@@ -0,0 +1,183 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// Mapping names the CSV column that holds each card field. Empty fields are
+// detected from the header row using common column names.
+type Mapping struct {
+	Merchant    string
+	Program     string
+	CardNumber  string
+	BarcodeType string
+	Notes       string
+	Status      string
+}
+
+// columnAliases lists the normalized header names recognized per field when
+// the mapping leaves a column empty
+var columnAliases = map[string][]string{
+	"merchant":     {"merchant", "merchantname", "store", "shop", "haendler", "händler", "geschaeft", "geschäft", "magasin", "commercant", "commerçant"},
+	"program":      {"program", "programme", "programm", "name", "cardname", "title"},
+	"card_number":  {"cardnumber", "number", "cardid", "barcode", "barcodevalue", "code", "kartennummer", "nummer", "numero", "numéro"},
+	"barcode_type": {"barcodetype", "barcodeformat", "format", "type", "barcodetyp", "typ"},
+	"notes":        {"notes", "note", "notiz", "notizen", "bemerkung", "bemerkungen", "comment", "remarque"},
+	"status":       {"status", "state", "statut"},
+}
+
+// statusAliases maps localized status values onto card statuses
+var statusAliases = map[string]string{
+	"":           statusActive,
+	"active":     statusActive,
+	"aktiv":      statusActive,
+	"actif":      statusActive,
+	"inactive":   statusInactive,
+	"inaktiv":    statusInactive,
+	"archived":   statusInactive,
+	"expired":    statusExpired,
+	"abgelaufen": statusExpired,
+	"expiré":     statusExpired,
+}
+
+// ParseCSV reads a generic CSV file with a header row. Comma, semicolon and
+// tab separated files are supported. The card number and merchant columns are
+// required; without a program column the merchant name is used as program.
+func ParseCSV(data []byte, mapping Mapping) ([]Record, error) {
+	data = trimBOM(data)
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comma = detectDelimiter(data)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, ErrNoCards
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedFormat, err)
+	}
+
+	columns, err := resolveColumns(header, mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []Record
+	for {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrUnsupportedFormat, err)
+		}
+
+		get := func(field string) string {
+			i, ok := columns[field]
+			if !ok || i >= len(row) {
+				return ""
+			}
+			return strings.TrimSpace(row[i])
+		}
+
+		// Skip blank lines padded with separators, as exported by spreadsheets
+		if strings.TrimSpace(strings.Join(row, "")) == "" {
+			continue
+		}
+
+		record := Record{
+			MerchantName: get("merchant"),
+			Program:      get("program"),
+			CardNumber:   get("card_number"),
+			BarcodeType:  get("barcode_type"),
+			Notes:        get("notes"),
+			Status:       get("status"),
+		}
+		record.Line, _ = reader.FieldPos(0)
+		if _, ok := columns["program"]; !ok {
+			record.Program = record.MerchantName
+		}
+		if status, ok := statusAliases[strings.ToLower(record.Status)]; ok {
+			record.Status = status
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// resolveColumns finds the column index of every mapped or recognized field
+func resolveColumns(header []string, mapping Mapping) (map[string]int, error) {
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		key := columnKey(name)
+		if _, exists := index[key]; !exists {
+			index[key] = i
+		}
+	}
+
+	explicit := map[string]string{
+		"merchant":     mapping.Merchant,
+		"program":      mapping.Program,
+		"card_number":  mapping.CardNumber,
+		"barcode_type": mapping.BarcodeType,
+		"notes":        mapping.Notes,
+		"status":       mapping.Status,
+	}
+
+	columns := make(map[string]int)
+	for field, column := range explicit {
+		if column != "" {
+			i, ok := index[columnKey(column)]
+			if !ok {
+				return nil, fmt.Errorf("%w: %q (available: %s)", ErrMissingColumn, column, strings.Join(header, ", "))
+			}
+			columns[field] = i
+			continue
+		}
+		for _, alias := range columnAliases[field] {
+			if i, ok := index[columnKey(alias)]; ok {
+				columns[field] = i
+				break
+			}
+		}
+	}
+
+	for _, required := range []string{"card_number", "merchant"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("%w: %s (available: %s)", ErrMissingColumn, required, strings.Join(header, ", "))
+		}
+	}
+	return columns, nil
+}
+
+// columnKey normalizes header names so "Card Number", "card_number" and "CardNumber" match
+func columnKey(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// detectDelimiter picks the most frequent separator of the header line
+func detectDelimiter(data []byte) rune {
+	line, _ := bufio.NewReader(bytes.NewReader(data)).ReadString('\n')
+	best, count := ',', strings.Count(line, ",")
+	for _, delimiter := range []rune{';', '\t'} {
+		if n := strings.Count(line, string(delimiter)); n > count {
+			best, count = delimiter, n
+		}
+	}
+	return best
+}
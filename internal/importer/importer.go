@@ -0,0 +1,187 @@
+// Package importer parses loyalty card exports of other apps (Catima, Stocard)
+// and generic CSV files into records that can be turned into cards.
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Format identifies the source of an import file
+type Format string
+
+// Supported import formats
+const (
+	FormatCatima  Format = "catima"
+	FormatStocard Format = "stocard"
+	FormatCSV     Format = "csv"
+)
+
+// maxEntrySize caps the decompressed size of a single ZIP entry
+const maxEntrySize = 10 << 20 // 10 MiB
+
+// Card statuses as stored on models.Card
+const (
+	statusActive   = "active"
+	statusInactive = "inactive"
+	statusExpired  = "expired"
+)
+
+var (
+	// ErrUnsupportedFormat is returned for unknown formats and unreadable files
+	ErrUnsupportedFormat = errors.New("unsupported import format")
+	// ErrEncrypted is returned for password-protected ZIP exports
+	ErrEncrypted = errors.New("encrypted exports are not supported")
+	// ErrNoCards is returned when a file contains no card records at all
+	ErrNoCards = errors.New("no cards found in import file")
+	// ErrMissingColumn is returned when a required CSV column cannot be found
+	ErrMissingColumn = errors.New("missing column")
+)
+
+// Record is a single card as read from an import file. Values are taken
+// verbatim from the source; validation happens when the import is previewed.
+type Record struct {
+	Line         int    // CSV line or entry number in the source file, for error reporting
+	MerchantName string // store name as written in the source
+	Program      string
+	CardNumber   string
+	BarcodeType  string // barcode format as written in the source, see BarcodeType
+	Notes        string
+	Status       string
+}
+
+// ParseFormat converts user input into a Format.
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(strings.TrimSpace(s))); f {
+	case FormatCatima, FormatStocard, FormatCSV:
+		return f, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrUnsupportedFormat, s)
+	}
+}
+
+// Parse reads the records of an import file. The mapping is only used for FormatCSV.
+func Parse(format Format, data []byte, mapping Mapping) ([]Record, error) {
+	var records []Record
+	var err error
+
+	switch format {
+	case FormatCatima:
+		records, err = ParseCatima(data)
+	case FormatStocard:
+		records, err = ParseStocard(data)
+	case FormatCSV:
+		records, err = ParseCSV(data, mapping)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedFormat, format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, ErrNoCards
+	}
+	return records, nil
+}
+
+// barcodeTypes maps normalized source barcode names (ZXing/Catima, Stocard and
+// our own) onto the barcode types accepted for cards. Formats without an exact
+// equivalent fall back to a scanner-compatible type of the same dimension.
+var barcodeTypes = map[string]struct {
+	barcodeType string
+	exact       bool
+}{
+	"CODE128":    {"CODE128", true},
+	"CODE39":     {"CODE128", false},
+	"CODE93":     {"CODE128", false},
+	"CODABAR":    {"CODE128", false},
+	"ITF":        {"CODE128", false},
+	"ITF14":      {"CODE128", false},
+	"UPCE":       {"CODE128", false},
+	"EAN13":      {"EAN13", true},
+	"UPCA":       {"EAN13", false},
+	"EAN8":       {"EAN8", true},
+	"QR":         {"QR", true},
+	"QRCODE":     {"QR", true},
+	"AZTEC":      {"QR", false},
+	"DATAMATRIX": {"QR", false},
+	"PDF417":     {"QR", false},
+}
+
+// BarcodeType maps a source barcode format onto a card barcode type. exact is
+// false if the source format had to be substituted; unknown and empty formats
+// fall back to CODE128, the default for new cards.
+func BarcodeType(source string) (barcodeType string, exact bool) {
+	key := barcodeKey(source)
+	if key == "" {
+		return "CODE128", true
+	}
+	if t, ok := barcodeTypes[key]; ok {
+		return t.barcodeType, t.exact
+	}
+	return "CODE128", false
+}
+
+// CardNumber returns the number to encode for a record, converting 12-digit
+// UPC-A numbers into their EAN-13 form (leading zero) when the barcode type
+// was mapped from UPC-A.
+func CardNumber(record Record) string {
+	number := strings.TrimSpace(record.CardNumber)
+	if barcodeKey(record.BarcodeType) == "UPCA" && len(number) == 12 && isDigits(number) {
+		return "0" + number
+	}
+	return number
+}
+
+// barcodeKey normalizes the different spellings of a barcode format ("EAN_13", "ean-13", "EAN13")
+func barcodeKey(s string) string {
+	return strings.NewReplacer("_", "", "-", "", " ", "").Replace(strings.ToUpper(strings.TrimSpace(s)))
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// isZip reports whether data starts with a ZIP local file header
+func isZip(data []byte) bool {
+	return bytes.HasPrefix(data, []byte("PK\x03\x04"))
+}
+
+// openZip opens a ZIP archive held in memory
+func openZip(data []byte) (*zip.Reader, error) {
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedFormat, err)
+	}
+	return reader, nil
+}
+
+// readZipFile reads a single archive entry, rejecting encrypted entries
+func readZipFile(f *zip.File) ([]byte, error) {
+	if f.Flags&0x1 != 0 {
+		return nil, ErrEncrypted
+	}
+	if f.UncompressedSize64 > maxEntrySize {
+		return nil, fmt.Errorf("%s exceeds %d bytes", f.Name, maxEntrySize)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rc.Close() }()
+	return io.ReadAll(io.LimitReader(rc, maxEntrySize))
+}
+
+// trimBOM strips a UTF-8 byte order mark as written by spreadsheet applications
+func trimBOM(data []byte) []byte {
+	return bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+}
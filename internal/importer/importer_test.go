@@ -0,0 +1,233 @@
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// buildZip creates an in-memory ZIP archive from name → content
+func buildZip(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for name, content := range files {
+		f, err := w.Create(name)
+		require.NoError(t, err)
+		_, err = f.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+const catimaCSV = `2
+
+_id
+Groceries
+
+_id,store,note,validfrom,expiry,balance,balancetype,cardid,barcodeid,barcodetype,barcodeencoding,headercolor,starstatus,lastused,zoomlevel,archive
+1,Migros,"Family card
+shared with Anna",,,0,,2099123456789,,EAN_13,,-16777216,0,1700000000,100,0
+2,Coop,,,,0,,CARD-7,77001234,AZTEC,,-1,1,1700000000,100,1
+3,Manor,,,1000,0,,123456,,,,,0,1700000000,100,0
+
+cardId,groupId
+1,Groceries
+`
+
+func TestParseCatima_CSV(t *testing.T) {
+	records, err := ParseCatima([]byte(catimaCSV))
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+
+	assert.Equal(t, Record{
+		Line:         7,
+		MerchantName: "Migros",
+		Program:      "Migros",
+		CardNumber:   "2099123456789",
+		BarcodeType:  "EAN_13",
+		Notes:        "Family card\nshared with Anna",
+		Status:       "active",
+	}, records[0])
+
+	// Barcode value wins over the displayed card ID; archived cards are inactive
+	assert.Equal(t, "77001234", records[1].CardNumber)
+	assert.Equal(t, "AZTEC", records[1].BarcodeType)
+	assert.Equal(t, "inactive", records[1].Status)
+
+	// Expiry in the past (epoch millis)
+	assert.Equal(t, "expired", records[2].Status)
+	assert.Equal(t, 10, records[2].Line)
+}
+
+func TestParseCatima_ZIP(t *testing.T) {
+	data := buildZip(t, map[string]string{
+		"catima.csv":         catimaCSV,
+		"card_1_front.png":   "not an image",
+		"card_2_icon.png":    "not an image",
+		"unrelated/file.txt": "ignored",
+	})
+
+	records, err := ParseCatima(data)
+	require.NoError(t, err)
+	assert.Len(t, records, 3)
+}
+
+func TestParseCatima_Invalid(t *testing.T) {
+	_, err := ParseCatima([]byte("merchant,number\nMigros,123\n"))
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+
+	_, err = ParseCatima(buildZip(t, map[string]string{"cards.json": "{}"}))
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+}
+
+func TestParseStocard(t *testing.T) {
+	data := buildZip(t, map[string]string{
+		"users/u1/loyalty-cards/b-card.json": `{
+			"input_id": "6001234",
+			"input_barcode_format": "CODE_39",
+			"input_provider_reference": {"identifier": "/loyalty-card-custom-providers/p1"}
+		}`,
+		"users/u1/loyalty-cards/a-card.json": `{
+			"input_id": " 2099000 ",
+			"input_provider_reference": {"identifier": "/loyalty-card-providers/4711"}
+		}`,
+		"users/u1/loyalty-cards/b-card/notes/default.json":    `{"content": "Kitchen drawer"}`,
+		"users/u1/loyalty-card-custom-providers/p1.json":      `{"name": "Bäckerei Huber"}`,
+		"users/u1/loyalty-cards/b-card/images/front.png":      "ignored",
+		"users/u1/usage-statistics/events/some-event.json":    `{"type": "open"}`,
+		"users/u1/loyalty-cards/b-card/usage-statistics.json": `{}`,
+	})
+
+	records, err := ParseStocard(data)
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+
+	assert.Equal(t, "Stocard provider 4711", records[0].MerchantName)
+	assert.Equal(t, "2099000", records[0].CardNumber)
+	assert.Equal(t, 1, records[0].Line)
+
+	assert.Equal(t, Record{
+		Line:         2,
+		MerchantName: "Bäckerei Huber",
+		Program:      "Bäckerei Huber",
+		CardNumber:   "6001234",
+		BarcodeType:  "CODE_39",
+		Notes:        "Kitchen drawer",
+		Status:       "active",
+	}, records[1])
+}
+
+func TestParseStocard_NotAZip(t *testing.T) {
+	_, err := ParseStocard([]byte("merchant,number\n"))
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+}
+
+func TestParseCSV_DetectsColumns(t *testing.T) {
+	data := "\xef\xbb\xbfHändler;Programm;Kartennummer;Barcode Type;Notizen;Status\n" +
+		"Migros;Cumulus;2099123456789;ean13;;aktiv\n" +
+		";;;;;\n" +
+		"Coop;Supercard;1234;QR_CODE;Shared;Abgelaufen\n"
+
+	records, err := ParseCSV([]byte(data), Mapping{})
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+
+	assert.Equal(t, Record{
+		Line:         2,
+		MerchantName: "Migros",
+		Program:      "Cumulus",
+		CardNumber:   "2099123456789",
+		BarcodeType:  "ean13",
+		Status:       "active",
+	}, records[0])
+	assert.Equal(t, 4, records[1].Line)
+	assert.Equal(t, "expired", records[1].Status)
+}
+
+func TestParseCSV_ExplicitMapping(t *testing.T) {
+	data := "Shop,Member No,Comment\nIKEA,9000,Family\n"
+
+	records, err := ParseCSV([]byte(data), Mapping{Merchant: "Shop", CardNumber: "member no"})
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+
+	// Program falls back to the merchant name when no column exists
+	assert.Equal(t, "IKEA", records[0].Program)
+	assert.Equal(t, "9000", records[0].CardNumber)
+	assert.Equal(t, "Family", records[0].Notes)
+	assert.Equal(t, "active", records[0].Status)
+}
+
+func TestParseCSV_MissingColumn(t *testing.T) {
+	_, err := ParseCSV([]byte("Shop,Comment\nIKEA,Family\n"), Mapping{})
+	assert.ErrorIs(t, err, ErrMissingColumn)
+	assert.Contains(t, err.Error(), "card_number")
+
+	_, err = ParseCSV([]byte("Shop,Number\nIKEA,1\n"), Mapping{CardNumber: "Card"})
+	assert.ErrorIs(t, err, ErrMissingColumn)
+}
+
+func TestParse_NoCards(t *testing.T) {
+	_, err := Parse(FormatCSV, []byte("merchant,card_number\n"), Mapping{})
+	assert.ErrorIs(t, err, ErrNoCards)
+
+	_, err = Parse(Format("passbook"), nil, Mapping{})
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+}
+
+func TestParseFormat(t *testing.T) {
+	format, err := ParseFormat(" Catima ")
+	require.NoError(t, err)
+	assert.Equal(t, FormatCatima, format)
+
+	_, err = ParseFormat("keyring")
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+}
+
+func TestBarcodeType(t *testing.T) {
+	tests := []struct {
+		source string
+		want   string
+		exact  bool
+	}{
+		{"", "CODE128", true},
+		{"CODE_128", "CODE128", true},
+		{"EAN_13", "EAN13", true},
+		{"ean-8", "EAN8", true},
+		{"QR_CODE", "QR", true},
+		{"UPC_A", "EAN13", false},
+		{"CODE_39", "CODE128", false},
+		{"AZTEC", "QR", false},
+		{"PDF_417", "QR", false},
+		{"MAXICODE", "CODE128", false},
+	}
+	for _, tt := range tests {
+		got, exact := BarcodeType(tt.source)
+		assert.Equal(t, tt.want, got, tt.source)
+		assert.Equal(t, tt.exact, exact, tt.source)
+	}
+}
+
+func TestCardNumber_UPCA(t *testing.T) {
+	assert.Equal(t, "0036000291452", CardNumber(Record{CardNumber: "036000291452", BarcodeType: "UPC_A"}))
+	assert.Equal(t, "036000291452", CardNumber(Record{CardNumber: "036000291452", BarcodeType: "CODE_128"}))
+	assert.Equal(t, "ABC", CardNumber(Record{CardNumber: " ABC ", BarcodeType: "UPC_A"}))
+}
+
+func TestParseCatima_EncryptedZip(t *testing.T) {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	f, err := w.CreateHeader(&zip.FileHeader{Name: "catima.csv", Flags: 0x1})
+	require.NoError(t, err)
+	_, err = f.Write([]byte("garbage"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	_, err = ParseCatima(buf.Bytes())
+	assert.ErrorIs(t, err, ErrEncrypted)
+}
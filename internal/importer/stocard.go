@@ -0,0 +1,132 @@
+package importer
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Directories of the Stocard data export ZIP that hold cards
+const (
+	stocardCardsDir     = "loyalty-cards"
+	stocardProvidersDir = "loyalty-card-custom-providers"
+)
+
+// stocardCard is a loyalty-cards/<id>.json entry
+type stocardCard struct {
+	InputID           string `json:"input_id"`
+	InputBarcodeFmt   string `json:"input_barcode_format"`
+	ProviderReference struct {
+		Identifier string `json:"identifier"`
+	} `json:"input_provider_reference"`
+}
+
+// stocardProvider is a loyalty-card-custom-providers/<id>.json entry
+type stocardProvider struct {
+	Name string `json:"name"`
+}
+
+// stocardNote is a loyalty-cards/<id>/notes/default.json entry
+type stocardNote struct {
+	Content string `json:"content"`
+}
+
+// ParseStocard reads the ZIP archive of a Stocard data export.
+//
+// Cards of custom stores carry the store name in the export. Cards of
+// Stocard's built-in stores only reference a provider ID, which is kept as
+// merchant name ("Stocard provider 123") so the row shows up in the preview
+// and can be corrected after the import.
+func ParseStocard(data []byte) ([]Record, error) {
+	if !isZip(data) {
+		return nil, fmt.Errorf("%w: Stocard exports are ZIP archives", ErrUnsupportedFormat)
+	}
+	archive, err := openZip(data)
+	if err != nil {
+		return nil, err
+	}
+
+	cards := make(map[string]stocardCard)
+	providers := make(map[string]string)
+	notes := make(map[string]string)
+
+	for _, f := range archive.File {
+		if f.FileInfo().IsDir() || path.Ext(f.Name) != ".json" {
+			continue
+		}
+		parts := strings.Split(strings.Trim(f.Name, "/"), "/")
+		id := strings.TrimSuffix(parts[len(parts)-1], ".json")
+
+		switch {
+		case len(parts) >= 2 && parts[len(parts)-2] == stocardCardsDir:
+			var card stocardCard
+			if err := decodeZipJSON(f, &card); err != nil {
+				return nil, err
+			}
+			cards[id] = card
+
+		case len(parts) >= 2 && parts[len(parts)-2] == stocardProvidersDir:
+			var provider stocardProvider
+			if err := decodeZipJSON(f, &provider); err != nil {
+				return nil, err
+			}
+			providers[id] = provider.Name
+
+		case len(parts) >= 4 && parts[len(parts)-2] == "notes" && parts[len(parts)-4] == stocardCardsDir:
+			var note stocardNote
+			if err := decodeZipJSON(f, &note); err != nil {
+				return nil, err
+			}
+			notes[parts[len(parts)-3]] = note.Content
+		}
+	}
+
+	if len(cards) == 0 {
+		return nil, fmt.Errorf("%w: no Stocard cards found in archive", ErrUnsupportedFormat)
+	}
+
+	// Map iteration is random; keep the preview stable
+	ids := make([]string, 0, len(cards))
+	for id := range cards {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	records := make([]Record, 0, len(ids))
+	for i, id := range ids {
+		card := cards[id]
+		providerID := card.ProviderReference.Identifier
+		providerID = providerID[strings.LastIndex(providerID, "/")+1:]
+
+		name := providers[providerID]
+		if name == "" && providerID != "" {
+			name = "Stocard provider " + providerID
+		}
+
+		records = append(records, Record{
+			Line:         i + 1,
+			MerchantName: name,
+			Program:      name, // Stocard has no program name
+			CardNumber:   strings.TrimSpace(card.InputID),
+			BarcodeType:  card.InputBarcodeFmt,
+			Notes:        strings.TrimSpace(notes[id]),
+			Status:       statusActive,
+		})
+	}
+	return records, nil
+}
+
+// decodeZipJSON decodes a JSON archive entry into v
+func decodeZipJSON(f *zip.File, v any) error {
+	data, err := readZipFile(f)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrUnsupportedFormat, f.Name, err)
+	}
+	return nil
+}
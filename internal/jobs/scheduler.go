@@ -0,0 +1,202 @@
+// Package jobs runs periodic background work inside the server.
+//
+// Every replica runs the same scheduler. A job runs once per schedule slot
+// across all replicas: the replica that gets the Postgres advisory lock of the
+// job claims the slot in the jobs table and runs it, the others skip it.
+// Local jobs, such as refreshing the gauges of this process, run on every
+// replica instead. Each run is stored as history for the admin page.
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"savvy/internal/metrics"
+	"savvy/internal/models"
+	"savvy/internal/repository"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron"
+)
+
+// DefaultTimeout cancels a run that takes longer and no timeout was set
+const DefaultTimeout = 10 * time.Minute
+
+// Job is a function that runs on a schedule
+type Job struct {
+	Name     string                          // Unique name, shown on the admin page
+	Schedule string                          // Cron expression ("*/5 * * * *") or descriptor ("@hourly", "@every 30s"), in UTC
+	Local    bool                            // Runs on every replica instead of once per slot
+	Timeout  time.Duration                   // Defaults to DefaultTimeout
+	Run      func(ctx context.Context) error // The work; an error marks the run as failed
+}
+
+// everySchedule runs at multiples of a fixed interval since the Unix epoch,
+// so all replicas compute the same slots
+type everySchedule struct {
+	interval time.Duration
+}
+
+func (s everySchedule) Next(t time.Time) time.Time {
+	return t.Truncate(s.interval).Add(s.interval)
+}
+
+// parseSchedule parses a cron expression or descriptor
+func parseSchedule(spec string) (cron.Schedule, error) {
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return nil, err
+	}
+	if every, ok := schedule.(cron.ConstantDelaySchedule); ok {
+		return everySchedule{interval: every.Delay}, nil
+	}
+	return schedule, nil
+}
+
+type scheduledJob struct {
+	Job
+	schedule cron.Schedule
+}
+
+// Scheduler runs the registered jobs until its context is canceled
+type Scheduler struct {
+	repo     repository.JobRepository
+	instance string
+	jobs     []scheduledJob
+	now      func() time.Time
+}
+
+// NewScheduler creates a scheduler; instance identifies this replica in the
+// run history, e.g. the host name
+func NewScheduler(repo repository.JobRepository, instance string) *Scheduler {
+	return &Scheduler{
+		repo:     repo,
+		instance: instance,
+		now:      func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// Register adds a job. Must be called before Run.
+func (s *Scheduler) Register(job Job) error {
+	if job.Name == "" || job.Run == nil {
+		return errors.New("job needs a name and a function")
+	}
+	for _, existing := range s.jobs {
+		if existing.Name == job.Name {
+			return fmt.Errorf("job %q is already registered", job.Name)
+		}
+	}
+	schedule, err := parseSchedule(job.Schedule)
+	if err != nil {
+		return fmt.Errorf("invalid schedule of job %q: %w", job.Name, err)
+	}
+	if job.Timeout <= 0 {
+		job.Timeout = DefaultTimeout
+	}
+	s.jobs = append(s.jobs, scheduledJob{Job: job, schedule: schedule})
+	return nil
+}
+
+// Run stores the registered jobs and runs them on their schedules. It blocks
+// until ctx is canceled and the running jobs have returned.
+func (s *Scheduler) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, job := range s.jobs {
+		if err := s.repo.Register(ctx, job.Name, job.Schedule, job.Local); err != nil {
+			slog.Error("Failed to register job", "job", job.Name, "error", err)
+		}
+		wg.Go(func() { s.loop(ctx, job) })
+	}
+	slog.Info("Job scheduler started", "jobs", len(s.jobs), "instance", s.instance)
+	wg.Wait()
+}
+
+// loop waits for each slot of the job and runs it. Slots that pass while a
+// run takes longer than the interval are skipped.
+func (s *Scheduler) loop(ctx context.Context, job scheduledJob) {
+	next := job.schedule.Next(s.now())
+	for {
+		timer := time.NewTimer(next.Sub(s.now()))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		s.runSlot(ctx, job, next)
+		next = job.schedule.Next(s.now())
+	}
+}
+
+// runSlot runs the job for the slot unless another replica runs it
+func (s *Scheduler) runSlot(ctx context.Context, job scheduledJob, slot time.Time) {
+	if job.Local {
+		s.execute(ctx, job, slot)
+		return
+	}
+
+	locked, err := s.repo.WithLock(ctx, job.Name, func(ctx context.Context) {
+		// The lock only protects against parallel runs; the claim makes sure
+		// a replica whose clock is behind does not repeat a finished slot
+		claimed, err := s.repo.Claim(ctx, job.Name, slot)
+		if err != nil {
+			slog.Warn("Failed to claim job", "job", job.Name, "error", err)
+			return
+		}
+		if claimed {
+			s.execute(ctx, job, slot)
+		}
+	})
+	if err != nil {
+		slog.Warn("Failed to lock job", "job", job.Name, "error", err)
+	} else if !locked {
+		slog.Debug("Job runs on another replica", "job", job.Name)
+	}
+}
+
+// execute runs the job and records the run
+func (s *Scheduler) execute(ctx context.Context, job scheduledJob, slot time.Time) {
+	run := &models.JobRun{
+		JobName:     job.Name,
+		Instance:    s.instance,
+		ScheduledAt: slot,
+		StartedAt:   s.now(),
+		Status:      models.JobRunRunning,
+	}
+	if err := s.repo.StartRun(ctx, run); err != nil {
+		slog.Warn("Failed to record job run", "job", job.Name, "error", err)
+	}
+
+	err := call(ctx, job.Job)
+
+	finishedAt := s.now()
+	run.FinishedAt = &finishedAt
+	run.Duration = finishedAt.Sub(run.StartedAt)
+	run.Status = models.JobRunSucceeded
+	if err != nil {
+		run.Status = models.JobRunFailed
+		run.Error = err.Error()
+		slog.Error("Job failed", "job", job.Name, "duration", run.Duration, "error", err)
+	}
+	metrics.RecordJobRun(job.Name, string(run.Status), run.Duration)
+
+	// Record the outcome even if the scheduler is stopping
+	if err := s.repo.FinishRun(context.WithoutCancel(ctx), run); err != nil {
+		slog.Warn("Failed to record job result", "job", job.Name, "error", err)
+	}
+}
+
+// call runs the job with its timeout and turns a panic into an error
+func call(ctx context.Context, job Job) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, job.Timeout)
+	defer cancel()
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return job.Run(ctx)
+}
@@ -0,0 +1,246 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"savvy/internal/models"
+)
+
+// fakeJobRepository keeps the job state in memory; locked simulates
+// another replica holding the advisory lock
+type fakeJobRepository struct {
+	mu        sync.Mutex
+	locked    bool
+	jobs      map[string]*models.Job
+	runs      []models.JobRun
+	finished  []models.JobRun
+	lockCalls int
+}
+
+func newFakeJobRepository() *fakeJobRepository {
+	return &fakeJobRepository{jobs: map[string]*models.Job{}}
+}
+
+func (r *fakeJobRepository) Register(_ context.Context, name, schedule string, local bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.jobs[name] = &models.Job{Name: name, Schedule: schedule, Local: local}
+	return nil
+}
+
+func (r *fakeJobRepository) WithLock(ctx context.Context, _ string, fn func(ctx context.Context)) (bool, error) {
+	r.mu.Lock()
+	r.lockCalls++
+	locked := r.locked
+	r.mu.Unlock()
+	if locked {
+		return false, nil
+	}
+	fn(ctx)
+	return true, nil
+}
+
+func (r *fakeJobRepository) Claim(_ context.Context, name string, scheduledAt time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job := r.jobs[name]
+	if job.LastScheduledAt != nil && !job.LastScheduledAt.Before(scheduledAt) {
+		return false, nil
+	}
+	job.LastScheduledAt = &scheduledAt
+	return true, nil
+}
+
+func (r *fakeJobRepository) StartRun(_ context.Context, run *models.JobRun) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.runs = append(r.runs, *run)
+	return nil
+}
+
+func (r *fakeJobRepository) FinishRun(_ context.Context, run *models.JobRun) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.finished = append(r.finished, *run)
+	return nil
+}
+
+func (r *fakeJobRepository) List(_ context.Context) ([]models.Job, error) {
+	return nil, nil
+}
+
+func (r *fakeJobRepository) ListRuns(_ context.Context, _ string, _ int) ([]models.JobRun, error) {
+	return nil, nil
+}
+
+func (r *fakeJobRepository) DeleteRunsBefore(_ context.Context, _ time.Time) (int64, error) {
+	return 0, nil
+}
+
+func (r *fakeJobRepository) finishedRuns() []models.JobRun {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]models.JobRun(nil), r.finished...)
+}
+
+func registered(t *testing.T, s *Scheduler, job Job) scheduledJob {
+	t.Helper()
+	require.NoError(t, s.Register(job))
+	require.NoError(t, s.repo.Register(context.Background(), job.Name, job.Schedule, job.Local))
+	return s.jobs[len(s.jobs)-1]
+}
+
+func TestParseSchedule(t *testing.T) {
+	base := time.Date(2026, 10, 16, 12, 3, 17, 0, time.UTC)
+
+	every, err := parseSchedule("@every 5m")
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 10, 16, 12, 5, 0, 0, time.UTC), every.Next(base), "Aligned to the interval")
+
+	cronSchedule, err := parseSchedule("0 3 * * *")
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 10, 17, 3, 0, 0, 0, time.UTC), cronSchedule.Next(base))
+
+	hourly, err := parseSchedule("@hourly")
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 10, 16, 13, 0, 0, 0, time.UTC), hourly.Next(base))
+
+	_, err = parseSchedule("every five minutes")
+	assert.Error(t, err)
+}
+
+func TestScheduler_Register(t *testing.T) {
+	s := NewScheduler(newFakeJobRepository(), "test")
+	noop := func(context.Context) error { return nil }
+
+	require.NoError(t, s.Register(Job{Name: "cleanup", Schedule: "@every 1m", Run: noop}))
+	assert.Equal(t, DefaultTimeout, s.jobs[0].Timeout)
+
+	assert.Error(t, s.Register(Job{Name: "cleanup", Schedule: "@every 1m", Run: noop}), "Duplicate name")
+	assert.Error(t, s.Register(Job{Name: "broken", Schedule: "* *", Run: noop}), "Invalid schedule")
+	assert.Error(t, s.Register(Job{Name: "empty", Schedule: "@hourly"}), "No function")
+}
+
+func TestScheduler_RunSlot(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeJobRepository()
+	s := NewScheduler(repo, "replica-1")
+	calls := 0
+	job := registered(t, s, Job{Name: "cleanup", Schedule: "@every 1m", Run: func(context.Context) error {
+		calls++
+		return nil
+	}})
+	slot := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	s.runSlot(ctx, job, slot)
+	require.Equal(t, 1, calls)
+	runs := repo.finishedRuns()
+	require.Len(t, runs, 1)
+	assert.Equal(t, models.JobRunSucceeded, runs[0].Status)
+	assert.Equal(t, "replica-1", runs[0].Instance)
+	assert.Equal(t, slot, runs[0].ScheduledAt)
+	assert.NotNil(t, runs[0].FinishedAt)
+
+	// Another replica already ran the slot
+	s.runSlot(ctx, job, slot)
+	assert.Equal(t, 1, calls)
+
+	// Another replica holds the lock
+	repo.locked = true
+	s.runSlot(ctx, job, slot.Add(time.Minute))
+	assert.Equal(t, 1, calls)
+
+	repo.locked = false
+	s.runSlot(ctx, job, slot.Add(time.Minute))
+	assert.Equal(t, 2, calls)
+}
+
+func TestScheduler_RunSlotLocal(t *testing.T) {
+	repo := newFakeJobRepository()
+	s := NewScheduler(repo, "replica-1")
+	calls := 0
+	job := registered(t, s, Job{Name: "metrics", Schedule: "@every 30s", Local: true, Run: func(context.Context) error {
+		calls++
+		return nil
+	}})
+	repo.locked = true
+	slot := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	s.runSlot(context.Background(), job, slot)
+	s.runSlot(context.Background(), job, slot)
+
+	assert.Equal(t, 2, calls, "Local jobs neither lock nor claim")
+	assert.Zero(t, repo.lockCalls)
+}
+
+func TestScheduler_RunSlotFailures(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeJobRepository()
+	s := NewScheduler(repo, "replica-1")
+	slot := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	failing := registered(t, s, Job{Name: "failing", Schedule: "@hourly", Run: func(context.Context) error {
+		return errors.New("database unavailable")
+	}})
+	panicking := registered(t, s, Job{Name: "panicking", Schedule: "@hourly", Run: func(context.Context) error {
+		panic("nil map")
+	}})
+	slow := registered(t, s, Job{Name: "slow", Schedule: "@hourly", Timeout: 10 * time.Millisecond, Run: func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}})
+
+	s.runSlot(ctx, failing, slot)
+	s.runSlot(ctx, panicking, slot)
+	s.runSlot(ctx, slow, slot)
+
+	runs := repo.finishedRuns()
+	require.Len(t, runs, 3)
+	for _, run := range runs {
+		assert.Equal(t, models.JobRunFailed, run.Status, run.JobName)
+	}
+	assert.Equal(t, "database unavailable", runs[0].Error)
+	assert.Equal(t, "panic: nil map", runs[1].Error)
+	assert.Equal(t, context.DeadlineExceeded.Error(), runs[2].Error)
+}
+
+func TestScheduler_Run(t *testing.T) {
+	repo := newFakeJobRepository()
+	s := NewScheduler(repo, "replica-1")
+	ran := make(chan struct{}, 1)
+	require.NoError(t, s.Register(Job{Name: "tick", Schedule: "@every 1s", Run: func(context.Context) error {
+		select {
+		case ran <- struct{}{}:
+		default:
+		}
+		return nil
+	}}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.Run(ctx)
+		close(done)
+	}()
+
+	select {
+	case <-ran:
+	case <-time.After(3 * time.Second):
+		t.Fatal("Job did not run")
+	}
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Scheduler did not stop")
+	}
+	assert.Contains(t, repo.jobs, "tick")
+}
@@ -0,0 +1,226 @@
+package mail
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	netmail "net/mail"
+	"os"
+	"strings"
+	"testing"
+
+	"savvy/internal/assets"
+	"savvy/internal/i18n"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	if err := i18n.Init(assets.Locales); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+// smtpSink is a minimal local SMTP server that accepts every message
+type smtpSink struct {
+	listener   net.Listener
+	extensions []string
+	messages   chan sinkMessage
+}
+
+type sinkMessage struct {
+	from string
+	to   []string
+	data string
+}
+
+func newSMTPSink(t *testing.T, extensions ...string) *smtpSink {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	sink := &smtpSink{listener: listener, extensions: extensions, messages: make(chan sinkMessage, 1)}
+	t.Cleanup(func() { _ = listener.Close() })
+	go sink.serve()
+	return sink
+}
+
+func (s *smtpSink) port() string {
+	_, port, _ := net.SplitHostPort(s.listener.Addr().String())
+	return port
+}
+
+func (s *smtpSink) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *smtpSink) handle(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { _, _ = io.WriteString(conn, line+"\r\n") }
+
+	var msg sinkMessage
+	reply("220 sink ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		command := strings.ToUpper(line)
+		switch {
+		case strings.HasPrefix(command, "EHLO"):
+			for _, ext := range s.extensions {
+				reply("250-" + ext)
+			}
+			reply("250 sink")
+		case strings.HasPrefix(command, "MAIL FROM:"):
+			msg.from = strings.Trim(line[len("MAIL FROM:"):], "<>")
+			reply("250 OK")
+		case strings.HasPrefix(command, "RCPT TO:"):
+			msg.to = append(msg.to, strings.Trim(line[len("RCPT TO:"):], "<>"))
+			reply("250 OK")
+		case command == "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				dataLine, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if dataLine == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(dataLine, "."))
+			}
+			msg.data = data.String()
+			s.messages <- msg
+			reply("250 OK")
+		case command == "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
+
+func TestSMTPSender(t *testing.T) {
+	sink := newSMTPSink(t)
+	sender, err := NewSMTPSender(SMTPConfig{
+		Host: "127.0.0.1",
+		Port: sink.port(),
+		From: "Savvy <savvy@example.com>",
+		TLS:  TLSNone,
+	})
+	require.NoError(t, err)
+
+	err = sender.Send(context.Background(), Message{
+		To:      "anna.mueller@example.com",
+		ToName:  "Anna Müller",
+		Subject: "Passwort zurücksetzen",
+		Text:    "Hallo Anna\n\nhttps://savvy.example.com/auth/reset-password?token=abc",
+		HTML:    "<p>Hallo Anna</p>",
+	})
+	require.NoError(t, err)
+
+	received := <-sink.messages
+	assert.Equal(t, "savvy@example.com", received.from)
+	assert.Equal(t, []string{"anna.mueller@example.com"}, received.to)
+
+	parsed, err := netmail.ReadMessage(strings.NewReader(received.data))
+	require.NoError(t, err)
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	require.NoError(t, err)
+	assert.Equal(t, "Passwort zurücksetzen", subject)
+	assert.Contains(t, parsed.Header.Get("Message-ID"), "@example.com>")
+
+	to, err := netmail.ParseAddress(parsed.Header.Get("To"))
+	require.NoError(t, err)
+	assert.Equal(t, "Anna Müller", to.Name)
+
+	mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	assert.Equal(t, "multipart/alternative", mediaType)
+
+	parts := multipart.NewReader(parsed.Body, params["boundary"])
+	var bodies []string
+	for {
+		part, err := parts.NextPart()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		body, err := io.ReadAll(part) // Decodes quoted-printable
+		require.NoError(t, err)
+		bodies = append(bodies, part.Header.Get("Content-Type")+": "+string(body))
+	}
+	require.Len(t, bodies, 2)
+	assert.Contains(t, bodies[0], "text/plain")
+	assert.Contains(t, bodies[0], "token=abc")
+	assert.Contains(t, bodies[1], "<p>Hallo Anna</p>")
+}
+
+func TestSMTPSenderRequiresStartTLS(t *testing.T) {
+	sink := newSMTPSink(t)
+	sender, err := NewSMTPSender(SMTPConfig{
+		Host:     "127.0.0.1",
+		Port:     sink.port(),
+		Username: "savvy",
+		Password: "secret",
+		From:     "savvy@example.com",
+	})
+	require.NoError(t, err)
+
+	err = sender.Send(context.Background(), Message{To: "anna@example.com", Subject: "Test"})
+	assert.ErrorIs(t, err, ErrStartTLSUnsupported, "Credentials must not be sent without STARTTLS")
+}
+
+func TestNewSMTPSenderValidation(t *testing.T) {
+	_, err := NewSMTPSender(SMTPConfig{From: "savvy@example.com"})
+	assert.ErrorIs(t, err, ErrNotConfigured)
+
+	_, err = NewSMTPSender(SMTPConfig{Host: "smtp.example.com", From: "not an address"})
+	assert.Error(t, err)
+
+	_, err = NewSMTPSender(SMTPConfig{Host: "smtp.example.com", From: "savvy@example.com", TLS: "ssl"})
+	assert.Error(t, err)
+}
+
+func TestRender(t *testing.T) {
+	data := map[string]any{
+		"Name":  "Anna <Admin>",
+		"Link":  "https://savvy.example.com/auth/reset-password?token=a.b",
+		"Hours": 1,
+	}
+
+	de := i18n.SetLocalizer(context.Background(), i18n.NewLocalizer("de"))
+	msg, err := Render(de, "anna@example.com", "Anna", TemplatePasswordReset, data)
+	require.NoError(t, err)
+	assert.Equal(t, "anna@example.com", msg.To)
+	assert.Equal(t, "Passwort zurücksetzen", msg.Subject)
+	assert.Contains(t, msg.Text, "Hallo Anna <Admin>")
+	assert.Contains(t, msg.Text, data["Link"])
+	assert.Contains(t, msg.HTML, "Anna &lt;Admin&gt;", "HTML body must escape user data")
+	assert.Contains(t, msg.HTML, `href="https://savvy.example.com/auth/reset-password?token=a.b"`)
+
+	en := i18n.SetLocalizer(context.Background(), i18n.NewLocalizer("en"))
+	msg, err = Render(en, "anna@example.com", "Anna", TemplateVerifyEmail, data)
+	require.NoError(t, err)
+	assert.Equal(t, "Confirm your email address", msg.Subject)
+	assert.Contains(t, msg.Text, "valid for 1 hour(s)")
+	assert.NotContains(t, msg.Text, "mail.verify_email.", "All strings must be translated")
+
+	_, err = Render(en, "anna@example.com", "Anna", "unknown", data)
+	assert.Error(t, err)
+}
@@ -0,0 +1,162 @@
+package mail
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"time"
+)
+
+// TLS modes of SMTP_TLS
+const (
+	TLSStartTLS = "starttls" // Plain connection upgraded with STARTTLS, usually port 587
+	TLSImplicit = "tls"      // TLS from the first byte, usually port 465
+	TLSNone     = "none"     // Unencrypted, only for a local relay or mail sink
+)
+
+const smtpTimeout = 30 * time.Second
+
+// ErrStartTLSUnsupported is returned when the server does not offer STARTTLS
+// although it is required
+var ErrStartTLSUnsupported = errors.New("SMTP server does not support STARTTLS")
+
+// SMTPConfig holds the connection settings of an SMTP server
+type SMTPConfig struct {
+	Host     string
+	Port     string
+	Username string // Empty disables authentication
+	Password string
+	From     string // Sender address, with optional display name
+	TLS      string // TLSStartTLS, TLSImplicit or TLSNone
+}
+
+// SMTPSender delivers messages to an SMTP server, one connection per message
+type SMTPSender struct {
+	host    string
+	addr    string
+	from    *mail.Address
+	auth    smtp.Auth
+	tls     string
+	timeout time.Duration
+	now     func() time.Time
+}
+
+// NewSMTPSender validates cfg and creates a sender
+func NewSMTPSender(cfg SMTPConfig) (*SMTPSender, error) {
+	if cfg.Host == "" {
+		return nil, ErrNotConfigured
+	}
+	from, err := mail.ParseAddress(cfg.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SMTP_FROM %q: %w", cfg.From, err)
+	}
+
+	mode := cfg.TLS
+	switch mode {
+	case "":
+		mode = TLSStartTLS
+	case TLSStartTLS, TLSImplicit, TLSNone:
+	default:
+		return nil, fmt.Errorf("unknown SMTP_TLS mode %q", cfg.TLS)
+	}
+
+	port := cfg.Port
+	if port == "" {
+		port = "587"
+	}
+
+	sender := &SMTPSender{
+		host:    cfg.Host,
+		addr:    net.JoinHostPort(cfg.Host, port),
+		from:    from,
+		tls:     mode,
+		timeout: smtpTimeout,
+		now:     time.Now,
+	}
+	if cfg.Username != "" {
+		// net/smtp refuses to send the password over an unencrypted
+		// connection unless the server is on localhost
+		sender.auth = smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)
+	}
+	return sender, nil
+}
+
+// Send delivers msg, giving up when ctx is done or the timeout passed
+func (s *SMTPSender) Send(ctx context.Context, msg Message) error {
+	data, err := msg.encode(s.from, s.now())
+	if err != nil {
+		return err
+	}
+
+	conn, err := s.dial(ctx)
+	if err != nil {
+		return fmt.Errorf("connect to SMTP server: %w", err)
+	}
+	deadline := time.Now().Add(s.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		_ = conn.Close()
+		return err
+	}
+
+	client, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		_ = conn.Close()
+		return fmt.Errorf("SMTP greeting: %w", err)
+	}
+	defer func() { _ = client.Close() }()
+
+	if s.tls == TLSStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return ErrStartTLSUnsupported
+		}
+		if err := client.StartTLS(&tls.Config{ServerName: s.host, MinVersion: tls.VersionTLS12}); err != nil {
+			return fmt.Errorf("SMTP STARTTLS: %w", err)
+		}
+	}
+	if s.auth != nil {
+		if err := client.Auth(s.auth); err != nil {
+			return fmt.Errorf("SMTP authentication: %w", err)
+		}
+	}
+
+	to, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return err
+	}
+	if err := client.Mail(s.from.Address); err != nil {
+		return fmt.Errorf("SMTP MAIL FROM: %w", err)
+	}
+	if err := client.Rcpt(to.Address); err != nil {
+		return fmt.Errorf("SMTP RCPT TO: %w", err)
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("SMTP DATA: %w", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("SMTP DATA: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("SMTP DATA: %w", err)
+	}
+	return client.Quit()
+}
+
+func (s *SMTPSender) dial(ctx context.Context) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: s.timeout}
+	if s.tls == TLSImplicit {
+		tlsDialer := &tls.Dialer{
+			NetDialer: dialer,
+			Config:    &tls.Config{ServerName: s.host, MinVersion: tls.VersionTLS12},
+		}
+		return tlsDialer.DialContext(ctx, "tcp", s.addr)
+	}
+	return dialer.DialContext(ctx, "tcp", s.addr)
+}
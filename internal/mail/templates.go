@@ -0,0 +1,80 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	texttemplate "text/template"
+
+	"savvy/internal/i18n"
+)
+
+// Templates of the messages, each with a .txt and a .html variant
+const (
+	TemplatePasswordReset = "password_reset"
+	TemplateVerifyEmail   = "verify_email"
+	TemplateAccountLocked = "account_locked"
+	TemplateNotification  = "notification"
+)
+
+//go:embed templates
+var templateFS embed.FS
+
+// placeholderFuncs lets the templates parse; T is bound to the request
+// language when a message is rendered
+var placeholderFuncs = map[string]any{"T": func(string) string { return "" }}
+
+var (
+	htmlTemplates = map[string]*htmltemplate.Template{}
+	textTemplates = map[string]*texttemplate.Template{}
+)
+
+func init() {
+	layout := htmltemplate.Must(htmltemplate.New("layout.html").Funcs(placeholderFuncs).ParseFS(templateFS, "templates/layout.html"))
+	for _, name := range []string{TemplatePasswordReset, TemplateVerifyEmail, TemplateAccountLocked, TemplateNotification} {
+		htmlTemplates[name] = htmltemplate.Must(htmltemplate.Must(layout.Clone()).ParseFS(templateFS, "templates/"+name+".html"))
+		textTemplates[name] = texttemplate.Must(texttemplate.New(name+".txt").Funcs(placeholderFuncs).ParseFS(templateFS, "templates/"+name+".txt"))
+	}
+}
+
+// Render renders the template name for the recipient in the language of
+// ctx. data is available in the templates and in the translated strings,
+// e.g. {{.Name}} in "mail.greeting". The subject is the
+// translation of "mail.<name>.subject".
+func Render(ctx context.Context, to, toName, name string, data map[string]any) (Message, error) {
+	htmlTmpl, ok := htmlTemplates[name]
+	if !ok {
+		return Message{}, fmt.Errorf("unknown mail template %q", name)
+	}
+	funcs := map[string]any{
+		"T": func(id string) string { return i18n.T(ctx, id, data) },
+	}
+
+	htmlClone, err := htmlTmpl.Clone()
+	if err != nil {
+		return Message{}, err
+	}
+	var html bytes.Buffer
+	if err := htmlClone.Funcs(funcs).ExecuteTemplate(&html, "layout.html", data); err != nil {
+		return Message{}, fmt.Errorf("render %s.html: %w", name, err)
+	}
+
+	textClone, err := textTemplates[name].Clone()
+	if err != nil {
+		return Message{}, err
+	}
+	var text bytes.Buffer
+	if err := textClone.Funcs(funcs).Execute(&text, data); err != nil {
+		return Message{}, fmt.Errorf("render %s.txt: %w", name, err)
+	}
+
+	return Message{
+		To:      to,
+		ToName:  toName,
+		Subject: i18n.T(ctx, "mail."+name+".subject", data),
+		Text:    text.String(),
+		HTML:    html.String(),
+	}, nil
+}
@@ -44,6 +44,75 @@ var (
 		},
 	)
 
+	// Authentication Metrics
+	loginFailures = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "auth_login_failures_total",
+			Help: "Total failed login attempts",
+		},
+		[]string{"step"},
+	)
+
+	loginLockouts = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "auth_login_lockouts_total",
+			Help: "Total lockouts after repeated failed logins",
+		},
+		[]string{"scope"},
+	)
+
+	loginBlocked = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "auth_login_blocked_total",
+			Help: "Total login attempts refused because of a lockout or delay",
+		},
+		[]string{"reason"},
+	)
+
+	// Notification Metrics
+	notificationDeliveries = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "notification_deliveries_total",
+			Help: "Total notification delivery attempts by email and Web Push",
+		},
+		[]string{"channel", "status"},
+	)
+
+	// Job Metrics
+	jobRuns = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "job_runs_total",
+			Help: "Total runs of background jobs on this replica",
+		},
+		[]string{"job", "status"},
+	)
+
+	jobDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "job_run_duration_seconds",
+			Help:    "Duration of background job runs in seconds",
+			Buckets: []float64{0.01, 0.05, 0.1, 0.5, 1, 5, 10, 30, 60, 300},
+		},
+		[]string{"job"},
+	)
+
+	// Item Lifecycle Metrics
+	itemStatusTransitions = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "item_status_transitions_total",
+			Help: "Total automatic status changes of vouchers and gift cards",
+		},
+		[]string{"resource_type", "status"},
+	)
+
+	itemsByStatus = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "items_by_status",
+			Help: "Number of vouchers and gift cards per status",
+		},
+		[]string{"resource_type", "status"},
+	)
+
 	// Database Metrics
 	dbConnectionsActive = promauto.NewGauge(
 		prometheus.GaugeOpts{
@@ -133,6 +202,38 @@ func RecordError(handler, errorType string) {
 	errorCount.WithLabelValues(handler, errorType).Inc()
 }
 
+// RecordLoginFailure records a failed login at the given step ("password" or "two_factor")
+func RecordLoginFailure(step string) {
+	loginFailures.WithLabelValues(step).Inc()
+}
+
+// RecordLoginLockout records a lockout of an account or IP ("account" or "ip")
+func RecordLoginLockout(scope string) {
+	loginLockouts.WithLabelValues(scope).Inc()
+}
+
+// RecordLoginBlocked records a refused login attempt ("locked" or "delayed")
+func RecordLoginBlocked(reason string) {
+	loginBlocked.WithLabelValues(reason).Inc()
+}
+
+// RecordNotificationDelivery records the outcome of a delivery attempt
+// ("sent", "skipped", "pending" for a retry or "failed" after the last one)
+func RecordNotificationDelivery(channel, status string) {
+	notificationDeliveries.WithLabelValues(channel, status).Inc()
+}
+
+// RecordJobRun records a finished run of a background job ("succeeded" or "failed")
+func RecordJobRun(job, status string, duration time.Duration) {
+	jobRuns.WithLabelValues(job, status).Inc()
+	jobDuration.WithLabelValues(job).Observe(duration.Seconds())
+}
+
+// RecordItemStatusTransition records that the lifecycle job moved an item to status
+func RecordItemStatusTransition(resourceType, status string) {
+	itemStatusTransitions.WithLabelValues(resourceType, status).Inc()
+}
+
 // SetActiveSessions updates the active sessions gauge
 func SetActiveSessions(count float64) {
 	activeSessions.Set(count)
@@ -161,3 +262,14 @@ func UpdateResourceCounts(cards, vouchers, giftCards, users int64) {
 	giftCardsTotal.Set(float64(giftCards))
 	usersTotal.Set(float64(users))
 }
+
+// UpdateItemStatusCounts replaces the item per status gauges, keyed by
+// resource type and then status, so statuses no item has anymore disappear
+func UpdateItemStatusCounts(counts map[string]map[string]int64) {
+	itemsByStatus.Reset()
+	for resourceType, statuses := range counts {
+		for status, count := range statuses {
+			itemsByStatus.WithLabelValues(resourceType, status).Set(float64(count))
+		}
+	}
+}
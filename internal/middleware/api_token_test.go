@@ -0,0 +1,121 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"savvy/internal/models"
+	"savvy/internal/services"
+)
+
+// stubAPITokenService authenticates a single known token
+type stubAPITokenService struct {
+	services.APITokenServiceInterface
+	plaintext string
+	token     *models.APIToken
+	err       error
+}
+
+func (s *stubAPITokenService) Authenticate(_ context.Context, plaintext string) (*models.APIToken, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	if plaintext != s.plaintext {
+		return nil, services.ErrInvalidAPIToken
+	}
+	return s.token, nil
+}
+
+func runAPITokenAuth(t *testing.T, service services.APITokenServiceInterface, method, authorization string, chain ...echo.MiddlewareFunc) (echo.Context, error) {
+	t.Helper()
+	e := echo.New()
+	req := httptest.NewRequest(method, "/api/v1/cards", nil)
+	if authorization != "" {
+		req.Header.Set(echo.HeaderAuthorization, authorization)
+	}
+	c := e.NewContext(req, httptest.NewRecorder())
+
+	handler := func(c echo.Context) error { return c.NoContent(http.StatusOK) }
+	for i := len(chain) - 1; i >= 0; i-- {
+		handler = chain[i](handler)
+	}
+	return c, APITokenAuth(service)(handler)(c)
+}
+
+func TestAPITokenAuth_SetsCurrentUser(t *testing.T) {
+	user := &models.User{ID: uuid.New()}
+	service := &stubAPITokenService{plaintext: "svy_abc", token: &models.APIToken{User: user, Scopes: "cards:read"}}
+
+	c, err := runAPITokenAuth(t, service, http.MethodGet, "Bearer svy_abc")
+
+	require.NoError(t, err)
+	assert.Same(t, user, c.Get("current_user"))
+	assert.Same(t, user, c.Request().Context().Value(UserContextKey))
+	assert.NotNil(t, c.Get("api_token"))
+}
+
+func TestAPITokenAuth_NoHeader(t *testing.T) {
+	c, err := runAPITokenAuth(t, &stubAPITokenService{}, http.MethodGet, "")
+
+	require.NoError(t, err)
+	assert.Nil(t, c.Get("current_user"))
+}
+
+func TestAPITokenAuth_InvalidToken(t *testing.T) {
+	_, err := runAPITokenAuth(t, &stubAPITokenService{plaintext: "svy_abc"}, http.MethodGet, "Bearer svy_wrong")
+
+	var httpErr *echo.HTTPError
+	require.ErrorAs(t, err, &httpErr)
+	assert.Equal(t, http.StatusUnauthorized, httpErr.Code)
+}
+
+func TestAPITokenAuth_ExpiredToken(t *testing.T) {
+	_, err := runAPITokenAuth(t, &stubAPITokenService{err: services.ErrAPITokenExpired}, http.MethodGet, "Bearer svy_abc")
+
+	var httpErr *echo.HTTPError
+	require.ErrorAs(t, err, &httpErr)
+	assert.Equal(t, http.StatusUnauthorized, httpErr.Code)
+}
+
+func TestRequireAPIScope(t *testing.T) {
+	service := &stubAPITokenService{plaintext: "svy_abc", token: &models.APIToken{User: &models.User{}, Scopes: "cards:read"}}
+
+	_, err := runAPITokenAuth(t, service, http.MethodGet, "Bearer svy_abc", RequireAPIScope(models.APIScopeCards))
+	assert.NoError(t, err)
+
+	_, err = runAPITokenAuth(t, service, http.MethodPost, "Bearer svy_abc", RequireAPIScope(models.APIScopeCards))
+	var httpErr *echo.HTTPError
+	require.ErrorAs(t, err, &httpErr)
+	assert.Equal(t, http.StatusForbidden, httpErr.Code)
+
+	_, err = runAPITokenAuth(t, service, http.MethodGet, "Bearer svy_abc", RequireAPIScope(models.APIScopeVouchers))
+	require.ErrorAs(t, err, &httpErr)
+	assert.Equal(t, http.StatusForbidden, httpErr.Code)
+}
+
+func TestRequireAPIScope_SessionUserUnrestricted(t *testing.T) {
+	_, err := runAPITokenAuth(t, &stubAPITokenService{}, http.MethodDelete, "", RequireAPIScope(models.APIScopeCards))
+
+	assert.NoError(t, err)
+}
+
+func TestIsBearerRequest(t *testing.T) {
+	e := echo.New()
+	for header, want := range map[string]bool{
+		"Bearer svy_abc": true,
+		"bearer svy_abc": true,
+		"Basic abc":      false,
+		"Bearer ":        false,
+		"":               false,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.Header.Set(echo.HeaderAuthorization, header)
+		assert.Equal(t, want, IsBearerRequest(e.NewContext(req, httptest.NewRecorder())), header)
+	}
+}
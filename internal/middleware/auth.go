@@ -73,6 +73,17 @@ func RequireAuth(next echo.HandlerFunc) echo.HandlerFunc {
 	}
 }
 
+// RequireAPIAuth middleware requires authentication for JSON endpoints.
+// Unlike RequireAuth it answers 401 instead of redirecting to the login page.
+func RequireAPIAuth(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if _, ok := c.Get("current_user").(*models.User); !ok {
+			return echo.NewHTTPError(http.StatusUnauthorized, "authentication required")
+		}
+		return next(c)
+	}
+}
+
 // RequireAdmin middleware requires admin role
 func RequireAdmin(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
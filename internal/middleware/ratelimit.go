@@ -2,62 +2,52 @@
 package middleware
 
 import (
+	"fmt"
+	"log/slog"
+	"math"
 	"net/http"
-	"sync"
+	"savvy/internal/models"
+	"savvy/internal/ratelimit"
+	"strconv"
 	"time"
 
 	"github.com/labstack/echo/v4"
-	"golang.org/x/time/rate"
 )
 
-// IPRateLimiter manages rate limiters per IP address
-type IPRateLimiter struct {
-	limiters map[string]*rate.Limiter
-	mu       sync.RWMutex
-	r        rate.Limit // requests per second
-	b        int        // burst size
-}
-
-// NewIPRateLimiter creates a new rate limiter with the specified rate and burst
-func NewIPRateLimiter(r rate.Limit, b int) *IPRateLimiter {
-	return &IPRateLimiter{
-		limiters: make(map[string]*rate.Limiter),
-		r:        r,
-		b:        b,
-	}
-}
-
-// GetLimiter returns the rate limiter for the given IP address
-func (i *IPRateLimiter) GetLimiter(ip string) *rate.Limiter {
-	i.mu.Lock()
-	defer i.mu.Unlock()
-
-	limiter, exists := i.limiters[ip]
-	if !exists {
-		limiter = rate.NewLimiter(i.r, i.b)
-		i.limiters[ip] = limiter
-
-		// Clean up old limiters after 1 hour
-		go func() {
-			time.Sleep(1 * time.Hour)
-			i.mu.Lock()
-			delete(i.limiters, ip)
-			i.mu.Unlock()
-		}()
-	}
-
-	return limiter
-}
+// Rate limit policies of the routes
+var (
+	// AuthRateLimit applies to login, registration, password reset and 2FA changes
+	AuthRateLimit = ratelimit.Policy{Name: "auth", Limit: 30, Window: time.Minute}
+	// ShareRateLimit applies to creating shares, which notifies the recipient
+	ShareRateLimit = ratelimit.Policy{Name: "shares", Limit: 60, Window: time.Hour}
+	// BarcodeRateLimit applies to barcode image generation
+	BarcodeRateLimit = ratelimit.Policy{Name: "barcodes", Limit: 300, Window: time.Minute}
+	// CalendarRateLimit applies to calendar feed fetches, which carry no session
+	CalendarRateLimit = ratelimit.Policy{Name: "calendar", Limit: 60, Window: time.Minute}
+)
 
-// RateLimitMiddleware creates a rate limiting middleware
-// r is the rate (requests per second), b is the burst size
-func RateLimitMiddleware(limiter *IPRateLimiter) echo.MiddlewareFunc {
+// RateLimit counts requests against the policy per signed-in user, or per
+// IP before login, and answers 429 once the limit is reached. The
+// RateLimit-* headers tell clients their quota. If the backend fails the
+// request is let through, the limits are a safeguard and not worth an outage.
+func RateLimit(limiter ratelimit.Limiter, policy ratelimit.Policy) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
-			ip := c.RealIP()
-			l := limiter.GetLimiter(ip)
+			result, err := limiter.Allow(c.Request().Context(), policy, rateLimitKey(c))
+			if err != nil {
+				slog.Warn("Rate limiter unavailable", "policy", policy.Name, "error", err)
+				return next(c)
+			}
 
-			if !l.Allow() {
+			reset := strconv.Itoa(int(math.Ceil(result.Reset.Seconds())))
+			header := c.Response().Header()
+			header.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", policy.Limit, int(policy.Window.Seconds())))
+			header.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+			header.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			header.Set("RateLimit-Reset", reset)
+
+			if !result.Allowed {
+				header.Set("Retry-After", reset)
 				return echo.NewHTTPError(http.StatusTooManyRequests, "Too many requests. Please try again later.")
 			}
 
@@ -65,3 +55,12 @@ func RateLimitMiddleware(limiter *IPRateLimiter) echo.MiddlewareFunc {
 		}
 	}
 }
+
+// rateLimitKey identifies the client: users behind a shared IP get their own
+// quota once signed in
+func rateLimitKey(c echo.Context) string {
+	if user, ok := c.Get("current_user").(*models.User); ok && user != nil {
+		return "user:" + user.ID.String()
+	}
+	return "ip:" + c.RealIP()
+}
@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"savvy/internal/models"
+	"savvy/internal/ratelimit"
+)
+
+// failingLimiter simulates an unreachable backend
+type failingLimiter struct{}
+
+func (failingLimiter) Allow(context.Context, ratelimit.Policy, string) (ratelimit.Result, error) {
+	return ratelimit.Result{}, errors.New("connection refused")
+}
+
+func serveRateLimited(t *testing.T, handler echo.HandlerFunc, ip string, user *models.User) *httptest.ResponseRecorder {
+	t.Helper()
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/auth/login", nil)
+	req.Header.Set(echo.HeaderXRealIP, ip)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	if user != nil {
+		c.Set("current_user", user)
+	}
+
+	if err := handler(c); err != nil {
+		e.HTTPErrorHandler(err, c)
+	}
+	return rec
+}
+
+func TestRateLimit(t *testing.T) {
+	policy := ratelimit.Policy{Name: "test", Limit: 2, Window: time.Minute}
+	handler := RateLimit(ratelimit.NewMemoryLimiter(), policy)(func(c echo.Context) error {
+		return c.NoContent(http.StatusNoContent)
+	})
+
+	rec := serveRateLimited(t, handler, "192.0.2.1", nil)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, "2", rec.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", rec.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "60", rec.Header().Get("RateLimit-Reset"))
+	assert.Equal(t, "2;w=60", rec.Header().Get("RateLimit-Policy"))
+
+	rec = serveRateLimited(t, handler, "192.0.2.1", nil)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, "0", rec.Header().Get("RateLimit-Remaining"))
+
+	rec = serveRateLimited(t, handler, "192.0.2.1", nil)
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "60", rec.Header().Get("Retry-After"))
+
+	rec = serveRateLimited(t, handler, "198.51.100.7", nil)
+	assert.Equal(t, http.StatusNoContent, rec.Code, "Other IPs have their own quota")
+
+	// Signed-in users are counted by account, not by the shared IP
+	user := &models.User{ID: uuid.New()}
+	rec = serveRateLimited(t, handler, "192.0.2.1", user)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+}
+
+func TestRateLimit_BackendUnavailable(t *testing.T) {
+	policy := ratelimit.Policy{Name: "test", Limit: 1, Window: time.Minute}
+	handler := RateLimit(failingLimiter{}, policy)(func(c echo.Context) error {
+		return c.NoContent(http.StatusNoContent)
+	})
+
+	for i := 0; i < 3; i++ {
+		rec := serveRateLimited(t, handler, "192.0.2.1", nil)
+		require.Equal(t, http.StatusNoContent, rec.Code, "Requests pass while the backend is down")
+		assert.Empty(t, rec.Header().Get("RateLimit-Limit"))
+	}
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ReauthWindow is how long a fresh password or OIDC confirmation unlocks
+// sensitive actions such as revealing a gift card PIN
+const ReauthWindow = 5 * time.Minute
+
+// Re-authentication methods
+const (
+	ReauthPassword = "password"
+	ReauthOIDC     = "oidc"
+)
+
+const (
+	reauthAtSessionKey     = "reauthenticated_at"
+	reauthMethodSessionKey = "reauth_method"
+)
+
+// MarkReauthenticated records in the session that the user just confirmed
+// their identity again with the given method.
+func MarkReauthenticated(c echo.Context, method string) error {
+	session, err := GetSession(c)
+	if err != nil {
+		return err
+	}
+	session.Values[reauthAtSessionKey] = time.Now().Unix()
+	session.Values[reauthMethodSessionKey] = method
+	return session.Save(c.Request(), c.Response())
+}
+
+// RecentReauthentication returns how the user confirmed their identity if
+// that happened within the ReauthWindow.
+func RecentReauthentication(c echo.Context) (method string, ok bool) {
+	session, err := GetSession(c)
+	if err != nil {
+		return "", false
+	}
+	at, ok := session.Values[reauthAtSessionKey].(int64)
+	if !ok || time.Since(time.Unix(at, 0)) >= ReauthWindow {
+		return "", false
+	}
+	method, _ = session.Values[reauthMethodSessionKey].(string)
+	return method, true
+}
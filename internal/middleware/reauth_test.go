@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReauthentication(t *testing.T) {
+	InitSessionStore("test-secret-with-at-least-32-characters", false)
+	e := echo.New()
+
+	// Fresh session without confirmation
+	rec := httptest.NewRecorder()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+	_, ok := RecentReauthentication(c)
+	assert.False(t, ok)
+	require.NoError(t, MarkReauthenticated(c, ReauthOIDC))
+
+	// Next request carries the session cookie
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	for _, cookie := range rec.Result().Cookies() {
+		req.AddCookie(cookie)
+	}
+	c = e.NewContext(req, httptest.NewRecorder())
+	method, ok := RecentReauthentication(c)
+	assert.True(t, ok)
+	assert.Equal(t, ReauthOIDC, method)
+
+	// Confirmation expires after the window
+	session, err := GetSession(c)
+	require.NoError(t, err)
+	session.Values[reauthAtSessionKey] = time.Now().Add(-ReauthWindow - time.Second).Unix()
+	_, ok = RecentReauthentication(c)
+	assert.False(t, ok)
+}
@@ -2,30 +2,61 @@
 package middleware
 
 import (
+	"net/http"
+	"savvy/internal/repository"
+
 	"github.com/gorilla/sessions"
 	"github.com/labstack/echo/v4"
 )
 
-// Store is the global session store used for cookie-based sessions
-var Store *sessions.CookieStore
+// Store is the global session store. The application uses a DBStore; tests
+// and tools without a database fall back to a cookie store.
+var Store sessions.Store
 
-// InitSessionStore initializes the session store with the given secret and production flag
-func InitSessionStore(secret string, isProduction bool) {
-	Store = sessions.NewCookieStore([]byte(secret))
-	Store.Options = &sessions.Options{
+// sessionOptions are the cookie options of new sessions
+var sessionOptions sessions.Options
+
+// newSessionOptions returns the cookie options for the production flag
+func newSessionOptions(isProduction bool) sessions.Options {
+	return sessions.Options{
 		Path:     "/",
 		MaxAge:   86400 * 7, // 7 days
 		HttpOnly: true,
 		Secure:   isProduction, // true in production (HTTPS), false in development
-		SameSite: 2,            // Lax
+		SameSite: http.SameSiteLaxMode,
 	}
 }
 
+// InitSessionStore initializes a cookie-only session store with the given secret and production flag
+func InitSessionStore(secret string, isProduction bool) {
+	sessionOptions = newSessionOptions(isProduction)
+	cookieStore := sessions.NewCookieStore([]byte(secret))
+	options := sessionOptions
+	cookieStore.Options = &options
+	Store = cookieStore
+}
+
+// InitDBSessionStore initializes the database-backed session store
+func InitDBSessionStore(repo repository.UserSessionRepository, secret string, isProduction bool) {
+	sessionOptions = newSessionOptions(isProduction)
+	Store = NewDBStore(repo, sessionOptions, []byte(secret))
+}
+
 // GetSession retrieves the session for the current request
 func GetSession(c echo.Context) (*sessions.Session, error) {
 	return Store.Get(c.Request(), "session")
 }
 
+// CurrentSessionHash returns the stored hash of the current session, empty
+// if the session has not been saved yet
+func CurrentSessionHash(c echo.Context) string {
+	session, err := GetSession(c)
+	if err != nil || session.ID == "" {
+		return ""
+	}
+	return HashSessionID(session.ID)
+}
+
 // RegenerateSession invalidates the old session and creates a new one with a new ID.
 // This prevents session fixation attacks by ensuring a fresh session ID after authentication.
 // Returns the new session.
@@ -36,22 +67,17 @@ func RegenerateSession(c echo.Context) (*sessions.Session, error) {
 		return nil, err
 	}
 
-	// Mark old session for deletion (MaxAge = -1 deletes the cookie)
+	// Mark old session for deletion (MaxAge = -1 deletes the cookie and the stored session)
 	oldSession.Options.MaxAge = -1
 	if err := oldSession.Save(c.Request(), c.Response()); err != nil {
 		return nil, err
 	}
 
 	// Create a NEW session with a fresh ID
-	// Using Store.New() instead of Store.Get() forces a new session ID
+	// Using sessions.NewSession() instead of Store.Get() forces a new session ID
 	newSession := sessions.NewSession(Store, "session")
-	newSession.Options = &sessions.Options{
-		Path:     "/",
-		MaxAge:   86400 * 7, // 7 days
-		HttpOnly: true,
-		Secure:   Store.Options.Secure, // Inherit from store config
-		SameSite: 2,                    // Lax
-	}
+	options := sessionOptions
+	newSession.Options = &options
 	newSession.IsNew = true
 
 	return newSession, nil
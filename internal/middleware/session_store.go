@@ -0,0 +1,205 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"net"
+	"net/http"
+	"savvy/internal/models"
+	"savvy/internal/repository"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/securecookie"
+	"github.com/gorilla/sessions"
+	"gorm.io/gorm"
+)
+
+// sessionTouchInterval throttles last-seen writes for busy sessions
+const sessionTouchInterval = time.Minute
+
+// anonymousSessionLifetime limits sessions without a logged-in user, which
+// only carry flashes, OAuth state or a pending second login step. The
+// sessions.cleanup job removes them soon after, so requests to the login
+// page cannot fill the table.
+const anonymousSessionLifetime = 15 * time.Minute
+
+// clientIPContextKey carries the client IP (as resolved by Echo) to DBStore.Save
+const clientIPContextKey ContextKey = "client_ip"
+
+var sessionIDEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// DBStore is a sessions.Store that keeps the session values in the
+// user_sessions table. The cookie only carries the signed session ID, so
+// sessions can be listed per user and revoked server-side.
+type DBStore struct {
+	Codecs  []securecookie.Codec
+	Options *sessions.Options
+	repo    repository.UserSessionRepository
+}
+
+// NewDBStore creates a database-backed session store. keyPairs are used like
+// in sessions.NewCookieStore to sign the cookie and the stored values.
+func NewDBStore(repo repository.UserSessionRepository, options sessions.Options, keyPairs ...[]byte) *DBStore {
+	s := &DBStore{
+		Codecs:  securecookie.CodecsFromPairs(keyPairs...),
+		Options: &options,
+		repo:    repo,
+	}
+	for _, codec := range s.Codecs {
+		if sc, ok := codec.(*securecookie.SecureCookie); ok {
+			sc.MaxAge(options.MaxAge)
+		}
+	}
+	return s
+}
+
+// HashSessionID returns the hex-encoded SHA-256 hash under which a session is stored.
+func HashSessionID(id string) string {
+	sum := sha256.Sum256([]byte(id))
+	return hex.EncodeToString(sum[:])
+}
+
+// Get returns a session for the given name after adding it to the registry.
+func (s *DBStore) Get(r *http.Request, name string) (*sessions.Session, error) {
+	return sessions.GetRegistry(r).Get(s, name)
+}
+
+// New loads the session of the request's cookie. Unknown, revoked and expired
+// sessions as well as cookies of the former cookie store start a new, empty
+// session instead of failing the request.
+func (s *DBStore) New(r *http.Request, name string) (*sessions.Session, error) {
+	session := sessions.NewSession(s, name)
+	opts := *s.Options
+	session.Options = &opts
+	session.IsNew = true
+
+	cookie, err := r.Cookie(name)
+	if err != nil {
+		return session, nil
+	}
+
+	var id string
+	if err := securecookie.DecodeMulti(name, cookie.Value, &id, s.Codecs...); err != nil {
+		return session, nil
+	}
+
+	stored, err := s.repo.GetByHash(r.Context(), HashSessionID(id))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return session, nil
+		}
+		return session, err
+	}
+	if !stored.ExpiresAt.After(time.Now()) {
+		return session, nil
+	}
+
+	if err := securecookie.DecodeMulti(name, stored.Data, &session.Values, s.Codecs...); err != nil {
+		return session, nil
+	}
+	session.ID = id
+	session.IsNew = false
+	return session, nil
+}
+
+// Save stores the session values and sets the cookie with the session ID.
+// A MaxAge <= 0 deletes the session (logout). Sessions without a user expire
+// after anonymousSessionLifetime.
+func (s *DBStore) Save(r *http.Request, w http.ResponseWriter, session *sessions.Session) error {
+	if session.Options.MaxAge <= 0 {
+		if session.ID != "" {
+			if err := s.repo.DeleteByHash(r.Context(), HashSessionID(session.ID)); err != nil {
+				return err
+			}
+		}
+		http.SetCookie(w, sessions.NewCookie(session.Name(), "", session.Options))
+		return nil
+	}
+
+	if session.ID == "" {
+		session.ID = sessionIDEncoding.EncodeToString(securecookie.GenerateRandomKey(32))
+	}
+
+	data, err := securecookie.EncodeMulti(session.Name(), session.Values, s.Codecs...)
+	if err != nil {
+		return err
+	}
+
+	owner := sessionOwner(session)
+	lifetime := time.Duration(session.Options.MaxAge) * time.Second
+	if owner == nil {
+		lifetime = min(lifetime, anonymousSessionLifetime)
+	}
+
+	now := time.Now()
+	userAgent := r.UserAgent()
+	stored := &models.UserSession{
+		TokenHash:  HashSessionID(session.ID),
+		UserID:     owner,
+		Data:       data,
+		Device:     DescribeUserAgent(userAgent),
+		UserAgent:  userAgent,
+		IPAddress:  clientIP(r),
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(lifetime),
+	}
+	if err := s.repo.Save(r.Context(), stored); err != nil {
+		return err
+	}
+
+	encoded, err := securecookie.EncodeMulti(session.Name(), session.ID, s.Codecs...)
+	if err != nil {
+		return err
+	}
+	http.SetCookie(w, sessions.NewCookie(session.Name(), encoded, session.Options))
+	return nil
+}
+
+// Touch records that the session was used, at most once per sessionTouchInterval.
+func (s *DBStore) Touch(r *http.Request, session *sessions.Session) error {
+	if session.ID == "" {
+		return nil
+	}
+	return s.repo.Touch(r.Context(), HashSessionID(session.ID), clientIP(r), time.Now(), sessionTouchInterval)
+}
+
+// CountActive returns the number of sessions with a logged-in user.
+func (s *DBStore) CountActive(ctx context.Context) (int64, error) {
+	return s.repo.CountActive(ctx)
+}
+
+// DeleteExpired removes expired sessions and returns how many there were.
+func (s *DBStore) DeleteExpired(ctx context.Context) (int64, error) {
+	return s.repo.DeleteExpired(ctx)
+}
+
+// sessionOwner returns the user a session is listed for. While impersonating
+// that is the admin, so the impersonated user cannot see or end it.
+func sessionOwner(session *sessions.Session) *uuid.UUID {
+	userIDStr, _ := session.Values["impersonated_by"].(string)
+	if userIDStr == "" {
+		userIDStr, _ = session.Values["user_id"].(string)
+	}
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		return nil
+	}
+	return &userID
+}
+
+// clientIP returns the IP resolved by SessionTracking, or the remote address
+// for requests that did not pass the middleware
+func clientIP(r *http.Request) string {
+	if ip, ok := r.Context().Value(clientIPContextKey).(string); ok && ip != "" {
+		return ip
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,284 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/sessions"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"savvy/internal/models"
+)
+
+// memorySessionRepository is an in-memory UserSessionRepository
+type memorySessionRepository struct {
+	mu       sync.Mutex
+	sessions map[string]models.UserSession
+}
+
+func newMemorySessionRepository() *memorySessionRepository {
+	return &memorySessionRepository{sessions: make(map[string]models.UserSession)}
+}
+
+func (r *memorySessionRepository) GetByHash(_ context.Context, tokenHash string) (*models.UserSession, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	session, ok := r.sessions[tokenHash]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &session, nil
+}
+
+func (r *memorySessionRepository) Save(_ context.Context, session *models.UserSession) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if existing, ok := r.sessions[session.TokenHash]; ok {
+		session.ID = existing.ID
+		session.CreatedAt = existing.CreatedAt
+	} else {
+		session.ID = uuid.New()
+		session.CreatedAt = time.Now()
+	}
+	r.sessions[session.TokenHash] = *session
+	return nil
+}
+
+func (r *memorySessionRepository) DeleteByHash(_ context.Context, tokenHash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.sessions, tokenHash)
+	return nil
+}
+
+func (r *memorySessionRepository) Touch(_ context.Context, tokenHash, ipAddress string, at time.Time, interval time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	session, ok := r.sessions[tokenHash]
+	if ok && session.LastSeenAt.Before(at.Add(-interval)) {
+		session.LastSeenAt = at
+		session.IPAddress = ipAddress
+		r.sessions[tokenHash] = session
+	}
+	return nil
+}
+
+func (r *memorySessionRepository) GetActiveByUserID(_ context.Context, userID uuid.UUID) ([]models.UserSession, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var result []models.UserSession
+	for _, session := range r.sessions {
+		if session.UserID != nil && *session.UserID == userID && session.ExpiresAt.After(time.Now()) {
+			result = append(result, session)
+		}
+	}
+	return result, nil
+}
+
+func (r *memorySessionRepository) Delete(_ context.Context, id, userID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for hash, session := range r.sessions {
+		if session.ID == id && session.UserID != nil && *session.UserID == userID {
+			delete(r.sessions, hash)
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+func (r *memorySessionRepository) DeleteByUserID(_ context.Context, userID uuid.UUID) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var count int64
+	for hash, session := range r.sessions {
+		if session.UserID != nil && *session.UserID == userID {
+			delete(r.sessions, hash)
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *memorySessionRepository) CountActive(_ context.Context) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var count int64
+	for _, session := range r.sessions {
+		if session.UserID != nil && session.ExpiresAt.After(time.Now()) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *memorySessionRepository) DeleteExpired(_ context.Context) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var count int64
+	for hash, session := range r.sessions {
+		if !session.ExpiresAt.After(time.Now()) {
+			delete(r.sessions, hash)
+			count++
+		}
+	}
+	return count, nil
+}
+
+func newTestDBStore(repo *memorySessionRepository) *DBStore {
+	return NewDBStore(repo, newSessionOptions(false), []byte("test-secret-with-at-least-32-characters"))
+}
+
+// requestWithCookies builds a request carrying the cookies set by a previous response
+func requestWithCookies(rec *httptest.ResponseRecorder) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10.15; rv:131.0) Gecko/20100101 Firefox/131.0")
+	for _, cookie := range rec.Result().Cookies() {
+		req.AddCookie(cookie)
+	}
+	return req
+}
+
+func TestDBStore_SaveAndLoad(t *testing.T) {
+	repo := newMemorySessionRepository()
+	store := newTestDBStore(repo)
+	userID := uuid.New()
+
+	req := requestWithCookies(httptest.NewRecorder())
+	rec := httptest.NewRecorder()
+	session, err := store.Get(req, "session")
+	require.NoError(t, err)
+	assert.True(t, session.IsNew)
+	session.Values["user_id"] = userID.String()
+	require.NoError(t, session.Save(req, rec))
+
+	// The values are stored server-side, the cookie only carries the ID
+	require.Len(t, repo.sessions, 1)
+	stored := repo.sessions[HashSessionID(session.ID)]
+	require.NotNil(t, stored.UserID)
+	assert.Equal(t, userID, *stored.UserID)
+	assert.Equal(t, "Firefox · macOS", stored.Device)
+	assert.True(t, stored.ExpiresAt.After(time.Now().Add(6*24*time.Hour)))
+
+	loaded, err := store.Get(requestWithCookies(rec), "session")
+	require.NoError(t, err)
+	assert.False(t, loaded.IsNew)
+	assert.Equal(t, userID.String(), loaded.Values["user_id"])
+}
+
+func TestDBStore_AnonymousSessionExpiresSoon(t *testing.T) {
+	repo := newMemorySessionRepository()
+	store := newTestDBStore(repo)
+
+	req := requestWithCookies(httptest.NewRecorder())
+	rec := httptest.NewRecorder()
+	session, err := store.Get(req, "session")
+	require.NoError(t, err)
+	session.AddFlash("Check your inbox")
+	require.NoError(t, session.Save(req, rec))
+
+	stored := repo.sessions[HashSessionID(session.ID)]
+	assert.Nil(t, stored.UserID)
+	assert.False(t, stored.ExpiresAt.After(time.Now().Add(anonymousSessionLifetime)), "Login page visits do not keep rows for days")
+
+	// Logging in keeps the session for the full MaxAge
+	loaded, err := store.Get(requestWithCookies(rec), "session")
+	require.NoError(t, err)
+	loaded.Values["user_id"] = uuid.NewString()
+	require.NoError(t, loaded.Save(req, httptest.NewRecorder()))
+	stored = repo.sessions[HashSessionID(session.ID)]
+	assert.True(t, stored.ExpiresAt.After(time.Now().Add(6*24*time.Hour)))
+}
+
+func TestDBStore_RevokedSession(t *testing.T) {
+	repo := newMemorySessionRepository()
+	store := newTestDBStore(repo)
+
+	req := requestWithCookies(httptest.NewRecorder())
+	rec := httptest.NewRecorder()
+	session, err := store.Get(req, "session")
+	require.NoError(t, err)
+	session.Values["user_id"] = uuid.NewString()
+	require.NoError(t, session.Save(req, rec))
+
+	// Logged out from another device
+	require.NoError(t, repo.DeleteByHash(context.Background(), HashSessionID(session.ID)))
+
+	loaded, err := store.Get(requestWithCookies(rec), "session")
+	require.NoError(t, err)
+	assert.True(t, loaded.IsNew)
+	assert.Empty(t, loaded.Values)
+}
+
+func TestDBStore_ForeignCookie(t *testing.T) {
+	store := newTestDBStore(newMemorySessionRepository())
+
+	// Cookie of the former cookie-only store
+	cookieStore := sessions.NewCookieStore([]byte("test-secret-with-at-least-32-characters"))
+	req := requestWithCookies(httptest.NewRecorder())
+	rec := httptest.NewRecorder()
+	old, err := cookieStore.Get(req, "session")
+	require.NoError(t, err)
+	old.Values["user_id"] = uuid.NewString()
+	require.NoError(t, old.Save(req, rec))
+
+	loaded, err := store.Get(requestWithCookies(rec), "session")
+	require.NoError(t, err)
+	assert.True(t, loaded.IsNew)
+	assert.Empty(t, loaded.Values)
+}
+
+func TestDBStore_Delete(t *testing.T) {
+	repo := newMemorySessionRepository()
+	store := newTestDBStore(repo)
+
+	req := requestWithCookies(httptest.NewRecorder())
+	session, err := store.Get(req, "session")
+	require.NoError(t, err)
+	require.NoError(t, session.Save(req, httptest.NewRecorder()))
+	require.Len(t, repo.sessions, 1)
+
+	session.Options.MaxAge = -1
+	require.NoError(t, session.Save(req, httptest.NewRecorder()))
+	assert.Empty(t, repo.sessions)
+}
+
+func TestDBStore_ImpersonationOwner(t *testing.T) {
+	repo := newMemorySessionRepository()
+	store := newTestDBStore(repo)
+	adminID := uuid.New()
+
+	req := requestWithCookies(httptest.NewRecorder())
+	session, err := store.Get(req, "session")
+	require.NoError(t, err)
+	session.Values["user_id"] = uuid.NewString()
+	session.Values["impersonated_by"] = adminID.String()
+	require.NoError(t, session.Save(req, httptest.NewRecorder()))
+
+	stored := repo.sessions[HashSessionID(session.ID)]
+	require.NotNil(t, stored.UserID)
+	assert.Equal(t, adminID, *stored.UserID)
+}
+
+func TestDescribeUserAgent(t *testing.T) {
+	tests := []struct {
+		userAgent string
+		want      string
+	}{
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/130.0.0.0 Safari/537.36 Edg/130.0.0.0", "Edge · Windows"},
+		{"Mozilla/5.0 (iPhone; CPU iPhone OS 18_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/18.0 Mobile/15E148 Safari/604.1", "Safari · iPhone"},
+		{"Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/130.0.0.0 Mobile Safari/537.36", "Chrome · Android"},
+		{"Mozilla/5.0 (X11; Linux x86_64; rv:131.0) Gecko/20100101 Firefox/131.0", "Firefox · Linux"},
+		{"curl/8.5.0", ""},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, DescribeUserAgent(tt.userAgent), tt.userAgent)
+	}
+}
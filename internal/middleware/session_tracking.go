@@ -2,39 +2,28 @@
 package middleware
 
 import (
+	"context"
 	"savvy/internal/metrics"
-	"sync"
 
 	"github.com/labstack/echo/v4"
 )
 
-var (
-	// activeSessions tracks currently active user sessions
-	activeSessions = make(map[string]bool)
-	sessionsMutex  sync.RWMutex
-)
-
-// SessionTracking tracks active user sessions for metrics
+// SessionTracking records the client IP and last request of the current session
 func SessionTracking(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
-		// Get session using the existing GetSession function (returns "session")
-		sess, err := GetSession(c)
-		if err != nil {
+		// DBStore.Save only sees the http.Request, remember the IP as resolved by Echo
+		ctx := context.WithValue(c.Request().Context(), clientIPContextKey, c.RealIP())
+		c.SetRequest(c.Request().WithContext(ctx))
+
+		store, ok := Store.(*DBStore)
+		if !ok {
 			return next(c)
 		}
 
-		// Check if user is logged in
-		userID := sess.Values["user_id"]
-		if userID != nil {
-			sessionID, ok := userID.(string)
-			if ok && sessionID != "" {
-				// Track this session
-				sessionsMutex.Lock()
-				if !activeSessions[sessionID] {
-					activeSessions[sessionID] = true
-					metrics.IncrementActiveSessions()
-				}
-				sessionsMutex.Unlock()
+		sess, err := GetSession(c)
+		if err == nil && !sess.IsNew {
+			if err := store.Touch(c.Request(), sess); err != nil {
+				c.Logger().Warnf("Failed to record session activity: %v", err)
 			}
 		}
 
@@ -42,19 +31,28 @@ func SessionTracking(next echo.HandlerFunc) echo.HandlerFunc {
 	}
 }
 
-// CleanupInactiveSessions removes sessions that haven't been seen in a while
-// This should be called periodically (e.g., every 5 minutes)
-func CleanupInactiveSessions() {
-	sessionsMutex.Lock()
-	defer sessionsMutex.Unlock()
-
-	// Clear all and let active users re-add themselves
-	count := len(activeSessions)
-	activeSessions = make(map[string]bool)
+// UpdateSessionMetrics sets the active_sessions gauge to the number of
+// sessions with a logged-in user.
+func UpdateSessionMetrics(ctx context.Context) error {
+	store, ok := Store.(*DBStore)
+	if !ok {
+		return nil
+	}
 
-	// Reset the gauge to 0
-	metrics.SetActiveSessions(0)
+	count, err := store.CountActive(ctx)
+	if err != nil {
+		return err
+	}
+	metrics.SetActiveSessions(float64(count))
+	return nil
+}
 
-	// Sessions will be re-counted as users make requests
-	_ = count
+// CleanupExpiredSessions removes expired sessions from the database and
+// returns how many there were. Cookie sessions expire on their own.
+func CleanupExpiredSessions(ctx context.Context) (int64, error) {
+	store, ok := Store.(*DBStore)
+	if !ok {
+		return 0, nil
+	}
+	return store.DeleteExpired(ctx)
 }
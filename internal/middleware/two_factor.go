@@ -0,0 +1,134 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"savvy/internal/config"
+	"savvy/internal/models"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// TwoFactorLoginWindow is how long the second login step may take after the
+// password was accepted
+const TwoFactorLoginWindow = 5 * time.Minute
+
+// MaxTwoFactorAttempts is the number of wrong codes after which the password
+// has to be entered again
+const MaxTwoFactorAttempts = 5
+
+// TwoFactorSetupPath is where admins are sent to enroll when 2FA is required
+const TwoFactorSetupPath = "/account/two-factor"
+
+// PasskeyCounter counts the passkeys of a user, which also count as second
+// factor while WebAuthn is configured
+type PasskeyCounter interface {
+	Enabled() bool
+	CountCredentials(ctx context.Context, userID uuid.UUID) (int64, error)
+}
+
+const (
+	pendingTwoFactorUserKey     = "pending_2fa_user_id"
+	pendingTwoFactorAtKey       = "pending_2fa_at"
+	pendingTwoFactorAttemptsKey = "pending_2fa_attempts"
+)
+
+// SetPendingTwoFactor records in the (not yet authenticated) session that the
+// user entered the correct password and still has to enter a TOTP code.
+func SetPendingTwoFactor(c echo.Context, userID uuid.UUID) error {
+	session, err := GetSession(c)
+	if err != nil {
+		return err
+	}
+	session.Values[pendingTwoFactorUserKey] = userID.String()
+	session.Values[pendingTwoFactorAtKey] = time.Now().Unix()
+	session.Values[pendingTwoFactorAttemptsKey] = 0
+	return session.Save(c.Request(), c.Response())
+}
+
+// PendingTwoFactor returns the user waiting for the second login step if the
+// password was entered within the TwoFactorLoginWindow and attempts are left.
+func PendingTwoFactor(c echo.Context) (uuid.UUID, bool) {
+	session, err := GetSession(c)
+	if err != nil {
+		return uuid.Nil, false
+	}
+	at, ok := session.Values[pendingTwoFactorAtKey].(int64)
+	if !ok || time.Since(time.Unix(at, 0)) >= TwoFactorLoginWindow {
+		return uuid.Nil, false
+	}
+	if attempts, _ := session.Values[pendingTwoFactorAttemptsKey].(int); attempts >= MaxTwoFactorAttempts {
+		return uuid.Nil, false
+	}
+	userIDStr, _ := session.Values[pendingTwoFactorUserKey].(string)
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		return uuid.Nil, false
+	}
+	return userID, true
+}
+
+// RecordFailedTwoFactor counts a wrong code and returns the attempts left.
+func RecordFailedTwoFactor(c echo.Context) (int, error) {
+	session, err := GetSession(c)
+	if err != nil {
+		return 0, err
+	}
+	attempts, _ := session.Values[pendingTwoFactorAttemptsKey].(int)
+	attempts++
+	session.Values[pendingTwoFactorAttemptsKey] = attempts
+	if err := session.Save(c.Request(), c.Response()); err != nil {
+		return 0, err
+	}
+	return MaxTwoFactorAttempts - attempts, nil
+}
+
+// RequireAdminTwoFactor sends admins with a local account to the 2FA setup
+// page until they enabled TOTP or registered a passkey, if REQUIRE_ADMIN_2FA
+// is set. Passkeys only count while WebAuthn is configured. OIDC admins are exempt, their provider is responsible for the
+// second factor. API requests, by session or API token, are refused with 403
+// instead, clients cannot follow the redirect.
+func RequireAdminTwoFactor(cfg *config.Config, passkeys PasskeyCounter) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if !cfg.RequireAdmin2FA {
+				return next(c)
+			}
+
+			user, ok := c.Get("current_user").(*models.User)
+			if !ok || !user.IsAdmin() || user.IsOAuthUser() || user.HasTwoFactor() {
+				return next(c)
+			}
+			// The admin passed this check before impersonating
+			if c.Get("is_impersonating") != nil {
+				return next(c)
+			}
+			if strings.HasPrefix(c.Request().URL.Path, TwoFactorSetupPath) {
+				return next(c)
+			}
+			// Without WebAuthn configuration the login skips the passkey step,
+			// so passkeys do not count
+			if passkeys.Enabled() {
+				count, err := passkeys.CountCredentials(c.Request().Context(), user.ID)
+				if err != nil {
+					return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load passkeys")
+				}
+				if count > 0 {
+					return next(c)
+				}
+			}
+
+			if c.Get("api_token") != nil || strings.HasPrefix(c.Request().URL.Path, "/api/v1/") {
+				return echo.NewHTTPError(http.StatusForbidden, "two-factor authentication is required for admins, enable it at "+TwoFactorSetupPath)
+			}
+			if c.Request().Header.Get("HX-Request") == "true" {
+				c.Response().Header().Set("HX-Redirect", TwoFactorSetupPath)
+				return c.NoContent(http.StatusOK)
+			}
+			return c.Redirect(http.StatusSeeOther, TwoFactorSetupPath)
+		}
+	}
+}
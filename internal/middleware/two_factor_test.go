@@ -0,0 +1,194 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"savvy/internal/config"
+	"savvy/internal/models"
+)
+
+func TestPendingTwoFactor(t *testing.T) {
+	InitSessionStore("test-secret-with-at-least-32-characters", false)
+	e := echo.New()
+	userID := uuid.New()
+
+	rec := httptest.NewRecorder()
+	c := e.NewContext(httptest.NewRequest(http.MethodPost, "/auth/login", nil), rec)
+	_, ok := PendingTwoFactor(c)
+	assert.False(t, ok)
+	require.NoError(t, SetPendingTwoFactor(c, userID))
+
+	// Next request carries the session cookie
+	req := httptest.NewRequest(http.MethodPost, "/auth/login/two-factor", nil)
+	for _, cookie := range rec.Result().Cookies() {
+		req.AddCookie(cookie)
+	}
+	c = e.NewContext(req, httptest.NewRecorder())
+	pending, ok := PendingTwoFactor(c)
+	assert.True(t, ok)
+	assert.Equal(t, userID, pending)
+
+	// The password has to be entered again after too many wrong codes
+	for i := 1; i < MaxTwoFactorAttempts; i++ {
+		remaining, err := RecordFailedTwoFactor(c)
+		require.NoError(t, err)
+		assert.Equal(t, MaxTwoFactorAttempts-i, remaining)
+		_, ok = PendingTwoFactor(c)
+		assert.True(t, ok)
+	}
+	remaining, err := RecordFailedTwoFactor(c)
+	require.NoError(t, err)
+	assert.Zero(t, remaining)
+	_, ok = PendingTwoFactor(c)
+	assert.False(t, ok)
+
+	// ... and after the login window
+	require.NoError(t, SetPendingTwoFactor(c, userID))
+	session, err := GetSession(c)
+	require.NoError(t, err)
+	session.Values[pendingTwoFactorAtKey] = time.Now().Add(-TwoFactorLoginWindow - time.Second).Unix()
+	_, ok = PendingTwoFactor(c)
+	assert.False(t, ok)
+}
+
+// passkeyCount is a PasskeyCounter that returns the same count for every user
+type passkeyCount int64
+
+func (n passkeyCount) Enabled() bool { return true }
+
+func (n passkeyCount) CountCredentials(context.Context, uuid.UUID) (int64, error) {
+	return int64(n), nil
+}
+
+// unconfiguredPasskeys is a PasskeyCounter without WebAuthn configuration
+type unconfiguredPasskeys struct{ passkeyCount }
+
+func (unconfiguredPasskeys) Enabled() bool { return false }
+
+func TestRequireAdminTwoFactor(t *testing.T) {
+	enabledAt := time.Now()
+	tests := []struct {
+		name          string
+		required      bool
+		user          *models.User
+		path          string
+		impersonating bool
+		passkeys      passkeyCount
+		wantRedirect  bool
+	}{
+		{name: "not required", required: false, user: &models.User{Role: "admin"}, path: "/", wantRedirect: false},
+		{name: "admin without 2FA", required: true, user: &models.User{Role: "admin"}, path: "/", wantRedirect: true},
+		{name: "admin with 2FA", required: true, user: &models.User{Role: "admin", TOTPSecret: "JBSWY3DPEHPK3PXP", TOTPEnabledAt: &enabledAt}, path: "/", wantRedirect: false},
+		{name: "admin with passkey", required: true, user: &models.User{Role: "admin"}, path: "/", passkeys: 1, wantRedirect: false},
+		{name: "setup page", required: true, user: &models.User{Role: "admin"}, path: "/account/two-factor", wantRedirect: false},
+		{name: "regular user", required: true, user: &models.User{Role: "user"}, path: "/", wantRedirect: false},
+		{name: "OIDC admin", required: true, user: &models.User{Role: "admin", AuthProvider: "oauth"}, path: "/", wantRedirect: false},
+		{name: "impersonating", required: true, user: &models.User{Role: "user"}, path: "/", impersonating: true, wantRedirect: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			rec := httptest.NewRecorder()
+			c := e.NewContext(httptest.NewRequest(http.MethodGet, tt.path, nil), rec)
+			c.Set("current_user", tt.user)
+			if tt.impersonating {
+				c.Set("is_impersonating", true)
+			}
+
+			handler := RequireAdminTwoFactor(&config.Config{RequireAdmin2FA: tt.required}, tt.passkeys)(func(c echo.Context) error {
+				return c.String(http.StatusOK, "ok")
+			})
+			require.NoError(t, handler(c))
+
+			if tt.wantRedirect {
+				assert.Equal(t, http.StatusSeeOther, rec.Code)
+				assert.Equal(t, TwoFactorSetupPath, rec.Header().Get("Location"))
+			} else {
+				assert.Equal(t, http.StatusOK, rec.Code)
+			}
+		})
+	}
+}
+
+func TestRequireAdminTwoFactor_API(t *testing.T) {
+	cfg := &config.Config{RequireAdmin2FA: true}
+	admin := &models.User{Role: "admin"}
+
+	tests := []struct {
+		name     string
+		path     string
+		apiToken bool
+	}{
+		{name: "session", path: "/api/v1/cards"},
+		{name: "API token", path: "/api/v1/gift-cards", apiToken: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			rec := httptest.NewRecorder()
+			c := e.NewContext(httptest.NewRequest(http.MethodGet, tt.path, nil), rec)
+			c.Set("current_user", admin)
+			if tt.apiToken {
+				c.Set("api_token", &models.APIToken{UserID: admin.ID, User: admin})
+			}
+
+			called := false
+			handler := RequireAdminTwoFactor(cfg, passkeyCount(0))(func(c echo.Context) error {
+				called = true
+				return c.NoContent(http.StatusOK)
+			})
+			err := handler(c)
+
+			var httpErr *echo.HTTPError
+			require.ErrorAs(t, err, &httpErr)
+			assert.Equal(t, http.StatusForbidden, httpErr.Code)
+			assert.False(t, called)
+			assert.Empty(t, rec.Header().Get("Location"), "API clients are not redirected")
+
+			// Enrolled admins keep their access
+			called = false
+			require.NoError(t, RequireAdminTwoFactor(cfg, passkeyCount(1))(func(c echo.Context) error {
+				called = true
+				return nil
+			})(c))
+			assert.True(t, called)
+		})
+	}
+}
+
+func TestRequireAdminTwoFactor_WebAuthnDisabled(t *testing.T) {
+	cfg := &config.Config{RequireAdmin2FA: true}
+	admin := &models.User{Role: "admin"}
+	passkeys := unconfiguredPasskeys{passkeyCount: 1}
+
+	// The login skipped the passkey step, the registered passkey is no second factor
+	e := echo.New()
+	rec := httptest.NewRecorder()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+	c.Set("current_user", admin)
+	require.NoError(t, RequireAdminTwoFactor(cfg, passkeys)(func(c echo.Context) error {
+		return c.String(http.StatusOK, "ok")
+	})(c))
+	assert.Equal(t, http.StatusSeeOther, rec.Code)
+	assert.Equal(t, TwoFactorSetupPath, rec.Header().Get("Location"))
+
+	c = e.NewContext(httptest.NewRequest(http.MethodGet, "/api/v1/cards", nil), httptest.NewRecorder())
+	c.Set("current_user", admin)
+	c.Set("api_token", &models.APIToken{UserID: admin.ID, User: admin})
+	err := RequireAdminTwoFactor(cfg, passkeys)(func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})(c)
+	var httpErr *echo.HTTPError
+	require.ErrorAs(t, err, &httpErr)
+	assert.Equal(t, http.StatusForbidden, httpErr.Code)
+}
@@ -74,6 +74,22 @@ func GetMigrations() []*gormigrate.Migration {
 		addNotifications(),
 		addNotificationsSoftDelete(),
 		fixShareUniqueConstraintsForSoftDelete(),
+		addAPITokens(),
+		convertMoneyToMinorUnits(),
+		addGiftCardTransactionKinds(),
+		addGiftCardTransactionRevisions(),
+		addExchangeRates(),
+		addUserSessions(),
+		addTwoFactorAuth(),
+		addWebAuthnCredentials(),
+		addEmailVerification(),
+		addLoginThrottles(),
+		addRateLimitCounters(),
+		addNotificationDelivery(),
+		addJobs(),
+		addExpiryReminders(),
+		addItemStatusChanges(),
+		addCalendarFeeds(),
 	}
 }
 
@@ -1269,3 +1285,1198 @@ func fixShareUniqueConstraintsForSoftDelete() *gormigrate.Migration {
 	}
 }
 
+// addAPITokens creates the api_tokens table for personal access tokens
+// Migration 000018 - 2026-10-16
+func addAPITokens() *gormigrate.Migration {
+	return &gormigrate.Migration{
+		ID: "202610160018_add_api_tokens",
+		Migrate: func(tx *gorm.DB) error {
+			// Define APIToken struct for migration
+			type APIToken struct {
+				ID         uuid.UUID      `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+				UserID     uuid.UUID      `gorm:"type:uuid;not null;index:idx_api_tokens_user_id"`
+				Name       string         `gorm:"type:varchar(100);not null"`
+				TokenHash  string         `gorm:"type:char(64);not null;uniqueIndex:idx_api_tokens_token_hash"`
+				Prefix     string         `gorm:"type:varchar(16);not null"`
+				Scopes     string         `gorm:"type:text;not null"`
+				ExpiresAt  *time.Time     `gorm:"type:timestamp with time zone"`
+				LastUsedAt *time.Time     `gorm:"type:timestamp with time zone"`
+				CreatedAt  time.Time      `gorm:"type:timestamp with time zone;default:CURRENT_TIMESTAMP"`
+				UpdatedAt  time.Time      `gorm:"type:timestamp with time zone;default:CURRENT_TIMESTAMP"`
+				DeletedAt  gorm.DeletedAt `gorm:"index:idx_api_tokens_deleted_at"`
+			}
+
+			// Create table
+			if err := tx.AutoMigrate(&APIToken{}); err != nil {
+				return err
+			}
+
+			// Tokens are removed together with their user
+			if err := tx.Exec(`
+				ALTER TABLE api_tokens
+				ADD CONSTRAINT fk_api_tokens_user
+				FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+			`).Error; err != nil {
+				return err
+			}
+
+			// Add comments
+			if err := tx.Exec(`
+				COMMENT ON TABLE api_tokens IS 'Personal access tokens for the REST API (revoked = soft deleted)';
+				COMMENT ON COLUMN api_tokens.token_hash IS 'Hex-encoded SHA-256 of the token, the plaintext is never stored';
+				COMMENT ON COLUMN api_tokens.scopes IS 'Space-separated resource:level pairs, e.g. cards:read gift_cards:write';
+			`).Error; err != nil {
+				return err
+			}
+
+			return nil
+		},
+		Rollback: func(tx *gorm.DB) error {
+			return tx.Exec("DROP TABLE IF EXISTS api_tokens CASCADE").Error
+		},
+	}
+}
+
+// convertMoneyToMinorUnits stores all money columns as BIGINT minor units of
+// their currency (e.g. 1250 for 12.50 CHF, 500 for 500 JPY) instead of NUMERIC
+// and rewrites the balance triggers to calculate with integers.
+// Migration 000019 - 2026-10-16
+func convertMoneyToMinorUnits() *gormigrate.Migration {
+	return &gormigrate.Migration{
+		ID: "202610160019_money_minor_units",
+		Migrate: func(tx *gorm.DB) error {
+			// Number of decimal places per currency, must match internal/money
+			if err := createFunction(tx, `
+				CREATE OR REPLACE FUNCTION currency_exponent(code TEXT)
+				RETURNS INT AS $$
+					SELECT CASE UPPER(code)
+						WHEN 'BIF' THEN 0 WHEN 'CLP' THEN 0 WHEN 'DJF' THEN 0 WHEN 'GNF' THEN 0
+						WHEN 'ISK' THEN 0 WHEN 'JPY' THEN 0 WHEN 'KMF' THEN 0 WHEN 'KRW' THEN 0
+						WHEN 'PYG' THEN 0 WHEN 'RWF' THEN 0 WHEN 'UGX' THEN 0 WHEN 'UYI' THEN 0
+						WHEN 'VND' THEN 0 WHEN 'VUV' THEN 0 WHEN 'XAF' THEN 0 WHEN 'XOF' THEN 0
+						WHEN 'XPF' THEN 0
+						WHEN 'BHD' THEN 3 WHEN 'IQD' THEN 3 WHEN 'JOD' THEN 3 WHEN 'KWD' THEN 3
+						WHEN 'LYD' THEN 3 WHEN 'OMR' THEN 3 WHEN 'TND' THEN 3
+						WHEN 'CLF' THEN 4 WHEN 'UYW' THEN 4
+						ELSE 2
+					END;
+				$$ LANGUAGE sql IMMUTABLE;
+			`); err != nil {
+				return err
+			}
+
+			// The update trigger references initial_balance in its WHEN clause,
+			// which blocks changing the column type
+			if err := dropTrigger(tx, "trigger_auto_set_gift_card_current_balance_update", "gift_cards"); err != nil {
+				return err
+			}
+
+			// Transactions take the currency of their gift card. USING cannot
+			// contain subqueries, so convert via a new column with the balance
+			// triggers disabled (they would mix units mid-conversion).
+			if err := tx.Exec(`
+				ALTER TABLE gift_card_transactions DISABLE TRIGGER USER;
+				ALTER TABLE gift_card_transactions ADD COLUMN amount_minor BIGINT;
+				UPDATE gift_card_transactions t
+				SET amount_minor = ROUND(t.amount * power(10::numeric, currency_exponent(
+					(SELECT gc.currency FROM gift_cards gc WHERE gc.id = t.gift_card_id)
+				)));
+				ALTER TABLE gift_card_transactions DROP COLUMN amount;
+				ALTER TABLE gift_card_transactions RENAME COLUMN amount_minor TO amount;
+				ALTER TABLE gift_card_transactions ALTER COLUMN amount SET NOT NULL;
+				ALTER TABLE gift_card_transactions ENABLE TRIGGER USER;
+			`).Error; err != nil {
+				return err
+			}
+
+			if err := tx.Exec(`
+				ALTER TABLE gift_cards
+					ALTER COLUMN initial_balance TYPE BIGINT
+						USING ROUND(initial_balance * power(10::numeric, currency_exponent(currency))),
+					ALTER COLUMN current_balance TYPE BIGINT
+						USING ROUND(current_balance * power(10::numeric, currency_exponent(currency)));
+			`).Error; err != nil {
+				return err
+			}
+
+			// Vouchers are always CHF; percentages and multipliers are kept in hundredths
+			if err := tx.Exec(`
+				ALTER TABLE vouchers ALTER COLUMN min_purchase_amount DROP DEFAULT;
+				ALTER TABLE vouchers
+					ALTER COLUMN value TYPE BIGINT USING ROUND(value * 100),
+					ALTER COLUMN min_purchase_amount TYPE BIGINT USING ROUND(min_purchase_amount * 100);
+				ALTER TABLE vouchers ALTER COLUMN min_purchase_amount SET DEFAULT 0;
+			`).Error; err != nil {
+				return err
+			}
+
+			if err := createMinorUnitBalanceFunctions(tx); err != nil {
+				return err
+			}
+
+			if err := createAutoSetBalanceUpdateTrigger(tx); err != nil {
+				return err
+			}
+
+			// Recalculate all balances from the converted amounts
+			if err := tx.Exec(`
+				UPDATE gift_cards
+				SET current_balance = initial_balance - (
+					SELECT COALESCE(SUM(amount), 0)
+					FROM gift_card_transactions
+					WHERE gift_card_transactions.gift_card_id = gift_cards.id
+					  AND gift_card_transactions.deleted_at IS NULL
+				);
+			`).Error; err != nil {
+				return err
+			}
+
+			return addComment(tx, `
+				COMMENT ON FUNCTION currency_exponent(TEXT) IS 'Number of decimal places of the ISO 4217 minor unit (default 2)';
+				COMMENT ON COLUMN gift_cards.initial_balance IS 'Minor units of gift_cards.currency, e.g. 1250 = 12.50 CHF';
+				COMMENT ON COLUMN gift_cards.current_balance IS 'Cached balance in minor units calculated as initial_balance - SUM(transactions.amount). Auto-updated by trigger on gift_card_transactions.';
+				COMMENT ON COLUMN gift_card_transactions.amount IS 'Minor units of the gift card currency';
+				COMMENT ON COLUMN vouchers.value IS 'Rappen for fixed_amount, hundredths of the percentage or multiplier otherwise';
+				COMMENT ON COLUMN vouchers.min_purchase_amount IS 'Minimum purchase in Rappen';
+			`)
+		},
+		Rollback: func(tx *gorm.DB) error {
+			if err := dropTrigger(tx, "trigger_auto_set_gift_card_current_balance_update", "gift_cards"); err != nil {
+				return err
+			}
+
+			if err := tx.Exec(`
+				ALTER TABLE vouchers ALTER COLUMN min_purchase_amount DROP DEFAULT;
+				ALTER TABLE vouchers
+					ALTER COLUMN value TYPE NUMERIC USING value / 100.0,
+					ALTER COLUMN min_purchase_amount TYPE NUMERIC USING min_purchase_amount / 100.0;
+				ALTER TABLE vouchers ALTER COLUMN min_purchase_amount SET DEFAULT 0;
+			`).Error; err != nil {
+				return err
+			}
+
+			if err := tx.Exec(`
+				ALTER TABLE gift_card_transactions DISABLE TRIGGER USER;
+				ALTER TABLE gift_card_transactions ADD COLUMN amount_decimal NUMERIC;
+				UPDATE gift_card_transactions t
+				SET amount_decimal = t.amount / power(10::numeric, currency_exponent(
+					(SELECT gc.currency FROM gift_cards gc WHERE gc.id = t.gift_card_id)
+				));
+				ALTER TABLE gift_card_transactions DROP COLUMN amount;
+				ALTER TABLE gift_card_transactions RENAME COLUMN amount_decimal TO amount;
+				ALTER TABLE gift_card_transactions ALTER COLUMN amount SET NOT NULL;
+				ALTER TABLE gift_card_transactions ENABLE TRIGGER USER;
+			`).Error; err != nil {
+				return err
+			}
+
+			if err := tx.Exec(`
+				ALTER TABLE gift_cards
+					ALTER COLUMN initial_balance TYPE NUMERIC
+						USING initial_balance / power(10::numeric, currency_exponent(currency)),
+					ALTER COLUMN current_balance TYPE DECIMAL(10,2)
+						USING current_balance / power(10::numeric, currency_exponent(currency));
+			`).Error; err != nil {
+				return err
+			}
+
+			// Restore the DECIMAL versions of the trigger functions
+			if err := createFunction(tx, `
+				CREATE OR REPLACE FUNCTION check_gift_card_balance()
+				RETURNS TRIGGER AS $$
+				DECLARE
+					current_balance DECIMAL(10,2);
+					initial_balance DECIMAL(10,2);
+				BEGIN
+					SELECT gc.initial_balance INTO initial_balance
+					FROM gift_cards gc
+					WHERE gc.id = NEW.gift_card_id;
+
+					SELECT initial_balance - COALESCE(SUM(t.amount), 0) INTO current_balance
+					FROM gift_card_transactions t
+					WHERE t.gift_card_id = NEW.gift_card_id
+						AND t.deleted_at IS NULL
+						AND t.id != COALESCE(NEW.id, '00000000-0000-0000-0000-000000000000'::uuid);
+
+					IF (current_balance - NEW.amount) < 0 THEN
+						RAISE EXCEPTION 'Insufficient balance: current=%, transaction=%, would result in=%',
+							current_balance, NEW.amount, (current_balance - NEW.amount);
+					END IF;
+
+					RETURN NEW;
+				END;
+				$$ LANGUAGE plpgsql;
+			`); err != nil {
+				return err
+			}
+
+			if err := createFunction(tx, `
+				CREATE OR REPLACE FUNCTION auto_set_gift_card_current_balance()
+				RETURNS TRIGGER AS $$
+				DECLARE
+					transaction_sum DECIMAL(10,2);
+				BEGIN
+					SELECT COALESCE(SUM(amount), 0) INTO transaction_sum
+					FROM gift_card_transactions
+					WHERE gift_card_id = NEW.id
+					  AND deleted_at IS NULL;
+
+					NEW.current_balance := NEW.initial_balance - transaction_sum;
+
+					RETURN NEW;
+				END;
+				$$ LANGUAGE plpgsql;
+			`); err != nil {
+				return err
+			}
+
+			if err := createAutoSetBalanceUpdateTrigger(tx); err != nil {
+				return err
+			}
+
+			return tx.Exec("DROP FUNCTION IF EXISTS currency_exponent(TEXT)").Error
+		},
+	}
+}
+
+// createMinorUnitBalanceFunctions replaces the balance trigger functions with
+// versions that calculate in BIGINT minor units
+func createMinorUnitBalanceFunctions(tx *gorm.DB) error {
+	if err := createFunction(tx, `
+		CREATE OR REPLACE FUNCTION check_gift_card_balance()
+		RETURNS TRIGGER AS $$
+		DECLARE
+			current_balance BIGINT;
+			initial_balance BIGINT;
+		BEGIN
+			-- Get initial balance
+			SELECT gc.initial_balance INTO initial_balance
+			FROM gift_cards gc
+			WHERE gc.id = NEW.gift_card_id;
+
+			-- Calculate current balance (initial - sum of all other transactions)
+			SELECT initial_balance - COALESCE(SUM(t.amount), 0) INTO current_balance
+			FROM gift_card_transactions t
+			WHERE t.gift_card_id = NEW.gift_card_id
+				AND t.deleted_at IS NULL
+				AND t.id != COALESCE(NEW.id, '00000000-0000-0000-0000-000000000000'::uuid);
+
+			-- Check if new transaction would result in negative balance
+			IF (current_balance - NEW.amount) < 0 THEN
+				RAISE EXCEPTION 'Insufficient balance: current=%, transaction=%, would result in=% (minor units)',
+					current_balance, NEW.amount, (current_balance - NEW.amount);
+			END IF;
+
+			RETURN NEW;
+		END;
+		$$ LANGUAGE plpgsql;
+	`); err != nil {
+		return err
+	}
+
+	return createFunction(tx, `
+		CREATE OR REPLACE FUNCTION auto_set_gift_card_current_balance()
+		RETURNS TRIGGER AS $$
+		DECLARE
+			transaction_sum BIGINT;
+		BEGIN
+			-- Calculate sum of all transactions for this gift card
+			SELECT COALESCE(SUM(amount), 0) INTO transaction_sum
+			FROM gift_card_transactions
+			WHERE gift_card_id = NEW.id
+			  AND deleted_at IS NULL;
+
+			-- Set current_balance based on initial_balance and transactions
+			NEW.current_balance := NEW.initial_balance - transaction_sum;
+
+			RETURN NEW;
+		END;
+		$$ LANGUAGE plpgsql;
+	`)
+}
+
+// createAutoSetBalanceUpdateTrigger (re)creates the BEFORE UPDATE trigger that
+// recalculates current_balance when initial_balance changes
+func createAutoSetBalanceUpdateTrigger(tx *gorm.DB) error {
+	return tx.Exec(`
+		DROP TRIGGER IF EXISTS trigger_auto_set_gift_card_current_balance_update ON gift_cards;
+		CREATE TRIGGER trigger_auto_set_gift_card_current_balance_update
+			BEFORE UPDATE ON gift_cards
+			FOR EACH ROW
+			WHEN (OLD.initial_balance IS DISTINCT FROM NEW.initial_balance)
+			EXECUTE FUNCTION auto_set_gift_card_current_balance();
+	`).Error
+}
+
+// addGiftCardTransactionKinds adds a kind to gift card transactions so reloads,
+// refunds and adjustments can raise the balance, and rewrites the balance
+// triggers to use the signed effect of each kind.
+// Migration 000020 - 2026-10-16
+func addGiftCardTransactionKinds() *gormigrate.Migration {
+	return &gormigrate.Migration{
+		ID: "202610160020_gift_card_transaction_kinds",
+		Migrate: func(tx *gorm.DB) error {
+			// Existing transactions were all purchases
+			if err := tx.Exec(`
+				ALTER TABLE gift_card_transactions
+					ADD COLUMN IF NOT EXISTS kind VARCHAR(20) NOT NULL DEFAULT 'purchase';
+				ALTER TABLE gift_card_transactions
+					ADD CONSTRAINT chk_gift_card_transactions_kind
+					CHECK (kind IN ('purchase', 'reload', 'refund', 'adjustment'));
+			`).Error; err != nil {
+				return err
+			}
+
+			// Signed effect of a transaction on the balance, must match
+			// GiftCardTransaction.BalanceEffect()
+			if err := createFunction(tx, `
+				CREATE OR REPLACE FUNCTION gift_card_transaction_delta(kind TEXT, amount BIGINT)
+				RETURNS BIGINT AS $$
+					SELECT CASE kind WHEN 'purchase' THEN -amount ELSE amount END;
+				$$ LANGUAGE sql IMMUTABLE;
+			`); err != nil {
+				return err
+			}
+
+			if err := createFunction(tx, `
+				CREATE OR REPLACE FUNCTION check_gift_card_balance()
+				RETURNS TRIGGER AS $$
+				DECLARE
+					current_balance BIGINT;
+					new_balance BIGINT;
+				BEGIN
+					-- Balance from the initial balance and all other transactions
+					SELECT gc.initial_balance + COALESCE((
+						SELECT SUM(gift_card_transaction_delta(t.kind, t.amount))
+						FROM gift_card_transactions t
+						WHERE t.gift_card_id = NEW.gift_card_id
+							AND t.deleted_at IS NULL
+							AND t.id != COALESCE(NEW.id, '00000000-0000-0000-0000-000000000000'::uuid)
+					), 0) INTO current_balance
+					FROM gift_cards gc
+					WHERE gc.id = NEW.gift_card_id;
+
+					-- Soft-deleting a transaction removes its effect (e.g. a spent reload)
+					new_balance := current_balance;
+					IF NEW.deleted_at IS NULL THEN
+						new_balance := current_balance + gift_card_transaction_delta(NEW.kind, NEW.amount);
+					END IF;
+
+					IF new_balance < 0 THEN
+						RAISE EXCEPTION 'Insufficient balance: current=%, transaction=% %, would result in=% (minor units)',
+							current_balance, NEW.kind, NEW.amount, new_balance;
+					END IF;
+
+					RETURN NEW;
+				END;
+				$$ LANGUAGE plpgsql;
+			`); err != nil {
+				return err
+			}
+
+			if err := createFunction(tx, `
+				CREATE OR REPLACE FUNCTION recalculate_gift_card_balance()
+				RETURNS TRIGGER AS $$
+				DECLARE
+					card_id UUID;
+				BEGIN
+					-- Determine which gift card was affected
+					IF TG_OP = 'DELETE' THEN
+						card_id := OLD.gift_card_id;
+					ELSE
+						card_id := NEW.gift_card_id;
+					END IF;
+
+					-- Recalculate and update the balance (exclude soft-deleted transactions)
+					UPDATE gift_cards
+					SET current_balance = initial_balance + (
+						SELECT COALESCE(SUM(gift_card_transaction_delta(kind, amount)), 0)
+						FROM gift_card_transactions
+						WHERE gift_card_id = card_id
+						  AND deleted_at IS NULL
+					)
+					WHERE id = card_id;
+
+					RETURN NEW;
+				END;
+				$$ LANGUAGE plpgsql;
+			`); err != nil {
+				return err
+			}
+
+			if err := createFunction(tx, `
+				CREATE OR REPLACE FUNCTION auto_set_gift_card_current_balance()
+				RETURNS TRIGGER AS $$
+				DECLARE
+					transaction_sum BIGINT;
+				BEGIN
+					-- Sum of the signed effects of all transactions for this gift card
+					SELECT COALESCE(SUM(gift_card_transaction_delta(kind, amount)), 0) INTO transaction_sum
+					FROM gift_card_transactions
+					WHERE gift_card_id = NEW.id
+					  AND deleted_at IS NULL;
+
+					NEW.current_balance := NEW.initial_balance + transaction_sum;
+
+					RETURN NEW;
+				END;
+				$$ LANGUAGE plpgsql;
+			`); err != nil {
+				return err
+			}
+
+			return addComment(tx, `
+				COMMENT ON COLUMN gift_card_transactions.kind IS 'purchase lowers the balance, reload and refund raise it, adjustment adds its signed amount';
+				COMMENT ON COLUMN gift_cards.current_balance IS 'Cached balance in minor units calculated as initial_balance + SUM(gift_card_transaction_delta(kind, amount)). Auto-updated by trigger on gift_card_transactions.';
+			`)
+		},
+		Rollback: func(tx *gorm.DB) error {
+			// Credits cannot be represented without a kind
+			if err := tx.Exec(`
+				DELETE FROM gift_card_transactions WHERE kind != 'purchase';
+			`).Error; err != nil {
+				return err
+			}
+
+			if err := createMinorUnitBalanceFunctions(tx); err != nil {
+				return err
+			}
+
+			if err := createFunction(tx, `
+				CREATE OR REPLACE FUNCTION recalculate_gift_card_balance()
+				RETURNS TRIGGER AS $$
+				DECLARE
+					card_id UUID;
+				BEGIN
+					IF TG_OP = 'DELETE' THEN
+						card_id := OLD.gift_card_id;
+					ELSE
+						card_id := NEW.gift_card_id;
+					END IF;
+
+					UPDATE gift_cards
+					SET current_balance = initial_balance - (
+						SELECT COALESCE(SUM(amount), 0)
+						FROM gift_card_transactions
+						WHERE gift_card_id = card_id
+						  AND deleted_at IS NULL
+					)
+					WHERE id = card_id;
+
+					RETURN NEW;
+				END;
+				$$ LANGUAGE plpgsql;
+			`); err != nil {
+				return err
+			}
+
+			if err := tx.Exec(`
+				ALTER TABLE gift_card_transactions DROP CONSTRAINT IF EXISTS chk_gift_card_transactions_kind;
+				ALTER TABLE gift_card_transactions DROP COLUMN IF EXISTS kind;
+				DROP FUNCTION IF EXISTS gift_card_transaction_delta(TEXT, BIGINT);
+			`).Error; err != nil {
+				return err
+			}
+
+			return tx.Exec(`
+				UPDATE gift_cards
+				SET current_balance = initial_balance - (
+					SELECT COALESCE(SUM(amount), 0)
+					FROM gift_card_transactions
+					WHERE gift_card_transactions.gift_card_id = gift_cards.id
+					  AND gift_card_transactions.deleted_at IS NULL
+				);
+			`).Error
+		},
+	}
+}
+
+// addGiftCardTransactionRevisions creates the table keeping the previous values
+// of edited gift card transactions
+// Migration 000021 - 2026-10-16
+func addGiftCardTransactionRevisions() *gormigrate.Migration {
+	return &gormigrate.Migration{
+		ID: "202610160021_gift_card_transaction_revisions",
+		Migrate: func(tx *gorm.DB) error {
+			// Define GiftCardTransactionRevision struct for migration
+			type GiftCardTransactionRevision struct {
+				ID              uuid.UUID  `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+				TransactionID   uuid.UUID  `gorm:"type:uuid;not null;index:idx_gift_card_transaction_revisions_transaction_id"`
+				Amount          int64      `gorm:"type:bigint;not null"`
+				Description     string     `gorm:"type:text"`
+				TransactionDate time.Time  `gorm:"type:timestamp with time zone"`
+				EditedByUserID  *uuid.UUID `gorm:"type:uuid;index:idx_gift_card_transaction_revisions_edited_by"`
+				CreatedAt       time.Time  `gorm:"type:timestamp with time zone;default:CURRENT_TIMESTAMP"`
+			}
+
+			// Create table
+			if err := tx.AutoMigrate(&GiftCardTransactionRevision{}); err != nil {
+				return err
+			}
+
+			// Revisions belong to their transaction; the editor may be deleted later
+			if err := tx.Exec(`
+				ALTER TABLE gift_card_transaction_revisions
+				ADD CONSTRAINT fk_gift_card_transaction_revisions_transaction
+				FOREIGN KEY (transaction_id) REFERENCES gift_card_transactions(id) ON DELETE CASCADE;
+				ALTER TABLE gift_card_transaction_revisions
+				ADD CONSTRAINT fk_gift_card_transaction_revisions_edited_by
+				FOREIGN KEY (edited_by_user_id) REFERENCES users(id) ON DELETE SET NULL;
+			`).Error; err != nil {
+				return err
+			}
+
+			return addComment(tx, `
+				COMMENT ON TABLE gift_card_transaction_revisions IS 'Previous values of gift card transactions, one row per edit';
+				COMMENT ON COLUMN gift_card_transaction_revisions.amount IS 'Amount before the edit in minor units of the gift card currency';
+			`)
+		},
+		Rollback: func(tx *gorm.DB) error {
+			return tx.Exec("DROP TABLE IF EXISTS gift_card_transaction_revisions CASCADE").Error
+		},
+	}
+}
+
+// addExchangeRates creates the admin maintained exchange rate table and the
+// preferred display currency of users
+// Migration 000022 - 2026-10-16
+func addExchangeRates() *gormigrate.Migration {
+	return &gormigrate.Migration{
+		ID: "202610160022_exchange_rates",
+		Migrate: func(tx *gorm.DB) error {
+			// Define ExchangeRate struct for migration
+			type ExchangeRate struct {
+				Currency  string    `gorm:"type:varchar(3);primaryKey"`
+				Rate      float64   `gorm:"type:double precision;not null"`
+				UpdatedAt time.Time `gorm:"type:timestamp with time zone;default:CURRENT_TIMESTAMP"`
+			}
+
+			// Create table
+			if err := tx.AutoMigrate(&ExchangeRate{}); err != nil {
+				return err
+			}
+
+			if err := tx.Exec(`
+				ALTER TABLE exchange_rates
+				ADD CONSTRAINT chk_exchange_rates_rate CHECK (rate > 0);
+				ALTER TABLE users
+				ADD COLUMN IF NOT EXISTS display_currency VARCHAR(3) NOT NULL DEFAULT '';
+			`).Error; err != nil {
+				return err
+			}
+
+			return addComment(tx, `
+				COMMENT ON TABLE exchange_rates IS 'Static exchange rates maintained by admins, override EXCHANGE_RATES';
+				COMMENT ON COLUMN exchange_rates.rate IS 'Units of the currency per one unit of EXCHANGE_RATE_BASE';
+				COMMENT ON COLUMN users.display_currency IS 'Currency for converted dashboard totals, empty for the base currency';
+			`)
+		},
+		Rollback: func(tx *gorm.DB) error {
+			return tx.Exec(`
+				ALTER TABLE users DROP COLUMN IF EXISTS display_currency;
+				DROP TABLE IF EXISTS exchange_rates CASCADE;
+			`).Error
+		},
+	}
+}
+
+// addUserSessions creates the server-side session store that replaces the
+// cookie-only sessions
+// Migration 000023 - 2026-10-16
+func addUserSessions() *gormigrate.Migration {
+	return &gormigrate.Migration{
+		ID: "202610160023_add_user_sessions",
+		Migrate: func(tx *gorm.DB) error {
+			// Define UserSession struct for migration
+			type UserSession struct {
+				ID         uuid.UUID  `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+				TokenHash  string     `gorm:"type:char(64);not null;uniqueIndex:idx_user_sessions_token_hash"`
+				UserID     *uuid.UUID `gorm:"type:uuid;index:idx_user_sessions_user_id"`
+				Data       string     `gorm:"type:text;not null"`
+				Device     string     `gorm:"type:varchar(100);not null;default:''"`
+				UserAgent  string     `gorm:"type:text;not null;default:''"`
+				IPAddress  string     `gorm:"type:varchar(45);not null;default:''"`
+				CreatedAt  time.Time  `gorm:"type:timestamp with time zone;default:CURRENT_TIMESTAMP"`
+				LastSeenAt time.Time  `gorm:"type:timestamp with time zone;not null"`
+				ExpiresAt  time.Time  `gorm:"type:timestamp with time zone;not null;index:idx_user_sessions_expires_at"`
+			}
+
+			// Create table
+			if err := tx.AutoMigrate(&UserSession{}); err != nil {
+				return err
+			}
+
+			// Sessions end together with their user
+			if err := tx.Exec(`
+				ALTER TABLE user_sessions
+				ADD CONSTRAINT fk_user_sessions_user
+				FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+			`).Error; err != nil {
+				return err
+			}
+
+			return addComment(tx, `
+				COMMENT ON TABLE user_sessions IS 'Server-side browser sessions, the cookie only carries the signed session ID';
+				COMMENT ON COLUMN user_sessions.token_hash IS 'Hex-encoded SHA-256 of the session ID';
+				COMMENT ON COLUMN user_sessions.user_id IS 'Logged-in user, the admin while impersonating; NULL before login';
+				COMMENT ON COLUMN user_sessions.data IS 'Session values, signed with SESSION_SECRET';
+			`)
+		},
+		Rollback: func(tx *gorm.DB) error {
+			return tx.Exec("DROP TABLE IF EXISTS user_sessions CASCADE").Error
+		},
+	}
+}
+
+// addTwoFactorAuth adds the TOTP secret to users and the recovery code table
+// Migration 000024 - 2026-10-16
+func addTwoFactorAuth() *gormigrate.Migration {
+	return &gormigrate.Migration{
+		ID: "202610160024_add_two_factor_auth",
+		Migrate: func(tx *gorm.DB) error {
+			// Define UserRecoveryCode struct for migration
+			type UserRecoveryCode struct {
+				ID        uuid.UUID  `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+				UserID    uuid.UUID  `gorm:"type:uuid;not null;index:idx_user_recovery_codes_user_id"`
+				CodeHash  string     `gorm:"type:char(64);not null;uniqueIndex:idx_user_recovery_codes_code_hash"`
+				UsedAt    *time.Time `gorm:"type:timestamp with time zone"`
+				CreatedAt time.Time  `gorm:"type:timestamp with time zone;default:CURRENT_TIMESTAMP"`
+			}
+
+			if err := tx.Exec(`
+				ALTER TABLE users
+				ADD COLUMN IF NOT EXISTS totp_secret TEXT NOT NULL DEFAULT '',
+				ADD COLUMN IF NOT EXISTS totp_enabled_at TIMESTAMP WITH TIME ZONE,
+				ADD COLUMN IF NOT EXISTS totp_last_step BIGINT NOT NULL DEFAULT 0
+			`).Error; err != nil {
+				return err
+			}
+
+			// Create table
+			if err := tx.AutoMigrate(&UserRecoveryCode{}); err != nil {
+				return err
+			}
+
+			// Codes are removed together with their user
+			if err := tx.Exec(`
+				ALTER TABLE user_recovery_codes
+				ADD CONSTRAINT fk_user_recovery_codes_user
+				FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+			`).Error; err != nil {
+				return err
+			}
+
+			return addComment(tx, `
+				COMMENT ON COLUMN users.totp_secret IS 'Base32 TOTP secret, encrypted with ENCRYPTION_KEYS; empty without 2FA';
+				COMMENT ON COLUMN users.totp_enabled_at IS 'When 2FA was confirmed, NULL if disabled';
+				COMMENT ON COLUMN users.totp_last_step IS 'Last accepted TOTP time step, codes of earlier steps are rejected';
+				COMMENT ON TABLE user_recovery_codes IS 'Single-use 2FA recovery codes';
+				COMMENT ON COLUMN user_recovery_codes.code_hash IS 'Hex-encoded SHA-256 of user ID and code';
+			`)
+		},
+		Rollback: func(tx *gorm.DB) error {
+			return tx.Exec(`
+				DROP TABLE IF EXISTS user_recovery_codes CASCADE;
+				ALTER TABLE users
+				DROP COLUMN IF EXISTS totp_secret,
+				DROP COLUMN IF EXISTS totp_enabled_at,
+				DROP COLUMN IF EXISTS totp_last_step;
+			`).Error
+		},
+	}
+}
+
+// addWebAuthnCredentials creates the table for passkeys and security keys
+// Migration 000025 - 2026-10-16
+func addWebAuthnCredentials() *gormigrate.Migration {
+	return &gormigrate.Migration{
+		ID: "202610160025_add_webauthn_credentials",
+		Migrate: func(tx *gorm.DB) error {
+			// Define WebAuthnCredential struct for migration
+			type WebAuthnCredential struct {
+				ID              uuid.UUID  `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+				UserID          uuid.UUID  `gorm:"type:uuid;not null;index:idx_webauthn_credentials_user_id"`
+				Name            string     `gorm:"type:varchar(100);not null"`
+				CredentialID    []byte     `gorm:"type:bytea;not null;uniqueIndex:idx_webauthn_credentials_credential_id"`
+				PublicKey       []byte     `gorm:"type:bytea;not null"`
+				AttestationType string     `gorm:"type:varchar(32);not null;default:''"`
+				Transports      string     `gorm:"type:varchar(100);not null;default:''"`
+				AAGUID          []byte     `gorm:"type:bytea"`
+				SignCount       int64      `gorm:"not null;default:0"`
+				BackupEligible  bool       `gorm:"not null;default:false"`
+				BackupState     bool       `gorm:"not null;default:false"`
+				LastUsedAt      *time.Time `gorm:"type:timestamp with time zone"`
+				CreatedAt       time.Time  `gorm:"type:timestamp with time zone;default:CURRENT_TIMESTAMP"`
+			}
+
+			// Create table
+			if err := tx.AutoMigrate(&WebAuthnCredential{}); err != nil {
+				return err
+			}
+
+			// Credentials are removed together with their user
+			if err := tx.Exec(`
+				ALTER TABLE webauthn_credentials
+				ADD CONSTRAINT fk_webauthn_credentials_user
+				FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+			`).Error; err != nil {
+				return err
+			}
+
+			return addComment(tx, `
+				COMMENT ON TABLE webauthn_credentials IS 'Passkeys and security keys for passwordless login and as second factor';
+				COMMENT ON COLUMN webauthn_credentials.credential_id IS 'Credential ID chosen by the authenticator';
+				COMMENT ON COLUMN webauthn_credentials.public_key IS 'COSE-encoded public key';
+				COMMENT ON COLUMN webauthn_credentials.sign_count IS 'Last signature counter; a counter that does not increase indicates a cloned authenticator';
+				COMMENT ON COLUMN webauthn_credentials.backup_eligible IS 'Passkey can be synced between devices';
+			`)
+		},
+		Rollback: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable("webauthn_credentials")
+		},
+	}
+}
+
+// addEmailVerification adds the verification timestamp of the email address.
+// Existing accounts are trusted and marked as verified.
+// Migration 000026 - 2026-10-16
+func addEmailVerification() *gormigrate.Migration {
+	return &gormigrate.Migration{
+		ID: "202610160026_add_email_verification",
+		Migrate: func(tx *gorm.DB) error {
+			if err := tx.Exec(`
+				ALTER TABLE users
+				ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMP WITH TIME ZONE
+			`).Error; err != nil {
+				return err
+			}
+
+			if err := tx.Exec(`UPDATE users SET email_verified_at = created_at WHERE email_verified_at IS NULL`).Error; err != nil {
+				return err
+			}
+
+			return addComment(tx, `
+				COMMENT ON COLUMN users.email_verified_at IS 'When the email address was confirmed, NULL until then; unverified users cannot be share targets';
+			`)
+		},
+		Rollback: func(tx *gorm.DB) error {
+			return tx.Exec(`ALTER TABLE users DROP COLUMN IF EXISTS email_verified_at`).Error
+		},
+	}
+}
+
+// addLoginThrottles creates the table counting failed logins per email address and IP
+// Migration 000027 - 2026-10-16
+func addLoginThrottles() *gormigrate.Migration {
+	return &gormigrate.Migration{
+		ID: "202610160027_add_login_throttles",
+		Migrate: func(tx *gorm.DB) error {
+			// Define LoginThrottle struct for migration
+			type LoginThrottle struct {
+				ID            uuid.UUID  `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+				Scope         string     `gorm:"type:varchar(10);not null;uniqueIndex:idx_login_throttles_scope_subject"`
+				Subject       string     `gorm:"type:varchar(255);not null;uniqueIndex:idx_login_throttles_scope_subject"`
+				Failures      int        `gorm:"not null;default:0"`
+				LastFailureAt time.Time  `gorm:"type:timestamp with time zone;not null;index:idx_login_throttles_last_failure_at"`
+				LockedUntil   *time.Time `gorm:"type:timestamp with time zone"`
+				CreatedAt     time.Time  `gorm:"type:timestamp with time zone;default:CURRENT_TIMESTAMP"`
+			}
+
+			// Create table
+			if err := tx.AutoMigrate(&LoginThrottle{}); err != nil {
+				return err
+			}
+
+			return addComment(tx, `
+				COMMENT ON TABLE login_throttles IS 'Recent failed logins per email address and client IP for progressive delays and lockouts';
+				COMMENT ON COLUMN login_throttles.scope IS 'account (subject is the lowercase email, also for unknown addresses) or ip';
+				COMMENT ON COLUMN login_throttles.failures IS 'Failed attempts since the count last started; restarts after a quiet hour';
+				COMMENT ON COLUMN login_throttles.locked_until IS 'Logins are refused until this time; admins can unlock early';
+			`)
+		},
+		Rollback: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable("login_throttles")
+		},
+	}
+}
+
+// addRateLimitCounters creates the table of the Postgres rate limiter backend
+// Migration 000028 - 2026-10-16
+func addRateLimitCounters() *gormigrate.Migration {
+	return &gormigrate.Migration{
+		ID: "202610160028_add_rate_limit_counters",
+		Migrate: func(tx *gorm.DB) error {
+			// Unlogged: counters are short-lived and not worth the WAL writes,
+			// losing them on a crash only resets the current windows
+			if err := tx.Exec(`
+				CREATE UNLOGGED TABLE IF NOT EXISTS rate_limit_counters (
+					key VARCHAR(255) PRIMARY KEY,
+					count INTEGER NOT NULL,
+					expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+				)
+			`).Error; err != nil {
+				return err
+			}
+
+			if err := createIndex(tx, `CREATE INDEX IF NOT EXISTS idx_rate_limit_counters_expires_at ON rate_limit_counters(expires_at)`); err != nil {
+				return err
+			}
+
+			return addComment(tx, `
+				COMMENT ON TABLE rate_limit_counters IS 'Request counters of the rate limiter when RATE_LIMIT_BACKEND=postgres, shared by all replicas';
+				COMMENT ON COLUMN rate_limit_counters.key IS 'Policy name and client, e.g. auth:ip:192.0.2.1 or shares:user:<uuid>';
+				COMMENT ON COLUMN rate_limit_counters.expires_at IS 'End of the current window; the count restarts afterwards';
+			`)
+		},
+		Rollback: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable("rate_limit_counters")
+		},
+	}
+}
+
+// addNotificationDelivery adds the delivery queue, the channel preferences
+// and the Web Push subscriptions for notifications by email and push
+// Migration 000029 - 2026-10-16
+func addNotificationDelivery() *gormigrate.Migration {
+	return &gormigrate.Migration{
+		ID: "202610160029_add_notification_delivery",
+		Migrate: func(tx *gorm.DB) error {
+			// Define structs for migration
+			type NotificationDelivery struct {
+				ID             uuid.UUID  `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+				NotificationID uuid.UUID  `gorm:"type:uuid;not null;index:idx_notification_deliveries_notification_id"`
+				UserID         uuid.UUID  `gorm:"type:uuid;not null;index:idx_notification_deliveries_user_id"`
+				Channel        string     `gorm:"type:varchar(10);not null"`
+				Status         string     `gorm:"type:varchar(10);not null;default:pending;index:idx_notification_deliveries_status"`
+				Language       string     `gorm:"type:varchar(10);not null;default:''"`
+				Attempts       int        `gorm:"not null;default:0"`
+				NextAttemptAt  time.Time  `gorm:"type:timestamp with time zone;not null"`
+				LastError      string     `gorm:"type:text;not null;default:''"`
+				SentAt         *time.Time `gorm:"type:timestamp with time zone"`
+				CreatedAt      time.Time  `gorm:"type:timestamp with time zone;default:CURRENT_TIMESTAMP"`
+				UpdatedAt      time.Time  `gorm:"type:timestamp with time zone;default:CURRENT_TIMESTAMP"`
+			}
+			type NotificationPreference struct {
+				UserID    uuid.UUID `gorm:"type:uuid;primaryKey"`
+				Type      string    `gorm:"type:varchar(50);primaryKey"`
+				Email     bool      `gorm:"not null;default:true"`
+				Push      bool      `gorm:"not null;default:true"`
+				UpdatedAt time.Time `gorm:"type:timestamp with time zone;default:CURRENT_TIMESTAMP"`
+			}
+			type PushSubscription struct {
+				ID         uuid.UUID  `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+				UserID     uuid.UUID  `gorm:"type:uuid;not null;index:idx_push_subscriptions_user_id"`
+				Endpoint   string     `gorm:"type:text;not null;uniqueIndex:idx_push_subscriptions_endpoint"`
+				P256dh     string     `gorm:"type:varchar(255);not null"`
+				Auth       string     `gorm:"type:varchar(255);not null"`
+				UserAgent  string     `gorm:"type:varchar(500);not null;default:''"`
+				LastUsedAt *time.Time `gorm:"type:timestamp with time zone"`
+				CreatedAt  time.Time  `gorm:"type:timestamp with time zone;default:CURRENT_TIMESTAMP"`
+			}
+
+			// Create tables
+			if err := tx.AutoMigrate(&NotificationDelivery{}, &NotificationPreference{}, &PushSubscription{}); err != nil {
+				return err
+			}
+
+			// Everything is removed together with its user or notification
+			if err := tx.Exec(`
+				ALTER TABLE notification_deliveries
+				ADD CONSTRAINT fk_notification_deliveries_notification
+				FOREIGN KEY (notification_id) REFERENCES notifications(id) ON DELETE CASCADE;
+				ALTER TABLE notification_deliveries
+				ADD CONSTRAINT fk_notification_deliveries_user
+				FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+				ALTER TABLE notification_preferences
+				ADD CONSTRAINT fk_notification_preferences_user
+				FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+				ALTER TABLE push_subscriptions
+				ADD CONSTRAINT fk_push_subscriptions_user
+				FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+			`).Error; err != nil {
+				return err
+			}
+
+			// The worker polls for pending deliveries that are due
+			if err := createIndex(tx, `
+				CREATE INDEX IF NOT EXISTS idx_notification_deliveries_due
+				ON notification_deliveries(next_attempt_at)
+				WHERE status = 'pending'
+			`); err != nil {
+				return err
+			}
+
+			return addComment(tx, `
+				COMMENT ON TABLE notification_deliveries IS 'Queue of notifications to send by email or Web Push, retried with backoff until sent';
+				COMMENT ON COLUMN notification_deliveries.channel IS 'email or push';
+				COMMENT ON COLUMN notification_deliveries.status IS 'pending, sent, skipped (nothing left to send) or failed (gave up after the last retry)';
+				COMMENT ON COLUMN notification_deliveries.language IS 'Language of the request that caused the notification, used for the message';
+				COMMENT ON COLUMN notification_deliveries.next_attempt_at IS 'Earliest time of the next attempt; moved ahead while a worker sends the delivery';
+				COMMENT ON TABLE notification_preferences IS 'Channels a user receives each notification type on; types without row use all channels';
+				COMMENT ON TABLE push_subscriptions IS 'Web Push endpoints of the browsers users enabled push notifications on';
+				COMMENT ON COLUMN push_subscriptions.endpoint IS 'URL of the push service, unique per browser profile';
+				COMMENT ON COLUMN push_subscriptions.p256dh IS 'Public key of the browser that encrypts the payload';
+			`)
+		},
+		Rollback: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable("notification_deliveries", "notification_preferences", "push_subscriptions")
+		},
+	}
+}
+
+// addJobs adds the state and the run history of the background job scheduler
+// Migration 000030 - 2026-10-16
+func addJobs() *gormigrate.Migration {
+	return &gormigrate.Migration{
+		ID: "202610160030_add_jobs",
+		Migrate: func(tx *gorm.DB) error {
+			// Define structs for migration
+			type Job struct {
+				Name            string     `gorm:"type:varchar(100);primaryKey"`
+				Schedule        string     `gorm:"type:varchar(100);not null"`
+				Local           bool       `gorm:"not null;default:false"`
+				LastScheduledAt *time.Time `gorm:"type:timestamp with time zone"`
+				LastStartedAt   *time.Time `gorm:"type:timestamp with time zone"`
+				LastFinishedAt  *time.Time `gorm:"type:timestamp with time zone"`
+				LastStatus      string     `gorm:"type:varchar(10);not null;default:''"`
+				LastError       string     `gorm:"type:text;not null;default:''"`
+				LastDuration    int64      `gorm:"not null;default:0"`
+				LastInstance    string     `gorm:"type:varchar(255);not null;default:''"`
+				LastErrorAt     *time.Time `gorm:"type:timestamp with time zone"`
+				CreatedAt       time.Time  `gorm:"type:timestamp with time zone;default:CURRENT_TIMESTAMP"`
+				UpdatedAt       time.Time  `gorm:"type:timestamp with time zone;default:CURRENT_TIMESTAMP"`
+			}
+			type JobRun struct {
+				ID          uuid.UUID  `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+				JobName     string     `gorm:"type:varchar(100);not null"`
+				Instance    string     `gorm:"type:varchar(255);not null"`
+				ScheduledAt time.Time  `gorm:"type:timestamp with time zone;not null"`
+				StartedAt   time.Time  `gorm:"type:timestamp with time zone;not null;index:idx_job_runs_started_at"`
+				FinishedAt  *time.Time `gorm:"type:timestamp with time zone"`
+				Status      string     `gorm:"type:varchar(10);not null"`
+				Error       string     `gorm:"type:text;not null;default:''"`
+				Duration    int64      `gorm:"not null;default:0"`
+			}
+
+			// Create tables
+			if err := tx.AutoMigrate(&Job{}, &JobRun{}); err != nil {
+				return err
+			}
+
+			// The admin page lists the latest runs per job
+			if err := createIndex(tx, `
+				CREATE INDEX IF NOT EXISTS idx_job_runs_job_started
+				ON job_runs(job_name, started_at DESC)
+			`); err != nil {
+				return err
+			}
+
+			return addComment(tx, `
+				COMMENT ON TABLE jobs IS 'Background jobs of the scheduler with the outcome of their latest run';
+				COMMENT ON COLUMN jobs.schedule IS 'Cron expression or descriptor such as @every 5m, in UTC';
+				COMMENT ON COLUMN jobs.local IS 'Runs on every replica instead of once per schedule slot';
+				COMMENT ON COLUMN jobs.last_scheduled_at IS 'Slot of the latest run; a replica claims a slot by moving it ahead';
+				COMMENT ON COLUMN jobs.last_duration IS 'Duration of the latest run in nanoseconds';
+				COMMENT ON COLUMN jobs.last_instance IS 'Host name of the replica that ran the job last';
+				COMMENT ON COLUMN jobs.last_error_at IS 'End of the latest failed run, kept after later runs succeed';
+				COMMENT ON TABLE job_runs IS 'Run history of the background jobs, pruned after a week';
+				COMMENT ON COLUMN job_runs.status IS 'running, succeeded or failed; running rows of a dead replica stay running';
+				COMMENT ON COLUMN job_runs.duration IS 'Duration of the run in nanoseconds';
+			`)
+		},
+		Rollback: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable("jobs", "job_runs")
+		},
+	}
+}
+
+// addExpiryReminders adds the reminder offsets of the users and the record
+// of sent reminders that keeps each one from being sent twice
+// Migration 000031 - 2026-10-16
+func addExpiryReminders() *gormigrate.Migration {
+	return &gormigrate.Migration{
+		ID: "202610160031_add_expiry_reminders",
+		Migrate: func(tx *gorm.DB) error {
+			// Define structs for migration
+			type ExpiryReminderPreference struct {
+				UserID    uuid.UUID `gorm:"type:uuid;primaryKey"`
+				Days      string    `gorm:"type:varchar(50);not null"`
+				UpdatedAt time.Time `gorm:"type:timestamp with time zone;default:CURRENT_TIMESTAMP"`
+			}
+			type ExpiryReminder struct {
+				ID           uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+				UserID       uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_expiry_reminders_unique,priority:1"`
+				ResourceType string    `gorm:"type:varchar(50);not null;uniqueIndex:idx_expiry_reminders_unique,priority:2"`
+				ResourceID   uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_expiry_reminders_unique,priority:3"`
+				Days         int       `gorm:"not null;uniqueIndex:idx_expiry_reminders_unique,priority:4"`
+				ExpiresAt    time.Time `gorm:"type:timestamp with time zone;not null;uniqueIndex:idx_expiry_reminders_unique,priority:5;index:idx_expiry_reminders_expires_at"`
+				CreatedAt    time.Time `gorm:"type:timestamp with time zone;default:CURRENT_TIMESTAMP"`
+			}
+
+			// Create tables
+			if err := tx.AutoMigrate(&ExpiryReminderPreference{}, &ExpiryReminder{}); err != nil {
+				return err
+			}
+
+			// Everything is removed together with its user
+			if err := tx.Exec(`
+				ALTER TABLE expiry_reminder_preferences
+				ADD CONSTRAINT fk_expiry_reminder_preferences_user
+				FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+				ALTER TABLE expiry_reminders
+				ADD CONSTRAINT fk_expiry_reminders_user
+				FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+			`).Error; err != nil {
+				return err
+			}
+
+			// The reminder job looks for items that expire soon
+			if err := createIndex(tx, `
+				CREATE INDEX IF NOT EXISTS idx_vouchers_valid_until
+				ON vouchers(valid_until)
+				WHERE deleted_at IS NULL;
+				CREATE INDEX IF NOT EXISTS idx_gift_cards_expires_at
+				ON gift_cards(expires_at)
+				WHERE deleted_at IS NULL AND expires_at IS NOT NULL
+			`); err != nil {
+				return err
+			}
+
+			return addComment(tx, `
+				COMMENT ON TABLE expiry_reminder_preferences IS 'Days before expiry a user is reminded of vouchers and gift cards; users without row use 30,7,1';
+				COMMENT ON COLUMN expiry_reminder_preferences.days IS 'Comma separated offsets in days, empty to turn reminders off';
+				COMMENT ON TABLE expiry_reminders IS 'Expiry reminders that were sent, one per user, item, offset and expiry date';
+				COMMENT ON COLUMN expiry_reminders.resource_type IS 'voucher or gift_card';
+				COMMENT ON COLUMN expiry_reminders.days IS 'Offset of the reminder in days before expiry';
+				COMMENT ON COLUMN expiry_reminders.expires_at IS 'Expiry date the reminder was for; a changed date is reminded again';
+			`)
+		},
+		Rollback: func(tx *gorm.DB) error {
+			if err := dropIndex(tx, "idx_vouchers_valid_until"); err != nil {
+				return err
+			}
+			if err := dropIndex(tx, "idx_gift_cards_expires_at"); err != nil {
+				return err
+			}
+			return tx.Migrator().DropTable("expiry_reminder_preferences", "expiry_reminders")
+		},
+	}
+}
+
+// addItemStatusChanges adds a status to vouchers and the history of the
+// transitions the lifecycle job makes to vouchers and gift cards
+// Migration 000032 - 2026-10-16
+func addItemStatusChanges() *gormigrate.Migration {
+	return &gormigrate.Migration{
+		ID: "202610160032_add_item_status_changes",
+		Migrate: func(tx *gorm.DB) error {
+			// Define structs for migration
+			type ItemStatusChange struct {
+				ID           uuid.UUID  `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+				ResourceType string     `gorm:"type:varchar(50);not null;index:idx_item_status_changes_resource,priority:1"`
+				ResourceID   uuid.UUID  `gorm:"type:uuid;not null;index:idx_item_status_changes_resource,priority:2"`
+				UserID       *uuid.UUID `gorm:"type:uuid;index"`
+				FromStatus   string     `gorm:"type:varchar(20);not null"`
+				ToStatus     string     `gorm:"type:varchar(20);not null"`
+				Reason       string     `gorm:"type:varchar(20);not null"`
+				CreatedAt    time.Time  `gorm:"type:timestamp with time zone;default:CURRENT_TIMESTAMP;index:idx_item_status_changes_resource,priority:3"`
+			}
+
+			// Vouchers start out active, the lifecycle job expires them on its first run
+			if err := tx.Exec(`
+				ALTER TABLE vouchers
+				ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'active'
+			`).Error; err != nil {
+				return err
+			}
+
+			// Create table
+			if err := tx.AutoMigrate(&ItemStatusChange{}); err != nil {
+				return err
+			}
+
+			// The history is kept when the owner is deleted
+			if err := tx.Exec(`
+				ALTER TABLE item_status_changes
+				ADD CONSTRAINT fk_item_status_changes_user
+				FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL
+			`).Error; err != nil {
+				return err
+			}
+
+			// Index filters and metrics query by status
+			if err := createIndex(tx, `
+				CREATE INDEX IF NOT EXISTS idx_vouchers_status
+				ON vouchers(status)
+				WHERE deleted_at IS NULL;
+				CREATE INDEX IF NOT EXISTS idx_gift_cards_status
+				ON gift_cards(status)
+				WHERE deleted_at IS NULL
+			`); err != nil {
+				return err
+			}
+
+			return addComment(tx, `
+				COMMENT ON COLUMN vouchers.status IS 'active or expired, kept up to date by the lifecycle job';
+				COMMENT ON TABLE item_status_changes IS 'Automatic status transitions of vouchers and gift cards';
+				COMMENT ON COLUMN item_status_changes.resource_type IS 'voucher or gift_card';
+				COMMENT ON COLUMN item_status_changes.user_id IS 'Owner of the item at the time of the change';
+				COMMENT ON COLUMN item_status_changes.reason IS 'expired, depleted or renewed';
+			`)
+		},
+		Rollback: func(tx *gorm.DB) error {
+			if err := dropIndex(tx, "idx_vouchers_status"); err != nil {
+				return err
+			}
+			if err := dropIndex(tx, "idx_gift_cards_status"); err != nil {
+				return err
+			}
+			if err := tx.Migrator().DropTable("item_status_changes"); err != nil {
+				return err
+			}
+			return tx.Exec("ALTER TABLE vouchers DROP COLUMN IF EXISTS status").Error
+		},
+	}
+}
+
+// addCalendarFeeds adds the secret iCalendar feeds of the users
+// Migration 000033 - 2026-10-16
+func addCalendarFeeds() *gormigrate.Migration {
+	return &gormigrate.Migration{
+		ID: "202610160033_add_calendar_feeds",
+		Migrate: func(tx *gorm.DB) error {
+			// Define struct for migration
+			type CalendarFeed struct {
+				UserID     uuid.UUID  `gorm:"type:uuid;primaryKey"`
+				TokenHash  string     `gorm:"type:char(64);not null;uniqueIndex"`
+				Language   string     `gorm:"type:varchar(10);not null;default:''"`
+				LastUsedAt *time.Time `gorm:"type:timestamp with time zone"`
+				CreatedAt  time.Time  `gorm:"type:timestamp with time zone;default:CURRENT_TIMESTAMP"`
+				UpdatedAt  time.Time  `gorm:"type:timestamp with time zone;default:CURRENT_TIMESTAMP"`
+			}
+
+			// Create table
+			if err := tx.AutoMigrate(&CalendarFeed{}); err != nil {
+				return err
+			}
+
+			// The feed is removed together with its user
+			if err := tx.Exec(`
+				ALTER TABLE calendar_feeds
+				ADD CONSTRAINT fk_calendar_feeds_user
+				FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+			`).Error; err != nil {
+				return err
+			}
+
+			return addComment(tx, `
+				COMMENT ON TABLE calendar_feeds IS 'Secret iCalendar feeds with the expiry dates of vouchers and gift cards, one per user';
+				COMMENT ON COLUMN calendar_feeds.token_hash IS 'SHA-256 hash of the token in the feed URL';
+				COMMENT ON COLUMN calendar_feeds.language IS 'Language of the event titles';
+			`)
+		},
+		Rollback: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable("calendar_feeds")
+		},
+	}
+}
@@ -0,0 +1,95 @@
+// Package models defines the database models for the savvy system.
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// API token scope resources
+const (
+	APIScopeCards     = "cards"
+	APIScopeVouchers  = "vouchers"
+	APIScopeGiftCards = "gift_cards"
+)
+
+// API token access levels
+const (
+	APIAccessRead  = "read"
+	APIAccessWrite = "write"
+)
+
+// APIScopeResources lists all resource types a token can be scoped to
+var APIScopeResources = []string{APIScopeCards, APIScopeVouchers, APIScopeGiftCards}
+
+// APIToken represents a personal access token for non-browser API clients.
+// Only the SHA-256 hash of the token is stored; the plaintext is shown once on creation.
+// Revoking a token soft deletes it.
+type APIToken struct {
+	ID         uuid.UUID      `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	UserID     uuid.UUID      `gorm:"type:uuid;not null;index" json:"user_id"`
+	Name       string         `gorm:"type:varchar(100);not null" json:"name"`
+	TokenHash  string         `gorm:"type:char(64);not null;uniqueIndex" json:"-"`
+	Prefix     string         `gorm:"type:varchar(16);not null" json:"prefix"` // First characters of the token, for display
+	Scopes     string         `gorm:"type:text;not null" json:"scopes"`        // Space-separated, e.g. "cards:read gift_cards:write"
+	ExpiresAt  *time.Time     `gorm:"type:timestamp with time zone" json:"expires_at,omitempty"`
+	LastUsedAt *time.Time     `gorm:"type:timestamp with time zone" json:"last_used_at,omitempty"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Associations
+	User *User `gorm:"foreignKey:UserID" json:"-"`
+}
+
+// TableName specifies the table name for APIToken
+func (APIToken) TableName() string {
+	return "api_tokens"
+}
+
+// BeforeCreate ensures a UUID is generated before creating a token
+func (t *APIToken) BeforeCreate(_ *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return nil
+}
+
+// IsExpired returns true if the token has an expiry date in the past
+func (t *APIToken) IsExpired() bool {
+	return t.ExpiresAt != nil && time.Now().After(*t.ExpiresAt)
+}
+
+// ScopeFor returns the access level ("read", "write" or "") granted for a resource
+func (t *APIToken) ScopeFor(resource string) string {
+	access := ""
+	for _, scope := range strings.Fields(t.Scopes) {
+		res, level, ok := strings.Cut(scope, ":")
+		if !ok || res != resource {
+			continue
+		}
+		if level == APIAccessWrite {
+			return APIAccessWrite
+		}
+		if level == APIAccessRead {
+			access = APIAccessRead
+		}
+	}
+	return access
+}
+
+// Allows returns true if the token grants access to the resource.
+// Write access implies read access.
+func (t *APIToken) Allows(resource string, write bool) bool {
+	switch t.ScopeFor(resource) {
+	case APIAccessWrite:
+		return true
+	case APIAccessRead:
+		return !write
+	default:
+		return false
+	}
+}
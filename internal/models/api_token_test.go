@@ -0,0 +1,40 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIToken_TableName(t *testing.T) {
+	assert.Equal(t, "api_tokens", APIToken{}.TableName())
+}
+
+func TestAPIToken_IsExpired(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+
+	assert.False(t, (&APIToken{}).IsExpired())
+	assert.True(t, (&APIToken{ExpiresAt: &past}).IsExpired())
+	assert.False(t, (&APIToken{ExpiresAt: &future}).IsExpired())
+}
+
+func TestAPIToken_ScopeFor(t *testing.T) {
+	token := &APIToken{Scopes: "cards:read gift_cards:write cards:write vouchers:bogus"}
+
+	assert.Equal(t, APIAccessWrite, token.ScopeFor(APIScopeCards))
+	assert.Equal(t, APIAccessWrite, token.ScopeFor(APIScopeGiftCards))
+	assert.Equal(t, "", token.ScopeFor(APIScopeVouchers))
+}
+
+func TestAPIToken_Allows(t *testing.T) {
+	token := &APIToken{Scopes: "cards:read vouchers:write"}
+
+	assert.True(t, token.Allows(APIScopeCards, false))
+	assert.False(t, token.Allows(APIScopeCards, true))
+	assert.True(t, token.Allows(APIScopeVouchers, false))
+	assert.True(t, token.Allows(APIScopeVouchers, true))
+	assert.False(t, token.Allows(APIScopeGiftCards, false))
+	assert.False(t, token.Allows(APIScopeGiftCards, true))
+}
@@ -21,6 +21,23 @@ type AuditLog struct {
 	CreatedAt    time.Time  `gorm:"index" json:"created_at"`
 }
 
+// AuditActionRevealPIN is logged whenever a user reveals a gift card PIN.
+// ResourceData holds how the user re-authenticated, never the PIN itself.
+const AuditActionRevealPIN = "reveal_pin"
+
+// Two-factor authentication changes, logged with resource type "users"
+const (
+	AuditActionEnableTwoFactor    = "enable_2fa"
+	AuditActionDisableTwoFactor   = "disable_2fa"
+	AuditActionRegenerateRecovery = "regenerate_recovery_codes"
+	AuditActionAddPasskey         = "add_passkey"
+	AuditActionRemovePasskey      = "remove_passkey"
+)
+
+// AuditActionUnlockLogin is logged when an admin lifts a login lockout,
+// with resource type "login_throttles"
+const AuditActionUnlockLogin = "unlock_login"
+
 // TableName overrides the table name
 func (AuditLog) TableName() string {
 	return "audit_logs"
@@ -0,0 +1,25 @@
+// Package models defines the database models for the savvy system.
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CalendarFeed is the secret iCalendar feed of a user with the expiry dates of
+// their vouchers and gift cards. Like API tokens only the SHA-256 hash of the
+// token is stored; rotating it replaces the hash, so old feed URLs stop working.
+type CalendarFeed struct {
+	UserID     uuid.UUID  `gorm:"type:uuid;primaryKey" json:"user_id"`
+	TokenHash  string     `gorm:"type:char(64);not null;uniqueIndex" json:"-"`
+	Language   string     `gorm:"type:varchar(10);not null;default:''" json:"language"` // Language of the request that created the token
+	LastUsedAt *time.Time `gorm:"type:timestamp with time zone" json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"` // Time of the last rotation
+}
+
+// TableName specifies the table name for CalendarFeed
+func (CalendarFeed) TableName() string {
+	return "calendar_feeds"
+}
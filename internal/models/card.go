@@ -17,7 +17,7 @@ type Card struct {
 	Merchant     *Merchant      `gorm:"foreignKey:MerchantID" json:"merchant,omitempty"`
 	MerchantName string         `gorm:"default:''" json:"merchant_name"` // Retailer as fallback for free text
 	Program      string         `gorm:"not null" json:"program"`         // Savvy program name (e.g. Cumulus, Supercard)
-	CardNumber   string         `gorm:"uniqueIndex;not null" json:"card_number"`
+	CardNumber   string         `gorm:"uniqueIndex;not null;serializer:encrypted_identifier" json:"card_number"`
 	BarcodeType  string         `gorm:"default:CODE128" json:"barcode_type"`
 	Status       string         `gorm:"default:active" json:"status"`
 	Notes        string         `gorm:"type:text" json:"notes"`
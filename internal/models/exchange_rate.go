@@ -0,0 +1,13 @@
+// Package models defines the database models for the savvy system.
+package models
+
+import "time"
+
+// ExchangeRate is a static exchange rate maintained in the admin UI.
+// Rate is the amount of Currency per one unit of the configured base
+// currency; rows override the rates from EXCHANGE_RATES.
+type ExchangeRate struct {
+	Currency  string    `gorm:"type:varchar(3);primaryKey" json:"currency"`
+	Rate      float64   `gorm:"type:double precision;not null" json:"rate"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
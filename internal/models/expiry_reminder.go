@@ -0,0 +1,121 @@
+// Package models defines the database models for the savvy system.
+package models
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ExpiryReminderDayOptions are the offsets a user can choose, in days before
+// a voucher or gift card expires
+var ExpiryReminderDayOptions = []int{90, 60, 30, 14, 7, 3, 1}
+
+// DefaultExpiryReminderDays are used until a user changes their reminders
+var DefaultExpiryReminderDays = ReminderDays{30, 7, 1}
+
+// ReminderDays is a set of offsets in days, stored as comma separated list
+type ReminderDays []int
+
+// Value implements the driver.Valuer interface
+func (d ReminderDays) Value() (driver.Value, error) {
+	parts := make([]string, len(d))
+	for i, days := range d {
+		parts[i] = strconv.Itoa(days)
+	}
+	return strings.Join(parts, ","), nil
+}
+
+// Scan implements the sql.Scanner interface
+func (d *ReminderDays) Scan(value interface{}) error {
+	var s string
+	switch v := value.(type) {
+	case nil:
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	default:
+		return fmt.Errorf("cannot scan %T into ReminderDays", value)
+	}
+
+	*d = ReminderDays{}
+	for _, part := range strings.Split(s, ",") {
+		if part == "" {
+			continue
+		}
+		days, err := strconv.Atoi(part)
+		if err != nil {
+			return err
+		}
+		*d = append(*d, days)
+	}
+	return nil
+}
+
+// Normalize sorts the offsets from the earliest reminder to the latest and
+// drops duplicates and offsets that cannot be chosen
+func (d ReminderDays) Normalize() ReminderDays {
+	normalized := ReminderDays{}
+	for _, days := range ExpiryReminderDayOptions {
+		if slices.Contains(d, days) {
+			normalized = append(normalized, days)
+		}
+	}
+	return normalized
+}
+
+// ExpiryReminderPreference stores how many days before an item expires a
+// user is reminded. Without a row DefaultExpiryReminderDays apply, an empty
+// list turns the reminders off.
+type ExpiryReminderPreference struct {
+	UserID    uuid.UUID    `gorm:"type:uuid;primaryKey" json:"user_id"`
+	Days      ReminderDays `gorm:"type:varchar(50);not null" json:"days"`
+	UpdatedAt time.Time    `json:"updated_at"`
+}
+
+// TableName specifies the table name for ExpiryReminderPreference
+func (ExpiryReminderPreference) TableName() string {
+	return "expiry_reminder_preferences"
+}
+
+// ExpiryReminder records that a user was reminded of an item for one offset,
+// so each reminder is sent only once. A new expiry date starts over.
+type ExpiryReminder struct {
+	ID           uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	UserID       uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_expiry_reminders_unique,priority:1" json:"user_id"`
+	ResourceType string    `gorm:"type:varchar(50);not null;uniqueIndex:idx_expiry_reminders_unique,priority:2" json:"resource_type"` // "voucher" or "gift_card"
+	ResourceID   uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_expiry_reminders_unique,priority:3" json:"resource_id"`
+	Days         int       `gorm:"not null;uniqueIndex:idx_expiry_reminders_unique,priority:4" json:"days"`
+	ExpiresAt    time.Time `gorm:"type:timestamp with time zone;not null;uniqueIndex:idx_expiry_reminders_unique,priority:5;index" json:"expires_at"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// TableName specifies the table name for ExpiryReminder
+func (ExpiryReminder) TableName() string {
+	return "expiry_reminders"
+}
+
+// BeforeCreate ensures a UUID is generated before creating a reminder
+func (r *ExpiryReminder) BeforeCreate(_ *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+// ExpiringItem is a voucher or gift card that expires soon, with everybody
+// who can see it
+type ExpiringItem struct {
+	ResourceType string // "voucher" or "gift_card"
+	ResourceID   uuid.UUID
+	Name         string // Merchant
+	ExpiresAt    time.Time
+	UserIDs      []uuid.UUID // Owner first, then the users it is shared with
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReminderDays_ValueScan(t *testing.T) {
+	value, err := ReminderDays{30, 7, 1}.Value()
+	require.NoError(t, err)
+	assert.Equal(t, "30,7,1", value)
+
+	var days ReminderDays
+	require.NoError(t, days.Scan([]byte("30,7,1")))
+	assert.Equal(t, ReminderDays{30, 7, 1}, days)
+
+	require.NoError(t, days.Scan(""))
+	assert.Empty(t, days, "Reminders turned off")
+
+	assert.Error(t, days.Scan("30,soon"))
+}
+
+func TestReminderDays_Normalize(t *testing.T) {
+	assert.Equal(t, ReminderDays{30, 7, 1}, ReminderDays{1, 7, 30, 7}.Normalize())
+	assert.Equal(t, ReminderDays{14}, ReminderDays{14, 5, 400}.Normalize(), "Only offered offsets")
+	assert.Empty(t, ReminderDays(nil).Normalize())
+}
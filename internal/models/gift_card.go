@@ -2,9 +2,12 @@
 package models
 
 import (
-	"math"
+	"sort"
 	"time"
 
+	_ "savvy/internal/encryption" // Registers the serializers for encrypted columns
+	"savvy/internal/money"
+
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
@@ -17,11 +20,11 @@ type GiftCard struct {
 	MerchantID     *uuid.UUID     `gorm:"type:uuid;index" json:"merchant_id"`
 	Merchant       *Merchant      `gorm:"foreignKey:MerchantID" json:"merchant,omitempty"`
 	MerchantName   string         `gorm:"default:''" json:"merchant_name"` // Retailer as fallback
-	CardNumber     string         `gorm:"uniqueIndex;not null" json:"card_number"`
-	InitialBalance float64        `gorm:"not null" json:"initial_balance"`
-	CurrentBalance float64        `gorm:"not null" json:"current_balance"` // Cached balance (auto-updated by trigger)
+	CardNumber     string         `gorm:"uniqueIndex;not null;serializer:encrypted_identifier" json:"card_number"`
+	InitialBalance money.Money    `gorm:"not null" json:"initial_balance"`
+	CurrentBalance money.Money    `gorm:"not null" json:"current_balance"` // Cached balance (auto-updated by trigger)
 	Currency       string         `gorm:"default:CHF" json:"currency"`
-	PIN            string         `json:"pin"`
+	PIN            string         `gorm:"serializer:encrypted" json:"-"` // Only revealed after re-authentication, see handlers/giftcards/pin.go
 	ExpiresAt      *time.Time     `json:"expires_at"`
 	Status         string         `gorm:"default:active" json:"status"`
 	BarcodeType    string         `gorm:"default:CODE128" json:"barcode_type"`
@@ -33,12 +36,38 @@ type GiftCard struct {
 	Transactions []GiftCardTransaction `gorm:"foreignKey:GiftCardID" json:"transactions,omitempty"`
 }
 
+// AfterFind sets the gift card currency on the balances and preloaded
+// transactions, whose columns only hold minor units
+func (g *GiftCard) AfterFind(_ *gorm.DB) error {
+	g.InitialBalance.Currency = g.Currency
+	g.CurrentBalance.Currency = g.Currency
+	for i := range g.Transactions {
+		transaction := &g.Transactions[i]
+		transaction.Amount.Currency = g.Currency
+		for j := range transaction.Revisions {
+			transaction.Revisions[j].Amount.Currency = g.Currency
+		}
+		// Newest revision first
+		sort.SliceStable(transaction.Revisions, func(a, b int) bool {
+			return transaction.Revisions[a].CreatedAt.After(transaction.Revisions[b].CreatedAt)
+		})
+	}
+	return nil
+}
+
 // GetCurrentBalance returns the cached current balance
 // Note: Balance is automatically maintained by database trigger on gift_card_transactions
 // This method is kept for backward compatibility but now just returns the cached value
-func (g *GiftCard) GetCurrentBalance() float64 {
-	// Round to 2 decimal places to avoid floating point precision issues
-	return math.Round(g.CurrentBalance*100) / 100
+func (g *GiftCard) GetCurrentBalance() money.Money {
+	return money.New(g.CurrentBalance.Amount, g.Currency)
+}
+
+// RemainingPercent returns the current balance as percentage of the initial balance
+func (g *GiftCard) RemainingPercent() int {
+	if g.InitialBalance.Amount <= 0 {
+		return 0
+	}
+	return int(g.CurrentBalance.Amount * 100 / g.InitialBalance.Amount)
 }
 
 // GetColor returns the merchant color or a default red
@@ -59,19 +88,19 @@ func (g *GiftCard) IsExpired() bool {
 
 // IsEmpty checks if the gift card has no balance left
 func (g *GiftCard) IsEmpty() bool {
-	return g.CurrentBalance <= 0
+	return g.CurrentBalance.Amount <= 0
 }
 
 // GetComputedStatus returns the computed status based on balance and expiry
 // Returns: "redeemed" (balance 0), "expired" (date passed + balance > 0), "active"
 func (g *GiftCard) GetComputedStatus() string {
 	if g.IsEmpty() {
-		return "redeemed"
+		return ItemStatusRedeemed
 	}
 	if g.IsExpired() {
-		return "expired"
+		return ItemStatusExpired
 	}
-	return "active"
+	return ItemStatusActive
 }
 
 // IsUsable checks if the gift card can still be used
@@ -79,12 +108,41 @@ func (g *GiftCard) IsUsable() bool {
 	return !g.IsExpired() && !g.IsEmpty()
 }
 
-// GiftCardTransaction represents a transaction (purchase or reload) on a gift card
+// Transaction kinds. Purchases lower the balance, reloads and refunds raise it,
+// adjustments carry a signed amount (e.g. to correct a balance after a
+// merchant statement).
+const (
+	TransactionKindPurchase   = "purchase"
+	TransactionKindReload     = "reload"
+	TransactionKindRefund     = "refund"
+	TransactionKindAdjustment = "adjustment"
+)
+
+// TransactionKinds lists all transaction kinds in display order
+var TransactionKinds = []string{
+	TransactionKindPurchase,
+	TransactionKindReload,
+	TransactionKindRefund,
+	TransactionKindAdjustment,
+}
+
+// IsValidTransactionKind reports whether kind is one of TransactionKinds
+func IsValidTransactionKind(kind string) bool {
+	for _, k := range TransactionKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// GiftCardTransaction represents a transaction (purchase, reload, refund or adjustment) on a gift card
 type GiftCardTransaction struct {
 	ID              uuid.UUID      `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
 	GiftCardID      uuid.UUID      `gorm:"type:uuid;index;not null" json:"gift_card_id"`
 	GiftCard        *GiftCard      `gorm:"foreignKey:GiftCardID" json:"gift_card,omitempty"`
-	Amount          float64        `gorm:"not null" json:"amount"`
+	Kind            string         `gorm:"type:varchar(20);not null;default:purchase" json:"kind"`
+	Amount          money.Money    `gorm:"not null" json:"amount"` // In the gift card currency, positive unless Kind is adjustment
 	Description     string         `gorm:"type:text" json:"description"`
 	TransactionDate time.Time      `gorm:"default:CURRENT_TIMESTAMP" json:"transaction_date"`
 	CreatedByUserID *uuid.UUID     `gorm:"type:uuid;index" json:"created_by_user_id"`
@@ -92,6 +150,51 @@ type GiftCardTransaction struct {
 	CreatedAt       time.Time      `json:"created_at"`
 	UpdatedAt       time.Time      `json:"updated_at"`
 	DeletedAt       gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+
+	Revisions []GiftCardTransactionRevision `gorm:"foreignKey:TransactionID" json:"revisions,omitempty"`
+}
+
+// AfterFind sets the currency on the amount if the gift card was preloaded.
+// Transactions loaded on their own must preload GiftCard, otherwise the amount
+// is formatted with the default exponent. Transactions preloaded with their
+// gift card get the currency from GiftCard.AfterFind.
+func (t *GiftCardTransaction) AfterFind(_ *gorm.DB) error {
+	if t.GiftCard != nil {
+		t.Amount.Currency = t.GiftCard.Currency
+	}
+	return nil
+}
+
+// BalanceEffect returns the signed change the transaction makes to the gift
+// card balance. Must match gift_card_transaction_delta() in the migrations.
+func (t *GiftCardTransaction) BalanceEffect() money.Money {
+	if t.Kind == TransactionKindPurchase || t.Kind == "" {
+		return t.Amount.Neg()
+	}
+	return t.Amount
+}
+
+// GiftCardTransactionRevision keeps the values a transaction had before an edit
+type GiftCardTransactionRevision struct {
+	ID              uuid.UUID   `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	TransactionID   uuid.UUID   `gorm:"type:uuid;index;not null" json:"transaction_id"`
+	Amount          money.Money `gorm:"not null" json:"amount"` // Previous amount
+	Description     string      `gorm:"type:text" json:"description"`
+	TransactionDate time.Time   `json:"transaction_date"`
+	EditedByUserID  *uuid.UUID  `gorm:"type:uuid;index" json:"edited_by_user_id"`
+	EditedByUser    *User       `gorm:"foreignKey:EditedByUserID" json:"edited_by_user,omitempty"`
+	CreatedAt       time.Time   `json:"created_at"` // Time of the edit
+}
+
+// NewRevision captures the current values of the transaction before an edit by userID
+func (t *GiftCardTransaction) NewRevision(userID uuid.UUID) GiftCardTransactionRevision {
+	return GiftCardTransactionRevision{
+		TransactionID:   t.ID,
+		Amount:          t.Amount,
+		Description:     t.Description,
+		TransactionDate: t.TransactionDate,
+		EditedByUserID:  &userID,
+	}
 }
 
 // GiftCardShare represents a shared gift card with granular permissions
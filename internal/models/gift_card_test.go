@@ -2,6 +2,9 @@ package models
 
 import (
 	"testing"
+	"time"
+
+	"savvy/internal/money"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -30,36 +33,64 @@ func TestGiftCard_GetColor_DefaultRed(t *testing.T) {
 
 func TestGiftCard_GetCurrentBalance_Positive(t *testing.T) {
 	giftCard := &GiftCard{
-		InitialBalance: 100.0,
-		CurrentBalance: 75.50,
+		InitialBalance: money.New(10000, "CHF"),
+		CurrentBalance: money.New(7550, "CHF"),
+		Currency:       "CHF",
 	}
 
-	assert.Equal(t, 75.50, giftCard.GetCurrentBalance())
+	assert.Equal(t, money.New(7550, "CHF"), giftCard.GetCurrentBalance())
+	assert.Equal(t, "75.50 CHF", giftCard.GetCurrentBalance().String())
 }
 
 func TestGiftCard_GetCurrentBalance_Zero(t *testing.T) {
 	giftCard := &GiftCard{
-		InitialBalance: 50.0,
-		CurrentBalance: 0.0,
+		InitialBalance: money.New(5000, "CHF"),
+		CurrentBalance: money.New(0, "CHF"),
+		Currency:       "CHF",
+	}
+
+	assert.True(t, giftCard.GetCurrentBalance().IsZero())
+}
+
+func TestGiftCard_GetCurrentBalance_UsesCardCurrency(t *testing.T) {
+	// Balances scanned from the database carry no currency yet
+	giftCard := &GiftCard{
+		CurrentBalance: money.Money{Amount: 5000},
+		Currency:       "JPY",
+	}
+
+	assert.Equal(t, "5000 JPY", giftCard.GetCurrentBalance().String())
+}
+
+func TestGiftCard_AfterFind_SetsCurrency(t *testing.T) {
+	giftCard := &GiftCard{
+		InitialBalance: money.Money{Amount: 10000},
+		CurrentBalance: money.Money{Amount: 7500},
+		Currency:       "EUR",
+		Transactions:   []GiftCardTransaction{{Amount: money.Money{Amount: 2500}}},
 	}
 
-	assert.Equal(t, 0.0, giftCard.GetCurrentBalance())
+	assert.NoError(t, giftCard.AfterFind(nil))
+
+	assert.Equal(t, "EUR", giftCard.InitialBalance.Currency)
+	assert.Equal(t, "EUR", giftCard.CurrentBalance.Currency)
+	assert.Equal(t, "EUR", giftCard.Transactions[0].Amount.Currency)
 }
 
-func TestGiftCard_GetCurrentBalance_Rounding(t *testing.T) {
+func TestGiftCard_RemainingPercent(t *testing.T) {
 	giftCard := &GiftCard{
-		InitialBalance: 100.0,
-		CurrentBalance: 33.33333333,
+		InitialBalance: money.New(20000, "CHF"),
+		CurrentBalance: money.New(5000, "CHF"),
 	}
+	assert.Equal(t, 25, giftCard.RemainingPercent())
 
-	// Should round to 2 decimal places
-	assert.Equal(t, 33.33, giftCard.GetCurrentBalance())
+	assert.Equal(t, 0, (&GiftCard{}).RemainingPercent())
 }
 
 func TestGiftCard_DefaultCurrency(t *testing.T) {
 	giftCard := &GiftCard{
-		InitialBalance: 100.0,
-		CurrentBalance: 100.0,
+		InitialBalance: money.New(10000, "CHF"),
+		CurrentBalance: money.New(10000, "CHF"),
 		Currency:       "CHF", // Default currency
 	}
 
@@ -68,8 +99,8 @@ func TestGiftCard_DefaultCurrency(t *testing.T) {
 
 func TestGiftCard_CustomCurrency(t *testing.T) {
 	giftCard := &GiftCard{
-		InitialBalance: 100.0,
-		CurrentBalance: 100.0,
+		InitialBalance: money.New(10000, "CHF"),
+		CurrentBalance: money.New(10000, "CHF"),
 		Currency:       "EUR",
 	}
 
@@ -78,8 +109,8 @@ func TestGiftCard_CustomCurrency(t *testing.T) {
 
 func TestGiftCard_Status_Active(t *testing.T) {
 	giftCard := &GiftCard{
-		InitialBalance: 100.0,
-		CurrentBalance: 75.0,
+		InitialBalance: money.New(10000, "CHF"),
+		CurrentBalance: money.New(7500, "CHF"),
 		Status:         "active",
 	}
 
@@ -88,8 +119,8 @@ func TestGiftCard_Status_Active(t *testing.T) {
 
 func TestGiftCard_Status_Inactive(t *testing.T) {
 	giftCard := &GiftCard{
-		InitialBalance: 100.0,
-		CurrentBalance: 0.0,
+		InitialBalance: money.New(10000, "CHF"),
+		CurrentBalance: money.New(0, "CHF"),
 		Status:         "inactive",
 	}
 
@@ -111,13 +142,80 @@ func TestGiftCard_BeforeCreate(t *testing.T) {
 
 func TestGiftCard_WithTransactions(t *testing.T) {
 	giftCard := &GiftCard{
-		InitialBalance: 100.0,
-		CurrentBalance: 75.0,
+		InitialBalance: money.New(10000, "CHF"),
+		CurrentBalance: money.New(7500, "CHF"),
 		Transactions: []GiftCardTransaction{
-			{Amount: -25.0, Description: "Purchase"},
+			{Amount: money.New(-2500, "CHF"), Description: "Purchase"},
 		},
 	}
 
 	assert.Len(t, giftCard.Transactions, 1)
-	assert.Equal(t, -25.0, giftCard.Transactions[0].Amount)
+	assert.Equal(t, int64(-2500), giftCard.Transactions[0].Amount.Amount)
+}
+
+func TestGiftCardTransaction_BalanceEffect(t *testing.T) {
+	tests := []struct {
+		kind   string
+		amount int64
+		want   int64
+	}{
+		{TransactionKindPurchase, 2500, -2500},
+		{"", 2500, -2500}, // Legacy rows without kind are purchases
+		{TransactionKindReload, 5000, 5000},
+		{TransactionKindRefund, 1990, 1990},
+		{TransactionKindAdjustment, -150, -150},
+		{TransactionKindAdjustment, 300, 300},
+	}
+
+	for _, tt := range tests {
+		tx := GiftCardTransaction{Kind: tt.kind, Amount: money.New(tt.amount, "CHF")}
+		assert.Equal(t, money.New(tt.want, "CHF"), tx.BalanceEffect(), tt.kind)
+	}
+}
+
+func TestIsValidTransactionKind(t *testing.T) {
+	for _, kind := range TransactionKinds {
+		assert.True(t, IsValidTransactionKind(kind), kind)
+	}
+	assert.False(t, IsValidTransactionKind(""))
+	assert.False(t, IsValidTransactionKind("withdrawal"))
+}
+
+func TestGiftCardTransaction_NewRevision(t *testing.T) {
+	userID := uuid.New()
+	tx := GiftCardTransaction{
+		ID:              uuid.New(),
+		Amount:          money.New(2500, "CHF"),
+		Description:     "Kopfhörer",
+		TransactionDate: time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	revision := tx.NewRevision(userID)
+
+	assert.Equal(t, tx.ID, revision.TransactionID)
+	assert.Equal(t, tx.Amount, revision.Amount)
+	assert.Equal(t, "Kopfhörer", revision.Description)
+	assert.Equal(t, tx.TransactionDate, revision.TransactionDate)
+	assert.Equal(t, &userID, revision.EditedByUserID)
+}
+
+func TestGiftCard_AfterFind_SortsRevisions(t *testing.T) {
+	older := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	newer := older.Add(time.Hour)
+	giftCard := &GiftCard{
+		Currency: "EUR",
+		Transactions: []GiftCardTransaction{{
+			Revisions: []GiftCardTransactionRevision{
+				{Amount: money.Money{Amount: 1000}, CreatedAt: older},
+				{Amount: money.Money{Amount: 2000}, CreatedAt: newer},
+			},
+		}},
+	}
+
+	assert.NoError(t, giftCard.AfterFind(nil))
+
+	revisions := giftCard.Transactions[0].Revisions
+	assert.Equal(t, newer, revisions[0].CreatedAt)
+	assert.Equal(t, "EUR", revisions[0].Amount.Currency)
+	assert.Equal(t, "EUR", revisions[1].Amount.Currency)
 }
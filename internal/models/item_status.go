@@ -0,0 +1,86 @@
+// Package models defines the database models for the savvy system.
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Statuses of vouchers and gift cards. The lifecycle job moves items between
+// active, expired and redeemed, a status set by hand like inactive is kept.
+const (
+	ItemStatusActive   = "active"
+	ItemStatusInactive = "inactive"
+	ItemStatusExpired  = "expired"
+	ItemStatusRedeemed = "redeemed"
+)
+
+// Reasons of a status change
+const (
+	// StatusReasonExpired is recorded when the expiry date has passed
+	StatusReasonExpired = "expired"
+	// StatusReasonDepleted is recorded when a gift card balance reached zero
+	StatusReasonDepleted = "depleted"
+	// StatusReasonRenewed is recorded when an item became usable again,
+	// e.g. after its expiry date was extended or its balance reloaded
+	StatusReasonRenewed = "renewed"
+)
+
+// VoucherStatuses are the statuses the lifecycle job assigns to vouchers
+var VoucherStatuses = []string{ItemStatusActive, ItemStatusExpired}
+
+// GiftCardStatuses are the statuses the lifecycle job assigns to gift cards
+var GiftCardStatuses = []string{ItemStatusActive, ItemStatusExpired, ItemStatusRedeemed}
+
+// IsManualItemStatus reports whether status may be set by hand. Expired and
+// redeemed are derived by the lifecycle job, which would revert them.
+func IsManualItemStatus(status string) bool {
+	return status == ItemStatusActive || status == ItemStatusInactive
+}
+
+// StatusChangeReason returns why an item moved to status
+func StatusChangeReason(status string) string {
+	switch status {
+	case ItemStatusExpired:
+		return StatusReasonExpired
+	case ItemStatusRedeemed:
+		return StatusReasonDepleted
+	default:
+		return StatusReasonRenewed
+	}
+}
+
+// ItemStatusChange records one automatic status transition of a voucher or
+// gift card
+type ItemStatusChange struct {
+	ID           uuid.UUID  `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	ResourceType string     `gorm:"type:varchar(50);not null;index:idx_item_status_changes_resource,priority:1" json:"resource_type"` // "voucher" or "gift_card"
+	ResourceID   uuid.UUID  `gorm:"type:uuid;not null;index:idx_item_status_changes_resource,priority:2" json:"resource_id"`
+	UserID       *uuid.UUID `gorm:"type:uuid;index" json:"user_id"` // Owner of the item at the time of the change
+	FromStatus   string     `gorm:"type:varchar(20);not null" json:"from_status"`
+	ToStatus     string     `gorm:"type:varchar(20);not null" json:"to_status"`
+	Reason       string     `gorm:"type:varchar(20);not null" json:"reason"`
+	CreatedAt    time.Time  `gorm:"index:idx_item_status_changes_resource,priority:3" json:"created_at"`
+}
+
+// TableName specifies the table name for ItemStatusChange
+func (ItemStatusChange) TableName() string {
+	return "item_status_changes"
+}
+
+// BeforeCreate ensures a UUID is generated before creating a status change
+func (c *ItemStatusChange) BeforeCreate(_ *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	return nil
+}
+
+// ItemStatusCount is the number of items of a type with a status
+type ItemStatusCount struct {
+	ResourceType string // "voucher" or "gift_card"
+	Status       string
+	Count        int64
+}
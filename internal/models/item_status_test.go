@@ -0,0 +1,17 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatusChangeReason(t *testing.T) {
+	assert.Equal(t, StatusReasonExpired, StatusChangeReason(ItemStatusExpired))
+	assert.Equal(t, StatusReasonDepleted, StatusChangeReason(ItemStatusRedeemed))
+	assert.Equal(t, StatusReasonRenewed, StatusChangeReason(ItemStatusActive))
+}
+
+func TestItemStatusChange_TableName(t *testing.T) {
+	assert.Equal(t, "item_status_changes", ItemStatusChange{}.TableName())
+}
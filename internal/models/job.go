@@ -0,0 +1,70 @@
+// Package models defines the database models for the savvy system.
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// JobRunStatus is the outcome of a job run
+type JobRunStatus string
+
+const (
+	// JobRunRunning has started and not finished yet, or its replica died
+	JobRunRunning JobRunStatus = "running"
+	// JobRunSucceeded finished without error
+	JobRunSucceeded JobRunStatus = "succeeded"
+	// JobRunFailed returned an error, panicked or timed out
+	JobRunFailed JobRunStatus = "failed"
+)
+
+// Job is the state of a scheduled background job, shared by all replicas.
+// LastScheduledAt is the slot of the latest run, so each slot runs only once.
+type Job struct {
+	Name            string        `gorm:"type:varchar(100);primaryKey" json:"name"`
+	Schedule        string        `gorm:"type:varchar(100);not null" json:"schedule"`
+	Local           bool          `gorm:"not null" json:"local"` // Runs on every replica
+	LastScheduledAt *time.Time    `gorm:"type:timestamp with time zone" json:"last_scheduled_at,omitempty"`
+	LastStartedAt   *time.Time    `gorm:"type:timestamp with time zone" json:"last_started_at,omitempty"`
+	LastFinishedAt  *time.Time    `gorm:"type:timestamp with time zone" json:"last_finished_at,omitempty"`
+	LastStatus      JobRunStatus  `gorm:"type:varchar(10);not null;default:''" json:"last_status,omitempty"`
+	LastError       string        `gorm:"type:text;not null;default:''" json:"last_error,omitempty"`
+	LastDuration    time.Duration `gorm:"not null;default:0" json:"last_duration"`
+	LastInstance    string        `gorm:"type:varchar(255);not null;default:''" json:"last_instance,omitempty"` // Host name of the replica
+	LastErrorAt     *time.Time    `gorm:"type:timestamp with time zone" json:"last_error_at,omitempty"`
+	CreatedAt       time.Time     `json:"created_at"`
+	UpdatedAt       time.Time     `json:"updated_at"`
+}
+
+// TableName specifies the table name for Job
+func (Job) TableName() string {
+	return "jobs"
+}
+
+// JobRun is one run of a job, kept as history for the admin page
+type JobRun struct {
+	ID          uuid.UUID     `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	JobName     string        `gorm:"type:varchar(100);not null;index:idx_job_runs_job_started,priority:1" json:"job_name"`
+	Instance    string        `gorm:"type:varchar(255);not null" json:"instance"`
+	ScheduledAt time.Time     `gorm:"type:timestamp with time zone;not null" json:"scheduled_at"`
+	StartedAt   time.Time     `gorm:"type:timestamp with time zone;not null;index:idx_job_runs_job_started,priority:2,sort:desc;index" json:"started_at"`
+	FinishedAt  *time.Time    `gorm:"type:timestamp with time zone" json:"finished_at,omitempty"`
+	Status      JobRunStatus  `gorm:"type:varchar(10);not null" json:"status"`
+	Error       string        `gorm:"type:text;not null;default:''" json:"error,omitempty"`
+	Duration    time.Duration `gorm:"not null;default:0" json:"duration"`
+}
+
+// TableName specifies the table name for JobRun
+func (JobRun) TableName() string {
+	return "job_runs"
+}
+
+// BeforeCreate ensures a UUID is generated before creating a run
+func (r *JobRun) BeforeCreate(_ *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
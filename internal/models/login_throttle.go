@@ -0,0 +1,47 @@
+// Package models defines the database models for the savvy system.
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Scopes of LoginThrottle
+const (
+	// LoginThrottleScopeAccount counts failures per email address, whether or not an account exists
+	LoginThrottleScopeAccount = "account"
+	// LoginThrottleScopeIP counts failures per client IP across all addresses
+	LoginThrottleScopeIP = "ip"
+)
+
+// LoginThrottle counts recent failed logins for an email address or a client
+// IP. Rows are kept until the failures no longer count and a lockout expired.
+type LoginThrottle struct {
+	ID            uuid.UUID  `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	Scope         string     `gorm:"type:varchar(10);not null;uniqueIndex:idx_login_throttles_scope_subject" json:"scope"`
+	Subject       string     `gorm:"type:varchar(255);not null;uniqueIndex:idx_login_throttles_scope_subject" json:"subject"` // Lowercase email or IP
+	Failures      int        `gorm:"not null;default:0" json:"failures"`
+	LastFailureAt time.Time  `gorm:"type:timestamp with time zone;not null;index" json:"last_failure_at"`
+	LockedUntil   *time.Time `gorm:"type:timestamp with time zone" json:"locked_until,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// TableName specifies the table name for LoginThrottle
+func (LoginThrottle) TableName() string {
+	return "login_throttles"
+}
+
+// BeforeCreate ensures a UUID is generated before creating a throttle
+func (t *LoginThrottle) BeforeCreate(_ *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return nil
+}
+
+// IsLocked reports whether logins are blocked at the given time
+func (t *LoginThrottle) IsLocked(at time.Time) bool {
+	return t.LockedUntil != nil && t.LockedUntil.After(at)
+}
@@ -4,6 +4,7 @@ package models
 import (
 	"database/sql/driver"
 	"encoding/json"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -18,6 +19,10 @@ const (
 	NotificationTypeShareReceived NotificationType = "share_received"
 	// NotificationTypeTransferReceived is sent when resource ownership is transferred to the user
 	NotificationTypeTransferReceived NotificationType = "transfer_received"
+	// NotificationTypeAccountLocked is sent when logins to the user's account were blocked after failed attempts
+	NotificationTypeAccountLocked NotificationType = "account_locked"
+	// NotificationTypeExpiringSoon is sent some days before a voucher or gift card of the user runs out
+	NotificationTypeExpiringSoon NotificationType = "expiring_soon"
 )
 
 // NotificationMetadata represents the JSONB metadata stored with a notification
@@ -52,7 +57,7 @@ type Notification struct {
 	ID           uuid.UUID            `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
 	UserID       uuid.UUID            `gorm:"type:uuid;not null;index" json:"user_id"`
 	Type         NotificationType     `gorm:"type:varchar(50);not null" json:"type"`
-	ResourceType string               `gorm:"type:varchar(50);not null" json:"resource_type"` // "card", "voucher", "gift_card"
+	ResourceType string               `gorm:"type:varchar(50);not null" json:"resource_type"` // "card", "voucher", "gift_card", "account"
 	ResourceID   uuid.UUID            `gorm:"type:uuid;not null" json:"resource_id"`
 	Metadata     NotificationMetadata `gorm:"type:jsonb;default:'{}'" json:"metadata"`
 	IsRead       bool                 `gorm:"default:false" json:"is_read"`
@@ -113,3 +118,68 @@ func (n *Notification) IsShareNotification() bool {
 func (n *Notification) IsTransferNotification() bool {
 	return n.Type == NotificationTypeTransferReceived
 }
+
+// IsAccountLockedNotification returns true if this is a lockout notification
+func (n *Notification) IsAccountLockedNotification() bool {
+	return n.Type == NotificationTypeAccountLocked
+}
+
+// IsExpiringSoonNotification returns true if this is an expiry reminder
+func (n *Notification) IsExpiringSoonNotification() bool {
+	return n.Type == NotificationTypeExpiringSoon
+}
+
+// GetItemName returns the merchant of the item an expiry reminder is about
+func (n *Notification) GetItemName() string {
+	if name, ok := n.Metadata["name"].(string); ok {
+		return name
+	}
+	return ""
+}
+
+// GetExpiresAt returns when the item of an expiry reminder runs out, nil if unknown
+func (n *Notification) GetExpiresAt() *time.Time {
+	value, ok := n.Metadata["expires_at"].(string)
+	if !ok {
+		return nil
+	}
+	expiresAt, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil
+	}
+	return &expiresAt
+}
+
+// GetLockedUntil returns until when logins were blocked, nil if unknown
+func (n *Notification) GetLockedUntil() *time.Time {
+	value, ok := n.Metadata["locked_until"].(string)
+	if !ok {
+		return nil
+	}
+	until, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil
+	}
+	return &until
+}
+
+// ResourcePath returns the page that shows the resource of the notification
+func (n *Notification) ResourcePath() string {
+	return NotificationResourcePath(n.ResourceType, n.ResourceID.String())
+}
+
+// NotificationResourcePath returns the page of a notification resource
+func NotificationResourcePath(resourceType, resourceID string) string {
+	switch resourceType {
+	case "card":
+		return fmt.Sprintf("/cards/%s", resourceID)
+	case "voucher":
+		return fmt.Sprintf("/vouchers/%s", resourceID)
+	case "gift_card":
+		return fmt.Sprintf("/gift-cards/%s", resourceID)
+	case "account":
+		return "/account"
+	default:
+		return "/"
+	}
+}
@@ -0,0 +1,111 @@
+// Package models defines the database models for the savvy system.
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// NotificationChannel is a way to deliver a notification outside the app
+type NotificationChannel string
+
+const (
+	// NotificationChannelEmail sends the notification to the address of the account
+	NotificationChannelEmail NotificationChannel = "email"
+	// NotificationChannelPush sends the notification to the subscribed browsers via Web Push
+	NotificationChannelPush NotificationChannel = "push"
+)
+
+// NotificationChannels lists the delivery channels in the order they are shown
+var NotificationChannels = []NotificationChannel{NotificationChannelEmail, NotificationChannelPush}
+
+// DeliveredNotificationTypes are the notification types fanned out to the
+// delivery channels. Lockouts are not included, they send their own email.
+var DeliveredNotificationTypes = []NotificationType{
+	NotificationTypeShareReceived,
+	NotificationTypeTransferReceived,
+	NotificationTypeExpiringSoon,
+}
+
+// NotificationDeliveryStatus is the state of a delivery
+type NotificationDeliveryStatus string
+
+const (
+	// NotificationDeliveryPending waits for its first or next attempt
+	NotificationDeliveryPending NotificationDeliveryStatus = "pending"
+	// NotificationDeliverySent was handed to the mail server or push service
+	NotificationDeliverySent NotificationDeliveryStatus = "sent"
+	// NotificationDeliverySkipped had nothing left to send, e.g. the
+	// notification was archived or the browser unsubscribed
+	NotificationDeliverySkipped NotificationDeliveryStatus = "skipped"
+	// NotificationDeliveryFailed gave up after the last retry
+	NotificationDeliveryFailed NotificationDeliveryStatus = "failed"
+)
+
+// NotificationDelivery is the queue entry that sends one notification on one
+// channel. It is written together with the notification and retried with
+// backoff until it was sent, so a failed send survives restarts.
+type NotificationDelivery struct {
+	ID             uuid.UUID                  `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	NotificationID uuid.UUID                  `gorm:"type:uuid;not null;index" json:"notification_id"`
+	UserID         uuid.UUID                  `gorm:"type:uuid;not null;index" json:"user_id"`
+	Channel        NotificationChannel        `gorm:"type:varchar(10);not null" json:"channel"`
+	Status         NotificationDeliveryStatus `gorm:"type:varchar(10);not null;index" json:"status"`
+	Language       string                     `gorm:"type:varchar(10);not null;default:''" json:"language"` // Language of the request that caused the notification
+	Attempts       int                        `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt  time.Time                  `gorm:"type:timestamp with time zone;not null" json:"next_attempt_at"`
+	LastError      string                     `gorm:"type:text;not null;default:''" json:"last_error,omitempty"`
+	SentAt         *time.Time                 `gorm:"type:timestamp with time zone" json:"sent_at,omitempty"`
+	CreatedAt      time.Time                  `json:"created_at"`
+	UpdatedAt      time.Time                  `json:"updated_at"`
+
+	// Associations
+	Notification *Notification `gorm:"foreignKey:NotificationID" json:"notification,omitempty"`
+}
+
+// TableName specifies the table name for NotificationDelivery
+func (NotificationDelivery) TableName() string {
+	return "notification_deliveries"
+}
+
+// BeforeCreate ensures a UUID is generated before creating a delivery
+func (d *NotificationDelivery) BeforeCreate(_ *gorm.DB) error {
+	if d.ID == uuid.Nil {
+		d.ID = uuid.New()
+	}
+	return nil
+}
+
+// NotificationPreference stores on which channels a user receives a type of
+// notification. Without a row both channels are enabled.
+type NotificationPreference struct {
+	UserID    uuid.UUID        `gorm:"type:uuid;primaryKey" json:"user_id"`
+	Type      NotificationType `gorm:"type:varchar(50);primaryKey" json:"type"`
+	Email     bool             `gorm:"not null" json:"email"`
+	Push      bool             `gorm:"not null" json:"push"`
+	UpdatedAt time.Time        `json:"updated_at"`
+}
+
+// TableName specifies the table name for NotificationPreference
+func (NotificationPreference) TableName() string {
+	return "notification_preferences"
+}
+
+// DefaultNotificationPreference returns the preference of a type the user never changed
+func DefaultNotificationPreference(userID uuid.UUID, notificationType NotificationType) NotificationPreference {
+	return NotificationPreference{UserID: userID, Type: notificationType, Email: true, Push: true}
+}
+
+// Enabled reports whether the channel is switched on
+func (p NotificationPreference) Enabled(channel NotificationChannel) bool {
+	switch channel {
+	case NotificationChannelEmail:
+		return p.Email
+	case NotificationChannelPush:
+		return p.Push
+	default:
+		return false
+	}
+}
@@ -0,0 +1,35 @@
+// Package models defines the database models for the savvy system.
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PushSubscription is the Web Push endpoint of one browser of a user, as
+// returned by PushManager.subscribe() in the service worker registration.
+type PushSubscription struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	UserID     uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	Endpoint   string     `gorm:"type:text;not null;uniqueIndex" json:"endpoint"` // URL of the browser vendor's push service
+	P256dh     string     `gorm:"type:varchar(255);not null" json:"-"`            // Public key of the browser, encrypts the payload
+	Auth       string     `gorm:"type:varchar(255);not null" json:"-"`            // Authentication secret of the browser
+	UserAgent  string     `gorm:"type:varchar(500);not null;default:''" json:"user_agent"`
+	LastUsedAt *time.Time `gorm:"type:timestamp with time zone" json:"last_used_at,omitempty"` // Last successful push
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// TableName specifies the table name for PushSubscription
+func (PushSubscription) TableName() string {
+	return "push_subscriptions"
+}
+
+// BeforeCreate ensures a UUID is generated before creating a subscription
+func (s *PushSubscription) BeforeCreate(_ *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
@@ -10,15 +10,24 @@ import (
 
 // User represents a user account in the system
 type User struct {
-	ID           uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
-	Email        string    `gorm:"uniqueIndex;not null" json:"email"`
-	PasswordHash string    `gorm:"not null" json:"-"`
-	FirstName    string    `gorm:"not null" json:"first_name"`
-	LastName     string    `gorm:"not null" json:"last_name"`
-	Role         string    `gorm:"default:user;not null" json:"role"`
-	AuthProvider string    `gorm:"default:local;not null" json:"auth_provider"` // "local" or "oauth"
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID              uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	Email           string    `gorm:"uniqueIndex;not null" json:"email"`
+	PasswordHash    string    `gorm:"not null" json:"-"`
+	FirstName       string    `gorm:"not null" json:"first_name"`
+	LastName        string    `gorm:"not null" json:"last_name"`
+	Role            string    `gorm:"default:user;not null" json:"role"`
+	AuthProvider    string    `gorm:"default:local;not null" json:"auth_provider"`                 // "local" or "oauth"
+	DisplayCurrency string    `gorm:"type:varchar(3);not null;default:''" json:"display_currency"` // Empty for the exchange rate base currency
+
+	EmailVerifiedAt *time.Time `gorm:"type:timestamp with time zone" json:"email_verified_at,omitempty"` // NULL until the address was confirmed
+
+	// TOTP two-factor authentication (local accounts only)
+	TOTPSecret    string     `gorm:"serializer:encrypted;not null;default:''" json:"-"`
+	TOTPEnabledAt *time.Time `gorm:"type:timestamp with time zone" json:"totp_enabled_at,omitempty"`
+	TOTPLastStep  int64      `gorm:"not null;default:0" json:"-"` // Last accepted time step, rejects replayed codes
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
 // BeforeCreate ensures a UUID is generated before creating a user
@@ -43,3 +52,37 @@ func (u *User) IsAdmin() bool {
 func (u *User) IsOAuthUser() bool {
 	return u.AuthProvider == "oauth"
 }
+
+// IsEmailVerified returns true if the user confirmed the email address.
+// Only verified users can be share targets.
+func (u *User) IsEmailVerified() bool {
+	return u.EmailVerifiedAt != nil
+}
+
+// HasTwoFactor returns true if the user enabled TOTP two-factor authentication
+func (u *User) HasTwoFactor() bool {
+	return u.TOTPEnabledAt != nil && u.TOTPSecret != ""
+}
+
+// UserRecoveryCode is a single-use code that replaces a TOTP code, e.g. when
+// the phone is lost. Only a hash is stored.
+type UserRecoveryCode struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	UserID    uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	CodeHash  string     `gorm:"type:char(64);not null;uniqueIndex" json:"-"`
+	UsedAt    *time.Time `gorm:"type:timestamp with time zone" json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// TableName specifies the table name for UserRecoveryCode
+func (UserRecoveryCode) TableName() string {
+	return "user_recovery_codes"
+}
+
+// BeforeCreate ensures a UUID is generated before creating a recovery code
+func (r *UserRecoveryCode) BeforeCreate(_ *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
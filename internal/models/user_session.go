@@ -0,0 +1,39 @@
+// Package models defines the database models for the savvy system.
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// UserSession is a server-side browser session. The cookie only carries the
+// signed session ID; the SHA-256 hash of that ID is the lookup key, so a
+// database dump cannot be used to hijack sessions. Logging out a device
+// deletes its row.
+type UserSession struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	TokenHash  string     `gorm:"type:char(64);not null;uniqueIndex" json:"-"`
+	UserID     *uuid.UUID `gorm:"type:uuid;index" json:"user_id,omitempty"` // Nil before login
+	Data       string     `gorm:"type:text;not null" json:"-"`              // Signed session values
+	Device     string     `gorm:"type:varchar(100);not null;default:''" json:"device"`
+	UserAgent  string     `gorm:"type:text;not null;default:''" json:"user_agent"`
+	IPAddress  string     `gorm:"type:varchar(45);not null;default:''" json:"ip_address"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastSeenAt time.Time  `gorm:"type:timestamp with time zone;not null" json:"last_seen_at"`
+	ExpiresAt  time.Time  `gorm:"type:timestamp with time zone;not null;index" json:"expires_at"`
+}
+
+// TableName specifies the table name for UserSession
+func (UserSession) TableName() string {
+	return "user_sessions"
+}
+
+// BeforeCreate ensures a UUID is generated before creating a session
+func (s *UserSession) BeforeCreate(_ *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
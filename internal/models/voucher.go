@@ -2,8 +2,13 @@
 package models
 
 import (
+	"encoding/json"
+	"math"
+	"strconv"
 	"time"
 
+	"savvy/internal/money"
+
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
@@ -16,20 +21,104 @@ type Voucher struct {
 	MerchantID        *uuid.UUID     `gorm:"type:uuid;index" json:"merchant_id"`
 	Merchant          *Merchant      `gorm:"foreignKey:MerchantID" json:"merchant,omitempty"`
 	MerchantName      string         `json:"merchant_name"` // Fallback for free text
-	Code              string         `gorm:"uniqueIndex;not null" json:"code"`
+	Code              string         `gorm:"uniqueIndex;not null;serializer:encrypted_identifier" json:"code"`
 	Type              string         `gorm:"not null" json:"type"` // percentage, fixed_amount, points_multiplier
-	Value             float64        `gorm:"not null" json:"value"`
+	Value             int64          `gorm:"not null" json:"-"`    // Minor units of VoucherCurrency for fixed_amount, basis points for percentage, hundredths for points_multiplier, encoded by MarshalJSON
 	Description       string         `gorm:"type:text" json:"description"`
-	MinPurchaseAmount float64        `gorm:"default:0" json:"min_purchase_amount"`
+	MinPurchaseAmount money.Money    `gorm:"default:0" json:"min_purchase_amount"`
 	ValidFrom         time.Time      `gorm:"not null" json:"valid_from"`
 	ValidUntil        time.Time      `gorm:"not null" json:"valid_until"`
 	UsageLimitType    string         `gorm:"default:single_use" json:"usage_limit_type"` // single_use, one_per_customer, multiple_use_with_card, multiple_use_without_card, unlimited
 	BarcodeType       string         `gorm:"default:CODE128" json:"barcode_type"`
+	Status            string         `gorm:"type:varchar(20);not null;default:active" json:"status"` // Kept up to date by the lifecycle job
 	CreatedAt         time.Time      `json:"created_at"`
 	UpdatedAt         time.Time      `json:"updated_at"`
 	DeletedAt         gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
 }
 
+// VoucherCurrency is the currency of fixed amount vouchers and minimum purchase amounts
+const VoucherCurrency = money.DefaultCurrency
+
+// voucherRatePlaces is the number of decimal places of percentages and
+// points multipliers, Value holds them in hundredths
+const voucherRatePlaces = 2
+
+// IsFixedVoucherType reports whether Value of the voucher type is an amount
+// of money instead of a percentage or points multiplier
+func IsFixedVoucherType(voucherType string) bool {
+	return voucherType == "fixed_amount"
+}
+
+// ParseVoucherValue reads a user-entered value of the voucher type, e.g.
+// "10.50" CHF or "12.5" percent
+func ParseVoucherValue(voucherType, input string) (int64, error) {
+	if IsFixedVoucherType(voucherType) {
+		amount, err := money.Parse(input, VoucherCurrency)
+		return amount.Amount, err
+	}
+	return money.ParseDecimal(input, voucherRatePlaces)
+}
+
+// VoucherValueFromFloat converts a decimal value of the voucher type, e.g.
+// from a JSON request, rounding half away from zero
+func VoucherValueFromFloat(voucherType string, value float64) int64 {
+	if IsFixedVoucherType(voucherType) {
+		return money.FromFloat(value, VoucherCurrency).Amount
+	}
+	return int64(math.Round(value * math.Pow10(voucherRatePlaces)))
+}
+
+// AfterFind sets the currency on the money fields, whose columns only hold minor units
+func (v *Voucher) AfterFind(_ *gorm.DB) error {
+	v.MinPurchaseAmount.Currency = VoucherCurrency
+	return nil
+}
+
+// Amount returns the discount of a fixed_amount voucher, zero for other types
+func (v *Voucher) Amount() money.Money {
+	if !IsFixedVoucherType(v.Type) {
+		return money.New(0, VoucherCurrency)
+	}
+	return money.New(v.Value, VoucherCurrency)
+}
+
+// Rate returns the percentage or points multiplier, e.g. 12.5 for 12.5 %,
+// zero for fixed_amount vouchers
+func (v *Voucher) Rate() float64 {
+	if IsFixedVoucherType(v.Type) {
+		return 0
+	}
+	return float64(v.Value) / math.Pow10(voucherRatePlaces)
+}
+
+// ValueFloat returns Value as decimal number for JSON: the amount of
+// fixed_amount vouchers, otherwise the rate
+func (v *Voucher) ValueFloat() float64 {
+	if IsFixedVoucherType(v.Type) {
+		return v.Amount().Float()
+	}
+	return v.Rate()
+}
+
+// FormatValue returns Value as plain decimal for form inputs, e.g. "10.50"
+// or "12.5"
+func (v *Voucher) FormatValue() string {
+	if IsFixedVoucherType(v.Type) {
+		return v.Amount().Format()
+	}
+	return strconv.FormatFloat(v.Rate(), 'f', -1, 64)
+}
+
+// MarshalJSON encodes Value as decimal number like the money fields, e.g.
+// 10.5 for 10.50 CHF or 12.5 for 12.5 %
+func (v Voucher) MarshalJSON() ([]byte, error) {
+	type voucher Voucher
+	return json.Marshal(struct {
+		voucher
+		Value float64 `json:"value"`
+	}{voucher: voucher(v), Value: v.ValueFloat()})
+}
+
 // GetColor returns the merchant color or a default green
 func (v *Voucher) GetColor() string {
 	if v.Merchant != nil && v.Merchant.Color != "" {
@@ -38,6 +127,14 @@ func (v *Voucher) GetColor() string {
 	return "#10B981"
 }
 
+// GetComputedStatus returns "expired" once ValidUntil has passed, otherwise "active"
+func (v *Voucher) GetComputedStatus() string {
+	if time.Now().After(v.ValidUntil) {
+		return ItemStatusExpired
+	}
+	return ItemStatusActive
+}
+
 // VoucherShare represents a shared voucher (read-only)
 type VoucherShare struct {
 	ID             uuid.UUID      `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
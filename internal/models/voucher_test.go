@@ -1,7 +1,11 @@
 package models
 
 import (
+	"encoding/json"
 	"testing"
+	"time"
+
+	"savvy/internal/money"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -40,3 +44,62 @@ func TestVoucher_BeforeCreate(t *testing.T) {
 	// ID should be set after creation
 	assert.NotEqual(t, uuid.Nil, voucher.ID)
 }
+
+func TestVoucher_AfterFind_SetsCurrency(t *testing.T) {
+	voucher := &Voucher{Type: "fixed_amount", Value: 1000, MinPurchaseAmount: money.Money{Amount: 5000}}
+	assert.NoError(t, voucher.AfterFind(nil))
+	assert.Equal(t, "50.00 CHF", voucher.MinPurchaseAmount.String())
+}
+
+func TestVoucher_Value(t *testing.T) {
+	fixed := &Voucher{Type: "fixed_amount", Value: 1050}
+	assert.Equal(t, "10.50 CHF", fixed.Amount().String())
+	assert.Equal(t, "10.50", fixed.FormatValue())
+	assert.InDelta(t, 10.5, fixed.ValueFloat(), 1e-9)
+	assert.Zero(t, fixed.Rate())
+
+	// Percentages are basis points, not money
+	percentage := &Voucher{Type: "percentage", Value: 1250}
+	assert.InDelta(t, 12.5, percentage.Rate(), 1e-9)
+	assert.Equal(t, "12.5", percentage.FormatValue())
+	assert.True(t, percentage.Amount().IsZero())
+
+	multiplier := &Voucher{Type: "points_multiplier", Value: 200}
+	assert.InDelta(t, 2.0, multiplier.ValueFloat(), 1e-9)
+	assert.Equal(t, "2", multiplier.FormatValue())
+}
+
+func TestParseVoucherValue(t *testing.T) {
+	value, err := ParseVoucherValue("percentage", "12,5")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1250), value)
+
+	value, err = ParseVoucherValue("fixed_amount", "1'000.50")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(100050), value)
+
+	_, err = ParseVoucherValue("percentage", "12.345")
+	assert.ErrorIs(t, err, money.ErrInvalidAmount)
+
+	assert.Equal(t, int64(1250), VoucherValueFromFloat("percentage", 12.5))
+	assert.Equal(t, int64(1999), VoucherValueFromFloat("fixed_amount", 19.99))
+}
+
+func TestVoucher_MarshalJSON(t *testing.T) {
+	data, err := json.Marshal(Voucher{Type: "percentage", Value: 1250, Code: "SUMMER"})
+	assert.NoError(t, err)
+
+	var decoded map[string]any
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, 12.5, decoded["value"])
+	assert.Equal(t, "SUMMER", decoded["code"])
+	assert.Equal(t, "percentage", decoded["type"])
+}
+
+func TestVoucher_GetComputedStatus(t *testing.T) {
+	voucher := &Voucher{ValidUntil: time.Now().Add(time.Hour)}
+	assert.Equal(t, ItemStatusActive, voucher.GetComputedStatus())
+
+	voucher.ValidUntil = time.Now().Add(-time.Hour)
+	assert.Equal(t, ItemStatusExpired, voucher.GetComputedStatus())
+}
@@ -0,0 +1,40 @@
+// Package models defines the database models for the savvy system.
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// WebAuthnCredential is a passkey or security key registered by a user. It
+// can be used for passwordless login or as second factor after the password.
+type WebAuthnCredential struct {
+	ID              uuid.UUID  `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	UserID          uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	Name            string     `gorm:"type:varchar(100);not null" json:"name"` // Chosen by the user, e.g. "iPhone"
+	CredentialID    []byte     `gorm:"type:bytea;not null;uniqueIndex" json:"-"`
+	PublicKey       []byte     `gorm:"type:bytea;not null" json:"-"` // COSE-encoded
+	AttestationType string     `gorm:"type:varchar(32);not null;default:''" json:"-"`
+	Transports      string     `gorm:"type:varchar(100);not null;default:''" json:"-"` // Comma-separated, e.g. "internal,hybrid"
+	AAGUID          []byte     `gorm:"type:bytea" json:"-"`                            // Authenticator model
+	SignCount       int64      `gorm:"not null;default:0" json:"-"`                    // Last signature counter, 0 for synced passkeys
+	BackupEligible  bool       `gorm:"not null;default:false" json:"backup_eligible"`  // Passkey can be synced between devices
+	BackupState     bool       `gorm:"not null;default:false" json:"backup_state"`     // Passkey is currently synced
+	LastUsedAt      *time.Time `gorm:"type:timestamp with time zone" json:"last_used_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+// TableName specifies the table name for WebAuthnCredential
+func (WebAuthnCredential) TableName() string {
+	return "webauthn_credentials"
+}
+
+// BeforeCreate ensures a UUID is generated before creating a credential
+func (c *WebAuthnCredential) BeforeCreate(_ *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	return nil
+}
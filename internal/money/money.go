@@ -0,0 +1,256 @@
+// Package money represents monetary amounts as integer minor units of an
+// ISO 4217 currency (Rappen, cents, yen), avoiding float rounding errors.
+//
+// Money is stored in the database as a single BIGINT column holding the
+// minor units. The currency lives in a separate column of the owning model
+// and is set on the loaded values by the model's AfterFind hook.
+package money
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency is used when no currency is given
+const DefaultCurrency = "CHF"
+
+// ErrInvalidAmount is returned by Parse for input that is not a decimal amount
+var ErrInvalidAmount = errors.New("invalid amount")
+
+// exponents lists the ISO 4217 currencies whose minor unit is not 1/100
+var exponents = map[string]int{
+	// No minor unit
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0,
+	"PYG": 0, "RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	// 1/1000
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	// 1/10000
+	"CLF": 4, "UYW": 4,
+}
+
+// Exponent returns the number of decimal places of the currency's minor unit.
+// Unknown and empty currencies use 2.
+func Exponent(currency string) int {
+	if exp, ok := exponents[strings.ToUpper(currency)]; ok {
+		return exp
+	}
+	return 2
+}
+
+// Money is an amount in minor units of a currency.
+type Money struct {
+	Amount   int64  // Minor units, e.g. 1250 for 12.50 CHF
+	Currency string // ISO 4217 code
+}
+
+// New creates an amount from minor units.
+func New(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// FromFloat converts a decimal value (e.g. from a JSON request) to minor
+// units, rounding half away from zero.
+func FromFloat(value float64, currency string) Money {
+	return Money{Amount: int64(math.Round(value * math.Pow10(Exponent(currency)))), Currency: currency}
+}
+
+// Parse reads a user-entered decimal amount. Both "." and "," are accepted
+// as decimal separator. Apostrophes and spaces are thousands separators, as
+// is the other separator or a repeated one ("1'234.50", "1.234,50",
+// "1.234.567"). More decimal places than the currency has are rejected.
+func Parse(input, currency string) (Money, error) {
+	amount, err := ParseDecimal(input, Exponent(currency))
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: amount, Currency: currency}, nil
+}
+
+// ParseDecimal reads a user-entered decimal number like Parse and returns it
+// as integer in units of 10^-places, e.g. 1250 for "12.5" with 2 places.
+// Used for numbers that are not amounts of a currency, like percentages.
+func ParseDecimal(input string, places int) (int64, error) {
+	s := strings.TrimSpace(input)
+	negative := false
+	if strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+") {
+		negative = s[0] == '-'
+		s = strings.TrimSpace(s[1:])
+	}
+	s = strings.NewReplacer("'", "", "’", "", " ", "", " ", "").Replace(s)
+	if s == "" {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, input)
+	}
+
+	// The last separator is the decimal separator, unless it occurs more than once
+	sep := strings.LastIndexAny(s, ".,")
+	if sep >= 0 {
+		if strings.Count(s, s[sep:sep+1]) > 1 {
+			sep = -1
+		}
+	}
+	intPart, fracPart := s, ""
+	if sep >= 0 {
+		intPart, fracPart = s[:sep], s[sep+1:]
+	}
+	if strings.ContainsAny(intPart, ".,") {
+		groups := strings.FieldsFunc(intPart, func(r rune) bool { return r == '.' || r == ',' })
+		if len(groups) != strings.Count(intPart, ".")+strings.Count(intPart, ",")+1 || len(groups[0]) > 3 {
+			return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, input)
+		}
+		for _, group := range groups[1:] {
+			if len(group) != 3 {
+				return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, input)
+			}
+		}
+		intPart = strings.Join(groups, "")
+	}
+
+	if len(fracPart) > places {
+		return 0, fmt.Errorf("%w: %q has more than %d decimal places", ErrInvalidAmount, input, places)
+	}
+	if intPart == "" {
+		intPart = "0"
+	}
+	digits := intPart + fracPart + strings.Repeat("0", places-len(fracPart))
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, input)
+		}
+	}
+
+	amount, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, input)
+	}
+	if negative {
+		amount = -amount
+	}
+	return amount, nil
+}
+
+// Exponent returns the number of decimal places of m's currency.
+func (m Money) Exponent() int {
+	return Exponent(m.Currency)
+}
+
+// Float returns the amount as decimal value, e.g. for JSON-based APIs that
+// expect numbers. Do not calculate with the result.
+func (m Money) Float() float64 {
+	return float64(m.Amount) / math.Pow10(m.Exponent())
+}
+
+// Format returns the plain decimal amount without currency, e.g. "1234.50",
+// as expected by <input type="number">.
+func (m Money) Format() string {
+	exp := m.Exponent()
+	amount := m.Amount
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	if exp == 0 {
+		return sign + strconv.FormatInt(amount, 10)
+	}
+	unit := int64(math.Pow10(exp))
+	return fmt.Sprintf("%s%d.%0*d", sign, amount/unit, exp, amount%unit)
+}
+
+// String returns the amount with its currency, e.g. "12.50 CHF".
+func (m Money) String() string {
+	if m.Currency == "" {
+		return m.Format()
+	}
+	return m.Format() + " " + m.Currency
+}
+
+// IsZero reports whether the amount is zero.
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+// IsPositive reports whether the amount is greater than zero.
+func (m Money) IsPositive() bool {
+	return m.Amount > 0
+}
+
+// IsNegative reports whether the amount is less than zero.
+func (m Money) IsNegative() bool {
+	return m.Amount < 0
+}
+
+// Add returns m + other. Both amounts must be in the same currency.
+func (m Money) Add(other Money) Money {
+	return Money{Amount: m.Amount + other.Amount, Currency: m.Currency}
+}
+
+// Sub returns m - other. Both amounts must be in the same currency.
+func (m Money) Sub(other Money) Money {
+	return Money{Amount: m.Amount - other.Amount, Currency: m.Currency}
+}
+
+// Neg returns -m.
+func (m Money) Neg() Money {
+	return Money{Amount: -m.Amount, Currency: m.Currency}
+}
+
+// GormDataType stores the minor units in a BIGINT column.
+func (Money) GormDataType() string {
+	return "bigint"
+}
+
+// Value implements driver.Valuer. Only the minor units are stored.
+func (m Money) Value() (driver.Value, error) {
+	return m.Amount, nil
+}
+
+// Scan implements sql.Scanner. The currency is left for the owning model to set.
+func (m *Money) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		m.Amount = 0
+	case int64:
+		m.Amount = v
+	case []byte:
+		return m.scanString(string(v))
+	case string:
+		return m.scanString(v)
+	default:
+		return fmt.Errorf("money: cannot scan %T", src)
+	}
+	return nil
+}
+
+func (m *Money) scanString(s string) error {
+	amount, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return fmt.Errorf("money: cannot scan %q: %w", s, err)
+	}
+	m.Amount = amount
+	return nil
+}
+
+// MarshalJSON encodes the amount as decimal number, e.g. 12.50, so JSON
+// clients keep seeing the same representation as before minor units.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.Format()), nil
+}
+
+// UnmarshalJSON decodes a decimal number or string using the exponent of
+// m's currency (2 if not yet set).
+func (m *Money) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	if s == "null" {
+		return nil
+	}
+	parsed, err := Parse(s, m.Currency)
+	if err != nil {
+		return err
+	}
+	m.Amount = parsed.Amount
+	return nil
+}
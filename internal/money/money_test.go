@@ -0,0 +1,131 @@
+package money
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExponent(t *testing.T) {
+	assert.Equal(t, 2, Exponent("CHF"))
+	assert.Equal(t, 2, Exponent("eur"))
+	assert.Equal(t, 0, Exponent("JPY"))
+	assert.Equal(t, 3, Exponent("KWD"))
+	assert.Equal(t, 2, Exponent(""))
+	assert.Equal(t, 2, Exponent("XYZ"))
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input    string
+		currency string
+		want     int64
+	}{
+		{"12.50", "CHF", 1250},
+		{"12,5", "CHF", 1250},
+		{"12", "CHF", 1200},
+		{".5", "CHF", 50},
+		{"1'234.50", "CHF", 123450},
+		{"1.234,50", "EUR", 123450},
+		{"1,234.50", "USD", 123450},
+		{"1.234.567", "EUR", 123456700},
+		{" 1 000 ", "CHF", 100000},
+		{"-3.20", "CHF", -320},
+		{"+3", "CHF", 300},
+		{"500", "JPY", 500},
+		{"1.234", "KWD", 1234},
+		{"0.1", "", 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			m, err := Parse(tt.input, tt.currency)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, m.Amount)
+			assert.Equal(t, tt.currency, m.Currency)
+		})
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, input := range []string{"", "-", "abc", "12.5x", "12.345", "1e3", "12.5.0,1"} {
+		_, err := Parse(input, "CHF")
+		assert.ErrorIs(t, err, ErrInvalidAmount, input)
+	}
+
+	// JPY has no minor unit
+	_, err := Parse("500.5", "JPY")
+	assert.ErrorIs(t, err, ErrInvalidAmount)
+}
+
+func TestParseDecimal(t *testing.T) {
+	value, err := ParseDecimal("12,5", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1250), value)
+
+	value, err = ParseDecimal("3", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), value)
+
+	_, err = ParseDecimal("12.345", 2)
+	assert.ErrorIs(t, err, ErrInvalidAmount)
+}
+
+func TestFromFloat(t *testing.T) {
+	assert.Equal(t, Money{Amount: 1999, Currency: "CHF"}, FromFloat(19.99, "CHF"))
+	assert.Equal(t, Money{Amount: 30, Currency: "CHF"}, FromFloat(0.1+0.2, "CHF"))
+	assert.Equal(t, Money{Amount: 1000, Currency: "JPY"}, FromFloat(999.5, "JPY"))
+	assert.Equal(t, Money{Amount: -1250, Currency: "EUR"}, FromFloat(-12.5, "EUR"))
+}
+
+func TestFormat(t *testing.T) {
+	assert.Equal(t, "12.50", New(1250, "CHF").Format())
+	assert.Equal(t, "0.05", New(5, "CHF").Format())
+	assert.Equal(t, "-3.20", New(-320, "CHF").Format())
+	assert.Equal(t, "500", New(500, "JPY").Format())
+	assert.Equal(t, "1.234", New(1234, "KWD").Format())
+	assert.Equal(t, "12.50 CHF", New(1250, "CHF").String())
+	assert.Equal(t, "12.50", New(1250, "").String())
+	assert.InDelta(t, 12.5, New(1250, "CHF").Float(), 1e-9)
+}
+
+func TestArithmetic(t *testing.T) {
+	a := New(1000, "CHF")
+	b := New(350, "CHF")
+	assert.Equal(t, New(1350, "CHF"), a.Add(b))
+	assert.Equal(t, New(650, "CHF"), a.Sub(b))
+	assert.Equal(t, New(-1000, "CHF"), a.Neg())
+	assert.True(t, a.IsPositive())
+	assert.True(t, a.Neg().IsNegative())
+	assert.True(t, Money{}.IsZero())
+}
+
+func TestValueScan(t *testing.T) {
+	v, err := New(1250, "CHF").Value()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1250), v)
+
+	var m Money
+	require.NoError(t, m.Scan(int64(42)))
+	assert.Equal(t, int64(42), m.Amount)
+	require.NoError(t, m.Scan([]byte("-7")))
+	assert.Equal(t, int64(-7), m.Amount)
+	require.NoError(t, m.Scan(nil))
+	assert.Equal(t, int64(0), m.Amount)
+	assert.Error(t, m.Scan(1.5))
+}
+
+func TestJSON(t *testing.T) {
+	data, err := json.Marshal(struct {
+		Balance Money `json:"balance"`
+	}{New(1250, "CHF")})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"balance": 12.50}`, string(data))
+
+	var decoded struct {
+		Balance Money `json:"balance"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(`{"balance": 7.5}`), &decoded))
+	assert.Equal(t, int64(750), decoded.Balance.Amount)
+}
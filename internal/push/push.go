@@ -0,0 +1,127 @@
+// Package push sends Web Push notifications to the browsers users subscribed
+// in the service worker.
+//
+// Messages are encrypted for the subscription (RFC 8291) and signed with the
+// VAPID key pair of the server (RFC 8292), so push services only accept
+// them from this server.
+package push
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	webpush "github.com/SherClockHolmes/webpush-go"
+
+	"savvy/internal/config"
+)
+
+// messageTTL is how long a push service keeps a message for an offline browser
+const messageTTL = 24 * time.Hour
+
+var (
+	// ErrNotConfigured is returned when no VAPID key pair is configured
+	ErrNotConfigured = errors.New("web push is not configured")
+	// ErrSubscriptionGone is returned when the push service no longer knows
+	// the subscription, e.g. because the user revoked the permission
+	ErrSubscriptionGone = errors.New("push subscription expired")
+)
+
+// Subscription is the endpoint and the keys of a browser, see PushSubscription.toJSON()
+type Subscription struct {
+	Endpoint string
+	P256dh   string
+	Auth     string
+}
+
+// Message is the payload the service worker shows as notification
+type Message struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+	URL   string `json:"url"`           // Opened when the notification is clicked
+	Tag   string `json:"tag,omitempty"` // Replaces an earlier notification with the same tag
+}
+
+// Sender delivers messages to subscriptions
+type Sender interface {
+	Send(ctx context.Context, sub Subscription, msg Message) error
+}
+
+// VAPIDSender sends messages signed with a VAPID key pair.
+type VAPIDSender struct {
+	publicKey  string
+	privateKey string
+	subject    string
+	client     *http.Client
+}
+
+// NewSender creates the sender configured in cfg.
+// Returns ErrNotConfigured if VAPID_PUBLIC_KEY or VAPID_PRIVATE_KEY is missing.
+func NewSender(cfg *config.Config) (*VAPIDSender, error) {
+	if !cfg.IsWebPushEnabled() {
+		return nil, ErrNotConfigured
+	}
+	return NewVAPIDSender(cfg.VAPIDPublicKey, cfg.VAPIDPrivateKey, cfg.VAPIDSubject), nil
+}
+
+// NewVAPIDSender creates a sender. The subject is a contact address or an
+// https URL that push services can use to reach the operator.
+func NewVAPIDSender(publicKey, privateKey, subject string) *VAPIDSender {
+	return &VAPIDSender{
+		publicKey:  publicKey,
+		privateKey: privateKey,
+		// The library adds the mailto: prefix itself
+		subject: strings.TrimPrefix(subject, "mailto:"),
+		client:  &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// PublicKey returns the key browsers need to subscribe (applicationServerKey)
+func (s *VAPIDSender) PublicKey() string {
+	return s.publicKey
+}
+
+// Send encrypts the message for the subscription and posts it to its push service.
+func (s *VAPIDSender) Send(ctx context.Context, sub Subscription, msg Message) error {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	resp, err := webpush.SendNotificationWithContext(ctx, payload, &webpush.Subscription{
+		Endpoint: sub.Endpoint,
+		Keys:     webpush.Keys{P256dh: sub.P256dh, Auth: sub.Auth},
+	}, &webpush.Options{
+		HTTPClient:      s.client,
+		Subscriber:      s.subject,
+		TTL:             int(messageTTL.Seconds()),
+		Urgency:         webpush.UrgencyNormal,
+		VAPIDPublicKey:  s.publicKey,
+		VAPIDPrivateKey: s.privateKey,
+	})
+	if err != nil {
+		return fmt.Errorf("push: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return ErrSubscriptionGone
+	case resp.StatusCode >= 300:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("push: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// GenerateKeys creates a new VAPID key pair, base64url encoded like the
+// VAPID_PUBLIC_KEY and VAPID_PRIVATE_KEY settings expect.
+func GenerateKeys() (publicKey, privateKey string, err error) {
+	privateKey, publicKey, err = webpush.GenerateVAPIDKeys()
+	return publicKey, privateKey, err
+}
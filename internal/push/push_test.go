@@ -0,0 +1,108 @@
+package push
+
+import (
+	"context"
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"savvy/internal/config"
+)
+
+// testSubscription returns the keys a browser would create for its subscription
+func testSubscription(t *testing.T, endpoint string) Subscription {
+	t.Helper()
+	key, err := ecdh.P256().GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	auth := make([]byte, 16)
+	_, err = rand.Read(auth)
+	require.NoError(t, err)
+
+	return Subscription{
+		Endpoint: endpoint,
+		P256dh:   base64.RawURLEncoding.EncodeToString(key.PublicKey().Bytes()),
+		Auth:     base64.RawURLEncoding.EncodeToString(auth),
+	}
+}
+
+func testSender(t *testing.T) *VAPIDSender {
+	t.Helper()
+	publicKey, privateKey, err := GenerateKeys()
+	require.NoError(t, err)
+	return NewVAPIDSender(publicKey, privateKey, "mailto:admin@example.com")
+}
+
+func TestVAPIDSender_Send(t *testing.T) {
+	type request struct {
+		header http.Header
+		body   []byte
+	}
+	requests := make(chan request, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- request{header: r.Header, body: body}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	sender := testSender(t)
+	err := sender.Send(context.Background(), testSubscription(t, server.URL+"/push/abc"), Message{
+		Title: "Shared with you",
+		Body:  "Anna has shared a card with you.",
+		URL:   "/cards/1",
+	})
+	require.NoError(t, err)
+
+	req := <-requests
+	assert.Equal(t, "aes128gcm", req.header.Get("Content-Encoding"))
+	assert.Equal(t, "86400", req.header.Get("TTL"))
+	assert.True(t, strings.HasPrefix(req.header.Get("Authorization"), "vapid t="))
+	assert.Contains(t, req.header.Get("Authorization"), "k="+sender.PublicKey())
+	assert.NotContains(t, string(req.body), "Anna", "The payload is encrypted")
+}
+
+func TestVAPIDSender_SendErrors(t *testing.T) {
+	status := http.StatusGone
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte("subscription unknown"))
+	}))
+	defer server.Close()
+
+	sender := testSender(t)
+	sub := testSubscription(t, server.URL)
+
+	err := sender.Send(context.Background(), sub, Message{Title: "Test"})
+	assert.ErrorIs(t, err, ErrSubscriptionGone)
+
+	status = http.StatusNotFound
+	err = sender.Send(context.Background(), sub, Message{Title: "Test"})
+	assert.ErrorIs(t, err, ErrSubscriptionGone)
+
+	status = http.StatusTooManyRequests
+	err = sender.Send(context.Background(), sub, Message{Title: "Test"})
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, ErrSubscriptionGone)
+	assert.Contains(t, err.Error(), "429")
+
+	sub.P256dh = "invalid"
+	assert.Error(t, sender.Send(context.Background(), sub, Message{Title: "Test"}))
+}
+
+func TestNewSender(t *testing.T) {
+	_, err := NewSender(&config.Config{})
+	assert.ErrorIs(t, err, ErrNotConfigured)
+
+	sender, err := NewSender(&config.Config{VAPIDPublicKey: "public", VAPIDPrivateKey: "private", VAPIDSubject: "mailto:admin@example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, "public", sender.PublicKey())
+	assert.Equal(t, "admin@example.com", sender.subject)
+}
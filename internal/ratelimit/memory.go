@@ -0,0 +1,62 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryLimiter keeps the counters in process memory. Limits apply per
+// instance, so with several replicas a client gets the limit on each.
+type MemoryLimiter struct {
+	mu        sync.Mutex
+	counters  map[string]*memoryCounter
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+type memoryCounter struct {
+	count     int
+	expiresAt time.Time
+}
+
+// NewMemoryLimiter creates an empty in-memory limiter.
+func NewMemoryLimiter() *MemoryLimiter {
+	return &MemoryLimiter{
+		counters: make(map[string]*memoryCounter),
+		now:      time.Now,
+	}
+}
+
+// Allow counts the request in the client's current window.
+func (l *MemoryLimiter) Allow(_ context.Context, policy Policy, key string) (Result, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	key = counterKey(policy, key)
+	counter, ok := l.counters[key]
+	if !ok || !counter.expiresAt.After(now) {
+		counter = &memoryCounter{expiresAt: now.Add(policy.Window)}
+		l.counters[key] = counter
+	}
+	counter.count++
+
+	return newResult(policy, counter.count, counter.expiresAt, now), nil
+}
+
+// sweep removes expired counters, at most once per sweepInterval.
+// Callers must hold the lock.
+func (l *MemoryLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+	for key, counter := range l.counters {
+		if !counter.expiresAt.After(now) {
+			delete(l.counters, key)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package ratelimit
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PostgresLimiter keeps the counters in the unlogged rate_limit_counters
+// table, so all replicas share them without an additional service.
+type PostgresLimiter struct {
+	db        *gorm.DB
+	now       func() time.Time
+	lastSweep atomic.Int64 // Unix nanoseconds
+}
+
+// NewPostgresLimiter creates a limiter on the application database.
+func NewPostgresLimiter(db *gorm.DB) *PostgresLimiter {
+	return &PostgresLimiter{db: db, now: time.Now}
+}
+
+// Allow counts the request with a single upsert, so concurrent requests on
+// different replicas are all counted.
+func (l *PostgresLimiter) Allow(ctx context.Context, policy Policy, key string) (Result, error) {
+	now := l.now()
+	l.sweep(ctx, now)
+
+	var counter struct {
+		Count     int
+		ExpiresAt time.Time
+	}
+	err := l.db.WithContext(ctx).Raw(`
+		INSERT INTO rate_limit_counters (key, count, expires_at)
+		VALUES (?, 1, ?)
+		ON CONFLICT (key) DO UPDATE SET
+			count = CASE WHEN rate_limit_counters.expires_at <= ? THEN 1 ELSE rate_limit_counters.count + 1 END,
+			expires_at = CASE WHEN rate_limit_counters.expires_at <= ? THEN EXCLUDED.expires_at ELSE rate_limit_counters.expires_at END
+		RETURNING count, expires_at
+	`, counterKey(policy, key), now.Add(policy.Window), now, now).Scan(&counter).Error
+	if err != nil {
+		return Result{}, err
+	}
+
+	return newResult(policy, counter.Count, counter.ExpiresAt, now), nil
+}
+
+// sweep deletes expired counters, at most once per sweepInterval and replica.
+func (l *PostgresLimiter) sweep(ctx context.Context, now time.Time) {
+	last := l.lastSweep.Load()
+	if now.UnixNano()-last < int64(sweepInterval) || !l.lastSweep.CompareAndSwap(last, now.UnixNano()) {
+		return
+	}
+	if err := l.db.WithContext(ctx).Exec(`DELETE FROM rate_limit_counters WHERE expires_at <= ?`, now).Error; err != nil {
+		slog.Warn("Failed to delete expired rate limit counters", "error", err)
+	}
+}
@@ -0,0 +1,87 @@
+// Package ratelimit counts requests per client in fixed windows.
+//
+// Counters live in a Backend: process memory for a single instance, or
+// Postgres or Redis so that all replicas share the same limits.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"savvy/internal/config"
+)
+
+// Backend names used in RATE_LIMIT_BACKEND
+const (
+	BackendMemory   = "memory"
+	BackendPostgres = "postgres"
+	BackendRedis    = "redis"
+)
+
+// sweepInterval is how often expired counters are removed from memory and Postgres
+const sweepInterval = time.Minute
+
+// Policy allows Limit requests per client within Window.
+type Policy struct {
+	Name   string // Prefix of the counter keys, e.g. "auth"
+	Limit  int
+	Window time.Duration
+}
+
+// Result is the state of a client's counter after a request was counted.
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int           // Requests left in the current window
+	Reset     time.Duration // Time until the window ends and the count restarts
+}
+
+// Limiter counts a request of a client against a policy.
+type Limiter interface {
+	// Allow counts the request and reports whether it is within the limit.
+	Allow(ctx context.Context, policy Policy, key string) (Result, error)
+}
+
+// New creates the limiter for the configured backend. Redis is pinged, so a
+// wrong REDIS_URL is reported at startup rather than on the first request.
+func New(ctx context.Context, cfg *config.Config, db *gorm.DB) (Limiter, error) {
+	switch strings.ToLower(cfg.RateLimitBackend) {
+	case "", BackendMemory:
+		return NewMemoryLimiter(), nil
+	case BackendPostgres:
+		return NewPostgresLimiter(db), nil
+	case BackendRedis:
+		if cfg.RedisURL == "" {
+			return nil, fmt.Errorf("REDIS_URL is required for RATE_LIMIT_BACKEND=%s", BackendRedis)
+		}
+		limiter, err := NewRedisLimiterFromURL(cfg.RedisURL)
+		if err != nil {
+			return nil, err
+		}
+		if err := limiter.Ping(ctx); err != nil {
+			return nil, err
+		}
+		return limiter, nil
+	default:
+		return nil, fmt.Errorf("unknown rate limit backend %q", cfg.RateLimitBackend)
+	}
+}
+
+// counterKey combines the policy and the client into the key of a counter
+func counterKey(policy Policy, key string) string {
+	return policy.Name + ":" + key
+}
+
+// newResult builds the result for the count of a window ending at expiresAt
+func newResult(policy Policy, count int, expiresAt, now time.Time) Result {
+	return Result{
+		Allowed:   count <= policy.Limit,
+		Limit:     policy.Limit,
+		Remaining: max(policy.Limit-count, 0),
+		Reset:     max(expiresAt.Sub(now), 0),
+	}
+}
@@ -0,0 +1,70 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisKeyPrefix keeps the counters apart from other data in a shared Redis
+const redisKeyPrefix = "savvy:ratelimit:"
+
+// redisAllowScript increments the counter and starts its window on the
+// first request. A counter without TTL, e.g. after a failed PEXPIRE, gets
+// one as well, so it cannot block a client forever.
+var redisAllowScript = redis.NewScript(`
+local count = redis.call('INCR', KEYS[1])
+local ttl = redis.call('PTTL', KEYS[1])
+if ttl < 0 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[1])
+	ttl = tonumber(ARGV[1])
+end
+return {count, ttl}
+`)
+
+// RedisLimiter keeps the counters in Redis, which expires them on its own.
+type RedisLimiter struct {
+	client redis.UniversalClient
+}
+
+// NewRedisLimiter creates a limiter on an existing client.
+func NewRedisLimiter(client redis.UniversalClient) *RedisLimiter {
+	return &RedisLimiter{client: client}
+}
+
+// NewRedisLimiterFromURL connects to a redis:// or rediss:// URL.
+func NewRedisLimiterFromURL(url string) (*RedisLimiter, error) {
+	options, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("REDIS_URL: %w", err)
+	}
+	return NewRedisLimiter(redis.NewClient(options)), nil
+}
+
+// Ping checks that Redis is reachable.
+func (l *RedisLimiter) Ping(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if err := l.client.Ping(ctx).Err(); err != nil {
+		return fmt.Errorf("redis: %w", err)
+	}
+	return nil
+}
+
+// Allow counts the request atomically in a Lua script.
+func (l *RedisLimiter) Allow(ctx context.Context, policy Policy, key string) (Result, error) {
+	values, err := redisAllowScript.Run(ctx, l.client,
+		[]string{redisKeyPrefix + counterKey(policy, key)}, policy.Window.Milliseconds(),
+	).Int64Slice()
+	if err != nil {
+		return Result{}, fmt.Errorf("redis: %w", err)
+	}
+	if len(values) != 2 {
+		return Result{}, fmt.Errorf("redis: unexpected script result %v", values)
+	}
+
+	now := time.Now()
+	return newResult(policy, int(values[0]), now.Add(time.Duration(values[1])*time.Millisecond), now), nil
+}
@@ -0,0 +1,89 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	// channel is the Postgres NOTIFY channel of the events
+	channel = "savvy_notifications"
+
+	// reconnectDelay and maxReconnectDelay bound the backoff after the
+	// listener lost its connection
+	reconnectDelay    = time.Second
+	maxReconnectDelay = time.Minute
+)
+
+// Publish sends the event to the subscribers on all replicas. Inside a
+// transaction Postgres delivers it on commit.
+func (h *Hub) Publish(ctx context.Context, event Event) error {
+	if h.db == nil {
+		h.dispatch(event)
+		return nil
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return h.db.WithContext(ctx).Exec("SELECT pg_notify(?, ?)", channel, string(payload)).Error
+}
+
+// Listen receives the events of all replicas on a dedicated connection until
+// ctx is done, then closes the hub. The connection is re-established with
+// backoff; subscribers get EventResync after each reconnect.
+func (h *Hub) Listen(ctx context.Context, databaseURL string) {
+	defer h.Close()
+	if h.db == nil {
+		<-ctx.Done()
+		return
+	}
+
+	delay := reconnectDelay
+	for {
+		err := h.listen(ctx, databaseURL, func() { delay = reconnectDelay })
+		if ctx.Err() != nil {
+			return
+		}
+		slog.Warn("Notification listener disconnected, reconnecting", "error", err, "retry_in", delay)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, maxReconnectDelay)
+	}
+}
+
+// listen runs one LISTEN session until its connection fails or ctx is done
+func (h *Hub) listen(ctx context.Context, databaseURL string, connected func()) error {
+	conn, err := pgx.Connect(ctx, databaseURL)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = conn.Close(context.Background()) }()
+
+	if _, err := conn.Exec(ctx, "LISTEN "+channel); err != nil {
+		return err
+	}
+	connected()
+	h.dispatch(Event{Type: EventResync})
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		var event Event
+		if err := json.Unmarshal([]byte(notification.Payload), &event); err != nil {
+			slog.Warn("Ignoring invalid notification event", "payload", notification.Payload, "error", err)
+			continue
+		}
+		h.dispatch(event)
+	}
+}
@@ -0,0 +1,128 @@
+// Package realtime tells the open browser tabs of a user that their
+// notifications changed, so the badge and dropdown update without polling.
+//
+// Events are published with Postgres NOTIFY and received by every replica
+// with LISTEN, so a notification created on one replica reaches tabs that
+// are connected to another one.
+package realtime
+
+import (
+	"sync"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// EventType says what changed
+type EventType string
+
+const (
+	// EventNotification is sent when a notification was created for the user
+	EventNotification EventType = "notification"
+	// EventCount is sent when notifications were read or deleted
+	EventCount EventType = "count"
+	// EventResync is sent to all local subscribers after the listener
+	// (re)connected, events published in the meantime may be lost
+	EventResync EventType = "resync"
+)
+
+// subscriberBuffer is how many events a slow subscriber may fall behind
+// before further events are dropped for it
+const subscriberBuffer = 16
+
+// Event is the payload of a NOTIFY. It only names the user and the change,
+// subscribers load the current state themselves.
+type Event struct {
+	UserID         uuid.UUID `json:"user_id"`
+	Type           EventType `json:"type"`
+	NotificationID uuid.UUID `json:"notification_id,omitempty"`
+}
+
+// Hub fans events out to the subscribers on this replica.
+type Hub struct {
+	mu          sync.Mutex
+	subscribers map[uuid.UUID]map[chan Event]struct{}
+	closed      bool
+
+	db *gorm.DB // nil for a hub that only reaches this process
+}
+
+// NewHub creates a hub that publishes through Postgres. Events reach
+// subscribers, also on this replica, only while Listen is running.
+func NewHub(db *gorm.DB) *Hub {
+	return &Hub{db: db}
+}
+
+// NewLocalHub creates a hub without Postgres for a single process.
+func NewLocalHub() *Hub {
+	return &Hub{}
+}
+
+// Subscribe returns the events of a user until cancel is called or the hub
+// is closed, which closes the channel.
+func (h *Hub) Subscribe(userID uuid.UUID) (events <-chan Event, cancel func()) {
+	ch := make(chan Event, subscriberBuffer)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		close(ch)
+		return ch, func() {}
+	}
+	if h.subscribers == nil {
+		h.subscribers = map[uuid.UUID]map[chan Event]struct{}{}
+	}
+	if h.subscribers[userID] == nil {
+		h.subscribers[userID] = map[chan Event]struct{}{}
+	}
+	h.subscribers[userID][ch] = struct{}{}
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			defer h.mu.Unlock()
+			if _, ok := h.subscribers[userID][ch]; !ok {
+				return // Already closed by Close
+			}
+			delete(h.subscribers[userID], ch)
+			if len(h.subscribers[userID]) == 0 {
+				delete(h.subscribers, userID)
+			}
+			close(ch)
+		})
+	}
+}
+
+// Close ends all subscriptions, e.g. so that open streams do not delay a
+// graceful shutdown.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for _, channels := range h.subscribers {
+		for ch := range channels {
+			close(ch)
+		}
+	}
+	h.subscribers = nil
+}
+
+// dispatch hands the event to the local subscribers of its user, or to all
+// of them for EventResync. It never blocks: a subscriber with a full buffer
+// misses the event, the next one carries the current state again.
+func (h *Hub) dispatch(event Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for userID, channels := range h.subscribers {
+		if event.Type != EventResync && userID != event.UserID {
+			continue
+		}
+		for ch := range channels {
+			select {
+			case ch <- event:
+			default:
+			}
+		}
+	}
+}
@@ -0,0 +1,28 @@
+// Package repository contains data access interfaces and implementations.
+package repository
+
+import (
+	"context"
+	"savvy/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// APITokenRepository defines the interface for personal API token data access.
+type APITokenRepository interface {
+	// Create stores a new token.
+	Create(ctx context.Context, token *models.APIToken) error
+
+	// GetByHash retrieves an active (not revoked) token by its SHA-256 hash, including its user.
+	GetByHash(ctx context.Context, tokenHash string) (*models.APIToken, error)
+
+	// GetByUserID retrieves all active tokens of a user, newest first.
+	GetByUserID(ctx context.Context, userID uuid.UUID) ([]models.APIToken, error)
+
+	// Revoke soft-deletes a token. Only the owning user can revoke it.
+	Revoke(ctx context.Context, id, userID uuid.UUID) error
+
+	// TouchLastUsed updates the last-used timestamp of a token.
+	TouchLastUsed(ctx context.Context, id uuid.UUID, at time.Time) error
+}
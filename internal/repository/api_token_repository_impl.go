@@ -0,0 +1,64 @@
+// Package repository contains data access implementations.
+package repository
+
+import (
+	"context"
+	"savvy/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// GormAPITokenRepository is a GORM implementation of APITokenRepository.
+type GormAPITokenRepository struct {
+	db *gorm.DB
+}
+
+// NewAPITokenRepository creates a new API token repository.
+func NewAPITokenRepository(db *gorm.DB) APITokenRepository {
+	return &GormAPITokenRepository{db: db}
+}
+
+func (r *GormAPITokenRepository) Create(ctx context.Context, token *models.APIToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+// GetByHash preloads the owning user so callers can authenticate without a second lookup.
+func (r *GormAPITokenRepository) GetByHash(ctx context.Context, tokenHash string) (*models.APIToken, error) {
+	var token models.APIToken
+	err := r.db.WithContext(ctx).Preload("User").Where("token_hash = ?", tokenHash).First(&token).Error
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *GormAPITokenRepository) GetByUserID(ctx context.Context, userID uuid.UUID) ([]models.APIToken, error) {
+	var tokens []models.APIToken
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&tokens).Error
+	return tokens, err
+}
+
+// Revoke returns gorm.ErrRecordNotFound if the token does not belong to the user.
+func (r *GormAPITokenRepository) Revoke(ctx context.Context, id, userID uuid.UUID) error {
+	result := r.db.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID).Delete(&models.APIToken{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// TouchLastUsed skips hooks and updated_at so usage tracking does not look like an edit.
+func (r *GormAPITokenRepository) TouchLastUsed(ctx context.Context, id uuid.UUID, at time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&models.APIToken{}).
+		Where("id = ?", id).
+		UpdateColumn("last_used_at", at).Error
+}
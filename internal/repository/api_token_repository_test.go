@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"savvy/internal/models"
+)
+
+func createTestAPIToken(t *testing.T, db *gorm.DB, userID uuid.UUID) *models.APIToken {
+	t.Helper()
+	token := &models.APIToken{
+		UserID:    userID,
+		Name:      "Test token",
+		TokenHash: uuid.NewString() + uuid.NewString()[:28],
+		Prefix:    "svy_test",
+		Scopes:    "cards:read",
+	}
+	db.Create(token)
+	t.Cleanup(func() {
+		db.Exec("DELETE FROM api_tokens WHERE id = ?", token.ID)
+	})
+	return token
+}
+
+func TestAPITokenRepository_GetByHash(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewAPITokenRepository(db)
+	ctx := context.Background()
+
+	userID := createTestUser(t, db)
+	token := createTestAPIToken(t, db, userID)
+
+	found, err := repo.GetByHash(ctx, token.TokenHash)
+	assert.NoError(t, err)
+	assert.Equal(t, token.ID, found.ID)
+
+	_, err = repo.GetByHash(ctx, "unknown")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestAPITokenRepository_Revoke(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewAPITokenRepository(db)
+	ctx := context.Background()
+
+	userID := createTestUser(t, db)
+	token := createTestAPIToken(t, db, userID)
+
+	// Other users cannot revoke the token
+	err := repo.Revoke(ctx, token.ID, uuid.New())
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	err = repo.Revoke(ctx, token.ID, userID)
+	assert.NoError(t, err)
+
+	_, err = repo.GetByHash(ctx, token.TokenHash)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	tokens, err := repo.GetByUserID(ctx, userID)
+	assert.NoError(t, err)
+	assert.Empty(t, tokens)
+}
+
+func TestAPITokenRepository_TouchLastUsed(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewAPITokenRepository(db)
+	ctx := context.Background()
+
+	userID := createTestUser(t, db)
+	token := createTestAPIToken(t, db, userID)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	err := repo.TouchLastUsed(ctx, token.ID, now)
+	assert.NoError(t, err)
+
+	found, err := repo.GetByHash(ctx, token.TokenHash)
+	assert.NoError(t, err)
+	if assert.NotNil(t, found.LastUsedAt) {
+		assert.True(t, now.Equal(found.LastUsedAt.UTC()))
+	}
+}
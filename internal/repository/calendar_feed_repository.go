@@ -0,0 +1,28 @@
+// Package repository contains data access interfaces and implementations.
+package repository
+
+import (
+	"context"
+	"savvy/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CalendarFeedRepository defines the interface for calendar feed data access.
+type CalendarFeedRepository interface {
+	// Get retrieves the feed of a user, gorm.ErrRecordNotFound if they have none.
+	Get(ctx context.Context, userID uuid.UUID) (*models.CalendarFeed, error)
+
+	// GetByHash retrieves a feed by the hash of its token.
+	GetByHash(ctx context.Context, tokenHash string) (*models.CalendarFeed, error)
+
+	// Save creates the feed of a user or replaces its token and language.
+	Save(ctx context.Context, feed *models.CalendarFeed) error
+
+	// Delete removes the feed of a user.
+	Delete(ctx context.Context, userID uuid.UUID) error
+
+	// TouchLastUsed records when a calendar app last fetched the feed.
+	TouchLastUsed(ctx context.Context, userID uuid.UUID, at time.Time) error
+}
@@ -0,0 +1,59 @@
+// Package repository contains data access implementations.
+package repository
+
+import (
+	"context"
+	"savvy/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GormCalendarFeedRepository is a GORM implementation of CalendarFeedRepository.
+type GormCalendarFeedRepository struct {
+	db *gorm.DB
+}
+
+// NewCalendarFeedRepository creates a new calendar feed repository.
+func NewCalendarFeedRepository(db *gorm.DB) CalendarFeedRepository {
+	return &GormCalendarFeedRepository{db: db}
+}
+
+func (r *GormCalendarFeedRepository) Get(ctx context.Context, userID uuid.UUID) (*models.CalendarFeed, error) {
+	var feed models.CalendarFeed
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&feed).Error; err != nil {
+		return nil, err
+	}
+	return &feed, nil
+}
+
+func (r *GormCalendarFeedRepository) GetByHash(ctx context.Context, tokenHash string) (*models.CalendarFeed, error) {
+	var feed models.CalendarFeed
+	if err := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&feed).Error; err != nil {
+		return nil, err
+	}
+	return &feed, nil
+}
+
+// Save resets the last use, the new token was not fetched yet.
+func (r *GormCalendarFeedRepository) Save(ctx context.Context, feed *models.CalendarFeed) error {
+	feed.LastUsedAt = nil
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"token_hash", "language", "last_used_at", "updated_at"}),
+	}).Create(feed).Error
+}
+
+func (r *GormCalendarFeedRepository) Delete(ctx context.Context, userID uuid.UUID) error {
+	return r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&models.CalendarFeed{}).Error
+}
+
+// TouchLastUsed skips hooks and updated_at, which is the time of the last rotation.
+func (r *GormCalendarFeedRepository) TouchLastUsed(ctx context.Context, userID uuid.UUID, at time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&models.CalendarFeed{}).
+		Where("user_id = ?", userID).
+		UpdateColumn("last_used_at", at).Error
+}
@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"savvy/internal/models"
+)
+
+func TestCalendarFeedRepository_SaveAndRotate(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewCalendarFeedRepository(db)
+	ctx := context.Background()
+	userID := createTestUser(t, db)
+	t.Cleanup(func() { db.Exec("DELETE FROM calendar_feeds WHERE user_id = ?", userID) })
+
+	_, err := repo.Get(ctx, userID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	oldHash := uuid.NewString() + uuid.NewString()[:28]
+	require.NoError(t, repo.Save(ctx, &models.CalendarFeed{UserID: userID, TokenHash: oldHash, Language: "de"}))
+	require.NoError(t, repo.TouchLastUsed(ctx, userID, time.Now()))
+
+	found, err := repo.GetByHash(ctx, oldHash)
+	require.NoError(t, err)
+	assert.Equal(t, userID, found.UserID)
+	assert.Equal(t, "de", found.Language)
+	assert.NotNil(t, found.LastUsedAt)
+
+	// Rotating replaces the token and forgets the last use
+	newHash := uuid.NewString() + uuid.NewString()[:28]
+	require.NoError(t, repo.Save(ctx, &models.CalendarFeed{UserID: userID, TokenHash: newHash, Language: "fr"}))
+	_, err = repo.GetByHash(ctx, oldHash)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	found, err = repo.Get(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, newHash, found.TokenHash)
+	assert.Nil(t, found.LastUsedAt)
+
+	require.NoError(t, repo.Delete(ctx, userID))
+	_, err = repo.GetByHash(ctx, newHash)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
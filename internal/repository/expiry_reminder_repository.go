@@ -0,0 +1,32 @@
+// Package repository contains data access interfaces and implementations.
+package repository
+
+import (
+	"context"
+	"savvy/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ExpiryReminderRepository defines the interface for expiry reminder data access.
+type ExpiryReminderRepository interface {
+	// GetPreferences retrieves the reminder offsets of the given users that
+	// changed them, keyed by user. Other users use models.DefaultExpiryReminderDays.
+	GetPreferences(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID]models.ReminderDays, error)
+
+	// SavePreference creates or replaces the reminder offsets of a user.
+	SavePreference(ctx context.Context, preference *models.ExpiryReminderPreference) error
+
+	// ListExpiring retrieves the vouchers and gift cards that expire after from
+	// and until including until, with their owner and the users they are
+	// shared with. Deleted items and gift cards without balance are left out.
+	ListExpiring(ctx context.Context, from, until time.Time) ([]models.ExpiringItem, error)
+
+	// Record stores reminders that were not sent before and returns how many were new.
+	Record(ctx context.Context, reminders []models.ExpiryReminder) (int64, error)
+
+	// DeleteExpiredBefore removes reminders of items that expired before the
+	// given time and returns how many there were.
+	DeleteExpiredBefore(ctx context.Context, before time.Time) (int64, error)
+}
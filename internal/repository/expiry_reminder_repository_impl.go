@@ -0,0 +1,149 @@
+// Package repository contains data access implementations.
+package repository
+
+import (
+	"context"
+	"savvy/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GormExpiryReminderRepository is a GORM implementation of ExpiryReminderRepository.
+type GormExpiryReminderRepository struct {
+	db *gorm.DB
+}
+
+// NewExpiryReminderRepository creates a new expiry reminder repository.
+func NewExpiryReminderRepository(db *gorm.DB) ExpiryReminderRepository {
+	return &GormExpiryReminderRepository{db: db}
+}
+
+func (r *GormExpiryReminderRepository) GetPreferences(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID]models.ReminderDays, error) {
+	preferences := make(map[uuid.UUID]models.ReminderDays, len(userIDs))
+	if len(userIDs) == 0 {
+		return preferences, nil
+	}
+
+	var rows []models.ExpiryReminderPreference
+	if err := r.db.WithContext(ctx).Where("user_id IN ?", userIDs).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		preferences[row.UserID] = row.Days
+	}
+	return preferences, nil
+}
+
+func (r *GormExpiryReminderRepository) SavePreference(ctx context.Context, preference *models.ExpiryReminderPreference) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"days", "updated_at"}),
+	}).Create(preference).Error
+}
+
+// expiringRow is an item of ListExpiring before its shares are added
+type expiringRow struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Name      string
+	ExpiresAt time.Time
+}
+
+// shareRow is a user an item is shared with
+type shareRow struct {
+	ItemID       uuid.UUID
+	SharedWithID uuid.UUID
+}
+
+func (r *GormExpiryReminderRepository) ListExpiring(ctx context.Context, from, until time.Time) ([]models.ExpiringItem, error) {
+	db := r.db.WithContext(ctx)
+
+	var vouchers []expiringRow
+	if err := db.Raw(`
+		SELECT v.id, v.user_id, COALESCE(m.name, v.merchant_name, '') AS name, v.valid_until AS expires_at
+		FROM vouchers v
+		LEFT JOIN merchants m ON m.id = v.merchant_id
+		WHERE v.deleted_at IS NULL AND v.user_id IS NOT NULL
+		  AND v.valid_until > ? AND v.valid_until <= ?
+	`, from, until).Scan(&vouchers).Error; err != nil {
+		return nil, err
+	}
+	voucherShares, err := r.shares(db, "voucher_shares", "voucher_id", vouchers)
+	if err != nil {
+		return nil, err
+	}
+
+	var giftCards []expiringRow
+	if err := db.Raw(`
+		SELECT g.id, g.user_id, COALESCE(m.name, g.merchant_name, '') AS name, g.expires_at
+		FROM gift_cards g
+		LEFT JOIN merchants m ON m.id = g.merchant_id
+		WHERE g.deleted_at IS NULL AND g.user_id IS NOT NULL
+		  AND g.status = 'active' AND g.current_balance > 0
+		  AND g.expires_at > ? AND g.expires_at <= ?
+	`, from, until).Scan(&giftCards).Error; err != nil {
+		return nil, err
+	}
+	giftCardShares, err := r.shares(db, "gift_card_shares", "gift_card_id", giftCards)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]models.ExpiringItem, 0, len(vouchers)+len(giftCards))
+	items = appendExpiring(items, "voucher", vouchers, voucherShares)
+	items = appendExpiring(items, "gift_card", giftCards, giftCardShares)
+	return items, nil
+}
+
+// shares returns the users each of the items is shared with
+func (r *GormExpiryReminderRepository) shares(db *gorm.DB, table, column string, items []expiringRow) (map[uuid.UUID][]uuid.UUID, error) {
+	shared := make(map[uuid.UUID][]uuid.UUID)
+	if len(items) == 0 {
+		return shared, nil
+	}
+
+	ids := make([]uuid.UUID, len(items))
+	for i, item := range items {
+		ids[i] = item.ID
+	}
+	var rows []shareRow
+	if err := db.Table(table).
+		Select(column+" AS item_id, shared_with_id").
+		Where(column+" IN ? AND deleted_at IS NULL", ids).
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		shared[row.ItemID] = append(shared[row.ItemID], row.SharedWithID)
+	}
+	return shared, nil
+}
+
+func appendExpiring(items []models.ExpiringItem, resourceType string, rows []expiringRow, shares map[uuid.UUID][]uuid.UUID) []models.ExpiringItem {
+	for _, row := range rows {
+		items = append(items, models.ExpiringItem{
+			ResourceType: resourceType,
+			ResourceID:   row.ID,
+			Name:         row.Name,
+			ExpiresAt:    row.ExpiresAt,
+			UserIDs:      append([]uuid.UUID{row.UserID}, shares[row.ID]...),
+		})
+	}
+	return items
+}
+
+func (r *GormExpiryReminderRepository) Record(ctx context.Context, reminders []models.ExpiryReminder) (int64, error) {
+	if len(reminders) == 0 {
+		return 0, nil
+	}
+	result := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&reminders)
+	return result.RowsAffected, result.Error
+}
+
+func (r *GormExpiryReminderRepository) DeleteExpiredBefore(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("expires_at < ?", before).Delete(&models.ExpiryReminder{})
+	return result.RowsAffected, result.Error
+}
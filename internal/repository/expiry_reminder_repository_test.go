@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"savvy/internal/models"
+	"savvy/internal/money"
+)
+
+func TestExpiryReminderRepository_ListExpiring(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewExpiryReminderRepository(db)
+	ctx := context.Background()
+
+	// Far in the future, so items of other tests are never listed
+	from := time.Date(2090, 1, 1, 0, 0, 0, 0, time.UTC)
+	ownerID := createTestUser(t, db)
+	shareeID := createTestUser(t, db)
+
+	voucher := &models.Voucher{UserID: &ownerID, Code: "EXPIRY-" + uuid.NewString()[:8], MerchantName: "Test Migros", ValidFrom: from, ValidUntil: from.AddDate(0, 0, 7), UsageLimitType: "unlimited"}
+	later := &models.Voucher{UserID: &ownerID, Code: "EXPIRY-" + uuid.NewString()[:8], MerchantName: "Test Coop", ValidFrom: from, ValidUntil: from.AddDate(0, 0, 60), UsageLimitType: "unlimited"}
+	expiresAt := from.AddDate(0, 0, 3)
+	giftCard := &models.GiftCard{UserID: &ownerID, CardNumber: "EXPIRY-" + uuid.NewString()[:8], MerchantName: "Test Manor", InitialBalance: money.New(5000, "CHF"), CurrentBalance: money.New(5000, "CHF"), Currency: "CHF", ExpiresAt: &expiresAt}
+	require.NoError(t, db.Create(voucher).Error)
+	require.NoError(t, db.Create(later).Error)
+	require.NoError(t, db.Create(giftCard).Error)
+	require.NoError(t, db.Create(&models.VoucherShare{VoucherID: voucher.ID, SharedWithID: shareeID}).Error)
+	t.Cleanup(func() {
+		db.Exec("DELETE FROM voucher_shares WHERE voucher_id = ?", voucher.ID)
+		db.Exec("DELETE FROM vouchers WHERE id IN ?", []uuid.UUID{voucher.ID, later.ID})
+		db.Exec("DELETE FROM gift_card_transactions WHERE gift_card_id = ?", giftCard.ID)
+		db.Exec("DELETE FROM gift_cards WHERE id = ?", giftCard.ID)
+	})
+
+	items, err := repo.ListExpiring(ctx, from, from.AddDate(0, 0, 30))
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+
+	assert.Equal(t, "voucher", items[0].ResourceType)
+	assert.Equal(t, voucher.ID, items[0].ResourceID)
+	assert.Equal(t, "Test Migros", items[0].Name)
+	assert.Equal(t, []uuid.UUID{ownerID, shareeID}, items[0].UserIDs)
+
+	assert.Equal(t, "gift_card", items[1].ResourceType)
+	assert.Equal(t, []uuid.UUID{ownerID}, items[1].UserIDs)
+
+	// Used up gift cards are not reminded
+	require.NoError(t, db.Exec("UPDATE gift_cards SET current_balance = 0 WHERE id = ?", giftCard.ID).Error)
+	items, err = repo.ListExpiring(ctx, from, from.AddDate(0, 0, 30))
+	require.NoError(t, err)
+	assert.Len(t, items, 1)
+}
+
+func TestExpiryReminderRepository_Record(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewExpiryReminderRepository(db)
+	ctx := context.Background()
+	userID := createTestUser(t, db)
+	expiresAt := time.Date(2090, 1, 8, 0, 0, 0, 0, time.UTC)
+
+	reminder := func(days int) models.ExpiryReminder {
+		return models.ExpiryReminder{UserID: userID, ResourceType: "voucher", ResourceID: uuid.Nil, Days: days, ExpiresAt: expiresAt}
+	}
+	recorded, err := repo.Record(ctx, []models.ExpiryReminder{reminder(30), reminder(7)})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), recorded)
+
+	recorded, err = repo.Record(ctx, []models.ExpiryReminder{reminder(30), reminder(7), reminder(1)})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), recorded, "Sent reminders are skipped")
+
+	deleted, err := repo.DeleteExpiredBefore(ctx, expiresAt.Add(time.Second))
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, deleted, int64(3))
+}
+
+func TestExpiryReminderRepository_Preferences(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewExpiryReminderRepository(db)
+	ctx := context.Background()
+	userID := createTestUser(t, db)
+	otherID := createTestUser(t, db)
+
+	require.NoError(t, repo.SavePreference(ctx, &models.ExpiryReminderPreference{UserID: userID, Days: models.ReminderDays{14, 1}}))
+	require.NoError(t, repo.SavePreference(ctx, &models.ExpiryReminderPreference{UserID: userID, Days: models.ReminderDays{}}))
+
+	preferences, err := repo.GetPreferences(ctx, []uuid.UUID{userID, otherID})
+	require.NoError(t, err)
+	require.Contains(t, preferences, userID)
+	assert.Empty(t, preferences[userID], "Turned off")
+	assert.NotContains(t, preferences, otherID)
+}
@@ -31,15 +31,19 @@ type GiftCardRepository interface {
 	// Count counts gift cards for a user
 	Count(ctx context.Context, userID uuid.UUID) (int64, error)
 
-	// GetTotalBalance calculates total balance across all user's gift cards
-	GetTotalBalance(ctx context.Context, userID uuid.UUID) (float64, error)
-
 	// CreateTransaction creates a new transaction for a gift card
 	CreateTransaction(ctx context.Context, transaction *models.GiftCardTransaction) error
 
 	// GetTransaction retrieves a transaction by ID, validating it belongs to the gift card
 	GetTransaction(ctx context.Context, transactionID, giftCardID uuid.UUID) (*models.GiftCardTransaction, error)
 
+	// UpdateTransaction saves the amount, description and date of a transaction
+	// and records its previous values as revision edited by userID
+	UpdateTransaction(ctx context.Context, transaction *models.GiftCardTransaction, userID uuid.UUID) error
+
 	// DeleteTransaction deletes a transaction by ID
 	DeleteTransaction(ctx context.Context, transactionID uuid.UUID) error
+
+	// GetPINReveals returns the audit log entries of PIN reveals, newest first
+	GetPINReveals(ctx context.Context, giftCardID uuid.UUID) ([]models.AuditLog, error)
 }
@@ -7,6 +7,7 @@ import (
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // GormGiftCardRepository implements GiftCardRepository using GORM.
@@ -87,27 +88,15 @@ func (r *GormGiftCardRepository) Count(ctx context.Context, userID uuid.UUID) (i
 	return count, err
 }
 
-func (r *GormGiftCardRepository) GetTotalBalance(ctx context.Context, userID uuid.UUID) (float64, error) {
-	giftCards, err := r.GetByUserID(ctx, userID)
-	if err != nil {
-		return 0, err
-	}
-
-	var totalBalance float64
-	for _, gc := range giftCards {
-		totalBalance += gc.CurrentBalance
-	}
-
-	return totalBalance, nil
-}
-
 func (r *GormGiftCardRepository) CreateTransaction(ctx context.Context, transaction *models.GiftCardTransaction) error {
 	return r.db.WithContext(ctx).Create(transaction).Error
 }
 
 func (r *GormGiftCardRepository) GetTransaction(ctx context.Context, transactionID, giftCardID uuid.UUID) (*models.GiftCardTransaction, error) {
 	var transaction models.GiftCardTransaction
+	// The gift card carries the currency of the amount
 	err := r.db.WithContext(ctx).
+		Preload("GiftCard").
 		Where("id = ? AND gift_card_id = ?", transactionID, giftCardID).
 		First(&transaction).Error
 	if err != nil {
@@ -116,6 +105,48 @@ func (r *GormGiftCardRepository) GetTransaction(ctx context.Context, transaction
 	return &transaction, nil
 }
 
+func (r *GormGiftCardRepository) UpdateTransaction(ctx context.Context, transaction *models.GiftCardTransaction, userID uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Lock the stored row so concurrent edits produce consecutive revisions
+		var previous models.GiftCardTransaction
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Preload("GiftCard").
+			Where("id = ? AND gift_card_id = ?", transaction.ID, transaction.GiftCardID).
+			First(&previous).Error; err != nil {
+			return err
+		}
+
+		if previous.Amount.Amount == transaction.Amount.Amount &&
+			previous.Description == transaction.Description &&
+			previous.TransactionDate.Equal(transaction.TransactionDate) {
+			return nil
+		}
+
+		revision := previous.NewRevision(userID)
+		if err := tx.Create(&revision).Error; err != nil {
+			return err
+		}
+
+		// The balance triggers re-check and recalculate the balance on update
+		return tx.Model(&previous).Updates(map[string]any{
+			"amount":           transaction.Amount,
+			"description":      transaction.Description,
+			"transaction_date": transaction.TransactionDate,
+		}).Error
+	})
+}
+
 func (r *GormGiftCardRepository) DeleteTransaction(ctx context.Context, transactionID uuid.UUID) error {
 	return r.db.WithContext(ctx).Delete(&models.GiftCardTransaction{}, "id = ?", transactionID).Error
 }
+
+func (r *GormGiftCardRepository) GetPINReveals(ctx context.Context, giftCardID uuid.UUID) ([]models.AuditLog, error) {
+	var reveals []models.AuditLog
+	err := r.db.WithContext(ctx).
+		Preload("User").
+		Where("resource_type = ? AND resource_id = ? AND action = ?", "gift_cards", giftCardID, models.AuditActionRevealPIN).
+		Order("created_at DESC").
+		Find(&reveals).Error
+
+	return reveals, err
+}
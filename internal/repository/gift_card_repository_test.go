@@ -7,6 +7,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"savvy/internal/models"
+	"savvy/internal/money"
 )
 
 func TestGiftCardRepository_Create(t *testing.T) {
@@ -19,8 +20,8 @@ func TestGiftCardRepository_Create(t *testing.T) {
 		UserID:         &userID,
 		CardNumber:     "GIFT-123",
 		MerchantName:   "Test Merchant",
-		InitialBalance: 100.0,
-		CurrentBalance: 100.0,
+		InitialBalance: money.New(10000, "CHF"),
+		CurrentBalance: money.New(10000, "CHF"),
 		Currency:       "CHF",
 	}
 
@@ -41,8 +42,8 @@ func TestGiftCardRepository_GetByID(t *testing.T) {
 		UserID:         &userID,
 		CardNumber:     "GIFT-GET",
 		MerchantName:   "Test",
-		InitialBalance: 50.0,
-		CurrentBalance: 50.0,
+		InitialBalance: money.New(5000, "CHF"),
+		CurrentBalance: money.New(5000, "CHF"),
 		Currency:       "CHF",
 	}
 	db.Create(giftCard)
@@ -52,7 +53,7 @@ func TestGiftCardRepository_GetByID(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, giftCard.ID, found.ID)
 	assert.Equal(t, "GIFT-GET", found.CardNumber)
-	assert.Equal(t, 50.0, found.CurrentBalance)
+	assert.Equal(t, money.New(5000, "CHF"), found.CurrentBalance)
 }
 
 func TestGiftCardRepository_GetByUserID(t *testing.T) {
@@ -62,8 +63,8 @@ func TestGiftCardRepository_GetByUserID(t *testing.T) {
 
 	userID := createTestUser(t, db)
 	giftCards := []models.GiftCard{
-		{UserID: &userID, CardNumber: "GC1", MerchantName: "M1", InitialBalance: 100, CurrentBalance: 100, Currency: "CHF"},
-		{UserID: &userID, CardNumber: "GC2", MerchantName: "M2", InitialBalance: 50, CurrentBalance: 50, Currency: "CHF"},
+		{UserID: &userID, CardNumber: "GC1", MerchantName: "M1", InitialBalance: money.New(10000, "CHF"), CurrentBalance: money.New(10000, "CHF"), Currency: "CHF"},
+		{UserID: &userID, CardNumber: "GC2", MerchantName: "M2", InitialBalance: money.New(5000, "CHF"), CurrentBalance: money.New(5000, "CHF"), Currency: "CHF"},
 	}
 	for i := range giftCards {
 		db.Create(&giftCards[i])
@@ -85,20 +86,20 @@ func TestGiftCardRepository_Update(t *testing.T) {
 		UserID:         &userID,
 		CardNumber:     "UPDATE-TEST",
 		MerchantName:   "Original",
-		InitialBalance: 100,
-		CurrentBalance: 100,
+		InitialBalance: money.New(10000, "CHF"),
+		CurrentBalance: money.New(10000, "CHF"),
 		Currency:       "CHF",
 	}
 	db.Create(giftCard)
 	defer db.Exec("DELETE FROM gift_cards WHERE id = ?", giftCard.ID)
 
-	giftCard.CurrentBalance = 75.5
+	giftCard.CurrentBalance = money.New(7550, "CHF")
 	err := repo.Update(ctx, giftCard)
 	assert.NoError(t, err)
 
 	var found models.GiftCard
 	db.First(&found, "id = ?", giftCard.ID)
-	assert.Equal(t, 75.5, found.CurrentBalance)
+	assert.Equal(t, money.New(7550, "CHF"), found.CurrentBalance)
 }
 
 func TestGiftCardRepository_Delete(t *testing.T) {
@@ -111,8 +112,8 @@ func TestGiftCardRepository_Delete(t *testing.T) {
 		UserID:         &userID,
 		CardNumber:     "DELETE-ME",
 		MerchantName:   "Test",
-		InitialBalance: 100,
-		CurrentBalance: 100,
+		InitialBalance: money.New(10000, "CHF"),
+		CurrentBalance: money.New(10000, "CHF"),
 		Currency:       "CHF",
 	}
 	db.Create(giftCard)
@@ -133,8 +134,8 @@ func TestGiftCardRepository_Count(t *testing.T) {
 		UserID:         &userID,
 		CardNumber:     "COUNT-TEST",
 		MerchantName:   "Test",
-		InitialBalance: 100,
-		CurrentBalance: 100,
+		InitialBalance: money.New(10000, "CHF"),
+		CurrentBalance: money.New(10000, "CHF"),
 		Currency:       "CHF",
 	}
 	db.Create(giftCard)
@@ -145,25 +146,69 @@ func TestGiftCardRepository_Count(t *testing.T) {
 	assert.Equal(t, initialCount+1, newCount)
 }
 
-func TestGiftCardRepository_GetTotalBalance(t *testing.T) {
+func TestGiftCardRepository_UpdateTransaction_KeepsRevision(t *testing.T) {
 	db := setupTestDB(t)
 	repo := NewGiftCardRepository(db)
 	ctx := context.Background()
 
 	userID := createTestUser(t, db)
-
-	// Create multiple gift cards
-	giftCards := []models.GiftCard{
-		{UserID: &userID, CardNumber: "BAL1", MerchantName: "M1", InitialBalance: 100, CurrentBalance: 75, Currency: "CHF"},
-		{UserID: &userID, CardNumber: "BAL2", MerchantName: "M2", InitialBalance: 50, CurrentBalance: 25, Currency: "CHF"},
+	giftCard := &models.GiftCard{
+		UserID:         &userID,
+		CardNumber:     "REVISION-TEST",
+		MerchantName:   "Test",
+		InitialBalance: money.New(10000, "CHF"),
+		CurrentBalance: money.New(10000, "CHF"),
+		Currency:       "CHF",
 	}
-	for i := range giftCards {
-		db.Create(&giftCards[i])
-		defer db.Exec("DELETE FROM gift_cards WHERE id = ?", giftCards[i].ID)
+	db.Create(giftCard)
+	defer db.Exec("DELETE FROM gift_cards WHERE id = ?", giftCard.ID)
+
+	transaction := &models.GiftCardTransaction{GiftCardID: giftCard.ID, Kind: models.TransactionKindPurchase, Amount: money.New(2500, "CHF"), Description: "Original"}
+	assert.NoError(t, repo.CreateTransaction(ctx, transaction))
+	defer db.Exec("DELETE FROM gift_card_transactions WHERE id = ?", transaction.ID)
+
+	edited := *transaction
+	edited.Amount = money.New(3000, "CHF")
+	edited.Description = "Edited"
+	assert.NoError(t, repo.UpdateTransaction(ctx, &edited, userID))
+
+	// Saving unchanged values adds no revision
+	assert.NoError(t, repo.UpdateTransaction(ctx, &edited, userID))
+
+	found, err := repo.GetByID(ctx, giftCard.ID, "Transactions.Revisions")
+	assert.NoError(t, err)
+	assert.Len(t, found.Transactions, 1)
+	assert.Equal(t, money.New(3000, "CHF"), found.Transactions[0].Amount)
+	assert.Len(t, found.Transactions[0].Revisions, 1)
+	assert.Equal(t, money.New(2500, "CHF"), found.Transactions[0].Revisions[0].Amount)
+	assert.Equal(t, "Original", found.Transactions[0].Revisions[0].Description)
+	assert.Equal(t, &userID, found.Transactions[0].Revisions[0].EditedByUserID)
+}
+
+func TestGiftCardRepository_GetTransaction_Currency(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewGiftCardRepository(db)
+	ctx := context.Background()
+
+	userID := createTestUser(t, db)
+	giftCard := &models.GiftCard{
+		UserID:         &userID,
+		CardNumber:     "JPY-TRANSACTION-TEST",
+		MerchantName:   "Test",
+		InitialBalance: money.New(5000, "JPY"),
+		CurrentBalance: money.New(5000, "JPY"),
+		Currency:       "JPY",
 	}
+	db.Create(giftCard)
+	defer db.Exec("DELETE FROM gift_cards WHERE id = ?", giftCard.ID)
+
+	transaction := &models.GiftCardTransaction{GiftCardID: giftCard.ID, Kind: models.TransactionKindPurchase, Amount: money.New(1200, "JPY")}
+	assert.NoError(t, repo.CreateTransaction(ctx, transaction))
+	defer db.Exec("DELETE FROM gift_card_transactions WHERE id = ?", transaction.ID)
 
-	totalBalance, err := repo.GetTotalBalance(ctx, userID)
+	// Loaded without the gift card, which has no minor units
+	found, err := repo.GetTransaction(ctx, transaction.ID, giftCard.ID)
 	assert.NoError(t, err)
-	// GetTotalBalance sums all gift cards for this user
-	assert.GreaterOrEqual(t, totalBalance, 100.0) // At least our 2 test cards (75+25=100)
+	assert.Equal(t, money.New(1200, "JPY"), found.Amount)
+	assert.Equal(t, "1200 JPY", found.Amount.String())
 }
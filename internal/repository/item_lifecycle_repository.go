@@ -0,0 +1,25 @@
+// Package repository contains data access interfaces and implementations.
+package repository
+
+import (
+	"context"
+	"savvy/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ItemLifecycleRepository defines the interface for voucher and gift card status data access.
+type ItemLifecycleRepository interface {
+	// ApplyTransitions sets the status of every voucher and gift card whose
+	// expiry date or balance no longer matches it, records each change and
+	// returns the changes. Items with a status set by hand (e.g. "inactive")
+	// are left alone.
+	ApplyTransitions(ctx context.Context, now time.Time) ([]models.ItemStatusChange, error)
+
+	// GetHistory retrieves the status changes of an item, newest first.
+	GetHistory(ctx context.Context, resourceType string, resourceID uuid.UUID) ([]models.ItemStatusChange, error)
+
+	// CountByStatus counts the vouchers and gift cards that are not deleted per status.
+	CountByStatus(ctx context.Context) ([]models.ItemStatusCount, error)
+}
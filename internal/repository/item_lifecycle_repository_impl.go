@@ -0,0 +1,116 @@
+// Package repository contains data access implementations.
+package repository
+
+import (
+	"context"
+	"savvy/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// GormItemLifecycleRepository is a GORM implementation of ItemLifecycleRepository.
+type GormItemLifecycleRepository struct {
+	db *gorm.DB
+}
+
+// NewItemLifecycleRepository creates a new item lifecycle repository.
+func NewItemLifecycleRepository(db *gorm.DB) ItemLifecycleRepository {
+	return &GormItemLifecycleRepository{db: db}
+}
+
+// lifecycleTransitions derive the status of each item type. Only items with
+// one of the automatic statuses are selected, so a status set by hand wins.
+// updated_at is not touched, a transition is no edit of the owner.
+var lifecycleTransitions = []struct {
+	resourceType string
+	sql          string
+}{
+	{
+		resourceType: "voucher",
+		sql: `
+			UPDATE vouchers v SET status = s.to_status
+			FROM (
+				SELECT id, status AS from_status,
+					CASE WHEN valid_until < @now THEN 'expired' ELSE 'active' END AS to_status
+				FROM vouchers
+				WHERE deleted_at IS NULL AND status IN @statuses
+			) s
+			WHERE v.id = s.id AND s.from_status <> s.to_status
+			RETURNING v.id AS resource_id, v.user_id, s.from_status, s.to_status
+		`,
+	},
+	{
+		resourceType: "gift_card",
+		sql: `
+			UPDATE gift_cards g SET status = s.to_status
+			FROM (
+				SELECT id, status AS from_status,
+					CASE
+						WHEN current_balance <= 0 THEN 'redeemed'
+						WHEN expires_at < @now THEN 'expired'
+						ELSE 'active'
+					END AS to_status
+				FROM gift_cards
+				WHERE deleted_at IS NULL AND status IN @statuses
+			) s
+			WHERE g.id = s.id AND s.from_status <> s.to_status
+			RETURNING g.id AS resource_id, g.user_id, s.from_status, s.to_status
+		`,
+	},
+}
+
+func (r *GormItemLifecycleRepository) ApplyTransitions(ctx context.Context, now time.Time) ([]models.ItemStatusChange, error) {
+	var changes []models.ItemStatusChange
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, transition := range lifecycleTransitions {
+			statuses := models.VoucherStatuses
+			if transition.resourceType == "gift_card" {
+				statuses = models.GiftCardStatuses
+			}
+
+			var changed []models.ItemStatusChange
+			if err := tx.Raw(transition.sql, map[string]any{"now": now, "statuses": statuses}).Scan(&changed).Error; err != nil {
+				return err
+			}
+			for i := range changed {
+				changed[i].ResourceType = transition.resourceType
+				changed[i].Reason = models.StatusChangeReason(changed[i].ToStatus)
+				changed[i].CreatedAt = now
+			}
+			changes = append(changes, changed...)
+		}
+
+		if len(changes) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(&changes, 500).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+func (r *GormItemLifecycleRepository) GetHistory(ctx context.Context, resourceType string, resourceID uuid.UUID) ([]models.ItemStatusChange, error) {
+	var changes []models.ItemStatusChange
+	err := r.db.WithContext(ctx).
+		Where("resource_type = ? AND resource_id = ?", resourceType, resourceID).
+		Order("created_at DESC").
+		Find(&changes).Error
+	return changes, err
+}
+
+func (r *GormItemLifecycleRepository) CountByStatus(ctx context.Context) ([]models.ItemStatusCount, error) {
+	var counts []models.ItemStatusCount
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT 'voucher' AS resource_type, status, COUNT(*) AS count
+		FROM vouchers WHERE deleted_at IS NULL GROUP BY status
+		UNION ALL
+		SELECT 'gift_card' AS resource_type, status, COUNT(*) AS count
+		FROM gift_cards WHERE deleted_at IS NULL GROUP BY status
+		ORDER BY resource_type, status
+	`).Scan(&counts).Error
+	return counts, err
+}
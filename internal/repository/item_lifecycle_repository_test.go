@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"savvy/internal/models"
+	"savvy/internal/money"
+)
+
+func TestItemLifecycleRepository_ApplyTransitions(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewItemLifecycleRepository(db)
+	ctx := context.Background()
+	ownerID := createTestUser(t, db)
+	now := time.Now()
+	past := now.AddDate(0, 0, -1)
+	future := now.AddDate(0, 0, 30)
+
+	expired := &models.Voucher{UserID: &ownerID, Code: "LIFECYCLE-" + uuid.NewString()[:8], MerchantName: "Test Migros", ValidFrom: past.AddDate(0, -1, 0), ValidUntil: past, UsageLimitType: "unlimited"}
+	valid := &models.Voucher{UserID: &ownerID, Code: "LIFECYCLE-" + uuid.NewString()[:8], MerchantName: "Test Coop", ValidFrom: past, ValidUntil: future, UsageLimitType: "unlimited"}
+	depleted := &models.GiftCard{UserID: &ownerID, CardNumber: "LIFECYCLE-" + uuid.NewString()[:8], MerchantName: "Test Manor", InitialBalance: money.New(5000, "CHF"), CurrentBalance: money.New(0, "CHF"), Currency: "CHF", ExpiresAt: &future}
+	inactive := &models.GiftCard{UserID: &ownerID, CardNumber: "LIFECYCLE-" + uuid.NewString()[:8], MerchantName: "Test Manor", InitialBalance: money.New(5000, "CHF"), CurrentBalance: money.New(5000, "CHF"), Currency: "CHF", ExpiresAt: &past, Status: "inactive"}
+	require.NoError(t, db.Create(expired).Error)
+	require.NoError(t, db.Create(valid).Error)
+	require.NoError(t, db.Create(depleted).Error)
+	require.NoError(t, db.Create(inactive).Error)
+	ids := []uuid.UUID{expired.ID, valid.ID, depleted.ID, inactive.ID}
+	t.Cleanup(func() {
+		db.Exec("DELETE FROM item_status_changes WHERE resource_id IN ?", ids)
+		db.Exec("DELETE FROM vouchers WHERE id IN ?", []uuid.UUID{expired.ID, valid.ID})
+		db.Exec("DELETE FROM gift_card_transactions WHERE gift_card_id IN ?", []uuid.UUID{depleted.ID, inactive.ID})
+		db.Exec("DELETE FROM gift_cards WHERE id IN ?", []uuid.UUID{depleted.ID, inactive.ID})
+	})
+
+	changes, err := repo.ApplyTransitions(ctx, now)
+	require.NoError(t, err)
+	byID := make(map[uuid.UUID]models.ItemStatusChange)
+	for _, change := range changes {
+		byID[change.ResourceID] = change
+	}
+
+	require.Contains(t, byID, expired.ID)
+	assert.Equal(t, "voucher", byID[expired.ID].ResourceType)
+	assert.Equal(t, models.ItemStatusActive, byID[expired.ID].FromStatus)
+	assert.Equal(t, models.ItemStatusExpired, byID[expired.ID].ToStatus)
+	assert.Equal(t, models.StatusReasonExpired, byID[expired.ID].Reason)
+	assert.Equal(t, &ownerID, byID[expired.ID].UserID)
+
+	require.Contains(t, byID, depleted.ID)
+	assert.Equal(t, models.ItemStatusRedeemed, byID[depleted.ID].ToStatus)
+	assert.Equal(t, models.StatusReasonDepleted, byID[depleted.ID].Reason)
+
+	assert.NotContains(t, byID, valid.ID)
+	assert.NotContains(t, byID, inactive.ID, "Statuses set by hand are kept")
+
+	var voucher models.Voucher
+	require.NoError(t, db.First(&voucher, "id = ?", expired.ID).Error)
+	assert.Equal(t, models.ItemStatusExpired, voucher.Status)
+
+	history, err := repo.GetHistory(ctx, "voucher", expired.ID)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, models.ItemStatusExpired, history[0].ToStatus)
+
+	// An extended voucher is renewed, the next run finds nothing left to do
+	require.NoError(t, db.Model(&models.Voucher{}).Where("id = ?", expired.ID).Update("valid_until", future).Error)
+	changes, err = repo.ApplyTransitions(ctx, now)
+	require.NoError(t, err)
+	renewed := 0
+	for _, change := range changes {
+		if change.ResourceID == expired.ID {
+			renewed++
+			assert.Equal(t, models.StatusReasonRenewed, change.Reason)
+		}
+		assert.NotEqual(t, depleted.ID, change.ResourceID)
+	}
+	assert.Equal(t, 1, renewed)
+
+	history, err = repo.GetHistory(ctx, "voucher", expired.ID)
+	require.NoError(t, err)
+	assert.Len(t, history, 2)
+}
+
+func TestItemLifecycleRepository_CountByStatus(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewItemLifecycleRepository(db)
+	ownerID := createTestUser(t, db)
+
+	voucher := &models.Voucher{UserID: &ownerID, Code: "LIFECYCLE-" + uuid.NewString()[:8], MerchantName: "Test Migros", ValidFrom: time.Now(), ValidUntil: time.Now().AddDate(0, 0, 30), UsageLimitType: "unlimited"}
+	require.NoError(t, db.Create(voucher).Error)
+	t.Cleanup(func() { db.Exec("DELETE FROM vouchers WHERE id = ?", voucher.ID) })
+
+	counts, err := repo.CountByStatus(context.Background())
+	require.NoError(t, err)
+	found := false
+	for _, count := range counts {
+		if count.ResourceType == "voucher" && count.Status == models.ItemStatusActive {
+			found = true
+			assert.GreaterOrEqual(t, count.Count, int64(1))
+		}
+	}
+	assert.True(t, found)
+}
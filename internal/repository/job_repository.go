@@ -0,0 +1,37 @@
+// Package repository contains data access interfaces and implementations.
+package repository
+
+import (
+	"context"
+	"savvy/internal/models"
+	"time"
+)
+
+// JobRepository defines the interface for scheduled job data access.
+type JobRepository interface {
+	// Register creates the row of a job or updates its schedule.
+	Register(ctx context.Context, name, schedule string, local bool) error
+
+	// WithLock runs fn while holding the Postgres advisory lock of the job.
+	// Returns false without calling fn if another replica holds the lock.
+	WithLock(ctx context.Context, name string, fn func(ctx context.Context)) (bool, error)
+
+	// Claim records that the slot of a job is being run. Returns false if
+	// the slot or a later one was already claimed, e.g. by another replica.
+	Claim(ctx context.Context, name string, scheduledAt time.Time) (bool, error)
+
+	// StartRun stores a run that has just started.
+	StartRun(ctx context.Context, run *models.JobRun) error
+
+	// FinishRun stores the outcome of a run and copies it to the job.
+	FinishRun(ctx context.Context, run *models.JobRun) error
+
+	// List retrieves all jobs ordered by name.
+	List(ctx context.Context) ([]models.Job, error)
+
+	// ListRuns retrieves the latest runs, of one job if name is not empty.
+	ListRuns(ctx context.Context, name string, limit int) ([]models.JobRun, error)
+
+	// DeleteRunsBefore removes runs started before the given time and returns how many there were.
+	DeleteRunsBefore(ctx context.Context, before time.Time) (int64, error)
+}
@@ -0,0 +1,117 @@
+// Package repository contains data access implementations.
+package repository
+
+import (
+	"context"
+	"hash/fnv"
+	"savvy/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GormJobRepository implements JobRepository using GORM
+type GormJobRepository struct {
+	db *gorm.DB
+}
+
+// NewJobRepository creates a new GORM-based job repository
+func NewJobRepository(db *gorm.DB) JobRepository {
+	return &GormJobRepository{db: db}
+}
+
+func (r *GormJobRepository) Register(ctx context.Context, name, schedule string, local bool) error {
+	job := models.Job{Name: name, Schedule: schedule, Local: local}
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "name"}},
+			DoUpdates: clause.AssignmentColumns([]string{"schedule", "local", "updated_at"}),
+		}).
+		Create(&job).Error
+}
+
+// jobLockKey maps a job name to the key of its advisory lock
+func jobLockKey(name string) int64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte("savvy.jobs." + name))
+	return int64(h.Sum64()) //nolint:gosec // Wrapping is fine for a lock key
+}
+
+// WithLock takes a session-level advisory lock, so it pins one connection of
+// the pool for the duration of fn. The lock is released if the connection
+// breaks, e.g. when the replica dies.
+func (r *GormJobRepository) WithLock(ctx context.Context, name string, fn func(ctx context.Context)) (bool, error) {
+	key := jobLockKey(name)
+	locked := false
+	err := r.db.WithContext(ctx).Connection(func(conn *gorm.DB) error {
+		if err := conn.Raw("SELECT pg_try_advisory_lock(?)", key).Scan(&locked).Error; err != nil {
+			return err
+		}
+		if !locked {
+			return nil
+		}
+		// Unlock even if ctx was canceled by the job
+		defer conn.WithContext(context.Background()).Exec("SELECT pg_advisory_unlock(?)", key)
+		fn(ctx)
+		return nil
+	})
+	return locked, err
+}
+
+func (r *GormJobRepository) Claim(ctx context.Context, name string, scheduledAt time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&models.Job{}).
+		Where("name = ? AND (last_scheduled_at IS NULL OR last_scheduled_at < ?)", name, scheduledAt).
+		Updates(map[string]any{"last_scheduled_at": scheduledAt, "updated_at": time.Now()})
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *GormJobRepository) StartRun(ctx context.Context, run *models.JobRun) error {
+	return r.db.WithContext(ctx).Create(run).Error
+}
+
+func (r *GormJobRepository) FinishRun(ctx context.Context, run *models.JobRun) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(run).
+			Select("finished_at", "status", "error", "duration").
+			Updates(run).Error; err != nil {
+			return err
+		}
+
+		updates := map[string]any{
+			"last_started_at":  run.StartedAt,
+			"last_finished_at": run.FinishedAt,
+			"last_status":      run.Status,
+			"last_error":       run.Error,
+			"last_duration":    run.Duration,
+			"last_instance":    run.Instance,
+			"updated_at":       time.Now(),
+		}
+		if run.Status == models.JobRunFailed {
+			updates["last_error_at"] = run.FinishedAt
+		}
+		return tx.Model(&models.Job{}).Where("name = ?", run.JobName).Updates(updates).Error
+	})
+}
+
+func (r *GormJobRepository) List(ctx context.Context) ([]models.Job, error) {
+	var jobs []models.Job
+	err := r.db.WithContext(ctx).Order("name ASC").Find(&jobs).Error
+	return jobs, err
+}
+
+func (r *GormJobRepository) ListRuns(ctx context.Context, name string, limit int) ([]models.JobRun, error) {
+	query := r.db.WithContext(ctx).Order("started_at DESC").Limit(limit)
+	if name != "" {
+		query = query.Where("job_name = ?", name)
+	}
+	var runs []models.JobRun
+	err := query.Find(&runs).Error
+	return runs, err
+}
+
+func (r *GormJobRepository) DeleteRunsBefore(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("started_at < ?", before).Delete(&models.JobRun{})
+	return result.RowsAffected, result.Error
+}
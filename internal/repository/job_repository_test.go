@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"savvy/internal/models"
+)
+
+func registerTestJob(t *testing.T, db *gorm.DB, repo JobRepository) string {
+	t.Helper()
+	name := "test." + uuid.NewString()
+	require.NoError(t, repo.Register(context.Background(), name, "@every 1m", false))
+	t.Cleanup(func() {
+		db.Exec("DELETE FROM job_runs WHERE job_name = ?", name)
+		db.Exec("DELETE FROM jobs WHERE name = ?", name)
+	})
+	return name
+}
+
+func TestJobRepository_Register(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewJobRepository(db)
+	ctx := context.Background()
+	name := registerTestJob(t, db, repo)
+
+	// Registering again on startup keeps the state and updates the schedule
+	slot := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	claimed, err := repo.Claim(ctx, name, slot)
+	require.NoError(t, err)
+	require.True(t, claimed)
+	require.NoError(t, repo.Register(ctx, name, "*/5 * * * *", true))
+
+	var job models.Job
+	require.NoError(t, db.First(&job, "name = ?", name).Error)
+	assert.Equal(t, "*/5 * * * *", job.Schedule)
+	assert.True(t, job.Local)
+	require.NotNil(t, job.LastScheduledAt)
+	assert.True(t, slot.Equal(*job.LastScheduledAt))
+}
+
+func TestJobRepository_Claim(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewJobRepository(db)
+	ctx := context.Background()
+	name := registerTestJob(t, db, repo)
+	slot := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	claimed, err := repo.Claim(ctx, name, slot)
+	require.NoError(t, err)
+	assert.True(t, claimed)
+
+	claimed, err = repo.Claim(ctx, name, slot)
+	require.NoError(t, err)
+	assert.False(t, claimed, "Each slot runs once")
+
+	claimed, err = repo.Claim(ctx, name, slot.Add(-time.Minute))
+	require.NoError(t, err)
+	assert.False(t, claimed, "Earlier slots are not repeated")
+
+	claimed, err = repo.Claim(ctx, name, slot.Add(time.Minute))
+	require.NoError(t, err)
+	assert.True(t, claimed)
+}
+
+func TestJobRepository_WithLock(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewJobRepository(db)
+	ctx := context.Background()
+	name := registerTestJob(t, db, repo)
+
+	ran := false
+	locked, err := repo.WithLock(ctx, name, func(ctx context.Context) {
+		// A second replica does not get the lock while it is held
+		nested, err := repo.WithLock(ctx, name, func(context.Context) {
+			t.Error("Lock was taken twice")
+		})
+		require.NoError(t, err)
+		assert.False(t, nested)
+		ran = true
+	})
+	require.NoError(t, err)
+	assert.True(t, locked)
+	assert.True(t, ran)
+
+	// Released afterwards
+	locked, err = repo.WithLock(ctx, name, func(context.Context) {})
+	require.NoError(t, err)
+	assert.True(t, locked)
+}
+
+func TestJobRepository_Runs(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewJobRepository(db)
+	ctx := context.Background()
+	name := registerTestJob(t, db, repo)
+	started := time.Now().Add(-time.Minute).UTC()
+
+	run := &models.JobRun{JobName: name, Instance: "replica-1", ScheduledAt: started, StartedAt: started, Status: models.JobRunRunning}
+	require.NoError(t, repo.StartRun(ctx, run))
+
+	finished := started.Add(2 * time.Second)
+	run.FinishedAt = &finished
+	run.Duration = 2 * time.Second
+	run.Status = models.JobRunFailed
+	run.Error = "database unavailable"
+	require.NoError(t, repo.FinishRun(ctx, run))
+
+	var job models.Job
+	require.NoError(t, db.First(&job, "name = ?", name).Error)
+	assert.Equal(t, models.JobRunFailed, job.LastStatus)
+	assert.Equal(t, "database unavailable", job.LastError)
+	assert.Equal(t, 2*time.Second, job.LastDuration)
+	assert.Equal(t, "replica-1", job.LastInstance)
+	require.NotNil(t, job.LastErrorAt)
+
+	runs, err := repo.ListRuns(ctx, name, 10)
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	assert.Equal(t, models.JobRunFailed, runs[0].Status)
+
+	old := &models.JobRun{JobName: name, Instance: "replica-1", ScheduledAt: started, StartedAt: started.Add(-30 * 24 * time.Hour), Status: models.JobRunSucceeded}
+	require.NoError(t, repo.StartRun(ctx, old))
+	deleted, err := repo.DeleteRunsBefore(ctx, started.Add(-24*time.Hour))
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, deleted, int64(1))
+
+	runs, err = repo.ListRuns(ctx, name, 10)
+	require.NoError(t, err)
+	assert.Len(t, runs, 1)
+}
@@ -0,0 +1,36 @@
+// Package repository contains data access interfaces and implementations.
+package repository
+
+import (
+	"context"
+	"savvy/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LoginThrottleRepository defines the interface for failed login counter data access.
+type LoginThrottleRepository interface {
+	// Get retrieves the counter of a subject, gorm.ErrRecordNotFound if there were no failures.
+	Get(ctx context.Context, scope, subject string) (*models.LoginThrottle, error)
+
+	// RecordFailure atomically counts a failure and returns the updated counter.
+	// The count restarts at 1 if the previous failure is older than window.
+	RecordFailure(ctx context.Context, scope, subject string, at time.Time, window time.Duration) (*models.LoginThrottle, error)
+
+	// Lock blocks logins of a counter until the given time.
+	Lock(ctx context.Context, id uuid.UUID, until time.Time) error
+
+	// Reset removes the counter of a subject, e.g. after a successful login.
+	Reset(ctx context.Context, scope, subject string) error
+
+	// Delete removes a counter by ID and returns it, gorm.ErrRecordNotFound if it does not exist.
+	Delete(ctx context.Context, id uuid.UUID) (*models.LoginThrottle, error)
+
+	// GetLocked retrieves the counters locked at the given time, longest lockout first.
+	GetLocked(ctx context.Context, at time.Time) ([]models.LoginThrottle, error)
+
+	// DeleteStale removes unlocked counters whose last failure is before the
+	// given time and returns how many there were.
+	DeleteStale(ctx context.Context, before time.Time) (int64, error)
+}
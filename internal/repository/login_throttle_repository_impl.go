@@ -0,0 +1,87 @@
+// Package repository contains data access implementations.
+package repository
+
+import (
+	"context"
+	"savvy/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GormLoginThrottleRepository is a GORM implementation of LoginThrottleRepository.
+type GormLoginThrottleRepository struct {
+	db *gorm.DB
+}
+
+// NewLoginThrottleRepository creates a new failed login counter repository.
+func NewLoginThrottleRepository(db *gorm.DB) LoginThrottleRepository {
+	return &GormLoginThrottleRepository{db: db}
+}
+
+func (r *GormLoginThrottleRepository) Get(ctx context.Context, scope, subject string) (*models.LoginThrottle, error) {
+	var throttle models.LoginThrottle
+	err := r.db.WithContext(ctx).Where("scope = ? AND subject = ?", scope, subject).First(&throttle).Error
+	if err != nil {
+		return nil, err
+	}
+	return &throttle, nil
+}
+
+// RecordFailure upserts on (scope, subject), so concurrent failures are all counted.
+func (r *GormLoginThrottleRepository) RecordFailure(ctx context.Context, scope, subject string, at time.Time, window time.Duration) (*models.LoginThrottle, error) {
+	var throttle models.LoginThrottle
+	err := r.db.WithContext(ctx).Raw(`
+		INSERT INTO login_throttles (id, scope, subject, failures, last_failure_at, created_at)
+		VALUES (?, ?, ?, 1, ?, ?)
+		ON CONFLICT (scope, subject) DO UPDATE SET
+			failures = CASE WHEN login_throttles.last_failure_at < ? THEN 1 ELSE login_throttles.failures + 1 END,
+			last_failure_at = EXCLUDED.last_failure_at
+		RETURNING *
+	`, uuid.New(), scope, subject, at, at, at.Add(-window)).Scan(&throttle).Error
+	if err != nil {
+		return nil, err
+	}
+	return &throttle, nil
+}
+
+func (r *GormLoginThrottleRepository) Lock(ctx context.Context, id uuid.UUID, until time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&models.LoginThrottle{}).
+		Where("id = ?", id).
+		Update("locked_until", until).Error
+}
+
+func (r *GormLoginThrottleRepository) Reset(ctx context.Context, scope, subject string) error {
+	return r.db.WithContext(ctx).Where("scope = ? AND subject = ?", scope, subject).Delete(&models.LoginThrottle{}).Error
+}
+
+func (r *GormLoginThrottleRepository) Delete(ctx context.Context, id uuid.UUID) (*models.LoginThrottle, error) {
+	var throttle models.LoginThrottle
+	result := r.db.WithContext(ctx).Clauses(clause.Returning{}).Where("id = ?", id).Delete(&throttle)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &throttle, nil
+}
+
+func (r *GormLoginThrottleRepository) GetLocked(ctx context.Context, at time.Time) ([]models.LoginThrottle, error) {
+	var throttles []models.LoginThrottle
+	err := r.db.WithContext(ctx).
+		Where("locked_until > ?", at).
+		Order("locked_until DESC").
+		Find(&throttles).Error
+	return throttles, err
+}
+
+func (r *GormLoginThrottleRepository) DeleteStale(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Where("last_failure_at < ? AND (locked_until IS NULL OR locked_until <= ?)", before, time.Now()).
+		Delete(&models.LoginThrottle{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"savvy/internal/models"
+)
+
+func testThrottleSubject(t *testing.T, db *gorm.DB) string {
+	t.Helper()
+	subject := "test-" + uuid.NewString()[:8] + "@example.com"
+	t.Cleanup(func() {
+		db.Exec("DELETE FROM login_throttles WHERE subject = ?", subject)
+	})
+	return subject
+}
+
+func TestLoginThrottleRepository_RecordFailure(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewLoginThrottleRepository(db)
+	ctx := context.Background()
+	subject := testThrottleSubject(t, db)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	for i := 1; i <= 3; i++ {
+		throttle, err := repo.RecordFailure(ctx, models.LoginThrottleScopeAccount, subject, now.Add(time.Duration(i)*time.Second), time.Hour)
+		require.NoError(t, err)
+		assert.Equal(t, i, throttle.Failures)
+	}
+
+	// The same subject is counted separately per scope
+	throttle, err := repo.RecordFailure(ctx, models.LoginThrottleScopeIP, subject, now, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 1, throttle.Failures)
+
+	// After a quiet window the count starts over
+	throttle, err = repo.RecordFailure(ctx, models.LoginThrottleScopeAccount, subject, now.Add(2*time.Hour), time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 1, throttle.Failures)
+	assert.True(t, now.Add(2*time.Hour).Equal(throttle.LastFailureAt.UTC()))
+}
+
+func TestLoginThrottleRepository_Lock(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewLoginThrottleRepository(db)
+	ctx := context.Background()
+	subject := testThrottleSubject(t, db)
+
+	throttle, err := repo.RecordFailure(ctx, models.LoginThrottleScopeAccount, subject, time.Now(), time.Hour)
+	require.NoError(t, err)
+	require.NoError(t, repo.Lock(ctx, throttle.ID, time.Now().Add(time.Hour)))
+
+	locked, err := repo.GetLocked(ctx, time.Now())
+	require.NoError(t, err)
+	found := false
+	for _, l := range locked {
+		found = found || l.ID == throttle.ID
+	}
+	assert.True(t, found)
+
+	// Locked counters are kept until the lockout expired
+	_, err = repo.DeleteStale(ctx, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	_, err = repo.Get(ctx, models.LoginThrottleScopeAccount, subject)
+	assert.NoError(t, err)
+
+	deleted, err := repo.Delete(ctx, throttle.ID)
+	require.NoError(t, err)
+	assert.Equal(t, subject, deleted.Subject)
+	_, err = repo.Delete(ctx, throttle.ID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestLoginThrottleRepository_DeleteStale(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewLoginThrottleRepository(db)
+	ctx := context.Background()
+	stale := testThrottleSubject(t, db)
+	recent := testThrottleSubject(t, db)
+
+	_, err := repo.RecordFailure(ctx, models.LoginThrottleScopeAccount, stale, time.Now().Add(-2*time.Hour), time.Hour)
+	require.NoError(t, err)
+	_, err = repo.RecordFailure(ctx, models.LoginThrottleScopeAccount, recent, time.Now(), time.Hour)
+	require.NoError(t, err)
+
+	deleted, err := repo.DeleteStale(ctx, time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, deleted, int64(1))
+
+	_, err = repo.Get(ctx, models.LoginThrottleScopeAccount, stale)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	_, err = repo.Get(ctx, models.LoginThrottleScopeAccount, recent)
+	assert.NoError(t, err)
+
+	require.NoError(t, repo.Reset(ctx, models.LoginThrottleScopeAccount, recent))
+	_, err = repo.Get(ctx, models.LoginThrottleScopeAccount, recent)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
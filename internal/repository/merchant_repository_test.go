@@ -38,8 +38,25 @@ func setupTestDB(t *testing.T) *gorm.DB {
 		&models.GiftCard{},
 		&models.GiftCardShare{},
 		&models.GiftCardTransaction{},
+		&models.GiftCardTransactionRevision{},
+		&models.ExchangeRate{},
 		&models.UserFavorite{},
 		&models.AuditLog{},
+		&models.APIToken{},
+		&models.UserSession{},
+		&models.UserRecoveryCode{},
+		&models.WebAuthnCredential{},
+		&models.LoginThrottle{},
+		&models.Notification{},
+		&models.NotificationDelivery{},
+		&models.NotificationPreference{},
+		&models.PushSubscription{},
+		&models.Job{},
+		&models.JobRun{},
+		&models.ExpiryReminderPreference{},
+		&models.ExpiryReminder{},
+		&models.ItemStatusChange{},
+		&models.CalendarFeed{},
 	)
 	if err != nil {
 		t.Fatalf("Failed to migrate: %v", err)
@@ -0,0 +1,26 @@
+// Package repository contains data access interfaces and implementations.
+package repository
+
+import (
+	"context"
+	"savvy/internal/models"
+	"time"
+)
+
+// NotificationDeliveryRepository defines the interface for the notification delivery queue.
+type NotificationDeliveryRepository interface {
+	// Create queues deliveries.
+	Create(ctx context.Context, deliveries []models.NotificationDelivery) error
+
+	// ClaimDue returns up to limit pending deliveries due at now, oldest
+	// first, with their notification. Their next attempt is moved to
+	// leaseUntil, so other replicas skip them while they are being sent.
+	ClaimDue(ctx context.Context, now, leaseUntil time.Time, limit int) ([]models.NotificationDelivery, error)
+
+	// Update stores the outcome of an attempt: status, attempts, next attempt, error and send time.
+	Update(ctx context.Context, delivery *models.NotificationDelivery) error
+
+	// DeleteFinished removes deliveries that are no longer pending and were
+	// last updated before the given time, and returns how many there were.
+	DeleteFinished(ctx context.Context, before time.Time) (int64, error)
+}
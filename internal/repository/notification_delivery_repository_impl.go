@@ -0,0 +1,77 @@
+// Package repository contains data access implementations.
+package repository
+
+import (
+	"context"
+	"savvy/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GormNotificationDeliveryRepository is a GORM implementation of NotificationDeliveryRepository.
+type GormNotificationDeliveryRepository struct {
+	db *gorm.DB
+}
+
+// NewNotificationDeliveryRepository creates a new notification delivery repository.
+func NewNotificationDeliveryRepository(db *gorm.DB) NotificationDeliveryRepository {
+	return &GormNotificationDeliveryRepository{db: db}
+}
+
+func (r *GormNotificationDeliveryRepository) Create(ctx context.Context, deliveries []models.NotificationDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Omit("Notification").Create(&deliveries).Error
+}
+
+// ClaimDue locks the rows with SKIP LOCKED, so replicas polling at the same
+// time claim different deliveries instead of waiting for each other.
+func (r *GormNotificationDeliveryRepository) ClaimDue(ctx context.Context, now, leaseUntil time.Time, limit int) ([]models.NotificationDelivery, error) {
+	var ids []uuid.UUID
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.NotificationDelivery{}).
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", models.NotificationDeliveryPending, now).
+			Order("next_attempt_at ASC").
+			Limit(limit).
+			Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		return tx.Model(&models.NotificationDelivery{}).
+			Where("id IN ?", ids).
+			Update("next_attempt_at", leaseUntil).Error
+	})
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+
+	// Archived notifications are not loaded and leave Notification nil
+	var deliveries []models.NotificationDelivery
+	err = r.db.WithContext(ctx).
+		Preload("Notification").
+		Where("id IN ?", ids).
+		Order("created_at ASC").
+		Find(&deliveries).Error
+	return deliveries, err
+}
+
+func (r *GormNotificationDeliveryRepository) Update(ctx context.Context, delivery *models.NotificationDelivery) error {
+	return r.db.WithContext(ctx).
+		Model(delivery).
+		Select("status", "attempts", "next_attempt_at", "last_error", "sent_at", "updated_at").
+		Updates(delivery).Error
+}
+
+func (r *GormNotificationDeliveryRepository) DeleteFinished(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Where("status <> ? AND updated_at < ?", models.NotificationDeliveryPending, before).
+		Delete(&models.NotificationDelivery{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"savvy/internal/models"
+)
+
+func createTestNotification(t *testing.T, db *gorm.DB, userID uuid.UUID) *models.Notification {
+	t.Helper()
+	notification := &models.Notification{
+		UserID:       userID,
+		Type:         models.NotificationTypeShareReceived,
+		ResourceType: "card",
+		ResourceID:   uuid.New(),
+	}
+	require.NoError(t, db.Create(notification).Error)
+	t.Cleanup(func() {
+		db.Exec("DELETE FROM notification_deliveries WHERE notification_id = ?", notification.ID)
+		db.Exec("DELETE FROM notifications WHERE id = ?", notification.ID)
+	})
+	return notification
+}
+
+func TestNotificationDeliveryRepository_ClaimDue(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewNotificationDeliveryRepository(db)
+	ctx := context.Background()
+
+	// Far in the past, so deliveries of other tests are never due
+	now := time.Date(2001, 1, 1, 12, 0, 0, 0, time.UTC)
+	userID := createTestUser(t, db)
+	notification := createTestNotification(t, db, userID)
+	archived := createTestNotification(t, db, userID)
+
+	require.NoError(t, repo.Create(ctx, []models.NotificationDelivery{
+		{NotificationID: notification.ID, UserID: userID, Channel: models.NotificationChannelEmail, Status: models.NotificationDeliveryPending, NextAttemptAt: now.Add(-time.Minute)},
+		{NotificationID: notification.ID, UserID: userID, Channel: models.NotificationChannelPush, Status: models.NotificationDeliveryPending, NextAttemptAt: now.Add(time.Minute)},
+		{NotificationID: archived.ID, UserID: userID, Channel: models.NotificationChannelEmail, Status: models.NotificationDeliveryPending, NextAttemptAt: now.Add(-time.Hour)},
+	}))
+	require.NoError(t, db.Delete(archived).Error)
+
+	claimed, err := repo.ClaimDue(ctx, now, now.Add(5*time.Minute), 10)
+	require.NoError(t, err)
+	require.Len(t, claimed, 2)
+	for _, delivery := range claimed {
+		assert.True(t, now.Add(5*time.Minute).Equal(delivery.NextAttemptAt), "Claimed deliveries are leased")
+		if delivery.NotificationID == archived.ID {
+			assert.Nil(t, delivery.Notification)
+		} else {
+			require.NotNil(t, delivery.Notification)
+			assert.Equal(t, notification.ID, delivery.Notification.ID)
+		}
+	}
+
+	// Leased deliveries are not claimed again
+	claimed, err = repo.ClaimDue(ctx, now, now.Add(5*time.Minute), 10)
+	require.NoError(t, err)
+	assert.Empty(t, claimed)
+}
+
+func TestNotificationDeliveryRepository_UpdateAndDeleteFinished(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewNotificationDeliveryRepository(db)
+	ctx := context.Background()
+
+	now := time.Date(2001, 1, 1, 12, 0, 0, 0, time.UTC)
+	userID := createTestUser(t, db)
+	notification := createTestNotification(t, db, userID)
+	require.NoError(t, repo.Create(ctx, []models.NotificationDelivery{
+		{NotificationID: notification.ID, UserID: userID, Channel: models.NotificationChannelEmail, Status: models.NotificationDeliveryPending, NextAttemptAt: now},
+		{NotificationID: notification.ID, UserID: userID, Channel: models.NotificationChannelPush, Status: models.NotificationDeliveryPending, NextAttemptAt: now},
+	}))
+
+	claimed, err := repo.ClaimDue(ctx, now, now.Add(5*time.Minute), 10)
+	require.NoError(t, err)
+	require.Len(t, claimed, 2)
+
+	sent := claimed[0]
+	sent.Status = models.NotificationDeliverySent
+	sent.Attempts = 1
+	sent.SentAt = &now
+	require.NoError(t, repo.Update(ctx, &sent))
+
+	var stored models.NotificationDelivery
+	require.NoError(t, db.First(&stored, "id = ?", sent.ID).Error)
+	assert.Equal(t, models.NotificationDeliverySent, stored.Status)
+	assert.Equal(t, 1, stored.Attempts)
+	require.NotNil(t, stored.SentAt)
+
+	// Only finished deliveries are removed
+	deleted, err := repo.DeleteFinished(ctx, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, deleted, int64(1))
+
+	var remaining int64
+	db.Model(&models.NotificationDelivery{}).Where("notification_id = ?", notification.ID).Count(&remaining)
+	assert.Equal(t, int64(1), remaining)
+}
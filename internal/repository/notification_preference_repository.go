@@ -0,0 +1,19 @@
+// Package repository contains data access interfaces and implementations.
+package repository
+
+import (
+	"context"
+	"savvy/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// NotificationPreferenceRepository defines the interface for notification channel preferences.
+type NotificationPreferenceRepository interface {
+	// GetByUserID retrieves the preferences a user changed. Types without a
+	// row use models.DefaultNotificationPreference.
+	GetByUserID(ctx context.Context, userID uuid.UUID) ([]models.NotificationPreference, error)
+
+	// Save creates or replaces the preference of a user for a type.
+	Save(ctx context.Context, preference *models.NotificationPreference) error
+}
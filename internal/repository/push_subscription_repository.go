@@ -0,0 +1,29 @@
+// Package repository contains data access interfaces and implementations.
+package repository
+
+import (
+	"context"
+	"savvy/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PushSubscriptionRepository defines the interface for Web Push subscription data access.
+type PushSubscriptionRepository interface {
+	// Save stores a subscription. A known endpoint gets the new keys and
+	// moves to the user, e.g. when someone else signs in on the browser.
+	Save(ctx context.Context, subscription *models.PushSubscription) error
+
+	// GetByUserID retrieves the subscriptions of a user, oldest first.
+	GetByUserID(ctx context.Context, userID uuid.UUID) ([]models.PushSubscription, error)
+
+	// MarkUsed records a successful push to a subscription.
+	MarkUsed(ctx context.Context, id uuid.UUID, at time.Time) error
+
+	// Delete removes a subscription, e.g. after the push service reported it as expired.
+	Delete(ctx context.Context, id uuid.UUID) error
+
+	// DeleteByEndpoint removes the subscription of a user with the endpoint.
+	DeleteByEndpoint(ctx context.Context, userID uuid.UUID, endpoint string) error
+}
@@ -0,0 +1,56 @@
+// Package repository contains data access implementations.
+package repository
+
+import (
+	"context"
+	"savvy/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GormPushSubscriptionRepository is a GORM implementation of PushSubscriptionRepository.
+type GormPushSubscriptionRepository struct {
+	db *gorm.DB
+}
+
+// NewPushSubscriptionRepository creates a new push subscription repository.
+func NewPushSubscriptionRepository(db *gorm.DB) PushSubscriptionRepository {
+	return &GormPushSubscriptionRepository{db: db}
+}
+
+// Save upserts on the endpoint, which is unique per browser profile.
+func (r *GormPushSubscriptionRepository) Save(ctx context.Context, subscription *models.PushSubscription) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "endpoint"}},
+		DoUpdates: clause.AssignmentColumns([]string{"user_id", "p256dh", "auth", "user_agent"}),
+	}).Create(subscription).Error
+}
+
+func (r *GormPushSubscriptionRepository) GetByUserID(ctx context.Context, userID uuid.UUID) ([]models.PushSubscription, error) {
+	var subscriptions []models.PushSubscription
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at ASC").
+		Find(&subscriptions).Error
+	return subscriptions, err
+}
+
+func (r *GormPushSubscriptionRepository) MarkUsed(ctx context.Context, id uuid.UUID, at time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&models.PushSubscription{}).
+		Where("id = ?", id).
+		Update("last_used_at", at).Error
+}
+
+func (r *GormPushSubscriptionRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Delete(&models.PushSubscription{}, "id = ?", id).Error
+}
+
+func (r *GormPushSubscriptionRepository) DeleteByEndpoint(ctx context.Context, userID uuid.UUID, endpoint string) error {
+	return r.db.WithContext(ctx).
+		Where("user_id = ? AND endpoint = ?", userID, endpoint).
+		Delete(&models.PushSubscription{}).Error
+}
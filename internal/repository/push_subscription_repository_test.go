@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"savvy/internal/models"
+)
+
+func TestPushSubscriptionRepository_Save(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewPushSubscriptionRepository(db)
+	ctx := context.Background()
+
+	userID := createTestUser(t, db)
+	otherUserID := createTestUser(t, db)
+	endpoint := "https://push.example.com/send/" + uuid.NewString()
+	t.Cleanup(func() {
+		db.Exec("DELETE FROM push_subscriptions WHERE endpoint = ?", endpoint)
+	})
+
+	require.NoError(t, repo.Save(ctx, &models.PushSubscription{UserID: userID, Endpoint: endpoint, P256dh: "key-1", Auth: "auth-1"}))
+
+	// The browser subscribes again after someone else signed in
+	require.NoError(t, repo.Save(ctx, &models.PushSubscription{UserID: otherUserID, Endpoint: endpoint, P256dh: "key-2", Auth: "auth-2"}))
+
+	subscriptions, err := repo.GetByUserID(ctx, userID)
+	require.NoError(t, err)
+	assert.Empty(t, subscriptions)
+
+	subscriptions, err = repo.GetByUserID(ctx, otherUserID)
+	require.NoError(t, err)
+	require.Len(t, subscriptions, 1)
+	assert.Equal(t, "key-2", subscriptions[0].P256dh)
+	assert.Equal(t, "auth-2", subscriptions[0].Auth)
+
+	require.NoError(t, repo.MarkUsed(ctx, subscriptions[0].ID, time.Now()))
+	subscriptions, err = repo.GetByUserID(ctx, otherUserID)
+	require.NoError(t, err)
+	assert.NotNil(t, subscriptions[0].LastUsedAt)
+}
+
+func TestPushSubscriptionRepository_DeleteByEndpoint(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewPushSubscriptionRepository(db)
+	ctx := context.Background()
+
+	userID := createTestUser(t, db)
+	otherUserID := createTestUser(t, db)
+	endpoint := "https://push.example.com/send/" + uuid.NewString()
+	t.Cleanup(func() {
+		db.Exec("DELETE FROM push_subscriptions WHERE endpoint = ?", endpoint)
+	})
+	require.NoError(t, repo.Save(ctx, &models.PushSubscription{UserID: userID, Endpoint: endpoint, P256dh: "key", Auth: "auth"}))
+
+	// Only the owner can remove it
+	require.NoError(t, repo.DeleteByEndpoint(ctx, otherUserID, endpoint))
+	subscriptions, err := repo.GetByUserID(ctx, userID)
+	require.NoError(t, err)
+	assert.Len(t, subscriptions, 1)
+
+	require.NoError(t, repo.DeleteByEndpoint(ctx, userID, endpoint))
+	subscriptions, err = repo.GetByUserID(ctx, userID)
+	require.NoError(t, err)
+	assert.Empty(t, subscriptions)
+}
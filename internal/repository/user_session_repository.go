@@ -0,0 +1,40 @@
+// Package repository contains data access interfaces and implementations.
+package repository
+
+import (
+	"context"
+	"savvy/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserSessionRepository defines the interface for server-side session data access.
+type UserSessionRepository interface {
+	// GetByHash retrieves a session by the SHA-256 hash of its ID.
+	GetByHash(ctx context.Context, tokenHash string) (*models.UserSession, error)
+
+	// Save creates the session or updates its values, owner and client.
+	Save(ctx context.Context, session *models.UserSession) error
+
+	// DeleteByHash removes a session, e.g. on logout.
+	DeleteByHash(ctx context.Context, tokenHash string) error
+
+	// Touch records a request of the session, at most once per interval.
+	Touch(ctx context.Context, tokenHash, ipAddress string, at time.Time, interval time.Duration) error
+
+	// GetActiveByUserID retrieves the unexpired sessions of a user, most recently used first.
+	GetActiveByUserID(ctx context.Context, userID uuid.UUID) ([]models.UserSession, error)
+
+	// Delete removes a session of a user.
+	Delete(ctx context.Context, id, userID uuid.UUID) error
+
+	// DeleteByUserID removes all sessions of a user and returns how many there were.
+	DeleteByUserID(ctx context.Context, userID uuid.UUID) (int64, error)
+
+	// CountActive counts unexpired sessions with a logged-in user.
+	CountActive(ctx context.Context) (int64, error)
+
+	// DeleteExpired removes expired sessions and returns how many there were.
+	DeleteExpired(ctx context.Context) (int64, error)
+}
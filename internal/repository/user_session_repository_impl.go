@@ -0,0 +1,94 @@
+// Package repository contains data access implementations.
+package repository
+
+import (
+	"context"
+	"savvy/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GormUserSessionRepository is a GORM implementation of UserSessionRepository.
+type GormUserSessionRepository struct {
+	db *gorm.DB
+}
+
+// NewUserSessionRepository creates a new session repository.
+func NewUserSessionRepository(db *gorm.DB) UserSessionRepository {
+	return &GormUserSessionRepository{db: db}
+}
+
+func (r *GormUserSessionRepository) GetByHash(ctx context.Context, tokenHash string) (*models.UserSession, error) {
+	var session models.UserSession
+	err := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&session).Error
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// Save upserts on the token hash and keeps the creation time of existing sessions.
+func (r *GormUserSessionRepository) Save(ctx context.Context, session *models.UserSession) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "token_hash"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"user_id", "data", "device", "user_agent", "ip_address", "last_seen_at", "expires_at",
+		}),
+	}).Create(session).Error
+}
+
+func (r *GormUserSessionRepository) DeleteByHash(ctx context.Context, tokenHash string) error {
+	return r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).Delete(&models.UserSession{}).Error
+}
+
+// Touch only writes if the last recorded request is older than the interval,
+// so busy sessions cost one UPDATE per interval at most.
+func (r *GormUserSessionRepository) Touch(ctx context.Context, tokenHash, ipAddress string, at time.Time, interval time.Duration) error {
+	return r.db.WithContext(ctx).
+		Model(&models.UserSession{}).
+		Where("token_hash = ? AND last_seen_at < ?", tokenHash, at.Add(-interval)).
+		UpdateColumns(map[string]interface{}{"last_seen_at": at, "ip_address": ipAddress}).Error
+}
+
+func (r *GormUserSessionRepository) GetActiveByUserID(ctx context.Context, userID uuid.UUID) ([]models.UserSession, error) {
+	var sessions []models.UserSession
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND expires_at > ?", userID, time.Now()).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+// Delete returns gorm.ErrRecordNotFound if the session does not belong to the user.
+func (r *GormUserSessionRepository) Delete(ctx context.Context, id, userID uuid.UUID) error {
+	result := r.db.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID).Delete(&models.UserSession{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *GormUserSessionRepository) DeleteByUserID(ctx context.Context, userID uuid.UUID) (int64, error) {
+	result := r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&models.UserSession{})
+	return result.RowsAffected, result.Error
+}
+
+func (r *GormUserSessionRepository) CountActive(ctx context.Context) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&models.UserSession{}).
+		Where("user_id IS NOT NULL AND expires_at > ?", time.Now()).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *GormUserSessionRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	result := r.db.WithContext(ctx).Where("expires_at <= ?", time.Now()).Delete(&models.UserSession{})
+	return result.RowsAffected, result.Error
+}
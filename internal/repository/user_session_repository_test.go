@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"savvy/internal/models"
+)
+
+func createTestUserSession(t *testing.T, db *gorm.DB, userID *uuid.UUID, expiresAt time.Time) *models.UserSession {
+	t.Helper()
+	session := &models.UserSession{
+		TokenHash:  uuid.NewString() + uuid.NewString()[:28],
+		UserID:     userID,
+		Data:       "signed",
+		LastSeenAt: time.Now().Add(-time.Hour),
+		ExpiresAt:  expiresAt,
+	}
+	db.Create(session)
+	t.Cleanup(func() {
+		db.Exec("DELETE FROM user_sessions WHERE id = ?", session.ID)
+	})
+	return session
+}
+
+func TestUserSessionRepository_Save(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewUserSessionRepository(db)
+	ctx := context.Background()
+
+	session := createTestUserSession(t, db, nil, time.Now().Add(time.Hour))
+
+	// Logging in updates the existing row
+	userID := createTestUser(t, db)
+	err := repo.Save(ctx, &models.UserSession{
+		TokenHash:  session.TokenHash,
+		UserID:     &userID,
+		Data:       "logged-in",
+		LastSeenAt: time.Now(),
+		ExpiresAt:  time.Now().Add(2 * time.Hour),
+	})
+	assert.NoError(t, err)
+
+	found, err := repo.GetByHash(ctx, session.TokenHash)
+	assert.NoError(t, err)
+	assert.Equal(t, session.ID, found.ID)
+	assert.Equal(t, "logged-in", found.Data)
+	if assert.NotNil(t, found.UserID) {
+		assert.Equal(t, userID, *found.UserID)
+	}
+}
+
+func TestUserSessionRepository_Touch(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewUserSessionRepository(db)
+	ctx := context.Background()
+
+	session := createTestUserSession(t, db, nil, time.Now().Add(time.Hour))
+
+	now := time.Now().UTC().Truncate(time.Second)
+	assert.NoError(t, repo.Touch(ctx, session.TokenHash, "192.0.2.1", now, time.Minute))
+
+	// Within the interval nothing is written
+	assert.NoError(t, repo.Touch(ctx, session.TokenHash, "192.0.2.2", now.Add(time.Second), time.Minute))
+
+	found, err := repo.GetByHash(ctx, session.TokenHash)
+	assert.NoError(t, err)
+	assert.True(t, now.Equal(found.LastSeenAt.UTC()))
+	assert.Equal(t, "192.0.2.1", found.IPAddress)
+}
+
+func TestUserSessionRepository_Delete(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewUserSessionRepository(db)
+	ctx := context.Background()
+
+	userID := createTestUser(t, db)
+	session := createTestUserSession(t, db, &userID, time.Now().Add(time.Hour))
+	createTestUserSession(t, db, &userID, time.Now().Add(-time.Hour))
+
+	sessions, err := repo.GetActiveByUserID(ctx, userID)
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 1)
+
+	// Other users cannot log out the session
+	err = repo.Delete(ctx, session.ID, uuid.New())
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	err = repo.Delete(ctx, session.ID, userID)
+	assert.NoError(t, err)
+
+	_, err = repo.GetByHash(ctx, session.TokenHash)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestUserSessionRepository_DeleteExpired(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewUserSessionRepository(db)
+	ctx := context.Background()
+
+	userID := createTestUser(t, db)
+	active := createTestUserSession(t, db, &userID, time.Now().Add(time.Hour))
+	expired := createTestUserSession(t, db, &userID, time.Now().Add(-time.Hour))
+
+	deleted, err := repo.DeleteExpired(ctx)
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, deleted, int64(1))
+
+	_, err = repo.GetByHash(ctx, expired.TokenHash)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	_, err = repo.GetByHash(ctx, active.TokenHash)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,31 @@
+// Package repository contains data access interfaces and implementations.
+package repository
+
+import (
+	"context"
+	"savvy/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// WebAuthnCredentialRepository defines the interface for passkey data access.
+type WebAuthnCredentialRepository interface {
+	// Create stores a newly registered credential.
+	Create(ctx context.Context, credential *models.WebAuthnCredential) error
+
+	// GetByUserID retrieves the credentials of a user, oldest first.
+	GetByUserID(ctx context.Context, userID uuid.UUID) ([]models.WebAuthnCredential, error)
+
+	// CountByUserID counts the credentials of a user.
+	CountByUserID(ctx context.Context, userID uuid.UUID) (int64, error)
+
+	// UpdateAfterLogin stores the signature counter and backup state of a
+	// successful login and records the time of use.
+	UpdateAfterLogin(ctx context.Context, credential *models.WebAuthnCredential) error
+
+	// Rename changes the name of a credential of a user.
+	Rename(ctx context.Context, id, userID uuid.UUID, name string) error
+
+	// Delete removes a credential of a user.
+	Delete(ctx context.Context, id, userID uuid.UUID) error
+}
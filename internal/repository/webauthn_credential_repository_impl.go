@@ -0,0 +1,79 @@
+// Package repository contains data access implementations.
+package repository
+
+import (
+	"context"
+	"savvy/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// GormWebAuthnCredentialRepository is a GORM implementation of WebAuthnCredentialRepository.
+type GormWebAuthnCredentialRepository struct {
+	db *gorm.DB
+}
+
+// NewWebAuthnCredentialRepository creates a new passkey repository.
+func NewWebAuthnCredentialRepository(db *gorm.DB) WebAuthnCredentialRepository {
+	return &GormWebAuthnCredentialRepository{db: db}
+}
+
+func (r *GormWebAuthnCredentialRepository) Create(ctx context.Context, credential *models.WebAuthnCredential) error {
+	return r.db.WithContext(ctx).Create(credential).Error
+}
+
+func (r *GormWebAuthnCredentialRepository) GetByUserID(ctx context.Context, userID uuid.UUID) ([]models.WebAuthnCredential, error) {
+	var credentials []models.WebAuthnCredential
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at ASC").
+		Find(&credentials).Error
+	return credentials, err
+}
+
+func (r *GormWebAuthnCredentialRepository) CountByUserID(ctx context.Context, userID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.WebAuthnCredential{}).
+		Where("user_id = ?", userID).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *GormWebAuthnCredentialRepository) UpdateAfterLogin(ctx context.Context, credential *models.WebAuthnCredential) error {
+	return r.db.WithContext(ctx).Model(&models.WebAuthnCredential{}).
+		Where("id = ?", credential.ID).
+		Updates(map[string]interface{}{
+			"sign_count":   credential.SignCount,
+			"backup_state": credential.BackupState,
+			"last_used_at": credential.LastUsedAt,
+		}).Error
+}
+
+// Rename returns gorm.ErrRecordNotFound if the credential does not belong to the user.
+func (r *GormWebAuthnCredentialRepository) Rename(ctx context.Context, id, userID uuid.UUID, name string) error {
+	result := r.db.WithContext(ctx).Model(&models.WebAuthnCredential{}).
+		Where("id = ? AND user_id = ?", id, userID).
+		Update("name", name)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// Delete returns gorm.ErrRecordNotFound if the credential does not belong to the user.
+func (r *GormWebAuthnCredentialRepository) Delete(ctx context.Context, id, userID uuid.UUID) error {
+	result := r.db.WithContext(ctx).
+		Where("id = ? AND user_id = ?", id, userID).
+		Delete(&models.WebAuthnCredential{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"savvy/internal/models"
+)
+
+func createTestWebAuthnCredential(t *testing.T, db *gorm.DB, userID uuid.UUID, name string) *models.WebAuthnCredential {
+	t.Helper()
+	credential := &models.WebAuthnCredential{
+		UserID:       userID,
+		Name:         name,
+		CredentialID: []byte(uuid.NewString()),
+		PublicKey:    []byte("public-key"),
+	}
+	require.NoError(t, db.Create(credential).Error)
+	t.Cleanup(func() {
+		db.Exec("DELETE FROM webauthn_credentials WHERE id = ?", credential.ID)
+	})
+	return credential
+}
+
+func TestWebAuthnCredentialRepository_GetByUserID(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewWebAuthnCredentialRepository(db)
+	ctx := context.Background()
+
+	userID := createTestUser(t, db)
+	otherUserID := createTestUser(t, db)
+	first := createTestWebAuthnCredential(t, db, userID, "iPhone")
+	second := createTestWebAuthnCredential(t, db, userID, "YubiKey")
+	createTestWebAuthnCredential(t, db, otherUserID, "Laptop")
+
+	credentials, err := repo.GetByUserID(ctx, userID)
+	assert.NoError(t, err)
+	if assert.Len(t, credentials, 2) {
+		assert.Equal(t, first.ID, credentials[0].ID)
+		assert.Equal(t, second.ID, credentials[1].ID)
+	}
+
+	count, err := repo.CountByUserID(ctx, userID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+}
+
+func TestWebAuthnCredentialRepository_UpdateAfterLogin(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewWebAuthnCredentialRepository(db)
+	ctx := context.Background()
+
+	credential := createTestWebAuthnCredential(t, db, createTestUser(t, db), "iPhone")
+
+	usedAt := time.Now().Truncate(time.Second)
+	credential.SignCount = 7
+	credential.BackupState = true
+	credential.LastUsedAt = &usedAt
+	credential.Name = "ignored"
+	assert.NoError(t, repo.UpdateAfterLogin(ctx, credential))
+
+	var found models.WebAuthnCredential
+	require.NoError(t, db.First(&found, "id = ?", credential.ID).Error)
+	assert.Equal(t, int64(7), found.SignCount)
+	assert.True(t, found.BackupState)
+	assert.Equal(t, "iPhone", found.Name)
+	if assert.NotNil(t, found.LastUsedAt) {
+		assert.WithinDuration(t, usedAt, *found.LastUsedAt, time.Second)
+	}
+}
+
+func TestWebAuthnCredentialRepository_RenameAndDelete(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewWebAuthnCredentialRepository(db)
+	ctx := context.Background()
+
+	userID := createTestUser(t, db)
+	otherUserID := createTestUser(t, db)
+	credential := createTestWebAuthnCredential(t, db, userID, "iPhone")
+
+	// Other users cannot change the credential
+	assert.ErrorIs(t, repo.Rename(ctx, credential.ID, otherUserID, "Mine"), gorm.ErrRecordNotFound)
+	assert.ErrorIs(t, repo.Delete(ctx, credential.ID, otherUserID), gorm.ErrRecordNotFound)
+
+	assert.NoError(t, repo.Rename(ctx, credential.ID, userID, "Work phone"))
+	credentials, err := repo.GetByUserID(ctx, userID)
+	assert.NoError(t, err)
+	if assert.Len(t, credentials, 1) {
+		assert.Equal(t, "Work phone", credentials[0].Name)
+	}
+
+	assert.NoError(t, repo.Delete(ctx, credential.ID, userID))
+	count, err := repo.CountByUserID(ctx, userID)
+	assert.NoError(t, err)
+	assert.Zero(t, count)
+}
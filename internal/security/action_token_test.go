@@ -0,0 +1,63 @@
+package security
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestActionToken(t *testing.T) {
+	Init("test-secret-key-for-action-tokens")
+	userID := uuid.New()
+
+	t.Run("Valid token for the same state", func(t *testing.T) {
+		token, err := GenerateActionToken(PurposePasswordReset, userID, "$2a$10$hash", time.Hour)
+		require.NoError(t, err)
+
+		claims, err := ValidateActionToken(token, PurposePasswordReset)
+		require.NoError(t, err)
+		assert.Equal(t, userID, claims.UserID)
+		assert.True(t, claims.MatchesState("$2a$10$hash"))
+		assert.False(t, claims.MatchesState("$2a$10$changed"), "Token must stop working after the state changed")
+		assert.NotContains(t, token, "hash", "State must not be readable from the token")
+	})
+
+	t.Run("Token of another purpose is rejected", func(t *testing.T) {
+		token, err := GenerateActionToken(PurposeVerifyEmail, userID, "anna@example.com", time.Hour)
+		require.NoError(t, err)
+
+		_, err = ValidateActionToken(token, PurposePasswordReset)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("Expired token is rejected", func(t *testing.T) {
+		token, err := GenerateActionToken(PurposeVerifyEmail, userID, "anna@example.com", -time.Minute)
+		require.NoError(t, err)
+
+		_, err = ValidateActionToken(token, PurposeVerifyEmail)
+		assert.ErrorIs(t, err, ErrTokenExpired)
+	})
+
+	t.Run("Tampered token is rejected", func(t *testing.T) {
+		token, err := GenerateActionToken(PurposeVerifyEmail, userID, "anna@example.com", time.Hour)
+		require.NoError(t, err)
+
+		other, err := GenerateActionToken(PurposeVerifyEmail, uuid.New(), "anna@example.com", time.Hour)
+		require.NoError(t, err)
+
+		// Claims of one token with the signature of another
+		claims, _, _ := strings.Cut(token, ".")
+		_, signature, _ := strings.Cut(other, ".")
+		_, err = ValidateActionToken(claims+"."+signature, PurposeVerifyEmail)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+
+		for _, invalid := range []string{"", "no-dot", "abc.", ".abc", "abc.!!!"} {
+			_, err = ValidateActionToken(invalid, PurposeVerifyEmail)
+			assert.ErrorIs(t, err, ErrInvalidToken, invalid)
+		}
+	})
+}
@@ -0,0 +1,262 @@
+// Package services contains business logic.
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"savvy/internal/mail"
+	"savvy/internal/models"
+	"savvy/internal/repository"
+	"savvy/internal/security"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const (
+	// passwordResetValidity is how long a password reset link can be used
+	passwordResetValidity = time.Hour
+	// emailVerificationValidity is how long an email verification link can be used
+	emailVerificationValidity = 48 * time.Hour
+)
+
+var (
+	// ErrMailDisabled indicates that no mail sender is configured
+	ErrMailDisabled = errors.New("mail is not configured")
+	// ErrInvalidEmailLink indicates a tampered link or one that was already used
+	ErrInvalidEmailLink = errors.New("invalid email link")
+	// ErrEmailLinkExpired indicates a link that is older than its validity
+	ErrEmailLinkExpired = errors.New("email link has expired")
+)
+
+// AccountEmailServiceInterface defines the interface for the emails sent to
+// confirm the address of an account and to reset a forgotten password.
+type AccountEmailServiceInterface interface {
+	// UseSender enables the emails. Links in them point to baseURL.
+	UseSender(sender mail.Sender, baseURL string)
+	// Enabled reports whether a sender is configured.
+	Enabled() bool
+
+	// SendVerification emails a link that confirms the address of the user.
+	SendVerification(ctx context.Context, user *models.User) error
+	// VerifyEmail marks the address of the link's user as verified.
+	VerifyEmail(ctx context.Context, token string) (*models.User, error)
+
+	// RequestPasswordReset emails a reset link if a local account with the
+	// address exists. Unknown addresses are not reported.
+	RequestPasswordReset(ctx context.Context, email string) error
+	// CheckPasswordReset returns the user of a valid reset link.
+	CheckPasswordReset(ctx context.Context, token string) (*models.User, error)
+	// ResetPassword sets the new password and logs out all sessions of the user.
+	ResetPassword(ctx context.Context, token, password string) (*models.User, error)
+
+	// SendLockoutNotice tells the user that logins are blocked for the given
+	// duration after repeated failed attempts.
+	SendLockoutNotice(ctx context.Context, user *models.User, duration time.Duration) error
+}
+
+// AccountEmailService implements AccountEmailServiceInterface.
+type AccountEmailService struct {
+	userRepo       repository.UserRepository
+	sessionService SessionServiceInterface
+
+	mu      sync.RWMutex
+	sender  mail.Sender
+	baseURL string
+}
+
+// NewAccountEmailService creates a new account email service. Emails are
+// disabled until UseSender is called.
+func NewAccountEmailService(userRepo repository.UserRepository, sessionService SessionServiceInterface) AccountEmailServiceInterface {
+	return &AccountEmailService{userRepo: userRepo, sessionService: sessionService}
+}
+
+// UseSender enables the emails.
+func (s *AccountEmailService) UseSender(sender mail.Sender, baseURL string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sender = sender
+	s.baseURL = strings.TrimRight(baseURL, "/")
+}
+
+// Enabled reports whether a sender is configured.
+func (s *AccountEmailService) Enabled() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.sender != nil
+}
+
+// SendVerification emails a verification link, valid until the address changes.
+func (s *AccountEmailService) SendVerification(ctx context.Context, user *models.User) error {
+	token, err := security.GenerateActionToken(security.PurposeVerifyEmail, user.ID, emailStamp(user), emailVerificationValidity)
+	if err != nil {
+		return err
+	}
+	return s.send(ctx, user, mail.TemplateVerifyEmail, "/auth/verify-email", token, emailVerificationValidity)
+}
+
+// VerifyEmail marks the address as verified. Verifying twice is not an error.
+func (s *AccountEmailService) VerifyEmail(ctx context.Context, token string) (*models.User, error) {
+	user, err := s.userForToken(ctx, token, security.PurposeVerifyEmail, emailStamp)
+	if err != nil {
+		return nil, err
+	}
+	if user.IsEmailVerified() {
+		return user, nil
+	}
+
+	now := time.Now()
+	user.EmailVerifiedAt = &now
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// RequestPasswordReset emails a reset link to a local account. OIDC accounts
+// have no password here and are skipped like unknown addresses.
+func (s *AccountEmailService) RequestPasswordReset(ctx context.Context, email string) error {
+	if !s.Enabled() {
+		return ErrMailDisabled
+	}
+
+	user, err := s.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if user.IsOAuthUser() {
+		return nil
+	}
+
+	token, err := security.GenerateActionToken(security.PurposePasswordReset, user.ID, passwordStamp(user), passwordResetValidity)
+	if err != nil {
+		return err
+	}
+	return s.send(ctx, user, mail.TemplatePasswordReset, "/auth/reset-password", token, passwordResetValidity)
+}
+
+// CheckPasswordReset returns the user of a valid reset link.
+func (s *AccountEmailService) CheckPasswordReset(ctx context.Context, token string) (*models.User, error) {
+	user, err := s.userForToken(ctx, token, security.PurposePasswordReset, passwordStamp)
+	if err != nil {
+		return nil, err
+	}
+	if user.IsOAuthUser() {
+		return nil, ErrInvalidEmailLink
+	}
+	return user, nil
+}
+
+// ResetPassword sets the new password. The link is bound to the old password
+// hash and cannot be used again. The caller validates the password strength.
+func (s *AccountEmailService) ResetPassword(ctx context.Context, token, password string) (*models.User, error) {
+	user, err := s.CheckPasswordReset(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+	user.PasswordHash = string(hash)
+
+	// The link arrived in the mailbox, which proves the address
+	if !user.IsEmailVerified() {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+	}
+
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+
+	// Someone else may know the old password
+	if _, err := s.sessionService.RevokeAllSessions(ctx, user.ID); err != nil {
+		return nil, fmt.Errorf("password changed, but sessions could not be revoked: %w", err)
+	}
+	return user, nil
+}
+
+// SendLockoutNotice links to the password reset, which also ends the lockout
+func (s *AccountEmailService) SendLockoutNotice(ctx context.Context, user *models.User, duration time.Duration) error {
+	s.mu.RLock()
+	sender, baseURL := s.sender, s.baseURL
+	s.mu.RUnlock()
+	if sender == nil {
+		return ErrMailDisabled
+	}
+
+	msg, err := mail.Render(ctx, user.Email, user.DisplayName(), mail.TemplateAccountLocked, map[string]any{
+		"Name":    user.FirstName,
+		"Link":    baseURL + "/auth/forgot-password",
+		"Minutes": int(duration.Round(time.Minute).Minutes()),
+	})
+	if err != nil {
+		return err
+	}
+	return sender.Send(ctx, msg)
+}
+
+// userForToken validates the token and loads its user. The token must have
+// been issued for the current state of the user, see stamp.
+func (s *AccountEmailService) userForToken(ctx context.Context, token, purpose string, stamp func(*models.User) string) (*models.User, error) {
+	claims, err := security.ValidateActionToken(token, purpose)
+	if err != nil {
+		if errors.Is(err, security.ErrTokenExpired) {
+			return nil, ErrEmailLinkExpired
+		}
+		return nil, ErrInvalidEmailLink
+	}
+
+	user, err := s.userRepo.GetByID(ctx, claims.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidEmailLink
+		}
+		return nil, err
+	}
+	if !claims.MatchesState(stamp(user)) {
+		return nil, ErrInvalidEmailLink
+	}
+	return user, nil
+}
+
+// send renders the template in the language of ctx and delivers it
+func (s *AccountEmailService) send(ctx context.Context, user *models.User, template, path, token string, validity time.Duration) error {
+	s.mu.RLock()
+	sender, baseURL := s.sender, s.baseURL
+	s.mu.RUnlock()
+	if sender == nil {
+		return ErrMailDisabled
+	}
+
+	msg, err := mail.Render(ctx, user.Email, user.DisplayName(), template, map[string]any{
+		"Name":  user.FirstName,
+		"Link":  baseURL + path + "?token=" + url.QueryEscape(token),
+		"Hours": int(validity.Hours()),
+	})
+	if err != nil {
+		return err
+	}
+	return sender.Send(ctx, msg)
+}
+
+// emailStamp binds verification links to the address they were sent to
+func emailStamp(user *models.User) string {
+	return strings.ToLower(user.Email)
+}
+
+// passwordStamp binds reset links to the password they replace, so a link
+// stops working once it was used or the password was changed otherwise
+func passwordStamp(user *models.User) string {
+	return user.PasswordHash
+}
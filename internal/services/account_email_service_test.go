@@ -0,0 +1,197 @@
+package services
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+	"savvy/internal/assets"
+	"savvy/internal/i18n"
+	"savvy/internal/mail"
+	"savvy/internal/models"
+	"savvy/internal/security"
+)
+
+var initMailI18n sync.Once
+
+// recordingSender keeps sent messages instead of delivering them
+type recordingSender struct {
+	messages []mail.Message
+}
+
+func (s *recordingSender) Send(_ context.Context, msg mail.Message) error {
+	s.messages = append(s.messages, msg)
+	return nil
+}
+
+// tokenFromLink extracts the token of the link in the last message
+func (s *recordingSender) tokenFromLink(t *testing.T, path string) string {
+	t.Helper()
+	require.NotEmpty(t, s.messages)
+	text := s.messages[len(s.messages)-1].Text
+	start := strings.Index(text, "https://savvy.example.com"+path+"?token=")
+	require.GreaterOrEqual(t, start, 0, "link to %s not found in %q", path, text)
+	link := strings.Fields(text[start:])[0]
+	parsed, err := url.Parse(link)
+	require.NoError(t, err)
+	return parsed.Query().Get("token")
+}
+
+// fakeSessionService counts revocations
+type fakeSessionService struct {
+	revoked []uuid.UUID
+}
+
+func (s *fakeSessionService) ListSessions(context.Context, uuid.UUID) ([]models.UserSession, error) {
+	return nil, nil
+}
+
+func (s *fakeSessionService) RevokeSession(context.Context, uuid.UUID, uuid.UUID) error { return nil }
+
+func (s *fakeSessionService) RevokeAllSessions(_ context.Context, userID uuid.UUID) (int64, error) {
+	s.revoked = append(s.revoked, userID)
+	return 1, nil
+}
+
+func setupAccountEmailService(t *testing.T) (*AccountEmailService, *fakeUserRepository, *recordingSender, *fakeSessionService) {
+	t.Helper()
+	initMailI18n.Do(func() {
+		require.NoError(t, i18n.Init(assets.Locales))
+	})
+	security.Init("test-secret-key-for-account-emails")
+
+	users := &fakeUserRepository{users: map[uuid.UUID]*models.User{}}
+	sessions := &fakeSessionService{}
+	sender := &recordingSender{}
+	service := NewAccountEmailService(users, sessions).(*AccountEmailService)
+	service.UseSender(sender, "https://savvy.example.com/")
+	return service, users, sender, sessions
+}
+
+func addLocalUser(t *testing.T, users *fakeUserRepository, email, password string) *models.User {
+	t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	require.NoError(t, err)
+	user := &models.User{ID: uuid.New(), Email: email, FirstName: "Anna", LastName: "Müller", PasswordHash: string(hash), AuthProvider: "local"}
+	users.users[user.ID] = user
+	return user
+}
+
+func TestAccountEmailService_Disabled(t *testing.T) {
+	service := NewAccountEmailService(&fakeUserRepository{}, &fakeSessionService{})
+	assert.False(t, service.Enabled())
+	assert.ErrorIs(t, service.RequestPasswordReset(context.Background(), "anna@example.com"), ErrMailDisabled)
+	assert.ErrorIs(t, service.SendVerification(context.Background(), &models.User{ID: uuid.New()}), ErrMailDisabled)
+	assert.ErrorIs(t, service.SendLockoutNotice(context.Background(), &models.User{ID: uuid.New()}, time.Minute), ErrMailDisabled)
+}
+
+func TestAccountEmailService_VerifyEmail(t *testing.T) {
+	service, users, sender, _ := setupAccountEmailService(t)
+	ctx := i18n.SetLocalizer(context.Background(), i18n.NewLocalizer("en"))
+	user := addLocalUser(t, users, "anna@example.com", "Secret-Password-1")
+
+	require.NoError(t, service.SendVerification(ctx, user))
+	require.Len(t, sender.messages, 1)
+	assert.Equal(t, "anna@example.com", sender.messages[0].To)
+	assert.Equal(t, "Confirm your email address", sender.messages[0].Subject)
+	token := sender.tokenFromLink(t, "/auth/verify-email")
+
+	verified, err := service.VerifyEmail(ctx, token)
+	require.NoError(t, err)
+	assert.True(t, verified.IsEmailVerified())
+	assert.True(t, users.users[user.ID].IsEmailVerified())
+
+	// Opening the link again is harmless
+	_, err = service.VerifyEmail(ctx, token)
+	assert.NoError(t, err)
+
+	// A link for a previous address does not verify the new one
+	require.NoError(t, service.SendVerification(ctx, user))
+	oldToken := sender.tokenFromLink(t, "/auth/verify-email")
+	users.users[user.ID].Email = "anna.new@example.com"
+	users.users[user.ID].EmailVerifiedAt = nil
+	_, err = service.VerifyEmail(ctx, oldToken)
+	assert.ErrorIs(t, err, ErrInvalidEmailLink)
+
+	_, err = service.VerifyEmail(ctx, "invalid")
+	assert.ErrorIs(t, err, ErrInvalidEmailLink)
+}
+
+func TestAccountEmailService_PasswordReset(t *testing.T) {
+	service, users, sender, sessions := setupAccountEmailService(t)
+	ctx := context.Background()
+	user := addLocalUser(t, users, "anna@example.com", "Old-Password-1")
+
+	require.NoError(t, service.RequestPasswordReset(ctx, "ANNA@example.com"))
+	require.Len(t, sender.messages, 1)
+	token := sender.tokenFromLink(t, "/auth/reset-password")
+
+	checked, err := service.CheckPasswordReset(ctx, token)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, checked.ID)
+
+	reset, err := service.ResetPassword(ctx, token, "New-Password-2")
+	require.NoError(t, err)
+	assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(users.users[user.ID].PasswordHash), []byte("New-Password-2")))
+	assert.True(t, reset.IsEmailVerified(), "Receiving the link proves the address")
+	assert.Equal(t, []uuid.UUID{user.ID}, sessions.revoked, "All sessions must be logged out")
+
+	// The link is bound to the old password and works only once
+	_, err = service.ResetPassword(ctx, token, "Another-Password-3")
+	assert.ErrorIs(t, err, ErrInvalidEmailLink)
+	assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(users.users[user.ID].PasswordHash), []byte("New-Password-2")))
+}
+
+func TestAccountEmailService_PasswordResetUnknownAccounts(t *testing.T) {
+	service, users, sender, _ := setupAccountEmailService(t)
+	ctx := context.Background()
+	oauthUser := addLocalUser(t, users, "oidc@example.com", "random")
+	oauthUser.AuthProvider = "oauth"
+
+	// Neither reveals whether the address exists
+	assert.NoError(t, service.RequestPasswordReset(ctx, "nobody@example.com"))
+	assert.NoError(t, service.RequestPasswordReset(ctx, "oidc@example.com"))
+	assert.Empty(t, sender.messages)
+
+	// A token forged for an OIDC account is rejected as well
+	token, err := security.GenerateActionToken(security.PurposePasswordReset, oauthUser.ID, oauthUser.PasswordHash, time.Hour)
+	require.NoError(t, err)
+	_, err = service.CheckPasswordReset(ctx, token)
+	assert.ErrorIs(t, err, ErrInvalidEmailLink)
+}
+
+func TestAccountEmailService_ExpiredLink(t *testing.T) {
+	service, users, _, _ := setupAccountEmailService(t)
+	user := addLocalUser(t, users, "anna@example.com", "Old-Password-1")
+
+	token, err := security.GenerateActionToken(security.PurposePasswordReset, user.ID, user.PasswordHash, -time.Minute)
+	require.NoError(t, err)
+	_, err = service.ResetPassword(context.Background(), token, "New-Password-2")
+	assert.ErrorIs(t, err, ErrEmailLinkExpired)
+
+	// A verification link cannot reset the password
+	token, err = security.GenerateActionToken(security.PurposeVerifyEmail, user.ID, user.Email, time.Hour)
+	require.NoError(t, err)
+	_, err = service.CheckPasswordReset(context.Background(), token)
+	assert.ErrorIs(t, err, ErrInvalidEmailLink)
+}
+
+func TestAccountEmailService_SendLockoutNotice(t *testing.T) {
+	service, users, sender, _ := setupAccountEmailService(t)
+	ctx := i18n.SetLocalizer(context.Background(), i18n.NewLocalizer("en"))
+	user := addLocalUser(t, users, "anna@example.com", "Secret-Password-1")
+
+	require.NoError(t, service.SendLockoutNotice(ctx, user, 15*time.Minute))
+	require.Len(t, sender.messages, 1)
+	assert.Equal(t, "anna@example.com", sender.messages[0].To)
+	assert.Contains(t, sender.messages[0].Text, "15 minutes")
+	assert.Contains(t, sender.messages[0].Text, "https://savvy.example.com/auth/forgot-password")
+	assert.NotContains(t, sender.messages[0].Text, "mail.account_locked.")
+}
@@ -216,7 +216,10 @@ func (s *AdminService) RestoreResource(ctx context.Context, resourceType string,
 
 	case "gift_card_transactions":
 		var transaction models.GiftCardTransaction
-		if err := s.db.WithContext(ctx).Unscoped().Where("id = ?", resourceID).First(&transaction).Error; err != nil {
+		// The gift card carries the currency of the amount, it may be deleted as well
+		if err := s.db.WithContext(ctx).Unscoped().
+			Preload("GiftCard", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+			Where("id = ?", resourceID).First(&transaction).Error; err != nil {
 			return err
 		}
 		if !transaction.DeletedAt.Valid {
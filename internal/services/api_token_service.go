@@ -0,0 +1,155 @@
+// Package services contains business logic.
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"savvy/internal/models"
+	"savvy/internal/repository"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// APITokenPrefix marks personal access tokens so they are recognizable in logs and secret scanners.
+const APITokenPrefix = "svy_"
+
+// apiTokenLastUsedInterval throttles last-used writes for busy tokens.
+const apiTokenLastUsedInterval = time.Minute
+
+var (
+	// ErrInvalidAPIToken indicates the token is unknown, malformed or revoked
+	ErrInvalidAPIToken = errors.New("invalid API token")
+	// ErrAPITokenExpired indicates the token has passed its expiry date
+	ErrAPITokenExpired = errors.New("API token has expired")
+	// ErrInvalidAPITokenScope indicates a scope outside of resource:read|write
+	ErrInvalidAPITokenScope = errors.New("invalid API token scope")
+)
+
+// APITokenServiceInterface defines the interface for personal API token business logic.
+type APITokenServiceInterface interface {
+	// CreateToken creates a token and returns its plaintext, which is not stored and cannot be shown again.
+	CreateToken(ctx context.Context, userID uuid.UUID, name string, scopes map[string]string, expiresAt *time.Time) (string, *models.APIToken, error)
+	// Authenticate resolves a plaintext token to the stored token including its user.
+	Authenticate(ctx context.Context, plaintext string) (*models.APIToken, error)
+	ListTokens(ctx context.Context, userID uuid.UUID) ([]models.APIToken, error)
+	RevokeToken(ctx context.Context, id, userID uuid.UUID) error
+}
+
+// APITokenService implements APITokenServiceInterface.
+type APITokenService struct {
+	repo repository.APITokenRepository
+}
+
+// NewAPITokenService creates a new API token service.
+func NewAPITokenService(repo repository.APITokenRepository) APITokenServiceInterface {
+	return &APITokenService{repo: repo}
+}
+
+// HashAPIToken returns the hex-encoded SHA-256 hash under which a token is stored.
+// Tokens carry 256 bits of entropy, so a fast unsalted hash is sufficient.
+func HashAPIToken(plaintext string) string {
+	sum := sha256.Sum256([]byte(plaintext))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateToken creates a personal access token.
+// scopes maps a resource (cards, vouchers, gift_cards) to "read" or "write";
+// resources with an empty level are not granted.
+func (s *APITokenService) CreateToken(ctx context.Context, userID uuid.UUID, name string, scopes map[string]string, expiresAt *time.Time) (string, *models.APIToken, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", nil, errors.New("name is required")
+	}
+
+	scopeList := make([]string, 0, len(scopes))
+	for _, resource := range models.APIScopeResources {
+		level := scopes[resource]
+		if level == "" {
+			continue
+		}
+		if level != models.APIAccessRead && level != models.APIAccessWrite {
+			return "", nil, ErrInvalidAPITokenScope
+		}
+		scopeList = append(scopeList, resource+":"+level)
+	}
+	for resource := range scopes {
+		if !slices.Contains(models.APIScopeResources, resource) {
+			return "", nil, ErrInvalidAPITokenScope
+		}
+	}
+	if len(scopeList) == 0 {
+		return "", nil, ErrInvalidAPITokenScope
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", nil, err
+	}
+	plaintext := APITokenPrefix + base64.RawURLEncoding.EncodeToString(raw)
+
+	token := &models.APIToken{
+		UserID:    userID,
+		Name:      name,
+		TokenHash: HashAPIToken(plaintext),
+		Prefix:    plaintext[:len(APITokenPrefix)+4],
+		Scopes:    strings.Join(scopeList, " "),
+		ExpiresAt: expiresAt,
+	}
+	if err := s.repo.Create(ctx, token); err != nil {
+		return "", nil, err
+	}
+
+	return plaintext, token, nil
+}
+
+// Authenticate looks up a token by its hash, rejects expired tokens and records usage.
+func (s *APITokenService) Authenticate(ctx context.Context, plaintext string) (*models.APIToken, error) {
+	if !strings.HasPrefix(plaintext, APITokenPrefix) {
+		return nil, ErrInvalidAPIToken
+	}
+
+	token, err := s.repo.GetByHash(ctx, HashAPIToken(plaintext))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidAPIToken
+		}
+		return nil, err
+	}
+	if token.User == nil {
+		return nil, ErrInvalidAPIToken
+	}
+	if token.IsExpired() {
+		return nil, ErrAPITokenExpired
+	}
+
+	now := time.Now()
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) >= apiTokenLastUsedInterval {
+		// Usage tracking must not fail the request
+		if err := s.repo.TouchLastUsed(ctx, token.ID, now); err != nil {
+			slog.Warn("Failed to update API token last use", "token_id", token.ID, "error", err)
+		} else {
+			token.LastUsedAt = &now
+		}
+	}
+
+	return token, nil
+}
+
+// ListTokens returns the active tokens of a user.
+func (s *APITokenService) ListTokens(ctx context.Context, userID uuid.UUID) ([]models.APIToken, error) {
+	return s.repo.GetByUserID(ctx, userID)
+}
+
+// RevokeToken revokes a token of the user.
+func (s *APITokenService) RevokeToken(ctx context.Context, id, userID uuid.UUID) error {
+	return s.repo.Revoke(ctx, id, userID)
+}
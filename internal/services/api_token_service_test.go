@@ -0,0 +1,169 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"savvy/internal/models"
+)
+
+// MockAPITokenRepository is a manual mock for APITokenRepository
+type MockAPITokenRepository struct {
+	mock.Mock
+}
+
+func (m *MockAPITokenRepository) Create(ctx context.Context, token *models.APIToken) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockAPITokenRepository) GetByHash(ctx context.Context, tokenHash string) (*models.APIToken, error) {
+	args := m.Called(ctx, tokenHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.APIToken), args.Error(1)
+}
+
+func (m *MockAPITokenRepository) GetByUserID(ctx context.Context, userID uuid.UUID) ([]models.APIToken, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.APIToken), args.Error(1)
+}
+
+func (m *MockAPITokenRepository) Revoke(ctx context.Context, id, userID uuid.UUID) error {
+	args := m.Called(ctx, id, userID)
+	return args.Error(0)
+}
+
+func (m *MockAPITokenRepository) TouchLastUsed(ctx context.Context, id uuid.UUID, at time.Time) error {
+	args := m.Called(ctx, id, at)
+	return args.Error(0)
+}
+
+func TestAPITokenService_CreateToken(t *testing.T) {
+	repo := new(MockAPITokenRepository)
+	service := NewAPITokenService(repo)
+	ctx := context.Background()
+	userID := uuid.New()
+
+	var stored *models.APIToken
+	repo.On("Create", ctx, mock.AnythingOfType("*models.APIToken")).
+		Run(func(args mock.Arguments) { stored = args.Get(1).(*models.APIToken) }).
+		Return(nil)
+
+	plaintext, token, err := service.CreateToken(ctx, userID, " CLI ", map[string]string{
+		models.APIScopeGiftCards: models.APIAccessWrite,
+		models.APIScopeCards:     models.APIAccessRead,
+		models.APIScopeVouchers:  "",
+	}, nil)
+
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(plaintext, APITokenPrefix))
+	assert.Same(t, stored, token)
+	assert.Equal(t, "CLI", token.Name)
+	assert.Equal(t, "cards:read gift_cards:write", token.Scopes)
+	assert.Equal(t, HashAPIToken(plaintext), token.TokenHash)
+	assert.NotContains(t, token.TokenHash, plaintext)
+	assert.True(t, strings.HasPrefix(plaintext, token.Prefix))
+	repo.AssertExpectations(t)
+}
+
+func TestAPITokenService_CreateToken_InvalidScopes(t *testing.T) {
+	repo := new(MockAPITokenRepository)
+	service := NewAPITokenService(repo)
+	ctx := context.Background()
+
+	tests := []map[string]string{
+		{},
+		{models.APIScopeCards: ""},
+		{models.APIScopeCards: "admin"},
+		{"users": models.APIAccessRead},
+	}
+	for _, scopes := range tests {
+		_, _, err := service.CreateToken(ctx, uuid.New(), "CLI", scopes, nil)
+		assert.ErrorIs(t, err, ErrInvalidAPITokenScope)
+	}
+	repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestAPITokenService_CreateToken_NameRequired(t *testing.T) {
+	service := NewAPITokenService(new(MockAPITokenRepository))
+
+	_, _, err := service.CreateToken(context.Background(), uuid.New(), "  ", map[string]string{models.APIScopeCards: models.APIAccessRead}, nil)
+
+	assert.Error(t, err)
+}
+
+func TestAPITokenService_Authenticate(t *testing.T) {
+	repo := new(MockAPITokenRepository)
+	service := NewAPITokenService(repo)
+	ctx := context.Background()
+
+	plaintext := APITokenPrefix + "secret"
+	stored := &models.APIToken{ID: uuid.New(), User: &models.User{ID: uuid.New()}}
+	repo.On("GetByHash", ctx, HashAPIToken(plaintext)).Return(stored, nil)
+	repo.On("TouchLastUsed", ctx, stored.ID, mock.AnythingOfType("time.Time")).Return(nil)
+
+	token, err := service.Authenticate(ctx, plaintext)
+
+	require.NoError(t, err)
+	assert.Equal(t, stored.ID, token.ID)
+	assert.NotNil(t, token.LastUsedAt)
+	repo.AssertExpectations(t)
+}
+
+func TestAPITokenService_Authenticate_ThrottlesLastUsed(t *testing.T) {
+	repo := new(MockAPITokenRepository)
+	service := NewAPITokenService(repo)
+	ctx := context.Background()
+
+	plaintext := APITokenPrefix + "secret"
+	recently := time.Now().Add(-10 * time.Second)
+	stored := &models.APIToken{ID: uuid.New(), User: &models.User{}, LastUsedAt: &recently}
+	repo.On("GetByHash", ctx, HashAPIToken(plaintext)).Return(stored, nil)
+
+	_, err := service.Authenticate(ctx, plaintext)
+
+	require.NoError(t, err)
+	repo.AssertNotCalled(t, "TouchLastUsed", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestAPITokenService_Authenticate_Expired(t *testing.T) {
+	repo := new(MockAPITokenRepository)
+	service := NewAPITokenService(repo)
+	ctx := context.Background()
+
+	plaintext := APITokenPrefix + "secret"
+	past := time.Now().Add(-time.Hour)
+	repo.On("GetByHash", ctx, HashAPIToken(plaintext)).Return(&models.APIToken{User: &models.User{}, ExpiresAt: &past}, nil)
+
+	_, err := service.Authenticate(ctx, plaintext)
+
+	assert.ErrorIs(t, err, ErrAPITokenExpired)
+}
+
+func TestAPITokenService_Authenticate_Invalid(t *testing.T) {
+	repo := new(MockAPITokenRepository)
+	service := NewAPITokenService(repo)
+	ctx := context.Background()
+
+	// Wrong prefix never reaches the database
+	_, err := service.Authenticate(ctx, "not-a-token")
+	assert.ErrorIs(t, err, ErrInvalidAPIToken)
+
+	plaintext := APITokenPrefix + "unknown"
+	repo.On("GetByHash", ctx, HashAPIToken(plaintext)).Return(nil, gorm.ErrRecordNotFound)
+
+	_, err = service.Authenticate(ctx, plaintext)
+	assert.ErrorIs(t, err, ErrInvalidAPIToken)
+}
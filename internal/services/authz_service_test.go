@@ -11,6 +11,7 @@ import (
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"savvy/internal/models"
+	"savvy/internal/money"
 )
 
 // setupTestDB creates a test database connection.
@@ -41,15 +42,18 @@ func setupTestDB(t *testing.T) *gorm.DB {
 		&models.GiftCard{},
 		&models.GiftCardShare{},
 		&models.GiftCardTransaction{},
+		&models.GiftCardTransactionRevision{},
+		&models.ExchangeRate{},
 		&models.UserFavorite{},
 		&models.AuditLog{},
+		&models.UserRecoveryCode{},
 	)
 	if err != nil {
 		t.Fatalf("Failed to migrate test database: %v", err)
 	}
 
 	// Clean up tables before each test
-	db.Exec("TRUNCATE users, merchants, cards, card_shares, vouchers, voucher_shares, gift_cards, gift_card_shares, gift_card_transactions, user_favorites, audit_logs CASCADE")
+	db.Exec("TRUNCATE users, merchants, cards, card_shares, vouchers, voucher_shares, gift_cards, gift_card_shares, gift_card_transactions, gift_card_transaction_revisions, exchange_rates, user_favorites, audit_logs, user_recovery_codes CASCADE")
 
 	return db
 }
@@ -209,8 +213,8 @@ func TestAuthzService_CheckGiftCardAccess_TransactionPermission(t *testing.T) {
 		UserID:         &owner.ID,
 		CardNumber:     "1234567890",
 		MerchantName:   "Test Merchant",
-		InitialBalance: 100.0,
-		CurrentBalance: 100.0,
+		InitialBalance: money.New(10000, "CHF"),
+		CurrentBalance: money.New(10000, "CHF"),
 	}
 	db.Create(giftCard)
 
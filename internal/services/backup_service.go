@@ -0,0 +1,617 @@
+// Package services contains business logic.
+package services
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"savvy/internal/backup"
+	"savvy/internal/database"
+	"savvy/internal/models"
+	"savvy/internal/money"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// BackupServiceInterface defines the interface for per-user account exports.
+type BackupServiceInterface interface {
+	// ExportUserData collects everything the user owns into an archive.
+	ExportUserData(ctx context.Context, userID uuid.UUID) (*backup.Archive, error)
+
+	// ImportUserData restores an archive into the account of the given user.
+	ImportUserData(ctx context.Context, userID uuid.UUID, archive *backup.Archive) (*BackupImportResult, error)
+}
+
+// BackupImportResult summarizes a restored archive.
+type BackupImportResult struct {
+	Cards        int
+	Vouchers     int
+	GiftCards    int
+	Transactions int
+	Favorites    int
+	Shares       int
+	Conflicts    []string // Items skipped because their number or code already exists
+	UnknownUsers []string // Share recipients without a verified account on this instance
+}
+
+// BackupService implements BackupServiceInterface.
+type BackupService struct {
+	db *gorm.DB
+}
+
+// NewBackupService creates a new backup service.
+func NewBackupService(db *gorm.DB) BackupServiceInterface {
+	return &BackupService{db: db}
+}
+
+// ExportUserData collects the user's owned cards, vouchers and gift cards
+// (including the full transaction history), their favorites on these items
+// and the shares they created. Items shared with the user are not included.
+func (s *BackupService) ExportUserData(ctx context.Context, userID uuid.UUID) (*backup.Archive, error) {
+	db := s.db.WithContext(ctx)
+
+	var user models.User
+	if err := db.First(&user, "id = ?", userID).Error; err != nil {
+		return nil, err
+	}
+
+	var cards []models.Card
+	if err := db.Where("user_id = ?", userID).Order("created_at ASC").Find(&cards).Error; err != nil {
+		return nil, err
+	}
+	var vouchers []models.Voucher
+	if err := db.Where("user_id = ?", userID).Order("created_at ASC").Find(&vouchers).Error; err != nil {
+		return nil, err
+	}
+	var giftCards []models.GiftCard
+	if err := db.Where("user_id = ?", userID).Order("created_at ASC").
+		Preload("Transactions", func(tx *gorm.DB) *gorm.DB {
+			return tx.Order("transaction_date ASC, created_at ASC")
+		}).
+		Preload("Transactions.CreatedByUser").
+		Find(&giftCards).Error; err != nil {
+		return nil, err
+	}
+
+	archive := &backup.Archive{
+		Version:    backup.FormatVersion,
+		ExportedAt: time.Now().UTC(),
+		User: backup.User{
+			Email:     user.Email,
+			FirstName: user.FirstName,
+			LastName:  user.LastName,
+		},
+		Merchants: []backup.Merchant{},
+		Cards:     make([]backup.Card, 0, len(cards)),
+		Vouchers:  make([]backup.Voucher, 0, len(vouchers)),
+		GiftCards: make([]backup.GiftCard, 0, len(giftCards)),
+		Favorites: []backup.Favorite{},
+		Shares:    []backup.Share{},
+	}
+
+	merchantIDs := make(map[uuid.UUID]bool)
+	addMerchant := func(id *uuid.UUID) {
+		if id != nil {
+			merchantIDs[*id] = true
+		}
+	}
+
+	cardIDs := make([]uuid.UUID, 0, len(cards))
+	for _, card := range cards {
+		addMerchant(card.MerchantID)
+		cardIDs = append(cardIDs, card.ID)
+		archive.Cards = append(archive.Cards, backup.Card{
+			ID:           card.ID,
+			MerchantID:   card.MerchantID,
+			MerchantName: card.MerchantName,
+			Program:      card.Program,
+			CardNumber:   card.CardNumber,
+			BarcodeType:  card.BarcodeType,
+			Status:       card.Status,
+			Notes:        card.Notes,
+			CreatedAt:    card.CreatedAt,
+		})
+	}
+
+	voucherIDs := make([]uuid.UUID, 0, len(vouchers))
+	for _, voucher := range vouchers {
+		addMerchant(voucher.MerchantID)
+		voucherIDs = append(voucherIDs, voucher.ID)
+		archive.Vouchers = append(archive.Vouchers, backup.Voucher{
+			ID:                voucher.ID,
+			MerchantID:        voucher.MerchantID,
+			MerchantName:      voucher.MerchantName,
+			Code:              voucher.Code,
+			Type:              voucher.Type,
+			Value:             voucher.ValueFloat(),
+			Description:       voucher.Description,
+			MinPurchaseAmount: voucher.MinPurchaseAmount.Float(),
+			ValidFrom:         voucher.ValidFrom,
+			ValidUntil:        voucher.ValidUntil,
+			UsageLimitType:    voucher.UsageLimitType,
+			BarcodeType:       voucher.BarcodeType,
+			CreatedAt:         voucher.CreatedAt,
+		})
+	}
+
+	giftCardIDs := make([]uuid.UUID, 0, len(giftCards))
+	for _, giftCard := range giftCards {
+		addMerchant(giftCard.MerchantID)
+		giftCardIDs = append(giftCardIDs, giftCard.ID)
+
+		transactions := make([]backup.Transaction, 0, len(giftCard.Transactions))
+		for _, t := range giftCard.Transactions {
+			createdBy := ""
+			if t.CreatedByUser != nil {
+				createdBy = t.CreatedByUser.Email
+			}
+			transactions = append(transactions, backup.Transaction{
+				Kind:            t.Kind,
+				Amount:          t.Amount.Float(),
+				Description:     t.Description,
+				TransactionDate: t.TransactionDate,
+				CreatedByEmail:  createdBy,
+				CreatedAt:       t.CreatedAt,
+			})
+		}
+
+		archive.GiftCards = append(archive.GiftCards, backup.GiftCard{
+			ID:             giftCard.ID,
+			MerchantID:     giftCard.MerchantID,
+			MerchantName:   giftCard.MerchantName,
+			CardNumber:     giftCard.CardNumber,
+			InitialBalance: giftCard.InitialBalance.Float(),
+			Currency:       giftCard.Currency,
+			PIN:            giftCard.PIN,
+			ExpiresAt:      giftCard.ExpiresAt,
+			Status:         giftCard.Status,
+			BarcodeType:    giftCard.BarcodeType,
+			Notes:          giftCard.Notes,
+			CreatedAt:      giftCard.CreatedAt,
+			Transactions:   transactions,
+		})
+	}
+
+	if len(merchantIDs) > 0 {
+		ids := make([]uuid.UUID, 0, len(merchantIDs))
+		for id := range merchantIDs {
+			ids = append(ids, id)
+		}
+		var merchants []models.Merchant
+		if err := db.Where("id IN ?", ids).Order("name ASC").Find(&merchants).Error; err != nil {
+			return nil, err
+		}
+		for _, m := range merchants {
+			archive.Merchants = append(archive.Merchants, backup.Merchant{
+				ID:      m.ID,
+				Name:    m.Name,
+				Website: m.Website,
+				LogoURL: m.LogoURL,
+				Color:   m.Color,
+			})
+		}
+	}
+
+	// Favorites on owned items only; favorites on shared items cannot be restored elsewhere
+	owned := map[string][]uuid.UUID{
+		backup.ResourceCard:     cardIDs,
+		backup.ResourceVoucher:  voucherIDs,
+		backup.ResourceGiftCard: giftCardIDs,
+	}
+	for _, resourceType := range []string{backup.ResourceCard, backup.ResourceVoucher, backup.ResourceGiftCard} {
+		ids := owned[resourceType]
+		if len(ids) == 0 {
+			continue
+		}
+		var favorites []models.UserFavorite
+		if err := db.Where("user_id = ? AND resource_type = ? AND resource_id IN ?", userID, resourceType, ids).
+			Order("created_at ASC").Find(&favorites).Error; err != nil {
+			return nil, err
+		}
+		for _, f := range favorites {
+			archive.Favorites = append(archive.Favorites, backup.Favorite{ResourceType: f.ResourceType, ResourceID: f.ResourceID})
+		}
+	}
+
+	if err := s.exportShares(db, archive, cardIDs, voucherIDs, giftCardIDs); err != nil {
+		return nil, err
+	}
+
+	return archive, nil
+}
+
+// exportShares adds the shares of the exported items
+func (s *BackupService) exportShares(db *gorm.DB, archive *backup.Archive, cardIDs, voucherIDs, giftCardIDs []uuid.UUID) error {
+	if len(cardIDs) > 0 {
+		var shares []models.CardShare
+		if err := db.Preload("SharedWithUser").Where("card_id IN ?", cardIDs).Order("created_at ASC").Find(&shares).Error; err != nil {
+			return err
+		}
+		for _, share := range shares {
+			if share.SharedWithUser == nil {
+				continue
+			}
+			archive.Shares = append(archive.Shares, backup.Share{
+				ResourceType:    backup.ResourceCard,
+				ResourceID:      share.CardID,
+				SharedWithEmail: share.SharedWithUser.Email,
+				CanEdit:         share.CanEdit,
+				CanDelete:       share.CanDelete,
+			})
+		}
+	}
+
+	if len(voucherIDs) > 0 {
+		var shares []models.VoucherShare
+		if err := db.Preload("SharedWithUser").Where("voucher_id IN ?", voucherIDs).Order("created_at ASC").Find(&shares).Error; err != nil {
+			return err
+		}
+		for _, share := range shares {
+			if share.SharedWithUser == nil {
+				continue
+			}
+			archive.Shares = append(archive.Shares, backup.Share{
+				ResourceType:    backup.ResourceVoucher,
+				ResourceID:      share.VoucherID,
+				SharedWithEmail: share.SharedWithUser.Email,
+				CanEdit:         share.CanEdit,
+				CanDelete:       share.CanDelete,
+			})
+		}
+	}
+
+	if len(giftCardIDs) > 0 {
+		var shares []models.GiftCardShare
+		if err := db.Preload("SharedWithUser").Where("gift_card_id IN ?", giftCardIDs).Order("created_at ASC").Find(&shares).Error; err != nil {
+			return err
+		}
+		for _, share := range shares {
+			if share.SharedWithUser == nil {
+				continue
+			}
+			archive.Shares = append(archive.Shares, backup.Share{
+				ResourceType:        backup.ResourceGiftCard,
+				ResourceID:          share.GiftCardID,
+				SharedWithEmail:     share.SharedWithUser.Email,
+				CanEdit:             share.CanEdit,
+				CanDelete:           share.CanDelete,
+				CanEditTransactions: share.CanEditTransactions,
+			})
+		}
+	}
+
+	return nil
+}
+
+// ImportUserData restores an archive into the account of the given user,
+// either on the instance it was exported from or on another one.
+//
+// All items get new IDs. Merchants are resolved by name; unknown merchants
+// are kept as free-text merchant name. Items whose card number or code
+// already exists are skipped and reported, as are shares with recipients
+// that have no account here. Everything else is restored in one transaction.
+func (s *BackupService) ImportUserData(ctx context.Context, userID uuid.UUID, archive *backup.Archive) (*BackupImportResult, error) {
+	result := &BackupImportResult{}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		imp := &backupImport{
+			tx:        tx,
+			userID:    userID,
+			archive:   archive,
+			result:    result,
+			merchants: make(map[uuid.UUID]*models.Merchant),
+			ids:       make(map[uuid.UUID]uuid.UUID),
+			users:     make(map[string]*uuid.UUID),
+			verified:  make(map[uuid.UUID]bool),
+		}
+		// Transactions of the exporting user belong to the importing user
+		imp.users[strings.ToLower(archive.User.Email)] = &userID
+
+		if err := imp.resolveMerchants(); err != nil {
+			return err
+		}
+		if err := imp.restoreCards(); err != nil {
+			return err
+		}
+		if err := imp.restoreVouchers(); err != nil {
+			return err
+		}
+		if err := imp.restoreGiftCards(); err != nil {
+			return err
+		}
+		if err := imp.restoreFavorites(); err != nil {
+			return err
+		}
+		return imp.restoreShares()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// backupImport holds the state of a running import
+type backupImport struct {
+	tx        *gorm.DB
+	userID    uuid.UUID
+	archive   *backup.Archive
+	result    *BackupImportResult
+	merchants map[uuid.UUID]*models.Merchant // Archive merchant ID → local merchant (nil if unknown)
+	ids       map[uuid.UUID]uuid.UUID        // Archive item ID → new item ID
+	users     map[string]*uuid.UUID          // Lowercase email → local user ID (nil if unknown)
+	verified  map[uuid.UUID]bool             // Local users that confirmed their email address
+}
+
+// resolveMerchants looks up the archive's merchants by name (case-insensitive)
+func (imp *backupImport) resolveMerchants() error {
+	for _, m := range imp.archive.Merchants {
+		var merchant models.Merchant
+		err := imp.tx.Where("LOWER(name) = LOWER(?)", m.Name).First(&merchant).Error
+		switch {
+		case err == nil:
+			imp.merchants[m.ID] = &merchant
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			imp.merchants[m.ID] = nil
+		default:
+			return err
+		}
+	}
+	return nil
+}
+
+// merchant maps an archive merchant reference to a local merchant ID and name
+func (imp *backupImport) merchant(id *uuid.UUID, fallbackName string) (*uuid.UUID, string) {
+	if id == nil {
+		return nil, fallbackName
+	}
+	if merchant := imp.merchants[*id]; merchant != nil {
+		return &merchant.ID, merchant.Name
+	}
+	// Unknown here: keep the exported merchant name as free text
+	for _, m := range imp.archive.Merchants {
+		if m.ID == *id && m.Name != "" {
+			return nil, m.Name
+		}
+	}
+	return nil, fallbackName
+}
+
+// user resolves an email to a local user ID, caching the result
+func (imp *backupImport) user(email string) (*uuid.UUID, error) {
+	key := strings.ToLower(strings.TrimSpace(email))
+	if key == "" {
+		return nil, nil
+	}
+	if id, ok := imp.users[key]; ok {
+		return id, nil
+	}
+
+	var user models.User
+	err := imp.tx.Where("email = ?", key).First(&user).Error
+	switch {
+	case err == nil:
+		imp.users[key] = &user.ID
+		imp.verified[user.ID] = user.IsEmailVerified()
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		imp.users[key] = nil
+	default:
+		return nil, err
+	}
+	return imp.users[key], nil
+}
+
+// create inserts a single item in a savepoint so that a unique constraint
+// violation skips the item instead of aborting the whole import
+func (imp *backupImport) create(conflict string, fn func(tx *gorm.DB) error) (bool, error) {
+	err := imp.tx.Transaction(fn)
+	if err != nil && database.IsDuplicateError(err) {
+		imp.result.Conflicts = append(imp.result.Conflicts, conflict)
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (imp *backupImport) restoreCards() error {
+	for _, c := range imp.archive.Cards {
+		merchantID, merchantName := imp.merchant(c.MerchantID, c.MerchantName)
+		card := models.Card{
+			ID:           uuid.New(),
+			UserID:       &imp.userID,
+			MerchantID:   merchantID,
+			MerchantName: merchantName,
+			Program:      c.Program,
+			CardNumber:   c.CardNumber,
+			BarcodeType:  c.BarcodeType,
+			Status:       c.Status,
+			Notes:        c.Notes,
+			CreatedAt:    c.CreatedAt,
+		}
+
+		created, err := imp.create(backup.ResourceCard+" "+c.CardNumber, func(tx *gorm.DB) error {
+			return tx.Create(&card).Error
+		})
+		if err != nil {
+			return err
+		}
+		if created {
+			imp.ids[c.ID] = card.ID
+			imp.result.Cards++
+		}
+	}
+	return nil
+}
+
+func (imp *backupImport) restoreVouchers() error {
+	for _, v := range imp.archive.Vouchers {
+		merchantID, merchantName := imp.merchant(v.MerchantID, v.MerchantName)
+		voucher := models.Voucher{
+			ID:                uuid.New(),
+			UserID:            &imp.userID,
+			MerchantID:        merchantID,
+			MerchantName:      merchantName,
+			Code:              v.Code,
+			Type:              v.Type,
+			Description:       v.Description,
+			MinPurchaseAmount: money.FromFloat(v.MinPurchaseAmount, models.VoucherCurrency),
+			ValidFrom:         v.ValidFrom,
+			ValidUntil:        v.ValidUntil,
+			UsageLimitType:    v.UsageLimitType,
+			BarcodeType:       v.BarcodeType,
+			CreatedAt:         v.CreatedAt,
+		}
+		voucher.Value = models.VoucherValueFromFloat(v.Type, v.Value)
+
+		created, err := imp.create(backup.ResourceVoucher+" "+v.Code, func(tx *gorm.DB) error {
+			return tx.Create(&voucher).Error
+		})
+		if err != nil {
+			return err
+		}
+		if created {
+			imp.ids[v.ID] = voucher.ID
+			imp.result.Vouchers++
+		}
+	}
+	return nil
+}
+
+func (imp *backupImport) restoreGiftCards() error {
+	for _, g := range imp.archive.GiftCards {
+		merchantID, merchantName := imp.merchant(g.MerchantID, g.MerchantName)
+		giftCard := models.GiftCard{
+			ID:             uuid.New(),
+			UserID:         &imp.userID,
+			MerchantID:     merchantID,
+			MerchantName:   merchantName,
+			CardNumber:     g.CardNumber,
+			InitialBalance: money.FromFloat(g.InitialBalance, g.Currency),
+			CurrentBalance: money.FromFloat(g.InitialBalance, g.Currency), // Recalculated by trigger as transactions are inserted
+			Currency:       g.Currency,
+			PIN:            g.PIN,
+			ExpiresAt:      g.ExpiresAt,
+			Status:         g.Status,
+			BarcodeType:    g.BarcodeType,
+			Notes:          g.Notes,
+			CreatedAt:      g.CreatedAt,
+		}
+
+		// Insert transactions in chronological order so the balance check
+		// trigger sees the same running balance as on the source instance
+		transactions := make([]backup.Transaction, len(g.Transactions))
+		copy(transactions, g.Transactions)
+		sort.SliceStable(transactions, func(i, j int) bool {
+			return transactions[i].TransactionDate.Before(transactions[j].TransactionDate)
+		})
+
+		created, err := imp.create(backup.ResourceGiftCard+" "+g.CardNumber, func(tx *gorm.DB) error {
+			if err := tx.Create(&giftCard).Error; err != nil {
+				return err
+			}
+			for _, t := range transactions {
+				createdBy, err := imp.user(t.CreatedByEmail)
+				if err != nil {
+					return err
+				}
+				kind := t.Kind
+				if kind == "" {
+					kind = models.TransactionKindPurchase
+				}
+				transaction := models.GiftCardTransaction{
+					GiftCardID:      giftCard.ID,
+					Kind:            kind,
+					Amount:          money.FromFloat(t.Amount, giftCard.Currency),
+					Description:     t.Description,
+					TransactionDate: t.TransactionDate,
+					CreatedByUserID: createdBy,
+					CreatedAt:       t.CreatedAt,
+				}
+				if err := tx.Create(&transaction).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if created {
+			imp.ids[g.ID] = giftCard.ID
+			imp.result.GiftCards++
+			imp.result.Transactions += len(transactions)
+		}
+	}
+	return nil
+}
+
+func (imp *backupImport) restoreFavorites() error {
+	for _, f := range imp.archive.Favorites {
+		resourceID, ok := imp.ids[f.ResourceID]
+		if !ok {
+			continue // Item was skipped
+		}
+		favorite := models.UserFavorite{
+			UserID:       imp.userID,
+			ResourceType: f.ResourceType,
+			ResourceID:   resourceID,
+		}
+		if err := imp.tx.Create(&favorite).Error; err != nil {
+			return err
+		}
+		imp.result.Favorites++
+	}
+	return nil
+}
+
+func (imp *backupImport) restoreShares() error {
+	for _, sh := range imp.archive.Shares {
+		resourceID, ok := imp.ids[sh.ResourceID]
+		if !ok {
+			continue // Item was skipped
+		}
+		sharedWith, err := imp.user(sh.SharedWithEmail)
+		if err != nil {
+			return err
+		}
+		if sharedWith != nil && *sharedWith == imp.userID {
+			continue // Restored into the account of a former share recipient
+		}
+		// Unknown and unverified accounts cannot be share targets
+		if sharedWith == nil || !imp.verified[*sharedWith] {
+			imp.result.UnknownUsers = appendUnique(imp.result.UnknownUsers, sh.SharedWithEmail)
+			continue
+		}
+
+		var share any
+		switch sh.ResourceType {
+		case backup.ResourceCard:
+			share = &models.CardShare{CardID: resourceID, SharedWithID: *sharedWith, CanEdit: sh.CanEdit, CanDelete: sh.CanDelete}
+		case backup.ResourceVoucher:
+			share = &models.VoucherShare{VoucherID: resourceID, SharedWithID: *sharedWith, CanEdit: sh.CanEdit, CanDelete: sh.CanDelete}
+		case backup.ResourceGiftCard:
+			share = &models.GiftCardShare{
+				GiftCardID: resourceID, SharedWithID: *sharedWith,
+				CanEdit: sh.CanEdit, CanDelete: sh.CanDelete, CanEditTransactions: sh.CanEditTransactions,
+			}
+		default:
+			continue
+		}
+		if err := imp.tx.Create(share).Error; err != nil {
+			return err
+		}
+		imp.result.Shares++
+	}
+	return nil
+}
+
+func appendUnique(list []string, value string) []string {
+	for _, v := range list {
+		if strings.EqualFold(v, value) {
+			return list
+		}
+	}
+	return append(list, value)
+}
@@ -0,0 +1,162 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"savvy/internal/backup"
+	"savvy/internal/models"
+	"savvy/internal/money"
+)
+
+func TestBackupService_ExportImport_RoundTrip(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewBackupService(db)
+	ctx := context.Background()
+
+	owner := &models.User{ID: uuid.New(), Email: "anna.mueller@example.com", PasswordHash: "hashed"}
+	verifiedAt := time.Now()
+	friend := &models.User{ID: uuid.New(), Email: "thomas.schmidt@example.com", PasswordHash: "hashed", EmailVerifiedAt: &verifiedAt}
+	require.NoError(t, db.Create(owner).Error)
+	require.NoError(t, db.Create(friend).Error)
+
+	merchant := &models.Merchant{ID: uuid.New(), Name: "Migros", Color: "#FF6600"}
+	require.NoError(t, db.Create(merchant).Error)
+
+	card := &models.Card{
+		UserID: &owner.ID, MerchantID: &merchant.ID, MerchantName: "Migros", Program: "Cumulus",
+		CardNumber: "2099123456789", BarcodeType: "EAN13", Status: "active",
+	}
+	require.NoError(t, db.Create(card).Error)
+
+	giftCard := &models.GiftCard{
+		UserID: &owner.ID, MerchantName: "Manor", CardNumber: "6001234",
+		InitialBalance: money.New(10000, "CHF"), CurrentBalance: money.New(10000, "CHF"), Currency: "CHF", Status: "active", BarcodeType: "CODE128",
+	}
+	require.NoError(t, db.Create(giftCard).Error)
+	require.NoError(t, db.Create(&models.GiftCardTransaction{
+		GiftCardID: giftCard.ID, Amount: money.New(3000, "CHF"), Description: "Shoes",
+		TransactionDate: time.Now().AddDate(0, -2, 0), CreatedByUserID: &owner.ID,
+	}).Error)
+	require.NoError(t, db.Create(&models.GiftCardTransaction{
+		GiftCardID: giftCard.ID, Amount: money.New(2000, "CHF"), Description: "Shirt",
+		TransactionDate: time.Now().AddDate(0, -1, 0), CreatedByUserID: &friend.ID,
+	}).Error)
+	require.NoError(t, db.Create(&models.GiftCardTransaction{
+		GiftCardID: giftCard.ID, Kind: models.TransactionKindReload, Amount: money.New(1500, "CHF"), Description: "Top-up",
+		TransactionDate: time.Now().AddDate(0, 0, -1), CreatedByUserID: &owner.ID,
+	}).Error)
+
+	require.NoError(t, db.Create(&models.UserFavorite{UserID: owner.ID, ResourceType: "card", ResourceID: card.ID}).Error)
+	require.NoError(t, db.Create(&models.GiftCardShare{
+		GiftCardID: giftCard.ID, SharedWithID: friend.ID, CanEdit: true, CanEditTransactions: true,
+	}).Error)
+
+	// Items shared with the owner are not part of the export
+	foreign := &models.Card{UserID: &friend.ID, MerchantName: "IKEA", CardNumber: "999", BarcodeType: "CODE128", Status: "active"}
+	require.NoError(t, db.Create(foreign).Error)
+	require.NoError(t, db.Create(&models.CardShare{CardID: foreign.ID, SharedWithID: owner.ID}).Error)
+
+	archive, err := service.ExportUserData(ctx, owner.ID)
+	require.NoError(t, err)
+
+	assert.Equal(t, backup.FormatVersion, archive.Version)
+	assert.Equal(t, owner.Email, archive.User.Email)
+	require.Len(t, archive.Cards, 1)
+	assert.Equal(t, "2099123456789", archive.Cards[0].CardNumber)
+	require.Len(t, archive.Merchants, 1)
+	assert.Equal(t, "Migros", archive.Merchants[0].Name)
+	require.Len(t, archive.GiftCards, 1)
+	require.Len(t, archive.GiftCards[0].Transactions, 3)
+	assert.Equal(t, "Shoes", archive.GiftCards[0].Transactions[0].Description)
+	assert.Equal(t, friend.Email, archive.GiftCards[0].Transactions[1].CreatedByEmail)
+	assert.Equal(t, models.TransactionKindReload, archive.GiftCards[0].Transactions[2].Kind)
+	require.Len(t, archive.Favorites, 1)
+	require.Len(t, archive.Shares, 1)
+	assert.Equal(t, friend.Email, archive.Shares[0].SharedWithEmail)
+	assert.True(t, archive.Shares[0].CanEditTransactions)
+
+	// Restore into another account after removing the originals so the
+	// card numbers are free again
+	require.NoError(t, db.Unscoped().Where("user_id = ?", owner.ID).Delete(&models.Card{}).Error)
+	require.NoError(t, db.Unscoped().Where("gift_card_id = ?", giftCard.ID).Delete(&models.GiftCardShare{}).Error)
+	require.NoError(t, db.Unscoped().Where("gift_card_id = ?", giftCard.ID).Delete(&models.GiftCardTransaction{}).Error)
+	require.NoError(t, db.Unscoped().Where("user_id = ?", owner.ID).Delete(&models.GiftCard{}).Error)
+
+	target := &models.User{ID: uuid.New(), Email: "anna@new-instance.example", PasswordHash: "hashed"}
+	require.NoError(t, db.Create(target).Error)
+
+	result, err := service.ImportUserData(ctx, target.ID, archive)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Cards)
+	assert.Equal(t, 1, result.GiftCards)
+	assert.Equal(t, 3, result.Transactions)
+	assert.Equal(t, 1, result.Favorites)
+	assert.Equal(t, 1, result.Shares)
+	assert.Empty(t, result.Conflicts)
+
+	var restoredCard models.Card
+	require.NoError(t, db.Where("user_id = ?", target.ID).First(&restoredCard).Error)
+	assert.NotEqual(t, card.ID, restoredCard.ID)
+	require.NotNil(t, restoredCard.MerchantID)
+	assert.Equal(t, merchant.ID, *restoredCard.MerchantID)
+
+	var restoredGiftCard models.GiftCard
+	require.NoError(t, db.Preload("Transactions").Where("user_id = ?", target.ID).First(&restoredGiftCard).Error)
+	assert.Equal(t, money.New(6500, "CHF"), restoredGiftCard.CurrentBalance)
+	require.Len(t, restoredGiftCard.Transactions, 3)
+
+	var favorite models.UserFavorite
+	require.NoError(t, db.Where("user_id = ?", target.ID).First(&favorite).Error)
+	assert.Equal(t, restoredCard.ID, favorite.ResourceID)
+
+	var share models.GiftCardShare
+	require.NoError(t, db.Where("gift_card_id = ?", restoredGiftCard.ID).First(&share).Error)
+	assert.Equal(t, friend.ID, share.SharedWithID)
+}
+
+func TestBackupService_Import_ConflictsAndUnknownUsers(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewBackupService(db)
+
+	user := &models.User{ID: uuid.New(), Email: "anna.mueller@example.com", PasswordHash: "hashed"}
+	require.NoError(t, db.Create(user).Error)
+	unverified := &models.User{ID: uuid.New(), Email: "thomas.schmidt@example.com", PasswordHash: "hashed"}
+	require.NoError(t, db.Create(unverified).Error)
+	existing := &models.Card{UserID: &user.ID, MerchantName: "Migros", CardNumber: "2099123456789", BarcodeType: "EAN13", Status: "active"}
+	require.NoError(t, db.Create(existing).Error)
+
+	merchantID := uuid.New()
+	cardID := uuid.New()
+	archive := &backup.Archive{
+		Version:   backup.FormatVersion,
+		User:      backup.User{Email: user.Email},
+		Merchants: []backup.Merchant{{ID: merchantID, Name: "Coop"}},
+		Cards: []backup.Card{
+			{ID: uuid.New(), MerchantName: "Migros", CardNumber: "2099123456789", BarcodeType: "EAN13", Status: "active"},
+			{ID: cardID, MerchantID: &merchantID, MerchantName: "Coop", CardNumber: "4000111", BarcodeType: "CODE128", Status: "active"},
+		},
+		Shares: []backup.Share{
+			{ResourceType: backup.ResourceCard, ResourceID: cardID, SharedWithEmail: "nobody@example.com"},
+			{ResourceType: backup.ResourceCard, ResourceID: cardID, SharedWithEmail: unverified.Email},
+		},
+	}
+
+	result, err := service.ImportUserData(context.Background(), user.ID, archive)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, result.Cards)
+	assert.Equal(t, 0, result.Shares)
+	assert.Equal(t, []string{"card 2099123456789"}, result.Conflicts)
+	assert.Equal(t, []string{"nobody@example.com", unverified.Email}, result.UnknownUsers)
+
+	// Unknown merchant is kept as free text
+	var card models.Card
+	require.NoError(t, db.First(&card, "id = ?", cardID).Error)
+	assert.Nil(t, card.MerchantID)
+	assert.Equal(t, "Coop", card.MerchantName)
+}
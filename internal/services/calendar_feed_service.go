@@ -0,0 +1,217 @@
+// Package services contains business logic.
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"log/slog"
+	"savvy/internal/i18n"
+	"savvy/internal/ical"
+	"savvy/internal/models"
+	"savvy/internal/repository"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CalendarFeedTokenPrefix marks calendar feed tokens so they are recognizable in logs and secret scanners.
+const CalendarFeedTokenPrefix = "svycal_"
+
+// calendarAlarmHour is the time of day calendar alarms go off, in the time
+// zone of the calendar app
+const calendarAlarmHour = 9
+
+// ErrInvalidCalendarFeedToken indicates the token is unknown, malformed or was rotated
+var ErrInvalidCalendarFeedToken = errors.New("invalid calendar feed token")
+
+// CalendarFeedServiceInterface publishes the expiry dates of the vouchers and
+// gift cards of a user as an iCalendar feed behind a secret URL
+type CalendarFeedServiceInterface interface {
+	// GetFeed returns the feed of a user, nil if they have none.
+	GetFeed(ctx context.Context, userID uuid.UUID) (*models.CalendarFeed, error)
+	// RotateToken creates the feed of a user or replaces its token, so the
+	// old URL stops working, and returns the new token. It is not stored and
+	// cannot be shown again.
+	RotateToken(ctx context.Context, userID uuid.UUID, language string) (string, error)
+	// DeleteFeed removes the feed of a user.
+	DeleteFeed(ctx context.Context, userID uuid.UUID) error
+	// Authenticate resolves a token to its feed and records the fetch.
+	Authenticate(ctx context.Context, token string) (*models.CalendarFeed, error)
+	// Calendar builds the feed with the items the user owns or that are shared
+	// with them, in the language of the feed. Links point to baseURL.
+	Calendar(ctx context.Context, feed *models.CalendarFeed, baseURL string) (*ical.Calendar, error)
+}
+
+// CalendarFeedService implements CalendarFeedServiceInterface
+type CalendarFeedService struct {
+	repo      repository.CalendarFeedRepository
+	vouchers  VoucherServiceInterface
+	giftCards GiftCardServiceInterface
+	reminders ExpiryReminderServiceInterface
+}
+
+// NewCalendarFeedService creates a new calendar feed service
+func NewCalendarFeedService(
+	repo repository.CalendarFeedRepository,
+	vouchers VoucherServiceInterface,
+	giftCards GiftCardServiceInterface,
+	reminders ExpiryReminderServiceInterface,
+) CalendarFeedServiceInterface {
+	return &CalendarFeedService{repo: repo, vouchers: vouchers, giftCards: giftCards, reminders: reminders}
+}
+
+func (s *CalendarFeedService) GetFeed(ctx context.Context, userID uuid.UUID) (*models.CalendarFeed, error) {
+	feed, err := s.repo.Get(ctx, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return feed, err
+}
+
+func (s *CalendarFeedService) RotateToken(ctx context.Context, userID uuid.UUID, language string) (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	token := CalendarFeedTokenPrefix + base64.RawURLEncoding.EncodeToString(raw)
+
+	// Stored like API tokens, the token carries 256 bits of entropy
+	feed := &models.CalendarFeed{UserID: userID, TokenHash: HashAPIToken(token), Language: language}
+	if err := s.repo.Save(ctx, feed); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func (s *CalendarFeedService) DeleteFeed(ctx context.Context, userID uuid.UUID) error {
+	return s.repo.Delete(ctx, userID)
+}
+
+func (s *CalendarFeedService) Authenticate(ctx context.Context, token string) (*models.CalendarFeed, error) {
+	if !strings.HasPrefix(token, CalendarFeedTokenPrefix) {
+		return nil, ErrInvalidCalendarFeedToken
+	}
+
+	feed, err := s.repo.GetByHash(ctx, HashAPIToken(token))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidCalendarFeedToken
+		}
+		return nil, err
+	}
+
+	// Usage tracking must not fail the fetch
+	now := time.Now()
+	if err := s.repo.TouchLastUsed(ctx, feed.UserID, now); err != nil {
+		slog.Warn("Failed to update calendar feed last use", "user_id", feed.UserID, "error", err)
+	} else {
+		feed.LastUsedAt = &now
+	}
+	return feed, nil
+}
+
+// Calendar adds an event on the first and the last day of each voucher and
+// on the expiry date of each gift card. Expiry events get an alarm for each
+// of the reminder days of the user. Gift cards without expiry date or
+// balance are left out.
+func (s *CalendarFeedService) Calendar(ctx context.Context, feed *models.CalendarFeed, baseURL string) (*ical.Calendar, error) {
+	ctx = i18n.SetLocalizer(i18n.SetLanguage(ctx, feed.Language), i18n.NewLocalizer(feed.Language))
+
+	days, err := s.reminders.GetDays(ctx, feed.UserID)
+	if err != nil {
+		return nil, err
+	}
+	vouchers, err := s.vouchers.GetUserVouchers(ctx, feed.UserID)
+	if err != nil {
+		return nil, err
+	}
+	giftCards, err := s.giftCards.GetUserGiftCards(ctx, feed.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	calendar := &ical.Calendar{
+		Name:   i18n.T(ctx, "calendar.name"),
+		Stamp:  time.Now(),
+		Events: make([]ical.Event, 0, 2*len(vouchers)+len(giftCards)),
+	}
+
+	for _, voucher := range vouchers {
+		name := voucher.MerchantName
+		if voucher.Merchant != nil {
+			name = voucher.Merchant.Name
+		}
+		url := baseURL + models.NotificationResourcePath("voucher", voucher.ID.String())
+		data := map[string]any{"Name": name, "Date": voucher.ValidUntil.UTC().Format("02.01.2006")}
+
+		// A voucher valid for one day only gets the expiry event
+		if voucher.ValidFrom.Before(voucher.ValidUntil) && !sameDay(voucher.ValidFrom, voucher.ValidUntil) {
+			summary := i18n.T(ctx, "calendar.voucher_valid_from", data)
+			calendar.Events = append(calendar.Events, ical.Event{
+				UID:     "voucher-" + voucher.ID.String() + "-valid-from@savvy",
+				Date:    voucher.ValidFrom,
+				Summary: summary,
+				URL:     url,
+				Alarms:  []ical.Alarm{{Before: -calendarAlarmHour * time.Hour, Description: summary}},
+			})
+		}
+
+		calendar.Events = append(calendar.Events, ical.Event{
+			UID:     "voucher-" + voucher.ID.String() + "-valid-until@savvy",
+			Date:    voucher.ValidUntil,
+			Summary: i18n.T(ctx, "calendar.voucher_expires", data),
+			URL:     url,
+			Alarms:  expiryAlarms(days, i18n.T(ctx, "notifications.expiring_soon.voucher", data)),
+		})
+	}
+
+	for _, giftCard := range giftCards {
+		if giftCard.ExpiresAt == nil || giftCard.Status == models.ItemStatusRedeemed || giftCard.IsEmpty() {
+			continue
+		}
+		name := giftCard.MerchantName
+		if giftCard.Merchant != nil {
+			name = giftCard.Merchant.Name
+		}
+		data := map[string]any{
+			"Name":    name,
+			"Date":    giftCard.ExpiresAt.UTC().Format("02.01.2006"),
+			"Balance": giftCard.GetCurrentBalance().String(),
+		}
+
+		calendar.Events = append(calendar.Events, ical.Event{
+			UID:         "gift_card-" + giftCard.ID.String() + "-expires@savvy",
+			Date:        *giftCard.ExpiresAt,
+			Summary:     i18n.T(ctx, "calendar.gift_card_expires", data),
+			Description: i18n.T(ctx, "calendar.gift_card_balance", data),
+			URL:         baseURL + models.NotificationResourcePath("gift_card", giftCard.ID.String()),
+			Alarms:      expiryAlarms(days, i18n.T(ctx, "notifications.expiring_soon.gift_card", data)),
+		})
+	}
+
+	return calendar, nil
+}
+
+// expiryAlarms returns an alarm at calendarAlarmHour the given number of
+// days before the expiry date, matching the expiry reminders
+func expiryAlarms(days models.ReminderDays, description string) []ical.Alarm {
+	alarms := make([]ical.Alarm, 0, len(days))
+	for _, offset := range days {
+		alarms = append(alarms, ical.Alarm{
+			Before:      time.Duration(offset)*24*time.Hour - calendarAlarmHour*time.Hour,
+			Description: description,
+		})
+	}
+	return alarms
+}
+
+// sameDay reports whether a and b fall on the same UTC date
+func sameDay(a, b time.Time) bool {
+	ay, am, ad := a.UTC().Date()
+	by, bm, bd := b.UTC().Date()
+	return ay == by && am == bm && ad == bd
+}
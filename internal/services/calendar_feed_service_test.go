@@ -0,0 +1,182 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"savvy/internal/assets"
+	"savvy/internal/i18n"
+	"savvy/internal/ical"
+	"savvy/internal/models"
+	"savvy/internal/money"
+)
+
+// fakeCalendarFeedRepository keeps feeds in memory
+type fakeCalendarFeedRepository struct {
+	feeds map[uuid.UUID]*models.CalendarFeed
+}
+
+func (r *fakeCalendarFeedRepository) Get(_ context.Context, userID uuid.UUID) (*models.CalendarFeed, error) {
+	feed, ok := r.feeds[userID]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return feed, nil
+}
+
+func (r *fakeCalendarFeedRepository) GetByHash(_ context.Context, tokenHash string) (*models.CalendarFeed, error) {
+	for _, feed := range r.feeds {
+		if feed.TokenHash == tokenHash {
+			return feed, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeCalendarFeedRepository) Save(_ context.Context, feed *models.CalendarFeed) error {
+	feed.LastUsedAt = nil
+	feed.UpdatedAt = time.Now()
+	r.feeds[feed.UserID] = feed
+	return nil
+}
+
+func (r *fakeCalendarFeedRepository) Delete(_ context.Context, userID uuid.UUID) error {
+	delete(r.feeds, userID)
+	return nil
+}
+
+func (r *fakeCalendarFeedRepository) TouchLastUsed(_ context.Context, userID uuid.UUID, at time.Time) error {
+	if feed, ok := r.feeds[userID]; ok {
+		feed.LastUsedAt = &at
+	}
+	return nil
+}
+
+type calendarFeedTest struct {
+	service   CalendarFeedServiceInterface
+	feeds     *fakeCalendarFeedRepository
+	vouchers  *MockVoucherRepository
+	giftCards *MockGiftCardRepository
+	reminders ExpiryReminderServiceInterface
+}
+
+func setupCalendarFeedService(t *testing.T) *calendarFeedTest {
+	t.Helper()
+	initMailI18n.Do(func() {
+		require.NoError(t, i18n.Init(assets.Locales))
+	})
+
+	test := &calendarFeedTest{
+		feeds:     &fakeCalendarFeedRepository{feeds: map[uuid.UUID]*models.CalendarFeed{}},
+		vouchers:  new(MockVoucherRepository),
+		giftCards: new(MockGiftCardRepository),
+		reminders: NewExpiryReminderService(newFakeExpiryReminderRepository(), &fakeReminderNotificationService{}),
+	}
+	test.service = NewCalendarFeedService(test.feeds, NewVoucherService(test.vouchers), NewGiftCardService(test.giftCards), test.reminders)
+	return test
+}
+
+func TestCalendarFeedService_RotateToken(t *testing.T) {
+	test := setupCalendarFeedService(t)
+	ctx := context.Background()
+	userID := uuid.New()
+
+	feed, err := test.service.GetFeed(ctx, userID)
+	require.NoError(t, err)
+	assert.Nil(t, feed)
+
+	first, err := test.service.RotateToken(ctx, userID, "de")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(first, CalendarFeedTokenPrefix))
+	assert.NotContains(t, test.feeds.feeds[userID].TokenHash, first, "Only the hash is stored")
+
+	feed, err = test.service.Authenticate(ctx, first)
+	require.NoError(t, err)
+	assert.Equal(t, userID, feed.UserID)
+	assert.Equal(t, "de", feed.Language)
+	assert.NotNil(t, feed.LastUsedAt)
+
+	second, err := test.service.RotateToken(ctx, userID, "en")
+	require.NoError(t, err)
+	assert.NotEqual(t, first, second)
+
+	_, err = test.service.Authenticate(ctx, first)
+	assert.ErrorIs(t, err, ErrInvalidCalendarFeedToken, "The old URL stops working")
+	_, err = test.service.Authenticate(ctx, second)
+	assert.NoError(t, err)
+
+	require.NoError(t, test.service.DeleteFeed(ctx, userID))
+	_, err = test.service.Authenticate(ctx, second)
+	assert.ErrorIs(t, err, ErrInvalidCalendarFeedToken)
+}
+
+func TestCalendarFeedService_Authenticate_InvalidToken(t *testing.T) {
+	test := setupCalendarFeedService(t)
+	ctx := context.Background()
+
+	for _, token := range []string{"", "svy_" + strings.Repeat("a", 43), CalendarFeedTokenPrefix + "unknown"} {
+		_, err := test.service.Authenticate(ctx, token)
+		assert.ErrorIs(t, err, ErrInvalidCalendarFeedToken, token)
+	}
+}
+
+func TestCalendarFeedService_Calendar(t *testing.T) {
+	test := setupCalendarFeedService(t)
+	ctx := context.Background()
+	userID := uuid.New()
+	require.NoError(t, test.reminders.UpdateDays(ctx, userID, models.ReminderDays{1, 7}))
+
+	validFrom := time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)
+	validUntil := time.Date(2026, 11, 30, 23, 59, 59, 0, time.UTC)
+	expiresAt := time.Date(2026, 12, 24, 0, 0, 0, 0, time.UTC)
+	owned := models.Voucher{ID: uuid.New(), MerchantName: "Migros", ValidFrom: validFrom, ValidUntil: validUntil}
+	shared := models.Voucher{ID: uuid.New(), MerchantName: "Coop", ValidFrom: validUntil, ValidUntil: validUntil}
+	giftCard := models.GiftCard{ID: uuid.New(), MerchantName: "Manor", Currency: "CHF", CurrentBalance: money.New(2500, "CHF"), ExpiresAt: &expiresAt}
+	noExpiry := models.GiftCard{ID: uuid.New(), MerchantName: "Globus", Currency: "CHF", CurrentBalance: money.New(1000, "CHF")}
+	empty := models.GiftCard{ID: uuid.New(), MerchantName: "Ikea", Currency: "CHF", ExpiresAt: &expiresAt}
+
+	test.vouchers.On("GetByUserID", mock.Anything, userID).Return([]models.Voucher{owned}, nil)
+	test.vouchers.On("GetSharedWithUser", mock.Anything, userID).Return([]models.Voucher{shared}, nil)
+	test.giftCards.On("GetByUserID", mock.Anything, userID).Return([]models.GiftCard{giftCard, noExpiry}, nil)
+	test.giftCards.On("GetSharedWithUser", mock.Anything, userID).Return([]models.GiftCard{empty}, nil)
+
+	calendar, err := test.service.Calendar(ctx, &models.CalendarFeed{UserID: userID, Language: "en"}, "https://savvy.example.com")
+	require.NoError(t, err)
+
+	events := map[string]ical.Event{}
+	for _, event := range calendar.Events {
+		events[event.UID] = event
+	}
+	require.Len(t, events, 4, "Valid from and until of the owned voucher, until of the one-day voucher, the gift card")
+
+	validFromEvent := events["voucher-"+owned.ID.String()+"-valid-from@savvy"]
+	assert.Equal(t, validFrom, validFromEvent.Date)
+	assert.Contains(t, validFromEvent.Summary, "Migros")
+	require.Len(t, validFromEvent.Alarms, 1)
+	assert.Equal(t, -calendarAlarmHour*time.Hour, validFromEvent.Alarms[0].Before, "Alarm on the day itself")
+
+	expiryEvent := events["voucher-"+owned.ID.String()+"-valid-until@savvy"]
+	assert.Equal(t, validUntil, expiryEvent.Date)
+	assert.Equal(t, "https://savvy.example.com/vouchers/"+owned.ID.String(), expiryEvent.URL)
+	var before []time.Duration
+	for _, alarm := range expiryEvent.Alarms {
+		before = append(before, alarm.Before)
+	}
+	assert.ElementsMatch(t, []time.Duration{24*time.Hour - calendarAlarmHour*time.Hour, 7*24*time.Hour - calendarAlarmHour*time.Hour}, before)
+
+	assert.Contains(t, events, "voucher-"+shared.ID.String()+"-valid-until@savvy")
+	assert.NotContains(t, events, "voucher-"+shared.ID.String()+"-valid-from@savvy")
+
+	giftCardEvent := events["gift_card-"+giftCard.ID.String()+"-expires@savvy"]
+	assert.Equal(t, expiresAt, giftCardEvent.Date)
+	assert.Contains(t, giftCardEvent.Summary, "Manor")
+	assert.Contains(t, giftCardEvent.Description, "25")
+	assert.Len(t, giftCardEvent.Alarms, 2)
+}
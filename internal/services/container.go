@@ -2,6 +2,7 @@
 package services
 
 import (
+	"savvy/internal/realtime"
 	"savvy/internal/repository"
 
 	"gorm.io/gorm"
@@ -9,18 +10,36 @@ import (
 
 // Container holds all service instances.
 type Container struct {
-	CardService         CardServiceInterface
-	VoucherService      VoucherServiceInterface
-	GiftCardService     GiftCardServiceInterface
-	MerchantService     MerchantServiceInterface
-	UserService         UserServiceInterface
-	ShareService        ShareServiceInterface
-	FavoriteService     FavoriteServiceInterface
-	AuthzService        AuthzServiceInterface
-	DashboardService    DashboardServiceInterface
-	AdminService        AdminServiceInterface
-	TransferService     TransferServiceInterface
-	NotificationService NotificationServiceInterface
+	CardService                 CardServiceInterface
+	VoucherService              VoucherServiceInterface
+	GiftCardService             GiftCardServiceInterface
+	MerchantService             MerchantServiceInterface
+	UserService                 UserServiceInterface
+	ShareService                ShareServiceInterface
+	FavoriteService             FavoriteServiceInterface
+	AuthzService                AuthzServiceInterface
+	DashboardService            DashboardServiceInterface
+	AdminService                AdminServiceInterface
+	TransferService             TransferServiceInterface
+	NotificationService         NotificationServiceInterface
+	APITokenService             APITokenServiceInterface
+	ImportService               ImportServiceInterface
+	BackupService               BackupServiceInterface
+	ExchangeRateService         ExchangeRateServiceInterface
+	EncryptionService           EncryptionServiceInterface
+	SessionService              SessionServiceInterface
+	TwoFactorService            TwoFactorServiceInterface
+	WebAuthnService             WebAuthnServiceInterface
+	AccountEmailService         AccountEmailServiceInterface
+	LoginThrottleService        LoginThrottleServiceInterface
+	NotificationDeliveryService NotificationDeliveryServiceInterface
+	JobService                  JobServiceInterface
+	ExpiryReminderService       ExpiryReminderServiceInterface
+	ItemLifecycleService        ItemLifecycleServiceInterface
+	CalendarFeedService         CalendarFeedServiceInterface
+
+	// NotificationEvents streams notification changes to the open tabs
+	NotificationEvents *realtime.Hub
 }
 
 // NewContainer creates a new service container with all services initialized.
@@ -33,23 +52,60 @@ func NewContainer(db *gorm.DB) *Container {
 	userRepo := repository.NewUserRepository(db)
 	favoriteRepo := repository.NewFavoriteRepository(db)
 	notificationRepo := repository.NewNotificationRepository(db)
+	apiTokenRepo := repository.NewAPITokenRepository(db)
+	userSessionRepo := repository.NewUserSessionRepository(db)
+	webAuthnCredentialRepo := repository.NewWebAuthnCredentialRepository(db)
+	loginThrottleRepo := repository.NewLoginThrottleRepository(db)
+	notificationDeliveryRepo := repository.NewNotificationDeliveryRepository(db)
+	notificationPreferenceRepo := repository.NewNotificationPreferenceRepository(db)
+	pushSubscriptionRepo := repository.NewPushSubscriptionRepository(db)
+	jobRepo := repository.NewJobRepository(db)
+	expiryReminderRepo := repository.NewExpiryReminderRepository(db)
+	itemLifecycleRepo := repository.NewItemLifecycleRepository(db)
+	calendarFeedRepo := repository.NewCalendarFeedRepository(db)
 
 	// Initialize notification service first (needed by ShareService and TransferService)
-	notificationService := NewNotificationService(notificationRepo)
+	notificationDeliveryService := NewNotificationDeliveryService(notificationDeliveryRepo, notificationPreferenceRepo, pushSubscriptionRepo, userRepo)
+	notificationEvents := realtime.NewHub(db)
+	notificationService := NewNotificationService(notificationRepo, notificationDeliveryService, notificationEvents)
+	cardService := NewCardService(cardRepo)
+	merchantService := NewMerchantService(merchantRepo)
+	exchangeRateService := NewExchangeRateService(db)
+	sessionService := NewSessionService(userSessionRepo)
+	accountEmailService := NewAccountEmailService(userRepo, sessionService)
+	voucherService := NewVoucherService(voucherRepo)
+	giftCardService := NewGiftCardService(giftCardRepo)
+	expiryReminderService := NewExpiryReminderService(expiryReminderRepo, notificationService)
 
 	// Initialize services
 	return &Container{
-		CardService:         NewCardService(cardRepo),
-		VoucherService:      NewVoucherService(voucherRepo),
-		GiftCardService:     NewGiftCardService(giftCardRepo),
-		MerchantService:     NewMerchantService(merchantRepo),
-		UserService:         NewUserService(userRepo),
-		ShareService:        NewShareService(cardRepo, voucherRepo, giftCardRepo, db, notificationService),
-		FavoriteService:     NewFavoriteService(favoriteRepo, cardRepo, voucherRepo, giftCardRepo),
-		AuthzService:        NewAuthzService(db),
-		DashboardService:    NewDashboardService(db),
-		AdminService:        NewAdminService(db),
-		TransferService:     NewTransferService(db, cardRepo, voucherRepo, giftCardRepo, notificationService),
-		NotificationService: notificationService,
+		CardService:                 cardService,
+		VoucherService:              voucherService,
+		GiftCardService:             giftCardService,
+		MerchantService:             merchantService,
+		UserService:                 NewUserService(userRepo),
+		ShareService:                NewShareService(cardRepo, voucherRepo, giftCardRepo, db, notificationService),
+		FavoriteService:             NewFavoriteService(favoriteRepo, cardRepo, voucherRepo, giftCardRepo),
+		AuthzService:                NewAuthzService(db),
+		DashboardService:            NewDashboardService(db, exchangeRateService),
+		AdminService:                NewAdminService(db),
+		TransferService:             NewTransferService(db, cardRepo, voucherRepo, giftCardRepo, notificationService),
+		NotificationService:         notificationService,
+		APITokenService:             NewAPITokenService(apiTokenRepo),
+		ImportService:               NewImportService(cardService, merchantService),
+		BackupService:               NewBackupService(db),
+		ExchangeRateService:         exchangeRateService,
+		EncryptionService:           NewEncryptionService(db),
+		SessionService:              sessionService,
+		TwoFactorService:            NewTwoFactorService(db),
+		WebAuthnService:             NewWebAuthnService(webAuthnCredentialRepo, userRepo),
+		AccountEmailService:         accountEmailService,
+		LoginThrottleService:        NewLoginThrottleService(loginThrottleRepo, userRepo, notificationService, accountEmailService),
+		NotificationDeliveryService: notificationDeliveryService,
+		JobService:                  NewJobService(jobRepo),
+		ExpiryReminderService:       expiryReminderService,
+		ItemLifecycleService:        NewItemLifecycleService(itemLifecycleRepo),
+		CalendarFeedService:         NewCalendarFeedService(calendarFeedRepo, voucherService, giftCardService, expiryReminderService),
+		NotificationEvents:          notificationEvents,
 	}
 }
@@ -23,6 +23,22 @@ func TestNewContainer(t *testing.T) {
 	assert.NotNil(t, container.FavoriteService)
 	assert.NotNil(t, container.AuthzService)
 	assert.NotNil(t, container.DashboardService)
+	assert.NotNil(t, container.APITokenService)
+	assert.NotNil(t, container.ImportService)
+	assert.NotNil(t, container.BackupService)
+	assert.NotNil(t, container.ExchangeRateService)
+	assert.NotNil(t, container.EncryptionService)
+	assert.NotNil(t, container.SessionService)
+	assert.NotNil(t, container.TwoFactorService)
+	assert.NotNil(t, container.WebAuthnService)
+	assert.NotNil(t, container.AccountEmailService)
+	assert.NotNil(t, container.LoginThrottleService)
+	assert.NotNil(t, container.NotificationDeliveryService)
+	assert.NotNil(t, container.JobService)
+	assert.NotNil(t, container.ExpiryReminderService)
+	assert.NotNil(t, container.ItemLifecycleService)
+	assert.NotNil(t, container.CalendarFeedService)
+	assert.NotNil(t, container.NotificationEvents)
 
 	// Verify services implement their interfaces
 	var _ CardServiceInterface = container.CardService
@@ -33,4 +49,19 @@ func TestNewContainer(t *testing.T) {
 	var _ FavoriteServiceInterface = container.FavoriteService
 	var _ AuthzServiceInterface = container.AuthzService
 	var _ DashboardServiceInterface = container.DashboardService
+	var _ APITokenServiceInterface = container.APITokenService
+	var _ ImportServiceInterface = container.ImportService
+	var _ BackupServiceInterface = container.BackupService
+	var _ ExchangeRateServiceInterface = container.ExchangeRateService
+	var _ EncryptionServiceInterface = container.EncryptionService
+	var _ SessionServiceInterface = container.SessionService
+	var _ TwoFactorServiceInterface = container.TwoFactorService
+	var _ WebAuthnServiceInterface = container.WebAuthnService
+	var _ AccountEmailServiceInterface = container.AccountEmailService
+	var _ LoginThrottleServiceInterface = container.LoginThrottleService
+	var _ NotificationDeliveryServiceInterface = container.NotificationDeliveryService
+	var _ JobServiceInterface = container.JobService
+	var _ ExpiryReminderServiceInterface = container.ExpiryReminderService
+	var _ ItemLifecycleServiceInterface = container.ItemLifecycleService
+	var _ CalendarFeedServiceInterface = container.CalendarFeedService
 }
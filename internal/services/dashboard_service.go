@@ -3,7 +3,10 @@ package services
 
 import (
 	"context"
+	"log/slog"
 	"savvy/internal/models"
+	"savvy/internal/money"
+	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -17,7 +20,16 @@ type DashboardStats struct {
 	VouchersShared  int64
 	GiftCardsOwned  int64
 	GiftCardsShared int64
-	TotalBalance    float64
+
+	// Gift card balances per currency and their sum converted into
+	// DisplayCurrency. Currencies without exchange rate are listed in
+	// MissingRates and not part of TotalBalance.
+	BalanceSubtotals []money.Money
+	TotalBalance     money.Money
+	DisplayCurrency  string
+	MissingRates     []string
+	RatesDate        time.Time // Zero if all balances are in DisplayCurrency
+	RatesSource      string
 }
 
 // DashboardData contains all data needed for dashboard rendering
@@ -34,22 +46,25 @@ type DashboardData struct {
 
 // DashboardServiceInterface defines the interface for dashboard operations
 type DashboardServiceInterface interface {
-	GetDashboardData(ctx context.Context, userID uuid.UUID) (*DashboardData, error)
+	// GetDashboardData loads the dashboard of a user. Balances are converted
+	// into displayCurrency, or the exchange rate base currency if empty.
+	GetDashboardData(ctx context.Context, userID uuid.UUID, displayCurrency string) (*DashboardData, error)
 }
 
 // DashboardService handles dashboard-related business logic
 type DashboardService struct {
-	db *gorm.DB
+	db            *gorm.DB
+	exchangeRates ExchangeRateServiceInterface
 }
 
 // NewDashboardService creates a new dashboard service
-func NewDashboardService(db *gorm.DB) DashboardServiceInterface {
-	return &DashboardService{db: db}
+func NewDashboardService(db *gorm.DB, exchangeRates ExchangeRateServiceInterface) DashboardServiceInterface {
+	return &DashboardService{db: db, exchangeRates: exchangeRates}
 }
 
 // GetDashboardData fetches all dashboard data with optimized queries
-func (s *DashboardService) GetDashboardData(ctx context.Context, userID uuid.UUID) (*DashboardData, error) {
-	stats, err := s.getStats(ctx, userID)
+func (s *DashboardService) GetDashboardData(ctx context.Context, userID uuid.UUID, displayCurrency string) (*DashboardData, error) {
+	stats, err := s.getStats(ctx, userID, displayCurrency)
 	if err != nil {
 		return nil, err
 	}
@@ -117,7 +132,7 @@ func (s *DashboardService) GetDashboardData(ctx context.Context, userID uuid.UUI
 }
 
 // getStats fetches all statistics with optimized queries
-func (s *DashboardService) getStats(ctx context.Context, userID uuid.UUID) (*DashboardStats, error) {
+func (s *DashboardService) getStats(ctx context.Context, userID uuid.UUID, displayCurrency string) (*DashboardStats, error) {
 	stats := &DashboardStats{}
 
 	// Batch all COUNT queries in parallel using goroutines
@@ -186,9 +201,14 @@ func (s *DashboardService) getStats(ctx context.Context, userID uuid.UUID) (*Das
 	}
 
 	// Calculate total balance using cached current_balance column
-	// Include both owned gift cards AND shared gift cards
+	// Include both owned gift cards AND shared gift cards. Balances are minor
+	// units, so they are summed per currency and converted afterwards.
+	var balances []struct {
+		Currency string
+		Total    int64
+	}
 	err := s.db.WithContext(ctx).Raw(`
-		SELECT COALESCE(SUM(current_balance), 0)
+		SELECT currency, COALESCE(SUM(current_balance), 0) AS total
 		FROM gift_cards
 		WHERE status = 'active'
 		  AND deleted_at IS NULL
@@ -201,14 +221,58 @@ func (s *DashboardService) getStats(ctx context.Context, userID uuid.UUID) (*Das
 		        AND deleted_at IS NULL
 		    )
 		  )
-	`, userID, userID).Scan(&stats.TotalBalance).Error
+		GROUP BY currency
+		ORDER BY currency
+	`, userID, userID).Scan(&balances).Error
 	if err != nil {
 		return nil, err
 	}
+	for _, balance := range balances {
+		stats.BalanceSubtotals = append(stats.BalanceSubtotals, money.New(balance.Total, balance.Currency))
+	}
+
+	s.convertBalances(ctx, stats, displayCurrency)
 
 	return stats, nil
 }
 
+// convertBalances sums the subtotals in the display currency. If the rates
+// are unavailable, only balances already in that currency are counted.
+func (s *DashboardService) convertBalances(ctx context.Context, stats *DashboardStats, displayCurrency string) {
+	rates, err := s.exchangeRates.GetRates(ctx)
+	if err != nil {
+		slog.Warn("Failed to load exchange rates", "error", err)
+	}
+
+	if displayCurrency == "" {
+		displayCurrency = money.DefaultCurrency
+		if rates != nil {
+			displayCurrency = rates.Base
+		}
+	}
+
+	stats.DisplayCurrency = displayCurrency
+	stats.TotalBalance = money.New(0, displayCurrency)
+	for _, subtotal := range stats.BalanceSubtotals {
+		if subtotal.Currency == displayCurrency {
+			stats.TotalBalance = stats.TotalBalance.Add(subtotal)
+			continue
+		}
+		if rates == nil {
+			stats.MissingRates = append(stats.MissingRates, subtotal.Currency)
+			continue
+		}
+		converted, err := rates.Convert(subtotal, displayCurrency)
+		if err != nil {
+			stats.MissingRates = append(stats.MissingRates, subtotal.Currency)
+			continue
+		}
+		stats.TotalBalance = stats.TotalBalance.Add(converted)
+		stats.RatesDate = rates.Date
+		stats.RatesSource = rates.Source
+	}
+}
+
 // getFavoriteCounts fetches favorite counts for all resource types in ONE query
 func (s *DashboardService) getFavoriteCounts(ctx context.Context, userID uuid.UUID) (map[string]int64, error) {
 	type CountRow struct {
@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"errors"
 	"os"
 	"testing"
 	"time"
@@ -10,7 +11,9 @@ import (
 	"github.com/stretchr/testify/assert"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"savvy/internal/exchange"
 	"savvy/internal/models"
+	"savvy/internal/money"
 )
 
 // setupDashboardTestDB creates a test database for dashboard tests
@@ -39,6 +42,8 @@ func setupDashboardTestDB(t *testing.T) *gorm.DB {
 		&models.GiftCard{},
 		&models.GiftCardShare{},
 		&models.GiftCardTransaction{},
+		&models.GiftCardTransactionRevision{},
+		&models.ExchangeRate{},
 		&models.UserFavorite{},
 		&models.AuditLog{},
 	)
@@ -51,7 +56,7 @@ func setupDashboardTestDB(t *testing.T) *gorm.DB {
 
 func TestDashboardService_GetDashboardData_EmptyUser(t *testing.T) {
 	db := setupDashboardTestDB(t)
-	service := NewDashboardService(db)
+	service := NewDashboardService(db, NewExchangeRateService(db))
 	ctx := context.Background()
 
 	// Create a user with no data
@@ -65,7 +70,7 @@ func TestDashboardService_GetDashboardData_EmptyUser(t *testing.T) {
 	defer db.Exec("DELETE FROM users WHERE id = ?", userID)
 
 	// Get dashboard data
-	data, err := service.GetDashboardData(ctx, userID)
+	data, err := service.GetDashboardData(ctx, userID, "")
 
 	// Assertions
 	assert.NoError(t, err)
@@ -74,7 +79,7 @@ func TestDashboardService_GetDashboardData_EmptyUser(t *testing.T) {
 	assert.Equal(t, int64(0), data.Stats.CardsOwned)
 	assert.Equal(t, int64(0), data.Stats.VouchersOwned)
 	assert.Equal(t, int64(0), data.Stats.GiftCardsOwned)
-	assert.Equal(t, 0.0, data.Stats.TotalBalance)
+	assert.Equal(t, money.New(0, "CHF"), data.Stats.TotalBalance)
 	assert.False(t, data.HasFavorites)
 	assert.Empty(t, data.RecentCards)
 	assert.Empty(t, data.RecentVouchers)
@@ -83,7 +88,7 @@ func TestDashboardService_GetDashboardData_EmptyUser(t *testing.T) {
 
 func TestDashboardService_GetDashboardData_WithOwnedItems(t *testing.T) {
 	db := setupDashboardTestDB(t)
-	service := NewDashboardService(db)
+	service := NewDashboardService(db, NewExchangeRateService(db))
 	ctx := context.Background()
 
 	// Create user
@@ -120,15 +125,15 @@ func TestDashboardService_GetDashboardData_WithOwnedItems(t *testing.T) {
 		UserID:         &userID,
 		CardNumber:     "DASH-GIFT-1",
 		MerchantName:   "Test",
-		InitialBalance: 100.0,
-		CurrentBalance: 75.0,
+		InitialBalance: money.New(10000, "CHF"),
+		CurrentBalance: money.New(7500, "CHF"),
 		Currency:       "CHF",
 	}
 	db.Create(giftCard)
 	defer db.Exec("DELETE FROM gift_cards WHERE id = ?", giftCard.ID)
 
 	// Get dashboard data
-	data, err := service.GetDashboardData(ctx, userID)
+	data, err := service.GetDashboardData(ctx, userID, "")
 
 	// Assertions
 	assert.NoError(t, err)
@@ -137,8 +142,8 @@ func TestDashboardService_GetDashboardData_WithOwnedItems(t *testing.T) {
 	assert.Equal(t, int64(1), data.Stats.CardsOwned)
 	assert.Equal(t, int64(1), data.Stats.VouchersOwned)
 	assert.Equal(t, int64(1), data.Stats.GiftCardsOwned)
-	assert.GreaterOrEqual(t, data.Stats.TotalBalance, 75.0) // At least our test card
-	assert.False(t, data.HasFavorites)                      // No favorites yet
+	assert.GreaterOrEqual(t, data.Stats.TotalBalance.Float(), 75.0) // At least our test card
+	assert.False(t, data.HasFavorites)                              // No favorites yet
 	assert.GreaterOrEqual(t, len(data.RecentCards), 1)
 	assert.GreaterOrEqual(t, len(data.RecentVouchers), 1)
 	assert.GreaterOrEqual(t, len(data.RecentGiftCards), 1)
@@ -146,7 +151,7 @@ func TestDashboardService_GetDashboardData_WithOwnedItems(t *testing.T) {
 
 func TestDashboardService_GetDashboardData_WithSharedItems(t *testing.T) {
 	db := setupDashboardTestDB(t)
-	service := NewDashboardService(db)
+	service := NewDashboardService(db, NewExchangeRateService(db))
 	ctx := context.Background()
 
 	// Create owner
@@ -189,7 +194,7 @@ func TestDashboardService_GetDashboardData_WithSharedItems(t *testing.T) {
 	defer db.Exec("DELETE FROM card_shares WHERE id = ?", share.ID)
 
 	// Get dashboard data
-	data, err := service.GetDashboardData(ctx, userID)
+	data, err := service.GetDashboardData(ctx, userID, "")
 
 	// Assertions
 	assert.NoError(t, err)
@@ -201,7 +206,7 @@ func TestDashboardService_GetDashboardData_WithSharedItems(t *testing.T) {
 
 func TestDashboardService_GetDashboardData_WithFavorites(t *testing.T) {
 	db := setupDashboardTestDB(t)
-	service := NewDashboardService(db)
+	service := NewDashboardService(db, NewExchangeRateService(db))
 	ctx := context.Background()
 
 	// Create user
@@ -233,7 +238,7 @@ func TestDashboardService_GetDashboardData_WithFavorites(t *testing.T) {
 	defer db.Exec("DELETE FROM user_favorites WHERE id = ?", favorite.ID)
 
 	// Get dashboard data
-	data, err := service.GetDashboardData(ctx, userID)
+	data, err := service.GetDashboardData(ctx, userID, "")
 
 	// Assertions
 	assert.NoError(t, err)
@@ -246,7 +251,7 @@ func TestDashboardService_GetDashboardData_WithFavorites(t *testing.T) {
 
 func TestDashboardService_GetDashboardData_MixedScenario(t *testing.T) {
 	db := setupDashboardTestDB(t)
-	service := NewDashboardService(db)
+	service := NewDashboardService(db, NewExchangeRateService(db))
 	ctx := context.Background()
 
 	// Create user
@@ -275,8 +280,8 @@ func TestDashboardService_GetDashboardData_MixedScenario(t *testing.T) {
 		UserID:         &userID,
 		CardNumber:     "DASH-MIX-GC1",
 		MerchantName:   "Test",
-		InitialBalance: 100,
-		CurrentBalance: 80,
+		InitialBalance: money.New(10000, "CHF"),
+		CurrentBalance: money.New(8000, "CHF"),
 		Currency:       "CHF",
 	}
 	db.Create(giftCard1)
@@ -286,15 +291,15 @@ func TestDashboardService_GetDashboardData_MixedScenario(t *testing.T) {
 		UserID:         &userID,
 		CardNumber:     "DASH-MIX-GC2",
 		MerchantName:   "Test",
-		InitialBalance: 50,
-		CurrentBalance: 20,
+		InitialBalance: money.New(5000, "CHF"),
+		CurrentBalance: money.New(2000, "CHF"),
 		Currency:       "CHF",
 	}
 	db.Create(giftCard2)
 	defer db.Exec("DELETE FROM gift_cards WHERE id = ?", giftCard2.ID)
 
 	// Get dashboard data
-	data, err := service.GetDashboardData(ctx, userID)
+	data, err := service.GetDashboardData(ctx, userID, "")
 
 	// Assertions
 	assert.NoError(t, err)
@@ -302,7 +307,50 @@ func TestDashboardService_GetDashboardData_MixedScenario(t *testing.T) {
 	assert.NotNil(t, data.Stats)
 	assert.Equal(t, int64(3), data.Stats.CardsOwned)
 	assert.Equal(t, int64(2), data.Stats.GiftCardsOwned)
-	assert.GreaterOrEqual(t, data.Stats.TotalBalance, 100.0) // 80 + 20 = 100
+	assert.GreaterOrEqual(t, data.Stats.TotalBalance.Float(), 100.0) // 80 + 20 = 100
 	assert.GreaterOrEqual(t, len(data.RecentCards), 1)
 	assert.GreaterOrEqual(t, len(data.RecentGiftCards), 1)
 }
+
+// stubExchangeRateService serves fixed rates without a database
+type stubExchangeRateService struct {
+	ExchangeRateServiceInterface
+	rates *exchange.Rates
+	err   error
+}
+
+func (s *stubExchangeRateService) GetRates(_ context.Context) (*exchange.Rates, error) {
+	return s.rates, s.err
+}
+
+func TestDashboardService_ConvertBalances(t *testing.T) {
+	rateDate := time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC)
+	rates := &exchange.Rates{Base: "CHF", Rates: map[string]float64{"EUR": 1.07}, Date: rateDate, Source: exchange.ProviderStatic}
+	service := &DashboardService{exchangeRates: &stubExchangeRateService{rates: rates}}
+	subtotals := []money.Money{money.New(10700, "EUR"), money.New(5000, "CHF"), money.New(1200, "GBP")}
+
+	stats := &DashboardStats{BalanceSubtotals: subtotals}
+	service.convertBalances(context.Background(), stats, "")
+
+	assert.Equal(t, "CHF", stats.DisplayCurrency)
+	assert.Equal(t, money.New(15000, "CHF"), stats.TotalBalance)
+	assert.Equal(t, []string{"GBP"}, stats.MissingRates)
+	assert.Equal(t, rateDate, stats.RatesDate)
+	assert.Equal(t, exchange.ProviderStatic, stats.RatesSource)
+
+	stats = &DashboardStats{BalanceSubtotals: subtotals}
+	service.convertBalances(context.Background(), stats, "EUR")
+
+	assert.Equal(t, money.New(16050, "EUR"), stats.TotalBalance)
+}
+
+func TestDashboardService_ConvertBalances_RatesUnavailable(t *testing.T) {
+	service := &DashboardService{exchangeRates: &stubExchangeRateService{err: errors.New("feed down")}}
+
+	stats := &DashboardStats{BalanceSubtotals: []money.Money{money.New(10700, "EUR"), money.New(5000, "CHF")}}
+	service.convertBalances(context.Background(), stats, "")
+
+	assert.Equal(t, money.New(5000, "CHF"), stats.TotalBalance)
+	assert.Equal(t, []string{"EUR"}, stats.MissingRates)
+	assert.True(t, stats.RatesDate.IsZero())
+}
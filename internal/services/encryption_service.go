@@ -0,0 +1,228 @@
+// Package services contains business logic.
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"savvy/internal/audit"
+	"savvy/internal/encryption"
+
+	"gorm.io/gorm"
+)
+
+// EncryptedColumn describes a column written through the encryption serializers.
+type EncryptedColumn struct {
+	Table      string
+	Column     string
+	Identifier bool // Deterministic, only encrypted with ENCRYPT_IDENTIFIERS
+}
+
+// EncryptedColumns lists all columns with encrypted values. Keep in sync
+// with the serializer tags on the models.
+var EncryptedColumns = []EncryptedColumn{
+	{Table: "gift_cards", Column: "pin"},
+	{Table: "gift_cards", Column: "card_number", Identifier: true},
+	{Table: "cards", Column: "card_number", Identifier: true},
+	{Table: "vouchers", Column: "code", Identifier: true},
+	{Table: "users", Column: "totp_secret"},
+}
+
+// ErrEncryptionDisabled indicates that no encryption keys are configured
+var ErrEncryptionDisabled = errors.New("encryption keys not configured")
+
+// EncryptionColumnStatus counts the stored values of a column by key.
+type EncryptionColumnStatus struct {
+	EncryptedColumn
+	Plaintext int64            // Non-empty values stored unencrypted
+	ByKey     map[string]int64 // Encrypted values per key ID
+}
+
+// ReencryptResult summarizes re-encrypting a column.
+type ReencryptResult struct {
+	EncryptedColumn
+	Scanned int
+	Updated int
+}
+
+// EncryptionServiceInterface defines the interface for maintaining encrypted columns.
+type EncryptionServiceInterface interface {
+	// Status counts plaintext and encrypted values per key for every column.
+	Status(ctx context.Context) ([]EncryptionColumnStatus, error)
+	// Reencrypt rewrites all values with the active key of the keyring, and
+	// encrypts or decrypts identifiers according to its configuration.
+	Reencrypt(ctx context.Context, keyring *encryption.Keyring, dryRun bool) ([]ReencryptResult, error)
+	// RedactAuditLogs removes PINs and masks numbers in existing audit snapshots.
+	RedactAuditLogs(ctx context.Context, dryRun bool) (int, error)
+}
+
+// EncryptionService implements EncryptionServiceInterface.
+type EncryptionService struct {
+	db        *gorm.DB
+	batchSize int
+}
+
+// NewEncryptionService creates a new encryption service.
+func NewEncryptionService(db *gorm.DB) EncryptionServiceInterface {
+	return &EncryptionService{db: db, batchSize: 500}
+}
+
+// storedValue is a raw row of an encrypted column
+type storedValue struct {
+	ID    string
+	Value string
+}
+
+// Status counts plaintext and encrypted values per key for every column.
+func (s *EncryptionService) Status(ctx context.Context) ([]EncryptionColumnStatus, error) {
+	statuses := make([]EncryptionColumnStatus, 0, len(EncryptedColumns))
+
+	for _, col := range EncryptedColumns {
+		status := EncryptionColumnStatus{EncryptedColumn: col, ByKey: make(map[string]int64)}
+
+		if err := s.db.WithContext(ctx).Table(col.Table).
+			Where(fmt.Sprintf("%s <> '' AND %s NOT LIKE 'enc:%%'", col.Column, col.Column)).
+			Count(&status.Plaintext).Error; err != nil {
+			return nil, fmt.Errorf("count %s.%s: %w", col.Table, col.Column, err)
+		}
+
+		var counts []struct {
+			KeyID string
+			Count int64
+		}
+		if err := s.db.WithContext(ctx).Table(col.Table).
+			Select(fmt.Sprintf("split_part(%s, ':', 3) AS key_id, COUNT(*) AS count", col.Column)).
+			Where(fmt.Sprintf("%s LIKE 'enc:%%'", col.Column)).
+			Group("key_id").
+			Scan(&counts).Error; err != nil {
+			return nil, fmt.Errorf("count keys of %s.%s: %w", col.Table, col.Column, err)
+		}
+		for _, c := range counts {
+			status.ByKey[c.KeyID] = c.Count
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+// Reencrypt rewrites all values with the active key. Soft-deleted rows are
+// included so they can still be restored after the old key is removed.
+func (s *EncryptionService) Reencrypt(ctx context.Context, keyring *encryption.Keyring, dryRun bool) ([]ReencryptResult, error) {
+	if keyring == nil {
+		return nil, ErrEncryptionDisabled
+	}
+
+	results := make([]ReencryptResult, 0, len(EncryptedColumns))
+	for _, col := range EncryptedColumns {
+		result := ReencryptResult{EncryptedColumn: col}
+		aad := encryption.ColumnAAD(col.Table, col.Column)
+
+		lastID := ""
+		for {
+			var rows []storedValue
+			query := s.db.WithContext(ctx).Table(col.Table).
+				Select(fmt.Sprintf("id::text AS id, COALESCE(%s, '') AS value", col.Column)).
+				Order("id").
+				Limit(s.batchSize)
+			if lastID != "" {
+				query = query.Where("id > ?", lastID)
+			}
+			if err := query.Scan(&rows).Error; err != nil {
+				return results, fmt.Errorf("read %s.%s: %w", col.Table, col.Column, err)
+			}
+			if len(rows) == 0 {
+				break
+			}
+			lastID = rows[len(rows)-1].ID
+
+			err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+				for _, row := range rows {
+					result.Scanned++
+					value, changed, err := keyring.Rewrap(row.Value, aad, col.Identifier)
+					if err != nil {
+						return fmt.Errorf("%s %s: %w", col.Table, row.ID, err)
+					}
+					if !changed {
+						continue
+					}
+					result.Updated++
+					if dryRun {
+						continue
+					}
+					if err := tx.Table(col.Table).Where("id = ?", row.ID).UpdateColumn(col.Column, value).Error; err != nil {
+						return fmt.Errorf("update %s %s: %w", col.Table, row.ID, err)
+					}
+				}
+				return nil
+			})
+			if err != nil {
+				return results, err
+			}
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// RedactAuditLogs removes PINs and masks numbers in existing audit snapshots.
+// Returns the number of entries that were (or, in a dry run, would be) changed.
+func (s *EncryptionService) RedactAuditLogs(ctx context.Context, dryRun bool) (int, error) {
+	updated := 0
+	lastID := ""
+
+	for {
+		var rows []storedValue
+		query := s.db.WithContext(ctx).Table("audit_logs").
+			Select("id::text AS id, resource_data::text AS value").
+			Where("resource_data IS NOT NULL").
+			Order("id").
+			Limit(s.batchSize)
+		if lastID != "" {
+			query = query.Where("id > ?", lastID)
+		}
+		if err := query.Scan(&rows).Error; err != nil {
+			return updated, fmt.Errorf("read audit logs: %w", err)
+		}
+		if len(rows) == 0 {
+			return updated, nil
+		}
+		lastID = rows[len(rows)-1].ID
+
+		for _, row := range rows {
+			redacted, err := audit.RedactSnapshot([]byte(row.Value))
+			if err != nil {
+				continue // Not JSON, nothing we could redact
+			}
+			if canonicalJSON(row.Value) == string(redacted) {
+				continue
+			}
+			updated++
+			if dryRun {
+				continue
+			}
+			if err := s.db.WithContext(ctx).Table("audit_logs").Where("id = ?", row.ID).
+				UpdateColumn("resource_data", string(redacted)).Error; err != nil {
+				return updated, fmt.Errorf("update audit log %s: %w", row.ID, err)
+			}
+		}
+	}
+}
+
+// canonicalJSON re-encodes a JSON document the way RedactSnapshot does, so
+// unchanged snapshots compare equal regardless of key order and whitespace
+func canonicalJSON(value string) string {
+	var data interface{}
+	if err := json.Unmarshal([]byte(value), &data); err != nil {
+		return value
+	}
+	canonical, err := json.Marshal(data)
+	if err != nil {
+		return value
+	}
+	return string(canonical)
+}
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"savvy/internal/encryption"
+	"savvy/internal/models"
+)
+
+const (
+	testEncryptionKeyOld = "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="
+	testEncryptionKeyNew = "ZmVkY2JhOTg3NjU0MzIxMGZlZGNiYTk4NzY1NDMyMTA="
+)
+
+func TestEncryptionService_Reencrypt(t *testing.T) {
+	db := setupTestDB(t)
+	t.Cleanup(func() { encryption.Init(nil) })
+	ctx := context.Background()
+
+	user := &models.User{ID: uuid.New(), Email: "crypto@example.com", PasswordHash: "hashed"}
+	require.NoError(t, db.Create(user).Error)
+
+	// Rows written with the old key, card number in plaintext
+	oldKeyring, err := encryption.NewKeyring("old:"+testEncryptionKeyOld, false)
+	require.NoError(t, err)
+	encryption.Init(oldKeyring)
+	giftCard := &models.GiftCard{UserID: &user.ID, MerchantName: "Migros", CardNumber: "6006491234567890", PIN: "4711"}
+	require.NoError(t, db.Create(giftCard).Error)
+
+	var storedPIN string
+	require.NoError(t, db.Raw("SELECT pin FROM gift_cards WHERE id = ?", giftCard.ID).Scan(&storedPIN).Error)
+	assert.True(t, strings.HasPrefix(storedPIN, "enc:v1:old:"))
+
+	service := NewEncryptionService(db)
+	rotated, err := encryption.NewKeyring("new:"+testEncryptionKeyNew+",old:"+testEncryptionKeyOld, true)
+	require.NoError(t, err)
+
+	// Dry run only counts
+	results, err := service.Reencrypt(ctx, rotated, true)
+	require.NoError(t, err)
+	require.Len(t, results, len(EncryptedColumns))
+	assert.Equal(t, 1, results[0].Updated)
+	require.NoError(t, db.Raw("SELECT pin FROM gift_cards WHERE id = ?", giftCard.ID).Scan(&storedPIN).Error)
+	assert.True(t, strings.HasPrefix(storedPIN, "enc:v1:old:"))
+
+	_, err = service.Reencrypt(ctx, rotated, false)
+	require.NoError(t, err)
+
+	statuses, err := service.Status(ctx)
+	require.NoError(t, err)
+	for _, status := range statuses {
+		assert.Zero(t, status.Plaintext, status.Table+"."+status.Column)
+		assert.NotContains(t, status.ByKey, "old")
+	}
+
+	// Readable with the new key alone
+	newOnly, err := encryption.NewKeyring("new:"+testEncryptionKeyNew, true)
+	require.NoError(t, err)
+	encryption.Init(newOnly)
+	var loaded models.GiftCard
+	require.NoError(t, db.First(&loaded, "id = ?", giftCard.ID).Error)
+	assert.Equal(t, "4711", loaded.PIN)
+	assert.Equal(t, "6006491234567890", loaded.CardNumber)
+}
+
+func TestEncryptionService_Reencrypt_NoKeyring(t *testing.T) {
+	service := NewEncryptionService(nil)
+
+	_, err := service.Reencrypt(context.Background(), nil, false)
+	assert.ErrorIs(t, err, ErrEncryptionDisabled)
+}
+
+func TestEncryptionService_RedactAuditLogs(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+
+	entry := &models.AuditLog{
+		Action:       "delete",
+		ResourceType: "gift_cards",
+		ResourceID:   uuid.New(),
+		ResourceData: `{"card_number": "6006491234567890", "pin": "4711"}`,
+	}
+	require.NoError(t, db.Create(entry).Error)
+
+	service := NewEncryptionService(db)
+	updated, err := service.RedactAuditLogs(ctx, false)
+	require.NoError(t, err)
+	assert.Equal(t, 1, updated)
+
+	var stored models.AuditLog
+	require.NoError(t, db.First(&stored, "id = ?", entry.ID).Error)
+	assert.NotContains(t, stored.ResourceData, "4711")
+	assert.NotContains(t, stored.ResourceData, "6006491234567890")
+
+	// Already redacted entries are left alone
+	updated, err = service.RedactAuditLogs(ctx, false)
+	require.NoError(t, err)
+	assert.Zero(t, updated)
+}
@@ -0,0 +1,132 @@
+// Package services contains business logic.
+package services
+
+import (
+	"context"
+	"errors"
+	"savvy/internal/exchange"
+	"savvy/internal/models"
+	"savvy/internal/money"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrInvalidExchangeRate indicates a currency code or rate that cannot be stored
+	ErrInvalidExchangeRate = errors.New("invalid exchange rate")
+	// ErrExchangeRatesNotStatic indicates rates are managed by an external feed
+	ErrExchangeRatesNotStatic = errors.New("exchange rates are loaded from an external feed")
+)
+
+// ExchangeRateServiceInterface defines the interface for exchange rate business logic.
+type ExchangeRateServiceInterface interface {
+	// UseProvider replaces the rate provider, e.g. with the one selected in the configuration.
+	UseProvider(provider exchange.Provider)
+	// GetRates returns the current rates. Static rates include the rates stored by admins.
+	GetRates(ctx context.Context) (*exchange.Rates, error)
+	// IsStatic reports whether rates can be maintained in the admin UI.
+	IsStatic() bool
+	ListStaticRates(ctx context.Context) ([]models.ExchangeRate, error)
+	SetStaticRate(ctx context.Context, currency string, rate float64) error
+	DeleteStaticRate(ctx context.Context, currency string) error
+}
+
+// ExchangeRateService implements ExchangeRateServiceInterface.
+type ExchangeRateService struct {
+	db *gorm.DB
+
+	mu       sync.RWMutex
+	provider exchange.Provider
+}
+
+// NewExchangeRateService creates a new exchange rate service with an empty
+// static rate table in the default currency.
+func NewExchangeRateService(db *gorm.DB) ExchangeRateServiceInterface {
+	return &ExchangeRateService{
+		db:       db,
+		provider: exchange.NewStaticProvider(money.DefaultCurrency, nil),
+	}
+}
+
+// UseProvider replaces the rate provider.
+func (s *ExchangeRateService) UseProvider(provider exchange.Provider) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.provider = provider
+}
+
+// IsStatic reports whether the static provider is in use.
+func (s *ExchangeRateService) IsStatic() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.provider.(*exchange.StaticProvider)
+	return ok
+}
+
+// GetRates returns the rates of the provider. For static rates, the rates
+// stored in the database override the configured ones.
+func (s *ExchangeRateService) GetRates(ctx context.Context) (*exchange.Rates, error) {
+	s.mu.RLock()
+	provider := s.provider
+	s.mu.RUnlock()
+
+	rates, err := provider.Rates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := provider.(*exchange.StaticProvider); !ok {
+		return rates, nil
+	}
+
+	stored, err := s.ListStaticRates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, rate := range stored {
+		if rate.Currency == rates.Base {
+			continue
+		}
+		rates.Rates[rate.Currency] = rate.Rate
+		if rate.UpdatedAt.After(rates.Date) {
+			rates.Date = rate.UpdatedAt
+		}
+	}
+
+	return rates, nil
+}
+
+// ListStaticRates returns the rates stored by admins, ordered by currency.
+func (s *ExchangeRateService) ListStaticRates(ctx context.Context) ([]models.ExchangeRate, error) {
+	var rates []models.ExchangeRate
+	err := s.db.WithContext(ctx).Order("currency").Find(&rates).Error
+	return rates, err
+}
+
+// SetStaticRate creates or updates the rate of a currency.
+func (s *ExchangeRateService) SetStaticRate(ctx context.Context, currency string, rate float64) error {
+	if !s.IsStatic() {
+		return ErrExchangeRatesNotStatic
+	}
+
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if len(currency) != 3 || rate <= 0 {
+		return ErrInvalidExchangeRate
+	}
+
+	return s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "currency"}},
+		DoUpdates: clause.AssignmentColumns([]string{"rate", "updated_at"}),
+	}).Create(&models.ExchangeRate{Currency: currency, Rate: rate}).Error
+}
+
+// DeleteStaticRate removes a stored rate; a configured rate takes effect again.
+func (s *ExchangeRateService) DeleteStaticRate(ctx context.Context, currency string) error {
+	if !s.IsStatic() {
+		return ErrExchangeRatesNotStatic
+	}
+
+	return s.db.WithContext(ctx).Where("currency = ?", strings.ToUpper(currency)).Delete(&models.ExchangeRate{}).Error
+}
@@ -0,0 +1,65 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"savvy/internal/exchange"
+	"savvy/internal/money"
+)
+
+func TestExchangeRateService_StaticRatesOverrideConfig(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewExchangeRateService(db)
+	service.UseProvider(exchange.NewStaticProvider("CHF", map[string]float64{"EUR": 1.05, "USD": 1.16}))
+	ctx := context.Background()
+
+	require.NoError(t, service.SetStaticRate(ctx, "eur", 1.07))
+	require.NoError(t, service.SetStaticRate(ctx, "GBP", 0.93))
+	require.NoError(t, service.SetStaticRate(ctx, "GBP", 0.94))
+
+	stored, err := service.ListStaticRates(ctx)
+	require.NoError(t, err)
+	require.Len(t, stored, 2)
+	assert.Equal(t, "EUR", stored[0].Currency)
+
+	rates, err := service.GetRates(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "CHF", rates.Base)
+	assert.InDelta(t, 1.07, rates.Rates["EUR"], 1e-9)
+	assert.InDelta(t, 1.16, rates.Rates["USD"], 1e-9)
+	assert.InDelta(t, 0.94, rates.Rates["GBP"], 1e-9)
+
+	converted, err := rates.Convert(money.New(10700, "EUR"), "CHF")
+	require.NoError(t, err)
+	assert.Equal(t, money.New(10000, "CHF"), converted)
+
+	// Removing the stored rate restores the configured one
+	require.NoError(t, service.DeleteStaticRate(ctx, "EUR"))
+	rates, err = service.GetRates(ctx)
+	require.NoError(t, err)
+	assert.InDelta(t, 1.05, rates.Rates["EUR"], 1e-9)
+}
+
+func TestExchangeRateService_SetStaticRate_Invalid(t *testing.T) {
+	service := NewExchangeRateService(nil)
+	ctx := context.Background()
+
+	assert.ErrorIs(t, service.SetStaticRate(ctx, "EURO", 1.07), ErrInvalidExchangeRate)
+	assert.ErrorIs(t, service.SetStaticRate(ctx, "EUR", 0), ErrInvalidExchangeRate)
+	assert.ErrorIs(t, service.SetStaticRate(ctx, "EUR", -1), ErrInvalidExchangeRate)
+}
+
+func TestExchangeRateService_FeedIsReadOnly(t *testing.T) {
+	service := NewExchangeRateService(nil)
+	assert.True(t, service.IsStatic())
+
+	service.UseProvider(exchange.NewECBProvider("testdata/missing.xml", 0))
+	assert.False(t, service.IsStatic())
+
+	ctx := context.Background()
+	assert.ErrorIs(t, service.SetStaticRate(ctx, "EUR", 1.07), ErrExchangeRatesNotStatic)
+	assert.ErrorIs(t, service.DeleteStaticRate(ctx, "EUR"), ErrExchangeRatesNotStatic)
+}
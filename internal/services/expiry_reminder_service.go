@@ -0,0 +1,127 @@
+// Package services contains business logic.
+package services
+
+import (
+	"context"
+	"log/slog"
+	"savvy/internal/models"
+	"savvy/internal/repository"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ExpiryReminderServiceInterface reminds owners and sharees of vouchers and
+// gift cards before they expire, a configurable number of days ahead
+type ExpiryReminderServiceInterface interface {
+	// GetDays returns the reminder offsets of a user, from the earliest reminder to the latest.
+	GetDays(ctx context.Context, userID uuid.UUID) (models.ReminderDays, error)
+	// UpdateDays replaces the reminder offsets of a user; none turns reminders off.
+	UpdateDays(ctx context.Context, userID uuid.UUID, days models.ReminderDays) error
+	// SendDue creates the reminders that are due at now and returns how many were sent.
+	SendDue(ctx context.Context, now time.Time) (int, error)
+	// DeleteExpired forgets the sent reminders of items that have expired.
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+// ExpiryReminderService implements ExpiryReminderServiceInterface
+type ExpiryReminderService struct {
+	repo          repository.ExpiryReminderRepository
+	notifications NotificationServiceInterface
+}
+
+// NewExpiryReminderService creates a new expiry reminder service
+func NewExpiryReminderService(repo repository.ExpiryReminderRepository, notifications NotificationServiceInterface) ExpiryReminderServiceInterface {
+	return &ExpiryReminderService{repo: repo, notifications: notifications}
+}
+
+func (s *ExpiryReminderService) GetDays(ctx context.Context, userID uuid.UUID) (models.ReminderDays, error) {
+	preferences, err := s.repo.GetPreferences(ctx, []uuid.UUID{userID})
+	if err != nil {
+		return nil, err
+	}
+	if days, ok := preferences[userID]; ok {
+		return days.Normalize(), nil
+	}
+	return models.DefaultExpiryReminderDays, nil
+}
+
+func (s *ExpiryReminderService) UpdateDays(ctx context.Context, userID uuid.UUID, days models.ReminderDays) error {
+	return s.repo.SavePreference(ctx, &models.ExpiryReminderPreference{UserID: userID, Days: days.Normalize()})
+}
+
+// SendDue sends one reminder per user and item when an offset is reached.
+// If several offsets are reached at once, e.g. for an item added two days
+// before it expires, they are recorded together and only one reminder is
+// sent. Reminders are recorded before they are sent, so a failed
+// notification is not repeated on the next run.
+func (s *ExpiryReminderService) SendDue(ctx context.Context, now time.Time) (int, error) {
+	maxDays := slices.Max(models.ExpiryReminderDayOptions)
+	items, err := s.repo.ListExpiring(ctx, now, now.AddDate(0, 0, maxDays+1))
+	if err != nil {
+		return 0, err
+	}
+
+	var userIDs []uuid.UUID
+	for _, item := range items {
+		for _, userID := range item.UserIDs {
+			if !slices.Contains(userIDs, userID) {
+				userIDs = append(userIDs, userID)
+			}
+		}
+	}
+	preferences, err := s.repo.GetPreferences(ctx, userIDs)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, item := range items {
+		daysLeft := daysUntil(now, item.ExpiresAt)
+		for _, userID := range item.UserIDs {
+			days, ok := preferences[userID]
+			if !ok {
+				days = models.DefaultExpiryReminderDays
+			}
+
+			var due []models.ExpiryReminder
+			for _, offset := range days {
+				if daysLeft <= offset {
+					due = append(due, models.ExpiryReminder{
+						UserID:       userID,
+						ResourceType: item.ResourceType,
+						ResourceID:   item.ResourceID,
+						Days:         offset,
+						ExpiresAt:    item.ExpiresAt,
+					})
+				}
+			}
+
+			recorded, err := s.repo.Record(ctx, due)
+			if err != nil {
+				return sent, err
+			}
+			if recorded == 0 {
+				continue
+			}
+			if err := s.notifications.CreateExpiringSoonNotification(ctx, userID, item, daysLeft); err != nil {
+				slog.Warn("Failed to create expiry reminder", "user_id", userID, "resource_type", item.ResourceType, "resource_id", item.ResourceID, "error", err)
+				continue
+			}
+			sent++
+		}
+	}
+	return sent, nil
+}
+
+func (s *ExpiryReminderService) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	return s.repo.DeleteExpiredBefore(ctx, now)
+}
+
+// daysUntil returns the number of calendar days (UTC) from now to the expiry
+// date, 0 if the item expires today
+func daysUntil(now, expiresAt time.Time) int {
+	const day = 24 * time.Hour
+	return int(expiresAt.UTC().Truncate(day).Sub(now.UTC().Truncate(day)) / day)
+}
@@ -0,0 +1,185 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"savvy/internal/models"
+)
+
+// fakeExpiryReminderRepository keeps preferences and sent reminders in memory
+type fakeExpiryReminderRepository struct {
+	preferences map[uuid.UUID]models.ReminderDays
+	items       []models.ExpiringItem
+	sent        map[models.ExpiryReminder]bool
+}
+
+func newFakeExpiryReminderRepository(items ...models.ExpiringItem) *fakeExpiryReminderRepository {
+	return &fakeExpiryReminderRepository{
+		preferences: map[uuid.UUID]models.ReminderDays{},
+		items:       items,
+		sent:        map[models.ExpiryReminder]bool{},
+	}
+}
+
+func (r *fakeExpiryReminderRepository) GetPreferences(_ context.Context, userIDs []uuid.UUID) (map[uuid.UUID]models.ReminderDays, error) {
+	preferences := map[uuid.UUID]models.ReminderDays{}
+	for _, userID := range userIDs {
+		if days, ok := r.preferences[userID]; ok {
+			preferences[userID] = days
+		}
+	}
+	return preferences, nil
+}
+
+func (r *fakeExpiryReminderRepository) SavePreference(_ context.Context, preference *models.ExpiryReminderPreference) error {
+	r.preferences[preference.UserID] = preference.Days
+	return nil
+}
+
+func (r *fakeExpiryReminderRepository) ListExpiring(_ context.Context, from, until time.Time) ([]models.ExpiringItem, error) {
+	var items []models.ExpiringItem
+	for _, item := range r.items {
+		if item.ExpiresAt.After(from) && !item.ExpiresAt.After(until) {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+func (r *fakeExpiryReminderRepository) Record(_ context.Context, reminders []models.ExpiryReminder) (int64, error) {
+	var recorded int64
+	for _, reminder := range reminders {
+		if !r.sent[reminder] {
+			r.sent[reminder] = true
+			recorded++
+		}
+	}
+	return recorded, nil
+}
+
+func (r *fakeExpiryReminderRepository) DeleteExpiredBefore(context.Context, time.Time) (int64, error) {
+	return 0, nil
+}
+
+// fakeReminderNotificationService records expiry reminders
+type fakeReminderNotificationService struct {
+	NotificationServiceInterface
+	reminders []expiringSoonNotification
+}
+
+type expiringSoonNotification struct {
+	userID   uuid.UUID
+	item     models.ExpiringItem
+	daysLeft int
+}
+
+func (s *fakeReminderNotificationService) CreateExpiringSoonNotification(_ context.Context, userID uuid.UUID, item models.ExpiringItem, daysLeft int) error {
+	s.reminders = append(s.reminders, expiringSoonNotification{userID: userID, item: item, daysLeft: daysLeft})
+	return nil
+}
+
+func TestExpiryReminderService_Days(t *testing.T) {
+	service := NewExpiryReminderService(newFakeExpiryReminderRepository(), &fakeReminderNotificationService{})
+	ctx := context.Background()
+	userID := uuid.New()
+
+	days, err := service.GetDays(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, models.DefaultExpiryReminderDays, days)
+
+	require.NoError(t, service.UpdateDays(ctx, userID, models.ReminderDays{1, 14, 5}))
+	days, err = service.GetDays(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, models.ReminderDays{14, 1}, days)
+
+	require.NoError(t, service.UpdateDays(ctx, userID, nil))
+	days, err = service.GetDays(ctx, userID)
+	require.NoError(t, err)
+	assert.Empty(t, days, "Reminders turned off")
+}
+
+func TestExpiryReminderService_SendDue(t *testing.T) {
+	ctx := context.Background()
+	ownerID, shareeID := uuid.New(), uuid.New()
+	now := time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC)
+	voucher := models.ExpiringItem{
+		ResourceType: "voucher",
+		ResourceID:   uuid.New(),
+		Name:         "Migros",
+		ExpiresAt:    time.Date(2026, 11, 15, 23, 59, 59, 0, time.UTC), // 30 days left
+		UserIDs:      []uuid.UUID{ownerID, shareeID},
+	}
+	repo := newFakeExpiryReminderRepository(voucher)
+	repo.preferences[shareeID] = models.ReminderDays{7}
+	notifications := &fakeReminderNotificationService{}
+	service := NewExpiryReminderService(repo, notifications)
+
+	sent, err := service.SendDue(ctx, now)
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent, "Only the owner wants a reminder 30 days ahead")
+	require.Len(t, notifications.reminders, 1)
+	assert.Equal(t, ownerID, notifications.reminders[0].userID)
+	assert.Equal(t, 30, notifications.reminders[0].daysLeft)
+
+	// The next run on the same day sends nothing
+	sent, err = service.SendDue(ctx, now.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Zero(t, sent)
+
+	// Both reach the 7 day reminder
+	sent, err = service.SendDue(ctx, now.AddDate(0, 0, 23))
+	require.NoError(t, err)
+	assert.Equal(t, 2, sent)
+
+	// A new expiry date starts over
+	repo.items[0].ExpiresAt = voucher.ExpiresAt.AddDate(0, 0, 7)
+	sent, err = service.SendDue(ctx, now.AddDate(0, 0, 23))
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent, "The owner's 30 day offset is due again")
+}
+
+func TestExpiryReminderService_SendDueCatchesUp(t *testing.T) {
+	ctx := context.Background()
+	ownerID := uuid.New()
+	now := time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC)
+	giftCard := models.ExpiringItem{
+		ResourceType: "gift_card",
+		ResourceID:   uuid.New(),
+		Name:         "Coop",
+		ExpiresAt:    time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC),
+		UserIDs:      []uuid.UUID{ownerID},
+	}
+	repo := newFakeExpiryReminderRepository(giftCard)
+	notifications := &fakeReminderNotificationService{}
+	service := NewExpiryReminderService(repo, notifications)
+
+	// Added two days before it expires: the 30 and 7 day offsets are due at once
+	sent, err := service.SendDue(ctx, now)
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	assert.Len(t, repo.sent, 2)
+	assert.Equal(t, 2, notifications.reminders[0].daysLeft)
+
+	sent, err = service.SendDue(ctx, now.AddDate(0, 0, 1))
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent, "1 day before")
+
+	// Turned off
+	repo.preferences[ownerID] = models.ReminderDays{}
+	repo.items[0].ExpiresAt = repo.items[0].ExpiresAt.AddDate(0, 0, 1)
+	sent, err = service.SendDue(ctx, now.AddDate(0, 0, 1))
+	require.NoError(t, err)
+	assert.Zero(t, sent)
+}
+
+func TestDaysUntil(t *testing.T) {
+	now := time.Date(2026, 10, 16, 23, 30, 0, 0, time.UTC)
+	assert.Equal(t, 0, daysUntil(now, time.Date(2026, 10, 16, 23, 59, 59, 0, time.UTC)))
+	assert.Equal(t, 1, daysUntil(now, time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, 30, daysUntil(now, time.Date(2026, 11, 15, 12, 0, 0, 0, time.UTC)))
+}
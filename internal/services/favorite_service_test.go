@@ -205,11 +205,6 @@ func (m *MockGiftCardRepositoryFav) Count(ctx context.Context, userID uuid.UUID)
 	return args.Get(0).(int64), args.Error(1)
 }
 
-func (m *MockGiftCardRepositoryFav) GetTotalBalance(ctx context.Context, userID uuid.UUID) (float64, error) {
-	args := m.Called(ctx, userID)
-	return args.Get(0).(float64), args.Error(1)
-}
-
 func (m *MockGiftCardRepositoryFav) CreateTransaction(ctx context.Context, transaction *models.GiftCardTransaction) error {
 	args := m.Called(ctx, transaction)
 	return args.Error(0)
@@ -223,11 +218,24 @@ func (m *MockGiftCardRepositoryFav) GetTransaction(ctx context.Context, transact
 	return args.Get(0).(*models.GiftCardTransaction), args.Error(1)
 }
 
+func (m *MockGiftCardRepositoryFav) UpdateTransaction(ctx context.Context, transaction *models.GiftCardTransaction, userID uuid.UUID) error {
+	args := m.Called(ctx, transaction, userID)
+	return args.Error(0)
+}
+
 func (m *MockGiftCardRepositoryFav) DeleteTransaction(ctx context.Context, transactionID uuid.UUID) error {
 	args := m.Called(ctx, transactionID)
 	return args.Error(0)
 }
 
+func (m *MockGiftCardRepositoryFav) GetPINReveals(ctx context.Context, giftCardID uuid.UUID) ([]models.AuditLog, error) {
+	args := m.Called(ctx, giftCardID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.AuditLog), args.Error(1)
+}
+
 // ============================================================================
 // TESTS
 // ============================================================================
@@ -5,6 +5,7 @@ import (
 	"context"
 	"errors"
 	"savvy/internal/models"
+	"savvy/internal/money"
 	"savvy/internal/repository"
 
 	"github.com/google/uuid"
@@ -19,12 +20,14 @@ type GiftCardServiceInterface interface {
 	UpdateGiftCard(ctx context.Context, giftCard *models.GiftCard) error
 	DeleteGiftCard(ctx context.Context, id uuid.UUID) error
 	CountUserGiftCards(ctx context.Context, userID uuid.UUID) (int64, error)
-	GetTotalBalance(ctx context.Context, userID uuid.UUID) (float64, error)
-	GetCurrentBalance(ctx context.Context, giftCardID uuid.UUID) (float64, error)
+	GetCurrentBalance(ctx context.Context, giftCardID uuid.UUID) (money.Money, error)
 	CanUserAccessGiftCard(ctx context.Context, giftCardID, userID uuid.UUID) (bool, error)
 	CreateTransaction(ctx context.Context, transaction *models.GiftCardTransaction) error
 	GetTransaction(ctx context.Context, transactionID, giftCardID uuid.UUID) (*models.GiftCardTransaction, error)
+	UpdateTransaction(ctx context.Context, transaction *models.GiftCardTransaction, userID uuid.UUID) error
 	DeleteTransaction(ctx context.Context, transactionID uuid.UUID) error
+	// GetPINReveals lists who revealed the PIN of a gift card and when.
+	GetPINReveals(ctx context.Context, giftCardID uuid.UUID) ([]models.AuditLog, error)
 }
 
 // GiftCardService implements GiftCardServiceInterface.
@@ -47,7 +50,7 @@ func (s *GiftCardService) CreateGiftCard(ctx context.Context, giftCard *models.G
 		return errors.New("card number is required")
 	}
 
-	if giftCard.InitialBalance <= 0 {
+	if !giftCard.InitialBalance.IsPositive() {
 		return errors.New("initial balance must be positive")
 	}
 
@@ -60,7 +63,7 @@ func (s *GiftCardService) CreateGiftCard(ctx context.Context, giftCard *models.G
 
 // GetGiftCard retrieves a gift card by ID.
 func (s *GiftCardService) GetGiftCard(ctx context.Context, id uuid.UUID) (*models.GiftCard, error) {
-	return s.repo.GetByID(ctx, id, "Merchant", "User", "Transactions")
+	return s.repo.GetByID(ctx, id, "Merchant", "User", "Transactions", "Transactions.Revisions.EditedByUser")
 }
 
 // GetUserGiftCards retrieves all gift cards for a user (owned + shared).
@@ -88,7 +91,7 @@ func (s *GiftCardService) UpdateGiftCard(ctx context.Context, giftCard *models.G
 		return errors.New("card number is required")
 	}
 
-	if giftCard.InitialBalance <= 0 {
+	if !giftCard.InitialBalance.IsPositive() {
 		return errors.New("initial balance must be positive")
 	}
 
@@ -105,19 +108,14 @@ func (s *GiftCardService) CountUserGiftCards(ctx context.Context, userID uuid.UU
 	return s.repo.Count(ctx, userID)
 }
 
-// GetTotalBalance calculates total balance for a user.
-func (s *GiftCardService) GetTotalBalance(ctx context.Context, userID uuid.UUID) (float64, error) {
-	return s.repo.GetTotalBalance(ctx, userID)
-}
-
 // GetCurrentBalance retrieves the current balance of a gift card.
-func (s *GiftCardService) GetCurrentBalance(ctx context.Context, giftCardID uuid.UUID) (float64, error) {
+func (s *GiftCardService) GetCurrentBalance(ctx context.Context, giftCardID uuid.UUID) (money.Money, error) {
 	giftCard, err := s.GetGiftCard(ctx, giftCardID)
 	if err != nil {
-		return 0, err
+		return money.Money{}, err
 	}
 
-	return giftCard.CurrentBalance, nil
+	return giftCard.GetCurrentBalance(), nil
 }
 
 // CanUserAccessGiftCard checks if a user can access a gift card (owner or shared).
@@ -140,9 +138,14 @@ func (s *GiftCardService) CanUserAccessGiftCard(ctx context.Context, giftCardID,
 }
 
 // CreateTransaction creates a new transaction for a gift card.
+// Transactions without kind are purchases.
 func (s *GiftCardService) CreateTransaction(ctx context.Context, transaction *models.GiftCardTransaction) error {
-	if transaction.Amount <= 0 {
-		return errors.New("transaction amount must be positive")
+	if transaction.Kind == "" {
+		transaction.Kind = models.TransactionKindPurchase
+	}
+
+	if err := validateTransaction(transaction); err != nil {
+		return err
 	}
 
 	return s.repo.CreateTransaction(ctx, transaction)
@@ -153,7 +156,40 @@ func (s *GiftCardService) GetTransaction(ctx context.Context, transactionID, gif
 	return s.repo.GetTransaction(ctx, transactionID, giftCardID)
 }
 
+// UpdateTransaction saves an edited transaction and keeps its previous values
+// as revision. The database trigger rejects edits that make the balance negative.
+func (s *GiftCardService) UpdateTransaction(ctx context.Context, transaction *models.GiftCardTransaction, userID uuid.UUID) error {
+	if err := validateTransaction(transaction); err != nil {
+		return err
+	}
+
+	return s.repo.UpdateTransaction(ctx, transaction, userID)
+}
+
 // DeleteTransaction deletes a transaction by ID.
 func (s *GiftCardService) DeleteTransaction(ctx context.Context, transactionID uuid.UUID) error {
 	return s.repo.DeleteTransaction(ctx, transactionID)
 }
+
+// GetPINReveals lists who revealed the PIN of a gift card and when, newest first.
+func (s *GiftCardService) GetPINReveals(ctx context.Context, giftCardID uuid.UUID) ([]models.AuditLog, error) {
+	return s.repo.GetPINReveals(ctx, giftCardID)
+}
+
+// validateTransaction checks the kind and the sign of the amount.
+func validateTransaction(transaction *models.GiftCardTransaction) error {
+	if !models.IsValidTransactionKind(transaction.Kind) {
+		return errors.New("invalid transaction kind")
+	}
+
+	// Adjustments are signed, all other kinds use positive amounts
+	if transaction.Kind == models.TransactionKindAdjustment {
+		if transaction.Amount.IsZero() {
+			return errors.New("adjustment amount must not be zero")
+		}
+	} else if !transaction.Amount.IsPositive() {
+		return errors.New("transaction amount must be positive")
+	}
+
+	return nil
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/stretchr/testify/mock"
 	"gorm.io/gorm"
 	"savvy/internal/models"
+	"savvy/internal/money"
 	"savvy/internal/repository"
 )
 
@@ -60,11 +61,6 @@ func (m *MockGiftCardRepository) Count(ctx context.Context, userID uuid.UUID) (i
 	return args.Get(0).(int64), args.Error(1)
 }
 
-func (m *MockGiftCardRepository) GetTotalBalance(ctx context.Context, userID uuid.UUID) (float64, error) {
-	args := m.Called(ctx, userID)
-	return args.Get(0).(float64), args.Error(1)
-}
-
 func (m *MockGiftCardRepository) CreateTransaction(ctx context.Context, transaction *models.GiftCardTransaction) error {
 	args := m.Called(ctx, transaction)
 	return args.Error(0)
@@ -78,11 +74,24 @@ func (m *MockGiftCardRepository) GetTransaction(ctx context.Context, transaction
 	return args.Get(0).(*models.GiftCardTransaction), args.Error(1)
 }
 
+func (m *MockGiftCardRepository) UpdateTransaction(ctx context.Context, transaction *models.GiftCardTransaction, userID uuid.UUID) error {
+	args := m.Called(ctx, transaction, userID)
+	return args.Error(0)
+}
+
 func (m *MockGiftCardRepository) DeleteTransaction(ctx context.Context, transactionID uuid.UUID) error {
 	args := m.Called(ctx, transactionID)
 	return args.Error(0)
 }
 
+func (m *MockGiftCardRepository) GetPINReveals(ctx context.Context, giftCardID uuid.UUID) ([]models.AuditLog, error) {
+	args := m.Called(ctx, giftCardID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.AuditLog), args.Error(1)
+}
+
 var _ repository.GiftCardRepository = (*MockGiftCardRepository)(nil)
 
 func TestGiftCardService_CreateGiftCard_Success(t *testing.T) {
@@ -95,7 +104,7 @@ func TestGiftCardService_CreateGiftCard_Success(t *testing.T) {
 		UserID:         &userID,
 		CardNumber:     "1234567890",
 		MerchantName:   "Test Store",
-		InitialBalance: 100.0,
+		InitialBalance: money.New(10000, "CHF"),
 		Currency:       "CHF",
 	}
 
@@ -117,7 +126,7 @@ func TestGiftCardService_CreateGiftCard_DefaultCurrency(t *testing.T) {
 		UserID:         &userID,
 		CardNumber:     "1234567890",
 		MerchantName:   "Test Store",
-		InitialBalance: 100.0,
+		InitialBalance: money.New(10000, "CHF"),
 	}
 
 	mockRepo.On("Create", ctx, giftCard).Return(nil)
@@ -137,7 +146,7 @@ func TestGiftCardService_CreateGiftCard_MissingMerchantName(t *testing.T) {
 	giftCard := &models.GiftCard{
 		UserID:         &userID,
 		CardNumber:     "1234567890",
-		InitialBalance: 100.0,
+		InitialBalance: money.New(10000, "CHF"),
 	}
 
 	err := service.CreateGiftCard(ctx, giftCard)
@@ -156,7 +165,7 @@ func TestGiftCardService_CreateGiftCard_MissingCardNumber(t *testing.T) {
 	giftCard := &models.GiftCard{
 		UserID:         &userID,
 		MerchantName:   "Test Store",
-		InitialBalance: 100.0,
+		InitialBalance: money.New(10000, "CHF"),
 	}
 
 	err := service.CreateGiftCard(ctx, giftCard)
@@ -175,7 +184,7 @@ func TestGiftCardService_CreateGiftCard_InvalidBalance(t *testing.T) {
 		UserID:         &userID,
 		CardNumber:     "1234567890",
 		MerchantName:   "Test Store",
-		InitialBalance: 0,
+		InitialBalance: money.New(0, "CHF"),
 	}
 
 	err := service.CreateGiftCard(ctx, giftCard)
@@ -196,11 +205,11 @@ func TestGiftCardService_GetGiftCard_Success(t *testing.T) {
 		UserID:         &userID,
 		CardNumber:     "1234567890",
 		MerchantName:   "Test Store",
-		InitialBalance: 100.0,
-		CurrentBalance: 75.0,
+		InitialBalance: money.New(10000, "CHF"),
+		CurrentBalance: money.New(7500, "CHF"),
 	}
 
-	mockRepo.On("GetByID", ctx, giftCardID, []string{"Merchant", "User", "Transactions"}).Return(expectedGiftCard, nil)
+	mockRepo.On("GetByID", ctx, giftCardID, []string{"Merchant", "User", "Transactions", "Transactions.Revisions.EditedByUser"}).Return(expectedGiftCard, nil)
 
 	giftCard, err := service.GetGiftCard(ctx, giftCardID)
 
@@ -215,7 +224,7 @@ func TestGiftCardService_GetGiftCard_NotFound(t *testing.T) {
 
 	giftCardID := uuid.New()
 
-	mockRepo.On("GetByID", ctx, giftCardID, []string{"Merchant", "User", "Transactions"}).Return(nil, gorm.ErrRecordNotFound)
+	mockRepo.On("GetByID", ctx, giftCardID, []string{"Merchant", "User", "Transactions", "Transactions.Revisions.EditedByUser"}).Return(nil, gorm.ErrRecordNotFound)
 
 	giftCard, err := service.GetGiftCard(ctx, giftCardID)
 
@@ -261,7 +270,7 @@ func TestGiftCardService_UpdateGiftCard_Success(t *testing.T) {
 		UserID:         &userID,
 		CardNumber:     "9999",
 		MerchantName:   "Updated Store",
-		InitialBalance: 200.0,
+		InitialBalance: money.New(20000, "CHF"),
 	}
 
 	mockRepo.On("Update", ctx, giftCard).Return(nil)
@@ -301,22 +310,6 @@ func TestGiftCardService_CountUserGiftCards_Success(t *testing.T) {
 	assert.Equal(t, expectedCount, count)
 }
 
-func TestGiftCardService_GetTotalBalance_Success(t *testing.T) {
-	mockRepo := new(MockGiftCardRepository)
-	service := NewGiftCardService(mockRepo)
-	ctx := context.Background()
-
-	userID := uuid.New()
-	expectedBalance := 350.75
-
-	mockRepo.On("GetTotalBalance", ctx, userID).Return(expectedBalance, nil)
-
-	balance, err := service.GetTotalBalance(ctx, userID)
-
-	assert.NoError(t, err)
-	assert.Equal(t, expectedBalance, balance)
-}
-
 func TestGiftCardService_GetCurrentBalance_Success(t *testing.T) {
 	mockRepo := new(MockGiftCardRepository)
 	service := NewGiftCardService(mockRepo)
@@ -327,15 +320,16 @@ func TestGiftCardService_GetCurrentBalance_Success(t *testing.T) {
 	giftCard := &models.GiftCard{
 		ID:             giftCardID,
 		UserID:         &userID,
-		CurrentBalance: 75.50,
+		CurrentBalance: money.New(7550, "CHF"),
+		Currency:       "CHF",
 	}
 
-	mockRepo.On("GetByID", ctx, giftCardID, []string{"Merchant", "User", "Transactions"}).Return(giftCard, nil)
+	mockRepo.On("GetByID", ctx, giftCardID, []string{"Merchant", "User", "Transactions", "Transactions.Revisions.EditedByUser"}).Return(giftCard, nil)
 
 	balance, err := service.GetCurrentBalance(ctx, giftCardID)
 
 	assert.NoError(t, err)
-	assert.Equal(t, 75.50, balance)
+	assert.Equal(t, money.New(7550, "CHF"), balance)
 }
 
 func TestGiftCardService_CanUserAccessGiftCard_Owner(t *testing.T) {
@@ -350,13 +344,94 @@ func TestGiftCardService_CanUserAccessGiftCard_Owner(t *testing.T) {
 		UserID:         &userID,
 		CardNumber:     "1234567890",
 		MerchantName:   "Test Store",
-		InitialBalance: 100.0,
+		InitialBalance: money.New(10000, "CHF"),
 	}
 
-	mockRepo.On("GetByID", ctx, giftCardID, []string{"Merchant", "User", "Transactions"}).Return(giftCard, nil)
+	mockRepo.On("GetByID", ctx, giftCardID, []string{"Merchant", "User", "Transactions", "Transactions.Revisions.EditedByUser"}).Return(giftCard, nil)
 
 	canAccess, err := service.CanUserAccessGiftCard(ctx, giftCardID, userID)
 
 	assert.NoError(t, err)
 	assert.True(t, canAccess)
 }
+
+func TestGiftCardService_CreateTransaction_DefaultsToPurchase(t *testing.T) {
+	mockRepo := new(MockGiftCardRepository)
+	service := NewGiftCardService(mockRepo)
+	ctx := context.Background()
+
+	transaction := &models.GiftCardTransaction{GiftCardID: uuid.New(), Amount: money.New(2500, "CHF")}
+	mockRepo.On("CreateTransaction", ctx, transaction).Return(nil)
+
+	err := service.CreateTransaction(ctx, transaction)
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.TransactionKindPurchase, transaction.Kind)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGiftCardService_CreateTransaction_Kinds(t *testing.T) {
+	tests := []struct {
+		name    string
+		kind    string
+		amount  int64
+		wantErr string
+	}{
+		{"reload", models.TransactionKindReload, 5000, ""},
+		{"refund", models.TransactionKindRefund, 1990, ""},
+		{"negative adjustment", models.TransactionKindAdjustment, -150, ""},
+		{"negative purchase", models.TransactionKindPurchase, -2500, "transaction amount must be positive"},
+		{"zero reload", models.TransactionKindReload, 0, "transaction amount must be positive"},
+		{"zero adjustment", models.TransactionKindAdjustment, 0, "adjustment amount must not be zero"},
+		{"unknown kind", "withdrawal", 1000, "invalid transaction kind"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockGiftCardRepository)
+			service := NewGiftCardService(mockRepo)
+			ctx := context.Background()
+
+			transaction := &models.GiftCardTransaction{GiftCardID: uuid.New(), Kind: tt.kind, Amount: money.New(tt.amount, "CHF")}
+			mockRepo.On("CreateTransaction", ctx, transaction).Return(nil)
+
+			err := service.CreateTransaction(ctx, transaction)
+
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				mockRepo.AssertExpectations(t)
+				return
+			}
+			assert.EqualError(t, err, tt.wantErr)
+			mockRepo.AssertNotCalled(t, "CreateTransaction", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestGiftCardService_UpdateTransaction_Success(t *testing.T) {
+	mockRepo := new(MockGiftCardRepository)
+	service := NewGiftCardService(mockRepo)
+	ctx := context.Background()
+
+	userID := uuid.New()
+	transaction := &models.GiftCardTransaction{ID: uuid.New(), GiftCardID: uuid.New(), Kind: models.TransactionKindPurchase, Amount: money.New(1800, "CHF")}
+	mockRepo.On("UpdateTransaction", ctx, transaction, userID).Return(nil)
+
+	err := service.UpdateTransaction(ctx, transaction, userID)
+
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGiftCardService_UpdateTransaction_InvalidAmount(t *testing.T) {
+	mockRepo := new(MockGiftCardRepository)
+	service := NewGiftCardService(mockRepo)
+	ctx := context.Background()
+
+	transaction := &models.GiftCardTransaction{ID: uuid.New(), GiftCardID: uuid.New(), Kind: models.TransactionKindReload, Amount: money.New(-500, "CHF")}
+
+	err := service.UpdateTransaction(ctx, transaction, uuid.New())
+
+	assert.EqualError(t, err, "transaction amount must be positive")
+	mockRepo.AssertNotCalled(t, "UpdateTransaction", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,273 @@
+// Package services contains business logic.
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"unicode"
+
+	"savvy/internal/database"
+	"savvy/internal/importer"
+	"savvy/internal/models"
+	"savvy/internal/validation"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+// minMerchantSimilarity is the score a merchant name must reach to be linked
+const minMerchantSimilarity = 0.8
+
+// ImportServiceInterface defines the interface for importing cards from other apps.
+type ImportServiceInterface interface {
+	// PreviewCards matches merchants and validates parsed records without writing anything.
+	PreviewCards(ctx context.Context, records []importer.Record) (*CardImportPreview, error)
+
+	// ImportCards creates the valid rows of a preview for the given user.
+	ImportCards(ctx context.Context, userID uuid.UUID, preview *CardImportPreview) (*CardImportResult, error)
+}
+
+// CardImportRow is a previewed import record.
+type CardImportRow struct {
+	Record       importer.Record
+	Request      validation.CardRequest
+	Merchant     *models.Merchant  // Matched merchant, nil if the free-text name is used
+	BarcodeExact bool              // False if the source barcode format had to be substituted
+	Errors       map[string]string // Field → failed validation rule
+}
+
+// Valid reports whether the row passed validation.
+func (r *CardImportRow) Valid() bool {
+	return len(r.Errors) == 0
+}
+
+// CardImportPreview is the dry-run result of an import.
+type CardImportPreview struct {
+	Rows []CardImportRow
+}
+
+// ValidCount returns the number of rows that will be imported.
+func (p *CardImportPreview) ValidCount() int {
+	count := 0
+	for i := range p.Rows {
+		if p.Rows[i].Valid() {
+			count++
+		}
+	}
+	return count
+}
+
+// CardImportResult summarizes a committed import.
+type CardImportResult struct {
+	Created    int
+	Invalid    int   // Rows skipped because of validation errors
+	Duplicates []int // Source lines skipped because the card number already exists
+}
+
+// ImportService implements ImportServiceInterface.
+type ImportService struct {
+	cardService     CardServiceInterface
+	merchantService MerchantServiceInterface
+}
+
+// NewImportService creates a new import service.
+func NewImportService(cardService CardServiceInterface, merchantService MerchantServiceInterface) ImportServiceInterface {
+	return &ImportService{
+		cardService:     cardService,
+		merchantService: merchantService,
+	}
+}
+
+// PreviewCards matches merchants and validates parsed records without writing anything.
+func (s *ImportService) PreviewCards(ctx context.Context, records []importer.Record) (*CardImportPreview, error) {
+	preview := &CardImportPreview{Rows: make([]CardImportRow, 0, len(records))}
+	merchants := make(map[string]*models.Merchant) // Match cache by normalized name
+	seen := make(map[string]bool)                  // Card numbers within this file
+
+	for _, record := range records {
+		barcodeType, exact := importer.BarcodeType(record.BarcodeType)
+		row := CardImportRow{
+			Record: record,
+			Request: validation.CardRequest{
+				MerchantName: strings.TrimSpace(record.MerchantName),
+				Program:      strings.TrimSpace(record.Program),
+				CardNumber:   importer.CardNumber(record),
+				BarcodeType:  barcodeType,
+				Notes:        strings.TrimSpace(record.Notes),
+				Status:       strings.TrimSpace(record.Status),
+			},
+			BarcodeExact: exact,
+		}
+
+		key := normalizeMerchantName(row.Request.MerchantName)
+		if key != "" {
+			merchant, cached := merchants[key]
+			if !cached {
+				var err error
+				merchant, err = s.matchMerchant(ctx, row.Request.MerchantName)
+				if err != nil {
+					return nil, err
+				}
+				merchants[key] = merchant
+			}
+			if merchant != nil {
+				row.Merchant = merchant
+				row.Request.MerchantID = merchant.ID.String()
+				row.Request.MerchantName = merchant.Name
+			}
+		}
+
+		row.Errors = validateCardRequest(row.Request)
+		if number := row.Request.CardNumber; number != "" {
+			if seen[number] {
+				row.Errors["card_number"] = "unique"
+			}
+			seen[number] = true
+		}
+
+		preview.Rows = append(preview.Rows, row)
+	}
+	return preview, nil
+}
+
+// ImportCards creates the valid rows of a preview for the given user.
+// Card numbers that already exist are skipped; other errors abort the import
+// and return the result so far.
+func (s *ImportService) ImportCards(ctx context.Context, userID uuid.UUID, preview *CardImportPreview) (*CardImportResult, error) {
+	result := &CardImportResult{}
+
+	for i := range preview.Rows {
+		row := &preview.Rows[i]
+		if !row.Valid() {
+			result.Invalid++
+			continue
+		}
+
+		card := &models.Card{
+			UserID:       &userID,
+			MerchantName: row.Request.MerchantName,
+			Program:      row.Request.Program,
+			CardNumber:   row.Request.CardNumber,
+			BarcodeType:  row.Request.BarcodeType,
+			Notes:        row.Request.Notes,
+			Status:       row.Request.Status,
+		}
+		if row.Merchant != nil {
+			card.MerchantID = &row.Merchant.ID
+		}
+
+		if err := s.cardService.CreateCard(ctx, card); err != nil {
+			if database.IsDuplicateError(err) {
+				result.Duplicates = append(result.Duplicates, row.Record.Line)
+				continue
+			}
+			return result, err
+		}
+		result.Created++
+	}
+	return result, nil
+}
+
+// matchMerchant finds the merchant whose name is most similar to name, or nil
+// if none is similar enough. Candidates come from a substring search for the
+// full name and, failing that, for each of its words.
+func (s *ImportService) matchMerchant(ctx context.Context, name string) (*models.Merchant, error) {
+	candidates, err := s.merchantService.SearchMerchants(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if len(candidates) == 0 {
+		for _, word := range strings.Fields(name) {
+			if len([]rune(word)) < 3 {
+				continue
+			}
+			found, err := s.merchantService.SearchMerchants(ctx, word)
+			if err != nil {
+				return nil, err
+			}
+			candidates = append(candidates, found...)
+		}
+	}
+
+	var best *models.Merchant
+	bestScore := 0.0
+	for i := range candidates {
+		if score := merchantSimilarity(name, candidates[i].Name); score > bestScore {
+			best, bestScore = &candidates[i], score
+		}
+	}
+	if bestScore < minMerchantSimilarity {
+		return nil, nil
+	}
+	return best, nil
+}
+
+// validateCardRequest returns the failed rules of req by JSON field name
+func validateCardRequest(req validation.CardRequest) map[string]string {
+	errs := make(map[string]string)
+	var validationErrs validator.ValidationErrors
+	if err := validation.ValidateStruct(req); errors.As(err, &validationErrs) {
+		for _, fe := range validationErrs {
+			errs[fe.Field()] = fe.Tag()
+		}
+	}
+	return errs
+}
+
+// normalizeMerchantName lowercases a name and strips everything but letters and digits
+func normalizeMerchantName(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// merchantSimilarity scores two merchant names between 0 and 1. Names that
+// differ only in case, spacing or punctuation score 1; a merchant whose full
+// name is one word of the imported name ("Migros" for "Migros Cumulus") scores
+// 0.9; everything else is scored by edit distance.
+func merchantSimilarity(imported, merchant string) float64 {
+	a, b := normalizeMerchantName(imported), normalizeMerchantName(merchant)
+	if a == "" || b == "" {
+		return 0
+	}
+	if a == b {
+		return 1
+	}
+	if len([]rune(b)) >= 4 {
+		for _, word := range strings.Fields(imported) {
+			if normalizeMerchantName(word) == b {
+				return 0.9
+			}
+		}
+	}
+
+	ra, rb := []rune(a), []rune(b)
+	longest := max(len(ra), len(rb))
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+// levenshtein returns the edit distance between a and b
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
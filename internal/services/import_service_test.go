@@ -0,0 +1,165 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"savvy/internal/database"
+	"savvy/internal/importer"
+	"savvy/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestImportService() (ImportServiceInterface, *MockCardRepository, *MockMerchantRepository) {
+	cardRepo := new(MockCardRepository)
+	merchantRepo := new(MockMerchantRepository)
+	return NewImportService(NewCardService(cardRepo), NewMerchantService(merchantRepo)), cardRepo, merchantRepo
+}
+
+func TestImportService_PreviewCards_MatchesMerchants(t *testing.T) {
+	service, _, merchantRepo := newTestImportService()
+	ctx := context.Background()
+	migros := models.Merchant{ID: uuid.New(), Name: "Migros"}
+	coop := models.Merchant{ID: uuid.New(), Name: "Coop"}
+
+	merchantRepo.On("Search", ctx, "migros").Return([]models.Merchant{migros}, nil).Once()
+	merchantRepo.On("Search", ctx, "Coop Supercard").Return([]models.Merchant{}, nil).Once()
+	merchantRepo.On("Search", ctx, "Coop").Return([]models.Merchant{coop}, nil).Once()
+	merchantRepo.On("Search", ctx, "Supercard").Return([]models.Merchant{}, nil).Once()
+	merchantRepo.On("Search", ctx, "Bäckerei Huber").Return([]models.Merchant{}, nil).Once()
+	merchantRepo.On("Search", ctx, "Bäckerei").Return([]models.Merchant{}, nil).Once()
+	merchantRepo.On("Search", ctx, "Huber").Return([]models.Merchant{}, nil).Once()
+
+	preview, err := service.PreviewCards(ctx, []importer.Record{
+		{Line: 2, MerchantName: "migros", Program: "Cumulus", CardNumber: "2099123456789", BarcodeType: "EAN_13", Status: "active"},
+		{Line: 3, MerchantName: "Coop Supercard", Program: "Supercard", CardNumber: "1234", BarcodeType: "AZTEC", Status: "active"},
+		{Line: 4, MerchantName: "Bäckerei Huber", Program: "Stempelkarte", CardNumber: "55", Status: "inactive"},
+		{Line: 5, MerchantName: "MIGROS", Program: "Cumulus", CardNumber: "2099000000000", BarcodeType: "EAN_13", Status: "active"},
+	})
+	require.NoError(t, err)
+	require.Len(t, preview.Rows, 4)
+	assert.Equal(t, 4, preview.ValidCount())
+
+	assert.Equal(t, &migros, preview.Rows[0].Merchant)
+	assert.Equal(t, migros.ID.String(), preview.Rows[0].Request.MerchantID)
+	assert.Equal(t, "Migros", preview.Rows[0].Request.MerchantName)
+	assert.Equal(t, "EAN13", preview.Rows[0].Request.BarcodeType)
+	assert.True(t, preview.Rows[0].BarcodeExact)
+
+	assert.Equal(t, &coop, preview.Rows[1].Merchant)
+	assert.Equal(t, "QR", preview.Rows[1].Request.BarcodeType)
+	assert.False(t, preview.Rows[1].BarcodeExact)
+
+	// No match: keep the free-text name
+	assert.Nil(t, preview.Rows[2].Merchant)
+	assert.Empty(t, preview.Rows[2].Request.MerchantID)
+	assert.Equal(t, "Bäckerei Huber", preview.Rows[2].Request.MerchantName)
+	assert.Equal(t, "CODE128", preview.Rows[2].Request.BarcodeType)
+
+	// Second "Migros" row is served from the match cache (Search called once)
+	assert.Equal(t, &migros, preview.Rows[3].Merchant)
+	merchantRepo.AssertExpectations(t)
+}
+
+func TestImportService_PreviewCards_ValidationErrors(t *testing.T) {
+	service, _, merchantRepo := newTestImportService()
+	ctx := context.Background()
+	merchantRepo.On("Search", ctx, mock.Anything).Return([]models.Merchant{}, nil)
+
+	preview, err := service.PreviewCards(ctx, []importer.Record{
+		{Line: 2, MerchantName: "IKEA", Program: "", CardNumber: "9000", Status: "active"},
+		{Line: 3, MerchantName: "", Program: "Family", CardNumber: "9001", Status: "active"},
+		{Line: 4, MerchantName: "IKEA", Program: "Family", CardNumber: "9002", Status: "gesperrt"},
+		{Line: 5, MerchantName: "IKEA", Program: "Family", CardNumber: "9000", Status: "active"},
+		{Line: 6, MerchantName: "IKEA", Program: "Family", CardNumber: "9003", Status: "active"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]string{"program": "required"}, preview.Rows[0].Errors)
+	assert.Equal(t, map[string]string{"merchant_name": "required_without"}, preview.Rows[1].Errors)
+	assert.Equal(t, map[string]string{"status": "oneof"}, preview.Rows[2].Errors)
+	assert.Equal(t, map[string]string{"card_number": "unique"}, preview.Rows[3].Errors)
+	assert.True(t, preview.Rows[4].Valid())
+	assert.Equal(t, 1, preview.ValidCount())
+}
+
+func TestImportService_PreviewCards_SearchError(t *testing.T) {
+	service, _, merchantRepo := newTestImportService()
+	ctx := context.Background()
+	merchantRepo.On("Search", ctx, "IKEA").Return(nil, errors.New("connection refused"))
+
+	_, err := service.PreviewCards(ctx, []importer.Record{{MerchantName: "IKEA", CardNumber: "1"}})
+	assert.Error(t, err)
+}
+
+func TestImportService_ImportCards(t *testing.T) {
+	service, cardRepo, _ := newTestImportService()
+	ctx := context.Background()
+	userID := uuid.New()
+	merchant := &models.Merchant{ID: uuid.New(), Name: "Migros"}
+
+	preview := &CardImportPreview{Rows: []CardImportRow{
+		{Record: importer.Record{Line: 2}, Merchant: merchant},
+		{Record: importer.Record{Line: 3}, Errors: map[string]string{"program": "required"}},
+		{Record: importer.Record{Line: 4}},
+	}}
+	preview.Rows[0].Request.MerchantName = "Migros"
+	preview.Rows[0].Request.Program = "Cumulus"
+	preview.Rows[0].Request.CardNumber = "2099123456789"
+	preview.Rows[0].Request.BarcodeType = "EAN13"
+	preview.Rows[0].Request.Status = "active"
+	preview.Rows[2].Request.MerchantName = "IKEA"
+	preview.Rows[2].Request.Program = "Family"
+	preview.Rows[2].Request.CardNumber = "9000"
+
+	cardRepo.On("Create", ctx, mock.MatchedBy(func(card *models.Card) bool {
+		return card.CardNumber == "2099123456789"
+	})).Return(nil).Once()
+	cardRepo.On("Create", ctx, mock.MatchedBy(func(card *models.Card) bool {
+		return card.CardNumber == "9000"
+	})).Return(database.ErrDuplicateKey).Once()
+
+	result, err := service.ImportCards(ctx, userID, preview)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Created)
+	assert.Equal(t, 1, result.Invalid)
+	assert.Equal(t, []int{4}, result.Duplicates)
+
+	created := cardRepo.Calls[0].Arguments.Get(1).(*models.Card)
+	assert.Equal(t, userID, *created.UserID)
+	assert.Equal(t, merchant.ID, *created.MerchantID)
+	assert.Equal(t, "Cumulus", created.Program)
+	cardRepo.AssertExpectations(t)
+}
+
+func TestImportService_ImportCards_AbortsOnError(t *testing.T) {
+	service, cardRepo, _ := newTestImportService()
+	ctx := context.Background()
+
+	preview := &CardImportPreview{Rows: []CardImportRow{{}, {}}}
+	for i := range preview.Rows {
+		preview.Rows[i].Request.MerchantName = "IKEA"
+		preview.Rows[i].Request.CardNumber = "9000"
+	}
+	cardRepo.On("Create", ctx, mock.Anything).Return(errors.New("connection reset")).Once()
+
+	result, err := service.ImportCards(ctx, uuid.New(), preview)
+	assert.Error(t, err)
+	assert.Equal(t, 0, result.Created)
+	cardRepo.AssertNumberOfCalls(t, "Create", 1)
+}
+
+func TestMerchantSimilarity(t *testing.T) {
+	assert.Equal(t, 1.0, merchantSimilarity("MIGROS", "Migros"))
+	assert.Equal(t, 1.0, merchantSimilarity("Lidl (Schweiz)", "Lidl Schweiz"))
+	assert.Equal(t, 0.9, merchantSimilarity("Migros Cumulus", "Migros"))
+	assert.GreaterOrEqual(t, merchantSimilarity("Mövenpik", "Mövenpick"), minMerchantSimilarity)
+	assert.Less(t, merchantSimilarity("Coop Pronto", "Coop City"), minMerchantSimilarity)
+	assert.Less(t, merchantSimilarity("dm Drogerie", "dm"), minMerchantSimilarity)
+	assert.Zero(t, merchantSimilarity("", "Migros"))
+}
@@ -0,0 +1,59 @@
+// Package services contains business logic.
+package services
+
+import (
+	"context"
+	"log/slog"
+	"savvy/internal/metrics"
+	"savvy/internal/models"
+	"savvy/internal/repository"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ItemLifecycleServiceInterface keeps the stored status of vouchers and gift
+// cards in line with their expiry date and balance
+type ItemLifecycleServiceInterface interface {
+	// ApplyTransitions expires, redeems or renews the items whose status is
+	// out of date at now and returns how many changed.
+	ApplyTransitions(ctx context.Context, now time.Time) (int, error)
+	// GetHistory returns the status changes of an item, newest first.
+	GetHistory(ctx context.Context, resourceType string, resourceID uuid.UUID) ([]models.ItemStatusChange, error)
+	// CountByStatus counts the vouchers and gift cards per status.
+	CountByStatus(ctx context.Context) ([]models.ItemStatusCount, error)
+}
+
+// ItemLifecycleService implements ItemLifecycleServiceInterface
+type ItemLifecycleService struct {
+	repo repository.ItemLifecycleRepository
+}
+
+// NewItemLifecycleService creates a new item lifecycle service
+func NewItemLifecycleService(repo repository.ItemLifecycleRepository) ItemLifecycleServiceInterface {
+	return &ItemLifecycleService{repo: repo}
+}
+
+func (s *ItemLifecycleService) ApplyTransitions(ctx context.Context, now time.Time) (int, error) {
+	changes, err := s.repo.ApplyTransitions(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+	for _, change := range changes {
+		metrics.RecordItemStatusTransition(change.ResourceType, change.ToStatus)
+		slog.Debug("Item status changed",
+			"resource_type", change.ResourceType,
+			"resource_id", change.ResourceID,
+			"from", change.FromStatus,
+			"to", change.ToStatus)
+	}
+	return len(changes), nil
+}
+
+func (s *ItemLifecycleService) GetHistory(ctx context.Context, resourceType string, resourceID uuid.UUID) ([]models.ItemStatusChange, error) {
+	return s.repo.GetHistory(ctx, resourceType, resourceID)
+}
+
+func (s *ItemLifecycleService) CountByStatus(ctx context.Context) ([]models.ItemStatusCount, error) {
+	return s.repo.CountByStatus(ctx)
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"savvy/internal/models"
+)
+
+// fakeItemLifecycleRepository derives the status of in-memory items like the
+// SQL of the GORM implementation
+type fakeItemLifecycleRepository struct {
+	vouchers  []*models.Voucher
+	giftCards []*models.GiftCard
+	history   []models.ItemStatusChange
+	err       error
+}
+
+func (r *fakeItemLifecycleRepository) ApplyTransitions(_ context.Context, now time.Time) ([]models.ItemStatusChange, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	var changes []models.ItemStatusChange
+	change := func(resourceType string, id uuid.UUID, userID *uuid.UUID, from, to string) {
+		changes = append(changes, models.ItemStatusChange{
+			ResourceType: resourceType,
+			ResourceID:   id,
+			UserID:       userID,
+			FromStatus:   from,
+			ToStatus:     to,
+			Reason:       models.StatusChangeReason(to),
+			CreatedAt:    now,
+		})
+	}
+	for _, v := range r.vouchers {
+		if v.Status != models.ItemStatusActive && v.Status != models.ItemStatusExpired {
+			continue
+		}
+		if status := v.GetComputedStatus(); status != v.Status {
+			change("voucher", v.ID, v.UserID, v.Status, status)
+			v.Status = status
+		}
+	}
+	for _, g := range r.giftCards {
+		if g.Status == "inactive" {
+			continue
+		}
+		if status := g.GetComputedStatus(); status != g.Status {
+			change("gift_card", g.ID, g.UserID, g.Status, status)
+			g.Status = status
+		}
+	}
+	r.history = append(r.history, changes...)
+	return changes, nil
+}
+
+func (r *fakeItemLifecycleRepository) GetHistory(_ context.Context, resourceType string, resourceID uuid.UUID) ([]models.ItemStatusChange, error) {
+	var changes []models.ItemStatusChange
+	for i := len(r.history) - 1; i >= 0; i-- {
+		if r.history[i].ResourceType == resourceType && r.history[i].ResourceID == resourceID {
+			changes = append(changes, r.history[i])
+		}
+	}
+	return changes, nil
+}
+
+func (r *fakeItemLifecycleRepository) CountByStatus(context.Context) ([]models.ItemStatusCount, error) {
+	return nil, r.err
+}
+
+func TestItemLifecycleService_ApplyTransitions(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	expired := &models.Voucher{ID: uuid.New(), Status: models.ItemStatusActive, ValidUntil: now.Add(-time.Hour)}
+	valid := &models.Voucher{ID: uuid.New(), Status: models.ItemStatusActive, ValidUntil: now.Add(time.Hour)}
+	depleted := &models.GiftCard{ID: uuid.New(), Status: models.ItemStatusActive}
+	repo := &fakeItemLifecycleRepository{
+		vouchers:  []*models.Voucher{expired, valid},
+		giftCards: []*models.GiftCard{depleted},
+	}
+	service := NewItemLifecycleService(repo)
+
+	changed, err := service.ApplyTransitions(ctx, now)
+	require.NoError(t, err)
+	assert.Equal(t, 2, changed)
+	assert.Equal(t, models.ItemStatusExpired, expired.Status)
+	assert.Equal(t, models.ItemStatusRedeemed, depleted.Status)
+
+	// Nothing left to do
+	changed, err = service.ApplyTransitions(ctx, now)
+	require.NoError(t, err)
+	assert.Zero(t, changed)
+
+	history, err := service.GetHistory(ctx, "gift_card", depleted.ID)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, models.StatusReasonDepleted, history[0].Reason)
+
+	repo.err = errors.New("database is gone")
+	_, err = service.ApplyTransitions(ctx, now)
+	assert.Error(t, err)
+}
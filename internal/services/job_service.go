@@ -0,0 +1,44 @@
+// Package services contains business logic.
+package services
+
+import (
+	"context"
+	"savvy/internal/models"
+	"savvy/internal/repository"
+	"time"
+)
+
+// JobRunRetention is how long the run history of background jobs is kept
+const JobRunRetention = 7 * 24 * time.Hour
+
+// JobServiceInterface gives admins insight into the background jobs
+type JobServiceInterface interface {
+	// List returns all jobs with the outcome of their last run.
+	List(ctx context.Context) ([]models.Job, error)
+	// ListRuns returns the latest runs, of one job if name is not empty.
+	ListRuns(ctx context.Context, name string, limit int) ([]models.JobRun, error)
+	// DeleteOldRuns removes runs older than JobRunRetention and returns how many there were.
+	DeleteOldRuns(ctx context.Context) (int64, error)
+}
+
+// JobService implements JobServiceInterface
+type JobService struct {
+	repo repository.JobRepository
+}
+
+// NewJobService creates a new job service
+func NewJobService(repo repository.JobRepository) JobServiceInterface {
+	return &JobService{repo: repo}
+}
+
+func (s *JobService) List(ctx context.Context) ([]models.Job, error) {
+	return s.repo.List(ctx)
+}
+
+func (s *JobService) ListRuns(ctx context.Context, name string, limit int) ([]models.JobRun, error) {
+	return s.repo.ListRuns(ctx, name, limit)
+}
+
+func (s *JobService) DeleteOldRuns(ctx context.Context) (int64, error) {
+	return s.repo.DeleteRunsBefore(ctx, time.Now().Add(-JobRunRetention))
+}
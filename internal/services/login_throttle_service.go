@@ -0,0 +1,257 @@
+// Package services contains business logic.
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"savvy/internal/metrics"
+	"savvy/internal/models"
+	"savvy/internal/repository"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// LoginFailureWindow is how long a failed login counts. The count of an
+// email address or IP starts over after a quiet window.
+const LoginFailureWindow = time.Hour
+
+const (
+	// loginDelayAfter is the number of failures per address without a delay
+	loginDelayAfter = 3
+	// loginMaxDelay caps the delay between attempts on the same address
+	loginMaxDelay = 30 * time.Second
+	// accountLockThreshold failures per address lock it, and every further multiple again
+	accountLockThreshold = 10
+	// ipLockThreshold failures from one IP lock it, across all addresses
+	ipLockThreshold = 50
+	// loginLockDuration is the first lockout, each further one doubles it
+	loginLockDuration = 15 * time.Minute
+	// loginMaxLockDuration caps the lockout
+	loginMaxLockDuration = 24 * time.Hour
+	// lockoutNoticeTimeout bounds the background delivery of the lockout email
+	lockoutNoticeTimeout = time.Minute
+)
+
+// Login steps whose failures are counted
+const (
+	LoginStepPassword  = "password"
+	LoginStepTwoFactor = "two_factor"
+)
+
+// LoginBlock describes why a login attempt is refused
+type LoginBlock struct {
+	RetryAfter time.Duration
+	Locked     bool // False for a progressive delay between attempts
+}
+
+// LoginThrottleServiceInterface defines the brute-force protection of the
+// local login. Failures are counted per email address, so attackers spread
+// over many IPs are slowed down, and per IP, so one client cannot try many
+// addresses. Unknown addresses are counted like existing ones.
+type LoginThrottleServiceInterface interface {
+	// Check returns a block if the address or the IP may not log in now, nil otherwise.
+	Check(ctx context.Context, email, ip string) (*LoginBlock, error)
+	// RecordFailure counts a failed attempt at the given step and locks the
+	// address or IP when a threshold is reached. The user is notified.
+	RecordFailure(ctx context.Context, email, ip, step string) error
+	// RecordSuccess clears the failures of the address. The IP count stays.
+	RecordSuccess(ctx context.Context, email string) error
+
+	// ListLocked returns the addresses and IPs that are currently locked.
+	ListLocked(ctx context.Context) ([]models.LoginThrottle, error)
+	// Unlock removes a lockout and its failures and returns what was unlocked.
+	Unlock(ctx context.Context, id uuid.UUID) (*models.LoginThrottle, error)
+}
+
+// LoginThrottleService implements LoginThrottleServiceInterface.
+type LoginThrottleService struct {
+	repo                repository.LoginThrottleRepository
+	userRepo            repository.UserRepository
+	notificationService NotificationServiceInterface
+	accountEmailService AccountEmailServiceInterface
+	now                 func() time.Time
+}
+
+// NewLoginThrottleService creates a new login throttle service.
+func NewLoginThrottleService(
+	repo repository.LoginThrottleRepository,
+	userRepo repository.UserRepository,
+	notificationService NotificationServiceInterface,
+	accountEmailService AccountEmailServiceInterface,
+) LoginThrottleServiceInterface {
+	return &LoginThrottleService{
+		repo:                repo,
+		userRepo:            userRepo,
+		notificationService: notificationService,
+		accountEmailService: accountEmailService,
+		now:                 time.Now,
+	}
+}
+
+// Check refuses locked addresses and IPs, and attempts on an address that
+// come sooner than its progressive delay allows.
+func (s *LoginThrottleService) Check(ctx context.Context, email, ip string) (*LoginBlock, error) {
+	now := s.now()
+
+	account, err := s.get(ctx, models.LoginThrottleScopeAccount, normalizeLoginEmail(email))
+	if err != nil {
+		return nil, err
+	}
+	client, err := s.get(ctx, models.LoginThrottleScopeIP, ip)
+	if err != nil {
+		return nil, err
+	}
+
+	var block *LoginBlock
+	for _, throttle := range []*models.LoginThrottle{account, client} {
+		if throttle != nil && throttle.IsLocked(now) {
+			block = longerBlock(block, &LoginBlock{RetryAfter: throttle.LockedUntil.Sub(now), Locked: true})
+		}
+	}
+	if block == nil && account != nil {
+		if next := account.LastFailureAt.Add(loginDelay(account.Failures)); next.After(now) {
+			block = &LoginBlock{RetryAfter: next.Sub(now)}
+		}
+	}
+
+	if block != nil {
+		reason := "delayed"
+		if block.Locked {
+			reason = "locked"
+		}
+		metrics.RecordLoginBlocked(reason)
+	}
+	return block, nil
+}
+
+// RecordFailure counts the failure for the address and the IP
+func (s *LoginThrottleService) RecordFailure(ctx context.Context, email, ip, step string) error {
+	metrics.RecordLoginFailure(step)
+	now := s.now()
+
+	email = normalizeLoginEmail(email)
+	if email != "" {
+		account, err := s.repo.RecordFailure(ctx, models.LoginThrottleScopeAccount, email, now, LoginFailureWindow)
+		if err != nil {
+			return err
+		}
+		if account.Failures%accountLockThreshold == 0 {
+			duration := lockDuration(account.Failures / accountLockThreshold)
+			if err := s.lock(ctx, account, now.Add(duration)); err != nil {
+				return err
+			}
+			s.notifyLockout(ctx, email, now.Add(duration), duration, account.Failures)
+		}
+	}
+
+	if ip != "" {
+		client, err := s.repo.RecordFailure(ctx, models.LoginThrottleScopeIP, ip, now, LoginFailureWindow)
+		if err != nil {
+			return err
+		}
+		if client.Failures%ipLockThreshold == 0 {
+			if err := s.lock(ctx, client, now.Add(lockDuration(client.Failures/ipLockThreshold))); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// RecordSuccess forgets the failures of the address. The IP keeps its
+// count, otherwise one valid account would reset it for a whole botnet.
+func (s *LoginThrottleService) RecordSuccess(ctx context.Context, email string) error {
+	return s.repo.Reset(ctx, models.LoginThrottleScopeAccount, normalizeLoginEmail(email))
+}
+
+// ListLocked returns the current lockouts, longest first
+func (s *LoginThrottleService) ListLocked(ctx context.Context) ([]models.LoginThrottle, error) {
+	return s.repo.GetLocked(ctx, s.now())
+}
+
+// Unlock deletes the counter, so the next attempt starts without delay
+func (s *LoginThrottleService) Unlock(ctx context.Context, id uuid.UUID) (*models.LoginThrottle, error) {
+	return s.repo.Delete(ctx, id)
+}
+
+// get returns the counter of a subject, nil if it has no failures
+func (s *LoginThrottleService) get(ctx context.Context, scope, subject string) (*models.LoginThrottle, error) {
+	if subject == "" {
+		return nil, nil
+	}
+	throttle, err := s.repo.Get(ctx, scope, subject)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return throttle, err
+}
+
+func (s *LoginThrottleService) lock(ctx context.Context, throttle *models.LoginThrottle, until time.Time) error {
+	if err := s.repo.Lock(ctx, throttle.ID, until); err != nil {
+		return err
+	}
+	metrics.RecordLoginLockout(throttle.Scope)
+	slog.Warn("Login locked after failed attempts",
+		"scope", throttle.Scope, "subject", throttle.Subject, "failures", throttle.Failures, "locked_until", until)
+	return nil
+}
+
+// notifyLockout tells the owner of the address, if it belongs to an account.
+// The email is sent in the background, so the response time of the failed
+// login does not reveal whether the account exists.
+func (s *LoginThrottleService) notifyLockout(ctx context.Context, email string, until time.Time, duration time.Duration, failures int) {
+	user, err := s.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			slog.Warn("Failed to load user for lockout notice", "error", err)
+		}
+		return
+	}
+
+	if err := s.notificationService.CreateAccountLockedNotification(ctx, user.ID, until, failures); err != nil {
+		slog.Warn("Failed to create lockout notification", "user_id", user.ID, "error", err)
+	}
+
+	if !s.accountEmailService.Enabled() {
+		return
+	}
+	sendCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), lockoutNoticeTimeout)
+	go func() {
+		defer cancel()
+		if err := s.accountEmailService.SendLockoutNotice(sendCtx, user, duration); err != nil {
+			slog.Warn("Failed to send lockout email", "user_id", user.ID, "error", err)
+		}
+	}()
+}
+
+// loginDelay is the minimum time between attempts after the given number of
+// failures: none for the first few, then doubling from one second
+func loginDelay(failures int) time.Duration {
+	if failures <= loginDelayAfter {
+		return 0
+	}
+	delay := time.Second << min(failures-loginDelayAfter-1, 16)
+	return min(delay, loginMaxDelay)
+}
+
+// lockDuration doubles with every lockout within the failure window
+func lockDuration(lockouts int) time.Duration {
+	duration := loginLockDuration << min(lockouts-1, 16)
+	return min(duration, loginMaxLockDuration)
+}
+
+// longerBlock returns the block that lasts longer
+func longerBlock(a, b *LoginBlock) *LoginBlock {
+	if a == nil || b.RetryAfter > a.RetryAfter {
+		return b
+	}
+	return a
+}
+
+func normalizeLoginEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,235 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"savvy/internal/models"
+)
+
+// fakeLoginThrottleRepository keeps the counters in memory
+type fakeLoginThrottleRepository struct {
+	throttles map[string]*models.LoginThrottle
+}
+
+func (r *fakeLoginThrottleRepository) Get(_ context.Context, scope, subject string) (*models.LoginThrottle, error) {
+	if throttle, ok := r.throttles[scope+"/"+subject]; ok {
+		copied := *throttle
+		return &copied, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeLoginThrottleRepository) RecordFailure(_ context.Context, scope, subject string, at time.Time, window time.Duration) (*models.LoginThrottle, error) {
+	throttle, ok := r.throttles[scope+"/"+subject]
+	switch {
+	case !ok:
+		throttle = &models.LoginThrottle{ID: uuid.New(), Scope: scope, Subject: subject, Failures: 1}
+		r.throttles[scope+"/"+subject] = throttle
+	case throttle.LastFailureAt.Before(at.Add(-window)):
+		throttle.Failures = 1
+	default:
+		throttle.Failures++
+	}
+	throttle.LastFailureAt = at
+	copied := *throttle
+	return &copied, nil
+}
+
+func (r *fakeLoginThrottleRepository) Lock(_ context.Context, id uuid.UUID, until time.Time) error {
+	for _, throttle := range r.throttles {
+		if throttle.ID == id {
+			throttle.LockedUntil = &until
+		}
+	}
+	return nil
+}
+
+func (r *fakeLoginThrottleRepository) Reset(_ context.Context, scope, subject string) error {
+	delete(r.throttles, scope+"/"+subject)
+	return nil
+}
+
+func (r *fakeLoginThrottleRepository) Delete(_ context.Context, id uuid.UUID) (*models.LoginThrottle, error) {
+	for key, throttle := range r.throttles {
+		if throttle.ID == id {
+			delete(r.throttles, key)
+			return throttle, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeLoginThrottleRepository) GetLocked(_ context.Context, at time.Time) ([]models.LoginThrottle, error) {
+	var locked []models.LoginThrottle
+	for _, throttle := range r.throttles {
+		if throttle.IsLocked(at) {
+			locked = append(locked, *throttle)
+		}
+	}
+	return locked, nil
+}
+
+func (r *fakeLoginThrottleRepository) DeleteStale(context.Context, time.Time) (int64, error) {
+	return 0, nil
+}
+
+// fakeNotificationService records lockout notifications
+type fakeNotificationService struct {
+	NotificationServiceInterface
+	lockedUsers []uuid.UUID
+}
+
+func (s *fakeNotificationService) CreateAccountLockedNotification(_ context.Context, userID uuid.UUID, _ time.Time, _ int) error {
+	s.lockedUsers = append(s.lockedUsers, userID)
+	return nil
+}
+
+func setupLoginThrottleService(t *testing.T) (*LoginThrottleService, *fakeNotificationService, *models.User, *time.Time) {
+	t.Helper()
+	user := &models.User{ID: uuid.New(), Email: "lena.fischer@example.com", FirstName: "Lena", AuthProvider: "local"}
+	users := &fakeUserRepository{users: map[uuid.UUID]*models.User{user.ID: user}}
+	notifications := &fakeNotificationService{}
+
+	// Without a mail sender only the in-app notification is created
+	accountEmails := NewAccountEmailService(users, &fakeSessionService{})
+	service := NewLoginThrottleService(
+		&fakeLoginThrottleRepository{throttles: map[string]*models.LoginThrottle{}},
+		users, notifications, accountEmails,
+	).(*LoginThrottleService)
+
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+	return service, notifications, user, &now
+}
+
+func TestLoginThrottleService_ProgressiveDelay(t *testing.T) {
+	service, _, user, now := setupLoginThrottleService(t)
+	ctx := context.Background()
+
+	for i := 0; i < loginDelayAfter; i++ {
+		require.NoError(t, service.RecordFailure(ctx, user.Email, "192.0.2.1", LoginStepPassword))
+	}
+	block, err := service.Check(ctx, user.Email, "192.0.2.1")
+	require.NoError(t, err)
+	assert.Nil(t, block, "The first failures are not delayed")
+
+	require.NoError(t, service.RecordFailure(ctx, user.Email, "192.0.2.1", LoginStepPassword))
+	block, err = service.Check(ctx, "  LENA.FISCHER@example.com ", "198.51.100.7")
+	require.NoError(t, err)
+	require.NotNil(t, block, "The delay applies to the address from any IP")
+	assert.False(t, block.Locked)
+	assert.Equal(t, time.Second, block.RetryAfter)
+
+	*now = now.Add(time.Second)
+	block, err = service.Check(ctx, user.Email, "192.0.2.1")
+	require.NoError(t, err)
+	assert.Nil(t, block)
+}
+
+func TestLoginThrottleService_AccountLockout(t *testing.T) {
+	service, notifications, user, now := setupLoginThrottleService(t)
+	ctx := context.Background()
+
+	for i := 0; i < accountLockThreshold; i++ {
+		*now = now.Add(loginMaxDelay)
+		require.NoError(t, service.RecordFailure(ctx, user.Email, fmt.Sprintf("192.0.2.%d", i), LoginStepPassword))
+	}
+
+	block, err := service.Check(ctx, user.Email, "203.0.113.1")
+	require.NoError(t, err)
+	require.NotNil(t, block)
+	assert.True(t, block.Locked)
+	assert.Equal(t, loginLockDuration, block.RetryAfter)
+	assert.Equal(t, []uuid.UUID{user.ID}, notifications.lockedUsers)
+
+	locked, err := service.ListLocked(ctx)
+	require.NoError(t, err)
+	require.Len(t, locked, 1)
+	assert.Equal(t, models.LoginThrottleScopeAccount, locked[0].Scope)
+
+	// The next lockout lasts twice as long
+	*now = now.Add(loginLockDuration)
+	for i := 0; i < accountLockThreshold; i++ {
+		require.NoError(t, service.RecordFailure(ctx, user.Email, "192.0.2.1", LoginStepTwoFactor))
+	}
+	block, err = service.Check(ctx, user.Email, "203.0.113.1")
+	require.NoError(t, err)
+	require.NotNil(t, block)
+	assert.Equal(t, 2*loginLockDuration, block.RetryAfter)
+
+	unlocked, err := service.Unlock(ctx, locked[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, user.Email, unlocked.Subject)
+	block, err = service.Check(ctx, user.Email, "203.0.113.1")
+	require.NoError(t, err)
+	assert.Nil(t, block)
+}
+
+func TestLoginThrottleService_UnknownAddress(t *testing.T) {
+	service, notifications, _, now := setupLoginThrottleService(t)
+	ctx := context.Background()
+
+	for i := 0; i < accountLockThreshold; i++ {
+		*now = now.Add(loginMaxDelay)
+		require.NoError(t, service.RecordFailure(ctx, "nobody@example.com", "", LoginStepPassword))
+	}
+
+	// Locked like an existing account, so lockouts do not reveal which addresses exist
+	block, err := service.Check(ctx, "nobody@example.com", "")
+	require.NoError(t, err)
+	require.NotNil(t, block)
+	assert.True(t, block.Locked)
+	assert.Empty(t, notifications.lockedUsers)
+}
+
+func TestLoginThrottleService_IPLockout(t *testing.T) {
+	service, _, user, _ := setupLoginThrottleService(t)
+	ctx := context.Background()
+
+	// Password spraying: a few attempts on many addresses from one IP
+	for i := 0; i < ipLockThreshold; i++ {
+		require.NoError(t, service.RecordFailure(ctx, fmt.Sprintf("user%d@example.com", i), "192.0.2.1", LoginStepPassword))
+	}
+
+	block, err := service.Check(ctx, user.Email, "192.0.2.1")
+	require.NoError(t, err)
+	require.NotNil(t, block)
+	assert.True(t, block.Locked)
+
+	block, err = service.Check(ctx, user.Email, "198.51.100.7")
+	require.NoError(t, err)
+	assert.Nil(t, block, "Other IPs are not affected")
+}
+
+func TestLoginThrottleService_RecordSuccess(t *testing.T) {
+	service, _, user, _ := setupLoginThrottleService(t)
+	ctx := context.Background()
+
+	for i := 0; i < loginDelayAfter+2; i++ {
+		require.NoError(t, service.RecordFailure(ctx, user.Email, "192.0.2.1", LoginStepPassword))
+	}
+	require.NoError(t, service.RecordSuccess(ctx, "Lena.Fischer@example.com"))
+
+	block, err := service.Check(ctx, user.Email, "192.0.2.1")
+	require.NoError(t, err)
+	assert.Nil(t, block)
+}
+
+func TestLoginThrottleDurations(t *testing.T) {
+	assert.Equal(t, time.Duration(0), loginDelay(loginDelayAfter))
+	assert.Equal(t, time.Second, loginDelay(loginDelayAfter+1))
+	assert.Equal(t, 8*time.Second, loginDelay(loginDelayAfter+4))
+	assert.Equal(t, loginMaxDelay, loginDelay(accountLockThreshold-1))
+	assert.Equal(t, loginMaxDelay, loginDelay(1000))
+
+	assert.Equal(t, loginLockDuration, lockDuration(1))
+	assert.Equal(t, 4*loginLockDuration, lockDuration(3))
+	assert.Equal(t, loginMaxLockDuration, lockDuration(100))
+}
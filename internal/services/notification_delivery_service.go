@@ -0,0 +1,565 @@
+// Package services contains business logic.
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
+	"savvy/internal/i18n"
+	"savvy/internal/mail"
+	"savvy/internal/metrics"
+	"savvy/internal/models"
+	"savvy/internal/push"
+	"savvy/internal/repository"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// deliveryBatchSize is how many deliveries a worker claims at once
+	deliveryBatchSize = 50
+	// deliveryLease is how long claimed deliveries are hidden from other
+	// workers. Deliveries of a worker that crashed are retried afterwards.
+	deliveryLease = 5 * time.Minute
+	// deliveryPollInterval is how often the worker looks for due retries and
+	// for deliveries queued on other replicas
+	deliveryPollInterval = 30 * time.Second
+	// maxDeliveryErrorLength limits the error stored with a delivery
+	maxDeliveryErrorLength = 1000
+
+	// NotificationDeliveryRetention is how long finished deliveries are kept
+	NotificationDeliveryRetention = 30 * 24 * time.Hour
+)
+
+// deliveryRetryDelays are the waits after each failed attempt. A delivery
+// fails for good after the attempt following the last delay.
+var deliveryRetryDelays = []time.Duration{
+	time.Minute,
+	5 * time.Minute,
+	30 * time.Minute,
+	2 * time.Hour,
+	12 * time.Hour,
+}
+
+var (
+	// ErrPushDisabled indicates that no VAPID key pair is configured
+	ErrPushDisabled = errors.New("web push is not configured")
+	// ErrInvalidPushSubscription indicates a subscription without keys or with an endpoint that is not a push service
+	ErrInvalidPushSubscription = errors.New("invalid push subscription")
+	// ErrInvalidNotificationType indicates a preference for a type that is not delivered
+	ErrInvalidNotificationType = errors.New("invalid notification type")
+
+	// errDeliverySkipped marks a delivery that has nothing left to send
+	errDeliverySkipped = errors.New("nothing to send")
+)
+
+// NotificationDeliveryServiceInterface defines the interface for sending
+// notifications by email and Web Push in addition to the notification center.
+type NotificationDeliveryServiceInterface interface {
+	// UseSender enables email delivery. Links in the emails point to baseURL.
+	UseSender(sender mail.Sender, baseURL string)
+	// UsePush enables Web Push delivery. Browsers subscribe with publicKey.
+	UsePush(sender push.Sender, publicKey string)
+	// EmailEnabled reports whether a mail sender is configured.
+	EmailEnabled() bool
+	// PushPublicKey returns the VAPID public key, empty if push is disabled.
+	PushPublicKey() string
+
+	// Enqueue queues the notification on the channels the recipient enabled
+	// for its type. Types not in models.DeliveredNotificationTypes are ignored.
+	Enqueue(ctx context.Context, notification *models.Notification) error
+	// ProcessDue sends the deliveries that are due and returns how many were claimed.
+	ProcessDue(ctx context.Context) (int, error)
+	// Run processes deliveries until ctx is done.
+	Run(ctx context.Context)
+
+	// GetPreferences returns the preference of each delivered notification type.
+	GetPreferences(ctx context.Context, userID uuid.UUID) ([]models.NotificationPreference, error)
+	// UpdatePreferences stores the preferences of a user.
+	UpdatePreferences(ctx context.Context, userID uuid.UUID, preferences []models.NotificationPreference) error
+
+	// Subscribe stores the Web Push subscription of a browser of the user.
+	Subscribe(ctx context.Context, userID uuid.UUID, subscription push.Subscription, userAgent string) error
+	// Unsubscribe removes the subscription of a browser of the user.
+	Unsubscribe(ctx context.Context, userID uuid.UUID, endpoint string) error
+	// CountSubscriptions counts the browsers of the user that receive push notifications.
+	CountSubscriptions(ctx context.Context, userID uuid.UUID) (int, error)
+}
+
+// NotificationDeliveryService implements NotificationDeliveryServiceInterface.
+// Deliveries are rows in notification_deliveries, so a failed send is
+// retried with backoff, also after a restart or on another replica.
+type NotificationDeliveryService struct {
+	deliveries    repository.NotificationDeliveryRepository
+	preferences   repository.NotificationPreferenceRepository
+	subscriptions repository.PushSubscriptionRepository
+	userRepo      repository.UserRepository
+	now           func() time.Time
+	wake          chan struct{}
+
+	mu            sync.RWMutex
+	mailSender    mail.Sender
+	baseURL       string
+	pushSender    push.Sender
+	pushPublicKey string
+}
+
+// NewNotificationDeliveryService creates a new notification delivery service.
+// Both channels are disabled until UseSender and UsePush are called.
+func NewNotificationDeliveryService(
+	deliveries repository.NotificationDeliveryRepository,
+	preferences repository.NotificationPreferenceRepository,
+	subscriptions repository.PushSubscriptionRepository,
+	userRepo repository.UserRepository,
+) NotificationDeliveryServiceInterface {
+	return &NotificationDeliveryService{
+		deliveries:    deliveries,
+		preferences:   preferences,
+		subscriptions: subscriptions,
+		userRepo:      userRepo,
+		now:           time.Now,
+		wake:          make(chan struct{}, 1),
+	}
+}
+
+// UseSender enables email delivery.
+func (s *NotificationDeliveryService) UseSender(sender mail.Sender, baseURL string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.mailSender = sender
+	s.baseURL = strings.TrimRight(baseURL, "/")
+}
+
+// UsePush enables Web Push delivery.
+func (s *NotificationDeliveryService) UsePush(sender push.Sender, publicKey string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pushSender = sender
+	s.pushPublicKey = publicKey
+}
+
+// EmailEnabled reports whether a mail sender is configured.
+func (s *NotificationDeliveryService) EmailEnabled() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.mailSender != nil
+}
+
+// PushPublicKey returns the VAPID public key, empty if push is disabled.
+func (s *NotificationDeliveryService) PushPublicKey() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.pushSender == nil {
+		return ""
+	}
+	return s.pushPublicKey
+}
+
+// Enqueue queues one delivery per enabled channel. The message is rendered
+// when it is sent, in the language of the request that caused it.
+func (s *NotificationDeliveryService) Enqueue(ctx context.Context, notification *models.Notification) error {
+	if !slices.Contains(models.DeliveredNotificationTypes, notification.Type) {
+		return nil
+	}
+
+	preference, err := s.preference(ctx, notification.UserID, notification.Type)
+	if err != nil {
+		return err
+	}
+
+	var channels []models.NotificationChannel
+	if preference.Email && s.EmailEnabled() {
+		channels = append(channels, models.NotificationChannelEmail)
+	}
+	if preference.Push && s.PushPublicKey() != "" {
+		count, err := s.CountSubscriptions(ctx, notification.UserID)
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			channels = append(channels, models.NotificationChannelPush)
+		}
+	}
+	if len(channels) == 0 {
+		return nil
+	}
+
+	now := s.now()
+	deliveries := make([]models.NotificationDelivery, 0, len(channels))
+	for _, channel := range channels {
+		deliveries = append(deliveries, models.NotificationDelivery{
+			NotificationID: notification.ID,
+			UserID:         notification.UserID,
+			Channel:        channel,
+			Status:         models.NotificationDeliveryPending,
+			Language:       i18n.GetLanguage(ctx),
+			NextAttemptAt:  now,
+		})
+	}
+	if err := s.deliveries.Create(ctx, deliveries); err != nil {
+		return err
+	}
+
+	// Send right away instead of at the next poll
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Run polls for due deliveries, and sends new ones as soon as they are queued
+// on this replica. Several replicas can run it, see ClaimDue.
+func (s *NotificationDeliveryService) Run(ctx context.Context) {
+	ticker := time.NewTicker(deliveryPollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Drain the queue, a full batch may be followed by more
+		for {
+			claimed, err := s.ProcessDue(ctx)
+			if err != nil {
+				slog.Warn("Failed to process notification deliveries", "error", err)
+			}
+			if err != nil || claimed < deliveryBatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+	}
+}
+
+// ProcessDue claims the due deliveries and sends them one after another.
+func (s *NotificationDeliveryService) ProcessDue(ctx context.Context) (int, error) {
+	now := s.now()
+	deliveries, err := s.deliveries.ClaimDue(ctx, now, now.Add(deliveryLease), deliveryBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range deliveries {
+		s.deliver(ctx, &deliveries[i])
+	}
+	return len(deliveries), nil
+}
+
+// deliver makes one attempt and schedules the next one if it failed
+func (s *NotificationDeliveryService) deliver(ctx context.Context, delivery *models.NotificationDelivery) {
+	delivery.Attempts++
+	err := s.send(ctx, delivery)
+	now := s.now()
+
+	switch {
+	case err == nil:
+		delivery.Status = models.NotificationDeliverySent
+		delivery.SentAt = &now
+		delivery.LastError = ""
+	case errors.Is(err, errDeliverySkipped):
+		delivery.Status = models.NotificationDeliverySkipped
+		delivery.LastError = ""
+	case delivery.Attempts > len(deliveryRetryDelays):
+		delivery.Status = models.NotificationDeliveryFailed
+		delivery.LastError = truncateDeliveryError(err)
+		slog.Error("Giving up notification delivery",
+			"delivery_id", delivery.ID, "channel", delivery.Channel, "attempts", delivery.Attempts, "error", err)
+	default:
+		delivery.NextAttemptAt = now.Add(deliveryRetryDelays[delivery.Attempts-1])
+		delivery.LastError = truncateDeliveryError(err)
+		slog.Warn("Notification delivery failed, retrying",
+			"delivery_id", delivery.ID, "channel", delivery.Channel, "attempts", delivery.Attempts,
+			"next_attempt_at", delivery.NextAttemptAt, "error", err)
+	}
+	metrics.RecordNotificationDelivery(string(delivery.Channel), string(delivery.Status))
+
+	if err := s.deliveries.Update(ctx, delivery); err != nil {
+		// The lease expires and the delivery is sent again
+		slog.Error("Failed to update notification delivery", "delivery_id", delivery.ID, "error", err)
+	}
+}
+
+// send renders the notification in the stored language and hands it to the channel
+func (s *NotificationDeliveryService) send(ctx context.Context, delivery *models.NotificationDelivery) error {
+	// Archived in the meantime, or the channel was switched off
+	if delivery.Notification == nil {
+		return errDeliverySkipped
+	}
+	preference, err := s.preference(ctx, delivery.UserID, delivery.Notification.Type)
+	if err != nil {
+		return err
+	}
+	if !preference.Enabled(delivery.Channel) {
+		return errDeliverySkipped
+	}
+
+	if delivery.Language != "" {
+		ctx = i18n.SetLocalizer(i18n.SetLanguage(ctx, delivery.Language), i18n.NewLocalizer(delivery.Language))
+	}
+
+	switch delivery.Channel {
+	case models.NotificationChannelEmail:
+		return s.sendEmail(ctx, delivery.Notification)
+	case models.NotificationChannelPush:
+		return s.sendPush(ctx, delivery.Notification)
+	default:
+		return fmt.Errorf("unknown notification channel %q", delivery.Channel)
+	}
+}
+
+func (s *NotificationDeliveryService) sendEmail(ctx context.Context, notification *models.Notification) error {
+	s.mu.RLock()
+	sender, baseURL := s.mailSender, s.baseURL
+	s.mu.RUnlock()
+	if sender == nil {
+		return ErrMailDisabled
+	}
+
+	user, err := s.userRepo.GetByID(ctx, notification.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errDeliverySkipped
+		}
+		return err
+	}
+
+	title, body := notificationContent(ctx, notification)
+	msg, err := mail.Render(ctx, user.Email, user.DisplayName(), mail.TemplateNotification, map[string]any{
+		"Name":         user.FirstName,
+		"Title":        title,
+		"Message":      body,
+		"Link":         baseURL + notification.ResourcePath(),
+		"SettingsLink": baseURL + "/notifications/settings",
+	})
+	if err != nil {
+		return err
+	}
+	return sender.Send(ctx, msg)
+}
+
+// sendPush sends to all browsers of the user. It succeeds if one of them got
+// the message; expired subscriptions are removed.
+func (s *NotificationDeliveryService) sendPush(ctx context.Context, notification *models.Notification) error {
+	s.mu.RLock()
+	sender := s.pushSender
+	s.mu.RUnlock()
+	if sender == nil {
+		return ErrPushDisabled
+	}
+
+	subscriptions, err := s.subscriptions.GetByUserID(ctx, notification.UserID)
+	if err != nil {
+		return err
+	}
+
+	title, body := notificationContent(ctx, notification)
+	msg := push.Message{
+		Title: title,
+		Body:  body,
+		URL:   notification.ResourcePath(),
+		Tag:   notification.ID.String(),
+	}
+
+	sent := 0
+	var lastErr error
+	for _, subscription := range subscriptions {
+		err := sender.Send(ctx, push.Subscription{
+			Endpoint: subscription.Endpoint,
+			P256dh:   subscription.P256dh,
+			Auth:     subscription.Auth,
+		}, msg)
+		switch {
+		case errors.Is(err, push.ErrSubscriptionGone):
+			if err := s.subscriptions.Delete(ctx, subscription.ID); err != nil {
+				slog.Warn("Failed to delete expired push subscription", "subscription_id", subscription.ID, "error", err)
+			}
+		case err != nil:
+			lastErr = err
+		default:
+			sent++
+			if err := s.subscriptions.MarkUsed(ctx, subscription.ID, s.now()); err != nil {
+				slog.Warn("Failed to update push subscription", "subscription_id", subscription.ID, "error", err)
+			}
+		}
+	}
+
+	switch {
+	case sent > 0:
+		if lastErr != nil {
+			slog.Warn("Push notification reached only some browsers", "notification_id", notification.ID, "error", lastErr)
+		}
+		return nil
+	case lastErr != nil:
+		return lastErr
+	default:
+		return errDeliverySkipped
+	}
+}
+
+// GetPreferences merges the stored preferences with the defaults.
+func (s *NotificationDeliveryService) GetPreferences(ctx context.Context, userID uuid.UUID) ([]models.NotificationPreference, error) {
+	stored, err := s.preferences.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	preferences := make([]models.NotificationPreference, 0, len(models.DeliveredNotificationTypes))
+	for _, notificationType := range models.DeliveredNotificationTypes {
+		preference := models.DefaultNotificationPreference(userID, notificationType)
+		for _, p := range stored {
+			if p.Type == notificationType {
+				preference = p
+			}
+		}
+		preferences = append(preferences, preference)
+	}
+	return preferences, nil
+}
+
+// UpdatePreferences stores the preferences of a user.
+func (s *NotificationDeliveryService) UpdatePreferences(ctx context.Context, userID uuid.UUID, preferences []models.NotificationPreference) error {
+	for _, preference := range preferences {
+		if !slices.Contains(models.DeliveredNotificationTypes, preference.Type) {
+			return ErrInvalidNotificationType
+		}
+	}
+	for _, preference := range preferences {
+		preference.UserID = userID
+		if err := s.preferences.Save(ctx, &preference); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// preference returns the preference of the user for a type
+func (s *NotificationDeliveryService) preference(ctx context.Context, userID uuid.UUID, notificationType models.NotificationType) (models.NotificationPreference, error) {
+	preferences, err := s.GetPreferences(ctx, userID)
+	if err != nil {
+		return models.NotificationPreference{}, err
+	}
+	for _, preference := range preferences {
+		if preference.Type == notificationType {
+			return preference, nil
+		}
+	}
+	return models.DefaultNotificationPreference(userID, notificationType), nil
+}
+
+// Subscribe stores the subscription. The server posts to the endpoint, so
+// only HTTPS endpoints on public host names are accepted.
+func (s *NotificationDeliveryService) Subscribe(ctx context.Context, userID uuid.UUID, subscription push.Subscription, userAgent string) error {
+	if s.PushPublicKey() == "" {
+		return ErrPushDisabled
+	}
+	if subscription.P256dh == "" || subscription.Auth == "" || len(subscription.P256dh) > 255 || len(subscription.Auth) > 255 {
+		return ErrInvalidPushSubscription
+	}
+	if !isPushServiceEndpoint(subscription.Endpoint) {
+		return ErrInvalidPushSubscription
+	}
+
+	if len(userAgent) > 500 {
+		userAgent = userAgent[:500]
+	}
+	return s.subscriptions.Save(ctx, &models.PushSubscription{
+		UserID:    userID,
+		Endpoint:  subscription.Endpoint,
+		P256dh:    subscription.P256dh,
+		Auth:      subscription.Auth,
+		UserAgent: userAgent,
+	})
+}
+
+// Unsubscribe removes the subscription of a browser of the user.
+func (s *NotificationDeliveryService) Unsubscribe(ctx context.Context, userID uuid.UUID, endpoint string) error {
+	return s.subscriptions.DeleteByEndpoint(ctx, userID, endpoint)
+}
+
+// CountSubscriptions counts the browsers of the user that receive push notifications.
+func (s *NotificationDeliveryService) CountSubscriptions(ctx context.Context, userID uuid.UUID) (int, error) {
+	subscriptions, err := s.subscriptions.GetByUserID(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	return len(subscriptions), nil
+}
+
+// notificationContent returns the title and text of a notification, the same
+// as shown in the notification center
+func notificationContent(ctx context.Context, notification *models.Notification) (title, body string) {
+	data := map[string]any{
+		"FromUser":     notification.GetFromUserName(),
+		"ResourceType": resourceTypeName(ctx, notification.ResourceType),
+	}
+	switch notification.Type {
+	case models.NotificationTypeTransferReceived:
+		return i18n.T(ctx, "notifications.transfer.title"), i18n.T(ctx, "notifications.transfer.message", data)
+	case models.NotificationTypeShareReceived:
+		return i18n.T(ctx, "notifications.share.title"), i18n.T(ctx, "notifications.share.message", data)
+	case models.NotificationTypeExpiringSoon:
+		return i18n.T(ctx, "notifications.expiring_soon.title"), expiringSoonMessage(ctx, notification)
+	default:
+		return i18n.T(ctx, "notifications.title"), ""
+	}
+}
+
+// expiringSoonMessage returns the text of an expiry reminder
+func expiringSoonMessage(ctx context.Context, notification *models.Notification) string {
+	date := ""
+	if expiresAt := notification.GetExpiresAt(); expiresAt != nil {
+		date = expiresAt.UTC().Format("02.01.2006")
+	}
+	return i18n.T(ctx, "notifications.expiring_soon."+notification.ResourceType, map[string]any{
+		"Name": notification.GetItemName(),
+		"Date": date,
+	})
+}
+
+// resourceTypeName returns the translated resource type, e.g. "a card"
+func resourceTypeName(ctx context.Context, resourceType string) string {
+	switch resourceType {
+	case "card", "voucher", "gift_card":
+		return i18n.T(ctx, "notifications.resource_types."+resourceType)
+	default:
+		return resourceType
+	}
+}
+
+// isPushServiceEndpoint rejects endpoints that would make the server post to
+// itself or the internal network
+func isPushServiceEndpoint(endpoint string) bool {
+	if len(endpoint) > 2048 {
+		return false
+	}
+	parsed, err := url.Parse(endpoint)
+	if err != nil || parsed.Scheme != "https" || parsed.User != nil {
+		return false
+	}
+	host := parsed.Hostname()
+	if host == "" || net.ParseIP(host) != nil {
+		return false
+	}
+	return strings.Contains(host, ".") && host != "localhost" && !strings.HasSuffix(host, ".localhost")
+}
+
+// truncateDeliveryError keeps stored errors short, push services may answer with whole pages
+func truncateDeliveryError(err error) string {
+	msg := err.Error()
+	if len(msg) > maxDeliveryErrorLength {
+		return msg[:maxDeliveryErrorLength]
+	}
+	return msg
+}
@@ -3,16 +3,23 @@ package services
 
 import (
 	"context"
+	"errors"
+	"log/slog"
 	"savvy/internal/models"
+	"savvy/internal/realtime"
 	"savvy/internal/repository"
+	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 // NotificationServiceInterface defines the interface for notification business logic
 type NotificationServiceInterface interface {
 	CreateShareNotification(ctx context.Context, recipientID, fromUserID uuid.UUID, fromUserName, resourceType string, resourceID uuid.UUID, permissions map[string]bool) error
 	CreateTransferNotification(ctx context.Context, recipientID, fromUserID uuid.UUID, fromUserName, resourceType string, resourceID uuid.UUID) error
+	CreateAccountLockedNotification(ctx context.Context, userID uuid.UUID, lockedUntil time.Time, failures int) error
+	CreateExpiringSoonNotification(ctx context.Context, userID uuid.UUID, item models.ExpiringItem, daysLeft int) error
 	GetUserNotifications(ctx context.Context, userID uuid.UUID, limit, offset int) ([]models.Notification, error)
 	GetUnreadCount(ctx context.Context, userID uuid.UUID) (int64, error)
 	MarkAsRead(ctx context.Context, notificationID uuid.UUID) error
@@ -20,14 +27,24 @@ type NotificationServiceInterface interface {
 	DeleteNotification(ctx context.Context, notificationID uuid.UUID) error
 }
 
+// NotificationEventPublisher tells the open tabs of a user that their
+// notifications changed, see realtime.Hub
+type NotificationEventPublisher interface {
+	Publish(ctx context.Context, event realtime.Event) error
+}
+
 // NotificationService implements NotificationServiceInterface
 type NotificationService struct {
-	repo repository.NotificationRepository
+	repo       repository.NotificationRepository
+	deliveries NotificationDeliveryServiceInterface
+	events     NotificationEventPublisher
 }
 
-// NewNotificationService creates a new notification service
-func NewNotificationService(repo repository.NotificationRepository) NotificationServiceInterface {
-	return &NotificationService{repo: repo}
+// NewNotificationService creates a new notification service. New
+// notifications are also queued for delivery by email and push, and every
+// change is published to the open tabs of the user.
+func NewNotificationService(repo repository.NotificationRepository, deliveries NotificationDeliveryServiceInterface, events NotificationEventPublisher) NotificationServiceInterface {
+	return &NotificationService{repo: repo, deliveries: deliveries, events: events}
 }
 
 // CreateShareNotification creates a notification when a resource is shared with a user
@@ -58,7 +75,7 @@ func (s *NotificationService) CreateShareNotification(
 		IsRead:       false,
 	}
 
-	return s.repo.Create(ctx, notification)
+	return s.create(ctx, notification)
 }
 
 // CreateTransferNotification creates a notification when resource ownership is transferred to a user
@@ -80,7 +97,65 @@ func (s *NotificationService) CreateTransferNotification(
 		IsRead: false,
 	}
 
-	return s.repo.Create(ctx, notification)
+	return s.create(ctx, notification)
+}
+
+// CreateAccountLockedNotification tells a user that logins were blocked after
+// repeated failed attempts. The resource is the account itself.
+func (s *NotificationService) CreateAccountLockedNotification(ctx context.Context, userID uuid.UUID, lockedUntil time.Time, failures int) error {
+	notification := &models.Notification{
+		UserID:       userID,
+		Type:         models.NotificationTypeAccountLocked,
+		ResourceType: "account",
+		ResourceID:   userID,
+		Metadata: models.NotificationMetadata{
+			"locked_until": lockedUntil.UTC().Format(time.RFC3339),
+			"failures":     failures,
+		},
+		IsRead: false,
+	}
+
+	return s.create(ctx, notification)
+}
+
+// CreateExpiringSoonNotification reminds a user that a voucher or gift card
+// they own or that is shared with them runs out in daysLeft days
+func (s *NotificationService) CreateExpiringSoonNotification(ctx context.Context, userID uuid.UUID, item models.ExpiringItem, daysLeft int) error {
+	notification := &models.Notification{
+		UserID:       userID,
+		Type:         models.NotificationTypeExpiringSoon,
+		ResourceType: item.ResourceType,
+		ResourceID:   item.ResourceID,
+		Metadata: models.NotificationMetadata{
+			"name":       item.Name,
+			"expires_at": item.ExpiresAt.UTC().Format(time.RFC3339),
+			"days_left":  daysLeft,
+		},
+		IsRead: false,
+	}
+
+	return s.create(ctx, notification)
+}
+
+// create stores the notification and queues its delivery. A failed enqueue
+// is only logged, the notification is still shown in the notification center.
+func (s *NotificationService) create(ctx context.Context, notification *models.Notification) error {
+	if err := s.repo.Create(ctx, notification); err != nil {
+		return err
+	}
+	if err := s.deliveries.Enqueue(ctx, notification); err != nil {
+		slog.Warn("Failed to queue notification delivery", "notification_id", notification.ID, "error", err)
+	}
+	s.publish(ctx, realtime.Event{UserID: notification.UserID, Type: realtime.EventNotification, NotificationID: notification.ID})
+	return nil
+}
+
+// publish sends the event to the open tabs. A failure is only logged, the
+// tabs catch up when they reconnect.
+func (s *NotificationService) publish(ctx context.Context, event realtime.Event) {
+	if err := s.events.Publish(ctx, event); err != nil {
+		slog.Warn("Failed to publish notification event", "user_id", event.UserID, "type", event.Type, "error", err)
+	}
 }
 
 // GetUserNotifications retrieves all notifications for a user with pagination
@@ -95,15 +170,37 @@ func (s *NotificationService) GetUnreadCount(ctx context.Context, userID uuid.UU
 
 // MarkAsRead marks a notification as read
 func (s *NotificationService) MarkAsRead(ctx context.Context, notificationID uuid.UUID) error {
-	return s.repo.MarkAsRead(ctx, notificationID)
+	return s.changeCount(ctx, notificationID, s.repo.MarkAsRead)
 }
 
 // MarkAllAsRead marks all unread notifications as read for a user
 func (s *NotificationService) MarkAllAsRead(ctx context.Context, userID uuid.UUID) error {
-	return s.repo.MarkAllAsRead(ctx, userID)
+	if err := s.repo.MarkAllAsRead(ctx, userID); err != nil {
+		return err
+	}
+	s.publish(ctx, realtime.Event{UserID: userID, Type: realtime.EventCount})
+	return nil
 }
 
 // DeleteNotification deletes a notification
 func (s *NotificationService) DeleteNotification(ctx context.Context, notificationID uuid.UUID) error {
-	return s.repo.Delete(ctx, notificationID)
+	return s.changeCount(ctx, notificationID, s.repo.Delete)
+}
+
+// changeCount applies a change to one notification and publishes the new
+// unread count to the tabs of its user. Unknown notifications are ignored,
+// e.g. when a second tab already deleted it.
+func (s *NotificationService) changeCount(ctx context.Context, notificationID uuid.UUID, change func(context.Context, uuid.UUID) error) error {
+	notification, err := s.repo.GetByID(ctx, notificationID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := change(ctx, notificationID); err != nil {
+		return err
+	}
+	s.publish(ctx, realtime.Event{UserID: notification.UserID, Type: realtime.EventCount, NotificationID: notificationID})
+	return nil
 }
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"savvy/internal/models"
+	"savvy/internal/realtime"
+)
+
+// fakeNotificationRepository keeps notifications in memory
+type fakeNotificationRepository struct {
+	notifications map[uuid.UUID]*models.Notification
+}
+
+func (r *fakeNotificationRepository) Create(_ context.Context, notification *models.Notification) error {
+	notification.ID = uuid.New()
+	r.notifications[notification.ID] = notification
+	return nil
+}
+
+func (r *fakeNotificationRepository) GetByID(_ context.Context, id uuid.UUID) (*models.Notification, error) {
+	if notification, ok := r.notifications[id]; ok {
+		return notification, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeNotificationRepository) GetByUserID(context.Context, uuid.UUID, int, int) ([]models.Notification, error) {
+	return nil, nil
+}
+
+func (r *fakeNotificationRepository) GetUnreadCount(_ context.Context, userID uuid.UUID) (int64, error) {
+	var count int64
+	for _, notification := range r.notifications {
+		if notification.UserID == userID && !notification.IsRead {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *fakeNotificationRepository) MarkAsRead(_ context.Context, id uuid.UUID) error {
+	if notification, ok := r.notifications[id]; ok {
+		notification.MarkAsRead()
+	}
+	return nil
+}
+
+func (r *fakeNotificationRepository) MarkAllAsRead(_ context.Context, userID uuid.UUID) error {
+	for _, notification := range r.notifications {
+		if notification.UserID == userID {
+			notification.MarkAsRead()
+		}
+	}
+	return nil
+}
+
+func (r *fakeNotificationRepository) Delete(_ context.Context, id uuid.UUID) error {
+	delete(r.notifications, id)
+	return nil
+}
+
+func TestNotificationService_PublishesEvents(t *testing.T) {
+	repo := &fakeNotificationRepository{notifications: map[uuid.UUID]*models.Notification{}}
+	deliveries := NewNotificationDeliveryService(&fakeNotificationDeliveryRepository{}, &fakeNotificationPreferenceRepository{}, &fakePushSubscriptionRepository{}, &fakeUserRepository{})
+	hub := realtime.NewLocalHub()
+	service := NewNotificationService(repo, deliveries, hub)
+	ctx := context.Background()
+
+	recipientID := uuid.New()
+	events, cancel := hub.Subscribe(recipientID)
+	defer cancel()
+
+	require.NoError(t, service.CreateShareNotification(ctx, recipientID, uuid.New(), "Ben", "card", uuid.New(), nil))
+	created := <-events
+	assert.Equal(t, realtime.EventNotification, created.Type)
+	assert.Equal(t, recipientID, created.UserID)
+	require.Contains(t, repo.notifications, created.NotificationID)
+
+	require.NoError(t, service.CreateTransferNotification(ctx, recipientID, uuid.New(), "Ben", "voucher", uuid.New()))
+	assert.Equal(t, realtime.EventNotification, (<-events).Type)
+
+	require.NoError(t, service.MarkAsRead(ctx, created.NotificationID))
+	read := <-events
+	assert.Equal(t, realtime.EventCount, read.Type)
+	assert.Equal(t, recipientID, read.UserID)
+
+	require.NoError(t, service.MarkAllAsRead(ctx, recipientID))
+	assert.Equal(t, realtime.EventCount, (<-events).Type)
+
+	require.NoError(t, service.DeleteNotification(ctx, created.NotificationID))
+	assert.Equal(t, realtime.EventCount, (<-events).Type)
+
+	// Deleted in another tab already
+	require.NoError(t, service.DeleteNotification(ctx, created.NotificationID))
+	assert.Empty(t, events)
+}
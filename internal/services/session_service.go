@@ -0,0 +1,46 @@
+// Package services contains business logic.
+package services
+
+import (
+	"context"
+	"savvy/internal/models"
+	"savvy/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// SessionServiceInterface defines the interface for managing a user's login sessions.
+type SessionServiceInterface interface {
+	// ListSessions returns the unexpired sessions of a user, most recently used first.
+	ListSessions(ctx context.Context, userID uuid.UUID) ([]models.UserSession, error)
+	// RevokeSession logs out a single device. Returns gorm.ErrRecordNotFound
+	// if the session does not belong to the user.
+	RevokeSession(ctx context.Context, id, userID uuid.UUID) error
+	// RevokeAllSessions logs out all devices of a user and returns how many sessions ended.
+	RevokeAllSessions(ctx context.Context, userID uuid.UUID) (int64, error)
+}
+
+// SessionService implements SessionServiceInterface.
+type SessionService struct {
+	repo repository.UserSessionRepository
+}
+
+// NewSessionService creates a new session service.
+func NewSessionService(repo repository.UserSessionRepository) SessionServiceInterface {
+	return &SessionService{repo: repo}
+}
+
+// ListSessions returns the unexpired sessions of a user.
+func (s *SessionService) ListSessions(ctx context.Context, userID uuid.UUID) ([]models.UserSession, error) {
+	return s.repo.GetActiveByUserID(ctx, userID)
+}
+
+// RevokeSession deletes the session; the device is logged out with its next request.
+func (s *SessionService) RevokeSession(ctx context.Context, id, userID uuid.UUID) error {
+	return s.repo.Delete(ctx, id, userID)
+}
+
+// RevokeAllSessions deletes all sessions of the user, including the current one.
+func (s *SessionService) RevokeAllSessions(ctx context.Context, userID uuid.UUID) (int64, error) {
+	return s.repo.DeleteByUserID(ctx, userID)
+}
@@ -12,6 +12,10 @@ import (
 	"gorm.io/gorm"
 )
 
+// ErrShareTargetUnverified is returned when sharing with a user who has not
+// confirmed their email address yet
+var ErrShareTargetUnverified = errors.New("user has not verified their email address")
+
 // ShareServiceInterface defines the interface for share business logic.
 type ShareServiceInterface interface {
 	CreateCardShare(ctx context.Context, cardID, sharedWithID uuid.UUID, canEdit, canDelete bool) error
@@ -73,6 +77,10 @@ func (s *ShareService) CreateCardShare(ctx context.Context, cardID, sharedWithID
 		return errors.New("cannot share card with its owner")
 	}
 
+	if err := s.requireVerifiedTarget(ctx, sharedWithID); err != nil {
+		return err
+	}
+
 	share := models.CardShare{
 		CardID:       cardID,
 		SharedWithID: sharedWithID,
@@ -157,6 +165,10 @@ func (s *ShareService) CreateVoucherShare(ctx context.Context, voucherID, shared
 		return errors.New("cannot share voucher with its owner")
 	}
 
+	if err := s.requireVerifiedTarget(ctx, sharedWithID); err != nil {
+		return err
+	}
+
 	share := models.VoucherShare{
 		VoucherID:    voucherID,
 		SharedWithID: sharedWithID,
@@ -217,6 +229,10 @@ func (s *ShareService) CreateGiftCardShare(ctx context.Context, giftCardID, shar
 		return errors.New("cannot share gift card with its owner")
 	}
 
+	if err := s.requireVerifiedTarget(ctx, sharedWithID); err != nil {
+		return err
+	}
+
 	share := models.GiftCardShare{
 		GiftCardID:          giftCardID,
 		SharedWithID:        sharedWithID,
@@ -258,6 +274,22 @@ func (s *ShareService) CreateGiftCardShare(ctx context.Context, giftCardID, shar
 	return nil
 }
 
+// requireVerifiedTarget rejects share targets whose email address is not
+// confirmed, so nothing is shared with a mistyped or foreign address
+func (s *ShareService) requireVerifiedTarget(ctx context.Context, sharedWithID uuid.UUID) error {
+	var target models.User
+	if err := s.db.WithContext(ctx).Select("id", "email_verified_at").Where("id = ?", sharedWithID).First(&target).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+	if !target.IsEmailVerified() {
+		return ErrShareTargetUnverified
+	}
+	return nil
+}
+
 // GetCardShares retrieves all active (non-deleted) shares for a card.
 func (s *ShareService) GetCardShares(ctx context.Context, cardID uuid.UUID) ([]models.CardShare, error) {
 	var shares []models.CardShare
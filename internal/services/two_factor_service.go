@@ -0,0 +1,234 @@
+// Package services contains business logic.
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"savvy/internal/models"
+	"savvy/internal/totp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RecoveryCodeCount is the number of recovery codes generated at once
+const RecoveryCodeCount = 10
+
+// recoveryCodeAlphabet leaves out characters that are easily confused (0/O, 1/I/L)
+const recoveryCodeAlphabet = "23456789abcdefghjkmnpqrstuvwxyz"
+
+var (
+	// ErrInvalidTwoFactorCode indicates a wrong, expired or already used TOTP or recovery code
+	ErrInvalidTwoFactorCode = errors.New("invalid two-factor code")
+	// ErrTwoFactorAlreadyEnabled indicates the user has to disable 2FA before enrolling again
+	ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	// ErrTwoFactorNotEnabled indicates the user has no TOTP secret
+	ErrTwoFactorNotEnabled = errors.New("two-factor authentication is not enabled")
+)
+
+// TwoFactorServiceInterface defines the interface for TOTP two-factor authentication.
+type TwoFactorServiceInterface interface {
+	// Enable stores the secret after checking a code generated with it and
+	// returns new recovery codes, which are only shown once.
+	Enable(ctx context.Context, userID uuid.UUID, secret, code string) ([]string, error)
+	// Disable removes the secret and all recovery codes.
+	Disable(ctx context.Context, userID uuid.UUID) error
+	// Verify checks a TOTP code or, if it is not one, a recovery code. Each
+	// code is accepted only once. Reports whether a recovery code was used.
+	Verify(ctx context.Context, user *models.User, code string) (bool, error)
+	// RegenerateRecoveryCodes replaces all recovery codes of the user.
+	RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID) ([]string, error)
+	// RemainingRecoveryCodes returns the number of unused recovery codes.
+	RemainingRecoveryCodes(ctx context.Context, userID uuid.UUID) (int64, error)
+}
+
+// TwoFactorService implements TwoFactorServiceInterface.
+type TwoFactorService struct {
+	db  *gorm.DB
+	now func() time.Time
+}
+
+// NewTwoFactorService creates a new two-factor service.
+func NewTwoFactorService(db *gorm.DB) TwoFactorServiceInterface {
+	return &TwoFactorService{db: db, now: time.Now}
+}
+
+// Enable stores the secret and creates the first recovery codes.
+func (s *TwoFactorService) Enable(ctx context.Context, userID uuid.UUID, secret, code string) ([]string, error) {
+	step, ok := totp.Validate(secret, code, s.now())
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	var codes []string
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.First(&user, "id = ?", userID).Error; err != nil {
+			return err
+		}
+		if user.HasTwoFactor() {
+			return ErrTwoFactorAlreadyEnabled
+		}
+
+		enabledAt := s.now()
+		user.TOTPSecret = secret
+		user.TOTPEnabledAt = &enabledAt
+		user.TOTPLastStep = step
+		// Struct update so the secret passes through the encryption serializer
+		if err := tx.Model(&user).Select("TOTPSecret", "TOTPEnabledAt", "TOTPLastStep").Updates(&user).Error; err != nil {
+			return err
+		}
+
+		var err error
+		codes, err = replaceRecoveryCodes(tx, userID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Disable removes the secret and the recovery codes.
+func (s *TwoFactorService) Disable(ctx context.Context, userID uuid.UUID) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.User{}).
+			Where("id = ? AND totp_enabled_at IS NOT NULL", userID).
+			Updates(map[string]interface{}{
+				"totp_secret":     "",
+				"totp_enabled_at": nil,
+				"totp_last_step":  0,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrTwoFactorNotEnabled
+		}
+		return tx.Where("user_id = ?", userID).Delete(&models.UserRecoveryCode{}).Error
+	})
+}
+
+// Verify checks a TOTP code first, then a recovery code.
+func (s *TwoFactorService) Verify(ctx context.Context, user *models.User, code string) (bool, error) {
+	if !user.HasTwoFactor() {
+		return false, ErrTwoFactorNotEnabled
+	}
+
+	db := s.db.WithContext(ctx)
+	if step, ok := totp.Validate(user.TOTPSecret, code, s.now()); ok {
+		// Advancing the last step in a single statement rejects replays,
+		// also when the same code is submitted twice in parallel
+		result := db.Model(&models.User{}).
+			Where("id = ? AND totp_last_step < ?", user.ID, step).
+			Update("totp_last_step", step)
+		if result.Error != nil {
+			return false, result.Error
+		}
+		if result.RowsAffected == 0 {
+			return false, ErrInvalidTwoFactorCode
+		}
+		user.TOTPLastStep = step
+		return false, nil
+	}
+
+	result := db.Model(&models.UserRecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", user.ID, hashRecoveryCode(user.ID, code)).
+		Update("used_at", s.now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return false, ErrInvalidTwoFactorCode
+	}
+	return true, nil
+}
+
+// RegenerateRecoveryCodes invalidates all previous recovery codes.
+func (s *TwoFactorService) RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	var codes []string
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.User{}).Where("id = ? AND totp_enabled_at IS NOT NULL", userID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return ErrTwoFactorNotEnabled
+		}
+
+		var err error
+		codes, err = replaceRecoveryCodes(tx, userID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// RemainingRecoveryCodes counts the unused recovery codes.
+func (s *TwoFactorService) RemainingRecoveryCodes(ctx context.Context, userID uuid.UUID) (int64, error) {
+	var count int64
+	err := s.db.WithContext(ctx).Model(&models.UserRecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
+
+// replaceRecoveryCodes deletes the user's recovery codes and stores new ones.
+func replaceRecoveryCodes(tx *gorm.DB, userID uuid.UUID) ([]string, error) {
+	if err := tx.Where("user_id = ?", userID).Delete(&models.UserRecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, RecoveryCodeCount)
+	records := make([]models.UserRecoveryCode, RecoveryCodeCount)
+	for i := range codes {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes[i] = code
+		records[i] = models.UserRecoveryCode{UserID: userID, CodeHash: hashRecoveryCode(userID, code)}
+	}
+
+	if err := tx.Create(&records).Error; err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// generateRecoveryCode returns a random code like "k7mq2-x9hcv"
+func generateRecoveryCode() (string, error) {
+	buf := make([]byte, 10)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	for i, b := range buf {
+		if i == 5 {
+			sb.WriteByte('-')
+		}
+		// 256 is not a multiple of the alphabet length, the bias is negligible
+		// for codes that can only be tried a few times
+		sb.WriteByte(recoveryCodeAlphabet[int(b)%len(recoveryCodeAlphabet)])
+	}
+	return sb.String(), nil
+}
+
+// hashRecoveryCode hashes a normalized recovery code. The user ID is part of
+// the hash so equal codes of different users do not collide.
+func hashRecoveryCode(userID uuid.UUID, code string) string {
+	normalized := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), " ", ""))
+	if !strings.Contains(normalized, "-") && len(normalized) == 10 {
+		normalized = normalized[:5] + "-" + normalized[5:]
+	}
+	sum := sha256.Sum256([]byte(userID.String() + ":" + normalized))
+	return hex.EncodeToString(sum[:])
+}
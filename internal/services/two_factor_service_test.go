@@ -0,0 +1,150 @@
+package services
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"savvy/internal/models"
+	"savvy/internal/totp"
+)
+
+func setupTwoFactorTest(t *testing.T) (*TwoFactorService, *models.User, string, time.Time) {
+	t.Helper()
+	db := setupTestDB(t)
+
+	user := &models.User{ID: uuid.New(), Email: "2fa@example.com", PasswordHash: "hashed"}
+	require.NoError(t, db.Create(user).Error)
+
+	secret, err := totp.GenerateSecret()
+	require.NoError(t, err)
+
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	service := &TwoFactorService{db: db, now: func() time.Time { return now }}
+	return service, user, secret, now
+}
+
+func codeAt(t *testing.T, secret string, at time.Time) string {
+	t.Helper()
+	code, err := totp.Code(secret, totp.Step(at))
+	require.NoError(t, err)
+	return code
+}
+
+func TestTwoFactorService_Enable(t *testing.T) {
+	service, user, secret, now := setupTwoFactorTest(t)
+	ctx := context.Background()
+
+	_, err := service.Enable(ctx, user.ID, secret, "000000")
+	assert.ErrorIs(t, err, ErrInvalidTwoFactorCode)
+
+	codes, err := service.Enable(ctx, user.ID, secret, codeAt(t, secret, now))
+	require.NoError(t, err)
+	assert.Len(t, codes, RecoveryCodeCount)
+
+	var stored models.User
+	require.NoError(t, service.db.First(&stored, "id = ?", user.ID).Error)
+	assert.True(t, stored.HasTwoFactor())
+	assert.Equal(t, secret, stored.TOTPSecret)
+
+	remaining, err := service.RemainingRecoveryCodes(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(RecoveryCodeCount), remaining)
+
+	_, err = service.Enable(ctx, user.ID, secret, codeAt(t, secret, now))
+	assert.ErrorIs(t, err, ErrTwoFactorAlreadyEnabled)
+}
+
+func TestTwoFactorService_VerifyRejectsReplay(t *testing.T) {
+	service, user, secret, now := setupTwoFactorTest(t)
+	ctx := context.Background()
+
+	_, err := service.Enable(ctx, user.ID, secret, codeAt(t, secret, now))
+	require.NoError(t, err)
+	require.NoError(t, service.db.First(user, "id = ?", user.ID).Error)
+
+	// The enrollment code cannot be used to log in
+	_, err = service.Verify(ctx, user, codeAt(t, secret, now))
+	assert.ErrorIs(t, err, ErrInvalidTwoFactorCode)
+
+	next := now.Add(totp.Period)
+	service.now = func() time.Time { return next }
+	usedRecovery, err := service.Verify(ctx, user, codeAt(t, secret, next))
+	require.NoError(t, err)
+	assert.False(t, usedRecovery)
+
+	_, err = service.Verify(ctx, user, codeAt(t, secret, next))
+	assert.ErrorIs(t, err, ErrInvalidTwoFactorCode)
+}
+
+func TestTwoFactorService_RecoveryCodes(t *testing.T) {
+	service, user, secret, now := setupTwoFactorTest(t)
+	ctx := context.Background()
+
+	codes, err := service.Enable(ctx, user.ID, secret, codeAt(t, secret, now))
+	require.NoError(t, err)
+	require.NoError(t, service.db.First(user, "id = ?", user.ID).Error)
+
+	usedRecovery, err := service.Verify(ctx, user, codes[0])
+	require.NoError(t, err)
+	assert.True(t, usedRecovery)
+
+	_, err = service.Verify(ctx, user, codes[0])
+	assert.ErrorIs(t, err, ErrInvalidTwoFactorCode)
+
+	remaining, err := service.RemainingRecoveryCodes(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(RecoveryCodeCount-1), remaining)
+
+	newCodes, err := service.RegenerateRecoveryCodes(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Len(t, newCodes, RecoveryCodeCount)
+
+	_, err = service.Verify(ctx, user, codes[1])
+	assert.ErrorIs(t, err, ErrInvalidTwoFactorCode)
+}
+
+func TestTwoFactorService_Disable(t *testing.T) {
+	service, user, secret, now := setupTwoFactorTest(t)
+	ctx := context.Background()
+
+	assert.ErrorIs(t, service.Disable(ctx, user.ID), ErrTwoFactorNotEnabled)
+
+	_, err := service.Enable(ctx, user.ID, secret, codeAt(t, secret, now))
+	require.NoError(t, err)
+	require.NoError(t, service.Disable(ctx, user.ID))
+
+	var stored models.User
+	require.NoError(t, service.db.First(&stored, "id = ?", user.ID).Error)
+	assert.False(t, stored.HasTwoFactor())
+	assert.Empty(t, stored.TOTPSecret)
+
+	remaining, err := service.RemainingRecoveryCodes(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Zero(t, remaining)
+}
+
+func TestGenerateRecoveryCode(t *testing.T) {
+	pattern := regexp.MustCompile(`^[2-9a-z]{5}-[2-9a-z]{5}$`)
+	seen := make(map[string]bool)
+	for i := 0; i < 50; i++ {
+		code, err := generateRecoveryCode()
+		require.NoError(t, err)
+		assert.Regexp(t, pattern, code)
+		assert.False(t, seen[code], "duplicate code %s", code)
+		seen[code] = true
+	}
+}
+
+func TestHashRecoveryCode_Normalizes(t *testing.T) {
+	userID := uuid.New()
+	want := hashRecoveryCode(userID, "k7mq2-x9hcv")
+
+	assert.Equal(t, want, hashRecoveryCode(userID, " K7MQ2-X9HCV "))
+	assert.Equal(t, want, hashRecoveryCode(userID, "k7mq2x9hcv"))
+	assert.NotEqual(t, want, hashRecoveryCode(uuid.New(), "k7mq2-x9hcv"))
+}
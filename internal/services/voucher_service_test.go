@@ -87,7 +87,7 @@ func TestVoucherService_CreateVoucher_Success(t *testing.T) {
 		Code:         "SAVE20",
 		MerchantName: "Test Merchant",
 		Type:         "percentage",
-		Value:        20.0,
+		Value:        2000,
 		ValidFrom:    validFrom,
 		ValidUntil:   validUntil,
 	}
@@ -110,7 +110,7 @@ func TestVoucherService_CreateVoucher_MissingMerchantName(t *testing.T) {
 		UserID: &userID,
 		Code:   "SAVE20",
 		Type:   "percentage",
-		Value:  20.0,
+		Value:  2000,
 	}
 
 	err := service.CreateVoucher(ctx, voucher)
@@ -130,7 +130,7 @@ func TestVoucherService_CreateVoucher_MissingCode(t *testing.T) {
 		UserID:       &userID,
 		MerchantName: "Test Merchant",
 		Type:         "percentage",
-		Value:        20.0,
+		Value:        2000,
 	}
 
 	err := service.CreateVoucher(ctx, voucher)
@@ -149,7 +149,7 @@ func TestVoucherService_CreateVoucher_MissingType(t *testing.T) {
 		UserID:       &userID,
 		Code:         "SAVE20",
 		MerchantName: "Test Merchant",
-		Value:        20.0,
+		Value:        2000,
 	}
 
 	err := service.CreateVoucher(ctx, voucher)
@@ -192,7 +192,7 @@ func TestVoucherService_CreateVoucher_InvalidDateRange(t *testing.T) {
 		Code:         "SAVE20",
 		MerchantName: "Test Merchant",
 		Type:         "percentage",
-		Value:        20.0,
+		Value:        2000,
 		ValidFrom:    validFrom,
 		ValidUntil:   validUntil,
 	}
@@ -216,7 +216,7 @@ func TestVoucherService_GetVoucher_Success(t *testing.T) {
 		Code:         "SAVE20",
 		MerchantName: "Test Merchant",
 		Type:         "percentage",
-		Value:        20.0,
+		Value:        2000,
 	}
 
 	mockRepo.On("GetByID", ctx, voucherID, []string{"Merchant", "User"}).Return(expectedVoucher, nil)
@@ -281,7 +281,7 @@ func TestVoucherService_UpdateVoucher_Success(t *testing.T) {
 		Code:         "UPDATED20",
 		MerchantName: "Updated Merchant",
 		Type:         "percentage",
-		Value:        25.0,
+		Value:        2500,
 	}
 
 	mockRepo.On("Update", ctx, voucher).Return(nil)
@@ -302,7 +302,7 @@ func TestVoucherService_UpdateVoucher_ValidationError(t *testing.T) {
 		Code:         "SAVE20",
 		MerchantName: "Test Merchant",
 		Type:         "percentage",
-		Value:        -10, // Invalid
+		Value:        -1000, // Invalid
 	}
 
 	err := service.UpdateVoucher(ctx, voucher)
@@ -0,0 +1,439 @@
+// Package services contains business logic.
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"savvy/internal/models"
+	"savvy/internal/repository"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// MaxPasskeyNameLength is the maximum length of a passkey name
+const MaxPasskeyNameLength = 100
+
+// passkeyCeremonyTimeout is how long the browser and the server wait for the
+// user to confirm with the authenticator
+const passkeyCeremonyTimeout = 5 * time.Minute
+
+var (
+	// ErrWebAuthnDisabled indicates WEBAUTHN_RP_ID is not configured
+	ErrWebAuthnDisabled = errors.New("passkeys are not configured")
+	// ErrInvalidPasskey indicates a failed registration or login ceremony
+	ErrInvalidPasskey = errors.New("invalid passkey response")
+	// ErrPasskeyCloned indicates a signature counter that did not increase,
+	// which means the private key may have been copied
+	ErrPasskeyCloned = errors.New("passkey signature counter did not increase")
+	// ErrInvalidPasskeyName indicates an empty or too long passkey name
+	ErrInvalidPasskeyName = errors.New("invalid passkey name")
+	// ErrPasskeyNotFound indicates the passkey does not exist or belongs to another user
+	ErrPasskeyNotFound = errors.New("passkey not found")
+	// ErrPasskeysUnavailable indicates an OIDC account, which signs in at its provider
+	ErrPasskeysUnavailable = errors.New("passkeys are only available for local accounts")
+)
+
+// WebAuthnServiceInterface defines the interface for passkey registration
+// and login. Each ceremony returns session data in its Begin step that has to
+// be passed to the matching Finish step.
+type WebAuthnServiceInterface interface {
+	// Configure sets the relying party. Without it all ceremonies fail with ErrWebAuthnDisabled.
+	Configure(rpID, rpName string, origins []string) error
+	// Enabled reports whether the relying party is configured.
+	Enabled() bool
+
+	// BeginRegistration creates the options for navigator.credentials.create().
+	BeginRegistration(ctx context.Context, user *models.User) (*protocol.CredentialCreation, *webauthn.SessionData, error)
+	// FinishRegistration validates the authenticator response and stores the passkey.
+	FinishRegistration(ctx context.Context, user *models.User, session webauthn.SessionData, name string, response []byte) (*models.WebAuthnCredential, error)
+
+	// BeginLogin creates the options for a passwordless login with a discoverable passkey.
+	BeginLogin(ctx context.Context) (*protocol.CredentialAssertion, *webauthn.SessionData, error)
+	// FinishLogin validates the assertion and returns the user it belongs to.
+	FinishLogin(ctx context.Context, session webauthn.SessionData, response []byte) (*models.User, error)
+
+	// BeginSecondFactor creates the options to confirm a password login with one of the user's passkeys.
+	BeginSecondFactor(ctx context.Context, user *models.User) (*protocol.CredentialAssertion, *webauthn.SessionData, error)
+	// FinishSecondFactor validates the assertion for the user.
+	FinishSecondFactor(ctx context.Context, user *models.User, session webauthn.SessionData, response []byte) error
+
+	ListCredentials(ctx context.Context, userID uuid.UUID) ([]models.WebAuthnCredential, error)
+	CountCredentials(ctx context.Context, userID uuid.UUID) (int64, error)
+	RenameCredential(ctx context.Context, id, userID uuid.UUID, name string) error
+	DeleteCredential(ctx context.Context, id, userID uuid.UUID) error
+}
+
+// WebAuthnService implements WebAuthnServiceInterface.
+type WebAuthnService struct {
+	repo     repository.WebAuthnCredentialRepository
+	userRepo repository.UserRepository
+	now      func() time.Time
+
+	mu       sync.RWMutex
+	webAuthn *webauthn.WebAuthn
+}
+
+// NewWebAuthnService creates a new passkey service. It stays disabled until
+// Configure is called.
+func NewWebAuthnService(repo repository.WebAuthnCredentialRepository, userRepo repository.UserRepository) WebAuthnServiceInterface {
+	return &WebAuthnService{repo: repo, userRepo: userRepo, now: time.Now}
+}
+
+// Configure creates the relying party. The RP ID is the domain the passkeys
+// are bound to, the origins are the URLs the login page is served from.
+func (s *WebAuthnService) Configure(rpID, rpName string, origins []string) error {
+	w, err := webauthn.New(&webauthn.Config{
+		RPID:          rpID,
+		RPDisplayName: rpName,
+		RPOrigins:     origins,
+		Timeouts: webauthn.TimeoutsConfig{
+			Login:        webauthn.TimeoutConfig{Enforce: true, Timeout: passkeyCeremonyTimeout, TimeoutUVD: passkeyCeremonyTimeout},
+			Registration: webauthn.TimeoutConfig{Enforce: true, Timeout: passkeyCeremonyTimeout, TimeoutUVD: passkeyCeremonyTimeout},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.webAuthn = w
+	return nil
+}
+
+// Enabled reports whether Configure succeeded.
+func (s *WebAuthnService) Enabled() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.webAuthn != nil
+}
+
+func (s *WebAuthnService) relyingParty() (*webauthn.WebAuthn, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.webAuthn == nil {
+		return nil, ErrWebAuthnDisabled
+	}
+	return s.webAuthn, nil
+}
+
+// BeginRegistration excludes the user's existing passkeys, so the same
+// authenticator is not registered twice, and asks for a discoverable
+// credential, which allows passwordless login.
+func (s *WebAuthnService) BeginRegistration(ctx context.Context, user *models.User) (*protocol.CredentialCreation, *webauthn.SessionData, error) {
+	w, err := s.relyingParty()
+	if err != nil {
+		return nil, nil, err
+	}
+	if user.IsOAuthUser() {
+		return nil, nil, ErrPasskeysUnavailable
+	}
+
+	account, err := s.loadUser(ctx, user)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return w.BeginRegistration(account,
+		webauthn.WithExclusions(webauthn.Credentials(account.credentials).CredentialDescriptors()),
+		webauthn.WithAuthenticatorSelection(protocol.AuthenticatorSelection{UserVerification: protocol.VerificationPreferred}),
+		webauthn.WithResidentKeyRequirement(protocol.ResidentKeyRequirementPreferred),
+	)
+}
+
+// FinishRegistration stores the passkey under the given name.
+func (s *WebAuthnService) FinishRegistration(ctx context.Context, user *models.User, session webauthn.SessionData, name string, response []byte) (*models.WebAuthnCredential, error) {
+	w, err := s.relyingParty()
+	if err != nil {
+		return nil, err
+	}
+	if user.IsOAuthUser() {
+		return nil, ErrPasskeysUnavailable
+	}
+
+	name, err = normalizePasskeyName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := protocol.ParseCredentialCreationResponseBytes(response)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPasskey, err)
+	}
+
+	account, err := s.loadUser(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	credential, err := w.CreateCredential(account, session, parsed)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPasskey, err)
+	}
+
+	record := &models.WebAuthnCredential{
+		UserID:          user.ID,
+		Name:            name,
+		CredentialID:    credential.ID,
+		PublicKey:       credential.PublicKey,
+		AttestationType: credential.AttestationType,
+		Transports:      joinTransports(credential.Transport),
+		AAGUID:          credential.Authenticator.AAGUID,
+		SignCount:       int64(credential.Authenticator.SignCount),
+		BackupEligible:  credential.Flags.BackupEligible,
+		BackupState:     credential.Flags.BackupState,
+	}
+	if err := s.repo.Create(ctx, record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// BeginLogin does not name a user, the browser offers all passkeys it has
+// for this site. User verification is required, because the passkey
+// replaces both the password and the second factor.
+func (s *WebAuthnService) BeginLogin(_ context.Context) (*protocol.CredentialAssertion, *webauthn.SessionData, error) {
+	w, err := s.relyingParty()
+	if err != nil {
+		return nil, nil, err
+	}
+	return w.BeginDiscoverableLogin(webauthn.WithUserVerification(protocol.VerificationRequired))
+}
+
+// FinishLogin looks up the user by the user handle stored on the passkey.
+func (s *WebAuthnService) FinishLogin(ctx context.Context, session webauthn.SessionData, response []byte) (*models.User, error) {
+	w, err := s.relyingParty()
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponseBytes(response)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPasskey, err)
+	}
+
+	var account *webAuthnUser
+	handler := func(_, userHandle []byte) (webauthn.User, error) {
+		userID, err := uuid.FromBytes(userHandle)
+		if err != nil {
+			return nil, err
+		}
+		user, err := s.userRepo.GetByID(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if user.IsOAuthUser() {
+			return nil, ErrPasskeysUnavailable
+		}
+		account, err = s.loadUser(ctx, user)
+		return account, err
+	}
+
+	_, credential, err := w.ValidatePasskeyLogin(handler, session, parsed)
+	if err != nil {
+		if errors.Is(err, ErrPasskeysUnavailable) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPasskey, err)
+	}
+
+	if err := s.recordLogin(ctx, account, credential); err != nil {
+		return nil, err
+	}
+	return account.user, nil
+}
+
+// BeginSecondFactor only allows the user's own passkeys. User presence is
+// enough, the password was already checked.
+func (s *WebAuthnService) BeginSecondFactor(ctx context.Context, user *models.User) (*protocol.CredentialAssertion, *webauthn.SessionData, error) {
+	w, err := s.relyingParty()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	account, err := s.loadUser(ctx, user)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(account.credentials) == 0 {
+		return nil, nil, ErrPasskeyNotFound
+	}
+
+	return w.BeginLogin(account, webauthn.WithUserVerification(protocol.VerificationDiscouraged))
+}
+
+// FinishSecondFactor checks that one of the user's passkeys signed the challenge.
+func (s *WebAuthnService) FinishSecondFactor(ctx context.Context, user *models.User, session webauthn.SessionData, response []byte) error {
+	w, err := s.relyingParty()
+	if err != nil {
+		return err
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponseBytes(response)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPasskey, err)
+	}
+
+	account, err := s.loadUser(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	credential, err := w.ValidateLogin(account, session, parsed)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPasskey, err)
+	}
+	return s.recordLogin(ctx, account, credential)
+}
+
+// recordLogin rejects a cloned authenticator and stores the new signature
+// counter. Synced passkeys always report 0, which is accepted.
+func (s *WebAuthnService) recordLogin(ctx context.Context, account *webAuthnUser, credential *webauthn.Credential) error {
+	if credential.Authenticator.CloneWarning {
+		return ErrPasskeyCloned
+	}
+
+	record := account.record(credential.ID)
+	if record == nil {
+		return ErrPasskeyNotFound
+	}
+
+	usedAt := s.now()
+	record.SignCount = int64(credential.Authenticator.SignCount)
+	record.BackupState = credential.Flags.BackupState
+	record.LastUsedAt = &usedAt
+	return s.repo.UpdateAfterLogin(ctx, record)
+}
+
+// ListCredentials returns the user's passkeys, oldest first.
+func (s *WebAuthnService) ListCredentials(ctx context.Context, userID uuid.UUID) ([]models.WebAuthnCredential, error) {
+	return s.repo.GetByUserID(ctx, userID)
+}
+
+// CountCredentials counts the user's passkeys.
+func (s *WebAuthnService) CountCredentials(ctx context.Context, userID uuid.UUID) (int64, error) {
+	return s.repo.CountByUserID(ctx, userID)
+}
+
+// RenameCredential changes the name of one of the user's passkeys.
+func (s *WebAuthnService) RenameCredential(ctx context.Context, id, userID uuid.UUID, name string) error {
+	name, err := normalizePasskeyName(name)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Rename(ctx, id, userID, name); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrPasskeyNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// DeleteCredential removes one of the user's passkeys.
+func (s *WebAuthnService) DeleteCredential(ctx context.Context, id, userID uuid.UUID) error {
+	if err := s.repo.Delete(ctx, id, userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrPasskeyNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// loadUser wraps the user and its stored passkeys for the webauthn library.
+func (s *WebAuthnService) loadUser(ctx context.Context, user *models.User) (*webAuthnUser, error) {
+	records, err := s.repo.GetByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	credentials := make([]webauthn.Credential, len(records))
+	for i, record := range records {
+		credentials[i] = webauthn.Credential{
+			ID:              record.CredentialID,
+			PublicKey:       record.PublicKey,
+			AttestationType: record.AttestationType,
+			Transport:       splitTransports(record.Transports),
+			Flags: webauthn.CredentialFlags{
+				BackupEligible: record.BackupEligible,
+				BackupState:    record.BackupState,
+			},
+			Authenticator: webauthn.Authenticator{
+				AAGUID:    record.AAGUID,
+				SignCount: uint32(record.SignCount), // #nosec G115 - stored from a uint32
+			},
+		}
+	}
+	return &webAuthnUser{user: user, records: records, credentials: credentials}, nil
+}
+
+// webAuthnUser implements webauthn.User. The user handle is the user ID, so
+// a discoverable passkey leads back to the account without an email address.
+type webAuthnUser struct {
+	user        *models.User
+	records     []models.WebAuthnCredential
+	credentials []webauthn.Credential
+}
+
+func (u *webAuthnUser) WebAuthnID() []byte {
+	return u.user.ID[:]
+}
+
+func (u *webAuthnUser) WebAuthnName() string {
+	return u.user.Email
+}
+
+func (u *webAuthnUser) WebAuthnDisplayName() string {
+	return u.user.DisplayName()
+}
+
+func (u *webAuthnUser) WebAuthnCredentials() []webauthn.Credential {
+	return u.credentials
+}
+
+// record returns the stored passkey with the given credential ID.
+func (u *webAuthnUser) record(credentialID []byte) *models.WebAuthnCredential {
+	for i := range u.records {
+		if bytes.Equal(u.records[i].CredentialID, credentialID) {
+			return &u.records[i]
+		}
+	}
+	return nil
+}
+
+func normalizePasskeyName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len([]rune(name)) > MaxPasskeyNameLength {
+		return "", ErrInvalidPasskeyName
+	}
+	return name, nil
+}
+
+func joinTransports(transports []protocol.AuthenticatorTransport) string {
+	values := make([]string, len(transports))
+	for i, transport := range transports {
+		values[i] = string(transport)
+	}
+	return strings.Join(values, ",")
+}
+
+func splitTransports(value string) []protocol.AuthenticatorTransport {
+	if value == "" {
+		return nil
+	}
+	parts := strings.Split(value, ",")
+	transports := make([]protocol.AuthenticatorTransport, len(parts))
+	for i, part := range parts {
+		transports[i] = protocol.AuthenticatorTransport(part)
+	}
+	return transports
+}
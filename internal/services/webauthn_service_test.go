@@ -0,0 +1,412 @@
+package services
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/go-webauthn/webauthn/protocol/webauthncbor"
+	"github.com/go-webauthn/webauthn/protocol/webauthncose"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"savvy/internal/models"
+)
+
+const (
+	testRPID   = "savvy.example"
+	testOrigin = "https://savvy.example"
+)
+
+// Authenticator data flags
+const (
+	flagUserPresent    = 0x01
+	flagUserVerified   = 0x04
+	flagBackupEligible = 0x08
+	flagBackupState    = 0x10
+	flagAttestedData   = 0x40
+)
+
+// softAuthenticator is a software passkey. It signs with a P-256 key and
+// reports a signature counter the test controls.
+type softAuthenticator struct {
+	t            *testing.T
+	key          *ecdsa.PrivateKey
+	credentialID []byte
+	userHandle   []byte
+	counter      uint32
+	synced       bool
+}
+
+func newSoftAuthenticator(t *testing.T) *softAuthenticator {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	credentialID := make([]byte, 16)
+	_, err = rand.Read(credentialID)
+	require.NoError(t, err)
+	return &softAuthenticator{t: t, key: key, credentialID: credentialID}
+}
+
+func (a *softAuthenticator) authenticatorData(flags byte, attested []byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(testRPID))
+	data := append([]byte{}, rpIDHash[:]...)
+	if a.synced {
+		flags |= flagBackupEligible | flagBackupState
+	}
+	data = append(data, flags)
+	data = binary.BigEndian.AppendUint32(data, a.counter)
+	return append(data, attested...)
+}
+
+func (a *softAuthenticator) clientData(ceremony, challenge string) []byte {
+	clientData, err := json.Marshal(map[string]string{
+		"type":      ceremony,
+		"challenge": challenge,
+		"origin":    testOrigin,
+	})
+	require.NoError(a.t, err)
+	return clientData
+}
+
+// create answers navigator.credentials.create() with "none" attestation.
+func (a *softAuthenticator) create(session *webauthn.SessionData) []byte {
+	a.userHandle = session.UserID
+
+	x := a.key.X.FillBytes(make([]byte, 32))
+	y := a.key.Y.FillBytes(make([]byte, 32))
+	publicKey, err := webauthncbor.Marshal(webauthncose.EC2PublicKeyData{
+		PublicKeyData: webauthncose.PublicKeyData{KeyType: int64(webauthncose.EllipticKey), Algorithm: int64(webauthncose.AlgES256)},
+		Curve:         int64(webauthncose.P256),
+		XCoord:        x,
+		YCoord:        y,
+	})
+	require.NoError(a.t, err)
+
+	attested := make([]byte, 16) // AAGUID of an unknown model
+	attested = binary.BigEndian.AppendUint16(attested, uint16(len(a.credentialID)))
+	attested = append(attested, a.credentialID...)
+	attested = append(attested, publicKey...)
+
+	attestation, err := webauthncbor.Marshal(map[string]any{
+		"fmt":      "none",
+		"attStmt":  map[string]any{},
+		"authData": a.authenticatorData(flagUserPresent|flagUserVerified|flagAttestedData, attested),
+	})
+	require.NoError(a.t, err)
+
+	return a.marshal(map[string]any{
+		"clientDataJSON":    b64(a.clientData("webauthn.create", session.Challenge)),
+		"attestationObject": b64(attestation),
+		"transports":        []string{"internal", "hybrid"},
+	})
+}
+
+// get answers navigator.credentials.get() after increasing the counter by step.
+func (a *softAuthenticator) get(session *webauthn.SessionData, step uint32) []byte {
+	a.counter += step
+	authData := a.authenticatorData(flagUserPresent|flagUserVerified, nil)
+	clientData := a.clientData("webauthn.get", session.Challenge)
+
+	clientDataHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	require.NoError(a.t, err)
+
+	return a.marshal(map[string]any{
+		"clientDataJSON":    b64(clientData),
+		"authenticatorData": b64(authData),
+		"signature":         b64(signature),
+		"userHandle":        b64(a.userHandle),
+	})
+}
+
+func (a *softAuthenticator) marshal(response map[string]any) []byte {
+	body, err := json.Marshal(map[string]any{
+		"id":       b64(a.credentialID),
+		"rawId":    b64(a.credentialID),
+		"type":     "public-key",
+		"response": response,
+	})
+	require.NoError(a.t, err)
+	return body
+}
+
+func b64(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// fakeWebAuthnCredentialRepository keeps passkeys in memory
+type fakeWebAuthnCredentialRepository struct {
+	credentials []models.WebAuthnCredential
+}
+
+func (r *fakeWebAuthnCredentialRepository) Create(_ context.Context, credential *models.WebAuthnCredential) error {
+	credential.ID = uuid.New()
+	r.credentials = append(r.credentials, *credential)
+	return nil
+}
+
+func (r *fakeWebAuthnCredentialRepository) GetByUserID(_ context.Context, userID uuid.UUID) ([]models.WebAuthnCredential, error) {
+	var credentials []models.WebAuthnCredential
+	for _, credential := range r.credentials {
+		if credential.UserID == userID {
+			credentials = append(credentials, credential)
+		}
+	}
+	return credentials, nil
+}
+
+func (r *fakeWebAuthnCredentialRepository) CountByUserID(ctx context.Context, userID uuid.UUID) (int64, error) {
+	credentials, err := r.GetByUserID(ctx, userID)
+	return int64(len(credentials)), err
+}
+
+func (r *fakeWebAuthnCredentialRepository) UpdateAfterLogin(_ context.Context, credential *models.WebAuthnCredential) error {
+	for i := range r.credentials {
+		if r.credentials[i].ID == credential.ID {
+			r.credentials[i].SignCount = credential.SignCount
+			r.credentials[i].BackupState = credential.BackupState
+			r.credentials[i].LastUsedAt = credential.LastUsedAt
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+func (r *fakeWebAuthnCredentialRepository) Rename(_ context.Context, id, userID uuid.UUID, name string) error {
+	for i := range r.credentials {
+		if r.credentials[i].ID == id && r.credentials[i].UserID == userID {
+			r.credentials[i].Name = name
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+func (r *fakeWebAuthnCredentialRepository) Delete(_ context.Context, id, userID uuid.UUID) error {
+	for i := range r.credentials {
+		if r.credentials[i].ID == id && r.credentials[i].UserID == userID {
+			r.credentials = append(r.credentials[:i], r.credentials[i+1:]...)
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+// fakeUserRepository keeps users in memory
+type fakeUserRepository struct {
+	users   map[uuid.UUID]*models.User
+	updates int
+}
+
+func (r *fakeUserRepository) GetByID(_ context.Context, id uuid.UUID) (*models.User, error) {
+	if user, ok := r.users[id]; ok {
+		copied := *user
+		return &copied, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeUserRepository) GetByEmail(_ context.Context, email string) (*models.User, error) {
+	for _, user := range r.users {
+		if strings.EqualFold(user.Email, email) {
+			copied := *user
+			return &copied, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeUserRepository) Create(context.Context, *models.User) error { return nil }
+
+func (r *fakeUserRepository) Update(_ context.Context, user *models.User) error {
+	copied := *user
+	r.users[user.ID] = &copied
+	r.updates++
+	return nil
+}
+
+func setupWebAuthnService(t *testing.T) (*WebAuthnService, *fakeWebAuthnCredentialRepository, *models.User) {
+	t.Helper()
+	user := &models.User{ID: uuid.New(), Email: "till@example.com", FirstName: "Till", AuthProvider: "local"}
+	repo := &fakeWebAuthnCredentialRepository{}
+	service := NewWebAuthnService(repo, &fakeUserRepository{users: map[uuid.UUID]*models.User{user.ID: user}}).(*WebAuthnService)
+	require.NoError(t, service.Configure(testRPID, "Savvy", []string{testOrigin}))
+	return service, repo, user
+}
+
+func registerSoftAuthenticator(t *testing.T, service *WebAuthnService, user *models.User, authenticator *softAuthenticator) *models.WebAuthnCredential {
+	t.Helper()
+	ctx := context.Background()
+	_, session, err := service.BeginRegistration(ctx, user)
+	require.NoError(t, err)
+	credential, err := service.FinishRegistration(ctx, user, *session, " iPhone ", authenticator.create(session))
+	require.NoError(t, err)
+	return credential
+}
+
+func TestWebAuthnService_Disabled(t *testing.T) {
+	service := NewWebAuthnService(&fakeWebAuthnCredentialRepository{}, &fakeUserRepository{})
+	assert.False(t, service.Enabled())
+
+	_, _, err := service.BeginLogin(context.Background())
+	assert.ErrorIs(t, err, ErrWebAuthnDisabled)
+}
+
+func TestWebAuthnService_Registration(t *testing.T) {
+	service, repo, user := setupWebAuthnService(t)
+	ctx := context.Background()
+	authenticator := newSoftAuthenticator(t)
+	authenticator.synced = true
+
+	credential := registerSoftAuthenticator(t, service, user, authenticator)
+	assert.Equal(t, "iPhone", credential.Name)
+	assert.Equal(t, authenticator.credentialID, credential.CredentialID)
+	assert.Equal(t, "internal,hybrid", credential.Transports)
+	assert.True(t, credential.BackupEligible)
+	assert.Len(t, repo.credentials, 1)
+
+	// The registered passkey is excluded from the next registration
+	creation, _, err := service.BeginRegistration(ctx, user)
+	require.NoError(t, err)
+	if assert.Len(t, creation.Response.CredentialExcludeList, 1) {
+		assert.Equal(t, authenticator.credentialID, []byte(creation.Response.CredentialExcludeList[0].CredentialID))
+	}
+
+	// A response to another challenge is rejected
+	_, session, err := service.BeginRegistration(ctx, user)
+	require.NoError(t, err)
+	other := newSoftAuthenticator(t)
+	response := other.create(session)
+	session.Challenge = "c29tZXRoaW5nIGVsc2U"
+	_, err = service.FinishRegistration(ctx, user, *session, "Laptop", response)
+	assert.ErrorIs(t, err, ErrInvalidPasskey)
+
+	_, err = service.FinishRegistration(ctx, user, *session, "  ", response)
+	assert.ErrorIs(t, err, ErrInvalidPasskeyName)
+
+	// OIDC accounts sign in at their provider
+	_, _, err = service.BeginRegistration(ctx, &models.User{ID: uuid.New(), AuthProvider: "oauth"})
+	assert.ErrorIs(t, err, ErrPasskeysUnavailable)
+}
+
+func TestWebAuthnService_PasswordlessLogin(t *testing.T) {
+	service, repo, user := setupWebAuthnService(t)
+	ctx := context.Background()
+	authenticator := newSoftAuthenticator(t)
+	registerSoftAuthenticator(t, service, user, authenticator)
+
+	_, session, err := service.BeginLogin(ctx)
+	require.NoError(t, err)
+	loggedIn, err := service.FinishLogin(ctx, *session, authenticator.get(session, 1))
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, loggedIn.ID)
+	assert.Equal(t, int64(1), repo.credentials[0].SignCount)
+	assert.NotNil(t, repo.credentials[0].LastUsedAt)
+
+	// The challenge must match
+	_, other, err := service.BeginLogin(ctx)
+	require.NoError(t, err)
+	_, err = service.FinishLogin(ctx, *other, authenticator.get(session, 1))
+	assert.ErrorIs(t, err, ErrInvalidPasskey)
+}
+
+func TestWebAuthnService_SignCounter(t *testing.T) {
+	service, repo, user := setupWebAuthnService(t)
+	ctx := context.Background()
+	authenticator := newSoftAuthenticator(t)
+	registerSoftAuthenticator(t, service, user, authenticator)
+
+	_, session, err := service.BeginSecondFactor(ctx, user)
+	require.NoError(t, err)
+	require.NoError(t, service.FinishSecondFactor(ctx, user, *session, authenticator.get(session, 5)))
+	assert.Equal(t, int64(5), repo.credentials[0].SignCount)
+
+	// A copy of the key reports a counter that did not increase
+	_, session, err = service.BeginSecondFactor(ctx, user)
+	require.NoError(t, err)
+	err = service.FinishSecondFactor(ctx, user, *session, authenticator.get(session, 0))
+	assert.ErrorIs(t, err, ErrPasskeyCloned)
+
+	authenticator.counter = 3
+	_, session, err = service.BeginLogin(ctx)
+	require.NoError(t, err)
+	_, err = service.FinishLogin(ctx, *session, authenticator.get(session, 0))
+	assert.ErrorIs(t, err, ErrPasskeyCloned)
+	assert.Equal(t, int64(5), repo.credentials[0].SignCount)
+
+	// A higher counter is accepted again
+	_, session, err = service.BeginSecondFactor(ctx, user)
+	require.NoError(t, err)
+	require.NoError(t, service.FinishSecondFactor(ctx, user, *session, authenticator.get(session, 4)))
+	assert.Equal(t, int64(7), repo.credentials[0].SignCount)
+}
+
+func TestWebAuthnService_SyncedPasskeyWithoutCounter(t *testing.T) {
+	service, repo, user := setupWebAuthnService(t)
+	ctx := context.Background()
+	authenticator := newSoftAuthenticator(t)
+	authenticator.synced = true
+	registerSoftAuthenticator(t, service, user, authenticator)
+
+	// Synced passkeys always report 0
+	for range 2 {
+		_, session, err := service.BeginSecondFactor(ctx, user)
+		require.NoError(t, err)
+		require.NoError(t, service.FinishSecondFactor(ctx, user, *session, authenticator.get(session, 0)))
+	}
+	assert.Zero(t, repo.credentials[0].SignCount)
+	assert.True(t, repo.credentials[0].BackupState)
+}
+
+func TestWebAuthnService_SecondFactorOtherUser(t *testing.T) {
+	service, _, user := setupWebAuthnService(t)
+	ctx := context.Background()
+	authenticator := newSoftAuthenticator(t)
+	registerSoftAuthenticator(t, service, user, authenticator)
+
+	// Someone without passkeys cannot start the ceremony
+	otherUser := &models.User{ID: uuid.New(), AuthProvider: "local"}
+	_, _, err := service.BeginSecondFactor(ctx, otherUser)
+	assert.ErrorIs(t, err, ErrPasskeyNotFound)
+
+	// ... nor answer another user's challenge with their passkey
+	_, session, err := service.BeginSecondFactor(ctx, user)
+	require.NoError(t, err)
+	err = service.FinishSecondFactor(ctx, otherUser, *session, authenticator.get(session, 1))
+	assert.ErrorIs(t, err, ErrInvalidPasskey)
+}
+
+func TestWebAuthnService_ManageCredentials(t *testing.T) {
+	service, _, user := setupWebAuthnService(t)
+	ctx := context.Background()
+	first := registerSoftAuthenticator(t, service, user, newSoftAuthenticator(t))
+	registerSoftAuthenticator(t, service, user, newSoftAuthenticator(t))
+
+	count, err := service.CountCredentials(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	assert.ErrorIs(t, service.RenameCredential(ctx, first.ID, user.ID, ""), ErrInvalidPasskeyName)
+	assert.ErrorIs(t, service.RenameCredential(ctx, first.ID, uuid.New(), "Mine"), ErrPasskeyNotFound)
+	require.NoError(t, service.RenameCredential(ctx, first.ID, user.ID, "Till phone"))
+
+	assert.ErrorIs(t, service.DeleteCredential(ctx, first.ID, uuid.New()), ErrPasskeyNotFound)
+	require.NoError(t, service.DeleteCredential(ctx, first.ID, user.ID))
+
+	credentials, err := service.ListCredentials(ctx, user.ID)
+	require.NoError(t, err)
+	if assert.Len(t, credentials, 1) {
+		assert.Equal(t, "iPhone", credentials[0].Name)
+	}
+}
@@ -9,11 +9,13 @@ import (
 	"savvy/internal/assets"
 	"savvy/internal/config"
 	"savvy/internal/database"
+	"savvy/internal/encryption"
 	"savvy/internal/handlers"
 	"savvy/internal/i18n"
 	"savvy/internal/middleware"
 	"savvy/internal/migrations"
 	"savvy/internal/oauth"
+	"savvy/internal/repository"
 	"savvy/internal/security"
 	"savvy/internal/telemetry"
 	"time"
@@ -77,9 +79,10 @@ func InitTelemetry(cfg *config.Config) (func(context.Context) error, error) {
 	return shutdown, nil
 }
 
-// InitSessionStore initializes the session store with secure configuration.
+// InitSessionStore initializes the database-backed session store with secure configuration.
+// Requires the database connection.
 func InitSessionStore(cfg *config.Config) {
-	middleware.InitSessionStore(cfg.SessionSecret, cfg.IsProduction())
+	middleware.InitDBSessionStore(repository.NewUserSessionRepository(database.DB), cfg.SessionSecret, cfg.IsProduction())
 }
 
 // InitI18n initializes internationalization with embedded locale files.
@@ -95,6 +98,26 @@ func InitSecurity(cfg *config.Config) {
 	security.Init(cfg.SessionSecret)
 }
 
+// InitEncryption loads the keyring for encrypted columns (gift card PINs,
+// optionally card numbers and voucher codes).
+func InitEncryption(cfg *config.Config) error {
+	if !cfg.IsEncryptionEnabled() {
+		encryption.Init(nil)
+		if cfg.IsProduction() {
+			log.Printf("⚠️  ENCRYPTION_KEYS not set, gift card PINs are stored unencrypted")
+		}
+		return nil
+	}
+
+	keyring, err := encryption.NewKeyring(cfg.EncryptionKeys, cfg.EncryptIdentifiers)
+	if err != nil {
+		return err
+	}
+	encryption.Init(keyring)
+	log.Printf("🔐 Column encryption enabled (active key: %s, identifiers: %t)", keyring.ActiveKeyID(), keyring.EncryptIdentifiers())
+	return nil
+}
+
 // InitDatabase connects to the database and optionally enables telemetry.
 func InitDatabase(cfg *config.Config) error {
 	if err := database.Connect(cfg.DatabaseURL); err != nil {
@@ -172,17 +195,19 @@ func InitAllDependencies(cfg *config.Config) (func(context.Context) error, error
 		return nil, err
 	}
 
-	// 3. Session Store
-	InitSessionStore(cfg)
-
-	// 4. i18n
+	// 3. i18n
 	if err := InitI18n(); err != nil {
 		return shutdown, err
 	}
 
-	// 5. Security
+	// 4. Security
 	InitSecurity(cfg)
 
+	// 5. Encryption
+	if err := InitEncryption(cfg); err != nil {
+		return shutdown, err
+	}
+
 	// 6. Database
 	if err := InitDatabase(cfg); err != nil {
 		return shutdown, err
@@ -193,10 +218,13 @@ func InitAllDependencies(cfg *config.Config) (func(context.Context) error, error
 		return shutdown, err
 	}
 
-	// 8. Audit Logging
+	// 8. Session Store (stored in the database)
+	InitSessionStore(cfg)
+
+	// 9. Audit Logging
 	InitAuditLogging()
 
-	// 9. OAuth
+	// 10. OAuth
 	InitOAuth(cfg)
 
 	return shutdown, nil
@@ -0,0 +1,167 @@
+// Package setup contains setup logic for initializing the Echo server.
+package setup
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"savvy/internal/jobs"
+	"savvy/internal/metrics"
+	"savvy/internal/middleware"
+	"savvy/internal/repository"
+	"savvy/internal/services"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// NewJobScheduler creates the scheduler with all background jobs of the server.
+func NewJobScheduler(db *gorm.DB, sc *services.Container) (*jobs.Scheduler, error) {
+	instance, err := os.Hostname()
+	if err != nil {
+		instance = "unknown"
+	}
+	scheduler := jobs.NewScheduler(repository.NewJobRepository(db), instance)
+
+	loginThrottles := repository.NewLoginThrottleRepository(db)
+	deliveries := repository.NewNotificationDeliveryRepository(db)
+
+	for _, job := range []jobs.Job{
+		{
+			// Gauges are per process, so every replica refreshes its own
+			Name:     "metrics",
+			Schedule: "@every 30s",
+			Local:    true,
+			Timeout:  20 * time.Second,
+			Run:      func(ctx context.Context) error { return updateMetrics(ctx, db, sc.ItemLifecycleService) },
+		},
+		{
+			Name:     "sessions.cleanup",
+			Schedule: "*/5 * * * *",
+			Run: func(ctx context.Context) error {
+				return logDeleted(ctx, "expired sessions", middleware.CleanupExpiredSessions)
+			},
+		},
+		{
+			// Forget failed logins that no longer count towards a lockout
+			Name:     "login_throttles.cleanup",
+			Schedule: "*/5 * * * *",
+			Run: func(ctx context.Context) error {
+				return logDeleted(ctx, "stale failed logins", func(ctx context.Context) (int64, error) {
+					return loginThrottles.DeleteStale(ctx, time.Now().Add(-services.LoginFailureWindow))
+				})
+			},
+		},
+		{
+			Name:     "notification_deliveries.cleanup",
+			Schedule: "@hourly",
+			Run: func(ctx context.Context) error {
+				return logDeleted(ctx, "finished notification deliveries", func(ctx context.Context) (int64, error) {
+					return deliveries.DeleteFinished(ctx, time.Now().Add(-services.NotificationDeliveryRetention))
+				})
+			},
+		},
+		{
+			// Expires, redeems and renews vouchers and gift cards
+			Name:     "items.lifecycle",
+			Schedule: "@every 1m",
+			Run: func(ctx context.Context) error {
+				changed, err := sc.ItemLifecycleService.ApplyTransitions(ctx, time.Now())
+				if err != nil {
+					return fmt.Errorf("failed to update item statuses: %w", err)
+				}
+				if changed > 0 {
+					slog.Info("Updated item statuses", "count", changed)
+				}
+				return nil
+			},
+		},
+		{
+			// Hourly, so items added shortly before they expire are reminded the same day
+			Name:     "expiry_reminders",
+			Schedule: "@hourly",
+			Run: func(ctx context.Context) error {
+				now := time.Now()
+				sent, err := sc.ExpiryReminderService.SendDue(ctx, now)
+				if err != nil {
+					return fmt.Errorf("failed to send expiry reminders: %w", err)
+				}
+				if sent > 0 {
+					slog.Info("Sent expiry reminders", "count", sent)
+				}
+				return logDeleted(ctx, "expiry reminders of expired items", func(ctx context.Context) (int64, error) {
+					return sc.ExpiryReminderService.DeleteExpired(ctx, now)
+				})
+			},
+		},
+		{
+			Name:     "job_runs.cleanup",
+			Schedule: "@daily",
+			Run: func(ctx context.Context) error {
+				return logDeleted(ctx, "old job runs", sc.JobService.DeleteOldRuns)
+			},
+		},
+	} {
+		if err := scheduler.Register(job); err != nil {
+			return nil, err
+		}
+	}
+
+	return scheduler, nil
+}
+
+// logDeleted runs a cleanup and logs how many rows it removed
+func logDeleted(ctx context.Context, what string, cleanup func(ctx context.Context) (int64, error)) error {
+	deleted, err := cleanup(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to remove %s: %w", what, err)
+	}
+	if deleted > 0 {
+		slog.Info("Removed "+what, "count", deleted)
+	}
+	return nil
+}
+
+// updateMetrics updates Prometheus gauges for resource counts, item statuses, sessions and DB stats.
+func updateMetrics(ctx context.Context, db *gorm.DB, lifecycle services.ItemLifecycleServiceInterface) error {
+	var cardsCount, vouchersCount, giftCardsCount, usersCount int64
+	for table, count := range map[string]*int64{
+		"cards":      &cardsCount,
+		"vouchers":   &vouchersCount,
+		"gift_cards": &giftCardsCount,
+		"users":      &usersCount,
+	} {
+		if err := db.WithContext(ctx).Table(table).Count(count).Error; err != nil {
+			return fmt.Errorf("failed to count %s: %w", table, err)
+		}
+	}
+	metrics.UpdateResourceCounts(cardsCount, vouchersCount, giftCardsCount, usersCount)
+
+	statusCounts, err := lifecycle.CountByStatus(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to count items by status: %w", err)
+	}
+	byStatus := make(map[string]map[string]int64)
+	for _, count := range statusCounts {
+		if byStatus[count.ResourceType] == nil {
+			byStatus[count.ResourceType] = make(map[string]int64)
+		}
+		byStatus[count.ResourceType][count.Status] = count.Count
+	}
+	metrics.UpdateItemStatusCounts(byStatus)
+
+	// Count logged-in sessions
+	if err := middleware.UpdateSessionMetrics(ctx); err != nil {
+		return fmt.Errorf("failed to count sessions: %w", err)
+	}
+
+	// Update DB connection pool metrics
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	stats := sqlDB.Stats()
+	metrics.UpdateDBMetrics(stats.InUse, stats.Idle)
+	return nil
+}
@@ -2,16 +2,27 @@
 package setup
 
 import (
+	"context"
+	"errors"
+	"log/slog"
 	"savvy/internal/config"
 	"savvy/internal/database"
 	"savvy/internal/debug"
+	"savvy/internal/exchange"
 	"savvy/internal/handlers"
+	"savvy/internal/handlers/api"
 	"savvy/internal/handlers/cards"
 	"savvy/internal/handlers/giftcards"
 	"savvy/internal/handlers/merchants"
+	"savvy/internal/handlers/shares"
 	"savvy/internal/handlers/vouchers"
+	"savvy/internal/mail"
 	"savvy/internal/middleware"
+	"savvy/internal/models"
+	"savvy/internal/push"
+	"savvy/internal/ratelimit"
 	"savvy/internal/services"
+	"savvy/internal/wallet"
 
 	"github.com/labstack/echo/v4"
 )
@@ -40,6 +51,7 @@ func RegisterRoutes(rc *RouteConfig) {
 		serviceContainer.FavoriteService,
 		serviceContainer.ShareService,
 		serviceContainer.TransferService,
+		serviceContainer.ImportService,
 		database.DB,
 	)
 
@@ -77,40 +89,162 @@ func RegisterRoutes(rc *RouteConfig) {
 		serviceContainer.GiftCardService,
 	)
 
+	appleWallet, err := wallet.NewApple(cfg)
+	if err != nil && !errors.Is(err, wallet.ErrNotConfigured) {
+		slog.Error("Apple Wallet disabled", "error", err)
+	}
+	googleWallet, err := wallet.NewGoogle(cfg)
+	if err != nil && !errors.Is(err, wallet.ErrNotConfigured) {
+		slog.Error("Google Wallet disabled", "error", err)
+	}
+	walletHandler := handlers.NewWalletHandler(
+		cfg,
+		appleWallet,
+		googleWallet,
+		serviceContainer.AuthzService,
+		serviceContainer.CardService,
+		serviceContainer.VoucherService,
+		serviceContainer.GiftCardService,
+	)
+
+	exchangeRateProvider, err := exchange.NewProvider(cfg)
+	if err != nil {
+		slog.Error("Exchange rates disabled, using an empty rate table", "error", err)
+	} else {
+		serviceContainer.ExchangeRateService.UseProvider(exchangeRateProvider)
+	}
+	exchangeRatesHandler := handlers.NewExchangeRatesHandler(serviceContainer.ExchangeRateService)
+
 	merchantsHandler := merchants.NewHandler(serviceContainer.MerchantService)
-	authHandler := handlers.NewAuthHandler(serviceContainer.UserService)
+	if cfg.IsWebAuthnEnabled() {
+		if err := serviceContainer.WebAuthnService.Configure(cfg.WebAuthnRPID, cfg.WebAuthnRPName, cfg.WebAuthnRPOrigins); err != nil {
+			slog.Error("Passkeys disabled", "error", err)
+		}
+	}
+
+	mailSender, err := mail.NewSender(cfg)
+	switch {
+	case err == nil:
+		serviceContainer.AccountEmailService.UseSender(mailSender, cfg.BaseURL)
+		serviceContainer.NotificationDeliveryService.UseSender(mailSender, cfg.BaseURL)
+	case !errors.Is(err, mail.ErrNotConfigured):
+		slog.Error("Mail disabled", "error", err)
+	}
+
+	pushSender, err := push.NewSender(cfg)
+	switch {
+	case err == nil:
+		serviceContainer.NotificationDeliveryService.UsePush(pushSender, pushSender.PublicKey())
+	case !errors.Is(err, push.ErrNotConfigured):
+		slog.Error("Web Push disabled", "error", err)
+	}
+
+	authHandler := handlers.NewAuthHandler(
+		serviceContainer.UserService,
+		serviceContainer.WebAuthnService,
+		serviceContainer.AccountEmailService,
+		serviceContainer.LoginThrottleService,
+	)
 	oauthHandler := handlers.NewOAuthHandler(serviceContainer.UserService)
 	sharedUsersHandler := handlers.NewSharedUsersHandler(serviceContainer.ShareService)
-	notificationHandler := handlers.NewNotificationHandler(serviceContainer.NotificationService)
-	adminHandler := handlers.NewAdminHandler(serviceContainer.AdminService, serviceContainer.UserService)
+	notificationHandler := handlers.NewNotificationHandler(serviceContainer.NotificationService, serviceContainer.NotificationDeliveryService, serviceContainer.NotificationEvents, serviceContainer.ExpiryReminderService, serviceContainer.CalendarFeedService, cfg.BaseURL)
+	calendarHandler := handlers.NewCalendarHandler(serviceContainer.CalendarFeedService, cfg.BaseURL)
+	adminHandler := handlers.NewAdminHandler(serviceContainer.AdminService, serviceContainer.UserService, serviceContainer.LoginThrottleService, serviceContainer.JobService)
+	accountHandler := handlers.NewAccountHandler(
+		serviceContainer.APITokenService,
+		serviceContainer.BackupService,
+		serviceContainer.UserService,
+		serviceContainer.ExchangeRateService,
+		serviceContainer.SessionService,
+		serviceContainer.AccountEmailService,
+	)
+	twoFactorHandler := handlers.NewTwoFactorHandler(
+		serviceContainer.TwoFactorService,
+		serviceContainer.WebAuthnService,
+		serviceContainer.UserService,
+		serviceContainer.LoginThrottleService,
+		database.DB,
+		cfg,
+	)
+	passkeyHandler := handlers.NewPasskeyHandler(
+		serviceContainer.WebAuthnService,
+		serviceContainer.UserService,
+		serviceContainer.LoginThrottleService,
+		database.DB,
+		cfg,
+	)
+
+	apiHandler := api.NewHandler(
+		serviceContainer.CardService,
+		serviceContainer.VoucherService,
+		serviceContainer.GiftCardService,
+		serviceContainer.AuthzService,
+		serviceContainer.MerchantService,
+		shares.NewCardShareAdapter(database.DB, serviceContainer.AuthzService, serviceContainer.UserService, serviceContainer.NotificationService),
+		shares.NewVoucherShareAdapter(database.DB, serviceContainer.AuthzService, serviceContainer.UserService, serviceContainer.NotificationService),
+		shares.NewGiftCardShareAdapter(database.DB, serviceContainer.AuthzService, serviceContainer.UserService, serviceContainer.NotificationService),
+		database.DB,
+	)
 
-	// Rate limiter for auth endpoints (5 requests per second, burst of 10)
-	authLimiter := middleware.NewIPRateLimiter(5, 10)
+	// Rate limits, shared by all replicas unless RATE_LIMIT_BACKEND=memory
+	limiter, err := ratelimit.New(context.Background(), cfg, database.DB)
+	if err != nil {
+		slog.Error("Rate limiter backend unavailable, limiting per instance", "backend", cfg.RateLimitBackend, "error", err)
+		limiter = ratelimit.NewMemoryLimiter()
+	}
+	authRateLimit := middleware.RateLimit(limiter, middleware.AuthRateLimit)
+	shareRateLimit := middleware.RateLimit(limiter, middleware.ShareRateLimit)
+	barcodeRateLimit := middleware.RateLimit(limiter, middleware.BarcodeRateLimit)
+	calendarRateLimit := middleware.RateLimit(limiter, middleware.CalendarRateLimit)
 
 	// ========================================
 	// Authentication Routes (Public)
 	// ========================================
 	auth := e.Group("/auth")
 	auth.GET("/login", handlers.AuthLoginGet)
-	auth.POST("/login", authHandler.LoginPost, middleware.RateLimitMiddleware(authLimiter), middleware.RequireLocalLoginEnabled(cfg))
+	auth.POST("/login", authHandler.LoginPost, authRateLimit, middleware.RequireLocalLoginEnabled(cfg))
 	auth.GET("/register", handlers.AuthRegisterGet, middleware.RequireRegistrationEnabled(cfg))
-	auth.POST("/register", authHandler.RegisterPost, middleware.RateLimitMiddleware(authLimiter), middleware.RequireRegistrationEnabled(cfg))
+	auth.POST("/register", authHandler.RegisterPost, authRateLimit, middleware.RequireRegistrationEnabled(cfg))
+	auth.GET("/login/two-factor", twoFactorHandler.LoginGet, middleware.RequireLocalLoginEnabled(cfg))
+	auth.POST("/login/two-factor", twoFactorHandler.LoginPost, authRateLimit, middleware.RequireLocalLoginEnabled(cfg))
+	auth.POST("/login/two-factor/passkey/begin", passkeyHandler.SecondFactorBegin, authRateLimit, middleware.RequireLocalLoginEnabled(cfg))
+	auth.POST("/login/two-factor/passkey/finish", passkeyHandler.SecondFactorFinish, authRateLimit, middleware.RequireLocalLoginEnabled(cfg))
+	auth.POST("/passkey/login/begin", passkeyHandler.LoginBegin, authRateLimit, middleware.RequireLocalLoginEnabled(cfg))
+	auth.POST("/passkey/login/finish", passkeyHandler.LoginFinish, authRateLimit, middleware.RequireLocalLoginEnabled(cfg))
+	auth.GET("/forgot-password", authHandler.ForgotPasswordGet, middleware.RequireLocalLoginEnabled(cfg))
+	auth.POST("/forgot-password", authHandler.ForgotPasswordPost, authRateLimit, middleware.RequireLocalLoginEnabled(cfg))
+	auth.GET("/reset-password", authHandler.ResetPasswordGet, middleware.RequireLocalLoginEnabled(cfg))
+	auth.POST("/reset-password", authHandler.ResetPasswordPost, authRateLimit, middleware.RequireLocalLoginEnabled(cfg))
+	auth.GET("/verify-email", authHandler.VerifyEmail)
 	auth.GET("/logout", handlers.AuthLogout)
 	// OAuth routes (public)
 	auth.GET("/oauth/login", handlers.OAuthLogin)
 	auth.GET("/oauth/callback", oauthHandler.Callback)
 
+	// ========================================
+	// Calendar Feed (Public, secret token in the URL)
+	// ========================================
+	e.GET("/calendar/:token", calendarHandler.Feed, calendarRateLimit)
+
 	// ========================================
 	// Protected Routes (Authentication Required)
 	// ========================================
 	protected := e.Group("")
 	protected.Use(middleware.RequireAuth)
+	protected.Use(middleware.RequireAdminTwoFactor(cfg, serviceContainer.WebAuthnService))
 
 	// Dashboard & Home
 	protected.GET("/", handlers.HomeIndex)
 
+	// OAuth re-authentication before sensitive actions (e.g. revealing a PIN)
+	protected.GET("/auth/oauth/reauth", handlers.OAuthReauth)
+
 	// Barcode generation (secure token-based access)
-	protected.GET("/barcode/:token", barcodeHandler.Generate)
+	protected.GET("/barcode/:token", barcodeHandler.Generate, barcodeRateLimit)
+
+	// Wallet passes (type: card, voucher, gift_card)
+	protected.GET("/wallet/apple/:type/:id", walletHandler.ApplePass)
+	protected.GET("/wallet/google/:type/:id", walletHandler.GooglePass)
 
 	// HTMX autocomplete endpoint (returns HTML fragment)
 	protected.GET("/api/shared-users", sharedUsersHandler.Autocomplete)
@@ -119,12 +253,41 @@ func RegisterRoutes(rc *RouteConfig) {
 	// Notifications
 	// ========================================
 	protected.GET("/notifications", notificationHandler.ShowNotifications)
+	protected.GET("/notifications/settings", notificationHandler.ShowSettings)
+	protected.POST("/notifications/settings", notificationHandler.UpdateSettings)
+	protected.POST("/notifications/settings/reminders", notificationHandler.UpdateReminders)
+	protected.POST("/notifications/settings/calendar", notificationHandler.RotateCalendarToken)
+	protected.POST("/notifications/settings/calendar/delete", notificationHandler.DeleteCalendarFeed)
+	protected.POST("/notifications/push-subscriptions", notificationHandler.Subscribe)
+	protected.DELETE("/notifications/push-subscriptions", notificationHandler.Unsubscribe)
 	protected.GET("/api/notifications/count", notificationHandler.GetUnreadCount)
+	protected.GET("/api/notifications/stream", notificationHandler.Stream)
 	protected.GET("/api/notifications/preview", notificationHandler.GetNotificationsDropdown)
 	protected.POST("/notifications/:id/read", notificationHandler.MarkAsRead)
 	protected.POST("/notifications/mark-all-read", notificationHandler.MarkAllAsRead)
 	protected.DELETE("/notifications/:id", notificationHandler.DeleteNotification)
 
+	// ========================================
+	// Account Settings
+	// ========================================
+	protected.GET("/account", accountHandler.Show)
+	protected.POST("/account/display-currency", accountHandler.UpdateDisplayCurrency)
+	protected.POST("/account/api-tokens", accountHandler.CreateAPIToken)
+	protected.DELETE("/account/api-tokens/:id", accountHandler.RevokeAPIToken)
+	protected.DELETE("/account/sessions/:id", accountHandler.RevokeSession)
+	protected.POST("/account/sessions/revoke-all", accountHandler.RevokeAllSessions)
+	protected.POST("/account/verify-email", accountHandler.ResendVerification, authRateLimit)
+	protected.GET("/account/two-factor", twoFactorHandler.Show)
+	protected.POST("/account/two-factor", twoFactorHandler.Enable, authRateLimit)
+	protected.POST("/account/two-factor/disable", twoFactorHandler.Disable, authRateLimit)
+	protected.POST("/account/two-factor/recovery-codes", twoFactorHandler.RegenerateRecoveryCodes, authRateLimit)
+	protected.POST("/account/two-factor/passkeys/begin", passkeyHandler.RegisterBegin, authRateLimit)
+	protected.POST("/account/two-factor/passkeys/finish", passkeyHandler.RegisterFinish, authRateLimit)
+	protected.POST("/account/two-factor/passkeys/:id", passkeyHandler.Rename)
+	protected.DELETE("/account/two-factor/passkeys/:id", passkeyHandler.Delete)
+	protected.GET("/account/export", accountHandler.Export)
+	protected.POST("/account/import", accountHandler.Import)
+
 	// ========================================
 	// Merchants Routes (Read-Only for All Users)
 	// ========================================
@@ -147,17 +310,17 @@ func RegisterRoutes(rc *RouteConfig) {
 	// ========================================
 	// Cards Resource
 	// ========================================
-	registerCardsRoutes(protected, cfg, cardHandler, cardSharesHandler, favoritesHandler)
+	registerCardsRoutes(protected, cfg, cardHandler, cardSharesHandler, favoritesHandler, shareRateLimit)
 
 	// ========================================
 	// Vouchers Resource
 	// ========================================
-	registerVouchersRoutes(protected, cfg, voucherHandler, voucherSharesHandler, favoritesHandler)
+	registerVouchersRoutes(protected, cfg, voucherHandler, voucherSharesHandler, favoritesHandler, shareRateLimit)
 
 	// ========================================
 	// Gift Cards Resource
 	// ========================================
-	registerGiftCardsRoutes(protected, cfg, giftCardHandler, giftCardSharesHandler, favoritesHandler)
+	registerGiftCardsRoutes(protected, cfg, giftCardHandler, giftCardSharesHandler, favoritesHandler, authRateLimit, shareRateLimit)
 
 	// ========================================
 	// Impersonation Management
@@ -175,8 +338,19 @@ func RegisterRoutes(rc *RouteConfig) {
 	admin.POST("/users/:id/role", adminHandler.UpdateUserRole)
 	admin.GET("/audit-log", adminHandler.AuditLogIndex)
 	admin.POST("/audit-log/restore", adminHandler.RestoreResource)
+	admin.GET("/lockouts", adminHandler.LockoutsIndex)
+	admin.DELETE("/lockouts/:id", adminHandler.UnlockLogin)
+	admin.GET("/jobs", adminHandler.JobsIndex)
+	admin.GET("/exchange-rates", exchangeRatesHandler.Index)
+	admin.POST("/exchange-rates", exchangeRatesHandler.Save)
+	admin.DELETE("/exchange-rates/:currency", exchangeRatesHandler.Delete)
 	admin.GET("/impersonate/:id", authHandler.Impersonate)
 
+	// ========================================
+	// JSON REST API (v1)
+	// ========================================
+	registerAPIRoutes(e, cfg, apiHandler, serviceContainer.APITokenService, serviceContainer.WebAuthnService, shareRateLimit)
+
 	// ========================================
 	// Development Debug Tools
 	// ========================================
@@ -192,12 +366,16 @@ func registerCardsRoutes(
 	cardHandler *cards.Handler,
 	cardSharesHandler *handlers.CardSharesHandler,
 	favoritesHandler *handlers.FavoritesHandler,
+	shareRateLimit echo.MiddlewareFunc,
 ) {
 	cardsGroup := protected.Group("/cards")
 	cardsGroup.Use(middleware.RequireCardsEnabled(cfg))
 	cardsGroup.GET("", cardHandler.Index)
 	cardsGroup.GET("/new", cardHandler.New)
 	cardsGroup.POST("", cardHandler.Create)
+	cardsGroup.GET("/import", cardHandler.ImportForm)
+	cardsGroup.POST("/import", cardHandler.ImportPreview)
+	cardsGroup.POST("/import/commit", cardHandler.ImportCommit)
 	cardsGroup.GET("/:id", cardHandler.Show)
 	cardsGroup.GET("/:id/edit", cardHandler.Edit)
 	cardsGroup.POST("/:id", cardHandler.Update)
@@ -207,7 +385,7 @@ func registerCardsRoutes(
 	cardsGroup.GET("/:id/cancel-edit", cardHandler.CancelEdit)
 	cardsGroup.PATCH("/:id", cardHandler.UpdateInline)
 	// Sharing
-	cardsGroup.POST("/:id/shares", cardSharesHandler.Create)
+	cardsGroup.POST("/:id/shares", cardSharesHandler.Create, shareRateLimit)
 	cardsGroup.PATCH("/:id/shares/:share_id", cardSharesHandler.Update)
 	cardsGroup.DELETE("/:id/shares/:share_id", cardSharesHandler.Delete)
 	cardsGroup.GET("/:id/shares/new-inline", cardSharesHandler.NewInline)
@@ -229,6 +407,7 @@ func registerVouchersRoutes(
 	voucherHandler *vouchers.Handler,
 	voucherSharesHandler *handlers.VoucherSharesHandler,
 	favoritesHandler *handlers.FavoritesHandler,
+	shareRateLimit echo.MiddlewareFunc,
 ) {
 	vouchersGroup := protected.Group("/vouchers")
 	vouchersGroup.Use(middleware.RequireVouchersEnabled(cfg))
@@ -244,7 +423,7 @@ func registerVouchersRoutes(
 	vouchersGroup.GET("/:id/cancel-edit", voucherHandler.CancelEdit)
 	vouchersGroup.PATCH("/:id", voucherHandler.UpdateInline)
 	// Sharing (read-only only)
-	vouchersGroup.POST("/:id/shares", voucherSharesHandler.Create)
+	vouchersGroup.POST("/:id/shares", voucherSharesHandler.Create, shareRateLimit)
 	vouchersGroup.DELETE("/:id/shares/:share_id", voucherSharesHandler.Delete)
 	vouchersGroup.GET("/:id/shares/new-inline", voucherSharesHandler.NewInline)
 	vouchersGroup.GET("/:id/shares/cancel", voucherSharesHandler.Cancel)
@@ -263,6 +442,8 @@ func registerGiftCardsRoutes(
 	giftCardHandler *giftcards.Handler,
 	giftCardSharesHandler *handlers.GiftCardSharesHandler,
 	favoritesHandler *handlers.FavoritesHandler,
+	authRateLimit echo.MiddlewareFunc,
+	shareRateLimit echo.MiddlewareFunc,
 ) {
 	giftCardsGroup := protected.Group("/gift-cards")
 	giftCardsGroup.Use(middleware.RequireGiftCardsEnabled(cfg))
@@ -281,9 +462,11 @@ func registerGiftCardsRoutes(
 	giftCardsGroup.GET("/:id/transactions/new", giftCardHandler.TransactionNew)
 	giftCardsGroup.GET("/:id/transactions/cancel", giftCardHandler.TransactionCancel)
 	giftCardsGroup.POST("/:id/transactions", giftCardHandler.TransactionCreate)
+	giftCardsGroup.GET("/:id/transactions/:transaction_id/edit", giftCardHandler.TransactionEdit)
+	giftCardsGroup.PATCH("/:id/transactions/:transaction_id", giftCardHandler.TransactionUpdate)
 	giftCardsGroup.DELETE("/:id/transactions/:transaction_id", giftCardHandler.TransactionDelete)
 	// Sharing
-	giftCardsGroup.POST("/:id/shares", giftCardSharesHandler.Create)
+	giftCardsGroup.POST("/:id/shares", giftCardSharesHandler.Create, shareRateLimit)
 	giftCardsGroup.PATCH("/:id/shares/:share_id", giftCardSharesHandler.Update)
 	giftCardsGroup.DELETE("/:id/shares/:share_id", giftCardSharesHandler.Delete)
 	giftCardsGroup.GET("/:id/shares/new-inline", giftCardSharesHandler.NewInline)
@@ -294,6 +477,70 @@ func registerGiftCardsRoutes(
 	giftCardsGroup.GET("/:id/transfer/inline", giftCardHandler.TransferInline)
 	giftCardsGroup.GET("/:id/transfer/cancel", giftCardHandler.CancelTransfer)
 	giftCardsGroup.POST("/:id/transfer", giftCardHandler.Transfer)
+	// PIN reveal (requires recent re-authentication)
+	giftCardsGroup.GET("/:id/pin", giftCardHandler.RevealPIN)
+	giftCardsGroup.POST("/:id/pin", giftCardHandler.ConfirmRevealPIN, authRateLimit)
+	giftCardsGroup.GET("/:id/pin/hide", giftCardHandler.HidePIN)
 	// Favorites
 	giftCardsGroup.POST("/:id/favorite", favoritesHandler.ToggleGiftCardFavorite)
 }
+
+// registerAPIRoutes registers the versioned JSON REST API.
+// All errors below /api/v1 are rendered as api.ErrorResponse.
+// Clients authenticate with the browser session or a personal API token.
+func registerAPIRoutes(
+	e *echo.Echo,
+	cfg *config.Config,
+	apiHandler *api.Handler,
+	apiTokenService services.APITokenServiceInterface,
+	passkeys middleware.PasskeyCounter,
+	shareRateLimit echo.MiddlewareFunc,
+) {
+	// Public so client generators can fetch it
+	e.GET(api.SpecPath, api.OpenAPI(cfg.ServiceVersion))
+
+	v1 := e.Group("/api/v1")
+	v1.Use(api.Errors)
+	v1.Use(middleware.APITokenAuth(apiTokenService))
+	v1.Use(middleware.RequireAPIAuth)
+	// Covers API tokens as well, they would otherwise bypass REQUIRE_ADMIN_2FA
+	v1.Use(middleware.RequireAdminTwoFactor(cfg, passkeys))
+
+	cardsGroup := v1.Group("/cards")
+	cardsGroup.Use(middleware.RequireCardsEnabled(cfg), middleware.RequireAPIScope(models.APIScopeCards))
+	cardsGroup.GET("", apiHandler.ListCards)
+	cardsGroup.POST("", apiHandler.CreateCard)
+	cardsGroup.GET("/:id", apiHandler.GetCard)
+	cardsGroup.PATCH("/:id", apiHandler.UpdateCard)
+	cardsGroup.DELETE("/:id", apiHandler.DeleteCard)
+	cardsGroup.GET("/:id/shares", apiHandler.ListCardShares)
+	cardsGroup.POST("/:id/shares", apiHandler.CreateCardShare, shareRateLimit)
+	cardsGroup.PATCH("/:id/shares/:share_id", apiHandler.UpdateCardShare)
+	cardsGroup.DELETE("/:id/shares/:share_id", apiHandler.DeleteCardShare)
+
+	vouchersGroup := v1.Group("/vouchers")
+	vouchersGroup.Use(middleware.RequireVouchersEnabled(cfg), middleware.RequireAPIScope(models.APIScopeVouchers))
+	vouchersGroup.GET("", apiHandler.ListVouchers)
+	vouchersGroup.POST("", apiHandler.CreateVoucher)
+	vouchersGroup.GET("/:id", apiHandler.GetVoucher)
+	vouchersGroup.PATCH("/:id", apiHandler.UpdateVoucher)
+	vouchersGroup.DELETE("/:id", apiHandler.DeleteVoucher)
+	vouchersGroup.GET("/:id/shares", apiHandler.ListVoucherShares)
+	vouchersGroup.POST("/:id/shares", apiHandler.CreateVoucherShare, shareRateLimit)
+	vouchersGroup.DELETE("/:id/shares/:share_id", apiHandler.DeleteVoucherShare)
+
+	giftCardsGroup := v1.Group("/gift-cards")
+	giftCardsGroup.Use(middleware.RequireGiftCardsEnabled(cfg), middleware.RequireAPIScope(models.APIScopeGiftCards))
+	giftCardsGroup.GET("", apiHandler.ListGiftCards)
+	giftCardsGroup.POST("", apiHandler.CreateGiftCard)
+	giftCardsGroup.GET("/:id", apiHandler.GetGiftCard)
+	giftCardsGroup.PATCH("/:id", apiHandler.UpdateGiftCard)
+	giftCardsGroup.DELETE("/:id", apiHandler.DeleteGiftCard)
+	giftCardsGroup.GET("/:id/transactions", apiHandler.ListTransactions)
+	giftCardsGroup.POST("/:id/transactions", apiHandler.CreateTransaction)
+	giftCardsGroup.DELETE("/:id/transactions/:transaction_id", apiHandler.DeleteTransaction)
+	giftCardsGroup.GET("/:id/shares", apiHandler.ListGiftCardShares)
+	giftCardsGroup.POST("/:id/shares", apiHandler.CreateGiftCardShare, shareRateLimit)
+	giftCardsGroup.PATCH("/:id/shares/:share_id", apiHandler.UpdateGiftCardShare)
+	giftCardsGroup.DELETE("/:id/shares/:share_id", apiHandler.DeleteGiftCardShare)
+}
@@ -0,0 +1,135 @@
+package setup
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"savvy/internal/config"
+	"savvy/internal/handlers/api"
+	"savvy/internal/middleware"
+	"savvy/internal/models"
+	"savvy/internal/ratelimit"
+	"savvy/internal/services"
+)
+
+// TestAPIRoutesDocumentedInOpenAPISpec fails when a route is registered under
+// /api/v1 without a matching operation in the OpenAPI document (and vice versa).
+func TestAPIRoutesDocumentedInOpenAPISpec(t *testing.T) {
+	e := echo.New()
+	cfg := &config.Config{EnableCards: true, EnableVouchers: true, EnableGiftCards: true}
+	shareRateLimit := middleware.RateLimit(ratelimit.NewMemoryLimiter(), middleware.ShareRateLimit)
+	registerAPIRoutes(e, cfg, api.NewHandler(nil, nil, nil, nil, nil, nil, nil, nil, nil), nil, nil, shareRateLimit)
+
+	doc := api.Spec("test")
+
+	registered := map[string]bool{}
+	for _, r := range e.Routes() {
+		if !strings.HasPrefix(r.Path, "/api/v1/") || strings.Contains(r.Path, "*") {
+			continue
+		}
+		if !isHTTPMethod(r.Method) {
+			continue
+		}
+
+		path := openAPITemplate(strings.TrimPrefix(r.Path, "/api/v1"))
+		method := strings.ToLower(r.Method)
+		registered[method+" "+path] = true
+
+		item, ok := doc.Paths[path]
+		if assert.True(t, ok, "route %s %s is missing from the OpenAPI spec", r.Method, r.Path) {
+			assert.Contains(t, item, method, "route %s %s is missing from the OpenAPI spec", r.Method, r.Path)
+		}
+	}
+
+	for path, item := range doc.Paths {
+		for method := range item {
+			assert.True(t, registered[method+" "+path], "OpenAPI operation %s %s has no registered route", method, path)
+		}
+	}
+}
+
+func isHTTPMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+		http.MethodPatch, http.MethodDelete, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+// openAPITemplate converts echo path params (":id") into OpenAPI templates ("{id}").
+func openAPITemplate(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") {
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// tokenOf is an APITokenServiceInterface that authenticates every token as user
+type tokenOf struct {
+	services.APITokenServiceInterface
+	user *models.User
+}
+
+func (s tokenOf) Authenticate(context.Context, string) (*models.APIToken, error) {
+	return &models.APIToken{UserID: s.user.ID, User: s.user, Scopes: "cards:write"}, nil
+}
+
+// noPasskeys is a PasskeyCounter for users without passkeys
+type noPasskeys struct{}
+
+func (noPasskeys) Enabled() bool { return true }
+
+func (noPasskeys) CountCredentials(context.Context, uuid.UUID) (int64, error) { return 0, nil }
+
+// TestAPIRoutesRequireAdminTwoFactor checks that REQUIRE_ADMIN_2FA also
+// covers /api/v1, which is not part of the protected group
+func TestAPIRoutesRequireAdminTwoFactor(t *testing.T) {
+	admin := &models.User{ID: uuid.New(), Role: "admin"}
+	cfg := &config.Config{EnableCards: true, RequireAdmin2FA: true}
+	shareRateLimit := middleware.RateLimit(ratelimit.NewMemoryLimiter(), middleware.ShareRateLimit)
+
+	tests := []struct {
+		name    string
+		session bool
+		bearer  bool
+	}{
+		{name: "session", session: true},
+		{name: "API token", bearer: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			if tt.session {
+				// Stands in for SetCurrentUser loading the user of the session cookie
+				e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+					return func(c echo.Context) error {
+						c.Set("current_user", admin)
+						return next(c)
+					}
+				})
+			}
+			registerAPIRoutes(e, cfg, api.NewHandler(nil, nil, nil, nil, nil, nil, nil, nil, nil), tokenOf{user: admin}, noPasskeys{}, shareRateLimit)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/cards", nil)
+			if tt.bearer {
+				req.Header.Set(echo.HeaderAuthorization, "Bearer svy_test")
+			}
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, http.StatusForbidden, rec.Code)
+			assert.Contains(t, rec.Body.String(), "two-factor authentication is required")
+		})
+	}
+}
@@ -8,11 +8,10 @@ import (
 	"log/slog"
 	"savvy/internal/assets"
 	"savvy/internal/config"
-	"savvy/internal/database"
 	"savvy/internal/handlers"
 	"savvy/internal/metrics"
 	"savvy/internal/middleware"
-	"time"
+	"strings"
 
 	"github.com/labstack/echo/v4"
 	echomiddleware "github.com/labstack/echo/v4/middleware"
@@ -107,7 +106,12 @@ func configureMiddleware(e *echo.Echo, sc *ServerConfig) {
 	e.Use(metrics.Middleware())
 
 	// CSRF Protection (only for non-GET requests)
+	// API requests with a Bearer token are exempt: browsers never attach that header on their own,
+	// and middleware.APITokenAuth rejects the request if the token is invalid.
 	e.Use(echomiddleware.CSRFWithConfig(echomiddleware.CSRFConfig{
+		Skipper: func(c echo.Context) bool {
+			return strings.HasPrefix(c.Request().URL.Path, "/api/v1/") && middleware.IsBearerRequest(c)
+		},
 		TokenLookup:    "form:csrf_token,header:X-CSRF-Token",
 		CookieName:     "_csrf",
 		CookieHTTPOnly: true,
@@ -129,7 +133,7 @@ func configureAuthMiddleware(e *echo.Echo, sc *ServerConfig) {
 	cfg := sc.Config
 
 	e.Use(middleware.SetCurrentUser)
-	e.Use(middleware.SessionTracking) // Track active sessions for metrics
+	e.Use(middleware.SessionTracking) // Record last activity of the session
 	e.Use(middleware.LanguageDetection)
 
 	// Set service version and config in context
@@ -166,37 +170,3 @@ func configurePublicEndpoints(e *echo.Echo) {
 	e.FileFS("/service-worker.js", "static/service-worker.js", assets.Static) // Service Worker
 	e.FileFS("/manifest.json", "static/manifest.json", assets.Static)         // PWA Manifest
 }
-
-// StartMetricsCollector starts a goroutine that periodically updates metrics.
-func StartMetricsCollector() {
-	go func() {
-		ticker := time.NewTicker(30 * time.Second)
-		defer ticker.Stop()
-
-		for range ticker.C {
-			updateMetrics()
-		}
-	}()
-}
-
-// updateMetrics updates Prometheus gauges for resource counts and DB stats.
-func updateMetrics() {
-	// Update resource counts
-	var cardsCount, vouchersCount, giftCardsCount, usersCount int64
-	database.DB.Table("cards").Count(&cardsCount)
-	database.DB.Table("vouchers").Count(&vouchersCount)
-	database.DB.Table("gift_cards").Count(&giftCardsCount)
-	database.DB.Table("users").Count(&usersCount)
-
-	metrics.UpdateResourceCounts(cardsCount, vouchersCount, giftCardsCount, usersCount)
-
-	// Cleanup inactive sessions (sessions are re-counted via middleware)
-	middleware.CleanupInactiveSessions()
-
-	// Update DB connection pool metrics
-	sqlDB, err := database.DB.DB()
-	if err == nil {
-		stats := sqlDB.Stats()
-		metrics.UpdateDBMetrics(stats.InUse, stats.Idle)
-	}
-}
@@ -3,7 +3,9 @@ package validation
 
 import (
 	"fmt"
+	"reflect"
 	"regexp"
+	"strings"
 	"time"
 
 	"github.com/go-playground/validator/v10"
@@ -14,6 +16,15 @@ var Validator *validator.Validate
 
 func init() {
 	Validator = validator.New()
+
+	// Report JSON field names in validation errors (falls back to the Go name)
+	Validator.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "" || name == "-" {
+			return field.Name
+		}
+		return name
+	})
 }
 
 // LoginRequest represents the login form validation
@@ -32,45 +43,67 @@ type RegisterRequest struct {
 
 // CardRequest represents card creation/update validation
 type CardRequest struct {
-	MerchantID   string `validate:"omitempty,uuid"`
-	MerchantName string `validate:"required_without=MerchantID,max=255"`
-	Program      string `validate:"required,max=255"`
-	CardNumber   string `validate:"required,max=255"`
-	BarcodeType  string `validate:"required,oneof=CODE128 QR EAN13 EAN8"`
-	Notes        string `validate:"max=1000"`
-	Status       string `validate:"required,oneof=active inactive expired"`
+	MerchantID   string `json:"merchant_id" validate:"omitempty,uuid"`
+	MerchantName string `json:"merchant_name" validate:"required_without=MerchantID,max=255"`
+	Program      string `json:"program" validate:"required,max=255"`
+	CardNumber   string `json:"card_number" validate:"required,max=255"`
+	BarcodeType  string `json:"barcode_type" validate:"required,oneof=CODE128 QR EAN13 EAN8"`
+	Notes        string `json:"notes" validate:"max=1000"`
+	Status       string `json:"status" validate:"required,oneof=active inactive expired"`
 }
 
 // VoucherRequest represents voucher creation/update validation
 type VoucherRequest struct {
-	MerchantID        string  `validate:"omitempty,uuid"`
-	MerchantName      string  `validate:"required_without=MerchantID,max=255"`
-	Code              string  `validate:"required,max=255"`
-	VoucherType       string  `validate:"required,oneof=percentage fixed_amount points_multiplier"`
-	Value             float64 `validate:"required,gt=0"`
-	MinPurchaseAmount float64 `validate:"omitempty,gte=0"`
-	UsageLimitType    string  `validate:"required,oneof=single_use one_per_customer multiple_use_with_card multiple_use_without_card unlimited"`
-	MaxUses           int     `validate:"omitempty,gte=1"`
-	BarcodeType       string  `validate:"required,oneof=CODE128 QR EAN13 EAN8"`
-	Status            string  `validate:"required,oneof=active inactive expired"`
+	MerchantID        string  `json:"merchant_id" validate:"omitempty,uuid"`
+	MerchantName      string  `json:"merchant_name" validate:"required_without=MerchantID,max=255"`
+	Code              string  `json:"code" validate:"required,max=255"`
+	VoucherType       string  `json:"type" validate:"required,oneof=percentage fixed_amount points_multiplier"`
+	Value             float64 `json:"value" validate:"required,gt=0"`
+	Description       string  `json:"description" validate:"max=1000"`
+	MinPurchaseAmount float64 `json:"min_purchase_amount" validate:"omitempty,gte=0"`
+	ValidFrom         string  `json:"valid_from" validate:"required,datetime=2006-01-02"`
+	ValidUntil        string  `json:"valid_until" validate:"required,datetime=2006-01-02"`
+	UsageLimitType    string  `json:"usage_limit_type" validate:"required,oneof=single_use one_per_customer multiple_use_with_card multiple_use_without_card unlimited"`
+	MaxUses           int     `json:"max_uses" validate:"omitempty,gte=1"`
+	BarcodeType       string  `json:"barcode_type" validate:"required,oneof=CODE128 QR EAN13 EAN8"`
+	Status            string  `json:"status" validate:"required,oneof=active inactive expired"`
 }
 
 // GiftCardRequest represents gift card creation/update validation
 type GiftCardRequest struct {
-	MerchantID     string  `validate:"omitempty,uuid"`
-	MerchantName   string  `validate:"required_without=MerchantID,max=255"`
-	CardNumber     string  `validate:"required,max=255"`
-	InitialBalance float64 `validate:"required,gte=0"`
-	Currency       string  `validate:"required,len=3"` // ISO 4217 currency code
-	PIN            string  `validate:"omitempty,max=50"`
-	BarcodeType    string  `validate:"required,oneof=CODE128 QR EAN13 EAN8"`
-	Status         string  `validate:"required,oneof=active inactive expired"`
+	MerchantID     string  `json:"merchant_id" validate:"omitempty,uuid"`
+	MerchantName   string  `json:"merchant_name" validate:"required_without=MerchantID,max=255"`
+	CardNumber     string  `json:"card_number" validate:"required,max=255"`
+	InitialBalance float64 `json:"initial_balance" validate:"required,gte=0"`
+	Currency       string  `json:"currency" validate:"required,len=3"` // ISO 4217 currency code
+	PIN            string  `json:"pin" validate:"omitempty,max=50"`
+	ExpiresAt      string  `json:"expires_at" validate:"omitempty,datetime=2006-01-02"`
+	BarcodeType    string  `json:"barcode_type" validate:"required,oneof=CODE128 QR EAN13 EAN8"`
+	Notes          string  `json:"notes" validate:"max=1000"`
+	Status         string  `json:"status" validate:"required,oneof=active inactive expired redeemed"`
 }
 
 // TransactionRequest represents transaction creation validation
 type TransactionRequest struct {
-	Amount      float64 `validate:"required,ne=0"` // Can be positive or negative, but not zero
-	Description string  `validate:"omitempty,max=500"`
+	Kind            string  `json:"kind" validate:"omitempty,oneof=purchase reload refund adjustment"` // Defaults to purchase
+	Amount          float64 `json:"amount" validate:"required,ne=0"`                                   // Positive, or signed for adjustments
+	Description     string  `json:"description" validate:"omitempty,max=500"`
+	TransactionDate string  `json:"transaction_date" validate:"omitempty,datetime=2006-01-02"`
+}
+
+// ShareRequest represents share creation validation
+type ShareRequest struct {
+	SharedWithEmail     string `json:"shared_with_email" validate:"required,email,max=255"`
+	CanEdit             bool   `json:"can_edit"`
+	CanDelete           bool   `json:"can_delete"`
+	CanEditTransactions bool   `json:"can_edit_transactions"` // Only used for gift cards
+}
+
+// SharePermissionsRequest represents share permission update validation
+type SharePermissionsRequest struct {
+	CanEdit             bool `json:"can_edit"`
+	CanDelete           bool `json:"can_delete"`
+	CanEditTransactions bool `json:"can_edit_transactions"` // Only used for gift cards
 }
 
 // ValidateStruct validates a struct using the validator
@@ -2,7 +2,9 @@
 package views
 
 import (
+	"savvy/internal/importer"
 	"savvy/internal/models"
+	"savvy/internal/services"
 )
 
 // CardPermissions represents user permissions for a card
@@ -36,3 +38,15 @@ type CardIndexView struct {
 	User            *models.User
 	IsImpersonating bool
 }
+
+// CardImportView contains all data needed for cards/import template
+type CardImportView struct {
+	User            *models.User
+	IsImpersonating bool
+	Format          importer.Format
+	Mapping         importer.Mapping
+	Error           string                      // Translated error of the uploaded file
+	Preview         *services.CardImportPreview // Set after a file was uploaded
+	Records         string                      // JSON-encoded records, posted back to commit the preview
+	Result          *services.CardImportResult  // Set after the import was committed
+}
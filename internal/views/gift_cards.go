@@ -18,6 +18,7 @@ type GiftCardShowView struct {
 	GiftCard        models.GiftCard
 	Merchants       []models.Merchant
 	Shares          []models.GiftCardShare
+	PINReveals      []models.AuditLog // Only loaded for the owner
 	User            *models.User
 	Permissions     GiftCardPermissions
 	IsImpersonating bool
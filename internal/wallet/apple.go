@@ -0,0 +1,395 @@
+package wallet
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/sha1" //nolint:gosec // manifest.json hashes are SHA-1 by Apple's pass format
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"os"
+	"sort"
+	"time"
+
+	"savvy/internal/assets"
+	"savvy/internal/barcodes"
+	"savvy/internal/config"
+	"savvy/internal/i18n"
+	"savvy/internal/models"
+
+	"github.com/smallstep/pkcs7"
+)
+
+// ApplePassContentType is the MIME type of a .pkpass bundle
+const ApplePassContentType = "application/vnd.apple.pkpass"
+
+const (
+	appleOrganizationName = "Savvy"
+	appleIconPath         = "static/icons/apple-touch-icon.png"
+)
+
+// Apple builds and signs Apple Wallet passes (.pkpass bundles).
+type Apple struct {
+	passTypeID   string
+	teamID       string
+	certificate  *x509.Certificate
+	privateKey   crypto.PrivateKey
+	intermediate *x509.Certificate // Apple WWDR certificate, optional for testing
+	httpClient   *http.Client
+}
+
+// NewApple loads the pass certificate, key and WWDR intermediate configured in cfg.
+// Returns ErrNotConfigured if Apple Wallet is not set up.
+func NewApple(cfg *config.Config) (*Apple, error) {
+	if !cfg.IsAppleWalletEnabled() {
+		return nil, ErrNotConfigured
+	}
+
+	certificate, err := loadCertificate(cfg.AppleWalletCertFile)
+	if err != nil {
+		return nil, fmt.Errorf("apple wallet certificate: %w", err)
+	}
+	privateKey, err := loadPrivateKey(cfg.AppleWalletKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("apple wallet key: %w", err)
+	}
+
+	var intermediate *x509.Certificate
+	if cfg.AppleWalletWWDRFile != "" {
+		intermediate, err = loadCertificate(cfg.AppleWalletWWDRFile)
+		if err != nil {
+			return nil, fmt.Errorf("apple wallet WWDR certificate: %w", err)
+		}
+	}
+
+	return &Apple{
+		passTypeID:   cfg.AppleWalletPassTypeID,
+		teamID:       cfg.AppleWalletTeamID,
+		certificate:  certificate,
+		privateKey:   privateKey,
+		intermediate: intermediate,
+		httpClient:   &http.Client{Timeout: logoFetchTimeout},
+	}, nil
+}
+
+// ApplePass is the pass.json document of a pass bundle
+type ApplePass struct {
+	FormatVersion      int                 `json:"formatVersion"`
+	PassTypeIdentifier string              `json:"passTypeIdentifier"`
+	SerialNumber       string              `json:"serialNumber"`
+	TeamIdentifier     string              `json:"teamIdentifier"`
+	OrganizationName   string              `json:"organizationName"`
+	Description        string              `json:"description"`
+	LogoText           string              `json:"logoText,omitempty"`
+	BackgroundColor    string              `json:"backgroundColor,omitempty"`
+	ForegroundColor    string              `json:"foregroundColor,omitempty"`
+	LabelColor         string              `json:"labelColor,omitempty"`
+	ExpirationDate     string              `json:"expirationDate,omitempty"`
+	Voided             bool                `json:"voided,omitempty"`
+	Barcodes           []ApplePassBarcode  `json:"barcodes,omitempty"`
+	StoreCard          *ApplePassStructure `json:"storeCard,omitempty"`
+	Coupon             *ApplePassStructure `json:"coupon,omitempty"`
+}
+
+// ApplePassBarcode is a barcode shown on the front of a pass
+type ApplePassBarcode struct {
+	Format          string `json:"format"`
+	Message         string `json:"message"`
+	MessageEncoding string `json:"messageEncoding"`
+	AltText         string `json:"altText,omitempty"`
+}
+
+// ApplePassStructure holds the fields of a pass style (storeCard, coupon, ...)
+type ApplePassStructure struct {
+	HeaderFields    []ApplePassField `json:"headerFields,omitempty"`
+	PrimaryFields   []ApplePassField `json:"primaryFields,omitempty"`
+	SecondaryFields []ApplePassField `json:"secondaryFields,omitempty"`
+	AuxiliaryFields []ApplePassField `json:"auxiliaryFields,omitempty"`
+	BackFields      []ApplePassField `json:"backFields,omitempty"`
+}
+
+// ApplePassField is a single label/value pair on a pass
+type ApplePassField struct {
+	Key          string `json:"key"`
+	Label        string `json:"label,omitempty"`
+	Value        any    `json:"value"`
+	CurrencyCode string `json:"currencyCode,omitempty"`
+	DateStyle    string `json:"dateStyle,omitempty"`
+}
+
+// appleBarcodeFormats maps symbologies to PassKit barcode formats.
+// PassKit only renders QR, PDF417, Aztec and Code 128.
+var appleBarcodeFormats = map[barcodes.Symbology]string{
+	barcodes.QR:         "PKBarcodeFormatQR",
+	barcodes.PDF417:     "PKBarcodeFormatPDF417",
+	barcodes.Aztec:      "PKBarcodeFormatAztec",
+	barcodes.DataMatrix: "PKBarcodeFormatQR",
+}
+
+// appleBarcode converts a stored barcode type; linear codes fall back to Code 128
+func appleBarcode(barcodeType, message string) []ApplePassBarcode {
+	if message == "" {
+		return nil
+	}
+	format := "PKBarcodeFormatCode128"
+	if symbology, ok := barcodes.SymbologyFor(barcodeType); ok {
+		if f, ok := appleBarcodeFormats[symbology]; ok {
+			format = f
+		}
+	}
+	return []ApplePassBarcode{{
+		Format:          format,
+		Message:         message,
+		MessageEncoding: "iso-8859-1",
+		AltText:         message,
+	}}
+}
+
+// newPass returns a pass with identifiers and merchant colors filled in
+func (a *Apple) newPass(kind, id, merchant, color string) *ApplePass {
+	pass := &ApplePass{
+		FormatVersion:      1,
+		PassTypeIdentifier: a.passTypeID,
+		SerialNumber:       kind + "-" + id,
+		TeamIdentifier:     a.teamID,
+		OrganizationName:   appleOrganizationName,
+		LogoText:           merchant,
+	}
+	if c, ok := parseHexColor(color); ok {
+		pass.BackgroundColor = c.cssRGB()
+		if c.isDark() {
+			pass.ForegroundColor = "rgb(255, 255, 255)"
+			pass.LabelColor = "rgb(235, 235, 235)"
+		} else {
+			pass.ForegroundColor = "rgb(0, 0, 0)"
+			pass.LabelColor = "rgb(60, 60, 60)"
+		}
+	}
+	return pass
+}
+
+// cssRGB formats the color as PassKit expects it, e.g. "rgb(0, 102, 204)"
+func (c rgb) cssRGB() string {
+	return fmt.Sprintf("rgb(%d, %d, %d)", c.r, c.g, c.b)
+}
+
+// CardPass builds the pass.json of a loyalty card as a store card
+func (a *Apple) CardPass(ctx context.Context, card *models.Card) *ApplePass {
+	merchant := merchantName(card.Merchant, card.MerchantName)
+	pass := a.newPass(KindCard, card.ID.String(), merchant, card.GetColor())
+	pass.Description = i18n.T(ctx, "wallet.pass.card", map[string]any{"Merchant": merchant})
+	pass.Barcodes = appleBarcode(card.BarcodeType, card.CardNumber)
+	pass.StoreCard = &ApplePassStructure{
+		PrimaryFields: []ApplePassField{
+			{Key: "program", Label: i18n.T(ctx, "cards.program"), Value: card.Program},
+		},
+		SecondaryFields: []ApplePassField{
+			{Key: "number", Label: i18n.T(ctx, "cards.card_number"), Value: card.CardNumber},
+		},
+	}
+	if card.Notes != "" {
+		pass.StoreCard.BackFields = append(pass.StoreCard.BackFields,
+			ApplePassField{Key: "notes", Label: i18n.T(ctx, "cards.notes"), Value: card.Notes})
+	}
+	return pass
+}
+
+// VoucherPass builds the pass.json of a voucher as a coupon that expires at ValidUntil
+func (a *Apple) VoucherPass(ctx context.Context, voucher *models.Voucher) *ApplePass {
+	merchant := merchantName(voucher.Merchant, voucher.MerchantName)
+	pass := a.newPass(KindVoucher, voucher.ID.String(), merchant, voucher.GetColor())
+	pass.Description = i18n.T(ctx, "wallet.pass.voucher", map[string]any{"Merchant": merchant})
+	pass.Barcodes = appleBarcode(voucher.BarcodeType, voucher.Code)
+	pass.ExpirationDate = voucher.ValidUntil.Format(time.RFC3339)
+
+	value := ApplePassField{Key: "value", Label: i18n.T(ctx, "vouchers.value")}
+	switch voucher.Type {
+	case "percentage":
+		value.Value = fmt.Sprintf("%g%%", voucher.Rate())
+	case "points_multiplier":
+		value.Value = fmt.Sprintf("%gx", voucher.Rate())
+	default:
+		value.Value = voucher.Amount().Float()
+		value.CurrencyCode = models.VoucherCurrency
+	}
+
+	pass.Coupon = &ApplePassStructure{
+		PrimaryFields: []ApplePassField{value},
+		SecondaryFields: []ApplePassField{
+			{Key: "description", Label: i18n.T(ctx, "vouchers.description"), Value: voucher.Description},
+		},
+		AuxiliaryFields: []ApplePassField{
+			{Key: "valid_from", Label: i18n.T(ctx, "vouchers.valid_from"), Value: voucher.ValidFrom.Format(time.RFC3339), DateStyle: "PKDateStyleMedium"},
+			{Key: "valid_until", Label: i18n.T(ctx, "vouchers.valid_until"), Value: voucher.ValidUntil.Format(time.RFC3339), DateStyle: "PKDateStyleMedium"},
+		},
+		BackFields: []ApplePassField{
+			{Key: "code", Label: i18n.T(ctx, "vouchers.code"), Value: voucher.Code},
+		},
+	}
+	if voucher.MinPurchaseAmount.IsPositive() {
+		pass.Coupon.BackFields = append(pass.Coupon.BackFields, ApplePassField{
+			Key: "min_purchase", Label: i18n.T(ctx, "vouchers.min_purchase"), Value: voucher.MinPurchaseAmount.Float(), CurrencyCode: models.VoucherCurrency,
+		})
+	}
+	return pass
+}
+
+// GiftCardPass builds the pass.json of a gift card as a store card showing the current balance
+func (a *Apple) GiftCardPass(ctx context.Context, giftCard *models.GiftCard) *ApplePass {
+	merchant := merchantName(giftCard.Merchant, giftCard.MerchantName)
+	pass := a.newPass(KindGiftCard, giftCard.ID.String(), merchant, giftCard.GetColor())
+	pass.Description = i18n.T(ctx, "wallet.pass.gift_card", map[string]any{"Merchant": merchant})
+	pass.Barcodes = appleBarcode(giftCard.BarcodeType, giftCard.CardNumber)
+	pass.Voided = giftCard.IsEmpty()
+
+	pass.StoreCard = &ApplePassStructure{
+		PrimaryFields: []ApplePassField{{
+			Key:          "balance",
+			Label:        i18n.T(ctx, "giftcards.current_balance"),
+			Value:        giftCard.GetCurrentBalance().Float(),
+			CurrencyCode: giftCard.Currency,
+		}},
+		SecondaryFields: []ApplePassField{
+			{Key: "number", Label: i18n.T(ctx, "cards.card_number"), Value: giftCard.CardNumber},
+		},
+	}
+	if giftCard.ExpiresAt != nil {
+		pass.ExpirationDate = giftCard.ExpiresAt.Format(time.RFC3339)
+		pass.StoreCard.AuxiliaryFields = append(pass.StoreCard.AuxiliaryFields, ApplePassField{
+			Key: "expires", Label: i18n.T(ctx, "common.valid_until"), Value: pass.ExpirationDate, DateStyle: "PKDateStyleMedium",
+		})
+	}
+	if giftCard.Notes != "" {
+		pass.StoreCard.BackFields = append(pass.StoreCard.BackFields,
+			ApplePassField{Key: "notes", Label: i18n.T(ctx, "cards.notes"), Value: giftCard.Notes})
+	}
+	return pass
+}
+
+// Bundle packages a pass with icon, merchant logo, manifest and signature into a .pkpass archive.
+// A missing or unreachable logo is not an error; the pass then only shows the logo text.
+func (a *Apple) Bundle(ctx context.Context, pass *ApplePass, merchant *models.Merchant) ([]byte, error) {
+	passJSON, err := json.Marshal(pass)
+	if err != nil {
+		return nil, err
+	}
+	icon, err := fs.ReadFile(assets.Static, appleIconPath)
+	if err != nil {
+		return nil, err
+	}
+
+	files := map[string][]byte{
+		"pass.json":   passJSON,
+		"icon.png":    icon,
+		"icon@2x.png": icon,
+	}
+	if merchant != nil && merchant.LogoURL != "" {
+		logo, err := fetchLogo(ctx, a.httpClient, merchant.LogoURL)
+		if err != nil {
+			slog.Warn("Failed to fetch merchant logo for wallet pass", "merchant", merchant.Name, "error", err)
+		} else {
+			files["logo.png"] = logo
+			files["logo@2x.png"] = logo
+		}
+	}
+
+	manifest := make(map[string]string, len(files))
+	for name, content := range files {
+		sum := sha1.Sum(content) //nolint:gosec // required by the pass format
+		manifest[name] = hex.EncodeToString(sum[:])
+	}
+	manifestJSON, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := a.sign(manifestJSON)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign pass: %w", err)
+	}
+	files["manifest.json"] = manifestJSON
+	files["signature"] = signature
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range names {
+		w, err := zw.Create(name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(files[name]); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// sign creates the detached PKCS#7 signature of manifest.json
+func (a *Apple) sign(manifest []byte) ([]byte, error) {
+	signedData, err := pkcs7.NewSignedData(manifest)
+	if err != nil {
+		return nil, err
+	}
+	signedData.SetDigestAlgorithm(pkcs7.OIDDigestAlgorithmSHA256)
+
+	var parents []*x509.Certificate
+	if a.intermediate != nil {
+		parents = append(parents, a.intermediate)
+	}
+	if err := signedData.AddSignerChain(a.certificate, a.privateKey, parents, pkcs7.SignerInfoConfig{}); err != nil {
+		return nil, err
+	}
+	signedData.Detach()
+	return signedData.Finish()
+}
+
+// loadCertificate reads the first PEM certificate from path
+func loadCertificate(path string) (*x509.Certificate, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // path comes from configuration
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, errors.New("no PEM certificate found")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+// loadPrivateKey reads a PEM encoded PKCS#1, PKCS#8 or EC private key from path
+func loadPrivateKey(path string) (crypto.PrivateKey, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // path comes from configuration
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM private key found")
+	}
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		return x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported private key type %q", block.Type)
+	}
+}
@@ -0,0 +1,353 @@
+package wallet
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1" //nolint:gosec // manifest.json hashes are SHA-1 by Apple's pass format
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"savvy/internal/assets"
+	"savvy/internal/config"
+	"savvy/internal/i18n"
+	"savvy/internal/models"
+	"savvy/internal/money"
+
+	"github.com/google/uuid"
+	"github.com/smallstep/pkcs7"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	if err := i18n.Init(assets.Locales); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+// newTestCertificate creates an RSA certificate signed by parent, or self-signed if parent is nil
+func newTestCertificate(t *testing.T, cn string, isCA bool, parent *x509.Certificate, parentKey *rsa.PrivateKey) (*x509.Certificate, *rsa.PrivateKey) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: cn, OrganizationalUnit: []string{"TEAM123456"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert, key
+}
+
+func writePEM(t *testing.T, dir, name, blockType string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600))
+	return path
+}
+
+// newTestApple configures Apple Wallet with a self-signed pass certificate
+func newTestApple(t *testing.T) (*Apple, *x509.Certificate) {
+	t.Helper()
+
+	cert, key := newTestCertificate(t, "Pass Type ID: pass.test.savvy", false, nil, nil)
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	apple, err := NewApple(&config.Config{
+		AppleWalletPassTypeID: "pass.test.savvy",
+		AppleWalletTeamID:     "TEAM123456",
+		AppleWalletCertFile:   writePEM(t, dir, "pass.pem", "CERTIFICATE", cert.Raw),
+		AppleWalletKeyFile:    writePEM(t, dir, "pass.key", "PRIVATE KEY", keyDER),
+	})
+	require.NoError(t, err)
+	return apple, cert
+}
+
+func readBundle(t *testing.T, data []byte) map[string][]byte {
+	t.Helper()
+
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+
+	files := map[string][]byte{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		content, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		require.NoError(t, rc.Close())
+		files[f.Name] = content
+	}
+	return files
+}
+
+func TestNewApple_NotConfigured(t *testing.T) {
+	_, err := NewApple(&config.Config{})
+	assert.ErrorIs(t, err, ErrNotConfigured)
+}
+
+func TestNewApple_MissingCertificate(t *testing.T) {
+	_, err := NewApple(&config.Config{
+		AppleWalletPassTypeID: "pass.test.savvy",
+		AppleWalletTeamID:     "TEAM123456",
+		AppleWalletCertFile:   filepath.Join(t.TempDir(), "missing.pem"),
+		AppleWalletKeyFile:    filepath.Join(t.TempDir(), "missing.key"),
+	})
+	assert.Error(t, err)
+}
+
+func TestApple_Bundle_SignedWithSelfSignedCertificate(t *testing.T) {
+	apple, cert := newTestApple(t)
+
+	card := &models.Card{
+		ID:           uuid.New(),
+		MerchantName: "Migros",
+		Program:      "Cumulus",
+		CardNumber:   "2099123456789",
+		BarcodeType:  "EAN13",
+	}
+	data, err := apple.Bundle(context.Background(), apple.CardPass(context.Background(), card), nil)
+	require.NoError(t, err)
+
+	files := readBundle(t, data)
+	for _, name := range []string{"pass.json", "icon.png", "manifest.json", "signature"} {
+		assert.Contains(t, files, name)
+	}
+	assert.NotContains(t, files, "logo.png")
+
+	// Every file except manifest and signature is listed with its SHA-1 hash
+	var manifest map[string]string
+	require.NoError(t, json.Unmarshal(files["manifest.json"], &manifest))
+	assert.Len(t, manifest, len(files)-2)
+	for name, hash := range manifest {
+		sum := sha1.Sum(files[name]) //nolint:gosec // required by the pass format
+		assert.Equal(t, hex.EncodeToString(sum[:]), hash, name)
+	}
+
+	// Detached signature over manifest.json
+	p7, err := pkcs7.Parse(files["signature"])
+	require.NoError(t, err)
+	assert.Empty(t, p7.Content)
+	p7.Content = files["manifest.json"]
+
+	roots := x509.NewCertPool()
+	roots.AddCert(cert)
+	require.NoError(t, p7.VerifyWithChain(roots))
+	assert.Equal(t, cert.Raw, p7.GetOnlySigner().Raw)
+
+	// A modified manifest no longer verifies
+	p7.Content = append([]byte(nil), files["manifest.json"]...)
+	p7.Content[1] ^= 0xff
+	assert.Error(t, p7.Verify())
+}
+
+func TestApple_Bundle_IncludesIntermediate(t *testing.T) {
+	wwdr, wwdrKey := newTestCertificate(t, "Test WWDR", true, nil, nil)
+	cert, key := newTestCertificate(t, "Pass Type ID: pass.test.savvy", false, wwdr, wwdrKey)
+
+	apple := &Apple{
+		passTypeID:   "pass.test.savvy",
+		teamID:       "TEAM123456",
+		certificate:  cert,
+		privateKey:   key,
+		intermediate: wwdr,
+		httpClient:   http.DefaultClient,
+	}
+
+	data, err := apple.Bundle(context.Background(), apple.CardPass(context.Background(), &models.Card{ID: uuid.New()}), nil)
+	require.NoError(t, err)
+
+	files := readBundle(t, data)
+	p7, err := pkcs7.Parse(files["signature"])
+	require.NoError(t, err)
+	p7.Content = files["manifest.json"]
+	assert.Len(t, p7.Certificates, 2)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(wwdr)
+	assert.NoError(t, p7.VerifyWithChain(roots))
+}
+
+func TestApple_Bundle_MerchantLogo(t *testing.T) {
+	apple, _ := newTestApple(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/logo.png" {
+			http.NotFound(w, r)
+			return
+		}
+		img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+		img.Set(1, 1, color.RGBA{R: 255, A: 255})
+		_ = png.Encode(w, img)
+	}))
+	defer server.Close()
+
+	pass := apple.CardPass(context.Background(), &models.Card{ID: uuid.New()})
+
+	data, err := apple.Bundle(context.Background(), pass, &models.Merchant{Name: "Coop", LogoURL: server.URL + "/logo.png"})
+	require.NoError(t, err)
+	files := readBundle(t, data)
+	require.Contains(t, files, "logo.png")
+	_, err = png.Decode(bytes.NewReader(files["logo.png"]))
+	assert.NoError(t, err)
+
+	// An unreachable logo is skipped
+	data, err = apple.Bundle(context.Background(), pass, &models.Merchant{Name: "Coop", LogoURL: server.URL + "/missing.png"})
+	require.NoError(t, err)
+	assert.NotContains(t, readBundle(t, data), "logo.png")
+}
+
+func TestApple_CardPass(t *testing.T) {
+	apple, _ := newTestApple(t)
+	card := &models.Card{
+		ID:           uuid.New(),
+		MerchantName: "Free text",
+		Merchant:     &models.Merchant{Name: "Migros", Color: "#FF6600"},
+		Program:      "Cumulus",
+		CardNumber:   "2099123456789",
+		BarcodeType:  "QR",
+		Notes:        "Family card",
+	}
+
+	pass := apple.CardPass(context.Background(), card)
+
+	assert.Equal(t, 1, pass.FormatVersion)
+	assert.Equal(t, "pass.test.savvy", pass.PassTypeIdentifier)
+	assert.Equal(t, "TEAM123456", pass.TeamIdentifier)
+	assert.Equal(t, "card-"+card.ID.String(), pass.SerialNumber)
+	assert.Equal(t, "Migros", pass.LogoText)
+	assert.Equal(t, "Kundenkarte Migros", pass.Description)
+	assert.Equal(t, "rgb(255, 102, 0)", pass.BackgroundColor)
+	require.NotNil(t, pass.StoreCard)
+	assert.Nil(t, pass.Coupon)
+	assert.Equal(t, "Cumulus", pass.StoreCard.PrimaryFields[0].Value)
+	assert.Equal(t, "2099123456789", pass.StoreCard.SecondaryFields[0].Value)
+	assert.Equal(t, "Family card", pass.StoreCard.BackFields[0].Value)
+	require.Len(t, pass.Barcodes, 1)
+	assert.Equal(t, "PKBarcodeFormatQR", pass.Barcodes[0].Format)
+	assert.Equal(t, "2099123456789", pass.Barcodes[0].Message)
+}
+
+func TestApple_VoucherPass(t *testing.T) {
+	apple, _ := newTestApple(t)
+	validUntil := time.Date(2026, 12, 31, 23, 59, 59, 0, time.UTC)
+	voucher := &models.Voucher{
+		ID:           uuid.New(),
+		MerchantName: "Coop",
+		Code:         "SAVE20",
+		Type:         "percentage",
+		Value:        2000,
+		ValidFrom:    time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		ValidUntil:   validUntil,
+		BarcodeType:  "CODE39",
+	}
+
+	pass := apple.VoucherPass(context.Background(), voucher)
+
+	require.NotNil(t, pass.Coupon)
+	assert.Nil(t, pass.StoreCard)
+	assert.Equal(t, "voucher-"+voucher.ID.String(), pass.SerialNumber)
+	assert.Equal(t, "2026-12-31T23:59:59Z", pass.ExpirationDate)
+	assert.Equal(t, "20%", pass.Coupon.PrimaryFields[0].Value)
+	assert.Equal(t, "PKBarcodeFormatCode128", pass.Barcodes[0].Format)
+
+	voucher.Type = "fixed_amount"
+	voucher.Value = 1550
+	pass = apple.VoucherPass(context.Background(), voucher)
+	assert.Equal(t, 15.5, pass.Coupon.PrimaryFields[0].Value)
+	assert.Equal(t, "CHF", pass.Coupon.PrimaryFields[0].CurrencyCode)
+}
+
+func TestApple_GiftCardPass(t *testing.T) {
+	apple, _ := newTestApple(t)
+	expiresAt := time.Date(2027, 6, 30, 0, 0, 0, 0, time.UTC)
+	giftCard := &models.GiftCard{
+		ID:             uuid.New(),
+		MerchantName:   "Manor",
+		CardNumber:     "6001234",
+		CurrentBalance: money.New(4250, "CHF"),
+		Currency:       "EUR",
+		ExpiresAt:      &expiresAt,
+		BarcodeType:    "PDF417",
+	}
+
+	pass := apple.GiftCardPass(context.Background(), giftCard)
+
+	require.NotNil(t, pass.StoreCard)
+	assert.Equal(t, "gift_card-"+giftCard.ID.String(), pass.SerialNumber)
+	balance := pass.StoreCard.PrimaryFields[0]
+	assert.Equal(t, "balance", balance.Key)
+	assert.Equal(t, 42.5, balance.Value)
+	assert.Equal(t, "EUR", balance.CurrencyCode)
+	assert.Equal(t, "2027-06-30T00:00:00Z", pass.ExpirationDate)
+	assert.Equal(t, "PKBarcodeFormatPDF417", pass.Barcodes[0].Format)
+	assert.False(t, pass.Voided)
+
+	giftCard.CurrentBalance = money.New(0, "CHF")
+	assert.True(t, apple.GiftCardPass(context.Background(), giftCard).Voided)
+}
+
+func TestAppleBarcode(t *testing.T) {
+	tests := map[string]string{
+		"QR":         "PKBarcodeFormatQR",
+		"PDF417":     "PKBarcodeFormatPDF417",
+		"AZTEC":      "PKBarcodeFormatAztec",
+		"DATAMATRIX": "PKBarcodeFormatQR",
+		"CODE128":    "PKBarcodeFormatCode128",
+		"EAN13":      "PKBarcodeFormatCode128",
+		"UPCA":       "PKBarcodeFormatCode128",
+		"":           "PKBarcodeFormatCode128",
+	}
+	for barcodeType, want := range tests {
+		assert.Equal(t, want, appleBarcode(barcodeType, "123")[0].Format, barcodeType)
+	}
+	assert.Nil(t, appleBarcode("QR", ""))
+}
+
+func TestParseHexColor(t *testing.T) {
+	c, ok := parseHexColor("#0066CC")
+	assert.True(t, ok)
+	assert.Equal(t, rgb{0, 102, 204}, c)
+	assert.True(t, c.isDark())
+
+	c, ok = parseHexColor("#fc0")
+	assert.True(t, ok)
+	assert.Equal(t, rgb{255, 204, 0}, c)
+	assert.False(t, c.isDark())
+
+	_, ok = parseHexColor("blue")
+	assert.False(t, ok)
+}
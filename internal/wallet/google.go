@@ -0,0 +1,385 @@
+package wallet
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"os"
+	"time"
+
+	"savvy/internal/config"
+	"savvy/internal/i18n"
+	"savvy/internal/models"
+	"savvy/internal/money"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// googleSaveURL is the prefix of "Save to Google Wallet" links
+const googleSaveURL = "https://pay.google.com/gp/v/save/"
+
+// Google builds "Save to Google Wallet" links signed with a service account key.
+type Google struct {
+	issuerID     string
+	accountEmail string
+	privateKey   *rsa.PrivateKey
+}
+
+// googleServiceAccount is the subset of a service account key file used for signing
+type googleServiceAccount struct {
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+}
+
+// NewGoogle loads the service account key configured in cfg.
+// Returns ErrNotConfigured if Google Wallet is not set up.
+func NewGoogle(cfg *config.Config) (*Google, error) {
+	if !cfg.IsGoogleWalletEnabled() {
+		return nil, ErrNotConfigured
+	}
+
+	data, err := os.ReadFile(cfg.GoogleWalletServiceAccountFile) //nolint:gosec // path comes from configuration
+	if err != nil {
+		return nil, fmt.Errorf("google wallet service account: %w", err)
+	}
+	var account googleServiceAccount
+	if err := json.Unmarshal(data, &account); err != nil {
+		return nil, fmt.Errorf("google wallet service account: %w", err)
+	}
+	if account.ClientEmail == "" {
+		return nil, errors.New("google wallet service account: missing client_email")
+	}
+
+	block, _ := pem.Decode([]byte(account.PrivateKey))
+	if block == nil {
+		return nil, errors.New("google wallet service account: no PEM private key found")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("google wallet service account: %w", err)
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("google wallet service account: private key is not RSA")
+	}
+
+	return &Google{
+		issuerID:     cfg.GoogleWalletIssuerID,
+		accountEmail: account.ClientEmail,
+		privateKey:   rsaKey,
+	}, nil
+}
+
+// GooglePayload holds the classes and objects embedded in a save JWT
+type GooglePayload struct {
+	LoyaltyClasses  []GoogleClass  `json:"loyaltyClasses,omitempty"`
+	LoyaltyObjects  []GoogleObject `json:"loyaltyObjects,omitempty"`
+	OfferClasses    []GoogleClass  `json:"offerClasses,omitempty"`
+	OfferObjects    []GoogleObject `json:"offerObjects,omitempty"`
+	GiftCardClasses []GoogleClass  `json:"giftCardClasses,omitempty"`
+	GiftCardObjects []GoogleObject `json:"giftCardObjects,omitempty"`
+}
+
+// GoogleClass is a loyalty, offer or gift card class. Unused fields are omitted.
+type GoogleClass struct {
+	ID                 string       `json:"id"`
+	IssuerName         string       `json:"issuerName"`
+	ReviewStatus       string       `json:"reviewStatus"`
+	HexBackgroundColor string       `json:"hexBackgroundColor,omitempty"`
+	ProgramName        string       `json:"programName,omitempty"`       // Loyalty
+	ProgramLogo        *GoogleImage `json:"programLogo,omitempty"`       // Loyalty, gift card
+	MerchantName       string       `json:"merchantName,omitempty"`      // Gift card
+	Title              string       `json:"title,omitempty"`             // Offer
+	Provider           string       `json:"provider,omitempty"`          // Offer
+	RedemptionChannel  string       `json:"redemptionChannel,omitempty"` // Offer
+	TitleImage         *GoogleImage `json:"titleImage,omitempty"`        // Offer
+}
+
+// GoogleObject is a loyalty, offer or gift card object. Unused fields are omitted.
+type GoogleObject struct {
+	ID                string              `json:"id"`
+	ClassID           string              `json:"classId"`
+	State             string              `json:"state"`
+	Barcode           *GoogleBarcode      `json:"barcode,omitempty"`
+	ValidTimeInterval *GoogleTimeInterval `json:"validTimeInterval,omitempty"`
+	TextModulesData   []GoogleTextModule  `json:"textModulesData,omitempty"`
+	AccountID         string              `json:"accountId,omitempty"`         // Loyalty
+	AccountName       string              `json:"accountName,omitempty"`       // Loyalty
+	CardNumber        string              `json:"cardNumber,omitempty"`        // Gift card
+	Balance           *GoogleMoney        `json:"balance,omitempty"`           // Gift card
+	BalanceUpdateTime *GoogleDateTime     `json:"balanceUpdateTime,omitempty"` // Gift card
+}
+
+// GoogleImage references a publicly reachable image
+type GoogleImage struct {
+	SourceURI GoogleURI `json:"sourceUri"`
+}
+
+// GoogleURI is a link or image location
+type GoogleURI struct {
+	URI string `json:"uri"`
+}
+
+// GoogleBarcode is the barcode shown on a pass
+type GoogleBarcode struct {
+	Type          string `json:"type"`
+	Value         string `json:"value"`
+	AlternateText string `json:"alternateText,omitempty"`
+}
+
+// GoogleTimeInterval limits the validity of an object
+type GoogleTimeInterval struct {
+	Start *GoogleDateTime `json:"start,omitempty"`
+	End   *GoogleDateTime `json:"end,omitempty"`
+}
+
+// GoogleDateTime is an ISO 8601 timestamp
+type GoogleDateTime struct {
+	Date string `json:"date"`
+}
+
+// GoogleMoney is an amount in micros (millionths of the currency unit)
+type GoogleMoney struct {
+	Micros       int64  `json:"micros"`
+	CurrencyCode string `json:"currencyCode"`
+}
+
+// GoogleTextModule is a header/body pair shown in the pass details
+type GoogleTextModule struct {
+	ID     string `json:"id"`
+	Header string `json:"header"`
+	Body   string `json:"body"`
+}
+
+// googleBarcodeTypes maps stored barcode types to Google Wallet barcode types.
+// Google renders more linear formats than the barcode handler (UPC-A, ITF-14),
+// so this maps the stored type directly instead of going through barcodes.SymbologyFor.
+var googleBarcodeTypes = map[string]string{
+	"CODE128":    "CODE_128",
+	"CODE39":     "CODE_39",
+	"CODABAR":    "CODABAR",
+	"QR":         "QR_CODE",
+	"EAN13":      "EAN_13",
+	"ISBN13":     "EAN_13",
+	"EAN8":       "EAN_8",
+	"PDF417":     "PDF_417",
+	"DATAMATRIX": "DATA_MATRIX",
+	"AZTEC":      "AZTEC",
+	"UPCA":       "UPC_A",
+	"ITF14":      "ITF_14",
+}
+
+// googleBarcode converts a stored barcode type; unsupported types fall back to Code 128
+func googleBarcode(barcodeType, value string) *GoogleBarcode {
+	if value == "" {
+		return nil
+	}
+	barcodeTypeName, ok := googleBarcodeTypes[barcodeType]
+	if !ok {
+		barcodeTypeName = "CODE_128"
+	}
+	return &GoogleBarcode{Type: barcodeTypeName, Value: value, AlternateText: value}
+}
+
+// googleImage returns the merchant logo if it is an absolute URL, otherwise the default logo.
+// Google fetches the image itself, so it must be publicly reachable.
+func googleImage(merchant *models.Merchant, defaultLogo string) *GoogleImage {
+	uri := defaultLogo
+	if merchant != nil && merchant.LogoURL != "" {
+		if u, err := url.Parse(merchant.LogoURL); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+			uri = merchant.LogoURL
+		}
+	}
+	if uri == "" {
+		return nil
+	}
+	return &GoogleImage{SourceURI: GoogleURI{URI: uri}}
+}
+
+func googleDateTime(t time.Time) *GoogleDateTime {
+	return &GoogleDateTime{Date: t.Format(time.RFC3339)}
+}
+
+// ids returns object and class IDs; both must be prefixed with the issuer ID
+func (g *Google) ids(kind, id string) (objectID, classID string) {
+	objectID = fmt.Sprintf("%s.%s-%s", g.issuerID, kind, id)
+	return objectID, objectID + "-class"
+}
+
+// googleColor normalizes a merchant color to "#rrggbb"
+func googleColor(color string) string {
+	c, ok := parseHexColor(color)
+	if !ok {
+		return ""
+	}
+	return c.hex()
+}
+
+// CardPayload builds the loyalty class and object of a card.
+// defaultLogo is used when the merchant has no logo; Google requires a program logo.
+func (g *Google) CardPayload(ctx context.Context, card *models.Card, defaultLogo string) *GooglePayload {
+	merchant := merchantName(card.Merchant, card.MerchantName)
+	objectID, classID := g.ids(KindCard, card.ID.String())
+
+	state := "ACTIVE"
+	if card.Status != "" && card.Status != "active" {
+		state = "INACTIVE"
+	}
+
+	object := GoogleObject{
+		ID:          objectID,
+		ClassID:     classID,
+		State:       state,
+		Barcode:     googleBarcode(card.BarcodeType, card.CardNumber),
+		AccountID:   card.CardNumber,
+		AccountName: card.Program,
+	}
+	if card.Notes != "" {
+		object.TextModulesData = append(object.TextModulesData,
+			GoogleTextModule{ID: "notes", Header: i18n.T(ctx, "cards.notes"), Body: card.Notes})
+	}
+
+	return &GooglePayload{
+		LoyaltyClasses: []GoogleClass{{
+			ID:                 classID,
+			IssuerName:         merchant,
+			ReviewStatus:       "UNDER_REVIEW",
+			HexBackgroundColor: googleColor(card.GetColor()),
+			ProgramName:        card.Program,
+			ProgramLogo:        googleImage(card.Merchant, defaultLogo),
+		}},
+		LoyaltyObjects: []GoogleObject{object},
+	}
+}
+
+// VoucherPayload builds the offer class and object of a voucher, valid from ValidFrom to ValidUntil
+func (g *Google) VoucherPayload(ctx context.Context, voucher *models.Voucher, defaultLogo string) *GooglePayload {
+	merchant := merchantName(voucher.Merchant, voucher.MerchantName)
+	objectID, classID := g.ids(KindVoucher, voucher.ID.String())
+
+	value := voucher.Amount().String()
+	switch voucher.Type {
+	case "percentage":
+		value = fmt.Sprintf("%g%%", voucher.Rate())
+	case "points_multiplier":
+		value = fmt.Sprintf("%gx", voucher.Rate())
+	}
+	title := value
+	if voucher.Description != "" {
+		title = value + " · " + voucher.Description
+	}
+
+	state := "ACTIVE"
+	if time.Now().After(voucher.ValidUntil) {
+		state = "EXPIRED"
+	}
+
+	object := GoogleObject{
+		ID:      objectID,
+		ClassID: classID,
+		State:   state,
+		Barcode: googleBarcode(voucher.BarcodeType, voucher.Code),
+		ValidTimeInterval: &GoogleTimeInterval{
+			Start: googleDateTime(voucher.ValidFrom),
+			End:   googleDateTime(voucher.ValidUntil),
+		},
+		TextModulesData: []GoogleTextModule{
+			{ID: "code", Header: i18n.T(ctx, "vouchers.code"), Body: voucher.Code},
+		},
+	}
+	if voucher.MinPurchaseAmount.IsPositive() {
+		object.TextModulesData = append(object.TextModulesData, GoogleTextModule{
+			ID: "min_purchase", Header: i18n.T(ctx, "vouchers.min_purchase"), Body: money.New(voucher.MinPurchaseAmount.Amount, models.VoucherCurrency).String(),
+		})
+	}
+
+	return &GooglePayload{
+		OfferClasses: []GoogleClass{{
+			ID:                 classID,
+			IssuerName:         merchant,
+			ReviewStatus:       "UNDER_REVIEW",
+			HexBackgroundColor: googleColor(voucher.GetColor()),
+			Title:              title,
+			Provider:           merchant,
+			RedemptionChannel:  "BOTH",
+			TitleImage:         googleImage(voucher.Merchant, defaultLogo),
+		}},
+		OfferObjects: []GoogleObject{object},
+	}
+}
+
+// GiftCardPayload builds the gift card class and object of a gift card including its current balance
+func (g *Google) GiftCardPayload(ctx context.Context, giftCard *models.GiftCard, defaultLogo string) *GooglePayload {
+	merchant := merchantName(giftCard.Merchant, giftCard.MerchantName)
+	objectID, classID := g.ids(KindGiftCard, giftCard.ID.String())
+
+	var state string
+	switch giftCard.GetComputedStatus() {
+	case "redeemed":
+		state = "COMPLETED"
+	case "expired":
+		state = "EXPIRED"
+	default:
+		state = "ACTIVE"
+	}
+
+	object := GoogleObject{
+		ID:         objectID,
+		ClassID:    classID,
+		State:      state,
+		Barcode:    googleBarcode(giftCard.BarcodeType, giftCard.CardNumber),
+		CardNumber: giftCard.CardNumber,
+		Balance: &GoogleMoney{
+			Micros:       giftCard.CurrentBalance.Amount * int64(math.Pow10(6-money.Exponent(giftCard.Currency))),
+			CurrencyCode: giftCard.Currency,
+		},
+		BalanceUpdateTime: googleDateTime(giftCard.UpdatedAt),
+	}
+	if giftCard.ExpiresAt != nil {
+		object.ValidTimeInterval = &GoogleTimeInterval{End: googleDateTime(*giftCard.ExpiresAt)}
+	}
+	if giftCard.Notes != "" {
+		object.TextModulesData = append(object.TextModulesData,
+			GoogleTextModule{ID: "notes", Header: i18n.T(ctx, "cards.notes"), Body: giftCard.Notes})
+	}
+
+	return &GooglePayload{
+		GiftCardClasses: []GoogleClass{{
+			ID:                 classID,
+			IssuerName:         merchant,
+			ReviewStatus:       "UNDER_REVIEW",
+			HexBackgroundColor: googleColor(giftCard.GetColor()),
+			MerchantName:       merchant,
+			ProgramLogo:        googleImage(giftCard.Merchant, defaultLogo),
+		}},
+		GiftCardObjects: []GoogleObject{object},
+	}
+}
+
+// SaveJWT signs the payload as a "savetowallet" JWT with the service account key (RS256)
+func (g *Google) SaveJWT(payload *GooglePayload) (string, error) {
+	claims := jwt.MapClaims{
+		"iss":     g.accountEmail,
+		"aud":     "google",
+		"typ":     "savetowallet",
+		"iat":     time.Now().Unix(),
+		"origins": []string{},
+		"payload": payload,
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(g.privateKey)
+}
+
+// SaveURL returns the "Save to Google Wallet" link for the payload
+func (g *Google) SaveURL(payload *GooglePayload) (string, error) {
+	token, err := g.SaveJWT(payload)
+	if err != nil {
+		return "", err
+	}
+	return googleSaveURL + token, nil
+}
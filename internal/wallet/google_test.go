@@ -0,0 +1,208 @@
+package wallet
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"savvy/internal/config"
+	"savvy/internal/models"
+	"savvy/internal/money"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testDefaultLogo = "https://savvy.example.com/static/icons/icon-512.png"
+
+// newTestGoogle configures Google Wallet with a freshly generated service account key
+func newTestGoogle(t *testing.T) (*Google, *rsa.PublicKey) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	account, err := json.Marshal(map[string]string{
+		"type":         "service_account",
+		"client_email": "wallet@savvy-test.iam.gserviceaccount.com",
+		"private_key":  string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+	})
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "service-account.json")
+	require.NoError(t, os.WriteFile(path, account, 0o600))
+
+	google, err := NewGoogle(&config.Config{
+		GoogleWalletIssuerID:           "3388000000012345678",
+		GoogleWalletServiceAccountFile: path,
+	})
+	require.NoError(t, err)
+	return google, &key.PublicKey
+}
+
+func TestNewGoogle_NotConfigured(t *testing.T) {
+	_, err := NewGoogle(&config.Config{})
+	assert.ErrorIs(t, err, ErrNotConfigured)
+}
+
+func TestNewGoogle_InvalidServiceAccount(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "service-account.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"client_email": "wallet@example.com", "private_key": "nope"}`), 0o600))
+
+	_, err := NewGoogle(&config.Config{
+		GoogleWalletIssuerID:           "3388000000012345678",
+		GoogleWalletServiceAccountFile: path,
+	})
+	assert.Error(t, err)
+}
+
+func TestGoogle_SaveURL_SignedJWT(t *testing.T) {
+	google, publicKey := newTestGoogle(t)
+	card := &models.Card{
+		ID:           uuid.New(),
+		MerchantName: "Migros",
+		Program:      "Cumulus",
+		CardNumber:   "2099123456789",
+		BarcodeType:  "EAN13",
+		Status:       "active",
+	}
+
+	saveURL, err := google.SaveURL(google.CardPayload(context.Background(), card, testDefaultLogo))
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(saveURL, "https://pay.google.com/gp/v/save/"))
+
+	claims := jwt.MapClaims{}
+	token, err := jwt.ParseWithClaims(strings.TrimPrefix(saveURL, googleSaveURL), claims, func(token *jwt.Token) (any, error) {
+		return publicKey, nil
+	}, jwt.WithValidMethods([]string{"RS256"}))
+	require.NoError(t, err)
+	assert.True(t, token.Valid)
+
+	assert.Equal(t, "wallet@savvy-test.iam.gserviceaccount.com", claims["iss"])
+	assert.Equal(t, "google", claims["aud"])
+	assert.Equal(t, "savetowallet", claims["typ"])
+
+	payload := claims["payload"].(map[string]any)
+	classes := payload["loyaltyClasses"].([]any)
+	objects := payload["loyaltyObjects"].([]any)
+	require.Len(t, classes, 1)
+	require.Len(t, objects, 1)
+	object := objects[0].(map[string]any)
+	assert.Equal(t, "3388000000012345678.card-"+card.ID.String(), object["id"])
+	assert.Equal(t, classes[0].(map[string]any)["id"], object["classId"])
+	assert.Equal(t, "EAN_13", object["barcode"].(map[string]any)["type"])
+}
+
+func TestGoogle_CardPayload(t *testing.T) {
+	google, _ := newTestGoogle(t)
+	card := &models.Card{
+		ID:          uuid.New(),
+		Merchant:    &models.Merchant{Name: "Coop", Color: "#E2001A", LogoURL: "https://coop.example.com/logo.png"},
+		Program:     "Supercard",
+		CardNumber:  "123456",
+		BarcodeType: "QR",
+		Status:      "inactive",
+	}
+
+	payload := google.CardPayload(context.Background(), card, testDefaultLogo)
+
+	class := payload.LoyaltyClasses[0]
+	assert.Equal(t, "Coop", class.IssuerName)
+	assert.Equal(t, "Supercard", class.ProgramName)
+	assert.Equal(t, "#e2001a", class.HexBackgroundColor)
+	assert.Equal(t, "https://coop.example.com/logo.png", class.ProgramLogo.SourceURI.URI)
+
+	object := payload.LoyaltyObjects[0]
+	assert.Equal(t, "INACTIVE", object.State)
+	assert.Equal(t, "123456", object.AccountID)
+	assert.Equal(t, "QR_CODE", object.Barcode.Type)
+	assert.Empty(t, payload.OfferObjects)
+	assert.Empty(t, payload.GiftCardObjects)
+}
+
+func TestGoogle_VoucherPayload(t *testing.T) {
+	google, _ := newTestGoogle(t)
+	voucher := &models.Voucher{
+		ID:           uuid.New(),
+		MerchantName: "Manor",
+		Code:         "SAVE10",
+		Type:         "fixed_amount",
+		Value:        1000,
+		Description:  "Summer sale",
+		ValidFrom:    time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		ValidUntil:   time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC),
+		BarcodeType:  "CODE93",
+	}
+
+	payload := google.VoucherPayload(context.Background(), voucher, testDefaultLogo)
+
+	class := payload.OfferClasses[0]
+	assert.Equal(t, "10.00 CHF · Summer sale", class.Title)
+	assert.Equal(t, "Manor", class.Provider)
+	assert.Equal(t, testDefaultLogo, class.TitleImage.SourceURI.URI)
+
+	object := payload.OfferObjects[0]
+	assert.Equal(t, "EXPIRED", object.State)
+	assert.Equal(t, "CODE_128", object.Barcode.Type)
+	assert.Equal(t, "2026-01-01T00:00:00Z", object.ValidTimeInterval.Start.Date)
+	assert.Equal(t, "2026-02-01T00:00:00Z", object.ValidTimeInterval.End.Date)
+}
+
+func TestGoogle_GiftCardPayload(t *testing.T) {
+	google, _ := newTestGoogle(t)
+	expiresAt := time.Now().AddDate(1, 0, 0)
+	giftCard := &models.GiftCard{
+		ID:             uuid.New(),
+		MerchantName:   "Globus",
+		CardNumber:     "6001234",
+		CurrentBalance: money.New(2530, "CHF"),
+		Currency:       "CHF",
+		ExpiresAt:      &expiresAt,
+		BarcodeType:    "PDF417",
+	}
+
+	payload := google.GiftCardPayload(context.Background(), giftCard, testDefaultLogo)
+
+	assert.Equal(t, "Globus", payload.GiftCardClasses[0].MerchantName)
+	object := payload.GiftCardObjects[0]
+	assert.Equal(t, "ACTIVE", object.State)
+	assert.Equal(t, "6001234", object.CardNumber)
+	assert.Equal(t, int64(25_300_000), object.Balance.Micros)
+	assert.Equal(t, "CHF", object.Balance.CurrencyCode)
+	assert.Equal(t, "PDF_417", object.Barcode.Type)
+	require.NotNil(t, object.ValidTimeInterval)
+	assert.Nil(t, object.ValidTimeInterval.Start)
+
+	giftCard.CurrentBalance = money.New(0, "CHF")
+	assert.Equal(t, "COMPLETED", google.GiftCardPayload(context.Background(), giftCard, testDefaultLogo).GiftCardObjects[0].State)
+}
+
+func TestGoogleBarcode(t *testing.T) {
+	tests := map[string]string{
+		"CODE128":    "CODE_128",
+		"CODE39":     "CODE_39",
+		"CODE93":     "CODE_128",
+		"EAN8":       "EAN_8",
+		"UPCA":       "UPC_A",
+		"UPCE":       "CODE_128",
+		"ITF14":      "ITF_14",
+		"DATAMATRIX": "DATA_MATRIX",
+		"MAXICODE":   "CODE_128",
+		"":           "CODE_128",
+	}
+	for barcodeType, want := range tests {
+		assert.Equal(t, want, googleBarcode(barcodeType, "123").Type, barcodeType)
+	}
+	assert.Nil(t, googleBarcode("QR", ""))
+}
@@ -0,0 +1,113 @@
+// Package wallet generates digital wallet passes for cards, vouchers and gift cards.
+package wallet
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"  // Register GIF decoder for merchant logos
+	_ "image/jpeg" // Register JPEG decoder for merchant logos
+	"image/png"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"savvy/internal/models"
+)
+
+// ErrNotConfigured is returned when a wallet provider has no signing credentials
+var ErrNotConfigured = errors.New("wallet provider is not configured")
+
+// Pass kinds, used in serial numbers and object IDs
+const (
+	KindCard     = "card"
+	KindVoucher  = "voucher"
+	KindGiftCard = "gift_card"
+)
+
+const (
+	logoFetchTimeout = 5 * time.Second
+	maxLogoSize      = 2 << 20 // 2 MiB
+)
+
+// rgb is a parsed merchant color
+type rgb struct {
+	r, g, b uint8
+}
+
+// parseHexColor parses "#RRGGBB" or "#RGB" colors as stored on merchants
+func parseHexColor(s string) (rgb, bool) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "#")
+	if len(s) == 3 {
+		s = string([]byte{s[0], s[0], s[1], s[1], s[2], s[2]})
+	}
+	if len(s) != 6 {
+		return rgb{}, false
+	}
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return rgb{}, false
+	}
+	return rgb{uint8(v >> 16), uint8(v >> 8), uint8(v)}, true
+}
+
+// isDark reports whether white text is more readable than black text on c
+func (c rgb) isDark() bool {
+	// Perceived brightness (ITU-R BT.601)
+	return int(c.r)*299+int(c.g)*587+int(c.b)*114 < 128*1000
+}
+
+// hex formats the color as "#rrggbb"
+func (c rgb) hex() string {
+	return fmt.Sprintf("#%02x%02x%02x", c.r, c.g, c.b)
+}
+
+// merchantName returns the linked merchant's name or the free-text fallback
+func merchantName(merchant *models.Merchant, fallback string) string {
+	if merchant != nil && merchant.Name != "" {
+		return merchant.Name
+	}
+	return fallback
+}
+
+// fetchLogo downloads a merchant logo and re-encodes it as PNG, the only format wallets accept.
+// Merchants are maintained by admins, so the URL is trusted but must be absolute http(s).
+func fetchLogo(ctx context.Context, client *http.Client, logoURL string) ([]byte, error) {
+	u, err := url.Parse(logoURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, fmt.Errorf("unsupported logo URL %q", logoURL)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, logoFetchTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("logo request failed: %s", resp.Status)
+	}
+
+	img, _, err := image.Decode(io.LimitReader(resp.Body, maxLogoSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode logo: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}